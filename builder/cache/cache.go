@@ -217,6 +217,7 @@ type EncodedPost struct {
 	Tags       []string
 	Templates  []string
 	Includes   []string
+	Files      []string
 }
 
 // batchOp represents a single key-value operation for bucket writes
@@ -234,6 +235,7 @@ type bucketOps struct {
 	tags      []batchOp
 	templates []batchOp
 	includes  []batchOp
+	files     []batchOp
 }
 
 // writeOps performs sequential writes to a bucket
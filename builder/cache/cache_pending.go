@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"errors"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// MarkPending records content paths whose posts are committed to the cache
+// before their pages reach the output directory. If the build is interrupted
+// or crashes before the sync, the next build re-renders them from the cache
+// instead of trusting whatever older page is on disk.
+func (m *Manager) MarkPending(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketPending))
+		for _, p := range paths {
+			if err := bucket.Put([]byte(utils.NormalizePath(p)), []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PendingPaths returns the content paths marked by MarkPending since the last
+// ClearPending
+func (m *Manager) PendingPaths() (map[string]bool, error) {
+	paths := make(map[string]bool)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(BucketPending)).ForEach(func(k, _ []byte) error {
+			paths[string(k)] = true
+			return nil
+		})
+	})
+	return paths, err
+}
+
+// ClearPending forgets every pending path, once the output has been synced
+func (m *Manager) ClearPending() error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(BucketPending)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte(BucketPending))
+		return err
+	})
+}
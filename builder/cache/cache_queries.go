@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 
 	bolt "go.etcd.io/bbolt"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
 // ListAllPosts returns all PostIDs
@@ -132,3 +134,158 @@ func (m *Manager) SetWasmHash(hash string) error {
 		return meta.Put([]byte(KeyWasmHash), []byte(hash))
 	})
 }
+
+// GetPluginsHash retrieves the fingerprint of the plugins the cached pages
+// were parsed with
+func (m *Manager) GetPluginsHash() (string, error) {
+	var hash string
+	err := m.db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket([]byte(BucketMeta))
+		data := meta.Get([]byte(KeyPluginsHash))
+		if data != nil {
+			hash = string(data)
+		}
+		return nil
+	})
+	return hash, err
+}
+
+// SetPluginsHash stores the plugins fingerprint
+func (m *Manager) SetPluginsHash(hash string) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket([]byte(BucketMeta))
+		return meta.Put([]byte(KeyPluginsHash), []byte(hash))
+	})
+}
+
+// GetTemplateMetas returns the template files recorded by the last build
+func (m *Manager) GetTemplateMetas() (map[string]*TemplateMeta, error) {
+	metas := make(map[string]*TemplateMeta)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketTemplates))
+		return bucket.ForEach(func(k, v []byte) error {
+			var meta TemplateMeta
+			if err := Decode(v, &meta); err != nil {
+				return err
+			}
+			metas[string(k)] = &meta
+			return nil
+		})
+	})
+	return metas, err
+}
+
+// SetTemplateMetas replaces the recorded template files with metas
+func (m *Manager) SetTemplateMetas(metas map[string]*TemplateMeta) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketTemplates))
+		var stale [][]byte
+		_ = bucket.ForEach(func(k, _ []byte) error {
+			if _, ok := metas[string(k)]; !ok {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		for path, meta := range metas {
+			data, err := Encode(meta)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetStaticFiles returns the static files recorded by the last build
+func (m *Manager) GetStaticFiles() (map[string]utils.StaticFile, error) {
+	files := make(map[string]utils.StaticFile)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketStatic))
+		return bucket.ForEach(func(k, v []byte) error {
+			var file utils.StaticFile
+			if err := Decode(v, &file); err != nil {
+				return err
+			}
+			files[string(k)] = file
+			return nil
+		})
+	})
+	return files, err
+}
+
+// SetStaticFiles replaces the recorded static files with files
+func (m *Manager) SetStaticFiles(files map[string]utils.StaticFile) error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketStatic))
+		var stale [][]byte
+		_ = bucket.ForEach(func(k, _ []byte) error {
+			if _, ok := files[string(k)]; !ok {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		for path, file := range files {
+			data, err := Encode(file)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetOutputFiles returns the output files recorded by SyncVFS, keyed by path
+// relative to the output directory
+func (m *Manager) GetOutputFiles() (map[string]utils.OutputFile, error) {
+	files := make(map[string]utils.OutputFile)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketOutput))
+		return bucket.ForEach(func(k, v []byte) error {
+			var file utils.OutputFile
+			if err := Decode(v, &file); err != nil {
+				return err
+			}
+			files[string(k)] = file
+			return nil
+		})
+	})
+	return files, err
+}
+
+// UpdateOutputFiles records the state of the output files a sync wrote.
+// Files it didn't touch keep their records: readers check size and mtime
+// before trusting one.
+func (m *Manager) UpdateOutputFiles(files map[string]utils.OutputFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	return m.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(BucketOutput))
+		for path, file := range files {
+			data, err := Encode(file)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(path), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
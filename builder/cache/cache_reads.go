@@ -178,6 +178,38 @@ func (m *Manager) GetPostsByTemplate(templatePath string) ([]string, error) {
 	return ids, err
 }
 
+// GetPostsByInclude retrieves all PostIDs whose pages include a fragment
+// (its name in the includes directory)
+func (m *Manager) GetPostsByInclude(name string) ([]string, error) {
+	var ids []string
+	prefix := []byte(name + "/")
+
+	err := m.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(BucketDepsIncludes)).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			ids = append(ids, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// GetPostsByFile retrieves all PostIDs of pages built from a data file (its
+// path relative to the site root, with forward slashes)
+func (m *Manager) GetPostsByFile(path string) ([]string, error) {
+	var ids []string
+	prefix := []byte(path + "/")
+
+	err := m.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(BucketDepsFiles)).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			ids = append(ids, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return ids, err
+}
+
 // GetSearchRecords retrieves multiple search records by PostIDs
 func (m *Manager) GetSearchRecords(postIDs []string) (map[string]*SearchRecord, error) {
 	result := make(map[string]*SearchRecord, len(postIDs))
@@ -271,11 +303,13 @@ func (m *Manager) GetPostsMetadataByVersion(version string) ([]PostListMeta, err
 			}
 			if meta.Version == version {
 				result = append(result, PostListMeta{
-					Title:   meta.Title,
-					Link:    meta.Link,
-					Weight:  meta.Weight,
-					Version: meta.Version,
-					Date:    meta.Date,
+					Title:       meta.Title,
+					Link:        meta.Link,
+					Weight:      meta.Weight,
+					Version:     meta.Version,
+					Date:        meta.Date,
+					PublishDate: meta.PublishDate,
+					ExpiryDate:  meta.ExpiryDate,
 				})
 			}
 		}
@@ -287,9 +321,11 @@ func (m *Manager) GetPostsMetadataByVersion(version string) ([]PostListMeta, err
 
 // PostListMeta contains minimal metadata needed for navigation/sorting
 type PostListMeta struct {
-	Title   string
-	Link    string
-	Weight  int
-	Version string
-	Date    time.Time
+	Title       string
+	Link        string
+	Weight      int
+	Version     string
+	Date        time.Time
+	PublishDate time.Time
+	ExpiryDate  time.Time
 }
@@ -384,6 +384,72 @@ func TestGetPostsByTemplate(t *testing.T) {
 	}
 }
 
+func TestGetPostsByInclude(t *testing.T) {
+	m, cleanup := createTestCache(t)
+	defer cleanup()
+
+	post1 := createSamplePostMeta()
+	post1.PostID = "post-1"
+
+	post2 := createSamplePostMeta()
+	post2.PostID = "post-2"
+
+	depsMap := map[string]*Dependencies{
+		"post-1": {Includes: []string{"snippets/warning.md", "snippets/install.md"}},
+		"post-2": {Includes: []string{"snippets/install.md"}},
+	}
+
+	if err := m.BatchCommit([]*PostMeta{post1, post2}, nil, depsMap); err != nil {
+		t.Fatalf("BatchCommit failed: %v", err)
+	}
+
+	posts, err := m.GetPostsByInclude("snippets/install.md")
+	if err != nil {
+		t.Fatalf("GetPostsByInclude failed: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Errorf("Expected 2 posts, got %d", len(posts))
+	}
+
+	posts, err = m.GetPostsByInclude("snippets/warning.md")
+	if err != nil {
+		t.Fatalf("GetPostsByInclude failed: %v", err)
+	}
+	if len(posts) != 1 || posts[0] != "post-1" {
+		t.Errorf("Expected [post-1], got %v", posts)
+	}
+}
+
+func TestGetPostsByFile(t *testing.T) {
+	m, cleanup := createTestCache(t)
+	defer cleanup()
+
+	post := createSamplePostMeta()
+	post.PostID = "api"
+
+	depsMap := map[string]*Dependencies{"api": {Files: []string{"content/api/petstore.yaml"}}}
+	if err := m.BatchCommit([]*PostMeta{post}, nil, depsMap); err != nil {
+		t.Fatalf("BatchCommit failed: %v", err)
+	}
+
+	posts, err := m.GetPostsByFile("content/api/petstore.yaml")
+	if err != nil {
+		t.Fatalf("GetPostsByFile failed: %v", err)
+	}
+	if len(posts) != 1 || posts[0] != "api" {
+		t.Errorf("Expected [api], got %v", posts)
+	}
+
+	// A file whose name extends another's isn't mistaken for it
+	posts, err = m.GetPostsByFile("content/api/pet")
+	if err != nil {
+		t.Fatalf("GetPostsByFile failed: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("Expected no posts, got %v", posts)
+	}
+}
+
 func TestGetCachedItem_Generic(t *testing.T) {
 	m, cleanup := createTestCache(t)
 	defer cleanup()
@@ -436,3 +436,40 @@ func TestManager_ListAllPosts(t *testing.T) {
 		t.Errorf("Expected 2 posts, got %d", len(posts))
 	}
 }
+
+func TestManager_TemplateMetas(t *testing.T) {
+	m, cleanup := createTestCache(t)
+	defer cleanup()
+
+	first := map[string]*TemplateMeta{
+		"layout.html":          {Hash: "a", Uses: []string{"partials/nav.html"}},
+		"partials/nav.html":    {Hash: "b"},
+		"partials/footer.html": {Hash: "c", Defines: []string{"footer"}},
+	}
+	if err := m.SetTemplateMetas(first); err != nil {
+		t.Fatalf("SetTemplateMetas failed: %v", err)
+	}
+
+	// Replacing drops templates that no longer exist
+	second := map[string]*TemplateMeta{
+		"layout.html":       {Hash: "a2", Uses: []string{"partials/nav.html"}},
+		"partials/nav.html": {Hash: "b"},
+	}
+	if err := m.SetTemplateMetas(second); err != nil {
+		t.Fatalf("SetTemplateMetas failed: %v", err)
+	}
+
+	got, err := m.GetTemplateMetas()
+	if err != nil {
+		t.Fatalf("GetTemplateMetas failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetTemplateMetas returned %d templates, want 2", len(got))
+	}
+	if got["layout.html"].Hash != "a2" || got["layout.html"].Uses[0] != "partials/nav.html" {
+		t.Errorf("layout.html = %+v", got["layout.html"])
+	}
+	if _, ok := got["partials/footer.html"]; ok {
+		t.Error("stale template was not removed")
+	}
+}
@@ -69,6 +69,7 @@ func (m *Manager) BatchCommit(posts []*PostMeta, searchRecords map[string]*Searc
 				ep.Tags = d.Tags
 				ep.Templates = d.Templates
 				ep.Includes = d.Includes
+				ep.Files = d.Files
 			}
 
 			encoded[idx] = ep
@@ -84,10 +85,12 @@ func (m *Manager) BatchCommit(posts []*PostMeta, searchRecords map[string]*Searc
 	totalTags := 0
 	totalTemplates := 0
 	totalIncludes := 0
+	totalFiles := 0
 	for _, ep := range encoded {
 		totalTags += len(ep.Tags)
 		totalTemplates += len(ep.Templates)
 		totalIncludes += len(ep.Includes)
+		totalFiles += len(ep.Files)
 	}
 
 	ops.posts = make([]batchOp, 0, len(encoded))
@@ -97,6 +100,7 @@ func (m *Manager) BatchCommit(posts []*PostMeta, searchRecords map[string]*Searc
 	ops.tags = make([]batchOp, 0, totalTags)
 	ops.templates = make([]batchOp, 0, totalTemplates)
 	ops.includes = make([]batchOp, 0, totalIncludes)
+	ops.files = make([]batchOp, 0, totalFiles)
 
 	for _, ep := range encoded {
 		ops.posts = append(ops.posts, batchOp{key: ep.PostID, value: ep.Data})
@@ -123,6 +127,11 @@ func (m *Manager) BatchCommit(posts []*PostMeta, searchRecords map[string]*Searc
 				incKey := []byte(inc + "/" + string(ep.PostID))
 				ops.includes = append(ops.includes, batchOp{key: incKey, value: nil})
 			}
+
+			for _, file := range ep.Files {
+				fileKey := []byte(file + "/" + string(ep.PostID))
+				ops.files = append(ops.files, batchOp{key: fileKey, value: nil})
+			}
 		}
 	}
 
@@ -148,6 +157,9 @@ func (m *Manager) BatchCommit(posts []*PostMeta, searchRecords map[string]*Searc
 		if err := writeOps(tx.Bucket([]byte(BucketDepsIncludes)), ops.includes); err != nil {
 			return err
 		}
+		if err := writeOps(tx.Bucket([]byte(BucketDepsFiles)), ops.files); err != nil {
+			return err
+		}
 
 		stats := tx.Bucket([]byte(BucketStats))
 		buildCount := uint32(1)
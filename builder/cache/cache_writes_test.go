@@ -485,3 +485,26 @@ func TestDeletePost_WithSearchRecord(t *testing.T) {
 		t.Error("Search record should be deleted")
 	}
 }
+
+func TestPendingPaths(t *testing.T) {
+	m, cleanup := createTestCache(t)
+	defer cleanup()
+
+	if err := m.MarkPending([]string{"posts/a.md", "posts\\b.md"}); err != nil {
+		t.Fatalf("MarkPending failed: %v", err)
+	}
+	pending, err := m.PendingPaths()
+	if err != nil {
+		t.Fatalf("PendingPaths failed: %v", err)
+	}
+	if len(pending) != 2 || !pending["posts/a.md"] || !pending["posts/b.md"] {
+		t.Errorf("PendingPaths() = %v, want posts/a.md and posts/b.md", pending)
+	}
+
+	if err := m.ClearPending(); err != nil {
+		t.Fatalf("ClearPending failed: %v", err)
+	}
+	if pending, _ := m.PendingPaths(); len(pending) != 0 {
+		t.Errorf("PendingPaths() after ClearPending = %v, want none", pending)
+	}
+}
@@ -9,11 +9,16 @@ const (
 	BucketPostDeps   = "post_deps"   // {PostID} -> Dependencies
 	BucketSSR        = "ssr"         // {type}:{inputHash} -> SSRArtifact
 	BucketSocialCard = "social_card" // {path} -> hash
+	BucketTemplates  = "templates"   // {template path} -> TemplateMeta
+	BucketStatic     = "static"      // {output path} -> utils.StaticFile
+	BucketOutput     = "output"      // {output-relative path} -> utils.OutputFile
+	BucketPending    = "pending"     // {filepath} -> empty, committed but not yet written to disk
 
 	// Index buckets (set-based, value is empty)
 	BucketTags          = "tags"           // {tag}/{PostID} -> empty
 	BucketDepsTemplates = "deps_templates" // {template}/{PostID} -> empty
 	BucketDepsIncludes  = "deps_includes"  // {include}/{PostID} -> empty
+	BucketDepsFiles     = "deps_files"     // {site-relative path}/{PostID} -> empty
 
 	// Global metadata
 	BucketMeta  = "meta"  // schema_version, cache_id
@@ -26,6 +31,7 @@ const (
 	KeyBuildCount    = "build_count"
 	KeyGraphHash     = "graph_hash"
 	KeyWasmHash      = "wasm_hash"
+	KeyPluginsHash   = "plugins_hash"
 )
 
 // AllBuckets returns all bucket names for initialization
@@ -37,9 +43,14 @@ func AllBuckets() []string {
 		BucketPostDeps,
 		BucketSSR,
 		BucketSocialCard,
+		BucketTemplates,
+		BucketStatic,
+		BucketOutput,
+		BucketPending,
 		BucketTags,
 		BucketDepsTemplates,
 		BucketDepsIncludes,
+		BucketDepsFiles,
 		BucketMeta,
 		BucketStats,
 	}
@@ -17,14 +17,18 @@ type PostMeta struct {
 	PostID         string                 `msgpack:"post_id"`
 	Path           string                 `msgpack:"path"`
 	ModTime        int64                  `msgpack:"mod_time"`
-	ContentHash    string                 `msgpack:"content_hash"`          // Frontmatter hash
-	BodyHash       string                 `msgpack:"body_hash"`             // Body content hash (CRITICAL for cache validity)
-	HTMLHash       string                 `msgpack:"html_hash,omitempty"`   // Only for large posts
-	InlineHTML     []byte                 `msgpack:"inline_html,omitempty"` // < 32KB posts stored inline
+	ContentHash    string                 `msgpack:"content_hash"`           // Frontmatter hash
+	BodyHash       string                 `msgpack:"body_hash"`              // Body content hash (CRITICAL for cache validity)
+	IncludeHash    string                 `msgpack:"include_hash,omitempty"` // Body hash with {{< include >}} fragments expanded
+	FilesHash      string                 `msgpack:"files_hash,omitempty"`   // Hash of the data files the page is built from (Dependencies.Files)
+	HTMLHash       string                 `msgpack:"html_hash,omitempty"`    // Only for large posts
+	InlineHTML     []byte                 `msgpack:"inline_html,omitempty"`  // < 32KB posts stored inline
 	TemplateHash   string                 `msgpack:"template_hash"`
 	SSRInputHashes []string               `msgpack:"ssr_input_hashes"`
 	Title          string                 `msgpack:"title"`
 	Date           time.Time              `msgpack:"date"`
+	PublishDate    time.Time              `msgpack:"publish_date,omitempty"` // publishDate frontmatter, zero when unset
+	ExpiryDate     time.Time              `msgpack:"expiry_date,omitempty"`  // expiryDate frontmatter, zero when unset
 	Tags           []string               `msgpack:"tags"`
 	WordCount      int                    `msgpack:"word_count"`
 	ReadingTime    int                    `msgpack:"reading_time"`
@@ -69,9 +73,17 @@ type SearchRecord struct {
 type Dependencies struct {
 	Templates []string `msgpack:"templates"`
 	Includes  []string `msgpack:"includes"`
+	Files     []string `msgpack:"files,omitempty"` // Data files read into the page, e.g. an OpenAPI spec
 	Tags      []string `msgpack:"tags"`
 }
 
+// TemplateMeta describes one theme template file as of the last compile
+type TemplateMeta struct {
+	Hash    string   `msgpack:"hash"`    // BLAKE3 of the file
+	Defines []string `msgpack:"defines"` // {{ define }} / {{ block }} names in the file
+	Uses    []string `msgpack:"uses"`    // Templates invoked with {{ template }}
+}
+
 // CacheStats holds runtime statistics
 type CacheStats struct {
 	TotalPosts    int   `msgpack:"total_posts"`
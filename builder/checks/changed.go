@@ -0,0 +1,368 @@
+package checks
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// proseRule is a compiled strict.prose rule
+type proseRule struct {
+	pattern *regexp.Regexp
+	message string
+}
+
+func compileProse(rules []config.ProseRule) ([]proseRule, error) {
+	compiled := make([]proseRule, 0, len(rules))
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("prose rule %q: %w", r.Pattern, err)
+		}
+		compiled = append(compiled, proseRule{pattern: re, message: r.Message})
+	}
+	return compiled, nil
+}
+
+// fenceLine opens or closes a fenced code block
+var fenceLine = regexp.MustCompile("^\\s*(```|~~~)")
+
+// maskCode blanks out the frontmatter, fenced code blocks and code spans of a
+// page, keeping line breaks so offsets still give the line
+func maskCode(source []byte) []byte {
+	masked := bytes.Clone(source)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+	if _, ok := frontmatter(source); ok {
+		// Up to the end of the closing --- line
+		end := len(source)
+		if first := bytes.IndexByte(source, '\n'); first >= 0 {
+			if closing := bytes.Index(source[first:], []byte("\n---")); closing >= 0 {
+				end = first + closing + 4
+				if eol := bytes.IndexByte(source[end:], '\n'); eol >= 0 {
+					end += eol
+				} else {
+					end = len(source)
+				}
+			}
+		}
+		blank(0, end)
+	}
+
+	inFence, start := false, 0
+	for offset := 0; offset < len(masked); {
+		end := bytes.IndexByte(masked[offset:], '\n')
+		if end < 0 {
+			end = len(masked)
+		} else {
+			end += offset + 1
+		}
+		line := masked[offset:end]
+		if fenceLine.Match(line) {
+			if inFence {
+				blank(start, end)
+			} else {
+				start = offset
+			}
+			inFence = !inFence
+		} else if !inFence {
+			for {
+				open := bytes.IndexByte(line, '`')
+				if open < 0 {
+					break
+				}
+				length := bytes.IndexByte(line[open+1:], '`')
+				if length < 0 {
+					break
+				}
+				blank(offset+open, offset+open+length+2)
+			}
+		}
+		offset = end
+	}
+	if inFence {
+		blank(start, len(masked))
+	}
+	return masked
+}
+
+// lineAt is the 1-based line of an offset in source
+func lineAt(source []byte, offset int) int {
+	return bytes.Count(source[:offset], []byte("\n")) + 1
+}
+
+// checkProse reports text matching a prose rule
+func checkProse(rules []proseRule, page string, source []byte) []Finding {
+	if len(rules) == 0 {
+		return nil
+	}
+	text := maskCode(source)
+	var findings []Finding
+	for _, r := range rules {
+		for _, m := range r.pattern.FindAllIndex(text, -1) {
+			findings = append(findings, Finding{
+				Class:   Prose,
+				Page:    page,
+				Message: fmt.Sprintf("line %d: %q: %s", lineAt(text, m[0]), text[m[0]:m[1]], r.message),
+			})
+		}
+	}
+	return findings
+}
+
+// markdownLink matches the target of an inline link or image
+var markdownLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+["'][^)]*["'])?\s*\)`)
+
+// checkSourceLinks reports links in a page's markdown to content files or
+// pages that don't exist, and to headings missing on a known page. Site
+// links are only resolved when the page is known or the site was built.
+func checkSourceLinks(opts Options, links *resolver, outputBuilt bool, page string, source []byte) []Finding {
+	text := maskCode(source)
+	htmlPage := page
+	if opts.HTMLPath != nil {
+		htmlPage = opts.HTMLPath(page)
+	}
+
+	var findings []Finding
+	broken := func(offset int, format string, args ...interface{}) {
+		findings = append(findings, Finding{Class: BrokenLink, Page: page, Message: fmt.Sprintf("line %d: ", lineAt(text, offset)) + fmt.Sprintf(format, args...)})
+	}
+	for _, m := range markdownLink.FindAllSubmatchIndex(text, -1) {
+		link := string(text[m[2]:m[3]])
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+
+		if u.Scheme == "" && u.Host == "" && strings.HasSuffix(u.Path, ".md") {
+			target := strings.TrimPrefix(u.Path, "/")
+			if !strings.HasPrefix(u.Path, "/") {
+				target = path.Join(path.Dir(page), u.Path)
+			}
+			if ok, _ := afero.Exists(opts.ContentFs, filepath.Join(opts.ContentDir, filepath.FromSlash(target))); !ok {
+				broken(m[2], "%s does not exist", link)
+			}
+			continue
+		}
+
+		target, internal := links.target(htmlPage, link)
+		if !internal {
+			if fragment, ok := fragmentOnly(link); ok {
+				if headings := opts.Pages["/"+htmlPage]; headings != nil && !slices.Contains(headings, fragment) {
+					broken(m[2], "%s: no heading #%s", link, fragment)
+				}
+			}
+			continue
+		}
+		headings, known := knownPage(opts.Pages, target)
+		if !known {
+			if !outputBuilt {
+				continue // Might be a tag, section or static file
+			}
+			if _, found := links.stat(target); !found {
+				broken(m[2], "%s does not exist", link)
+			}
+			continue
+		}
+		if u.Fragment != "" && headings != nil && !slices.Contains(headings, u.Fragment) {
+			broken(m[2], "%s: no heading #%s", link, u.Fragment)
+		}
+	}
+	return findings
+}
+
+// knownPage looks a site path up in pages the way the server resolves it
+func knownPage(pages map[string][]string, sitePath string) ([]string, bool) {
+	candidates := []string{sitePath + "index.html"}
+	if !strings.HasSuffix(sitePath, "/") {
+		candidates = []string{sitePath, sitePath + ".html", sitePath + "/index.html"}
+	}
+	for _, c := range candidates {
+		if headings, ok := pages[c]; ok {
+			return headings, true
+		}
+	}
+	return nil, false
+}
+
+// CheckFiles runs the checks that need no build on the given content files
+// (relative to the content directory): frontmatter, refs, links in the
+// markdown and prose rules. Files that no longer exist are skipped.
+func CheckFiles(opts Options, files []string) ([]Finding, error) {
+	prose, err := compileProse(opts.Prose)
+	if err != nil {
+		return nil, err
+	}
+	links := newResolver(opts)
+	outputBuilt := false
+	if opts.OutputFs != nil {
+		outputBuilt, _ = afero.DirExists(opts.OutputFs, opts.OutputDir)
+	}
+
+	sources := make(map[string][]byte)
+	for _, rel := range files {
+		rel = filepath.ToSlash(rel)
+		if !strings.HasSuffix(rel, ".md") {
+			continue
+		}
+		if source, err := afero.ReadFile(opts.ContentFs, filepath.Join(opts.ContentDir, filepath.FromSlash(rel))); err == nil {
+			sources[rel] = source
+		}
+	}
+
+	// Links are checked against the headings the files have now, and
+	// headings they lost are looked up in the rest of the content
+	removed := make(map[string][]string)
+	if opts.HeadingIDs != nil {
+		opts.Pages = maps.Clone(opts.Pages)
+		if opts.Pages == nil {
+			opts.Pages = make(map[string][]string)
+		}
+		for rel, source := range sources {
+			ids, ok := opts.HeadingIDs(rel, source)
+			if !ok {
+				continue
+			}
+			sitePath := "/" + rel
+			if opts.HTMLPath != nil {
+				sitePath = "/" + opts.HTMLPath(rel)
+			}
+			for _, id := range opts.Pages[sitePath] {
+				if !slices.Contains(ids, id) {
+					removed[sitePath] = append(removed[sitePath], id)
+				}
+			}
+			opts.Pages[sitePath] = ids
+		}
+	}
+
+	var findings []Finding
+	for rel, source := range sources {
+		if !strings.HasSuffix(rel, "_index.md") && !strings.HasSuffix(rel, "404.md") {
+			findings = append(findings, checkFrontmatter(rel, source, opts.IncludeDrafts)...)
+		}
+		findings = append(findings, checkRefs(opts, rel, source)...)
+		findings = append(findings, checkSourceLinks(opts, links, outputBuilt, rel, source)...)
+		findings = append(findings, checkProse(prose, rel, source)...)
+	}
+	if len(removed) > 0 {
+		findings = append(findings, removedHeadingLinks(opts, links, sources, removed)...)
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+// removedHeadingLinks reports links in the rest of the content to headings
+// that the pages being checked no longer have. The finding is on the page
+// that lost the heading, since that is the edit to look at.
+func removedHeadingLinks(opts Options, links *resolver, checked map[string][]byte, removed map[string][]string) []Finding {
+	pageOf := make(map[string]string, len(removed)) // Site path to the content file
+	for rel := range checked {
+		sitePath := "/" + rel
+		if opts.HTMLPath != nil {
+			sitePath = "/" + opts.HTMLPath(rel)
+		}
+		pageOf[sitePath] = rel
+	}
+
+	var findings []Finding
+	_ = afero.Walk(opts.ContentFs, opts.ContentDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(p, ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(opts.ContentDir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := checked[rel]; ok {
+			return nil // Its links were checked against the new headings
+		}
+		source, err := afero.ReadFile(opts.ContentFs, p)
+		if err != nil {
+			return nil
+		}
+		htmlPage := rel
+		if opts.HTMLPath != nil {
+			htmlPage = opts.HTMLPath(rel)
+		}
+		text := maskCode(source)
+		for _, m := range markdownLink.FindAllSubmatchIndex(text, -1) {
+			link := string(text[m[2]:m[3]])
+			u, err := url.Parse(link)
+			if err != nil || u.Fragment == "" {
+				continue
+			}
+			target, internal := links.target(htmlPage, link)
+			if !internal {
+				continue
+			}
+			for sitePath, ids := range removed {
+				if _, ok := knownPage(map[string][]string{sitePath: nil}, target); ok && slices.Contains(ids, u.Fragment) {
+					findings = append(findings, Finding{
+						Class:   BrokenLink,
+						Page:    pageOf[sitePath],
+						Message: fmt.Sprintf("heading #%s is gone, but %s links to it on line %d", u.Fragment, rel, lineAt(text, m[2])),
+					})
+				}
+			}
+		}
+		return nil
+	})
+	return findings
+}
+
+// ChangedFiles lists the files added or modified in the git work tree of the
+// current directory, relative to it: staged, unstaged and untracked, or only
+// the staged ones
+func ChangedFiles(staged bool) ([]string, error) {
+	git := func(args ...string) ([]string, error) {
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+				return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+			}
+			return nil, fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return strings.FieldsFunc(string(out), func(r rune) bool { return r == '\n' || r == '\r' }), nil
+	}
+
+	diff := []string{"diff", "--name-only", "--relative", "--diff-filter=ACMR"}
+	if staged {
+		return git(append(diff, "--cached")...)
+	}
+	files, err := git(append(diff, "HEAD")...)
+	if err != nil {
+		// No commit yet: everything staged is new
+		if files, err = git(append(diff, "--cached")...); err != nil {
+			return nil, err
+		}
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range untracked {
+		if !slices.Contains(files, f) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,137 @@
+package checks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestMaskCode(t *testing.T) {
+	source := "---\ntitle: Click here\n---\nSay `click here` and\n```\nclick here\n```\nclick here\n"
+	masked := string(maskCode([]byte(source)))
+	if len(masked) != len(source) || strings.Count(masked, "\n") != strings.Count(source, "\n") {
+		t.Fatalf("maskCode changed the layout:\n%q", masked)
+	}
+	if strings.Count(masked, "click here") != 1 || !strings.HasSuffix(masked, "click here\n") {
+		t.Errorf("maskCode left code or frontmatter:\n%q", masked)
+	}
+}
+
+func TestCheckFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/site/content/guides/setup.md": "---\ntitle: Setup\ndescription: Install\n---\n" +
+			"See [intro](../intro.md), [gone](./gone.md) and [install](/guides/install.html#linux).\n" +
+			"Just [click here](/guides/install.html#windows) or [tags](/tags/go.html).\n" +
+			"```\n[not a link](missing.md) click here\n```\n",
+		"/site/content/intro.md":           "---\ntitle: Intro\n---\n",
+		"/site/content/guides/install.md":  "---\ntitle: Install\ndescription: x\n---\n",
+		"/site/public/guides/install.html": "<article></article>",
+	}
+	for path, content := range files {
+		_ = afero.WriteFile(fs, path, []byte(content), 0644)
+	}
+	opts := Options{
+		ContentFs: fs, ContentDir: "/site/content",
+		OutputFs: fs, OutputDir: "/site/public",
+		BaseURL:  "https://example.com",
+		Prose:    []config.ProseRule{{Pattern: `(?i)\bclick here\b`, Message: "use descriptive link text"}},
+		HTMLPath: func(rel string) string { return strings.TrimSuffix(rel, ".md") + ".html" },
+		Pages: map[string][]string{
+			"/guides/install.html": {"linux", "macos"},
+			"/intro.html":          nil,
+		},
+	}
+
+	findings, err := CheckFiles(opts, []string{"guides/setup.md", "deleted.md", "notes.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"broken-link guides/setup.md: line 5: ./gone.md does not exist",
+		"broken-link guides/setup.md: line 6: /guides/install.html#windows: no heading #windows",
+		"broken-link guides/setup.md: line 6: /tags/go.html does not exist",
+		`prose guides/setup.md: line 6: "click here": use descriptive link text`,
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("CheckFiles() = %+v, want %d findings", findings, len(want))
+	}
+	for i, f := range findings {
+		if got := string(f.Class) + " " + f.Page + ": " + f.Message; got != want[i] {
+			t.Errorf("finding %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	// Without a built site, unknown site links can't be told apart from
+	// generated pages
+	_ = fs.RemoveAll("/site/public")
+	findings, _ = CheckFiles(opts, []string{"guides/setup.md"})
+	for _, f := range findings {
+		if strings.Contains(f.Message, "/tags/go.html") {
+			t.Errorf("unbuilt site: %s", f.Message)
+		}
+	}
+
+	if _, err := CheckFiles(Options{ContentFs: fs, Prose: []config.ProseRule{{Pattern: "("}}}, nil); err == nil {
+		t.Error("CheckFiles accepted an invalid prose pattern")
+	}
+}
+
+func TestCheckFilesHeadingIDs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		// "## Setup" was reworded since the build
+		"/site/content/guide.md": "---\ntitle: Guide\ndescription: x\n---\n## Installation\n\nSee [below](#installation) and [above](#setup).\n",
+		"/site/content/faq.md":   "---\ntitle: FAQ\ndescription: x\n---\nRead [setup](guide.html#setup) or [usage](/guide.html#usage).\n",
+		"/site/content/api.md":   "---\ntitle: API\ndescription: x\n---\n",
+	}
+	for path, content := range files {
+		_ = afero.WriteFile(fs, path, []byte(content), 0644)
+	}
+	opts := Options{
+		ContentFs: fs, ContentDir: "/site/content",
+		BaseURL:  "https://example.com",
+		HTMLPath: func(rel string) string { return strings.TrimSuffix(rel, ".md") + ".html" },
+		Pages: map[string][]string{
+			"/guide.html": {"setup", "usage"},
+			"/faq.html":   nil,
+			"/api.html":   {"endpoints"},
+		},
+		HeadingIDs: func(rel string, source []byte) ([]string, bool) {
+			if rel == "api.md" {
+				return nil, false
+			}
+			var ids []string
+			for _, line := range strings.Split(string(source), "\n") {
+				if heading, ok := strings.CutPrefix(line, "## "); ok {
+					ids = append(ids, strings.ToLower(heading))
+				}
+			}
+			return ids, true
+		},
+	}
+
+	findings, err := CheckFiles(opts, []string{"guide.md", "api.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"broken-link guide.md: line 7: #setup: no heading #setup",
+		"broken-link guide.md: heading #setup is gone, but faq.md links to it on line 5",
+		"broken-link guide.md: heading #usage is gone, but faq.md links to it on line 5",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("CheckFiles() = %+v, want %d findings", findings, len(want))
+	}
+	for i, f := range findings {
+		if got := string(f.Class) + " " + f.Page + ": " + f.Message; got != want[i] {
+			t.Errorf("finding %d = %q, want %q", i, got, want[i])
+		}
+	}
+	if len(opts.Pages["/guide.html"]) != 2 {
+		t.Error("CheckFiles changed the caller's pages")
+	}
+}
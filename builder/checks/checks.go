@@ -0,0 +1,454 @@
+// Package checks finds content problems in a built site: posts without a
+// description, frontmatter fields Kosh can't use, ref shortcodes and internal
+// links to pages that don't exist, oversized images and wording flagged by
+// prose rules. `kosh build --strict` runs them and fails the build on the
+// classes configured under strict.checks; `kosh check --changed` runs the
+// source checks on the files changed in git.
+package checks
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// Class is a kind of problem, as named in strict.checks
+type Class string
+
+const (
+	MissingDescription Class = "missing-description"
+	InvalidFrontmatter Class = "invalid-frontmatter"
+	BrokenRef          Class = "broken-ref"
+	BrokenLink         Class = "broken-link"
+	OversizedImage     Class = "oversized-image"
+	Prose              Class = "prose"
+)
+
+// Classes lists every check in the order they are reported
+var Classes = []Class{MissingDescription, InvalidFrontmatter, BrokenRef, BrokenLink, OversizedImage, Prose}
+
+// DefaultMaxImageKB is the image size above which an image is oversized
+const DefaultMaxImageKB = 500
+
+// Finding is one problem on one page
+type Finding struct {
+	Class    Class    `json:"class"`
+	Severity Severity `json:"severity,omitempty"` // Set by the SEO audit
+	Page     string   `json:"page"`               // Content file for frontmatter checks, output file for link and image checks
+	Message  string   `json:"message"`
+}
+
+// Options says where the site's content and output are
+type Options struct {
+	ContentFs     afero.Fs
+	ContentDir    string
+	OutputFs      afero.Fs
+	OutputDir     string
+	BaseURL       string
+	MaxImageBytes int64 // 0 uses DefaultMaxImageKB
+	IncludeDrafts bool
+	Prose         []config.ProseRule
+
+	// For links in sources (CheckFiles): the output path of a content file,
+	// and the site paths of known pages ("/guide.html") with their heading
+	// IDs, nil when unknown
+	HTMLPath func(relPath string) string
+	Pages    map[string][]string
+
+	// The heading IDs a content file gets as it is now, so that links to a
+	// page edited since the last build are checked against its headings.
+	// ok is false when they can't be told from the source alone.
+	HeadingIDs func(relPath string, source []byte) (ids []string, ok bool)
+}
+
+// Run checks every content file and every built page, returning the
+// findings sorted by class and page
+func Run(opts Options) ([]Finding, error) {
+	if opts.MaxImageBytes <= 0 {
+		opts.MaxImageBytes = DefaultMaxImageKB * 1024
+	}
+	prose, err := compileProse(opts.Prose)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	err = afero.Walk(opts.ContentFs, opts.ContentDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".md") || strings.HasSuffix(p, "_index.md") || strings.HasSuffix(p, "404.md") {
+			return nil
+		}
+		source, err := afero.ReadFile(opts.ContentFs, p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(opts.ContentDir, p)
+		rel = filepath.ToSlash(rel)
+		findings = append(findings, checkFrontmatter(rel, source, opts.IncludeDrafts)...)
+		findings = append(findings, checkRefs(opts, rel, source)...)
+		findings = append(findings, checkProse(prose, rel, source)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking content: %w", err)
+	}
+
+	links := newResolver(opts)
+	err = afero.Walk(opts.OutputFs, opts.OutputDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".html") {
+			return nil
+		}
+		page, err := afero.ReadFile(opts.OutputFs, p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(opts.OutputDir, p)
+		findings = append(findings, links.checkPage(filepath.ToSlash(rel), page)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking output: %w", err)
+	}
+
+	sortFindings(findings)
+	return findings, nil
+}
+
+// sortFindings sorts findings by class, then page
+func sortFindings(findings []Finding) {
+	order := make(map[Class]int, len(Classes))
+	for i, c := range Classes {
+		order[c] = i
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Class != findings[j].Class {
+			return order[findings[i].Class] < order[findings[j].Class]
+		}
+		return findings[i].Page < findings[j].Page
+	})
+}
+
+// checkRefs reports ref and relref shortcodes whose target content file
+// doesn't exist
+func checkRefs(opts Options, page string, source []byte) []Finding {
+	if !mdParser.HasRefs(source) {
+		return nil
+	}
+	_, broken := mdParser.ResolveRefs(source, page, func(rel string) (string, bool) {
+		ok, _ := afero.Exists(opts.ContentFs, filepath.Join(opts.ContentDir, filepath.FromSlash(rel)))
+		return rel, ok
+	})
+	findings := make([]Finding, 0, len(broken))
+	for _, target := range broken {
+		findings = append(findings, Finding{Class: BrokenRef, Page: page, Message: fmt.Sprintf("ref %q does not exist", target)})
+	}
+	return findings
+}
+
+// fieldKinds are the frontmatter fields Kosh reads that need a specific type
+var fieldKinds = map[string]string{
+	"title":       "text",
+	"description": "text",
+	"date":        "date",
+	"publishDate": "time",
+	"expiryDate":  "time",
+	"tags":        "list",
+	"weight":      "number",
+	"draft":       "bool",
+	"pinned":      "bool",
+}
+
+// checkFrontmatter reports a missing description and fields of the wrong
+// type. Drafts are skipped unless they are built.
+func checkFrontmatter(page string, source []byte, includeDrafts bool) []Finding {
+	front, ok := frontmatter(source)
+	if !ok {
+		return []Finding{{Class: MissingDescription, Page: page, Message: "no frontmatter"}}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(front, &doc); err != nil {
+		return []Finding{{Class: InvalidFrontmatter, Page: page, Message: fmt.Sprintf("not valid YAML: %v", err)}}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return []Finding{{Class: MissingDescription, Page: page, Message: "empty frontmatter"}}
+	}
+
+	fields := doc.Content[0].Content
+	values := make(map[string]*yaml.Node, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		values[fields[i].Value] = fields[i+1]
+	}
+	if draft := values["draft"]; draft != nil && draft.Value == "true" && !includeDrafts {
+		return nil
+	}
+
+	var findings []Finding
+	for i := 0; i+1 < len(fields); i += 2 {
+		key, value := fields[i].Value, fields[i+1]
+		kind, known := fieldKinds[key]
+		if !known || value.Tag == "!!null" {
+			continue
+		}
+		if problem := checkField(kind, value); problem != "" {
+			findings = append(findings, Finding{
+				Class:   InvalidFrontmatter,
+				Page:    page,
+				Message: fmt.Sprintf("line %d: %s %s", value.Line+1, key, problem),
+			})
+		}
+	}
+	if desc := values["description"]; desc == nil || strings.TrimSpace(desc.Value) == "" {
+		findings = append(findings, Finding{Class: MissingDescription, Page: page, Message: "no description"})
+	}
+	return findings
+}
+
+func checkField(kind string, value *yaml.Node) string {
+	switch kind {
+	case "text":
+		if value.Kind != yaml.ScalarNode {
+			return "should be text"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value.Value); value.Kind != yaml.ScalarNode || err != nil {
+			return fmt.Sprintf("%q is not a YYYY-MM-DD date", value.Value)
+		}
+	case "time":
+		if _, err := utils.ParseDate(value.Value); value.Kind != yaml.ScalarNode || err != nil {
+			return fmt.Sprintf("%q is not a YYYY-MM-DD date or RFC 3339 time", value.Value)
+		}
+	case "list":
+		if value.Kind != yaml.SequenceNode {
+			return "should be a list, e.g. [go, web]"
+		}
+	case "number":
+		if value.Tag != "!!int" && value.Tag != "!!float" {
+			return fmt.Sprintf("%q is not a number", value.Value)
+		}
+	case "bool":
+		if value.Tag != "!!bool" {
+			return fmt.Sprintf("%q is not true or false", value.Value)
+		}
+	}
+	return ""
+}
+
+// frontmatter returns the YAML between the leading --- lines of a post
+func frontmatter(source []byte) ([]byte, bool) {
+	source = bytes.TrimPrefix(source, []byte("\ufeff"))
+	rest, ok := bytes.CutPrefix(source, []byte("---\n"))
+	if !ok {
+		if rest, ok = bytes.CutPrefix(source, []byte("---\r\n")); !ok {
+			return nil, false
+		}
+	}
+	if bytes.HasPrefix(rest, []byte("---")) {
+		return nil, true
+	}
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return nil, false
+	}
+	return rest[:end+1], true
+}
+
+// The minifier drops attribute quotes where it can, so values may be unquoted
+var (
+	articleLink  = regexp.MustCompile(`(?i)<a\s[^>]*?\bhref=(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	articleImage = regexp.MustCompile(`(?i)<img\s[^>]*?\bsrc=(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	elementID    = regexp.MustCompile(`(?i)<[a-z][^>]*?\sid=(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// attrValue returns the value matched by articleLink or articleImage
+func attrValue(m [][]byte) string {
+	return string(m[1]) + string(m[2]) + string(m[3])
+}
+
+// resolver maps links in built pages to files in the output directory
+type resolver struct {
+	opts     Options
+	host     string
+	basePath string                     // Path of baseURL without the trailing slash, e.g. "/docs"
+	ids      map[string]map[string]bool // Element ids of the pages read so far, by file
+}
+
+func newResolver(opts Options) *resolver {
+	r := &resolver{opts: opts, ids: make(map[string]map[string]bool)}
+	if u, err := url.Parse(opts.BaseURL); err == nil {
+		r.host = u.Host
+		r.basePath = strings.TrimSuffix(u.Path, "/")
+	}
+	return r
+}
+
+// checkPage reports broken links, links to anchors missing on their page
+// and oversized images inside the <article> of a page. Navigation and list
+// pages come from the theme and are skipped.
+func (r *resolver) checkPage(page string, content []byte) []Finding {
+	start := bytes.Index(content, []byte("<article"))
+	end := bytes.LastIndex(content, []byte("</article>"))
+	if start < 0 || end < start {
+		return nil
+	}
+	ownIDs := elementIDs(content)
+	content = content[start:end]
+
+	var findings []Finding
+	seen := make(map[string]bool)
+	check := func(link string, image bool) {
+		link = html.UnescapeString(link)
+		if seen[link] {
+			return
+		}
+		seen[link] = true
+		target, internal := r.target(page, link)
+		if !internal {
+			if fragment, ok := fragmentOnly(link); ok && !ownIDs[fragment] {
+				findings = append(findings, Finding{Class: BrokenLink, Page: page, Message: fmt.Sprintf("%s: no anchor #%s", link, fragment)})
+			}
+			return
+		}
+		info, found := r.stat(target)
+		switch {
+		case !found:
+			findings = append(findings, Finding{Class: BrokenLink, Page: page, Message: fmt.Sprintf("%s does not exist", link)})
+		case !image:
+			if u, err := url.Parse(link); err == nil && checkedFragment(u.Fragment) {
+				if ids := r.pageIDs(target); ids != nil && !ids[u.Fragment] {
+					findings = append(findings, Finding{Class: BrokenLink, Page: page, Message: fmt.Sprintf("%s: no anchor #%s", link, u.Fragment)})
+				}
+			}
+		case info.Size() > r.opts.MaxImageBytes:
+			findings = append(findings, Finding{
+				Class:   OversizedImage,
+				Page:    page,
+				Message: fmt.Sprintf("%s is %d KB (limit %d KB)", link, info.Size()/1024, r.opts.MaxImageBytes/1024),
+			})
+		}
+	}
+	for _, m := range articleLink.FindAllSubmatch(content, -1) {
+		check(attrValue(m), false)
+	}
+	for _, m := range articleImage.FindAllSubmatch(content, -1) {
+		check(attrValue(m), true)
+	}
+	return findings
+}
+
+// target returns the site path a link on page points to. internal is false
+// for external links, other schemes and fragment-only links.
+func (r *resolver) target(page, link string) (target string, internal bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Opaque != "" {
+		return "", false
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+	if u.Host != "" && u.Host != r.host {
+		return "", false
+	}
+	if u.Path == "" {
+		return "", false
+	}
+
+	p := u.Path
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join("/", path.Dir(page), p)
+		if strings.HasSuffix(u.Path, "/") {
+			p += "/"
+		}
+	} else if r.basePath != "" && (p == r.basePath || strings.HasPrefix(p, r.basePath+"/")) {
+		p = strings.TrimPrefix(p, r.basePath)
+	}
+	if p == "" {
+		p = "/"
+	}
+	return p, true
+}
+
+// stat finds the file a site path is served from
+func (r *resolver) stat(sitePath string) (fs.FileInfo, bool) {
+	_, info, found := r.find(sitePath)
+	return info, found
+}
+
+// find returns the path of the file a site path is served from
+func (r *resolver) find(sitePath string) (string, fs.FileInfo, bool) {
+	candidates := []string{sitePath + "index.html"}
+	if !strings.HasSuffix(sitePath, "/") {
+		candidates = []string{sitePath, sitePath + ".html", sitePath + "/index.html"}
+	}
+	for _, c := range candidates {
+		file := filepath.Join(r.opts.OutputDir, filepath.FromSlash(c))
+		info, err := r.opts.OutputFs.Stat(file)
+		if err == nil && !info.IsDir() {
+			return file, info, true
+		}
+	}
+	return "", nil, false
+}
+
+// pageIDs returns the element ids of the page a site path is served from,
+// nil when it isn't an HTML page
+func (r *resolver) pageIDs(sitePath string) map[string]bool {
+	file, _, found := r.find(sitePath)
+	if !found || !strings.HasSuffix(file, ".html") {
+		return nil
+	}
+	if ids, ok := r.ids[file]; ok {
+		return ids
+	}
+	content, err := afero.ReadFile(r.opts.OutputFs, file)
+	var ids map[string]bool
+	if err == nil {
+		ids = elementIDs(content)
+	}
+	r.ids[file] = ids
+	return ids
+}
+
+// elementIDs returns the ids of the elements of a page
+func elementIDs(content []byte) map[string]bool {
+	ids := make(map[string]bool)
+	for _, m := range elementID.FindAllSubmatch(content, -1) {
+		ids[html.UnescapeString(attrValue(m))] = true
+	}
+	return ids
+}
+
+// fragmentOnly returns the anchor of a link to the page it is on
+func fragmentOnly(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path != "" || u.RawQuery != "" {
+		return "", false
+	}
+	return u.Fragment, checkedFragment(u.Fragment)
+}
+
+// checkedFragment reports whether an anchor must be an element id. "#top"
+// scrolls to the top of any page, and "#/..." and "#!..." are script routes.
+func checkedFragment(fragment string) bool {
+	return fragment != "" && fragment != "top" && !strings.HasPrefix(fragment, "/") && !strings.HasPrefix(fragment, "!")
+}
@@ -0,0 +1,178 @@
+package checks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestCheckFrontmatter(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		includeDrafts bool
+		want          []string // "class: message" of each finding
+	}{
+		{
+			name:   "valid",
+			source: "---\ntitle: Intro\ndescription: Getting started\ndate: 2024-03-01\ntags: [go]\nweight: 2\n---\nBody",
+		},
+		{
+			name:   "missing description",
+			source: "---\ntitle: Intro\n---\nBody",
+			want:   []string{"missing-description: no description"},
+		},
+		{
+			name:   "no frontmatter",
+			source: "# Intro\n",
+			want:   []string{"missing-description: no frontmatter"},
+		},
+		{
+			name:   "wrong types",
+			source: "---\ndescription: x\ndate: 01/03/2024\ntags: go\ndraft: yes please\n---\n",
+			want: []string{
+				`invalid-frontmatter: line 3: date "01/03/2024" is not a YYYY-MM-DD date`,
+				"invalid-frontmatter: line 4: tags should be a list, e.g. [go, web]",
+				`invalid-frontmatter: line 5: draft "yes please" is not true or false`,
+			},
+		},
+		{
+			name:   "schedule",
+			source: "---\ndescription: x\npublishDate: 2024-06-01T09:00:00+02:00\nexpiryDate: next year\n---\n",
+			want:   []string{`invalid-frontmatter: line 4: expiryDate "next year" is not a YYYY-MM-DD date or RFC 3339 time`},
+		},
+		{
+			name:   "broken yaml",
+			source: "---\ntitle: [unclosed\n---\n",
+			want:   []string{"invalid-frontmatter: not valid YAML"},
+		},
+		{
+			name:   "draft skipped",
+			source: "---\ntitle: WIP\ndraft: true\n---\n",
+		},
+		{
+			name:          "draft built",
+			source:        "---\ntitle: WIP\ndraft: true\n---\n",
+			includeDrafts: true,
+			want:          []string{"missing-description: no description"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkFrontmatter("post.md", []byte(tt.source), tt.includeDrafts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("checkFrontmatter() = %+v, want %d findings", got, len(tt.want))
+			}
+			for i, f := range got {
+				if s := string(f.Class) + ": " + f.Message; !strings.HasPrefix(s, tt.want[i]) {
+					t.Errorf("finding %d = %q, want %q", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolverTarget(t *testing.T) {
+	r := newResolver(Options{BaseURL: "https://example.com/docs"})
+	tests := []struct {
+		link         string
+		want         string
+		wantInternal bool
+	}{
+		{"https://example.com/docs/guide/intro.html", "/guide/intro.html", true},
+		{"/docs/guide/", "/guide/", true},
+		{"setup.html#install", "/guide/setup.html", true},
+		{"../api/", "/api/", true},
+		{"https://other.org/page", "", false},
+		{"mailto:me@example.com", "", false},
+		{"#heading", "", false},
+	}
+	for _, tt := range tests {
+		got, internal := r.target("guide/intro.html", tt.link)
+		if got != tt.want || internal != tt.wantInternal {
+			t.Errorf("target(%q) = %q, %v; want %q, %v", tt.link, got, internal, tt.want, tt.wantInternal)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/site/content/ok.md":          "---\ntitle: OK\ndescription: Fine\n---\n",
+		"/site/content/bare.md":        "---\ntitle: Bare\n---\n",
+		"/site/content/refs.md":        "---\ntitle: Refs\ndescription: Links\n---\n[ok]({{< ref \"ok.md\" >}}) [gone]({{< relref \"guides/gone\" >}})\n",
+		"/site/content/_index.md":      "# Section\n",
+		"/site/public/ok.html":         `<nav><a href="/nowhere.html">x</a></nav><article><a href="/bare.html">b</a><a href=/gone/>g</a><img alt=x src='/static/big.webp'></article>`,
+		"/site/public/bare.html":       `<article><a href="https://other.org/">o</a></article>`,
+		"/site/public/static/big.webp": strings.Repeat("x", 3*1024),
+	}
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := Run(Options{
+		ContentFs: fs, ContentDir: "/site/content",
+		OutputFs: fs, OutputDir: "/site/public",
+		BaseURL: "https://example.com", MaxImageBytes: 2 * 1024,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{
+		"missing-description bare.md: no description",
+		`broken-ref refs.md: ref "guides/gone" does not exist`,
+		"broken-link ok.html: /gone/ does not exist",
+		"oversized-image ok.html: /static/big.webp is 3 KB (limit 2 KB)",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("Run() = %+v, want %d findings", findings, len(want))
+	}
+	for i, f := range findings {
+		if got := string(f.Class) + " " + f.Page + ": " + f.Message; got != want[i] {
+			t.Errorf("finding %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestRunAnchors(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/site/content/guide.md": "---\ntitle: Guide\ndescription: Anchors\n---\n",
+		"/site/public/guide.html": `<article><h2 id=install>Install</h2><h2 id="caf&#233;">Café</h2>` +
+			`<a href="#install">i</a><a href="#gone">g</a><a href="#top">t</a><a href="#café">c</a>` +
+			`<a href="/faq.html#why">w</a><a href="/faq.html#how">h</a><a href="/file.txt#l1">f</a></article>`,
+		"/site/public/faq.html": `<article><h2 id='why'>Why</h2></article>`,
+		"/site/public/file.txt": "text",
+	}
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := Run(Options{
+		ContentFs: fs, ContentDir: "/site/content",
+		OutputFs: fs, OutputDir: "/site/public",
+		BaseURL: "https://example.com", MaxImageBytes: 1024,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []string{
+		"broken-link guide.html: #gone: no anchor #gone",
+		"broken-link guide.html: /faq.html#how: no anchor #how",
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("Run() = %+v, want %d findings", findings, len(want))
+	}
+	for i, f := range findings {
+		if got := string(f.Class) + " " + f.Page + ": " + f.Message; got != want[i] {
+			t.Errorf("finding %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package checks
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/spf13/afero"
+)
+
+// Severity says how much an SEO finding matters
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// SEO audit classes. MissingDescription is shared with the strict checks.
+const (
+	TitleLength       Class = "title-length"
+	DescriptionLength Class = "description-length"
+	DuplicateTitle    Class = "duplicate-title"
+	MissingOGImage    Class = "missing-og-image"
+	DuplicateURL      Class = "duplicate-url"
+)
+
+// SEOClasses lists every SEO check in the order they are reported
+var SEOClasses = []Class{MissingDescription, DuplicateURL, TitleLength, DescriptionLength, DuplicateTitle, MissingOGImage}
+
+// Length limits search engines display without truncating
+const (
+	MinTitleLength       = 10
+	MaxTitleLength       = 60
+	MinDescriptionLength = 50
+	MaxDescriptionLength = 160
+)
+
+// seoPage is what the audit reads from one built page
+type seoPage struct {
+	path        string
+	title       string
+	description string
+	hasDesc     bool
+	ogImage     string
+	canonical   string
+	content     string // Text of the <article>, or of <main>, for duplicates
+}
+
+// SEO audits every built page of outputDir: title and description lengths,
+// missing descriptions, duplicate titles, missing og:image and pages that
+// repeat another page's content without a canonical link. Redirects and
+// noindex pages are skipped. Findings are sorted by class and page.
+func SEO(outputFs afero.Fs, outputDir string) ([]Finding, error) {
+	var pages []seoPage
+	err := afero.Walk(outputFs, outputDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".html") || filepath.Base(p) == "404.html" {
+			return nil
+		}
+		data, err := afero.ReadFile(outputFs, p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(outputDir, p)
+		if page, ok := readSEOPage(filepath.ToSlash(rel), data); ok {
+			pages = append(pages, page)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking output: %w", err)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].path < pages[j].path })
+
+	var findings []Finding
+	add := func(class Class, severity Severity, page, format string, args ...any) {
+		findings = append(findings, Finding{Class: class, Severity: severity, Page: page, Message: fmt.Sprintf(format, args...)})
+	}
+
+	titles := make(map[string][]string)
+	contents := make(map[string][]seoPage)
+	for _, p := range pages {
+		switch n := utf8.RuneCountInString(p.title); {
+		case n == 0:
+			add(TitleLength, SeverityError, p.path, "no <title>")
+		case n < MinTitleLength:
+			add(TitleLength, SeverityWarning, p.path, "title is %d characters, under %d: %q", n, MinTitleLength, p.title)
+		case n > MaxTitleLength:
+			add(TitleLength, SeverityWarning, p.path, "title is %d characters, over %d and truncated in results", n, MaxTitleLength)
+		}
+
+		switch n := utf8.RuneCountInString(p.description); {
+		case n == 0:
+			msg := "no meta description"
+			if p.hasDesc {
+				msg = "empty meta description"
+			}
+			add(MissingDescription, SeverityError, p.path, "%s", msg)
+		case n < MinDescriptionLength:
+			add(DescriptionLength, SeverityWarning, p.path, "description is %d characters, under %d", n, MinDescriptionLength)
+		case n > MaxDescriptionLength:
+			add(DescriptionLength, SeverityWarning, p.path, "description is %d characters, over %d and truncated in results", n, MaxDescriptionLength)
+		}
+
+		if p.ogImage == "" {
+			add(MissingOGImage, SeverityWarning, p.path, "no og:image, so shared links have no preview")
+		}
+		if p.title != "" {
+			titles[p.title] = append(titles[p.title], p.path)
+		}
+		if p.content != "" {
+			contents[p.content] = append(contents[p.content], p)
+		}
+	}
+
+	for title, paths := range titles {
+		if len(paths) < 2 {
+			continue
+		}
+		for _, path := range paths {
+			add(DuplicateTitle, SeverityWarning, path, "%q is also the title of %s", title, others(paths, path))
+		}
+	}
+
+	for _, same := range contents {
+		if len(same) < 2 || canonicalized(same) {
+			continue
+		}
+		paths := make([]string, len(same))
+		for i, p := range same {
+			paths[i] = p.path
+		}
+		for _, path := range paths {
+			add(DuplicateURL, SeverityError, path, "same content as %s and no shared rel=canonical", others(paths, path))
+		}
+	}
+
+	order := make(map[Class]int, len(SEOClasses))
+	for i, c := range SEOClasses {
+		order[c] = i
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Class != findings[j].Class {
+			return order[findings[i].Class] < order[findings[j].Class]
+		}
+		return findings[i].Page < findings[j].Page
+	})
+	return findings, nil
+}
+
+// readSEOPage extracts the head tags the audit needs. ok is false for pages
+// search engines don't index: redirects and noindex pages.
+func readSEOPage(path string, data []byte) (seoPage, bool) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return seoPage{}, false
+	}
+	if doc.Find(`meta[http-equiv="refresh" i]`).Length() > 0 {
+		return seoPage{}, false
+	}
+	if robots, _ := doc.Find(`meta[name="robots" i]`).Attr("content"); strings.Contains(strings.ToLower(robots), "noindex") {
+		return seoPage{}, false
+	}
+
+	p := seoPage{path: path, title: strings.TrimSpace(doc.Find("title").First().Text())}
+	if desc := doc.Find(`meta[name="description" i]`).First(); desc.Length() > 0 {
+		p.hasDesc = true
+		p.description = strings.TrimSpace(desc.AttrOr("content", ""))
+	}
+	p.ogImage = strings.TrimSpace(doc.Find(`meta[property="og:image"]`).First().AttrOr("content", ""))
+	p.canonical = strings.TrimSpace(doc.Find(`link[rel="canonical" i]`).First().AttrOr("href", ""))
+
+	body := doc.Find("article").First()
+	if body.Length() == 0 {
+		body = doc.Find("main").First()
+	}
+	p.content = strings.Join(strings.Fields(body.Text()), " ")
+	return p, true
+}
+
+// canonicalized reports whether pages with the same content all name the
+// same canonical URL
+func canonicalized(same []seoPage) bool {
+	for _, p := range same {
+		if p.canonical == "" || p.canonical != same[0].canonical {
+			return false
+		}
+	}
+	return true
+}
+
+// others lists paths other than self, at most three
+func others(paths []string, self string) string {
+	var list []string
+	for _, p := range paths {
+		if p != self {
+			list = append(list, p)
+		}
+	}
+	if len(list) > 3 {
+		return strings.Join(list[:3], ", ") + fmt.Sprintf(" and %d more", len(list)-3)
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,54 @@
+package checks
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestSEO(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	head := func(title, extra string) string {
+		return "<!doctype html><html><head><title>" + title + "</title>" + extra + "</head><body>"
+	}
+	good := `<meta name=description content="A description that is long enough to show up in search results."><meta property=og:image content=/card.webp>`
+	pages := map[string]string{
+		"index.html":      head("Home of the example site", good) + "<main>Welcome</main>",
+		"posts/a.html":    head("Hi", `<meta name="description" content="">`) + "<article>Alpha</article>",
+		"posts/b.html":    head("Duplicated title here", good) + "<article>Same body</article>",
+		"posts/c.html":    head("Duplicated title here", good) + "<article>Same   body</article>",
+		"v1/posts/d.html": head("Versioned copy of a page", good+`<link rel=canonical href=https://example.com/posts/d.html>`) + "<article>Versioned</article>",
+		"posts/d.html":    head("Latest copy of that page", good+`<link rel=canonical href=https://example.com/posts/d.html>`) + "<article>Versioned</article>",
+		"tags/old.html":   `<meta http-equiv="refresh" content="0; url=/tags/new.html">`,
+		"private.html":    head("x", `<meta name="robots" content="noindex">`),
+		"404.html":        head("Not found", ""),
+	}
+	for name, content := range pages {
+		if err := afero.WriteFile(fs, filepath.Join("public", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := SEO(fs, "public")
+	if err != nil {
+		t.Fatalf("SEO() error = %v", err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, string(f.Severity)+" "+string(f.Class)+" "+f.Page)
+	}
+	want := []string{
+		"error missing-description posts/a.html",
+		"error duplicate-url posts/b.html",
+		"error duplicate-url posts/c.html",
+		"warning title-length posts/a.html",
+		"warning duplicate-title posts/b.html",
+		"warning duplicate-title posts/c.html",
+		"warning missing-og-image posts/a.html",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("SEO() findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,134 @@
+package checks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// LatestFile is where the findings of the latest check are kept, in the
+// cache directory, for the dev server's status page
+const LatestFile = "last-check.json"
+
+// Latest is a saved check run
+type Latest struct {
+	At       time.Time `json:"at"`
+	Findings []Finding `json:"findings"`
+}
+
+// SaveLatest keeps the findings of a check run in cacheDir
+func SaveLatest(cacheDir string, findings []Finding, at time.Time) error {
+	data, err := json.Marshal(Latest{At: at, Findings: findings})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cacheDir, LatestFile), data, 0644)
+}
+
+// LoadLatest reads the findings saved by SaveLatest; ok is false when no
+// check has run yet
+func LoadLatest(cacheDir string) (latest Latest, ok bool, err error) {
+	data, err := os.ReadFile(filepath.Join(cacheDir, LatestFile))
+	if os.IsNotExist(err) {
+		return Latest{}, false, nil
+	} else if err != nil {
+		return Latest{}, false, err
+	}
+	if err := json.Unmarshal(data, &latest); err != nil {
+		return Latest{}, false, fmt.Errorf("reading %s: %w", LatestFile, err)
+	}
+	return latest, true, nil
+}
+
+// StatusPage is a content file listed on the status page
+type StatusPage struct {
+	Path  string // Relative to the content directory
+	Title string
+	Date  time.Time // Zero when undated
+}
+
+// Status is the health of a site's content at a glance
+type Status struct {
+	Drafts        []StatusPage
+	Future        []StatusPage // Dated after today, or with a publishDate still to come
+	Stale         []StatusPage // Last dated more than StaleMonths ago, oldest first
+	NoDescription []StatusPage
+	StaleMonths   int
+}
+
+// ContentStatus reads the frontmatter of every content file. A page's age is
+// its lastmod, else its date; staleMonths 0 lists no stale pages. Drafts are
+// only listed as drafts.
+func ContentStatus(contentFs afero.Fs, contentDir string, now time.Time, staleMonths int) (Status, error) {
+	st := Status{StaleMonths: staleMonths}
+	today := now.Truncate(24 * time.Hour)
+	staleBefore := now.AddDate(0, -staleMonths, 0)
+	err := afero.Walk(contentFs, contentDir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".md") || strings.HasSuffix(p, "_index.md") || strings.HasSuffix(p, "404.md") {
+			return nil
+		}
+		source, err := afero.ReadFile(contentFs, p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(contentDir, p)
+		page := StatusPage{Path: filepath.ToSlash(rel)}
+
+		var meta struct {
+			Title       string `yaml:"title"`
+			Description string `yaml:"description"`
+			Date        string `yaml:"date"`
+			LastMod     string `yaml:"lastmod"`
+			PublishDate string `yaml:"publishDate"`
+			Draft       bool   `yaml:"draft"`
+		}
+		if front, ok := frontmatter(source); ok {
+			_ = yaml.Unmarshal(front, &meta) // Broken frontmatter is the checks' business
+		}
+		page.Title = meta.Title
+		page.Date, _ = time.Parse("2006-01-02", meta.Date)
+
+		if meta.Draft {
+			st.Drafts = append(st.Drafts, page)
+			return nil
+		}
+		if publish, err := utils.ParseDate(meta.PublishDate); err == nil && publish.After(now) {
+			st.Future = append(st.Future, StatusPage{Path: page.Path, Title: page.Title, Date: publish})
+		} else if page.Date.After(today) {
+			st.Future = append(st.Future, page)
+		}
+		if strings.TrimSpace(meta.Description) == "" {
+			st.NoDescription = append(st.NoDescription, page)
+		}
+		updated := page.Date
+		if lastMod, err := time.Parse("2006-01-02", meta.LastMod); err == nil {
+			updated = lastMod
+		}
+		if staleMonths > 0 && !updated.IsZero() && updated.Before(staleBefore) {
+			st.Stale = append(st.Stale, StatusPage{Path: page.Path, Title: page.Title, Date: updated})
+		}
+		return nil
+	})
+	if err != nil {
+		return Status{}, fmt.Errorf("reading content: %w", err)
+	}
+	sort.SliceStable(st.Stale, func(i, j int) bool { return st.Stale[i].Date.Before(st.Stale[j].Date) })
+	sort.SliceStable(st.Future, func(i, j int) bool { return st.Future[i].Date.Before(st.Future[j].Date) })
+	return st, nil
+}
@@ -0,0 +1,82 @@
+package checks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestContentStatus(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"content/fresh.md":     "---\ntitle: Fresh\ndescription: x\ndate: 2024-05-01\n---\n",
+		"content/old.md":       "---\ntitle: Old\ndescription: x\ndate: 2022-01-01\n---\n",
+		"content/older.md":     "---\ntitle: Older\ndescription: x\ndate: 2020-01-01\n---\n",
+		"content/updated.md":   "---\ntitle: Updated\ndescription: x\ndate: 2020-01-01\nlastmod: 2024-04-01\n---\n",
+		"content/future.md":    "---\ntitle: Future\ndescription: x\ndate: 2024-07-01\n---\n",
+		"content/scheduled.md": "---\ntitle: Scheduled\ndescription: x\ndate: 2024-05-01\npublishDate: 2024-06-01T15:00:00Z\n---\n",
+		"content/released.md":  "---\ntitle: Released\ndescription: x\ndate: 2024-05-01\npublishDate: 2024-06-01T09:00:00Z\n---\n",
+		"content/wip.md":       "---\ntitle: WIP\ndate: 2020-01-01\ndraft: true\n---\n",
+		"content/bare.md":      "# Bare\n",
+		"content/_index.md":    "---\ntitle: Home\n---\n",
+		"content/docs/page.md": "---\ntitle: Page\n---\n",
+	}
+	for name, src := range files {
+		_ = afero.WriteFile(fs, name, []byte(src), 0644)
+	}
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	st, err := ContentStatus(fs, "content", now, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := func(pages []StatusPage) []string {
+		var out []string
+		for _, p := range pages {
+			out = append(out, p.Path)
+		}
+		return out
+	}
+	check := func(name string, got, want []string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+	check("Drafts", paths(st.Drafts), []string{"wip.md"})
+	check("Future", paths(st.Future), []string{"scheduled.md", "future.md"})
+	check("Stale", paths(st.Stale), []string{"older.md", "old.md"})
+	check("NoDescription", paths(st.NoDescription), []string{"bare.md", "docs/page.md"})
+
+	st, _ = ContentStatus(fs, "content", now, 0)
+	if len(st.Stale) != 0 {
+		t.Errorf("staleMonths 0 listed %v", paths(st.Stale))
+	}
+}
+
+func TestLatest(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok, err := LoadLatest(dir); ok || err != nil {
+		t.Fatalf("LoadLatest before a check = %v, %v", ok, err)
+	}
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	findings := []Finding{{Class: BrokenLink, Page: "index.html", Message: "/missing.html"}}
+	if err := SaveLatest(dir, findings, at); err != nil {
+		t.Fatal(err)
+	}
+	latest, ok, err := LoadLatest(dir)
+	if !ok || err != nil {
+		t.Fatalf("LoadLatest = %v, %v", ok, err)
+	}
+	if !latest.At.Equal(at) || len(latest.Findings) != 1 || latest.Findings[0] != findings[0] {
+		t.Errorf("LoadLatest = %+v, want %+v at %v", latest, findings, at)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// PublicAudience is the audience of a build without --audience. Pages list it
+// in `audience:` to appear in the public site as well as other variants.
+const PublicAudience = "public"
+
+// audienceName keeps audience names usable as directory names
+var audienceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// AudienceConfig sets where a `kosh build --audience <name>` variant goes
+type AudienceConfig struct {
+	OutputDir string `yaml:"outputDir"` // Default: <outputDir>-<name>
+	BaseURL   string `yaml:"baseURL"`   // Default: the site baseURL
+}
+
+// ValidAudience reports whether name can be used with --audience
+func ValidAudience(name string) bool {
+	return audienceName.MatchString(name)
+}
+
+// applyAudience points the build at an audience variant: its own output
+// directory, and its own cache so incremental builds of one variant never see
+// pages of another
+func (cfg *Config) applyAudience(name string, overrideBaseURL bool) {
+	cfg.Audience = name
+	ac := cfg.Audiences[name]
+
+	if ac.OutputDir == "" {
+		cfg.OutputDir += "-" + name
+	} else if abs, err := filepath.Abs(ac.OutputDir); err == nil {
+		cfg.OutputDir = utils.NormalizePath(abs)
+	}
+	cfg.CacheDir = utils.NormalizePath(filepath.Join(cfg.CacheDir, "audiences", name))
+	if ac.BaseURL != "" && overrideBaseURL {
+		cfg.BaseURL = strings.TrimSuffix(ac.BaseURL, "/")
+	}
+}
+
+// InAudience reports whether a page with the given `audience:` frontmatter is
+// part of this build. Pages without one are public and in every variant.
+func (cfg *Config) InAudience(audiences []string) bool {
+	if len(audiences) == 0 {
+		return true
+	}
+	build := cfg.Audience
+	if build == "" {
+		build = PublicAudience
+	}
+	for _, a := range audiences {
+		if strings.EqualFold(strings.TrimSpace(a), build) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkAudiences(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "audiences")
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !ValidAudience(key.Value) || key.Value == PublicAudience {
+			*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "audiences", Message: fmt.Sprintf("invalid audience %q (lowercase letters, digits, - and _; %q is the default build)", key.Value, PublicAudience)})
+		}
+	}
+}
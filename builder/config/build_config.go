@@ -26,6 +26,7 @@ type BuildConfig struct {
 	DebounceDuration time.Duration `yaml:"debounceDuration"` // File watcher debounce (default: 500ms)
 	TemplateCheckTTL time.Duration `yaml:"templateCheckTTL"` // Template mtime check TTL (default: 2s)
 	CacheDBTimeout   time.Duration `yaml:"cacheDBTimeout"`   // BoltDB timeout (default: 10s)
+	RemoteTimeout    time.Duration `yaml:"remoteTimeout"`    // getRemote/getJSON request timeout (default: 10s)
 
 	// Search settings
 	MaxSnippetContentLength int     `yaml:"maxSnippetContentLength"` // Max content length for snippets (default: 10000)
@@ -57,6 +58,7 @@ func DefaultBuildConfig() *BuildConfig {
 		DebounceDuration: 500 * time.Millisecond,
 		TemplateCheckTTL: 2 * time.Second,
 		CacheDBTimeout:   10 * time.Second,
+		RemoteTimeout:    10 * time.Second,
 
 		// Search
 		MaxSnippetContentLength: 10000,
@@ -147,6 +149,12 @@ func (c *BuildConfig) validate() {
 	if c.CacheDBTimeout < 1*time.Second {
 		c.CacheDBTimeout = 1 * time.Second
 	}
+	if c.RemoteTimeout < 1*time.Second {
+		c.RemoteTimeout = 1 * time.Second
+	}
+	if c.RemoteTimeout > 2*time.Minute {
+		c.RemoteTimeout = 2 * time.Minute
+	}
 
 	// Search
 	if c.DefaultSnippetLength < 50 {
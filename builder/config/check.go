@@ -0,0 +1,811 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a single problem found while validating a config file
+type Issue struct {
+	Line    int
+	Column  int
+	Path    string // Dotted key path, e.g. "features.generators.rss"
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Path, i.Message)
+}
+
+// CheckFile validates a config file on disk. See Check.
+func CheckFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Check(data)
+}
+
+// Check validates raw kosh.yaml content against the Config schema.
+// It reports unknown keys, values of the wrong type and conflicting options,
+// each with the line it was found on. A YAML syntax error is returned as err.
+func Check(data []byte) ([]Issue, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return issues, nil // Empty file: everything falls back to defaults
+	}
+
+	doc := root.Content[0]
+	checkNode(doc, reflect.TypeOf(Config{}), "", &issues)
+	checkVersions(doc, &issues)
+	checkMounts(doc, &issues)
+	checkModules(doc, &issues)
+	checkComments(doc, &issues)
+	checkFediverse(doc, &issues)
+	checkAnalytics(doc, &issues)
+	checkWellKnown(doc, &issues)
+	checkPWA(doc, &issues)
+	checkStrict(doc, &issues)
+	checkMarkdown(doc, &issues)
+	checkAudiences(doc, &issues)
+	checkLanguages(doc, &issues)
+	checkSearch(doc, &issues)
+	checkFeeds(doc, &issues)
+	checkSitemap(doc, &issues)
+	checkPagination(doc, &issues)
+	checkAssets(doc, &issues)
+	checkImages(doc, &issues)
+	checkDeploy(doc, &issues)
+	checkLargeFiles(doc, &issues)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues, nil
+}
+
+// checkNode walks a YAML node alongside the Go type it will be decoded into
+func checkNode(node *yaml.Node, t reflect.Type, path string, issues *[]Issue) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	if node.Kind == yaml.ScalarNode && envPattern.MatchString(node.Value) {
+		return // Type is only known after ${VAR} expansion in Load
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			addTypeIssue(node, path, "a mapping", issues)
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if s := suggestKey(key.Value, fields); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: path, Message: msg})
+				continue
+			}
+			checkNode(value, field.Type, keyPath, issues)
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			addTypeIssue(node, path, "a list", issues)
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), issues)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			addTypeIssue(node, path, "a mapping", issues)
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), issues)
+		}
+
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			addTypeIssue(node, path, "a string", issues)
+		}
+
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			addTypeIssue(node, path, "a boolean (true/false)", issues)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			addTypeIssue(node, path, "an integer", issues)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			addTypeIssue(node, path, "a number", issues)
+		}
+	}
+}
+
+// checkVersions reports conflicting version options
+func checkVersions(doc *yaml.Node, issues *[]Issue) {
+	keyNode, versionsNode := lookupKey(doc, "versions")
+	if versionsNode == nil || versionsNode.Kind != yaml.SequenceNode || len(versionsNode.Content) == 0 {
+		return
+	}
+
+	var versions []Version
+	if err := versionsNode.Decode(&versions); err != nil {
+		return
+	}
+
+	latestCount := 0
+	seen := make(map[string]bool)
+	for i, v := range versions {
+		item := versionsNode.Content[i]
+		if v.IsLatest {
+			latestCount++
+		}
+		if v.Name == "" {
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: fmt.Sprintf("versions[%d]", i), Message: "version is missing a name"})
+			continue
+		}
+		if seen[v.Name] {
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: fmt.Sprintf("versions[%d]", i), Message: fmt.Sprintf("duplicate version name %q", v.Name)})
+		}
+		seen[v.Name] = true
+	}
+
+	switch {
+	case latestCount == 0:
+		*issues = append(*issues, Issue{Line: keyNode.Line, Column: keyNode.Column, Path: "versions", Message: "no version is marked isLatest: true"})
+	case latestCount > 1:
+		*issues = append(*issues, Issue{Line: keyNode.Line, Column: keyNode.Column, Path: "versions", Message: fmt.Sprintf("%d versions are marked isLatest: true, expected exactly one", latestCount)})
+	}
+}
+
+// checkMounts reports mounts that are missing a source or target
+func checkMounts(doc *yaml.Node, issues *[]Issue) {
+	_, mountsNode := lookupKey(doc, "mounts")
+	if mountsNode == nil || mountsNode.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, item := range mountsNode.Content {
+		var m Mount
+		if err := item.Decode(&m); err != nil {
+			continue
+		}
+		if m.Source == "" || m.Target == "" {
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: fmt.Sprintf("mounts[%d]", i), Message: "mount needs both source and target"})
+		}
+	}
+}
+
+// checkModules reports content modules without a url, or with a url or ref
+// git would take for an option
+func checkModules(doc *yaml.Node, issues *[]Issue) {
+	_, modulesNode := lookupKey(doc, "modules")
+	if modulesNode == nil || modulesNode.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, item := range modulesNode.Content {
+		var m ContentModule
+		if err := item.Decode(&m); err != nil {
+			continue
+		}
+		if err := m.Validate(); err != nil {
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: fmt.Sprintf("modules[%d]", i), Message: err.Error()})
+		}
+	}
+}
+
+// checkComments reports an unknown comments provider or missing provider settings
+func checkComments(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "comments")
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	var c CommentsConfig
+	if err := node.Decode(&c); err != nil || c.Provider == "" {
+		return
+	}
+
+	var required []string
+	switch strings.ToLower(c.Provider) {
+	case "giscus":
+		required = []string{"repo", "repoId", "category", "categoryId"}
+	case "utterances":
+		required = []string{"repo"}
+	case "isso":
+		required = []string{"url"}
+	default:
+		*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: "comments.provider", Message: fmt.Sprintf("unknown provider %q (expected giscus, utterances or isso)", c.Provider)})
+		return
+	}
+	for _, key := range required {
+		if _, v := lookupKey(node, key); v == nil || v.Value == "" {
+			*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: "comments", Message: fmt.Sprintf("%s comments need %q", c.Provider, key)})
+		}
+	}
+}
+
+// checkFediverse reports a creator handle that isn't of the form @user@instance
+func checkFediverse(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "fediverse")
+	if node == nil {
+		return
+	}
+	_, creator := lookupKey(node, "creator")
+	if creator == nil || creator.Kind != yaml.ScalarNode || creator.Value == "" || envPattern.MatchString(creator.Value) {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(creator.Value, "@"), "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.ContainsAny(creator.Value, "/: ") {
+		*issues = append(*issues, Issue{Line: creator.Line, Column: creator.Column, Path: "fediverse.creator", Message: fmt.Sprintf("invalid handle %q (expected @user@instance)", creator.Value)})
+	}
+}
+
+// checkAnalytics reports an unknown analytics provider or a missing id
+func checkAnalytics(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "analytics")
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	var a AnalyticsConfig
+	if err := node.Decode(&a); err != nil || a.Provider == "" {
+		return
+	}
+	switch strings.ToLower(a.Provider) {
+	case "plausible", "umami", "goatcounter", "ga4":
+		if a.ID == "" {
+			*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: "analytics", Message: fmt.Sprintf("%s analytics need an \"id\"", a.Provider)})
+		}
+	default:
+		*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: "analytics.provider", Message: fmt.Sprintf("unknown provider %q (expected plausible, umami, goatcounter or ga4)", a.Provider)})
+	}
+}
+
+// checkWellKnown reports a security.txt without a contact or with an
+// unparseable expiry date
+func checkWellKnown(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "wellKnown")
+	if node == nil {
+		return
+	}
+	_, sec := lookupKey(node, "securityTxt")
+	if sec == nil || sec.Kind != yaml.MappingNode {
+		return
+	}
+	var st SecurityTxtConfig
+	if err := sec.Decode(&st); err != nil {
+		return
+	}
+	if len(st.Contact) == 0 {
+		*issues = append(*issues, Issue{Line: sec.Line, Column: sec.Column, Path: "wellKnown.securityTxt", Message: "security.txt needs at least one \"contact\""})
+	}
+	if _, expires := lookupKey(sec, "expires"); expires != nil && expires.Value != "" && !envPattern.MatchString(expires.Value) {
+		_, errRFC := time.Parse(time.RFC3339, expires.Value)
+		_, errDate := time.Parse("2006-01-02", expires.Value)
+		if errRFC != nil && errDate != nil {
+			*issues = append(*issues, Issue{Line: expires.Line, Column: expires.Column, Path: "wellKnown.securityTxt.expires", Message: fmt.Sprintf("invalid date %q (expected RFC 3339 or YYYY-MM-DD)", expires.Value)})
+		}
+	}
+}
+
+// checkPWA reports unknown service worker hooks and routes without a pattern
+// or with an unknown caching strategy
+func checkPWA(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "pwa")
+	if node == nil {
+		return
+	}
+	if _, hooks := lookupKey(node, "hooks"); hooks != nil && hooks.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(hooks.Content); i += 2 {
+			key := hooks.Content[i]
+			switch key.Value {
+			case "head", "install", "activate", "fetch", "tail":
+			default:
+				*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "pwa.hooks", Message: fmt.Sprintf("unknown service worker hook %q (expected head, install, activate, fetch or tail)", key.Value)})
+			}
+		}
+	}
+
+	_, routes := lookupKey(node, "routes")
+	if routes == nil || routes.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, item := range routes.Content {
+		var r PWARoute
+		if err := item.Decode(&r); err != nil {
+			continue
+		}
+		path := fmt.Sprintf("pwa.routes[%d]", i)
+		if r.Pattern == "" {
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: path, Message: "route is missing a pattern"})
+		}
+		switch r.Strategy {
+		case "network-first", "cache-first", "stale-while-revalidate", "network-only", "cache-only":
+		default:
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: path, Message: fmt.Sprintf("unknown strategy %q (expected network-first, cache-first, stale-while-revalidate, network-only or cache-only)", r.Strategy)})
+		}
+	}
+}
+
+// checkStrict reports unknown check names in strict.checks and prose rules
+// without a valid pattern
+func checkStrict(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "strict")
+	if node == nil {
+		return
+	}
+	if _, list := lookupKey(node, "checks"); list != nil && list.Kind == yaml.SequenceNode {
+		for _, item := range list.Content {
+			switch item.Value {
+			case "missing-description", "invalid-frontmatter", "broken-ref", "broken-link", "oversized-image", "prose":
+			default:
+				*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: "strict.checks", Message: fmt.Sprintf("unknown check %q (expected missing-description, invalid-frontmatter, broken-ref, broken-link, oversized-image or prose)", item.Value)})
+			}
+		}
+	}
+	if _, rules := lookupKey(node, "prose"); rules != nil && rules.Kind == yaml.SequenceNode {
+		for i, rule := range rules.Content {
+			path := fmt.Sprintf("strict.prose[%d]", i)
+			_, pattern := lookupKey(rule, "pattern")
+			if pattern == nil || pattern.Value == "" {
+				*issues = append(*issues, Issue{Line: rule.Line, Column: rule.Column, Path: path, Message: "missing pattern"})
+				continue
+			}
+			if _, err := regexp.Compile(pattern.Value); err != nil {
+				*issues = append(*issues, Issue{Line: pattern.Line, Column: pattern.Column, Path: path + ".pattern", Message: fmt.Sprintf("invalid pattern: %v", err)})
+			}
+		}
+	}
+}
+
+// checkMarkdown reports an unknown raw HTML policy, heading anchor position
+// or heading id style, internal link domains written as URLs and typography
+// settings the typographer can't use
+func checkMarkdown(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "markdown")
+	if node == nil {
+		return
+	}
+	if _, policy := lookupKey(node, "rawHTML"); policy != nil && policy.Kind == yaml.ScalarNode {
+		switch policy.Value {
+		case "allow", "omit", "sanitize":
+		default:
+			*issues = append(*issues, Issue{Line: policy.Line, Column: policy.Column, Path: "markdown.rawHTML", Message: fmt.Sprintf("unknown raw HTML policy %q (expected allow, omit or sanitize)", policy.Value)})
+		}
+	}
+
+	if _, anchors := lookupKey(node, "headingAnchors"); anchors != nil {
+		if _, position := lookupKey(anchors, "position"); position != nil && position.Kind == yaml.ScalarNode && position.Value != "before" && position.Value != "after" {
+			*issues = append(*issues, Issue{Line: position.Line, Column: position.Column, Path: "markdown.headingAnchors.position", Message: fmt.Sprintf("unknown anchor position %q (expected before or after)", position.Value)})
+		}
+	}
+
+	if _, ids := lookupKey(node, "headingIDs"); ids != nil {
+		if _, style := lookupKey(ids, "style"); style != nil && style.Kind == yaml.ScalarNode {
+			switch style.Value {
+			case "default", "github", "unicode":
+			default:
+				*issues = append(*issues, Issue{Line: style.Line, Column: style.Column, Path: "markdown.headingIDs.style", Message: fmt.Sprintf("unknown heading id style %q (expected default, github or unicode)", style.Value)})
+			}
+		}
+	}
+
+	if _, links := lookupKey(node, "externalLinks"); links != nil {
+		if _, internal := lookupKey(links, "internal"); internal != nil && internal.Kind == yaml.SequenceNode {
+			for _, domain := range internal.Content {
+				if strings.Contains(domain.Value, "/") {
+					*issues = append(*issues, Issue{Line: domain.Line, Column: domain.Column, Path: "markdown.externalLinks.internal", Message: fmt.Sprintf("%q is not a domain (write it without scheme or path)", domain.Value)})
+				}
+			}
+		}
+	}
+
+	_, typography := lookupKey(node, "typography")
+	if typography == nil || typography.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(typography.Content); i += 2 {
+		lang, settings := typography.Content[i].Value, typography.Content[i+1]
+		path := "markdown.typography." + lang
+		if _, quotes := lookupKey(settings, "quotes"); quotes != nil && quotes.Kind == yaml.ScalarNode && utf8.RuneCountInString(quotes.Value) != 4 {
+			*issues = append(*issues, Issue{Line: quotes.Line, Column: quotes.Column, Path: path + ".quotes", Message: fmt.Sprintf("quotes %q must be 4 characters: double open, double close, single open, single close", quotes.Value)})
+		}
+		if _, dashes := lookupKey(settings, "dashes"); dashes != nil && dashes.Kind == yaml.ScalarNode {
+			switch dashes.Value {
+			case "en", "em", "none":
+			default:
+				*issues = append(*issues, Issue{Line: dashes.Line, Column: dashes.Column, Path: path + ".dashes", Message: fmt.Sprintf("unknown dash style %q (expected en, em or none)", dashes.Value)})
+			}
+		}
+	}
+}
+
+// checkSearch reports unknown search exporters, servers without a URL and
+// negative boosts
+func checkSearch(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "search")
+	if node == nil {
+		return
+	}
+	checkSearchBoost(node, issues)
+	_, exporters := lookupKey(node, "exporters")
+	if exporters == nil || exporters.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, exp := range exporters.Content {
+		path := fmt.Sprintf("search.exporters[%d]", i)
+		_, typ := lookupKey(exp, "type")
+		if typ == nil || typ.Kind != yaml.ScalarNode {
+			*issues = append(*issues, Issue{Line: exp.Line, Column: exp.Column, Path: path, Message: "missing exporter type (lunr, pagefind, meilisearch or typesense)"})
+			continue
+		}
+		switch typ.Value {
+		case "lunr", "pagefind":
+		case "meilisearch", "typesense":
+			if _, u := lookupKey(exp, "url"); u == nil || u.Value == "" {
+				*issues = append(*issues, Issue{Line: exp.Line, Column: exp.Column, Path: path + ".url", Message: fmt.Sprintf("the %s exporter needs the server url", typ.Value)})
+			}
+		default:
+			*issues = append(*issues, Issue{Line: typ.Line, Column: typ.Column, Path: path + ".type", Message: fmt.Sprintf("unknown search exporter %q (expected lunr, pagefind, meilisearch or typesense)", typ.Value)})
+		}
+	}
+}
+
+// checkSearchBoost reports negative search weights, which would push
+// matching pages below the ones that don't match
+func checkSearchBoost(search *yaml.Node, issues *[]Issue) {
+	_, boost := lookupKey(search, "boost")
+	if boost == nil {
+		return
+	}
+	report := func(node *yaml.Node, path string) {
+		if node == nil {
+			return
+		}
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil && f < 0 {
+			*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: path, Message: fmt.Sprintf("%s must not be negative, got %s", path, node.Value)})
+		}
+	}
+	for _, field := range []string{"title", "tags", "body"} {
+		_, node := lookupKey(boost, field)
+		report(node, "search.boost."+field)
+	}
+	if _, recency := lookupKey(boost, "recency"); recency != nil {
+		for _, field := range []string{"weight", "halfLife"} {
+			_, node := lookupKey(recency, field)
+			report(node, "search.boost.recency."+field)
+		}
+	}
+	if _, sections := lookupKey(boost, "sections"); sections != nil && sections.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(sections.Content); i += 2 {
+			report(sections.Content[i+1], "search.boost.sections."+sections.Content[i].Value)
+		}
+	}
+}
+
+// checkFeeds reports unknown feed formats
+func checkFeeds(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "feeds")
+	if node == nil {
+		return
+	}
+	_, list := lookupKey(node, "formats")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range list.Content {
+		switch item.Value {
+		case "rss", "atom", "json":
+		default:
+			*issues = append(*issues, Issue{Line: item.Line, Column: item.Column, Path: "feeds.formats", Message: fmt.Sprintf("unknown feed format %q (expected rss, atom or json)", item.Value)})
+		}
+	}
+}
+
+// checkSitemap reports a URL limit over the 50,000 sitemaps allow per file
+func checkSitemap(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "sitemap")
+	if node == nil {
+		return
+	}
+	_, limit := lookupKey(node, "maxURLs")
+	if limit == nil {
+		return
+	}
+	if n, err := strconv.Atoi(limit.Value); err == nil && (n < 0 || n > 50000) {
+		*issues = append(*issues, Issue{Line: limit.Line, Column: limit.Column, Path: "sitemap.maxURLs", Message: fmt.Sprintf("sitemap.maxURLs %d is out of range (1-50000, 0 for the default)", n)})
+	}
+}
+
+// checkPagination reports page sizes below one, which would leave the list
+// pages without posts
+func checkPagination(doc *yaml.Node, issues *[]Issue) {
+	report := func(node *yaml.Node, path string, zeroOK bool) {
+		if node == nil {
+			return
+		}
+		if n, err := strconv.Atoi(node.Value); err == nil && (n < 0 || n == 0 && !zeroOK) {
+			*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: path, Message: fmt.Sprintf("%s must be at least 1, got %d", path, n)})
+		}
+	}
+	_, perPage := lookupKey(doc, "postsPerPage")
+	report(perPage, "postsPerPage", false)
+	if _, node := lookupKey(doc, "pagination"); node != nil {
+		_, size := lookupKey(node, "pageSize")
+		report(size, "pagination.pageSize", true) // 0 keeps postsPerPage
+	}
+}
+
+// yamlFields maps the yaml key of each decodable field of a struct to the field
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func lookupKey(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func addTypeIssue(node *yaml.Node, path, want string, issues *[]Issue) {
+	*issues = append(*issues, Issue{
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf("expected %s, got %s", want, describeNode(node)),
+	})
+}
+
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// suggestKey returns the closest known key for a likely typo, or "" if none is close
+func suggestKey(key string, fields map[string]reflect.StructField) string {
+	const maxDistance = 2 // Only suggest for small typos
+
+	best := ""
+	bestDist := maxDistance + 1
+	lower := strings.ToLower(key)
+	for name := range fields {
+		d := editDistance(lower, strings.ToLower(name))
+		if d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	if bestDist > maxDistance {
+		return ""
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// checkAssets reports bundles that aren't .css or .js files, or that join
+// files of another kind
+func checkAssets(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "assets")
+	if node == nil {
+		return
+	}
+	_, bundles := lookupKey(node, "bundles")
+	if bundles == nil || bundles.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(bundles.Content); i += 2 {
+		key, files := bundles.Content[i], bundles.Content[i+1]
+		ext := strings.ToLower(filepath.Ext(key.Value))
+		if ext != ".css" && ext != ".js" {
+			*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "assets.bundles", Message: fmt.Sprintf("bundle %q must be a .css or .js file", key.Value)})
+			continue
+		}
+		if files.Kind != yaml.SequenceNode || len(files.Content) == 0 {
+			*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "assets.bundles." + key.Value, Message: "a bundle needs a list of files"})
+			continue
+		}
+		for _, f := range files.Content {
+			fext := strings.ToLower(filepath.Ext(f.Value))
+			if (ext == ".js" && fext != ".js") || (ext == ".css" && fext != ".css" && fext != ".scss" && fext != ".sass") {
+				*issues = append(*issues, Issue{Line: f.Line, Column: f.Column, Path: "assets.bundles." + key.Value, Message: fmt.Sprintf("%q can't be joined into a %s bundle", f.Value, ext)})
+			}
+		}
+	}
+}
+
+func checkImages(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "images")
+	if node == nil {
+		return
+	}
+	if key, widths := lookupKey(node, "widths"); widths != nil && widths.Kind == yaml.SequenceNode {
+		for _, w := range widths.Content {
+			if n, err := strconv.Atoi(w.Value); err != nil || n <= 0 {
+				*issues = append(*issues, Issue{Line: w.Line, Column: w.Column, Path: "images.widths", Message: fmt.Sprintf("width %q must be a positive number of pixels", w.Value)})
+			}
+		}
+		if _, compress := lookupKey(doc, "compressImages"); compress != nil && compress.Value == "false" && len(widths.Content) > 0 {
+			*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "images.widths", Message: "images.widths has no effect with compressImages: false"})
+		}
+	}
+	if _, formats := lookupKey(node, "formats"); formats != nil && formats.Kind == yaml.SequenceNode {
+		for _, f := range formats.Content {
+			if f.Value != "webp" && f.Value != "avif" {
+				*issues = append(*issues, Issue{Line: f.Line, Column: f.Column, Path: "images.formats", Message: fmt.Sprintf("unknown image format %q (want webp or avif)", f.Value)})
+			}
+		}
+	}
+	if _, quality := lookupKey(node, "quality"); quality != nil {
+		if n, err := strconv.Atoi(quality.Value); err != nil || n < 1 || n > 100 {
+			*issues = append(*issues, Issue{Line: quality.Line, Column: quality.Column, Path: "images.quality", Message: fmt.Sprintf("quality %q must be between 1 and 100", quality.Value)})
+		}
+	}
+}
+
+// deployRequired is the setting each deploy target type can't do without
+var deployRequired = map[string]string{
+	"s3":           "bucket",
+	"github-pages": "",
+	"netlify":      "site",
+	"rsync":        "dest",
+	"sftp":         "dest",
+}
+
+// checkDeploy reports deploy targets of unknown types, without the setting
+// their type needs, or sharing a name
+func checkDeploy(doc *yaml.Node, issues *[]Issue) {
+	_, targets := lookupKey(doc, "deploy")
+	if targets == nil || targets.Kind != yaml.SequenceNode {
+		return
+	}
+	names := map[string]bool{}
+	for i, target := range targets.Content {
+		path := fmt.Sprintf("deploy[%d]", i)
+		_, typ := lookupKey(target, "type")
+		if typ == nil || typ.Kind != yaml.ScalarNode {
+			*issues = append(*issues, Issue{Line: target.Line, Column: target.Column, Path: path, Message: "missing deploy target type (s3, github-pages, netlify, rsync or sftp)"})
+			continue
+		}
+		required, ok := deployRequired[typ.Value]
+		if !ok {
+			*issues = append(*issues, Issue{Line: typ.Line, Column: typ.Column, Path: path + ".type", Message: fmt.Sprintf("unknown deploy target type %q (expected s3, github-pages, netlify, rsync or sftp)", typ.Value)})
+			continue
+		}
+		if required != "" {
+			if _, v := lookupKey(target, required); v == nil || v.Value == "" {
+				*issues = append(*issues, Issue{Line: target.Line, Column: target.Column, Path: path + "." + required, Message: fmt.Sprintf("the %s deploy target needs %s", typ.Value, required)})
+			}
+		}
+		name := typ.Value
+		if _, n := lookupKey(target, "name"); n != nil && n.Value != "" {
+			name = n.Value
+		}
+		if names[name] {
+			*issues = append(*issues, Issue{Line: target.Line, Column: target.Column, Path: path, Message: fmt.Sprintf("deploy target %q is defined twice; give one a different name", name)})
+		}
+		names[name] = true
+	}
+}
+
+// checkLargeFiles reports largeFiles storage that isn't a file store, or
+// without the setting its type needs or the URL the files are served at
+func checkLargeFiles(doc *yaml.Node, issues *[]Issue) {
+	key, lf := lookupKey(doc, "largeFiles")
+	if lf == nil || lf.Kind != yaml.MappingNode {
+		return
+	}
+	_, storage := lookupKey(lf, "storage")
+	if storage == nil {
+		return
+	}
+	_, typ := lookupKey(storage, "type")
+	if typ == nil || typ.Kind != yaml.ScalarNode {
+		*issues = append(*issues, Issue{Line: storage.Line, Column: storage.Column, Path: "largeFiles.storage", Message: "missing storage type (s3, rsync or sftp)"})
+		return
+	}
+	if typ.Value != "s3" && typ.Value != "rsync" && typ.Value != "sftp" {
+		*issues = append(*issues, Issue{Line: typ.Line, Column: typ.Column, Path: "largeFiles.storage.type", Message: fmt.Sprintf("unknown storage type %q (expected s3, rsync or sftp)", typ.Value)})
+		return
+	}
+	if required := deployRequired[typ.Value]; required != "" {
+		if _, v := lookupKey(storage, required); v == nil || v.Value == "" {
+			*issues = append(*issues, Issue{Line: storage.Line, Column: storage.Column, Path: "largeFiles.storage." + required, Message: fmt.Sprintf("the %s storage needs %s", typ.Value, required)})
+		}
+	}
+	if _, u := lookupKey(lf, "url"); u == nil || u.Value == "" {
+		*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "largeFiles.url", Message: "largeFiles needs the url its storage serves the files at"})
+	}
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		wantLines []int
+		wantMsgs  []string
+	}{
+		{
+			name: "valid config",
+			yaml: `title: "My Site"
+postsPerPage: 5
+features:
+  generators:
+    rss: false
+versions:
+  - name: v2.0
+    isLatest: true
+  - name: v1.0
+    path: v1.0
+`,
+		},
+		{
+			name: "unknown key with suggestion",
+			yaml: `title: "My Site"
+tittle: "Typo"
+`,
+			wantLines: []int{2},
+			wantMsgs:  []string{`unknown key "tittle" (did you mean "title"?)`},
+		},
+		{
+			name: "unknown nested key",
+			yaml: `features:
+  generators:
+    sitemapz: true
+`,
+			wantLines: []int{3},
+			wantMsgs:  []string{`features.generators: unknown key "sitemapz"`},
+		},
+		{
+			name: "wrong types",
+			yaml: `postsPerPage: ten
+compressImages: "yes"
+menu: home
+`,
+			wantLines: []int{1, 2, 3},
+			wantMsgs:  []string{"expected an integer", "expected a boolean", "expected a list"},
+		},
+		{
+			name: "versions without latest",
+			yaml: `versions:
+  - name: v2.0
+  - name: v1.0
+`,
+			wantLines: []int{1},
+			wantMsgs:  []string{"no version is marked isLatest"},
+		},
+		{
+			name: "multiple latest and duplicate names",
+			yaml: `versions:
+  - name: v2.0
+    isLatest: true
+  - name: v2.0
+    isLatest: true
+`,
+			wantLines: []int{1, 4},
+			wantMsgs:  []string{"2 versions are marked isLatest", `duplicate version name "v2.0"`},
+		},
+		{
+			name: "incomplete comments provider",
+			yaml: `comments:
+  provider: utterances
+  theme: github-dark
+`,
+			wantLines: []int{2},
+			wantMsgs:  []string{`utterances comments need "repo"`},
+		},
+		{
+			name: "invalid fediverse handle",
+			yaml: `fediverse:
+  creator: "mastodon.social/@me"
+`,
+			wantLines: []int{2},
+			wantMsgs:  []string{"expected @user@instance"},
+		},
+		{
+			name: "security.txt without contact and bad expiry",
+			yaml: `wellKnown:
+  securityTxt:
+    expires: "next year"
+`,
+			wantLines: []int{3, 3},
+			wantMsgs:  []string{"needs at least one", "invalid date"},
+		},
+		{
+			name: "pwa route with unknown strategy",
+			yaml: `pwa:
+  routes:
+    - pattern: "\\.html$"
+      strategy: network-first
+    - pattern: "/static/"
+      strategy: cache-forever
+`,
+			wantLines: []int{5},
+			wantMsgs:  []string{"unknown strategy \"cache-forever\""},
+		},
+		{
+			name: "unknown service worker hook",
+			yaml: `pwa:
+  hooks:
+    fetch: sw/fetch.js
+    message: sw/message.js
+`,
+			wantLines: []int{4},
+			wantMsgs:  []string{"unknown service worker hook \"message\""},
+		},
+		{
+			name: "unknown strict check",
+			yaml: `strict:
+  checks: [broken-link, spelling]
+  maxImageKB: 300
+`,
+			wantLines: []int{2},
+			wantMsgs:  []string{"unknown check \"spelling\""},
+		},
+		{
+			name: "invalid prose rules",
+			yaml: `strict:
+  prose:
+    - pattern: "(?i)click here"
+      message: Use descriptive link text
+    - message: No pattern
+    - pattern: "simply("
+`,
+			wantLines: []int{5, 6},
+			wantMsgs:  []string{"missing pattern", "invalid pattern"},
+		},
+		{
+			name: "unknown feed format",
+			yaml: `feeds:
+  formats: [rss, atom, rdf]
+`,
+			wantLines: []int{2},
+			wantMsgs:  []string{"unknown feed format \"rdf\""},
+		},
+		{
+			name: "page sizes below one",
+			yaml: `postsPerPage: 0
+pagination:
+  pageSize: -2
+`,
+			wantLines: []int{1, 3},
+			wantMsgs:  []string{"postsPerPage must be at least 1", "pagination.pageSize must be at least 1"},
+		},
+		{
+			name: "sitemap URL limit out of range",
+			yaml: `sitemap:
+  maxURLs: 60000
+`,
+			wantLines: []int{2},
+			wantMsgs:  []string{"sitemap.maxURLs 60000 is out of range"},
+		},
+		{
+			name: "negative search boosts",
+			yaml: `search:
+  boost:
+    title: 2
+    tags: -1
+    recency:
+      weight: 0.5
+      halfLife: -30
+    sections:
+      docs: 1.5
+      archive: -0.5
+`,
+			wantLines: []int{4, 7, 10},
+			wantMsgs:  []string{"search.boost.tags must not be negative", "search.boost.recency.halfLife must not be negative", "search.boost.sections.archive must not be negative"},
+		},
+		{
+			name: "invalid audience names",
+			yaml: `audiences:
+  internal:
+    outputDir: public-internal
+  Partners/EU:
+    baseURL: https://eu.example.com
+  public: {}
+`,
+			wantLines: []int{4, 6},
+			wantMsgs:  []string{"invalid audience \"Partners/EU\"", "invalid audience \"public\""},
+		},
+		{
+			name: "markdown extensions",
+			yaml: `markdown:
+  footnotes: true
+  tables: false
+  rawHTML: strip
+`,
+			wantLines: []int{4},
+			wantMsgs:  []string{"unknown raw HTML policy \"strip\""},
+		},
+		{
+			name: "bad typography settings",
+			yaml: `markdown:
+  typography:
+    fr:
+      quotes: "«»"
+      nbsp: false
+    es:
+      dashes: long
+`,
+			wantLines: []int{4, 7},
+			wantMsgs:  []string{"must be 4 characters", "unknown dash style \"long\""},
+		},
+		{
+			name: "bad heading anchor position",
+			yaml: `markdown:
+  headingAnchors:
+    enabled: true
+    position: left
+`,
+			wantLines: []int{4},
+			wantMsgs:  []string{"unknown anchor position \"left\""},
+		},
+		{
+			name: "bad heading id style",
+			yaml: `markdown:
+  headingIDs:
+    style: slug
+`,
+			wantLines: []int{3},
+			wantMsgs:  []string{"unknown heading id style \"slug\""},
+		},
+		{
+			name: "external link domain as URL",
+			yaml: `markdown:
+  externalLinks:
+    internal:
+      - docs.example.com
+      - https://example.com/
+`,
+			wantLines: []int{5},
+			wantMsgs:  []string{"\"https://example.com/\" is not a domain"},
+		},
+		{
+			name: "bad languages",
+			yaml: `languages:
+  - code: en
+    default: true
+  - code: PT-BR
+  - code: en
+    default: true
+  - name: Deutsch
+`,
+			wantLines: []int{4, 5, 6, 7},
+			wantMsgs:  []string{"invalid language code \"PT-BR\"", "language \"en\" is listed twice", "more than one default language", "language without a code"},
+		},
+		{
+			name: "bad search exporters",
+			yaml: `search:
+  exporters:
+    - type: lunr
+    - type: algolia
+    - type: meilisearch
+      index: docs
+`,
+			wantLines: []int{4, 5},
+			wantMsgs:  []string{"unknown search exporter \"algolia\"", "needs the server url"},
+		},
+		{
+			name: "bad asset bundles",
+			yaml: `assets:
+  bundles:
+    js/app.js: [js/a.js, css/b.css]
+    css/site.css: [css/a.css, css/b.scss]
+    app.txt: [a.txt]
+`,
+			wantLines: []int{3, 5},
+			wantMsgs:  []string{"\"css/b.css\" can't be joined into a .js bundle", "bundle \"app.txt\" must be a .css or .js file"},
+		},
+		{
+			name: "bad responsive images",
+			yaml: `compressImages: false
+images:
+  widths: [480, -100]
+  formats: [webp, jxl]
+  quality: 120
+`,
+			wantLines: []int{3, 3, 4, 5},
+			wantMsgs:  []string{"no effect with compressImages: false", "width \"-100\" must be a positive number", "unknown image format \"jxl\"", "quality \"120\" must be between 1 and 100"},
+		},
+		{
+			name: "module ref taken for a git option",
+			yaml: `modules:
+  - url: https://github.com/org/docs.git
+    ref: --upload-pack=touch /tmp/pwned
+  - ref: main
+`,
+			wantLines: []int{2, 4},
+			wantMsgs:  []string{`module ref "--upload-pack=touch /tmp/pwned" must not start with "-"`, "module is missing a url"},
+		},
+		{
+			name: "bad deploy targets",
+			yaml: `deploy:
+  - type: s3
+    region: eu-west-1
+  - type: ftp
+  - type: netlify
+    site: abc
+  - name: netlify
+    type: rsync
+    dest: host:/srv
+`,
+			wantLines: []int{2, 4, 7},
+			wantMsgs:  []string{"the s3 deploy target needs bucket", "unknown deploy target type \"ftp\"", "deploy target \"netlify\" is defined twice"},
+		},
+		{
+			name: "bad large files storage",
+			yaml: `largeFiles:
+  thresholdKB: 5120
+  storage:
+    type: rsync
+`,
+			wantLines: []int{1, 4},
+			wantMsgs:  []string{"largeFiles needs the url", "the rsync storage needs dest"},
+		},
+		{
+			name: "large files on a host",
+			yaml: `largeFiles:
+  url: https://media.example.com
+  storage:
+    type: netlify
+    site: abc
+`,
+			wantLines: []int{4},
+			wantMsgs:  []string{"unknown storage type \"netlify\""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := Check([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if len(issues) != len(tt.wantLines) {
+				t.Fatalf("Check() returned %d issues, want %d: %v", len(issues), len(tt.wantLines), issues)
+			}
+			for i, issue := range issues {
+				if issue.Line != tt.wantLines[i] {
+					t.Errorf("issue %d line = %d, want %d", i, issue.Line, tt.wantLines[i])
+				}
+				if !strings.Contains(issue.String(), tt.wantMsgs[i]) {
+					t.Errorf("issue %d = %q, want it to contain %q", i, issue.String(), tt.wantMsgs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_InvalidYAML(t *testing.T) {
+	if _, err := Check([]byte("title: [unclosed")); err == nil {
+		t.Error("Check() should return an error for invalid YAML")
+	}
+}
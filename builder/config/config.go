@@ -2,18 +2,19 @@
 package config
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
-
-	"gopkg.in/yaml.v3"
 )
 
 // Global flag to track if we're in development mode
@@ -35,12 +36,401 @@ type Version struct {
 	Strategy string `yaml:"strategy"` // "snapshot" or "delta"
 }
 
+// Language is a locale of a multilingual site. Its pages live in
+// content/<code>/ and are built under /<code>/, except the default
+// language's, which are built at the site root.
+type Language struct {
+	Code    string `yaml:"code"`    // "en", "hi"; the content directory and URL prefix
+	Name    string `yaml:"name"`    // Shown in the language switcher, e.g. "हिन्दी" (default: the code)
+	Title   string `yaml:"title"`   // Site title in this language (default: title)
+	Default bool   `yaml:"default"` // Built at the site root (default: the first language)
+}
+
+// Mount maps an external directory into the content or static tree
+type Mount struct {
+	Source string `yaml:"source"` // External directory, e.g. "../shared-docs"
+	Target string `yaml:"target"` // Virtual location, e.g. "content/shared"
+}
+
+// ContentModule pulls content from a git repository at build time
+type ContentModule struct {
+	URL    string `yaml:"url"`
+	Ref    string `yaml:"ref"`    // Branch, tag or commit (default: remote HEAD)
+	Path   string `yaml:"path"`   // Subdirectory of the repository to use (default: root)
+	Target string `yaml:"target"` // Directory under contentDir (default: repository name)
+}
+
+// Validate reports a module without a url, or whose url or ref starts with
+// "-" and would reach git as an option
+func (m ContentModule) Validate() error {
+	switch {
+	case m.URL == "":
+		return fmt.Errorf("module is missing a url")
+	case strings.HasPrefix(m.URL, "-"):
+		return fmt.Errorf("module url %q must not start with \"-\"", m.URL)
+	case strings.HasPrefix(m.Ref, "-"):
+		return fmt.Errorf("module ref %q must not start with \"-\"", m.Ref)
+	}
+	return nil
+}
+
+// DataSource is a named remote URL exposed to templates via `data "name"`
+type DataSource struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// CommentsConfig configures the comment widget injected into post pages
+type CommentsConfig struct {
+	Provider   string            `yaml:"provider"`   // "giscus", "utterances" or "isso" (empty disables comments)
+	Repo       string            `yaml:"repo"`       // GitHub repository, e.g. "owner/name" (giscus, utterances)
+	RepoID     string            `yaml:"repoId"`     // giscus only
+	Category   string            `yaml:"category"`   // giscus only
+	CategoryID string            `yaml:"categoryId"` // giscus only
+	Mapping    string            `yaml:"mapping"`    // Page ↔ discussion mapping (default: "pathname")
+	Label      string            `yaml:"label"`      // Issue label (utterances)
+	Theme      string            `yaml:"theme"`      // Provider theme used when themeMap has no match
+	ThemeMap   map[string]string `yaml:"themeMap"`   // Site theme (data-theme) → provider theme
+	Lang       string            `yaml:"lang"`       // Widget language (giscus, isso)
+	URL        string            `yaml:"url"`        // Isso server, e.g. "https://comments.example.com"
+}
+
+// WebmentionsConfig configures receiving (via webmention.io) and sending webmentions
+type WebmentionsConfig struct {
+	Domain         string `yaml:"domain"`         // webmention.io domain (default: host of baseURL)
+	Token          string `yaml:"token"`          // webmention.io API token, e.g. "${WEBMENTION_IO_TOKEN}"
+	RefreshMinutes int    `yaml:"refreshMinutes"` // Minimum time between API requests (default: 60)
+	Send           bool   `yaml:"send"`           // Send webmentions for new outgoing links after each build
+}
+
+// FediverseConfig attributes the site to a fediverse (e.g. Mastodon) account
+type FediverseConfig struct {
+	Creator      string `yaml:"creator"`      // "@user@instance", emitted as the fediverse:creator meta tag
+	WebFinger    bool   `yaml:"webfinger"`    // Write .well-known/webfinger aliasing the site domain to Creator
+	DiscussLinks bool   `yaml:"discussLinks"` // Add a "discuss on Mastodon" link to posts
+}
+
+// SearchConfig exports the search index for other search frontends
+type SearchConfig struct {
+	Exporters []SearchExporter  `yaml:"exporters"`
+	Boost     SearchBoostConfig `yaml:"boost"`
+}
+
+// SearchBoostConfig tunes the relevance of the built-in search. The field
+// weights travel in search.bin to the client; recency and sections are
+// folded into a per-page multiplier when the index is built.
+type SearchBoostConfig struct {
+	Title    float64            `yaml:"title"` // Weight of title matches (default 1, 0 ignores titles)
+	Tags     float64            `yaml:"tags"`  // Weight of tag matches (default 1)
+	Body     float64            `yaml:"body"`  // Weight of BM25 matches in the text (default 1)
+	Recency  RecencyBoostConfig `yaml:"recency"`
+	Sections map[string]float64 `yaml:"sections"` // Multiplier by output path prefix, e.g. {"docs": 1.5, "blog/archive": 0.5}
+}
+
+// RecencyBoostConfig raises newer pages: a page dated at build time scores
+// 1+Weight times as much, decaying by half every HalfLife days
+type RecencyBoostConfig struct {
+	Weight   float64 `yaml:"weight"`   // 0 disables the boost
+	HalfLife int     `yaml:"halfLife"` // Days (default 180)
+}
+
+// SassConfig compiles the .scss and .sass stylesheets of the theme's static
+// directory with Dart Sass (https://sass-lang.com/install)
+type SassConfig struct {
+	Binary    string   `yaml:"binary"`    // Dart Sass executable (default: "sass" on the PATH)
+	LoadPaths []string `yaml:"loadPaths"` // Extra directories for @use and @import, e.g. "node_modules/bootstrap/scss"
+}
+
+// AssetsConfig tunes how the CSS and JS of the theme's static directory are
+// built
+type AssetsConfig struct {
+	Minify   *bool               `yaml:"minify"`   // Minify and fingerprint CSS and JS (default: compressImages)
+	BundleJS bool                `yaml:"bundleJS"` // Inline the imports of JS files (default: each file stands alone)
+	Bundles  map[string][]string `yaml:"bundles"`  // Extra outputs joining files of the static directory: "js/app.js": [js/a.js, js/b.js]
+	Manifest bool                `yaml:"manifest"` // Publish the asset map as /static/asset-manifest.json
+}
+
+// ImagesConfig makes compressed images responsive: each JPEG and PNG of the
+// static directories is also published at smaller widths (and as AVIF), and
+// Markdown images get a srcset choosing among them
+type ImagesConfig struct {
+	Widths  []int    `yaml:"widths"`  // Smaller widths to publish, e.g. [480, 800]; none turns srcset off
+	Formats []string `yaml:"formats"` // "webp" (always) and "avif", encoded with ffmpeg and offered in a <picture>
+	Sizes   string   `yaml:"sizes"`   // The sizes attribute (default: full width up to the image's own)
+	Quality int      `yaml:"quality"` // Encoder quality, 1-100 (default: 80)
+	Dedupe  bool     `yaml:"dedupe"`  // Publish each distinct static image once, under a content-hash name in static/media/
+}
+
+// Fingerprint identifies the settings that change the HTML of pages with
+// images; "" when responsive images are off
+func (i ImagesConfig) Fingerprint() string {
+	if len(i.Widths) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(i)
+	return string(data)
+}
+
+// SearchExporter is one search index export
+type SearchExporter struct {
+	Type   string `yaml:"type"`   // "lunr", "pagefind", "meilisearch" or "typesense"
+	Output string `yaml:"output"` // Path in the output dir: the Lunr file (default: "search/lunr.json") or the Pagefind bundle (default: "pagefind")
+	URL    string `yaml:"url"`    // Meilisearch/Typesense server, e.g. "https://search.example.com"
+	Index  string `yaml:"index"`  // Meilisearch index or Typesense collection (default: "kosh")
+	APIKey string `yaml:"apiKey"` // Admin API key, e.g. "${MEILI_MASTER_KEY}"
+}
+
+// DeployTarget is a place `kosh deploy` publishes the output directory to
+type DeployTarget struct {
+	Name string `yaml:"name"` // Picked with `kosh deploy <name>` (default: the type)
+	Type string `yaml:"type"` // "s3", "github-pages", "netlify", "rsync" or "sftp"
+
+	// s3
+	Bucket       string `yaml:"bucket"`
+	Region       string `yaml:"region"`       // Default: "us-east-1"
+	Prefix       string `yaml:"prefix"`       // Key prefix the site lives under, e.g. "blog/"
+	Endpoint     string `yaml:"endpoint"`     // S3-compatible storage, e.g. "https://<account>.r2.cloudflarestorage.com"
+	Distribution string `yaml:"distribution"` // CloudFront distribution to invalidate the changed paths of
+	AccessKey    string `yaml:"accessKey"`    // Default: $AWS_ACCESS_KEY_ID
+	SecretKey    string `yaml:"secretKey"`    // Default: $AWS_SECRET_ACCESS_KEY
+
+	// github-pages
+	Repo   string `yaml:"repo"`   // Git remote (default: the site's origin)
+	Branch string `yaml:"branch"` // Default: "gh-pages"
+
+	// netlify
+	Site  string `yaml:"site"`  // Site ID
+	Token string `yaml:"token"` // Personal access token, e.g. "${NETLIFY_AUTH_TOKEN}"
+
+	// rsync, sftp
+	Dest     string `yaml:"dest"`     // "user@host:/var/www/site", or a local directory for rsync
+	Port     int    `yaml:"port"`     // SSH port (default: 22)
+	Manifest string `yaml:"manifest"` // Deploy manifest path on the same host, outside the webroot (default: dest + ".kosh-deploy.json")
+}
+
+// DisplayName is the name a deploy target is picked and reported by
+func (t DeployTarget) DisplayName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Type
+}
+
+// LargeFilesConfig keeps big static files out of the output directory: they
+// are uploaded to external storage at build time and pages link them there
+type LargeFilesConfig struct {
+	ThresholdKB int          `yaml:"thresholdKB"` // Files at least this big move (default: 10240, 10 MB)
+	Extensions  []string     `yaml:"extensions"`  // Only files with these extensions, e.g. [.mp4, .zip] (default: any)
+	URL         string       `yaml:"url"`         // Where the storage serves the files, e.g. "https://media.example.com"
+	Storage     DeployTarget `yaml:"storage"`     // An s3 (R2 with endpoint), rsync or sftp target, like under deploy
+}
+
+// LargeFile reports whether a static file (slash-separated, relative to a
+// static directory) goes to largeFiles.storage instead of the output.
+// Stylesheets, scripts, WebAssembly and images the build compresses stay,
+// and so does everything in dev builds.
+func (cfg *Config) LargeFile(relPath string, size int64) bool {
+	lf := cfg.LargeFiles
+	if lf.URL == "" || lf.Storage.Type == "" || cfg.IsDev {
+		return false
+	}
+	threshold := lf.ThresholdKB
+	if threshold <= 0 {
+		threshold = 10240
+	}
+	if size < int64(threshold)*1024 {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if ext == ".gz" || ext == ".br" {
+		// Precompressed search WebAssembly stays with the pages that fetch it
+		if strings.EqualFold(filepath.Ext(strings.TrimSuffix(relPath, filepath.Ext(relPath))), ".wasm") {
+			return false
+		}
+	}
+	switch ext {
+	case ".css", ".scss", ".sass", ".js", ".mjs", ".wasm":
+		return false
+	case ".jpg", ".jpeg", ".png":
+		if cfg.CompressImages {
+			return false
+		}
+	}
+	if len(lf.Extensions) == 0 {
+		return true
+	}
+	for _, e := range lf.Extensions {
+		if strings.EqualFold("."+strings.TrimPrefix(e, "."), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyticsConfig selects the analytics snippet injected into every page
+type AnalyticsConfig struct {
+	Provider         string `yaml:"provider"`         // "plausible", "umami", "goatcounter" or "ga4" (empty disables analytics)
+	ID               string `yaml:"id"`               // Plausible domain, Umami website ID, GoatCounter code or GA4 measurement ID
+	Src              string `yaml:"src"`              // Script URL for self-hosted Plausible/Umami/GoatCounter
+	IgnoreDoNotTrack bool   `yaml:"ignoreDoNotTrack"` // Track visitors who send Do Not Track
+}
+
+// PaginationConfig splits the home, section and tag lists into pages at
+// /page/N/. Tag pages are only split when the theme has term.html or
+// list.html to link them.
+type PaginationConfig struct {
+	PageSize int `yaml:"pageSize"` // Posts per list page; replaces postsPerPage when set
+}
+
+// PreloadConfig adds preload hints for the critical resources of each page:
+// its stylesheet, fonts, hero image, module scripts and the search index on
+// the search page. A page's preload: frontmatter adds hints or turns them off.
+type PreloadConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Fonts   int  `yaml:"fonts"` // Fonts preloaded per page, from @font-face rules of the page's stylesheets (default: 2)
+}
+
+// CacheControlConfig is the site's HTTP caching policy: a Cache-Control
+// value per class of output file, used for the _headers file and by the dev
+// server. "none" sends no header for a class.
+type CacheControlConfig struct {
+	Headers bool   `yaml:"headers"` // Write a _headers file (Netlify, Cloudflare Pages)
+	Assets  string `yaml:"assets"`  // Fingerprinted files (name.<hash>.ext)
+	HTML    string `yaml:"html"`    // Pages
+	Feeds   string `yaml:"feeds"`   // Feeds, sitemaps, the search index and JSON files
+	Images  string `yaml:"images"`
+	Default string `yaml:"default"` // Everything else
+}
+
+// SitemapConfig tunes sitemap/sitemap.xml, written when
+// features.generators.sitemap is on
+type SitemapConfig struct {
+	Exclude []string `yaml:"exclude"` // URL path patterns left out, e.g. "/drafts/*" or "/internal/**"
+	MaxURLs int      `yaml:"maxURLs"` // URLs per file; bigger sitemaps are split under a sitemap index (default: 50000)
+}
+
+// RobotsConfig writes robots.txt, listing the sitemap
+type RobotsConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	UserAgent string   `yaml:"userAgent"` // default: *
+	Disallow  []string `yaml:"disallow"`
+	Allow     []string `yaml:"allow"`
+	Extra     string   `yaml:"extra"` // Appended as written, e.g. rules for other user agents
+}
+
+// FeedsConfig configures the feeds written when features.generators.rss is
+// on. Drafts are never syndicated, and the site feed lists the latest
+// version only.
+type FeedsConfig struct {
+	Formats  []string `yaml:"formats"`  // "rss" (rss.xml), "atom" (atom.xml), "json" (feed.json) (default: [rss])
+	Limit    int      `yaml:"limit"`    // Newest entries per feed, 0 for all
+	Tags     bool     `yaml:"tags"`     // A feed per tag under /tags/<tag>/
+	Versions bool     `yaml:"versions"` // A feed per older documentation version under /<version>/
+}
+
+// StatusPageConfig is the dev server's content status page at /__status/
+type StatusPageConfig struct {
+	Disabled    bool `yaml:"disabled"`
+	StaleMonths int  `yaml:"staleMonths"` // Pages not updated in this many months are stale; 0 lists none (default: 12)
+}
+
+// WellKnownConfig generates files under /.well-known/
+type WellKnownConfig struct {
+	SecurityTxt    SecurityTxtConfig `yaml:"securityTxt"`
+	ChangePassword string            `yaml:"changePassword"` // URL that /.well-known/change-password redirects to
+}
+
+// SecurityTxtConfig holds the fields of an RFC 9116 security.txt.
+// The file is only written when Contact is set.
+type SecurityTxtConfig struct {
+	Contact            []string `yaml:"contact"`            // Email addresses or URLs; bare addresses get mailto:
+	Expires            string   `yaml:"expires"`            // RFC 3339 time or YYYY-MM-DD (default: one year after the build)
+	Encryption         string   `yaml:"encryption"`         // URL of a PGP key
+	Policy             string   `yaml:"policy"`             // URL of the disclosure policy
+	Acknowledgments    string   `yaml:"acknowledgments"`    // URL of the hall of fame
+	Hiring             string   `yaml:"hiring"`             // URL of security job openings
+	PreferredLanguages []string `yaml:"preferredLanguages"` // e.g. ["en", "de"]
+}
+
+// PWAConfig customizes the generated service worker
+type PWAConfig struct {
+	OfflinePage     string            `yaml:"offlinePage"`     // Page served when a navigation fails offline, e.g. "offline.html"
+	Routes          []PWARoute        `yaml:"routes"`          // Runtime caching rules, first match wins (default: built-in rules)
+	Icon            string            `yaml:"icon"`            // Source image for every icon, ideally 512px+ and square (default: logo, then theme favicon)
+	ShortName       string            `yaml:"shortName"`       // Home screen name (default: title)
+	ThemeColor      string            `yaml:"themeColor"`      // Browser UI color (default: #111113)
+	BackgroundColor string            `yaml:"backgroundColor"` // Splash screen and maskable icon background (default: #111113)
+	Display         string            `yaml:"display"`         // standalone, minimal-ui, fullscreen or browser (default: standalone)
+	Hooks           map[string]string `yaml:"hooks"`           // Service worker extension point (head, install, activate, fetch, tail) → JS file
+}
+
+// PWARoute applies a caching strategy to requests whose path matches Pattern
+type PWARoute struct {
+	Pattern    string `yaml:"pattern"`    // JavaScript regular expression tested against the URL path
+	Strategy   string `yaml:"strategy"`   // network-first, cache-first, stale-while-revalidate, network-only or cache-only
+	CacheName  string `yaml:"cacheName"`  // Runtime cache to store responses in (default: "runtime")
+	MaxEntries int    `yaml:"maxEntries"` // Evict the oldest responses beyond this many (0 = unlimited)
+}
+
+// StrictConfig configures which problems fail a --strict build
+type StrictConfig struct {
+	Checks     []string    `yaml:"checks"`     // missing-description, invalid-frontmatter, broken-ref, broken-link, oversized-image, prose (default: all)
+	MaxImageKB int         `yaml:"maxImageKB"` // Images larger than this are oversized (default: 500)
+	Prose      []ProseRule `yaml:"prose"`      // Wording the prose check flags in page text
+}
+
+// ProseRule flags text matching a regular expression, outside code and
+// frontmatter
+type ProseRule struct {
+	Pattern string `yaml:"pattern"` // e.g. "(?i)\\bclick here\\b"
+	Message string `yaml:"message"` // Shown with each match
+}
+
+// DraftPreviewsConfig builds drafts at unguessable URLs that can be shared
+// with reviewers without publishing the draft
+type DraftPreviewsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"` // Output directory of the previews (default: "preview")
+}
+
+// DevDraftsPath is where the dev server serves drafts that aren't part of the
+// build, at their usual path: /drafts/posts/wip.html
+const DevDraftsPath = "/drafts/"
+
+// DevDraftsDir is where dev builds render drafts that aren't part of the
+// build. It is outside the output directory, so they never reach a deploy.
+func (cfg *Config) DevDraftsDir() string {
+	return filepath.Join(cfg.CacheDir, "drafts")
+}
+
+// DevStatusPath is where the dev server serves the content status page
+const DevStatusPath = "/__status/"
+
+// DevStatusFile is where dev builds write the content status page. Like
+// DevDraftsDir it is outside the output directory: the page lists drafts
+// and future posts, which a deploy mustn't publish.
+func (cfg *Config) DevStatusFile() string {
+	return filepath.Join(cfg.CacheDir, "status.html")
+}
+
+// IsScheduled reports whether a page with the given publishDate and
+// expiryDate (zero when unset) is published now: its publishDate has come,
+// or IncludeFuture is set, and its expiryDate hasn't
+func (cfg *Config) IsScheduled(publish, expiry time.Time) bool {
+	now := time.Now()
+	if !publish.IsZero() && publish.After(now) && !cfg.IncludeFuture {
+		return false
+	}
+	return expiry.IsZero() || expiry.After(now)
+}
+
 type GeneratorsConfig struct {
 	Sitemap bool `yaml:"sitemap"`
 	RSS     bool `yaml:"rss"`
 	Graph   bool `yaml:"graph"`
 	PWA     bool `yaml:"pwa"`
 	Search  bool `yaml:"search"`
+	API     bool `yaml:"api"` // JSON listings under /api/ for client-side themes
 }
 
 type FeaturesConfig struct {
@@ -48,14 +438,94 @@ type FeaturesConfig struct {
 	Generators  GeneratorsConfig `yaml:"generators"`
 }
 
+// MarkdownConfig selects the goldmark extensions posts are rendered with.
+// It is part of the cache fingerprint, so changing it re-renders every post.
+type MarkdownConfig struct {
+	Tables          bool   `yaml:"tables"`          // GFM tables (default: true)
+	Strikethrough   bool   `yaml:"strikethrough"`   // ~~text~~ (default: true)
+	TaskLists       bool   `yaml:"taskLists"`       // - [ ] items (default: true)
+	Linkify         bool   `yaml:"linkify"`         // Bare URLs become links (default: true)
+	DefinitionLists bool   `yaml:"definitionLists"` // PHP Markdown Extra definition lists
+	Footnotes       bool   `yaml:"footnotes"`       // [^1] footnotes
+	Typographer     bool   `yaml:"typographer"`     // Smart quotes, dashes and ellipses
+	HardWraps       bool   `yaml:"hardWraps"`       // Line breaks in a paragraph become <br>
+	RawHTML         string `yaml:"rawHTML"`         // "allow" (default) passes HTML in markdown through, "omit" drops it, "sanitize" keeps the allowlisted parts
+
+	// Allowlist for rawHTML: sanitize, on top of the built-in one
+	Sanitize SanitizeConfig `yaml:"sanitize"`
+
+	// Typographer settings per language code ("fr", "de-CH"), on top of the
+	// built-in ones; a page's lang frontmatter or the site language picks one
+	Typography map[string]TypographyConfig `yaml:"typography"`
+
+	// Permalink anchors rendered into headings
+	HeadingAnchors HeadingAnchorsConfig `yaml:"headingAnchors"`
+
+	// How heading ids are made from heading text
+	HeadingIDs HeadingIDsConfig `yaml:"headingIDs"`
+
+	// How links to other sites are marked
+	ExternalLinks ExternalLinksConfig `yaml:"externalLinks"`
+}
+
+// ExternalLinksConfig decorates links whose host isn't the site's. Links to
+// the Internal domains are left as they are.
+type ExternalLinksConfig struct {
+	Target   string   `yaml:"target"`   // Target window (default: "_blank"; "none" opens in the same tab)
+	Rel      string   `yaml:"rel"`      // rel values, e.g. "noopener nofollow" (default: "noopener noreferrer"; "none" for none)
+	Class    string   `yaml:"class"`    // Class added to the link, e.g. for an external-link icon
+	Internal []string `yaml:"internal"` // Domains treated as the site's own, subdomains included
+}
+
+// HeadingAnchorsConfig renders a link to each heading's id inside the heading
+type HeadingAnchorsConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Position  string `yaml:"position"`  // "after" (default) or "before" the heading text
+	Symbol    string `yaml:"symbol"`    // Link content, may be HTML such as an SVG icon (default: "#")
+	Class     string `yaml:"class"`     // Class of the link (default: "heading-anchor")
+	AriaLabel string `yaml:"ariaLabel"` // Accessible name; {heading} is the heading text (default: "Link to this section: {heading}")
+	Levels    []int  `yaml:"levels"`    // Heading levels given anchors (default: 2-6, like the TOC)
+}
+
+// HeadingIDsConfig picks how a heading's id is derived from its text. An
+// id written as {#id} after the heading is always kept as it is.
+type HeadingIDsConfig struct {
+	Style         string `yaml:"style"`         // "default" (ASCII letters and digits), "github" (GitHub's anchors) or "unicode" (letters of any script, runs of other characters as one "-")
+	SectionPrefix bool   `yaml:"sectionPrefix"` // Prefix ids with the id of the enclosing heading: "install-linux" for ### Linux under ## Install
+}
+
+// SanitizeConfig is what the sanitize raw HTML policy keeps. Event handler
+// attributes and javascript: URLs are removed whatever it allows.
+type SanitizeConfig struct {
+	Tags       []string            `yaml:"tags"`       // Elements kept; replaces the built-in list
+	Attributes map[string][]string `yaml:"attributes"` // Attributes kept per element, "*" for every element; replaces the built-in list of that element
+}
+
+// TypographyConfig is how the typographer punctuates one language
+type TypographyConfig struct {
+	Quotes string `yaml:"quotes"` // Opening and closing double quotes, then single quotes, e.g. "«»‹›"
+	Dashes string `yaml:"dashes"` // "en": -- is an en dash, --- an em dash (default); "em": both are em dashes; "none"
+	NBSP   *bool  `yaml:"nbsp"`   // Non-breaking spaces before ; : ! ? and inside « » (default: on for French)
+}
+
+// Fingerprint identifies the extension set for the cache ID
+func (m MarkdownConfig) Fingerprint() string {
+	data, _ := json.Marshal(m) // Map keys are sorted
+	return string(data)
+}
+
 type AuthorConfig struct {
 	Name string `yaml:"name"`
 	URL  string `yaml:"url"`
 }
 
+// ThemeConfig is a theme's theme.yaml
 type ThemeConfig struct {
 	Name               string `yaml:"name"`
 	SupportsVersioning bool   `yaml:"supportsVersioning"`
+	Description        string `yaml:"description"`
+	Version            string `yaml:"version"`
+	Homepage           string `yaml:"homepage"`
 }
 
 type SocialCardsConfig struct {
@@ -65,36 +535,91 @@ type SocialCardsConfig struct {
 	TextColor  string   `yaml:"textColor"`
 }
 
+// WorkersConfig sets the size of each post processing pool. 0 uses the
+// CPU-based default; parsing is mostly IO-bound while social cards are
+// CPU-heavy, so the best values differ per site and machine.
+type WorkersConfig struct {
+	Parse  int `yaml:"parse"`  // Markdown parsing and cache lookups
+	Render int `yaml:"render"` // Page template rendering
+	Cards  int `yaml:"cards"`  // Social card generation
+}
+
 type Config struct {
-	Title          string            `yaml:"title"`
-	Description    string            `yaml:"description"`
-	BaseURL        string            `yaml:"baseURL"`
-	Language       string            `yaml:"language"`
-	Author         AuthorConfig      `yaml:"author"`
-	Menu           []MenuEntry       `yaml:"menu"`
-	PostsPerPage   int               `yaml:"postsPerPage"`
-	CompressImages bool              `yaml:"compressImages"`
-	ImageWorkers   int               `yaml:"imageWorkers"` // Number of parallel image workers (default: 24)
-	Theme          string            `yaml:"theme"`
-	ThemeDir       string            `yaml:"themeDir"`
-	TemplateDir    string            `yaml:"templateDir"`
-	StaticDir      string            `yaml:"staticDir"`
-	Logo           string            `yaml:"logo"`     // Path to site logo/favicon
-	Versions       []Version         `yaml:"versions"` // Documentation versions
-	Features       FeaturesConfig    `yaml:"features"` // Enable/Disable features
-	ThemeMetadata  ThemeConfig       `yaml:"-"`        // Loaded from theme.yaml
-	SocialCards    SocialCardsConfig `yaml:"socialCards"`
+	Title           string                    `yaml:"title"`
+	Description     string                    `yaml:"description"`
+	BaseURL         string                    `yaml:"baseURL"`
+	Language        string                    `yaml:"language"`
+	Author          AuthorConfig              `yaml:"author"`
+	Menu            []MenuEntry               `yaml:"menu"`
+	PostsPerPage    int                       `yaml:"postsPerPage"`
+	Pagination      PaginationConfig          `yaml:"pagination"`
+	CompressImages  bool                      `yaml:"compressImages"`
+	ImageWorkers    int                       `yaml:"imageWorkers"` // Number of parallel image workers (default: 24)
+	Workers         WorkersConfig             `yaml:"workers"`      // Per-pool worker counts for post processing
+	Theme           string                    `yaml:"theme"`
+	ThemeDir        string                    `yaml:"themeDir"`
+	TemplateDir     string                    `yaml:"templateDir"`
+	SiteTemplateDir string                    `yaml:"-"` // The site's templates/, shadowing the theme's files
+	StaticDir       string                    `yaml:"staticDir"`
+	Logo            string                    `yaml:"logo"`      // Path to site logo/favicon
+	Versions        []Version                 `yaml:"versions"`  // Documentation versions
+	Languages       []Language                `yaml:"languages"` // Locales of a multilingual site
+	Features        FeaturesConfig            `yaml:"features"`  // Enable/Disable features
+	Markdown        MarkdownConfig            `yaml:"markdown"`  // Markdown extensions
+	ThemeMetadata   ThemeConfig               `yaml:"-"`         // Loaded from theme.yaml
+	SocialCards     SocialCardsConfig         `yaml:"socialCards"`
+	Mounts          []Mount                   `yaml:"mounts"`  // External directories mounted into content/static
+	Modules         []ContentModule           `yaml:"modules"` // Git repositories merged into the content tree
+	Data            []DataSource              `yaml:"data"`    // Remote data fetched at build time
+	Comments        CommentsConfig            `yaml:"comments"`
+	CSP             string                    `yaml:"contentSecurityPolicy"` // Emitted as a meta tag on every page, with the comment widget's sources added
+	Webmentions     WebmentionsConfig         `yaml:"webmentions"`
+	Fediverse       FediverseConfig           `yaml:"fediverse"`
+	Analytics       AnalyticsConfig           `yaml:"analytics"`
+	Preload         PreloadConfig             `yaml:"preload"`
+	CacheControl    CacheControlConfig        `yaml:"cacheControl"`
+	StatusPage      StatusPageConfig          `yaml:"statusPage"`
+	Feeds           FeedsConfig               `yaml:"feeds"`
+	Sitemap         SitemapConfig             `yaml:"sitemap"`
+	Robots          RobotsConfig              `yaml:"robots"`
+	WellKnown       WellKnownConfig           `yaml:"wellKnown"`
+	PWA             PWAConfig                 `yaml:"pwa"`
+	Strict          StrictConfig              `yaml:"strict"`
+	TagRedirects    map[string]string         `yaml:"tagRedirects"` // Old tag -> new tag, written by kosh tags rename/merge
+	DraftPreviews   DraftPreviewsConfig       `yaml:"draftPreviews"`
+	Audiences       map[string]AudienceConfig `yaml:"audiences"` // Output settings of --audience variants
+	Layouts         map[string]string         `yaml:"layouts"`   // Content section ("docs", "blog/notes") → default layout of its pages
+	Search          SearchConfig              `yaml:"search"`
+	Sass            SassConfig                `yaml:"sass"`
+	Assets          AssetsConfig              `yaml:"assets"`
+	Images          ImagesConfig              `yaml:"images"`
+	Deploy          []DeployTarget            `yaml:"deploy"` // Where kosh deploy publishes the output; the first is the default
+	LargeFiles      LargeFilesConfig          `yaml:"largeFiles"`
 
 	// Configurable directory paths
-	ContentDir string `yaml:"contentDir"` // Content source directory (default: "content")
-	OutputDir  string `yaml:"outputDir"`  // Build output directory (default: "public")
-	CacheDir   string `yaml:"cacheDir"`   // Cache directory (default: ".kosh-cache")
+	ContentDir  string `yaml:"contentDir"`  // Content source directory (default: "content")
+	IncludesDir string `yaml:"includesDir"` // Markdown fragments for {{< include >}} (default: "includes")
+	OutputDir   string `yaml:"outputDir"`   // Build output directory (default: "public")
+	CacheDir    string `yaml:"cacheDir"`    // Cache directory (default: ".kosh-cache")
+	LinkDest    string `yaml:"linkDest"`    // Previous output to link unchanged files from (e.g. the last release)
 
 	// Internal / Runtime fields
-	ForceRebuild  bool  `yaml:"-"`
-	IncludeDrafts bool  `yaml:"-"`
-	BuildVersion  int64 `yaml:"-"`
-	IsDev         bool  `yaml:"-"`
+	ForceRebuild  bool   `yaml:"-"`
+	IncludeDrafts bool   `yaml:"-"`
+	IncludeFuture bool   `yaml:"-"` // Build posts whose publishDate hasn't come yet
+	BuildVersion  int64  `yaml:"-"`
+	IsDev         bool   `yaml:"-"`
+	Offline       bool   `yaml:"-"` // Only use cached remote data, never hit the network
+	LowMemory     bool   `yaml:"-"` // Write straight to disk and spool search data, trading speed for memory
+	Only          string `yaml:"-"` // Absolute content path a scoped build (--only) is limited to
+	SlowPages     int    `yaml:"-"` // Print the N slowest pages after the build
+	SlowPagesJSON string `yaml:"-"` // Write the slowest pages to this JSON file
+	Report        string `yaml:"-"` // Write a build report here (HTML for .html, JSON otherwise)
+	StrictMode    bool   `yaml:"-"` // Fail the build on the problems listed in strict.checks
+	MaxErrors     int    `yaml:"-"` // Print only the first N errors and fail the build beyond them (0 = no limit)
+	FailFast      bool   `yaml:"-"` // Stop the build at the first error
+	ErrorSummary  string `yaml:"-"` // Write the grouped error summary to this JSON file
+	Audience      string `yaml:"-"` // Build variant (--audience); "" is the public site
 
 	// Build configuration (loaded from kosh.build.yaml)
 	Build *BuildConfig `yaml:"-"`
@@ -112,6 +637,7 @@ func Load(args []string) *Config {
 		Theme:          "blog",
 		ThemeDir:       "themes",
 		ContentDir:     "content",
+		IncludesDir:    "includes",
 		OutputDir:      "public",
 		CacheDir:       ".kosh-cache",
 		Features: FeaturesConfig{
@@ -124,6 +650,28 @@ func Load(args []string) *Config {
 				Search:  true,
 			},
 		},
+		Markdown: MarkdownConfig{
+			Tables:        true,
+			Strikethrough: true,
+			TaskLists:     true,
+			Linkify:       true,
+			RawHTML:       "allow",
+		},
+		Preload: PreloadConfig{Fonts: 2},
+		CacheControl: CacheControlConfig{
+			Assets:  "public, max-age=31536000, immutable",
+			HTML:    "public, max-age=0, must-revalidate",
+			Feeds:   "public, max-age=3600",
+			Images:  "public, max-age=604800",
+			Default: "public, max-age=3600",
+		},
+		StatusPage: StatusPageConfig{StaleMonths: 12},
+		Feeds:      FeedsConfig{Formats: []string{"rss"}},
+		Sitemap:    SitemapConfig{MaxURLs: 50000},
+		Search: SearchConfig{Boost: SearchBoostConfig{
+			Title: 1, Tags: 1, Body: 1,
+			Recency: RecencyBoostConfig{HalfLife: 180},
+		}},
 		SocialCards: SocialCardsConfig{
 			Background: "#faf8f5",
 			Gradient:   []string{"#e8e0d0", "#d4c4a8"},
@@ -132,27 +680,24 @@ func Load(args []string) *Config {
 		},
 	}
 
-	// 2. Load from YAML file if exists
+	// 2. Load from YAML file if exists (${VAR} / ${VAR:-default} are expanded)
 	if data, err := os.ReadFile("kosh.yaml"); err == nil {
-		if err := yaml.Unmarshal(data, cfg); err != nil {
-			fmt.Printf("⚠️ Failed to parse kosh.yaml: %v\n", err)
+		if err := unmarshalWithEnv(data, cfg); err != nil {
+			logging.Statusf("⚠️ Failed to parse kosh.yaml: %v", err)
 		}
 	} else {
 		// Try fallback to config.yaml
 		if data, err := os.ReadFile("config.yaml"); err == nil {
-			if err := yaml.Unmarshal(data, cfg); err != nil {
-				fmt.Printf("⚠️ Failed to parse config.yaml: %v\n", err)
+			if err := unmarshalWithEnv(data, cfg); err != nil {
+				logging.Statusf("⚠️ Failed to parse config.yaml: %v", err)
 			}
 		}
 	}
 
-	// Validate and set defaults for ImageWorkers
-	if cfg.ImageWorkers <= 0 {
-		cfg.ImageWorkers = 24
-	}
-	// Cap at reasonable maximum to prevent resource exhaustion
-	if cfg.ImageWorkers > 32 {
-		cfg.ImageWorkers = 32
+	if cfg.Pagination.PageSize > 0 {
+		cfg.PostsPerPage = cfg.Pagination.PageSize
+	} else if cfg.PostsPerPage <= 0 {
+		cfg.PostsPerPage = 10
 	}
 
 	// Load build configuration from kosh.build.yaml
@@ -177,6 +722,10 @@ func Load(args []string) *Config {
 		cfg.TemplateDir = utils.NormalizePath(cfg.TemplateDir)
 	}
 
+	if abs, err := filepath.Abs(SiteTemplatesDir); err == nil {
+		cfg.SiteTemplateDir = utils.NormalizePath(abs)
+	}
+
 	if cfg.StaticDir == "" {
 		// Default: themes/<theme>/static
 		cfg.StaticDir = filepath.Join(cfg.ThemeDir, cfg.Theme, "static")
@@ -196,6 +745,13 @@ func Load(args []string) *Config {
 		cfg.ContentDir = utils.NormalizePath(abs)
 	}
 
+	if cfg.IncludesDir == "" {
+		cfg.IncludesDir = "includes"
+	}
+	if abs, err := filepath.Abs(cfg.IncludesDir); err == nil {
+		cfg.IncludesDir = utils.NormalizePath(abs)
+	}
+
 	if cfg.OutputDir == "" {
 		cfg.OutputDir = "public"
 	}
@@ -210,22 +766,89 @@ func Load(args []string) *Config {
 		cfg.CacheDir = utils.NormalizePath(abs)
 	}
 
-	// 3. Override with CLI Flags
-	fs := flag.NewFlagSet("config", flag.ContinueOnError)
-	baseUrlFlag := fs.String("baseurl", "", "Base URL (overrides config file)")
-	draftsFlag := fs.Bool("drafts", false, "Include draft posts in the build")
-	themeFlag := fs.String("theme", "", "Theme to use (overrides config file)")
+	// Resolve mount paths relative to the site root
+	for i := range cfg.Mounts {
+		if abs, err := filepath.Abs(cfg.Mounts[i].Source); err == nil {
+			cfg.Mounts[i].Source = utils.NormalizePath(abs)
+		}
+		if abs, err := filepath.Abs(cfg.Mounts[i].Target); err == nil {
+			cfg.Mounts[i].Target = utils.NormalizePath(abs)
+		}
+	}
 
+	// 3. Override with CLI Flags
+	fs, f := newFlagSet()
 	_ = fs.Parse(args)
 
-	if *baseUrlFlag != "" {
-		cfg.BaseURL = strings.TrimSuffix(*baseUrlFlag, "/")
+	if a := strings.ToLower(strings.TrimSpace(*f.audience)); a != "" && a != PublicAudience {
+		cfg.applyAudience(a, *f.baseURL == "")
+	}
+	if *f.baseURL != "" {
+		cfg.BaseURL = strings.TrimSuffix(*f.baseURL, "/")
 	}
-	if *draftsFlag {
+	if *f.drafts {
 		cfg.IncludeDrafts = true
 	}
-	if *themeFlag != "" {
-		cfg.Theme = *themeFlag
+	if *f.draftPreviews {
+		cfg.DraftPreviews.Enabled = true
+	}
+	if *f.future {
+		cfg.IncludeFuture = true
+	}
+	if cfg.DraftPreviews.Dir == "" {
+		cfg.DraftPreviews.Dir = "preview"
+	}
+	if *f.offline {
+		cfg.Offline = true
+	}
+	if *f.lowMemory {
+		cfg.LowMemory = true
+	}
+	if *f.only != "" {
+		cfg.Only = resolveOnly(cfg.ContentDir, *f.only)
+	}
+	if *f.slowPages > 0 {
+		cfg.SlowPages = *f.slowPages
+	}
+	cfg.SlowPagesJSON = *f.slowPagesJSON
+	cfg.Report = *f.report
+	cfg.StrictMode = *f.strict
+	if *f.maxErrors > 0 {
+		cfg.MaxErrors = *f.maxErrors
+	}
+	cfg.FailFast = *f.failFast
+	if *f.linkDest != "" {
+		cfg.LinkDest = *f.linkDest
+	}
+	if cfg.LinkDest != "" {
+		if abs, err := filepath.Abs(cfg.LinkDest); err == nil {
+			cfg.LinkDest = utils.NormalizePath(abs)
+		}
+	}
+	cfg.ErrorSummary = *f.errorSummary
+	if *f.parseWorkers > 0 {
+		cfg.Workers.Parse = *f.parseWorkers
+	}
+	if *f.renderWorkers > 0 {
+		cfg.Workers.Render = *f.renderWorkers
+	}
+	if *f.cardWorkers > 0 {
+		cfg.Workers.Cards = *f.cardWorkers
+	}
+	if *f.imageWorkers > 0 {
+		cfg.ImageWorkers = *f.imageWorkers
+	}
+
+	// Validate and set defaults for ImageWorkers
+	if cfg.ImageWorkers <= 0 {
+		cfg.ImageWorkers = 24
+	}
+	// Cap at reasonable maximum to prevent resource exhaustion
+	if cfg.ImageWorkers > 32 {
+		cfg.ImageWorkers = 32
+	}
+	if *f.theme != "" {
+		cfg.Theme = *f.theme
 		// Re-apply smart defaults and absolute resolution since theme changed
 		cfg.TemplateDir = filepath.Join(cfg.ThemeDir, cfg.Theme, "templates")
 		cfg.StaticDir = filepath.Join(cfg.ThemeDir, cfg.Theme, "static")
@@ -234,12 +857,186 @@ func Load(args []string) *Config {
 	return cfg
 }
 
+// flagValues holds the command-line flags Load understands
+type flagValues struct {
+	baseURL       *string
+	drafts        *bool
+	draftPreviews *bool
+	future        *bool
+	audience      *string
+	theme         *string
+	offline       *bool
+	lowMemory     *bool
+	parseWorkers  *int
+	renderWorkers *int
+	cardWorkers   *int
+	imageWorkers  *int
+	only          *string
+	slowPages     *int
+	report        *string
+	maxErrors     *int
+	failFast      *bool
+	errorSummary  *string
+	strict        *bool
+	slowPagesJSON *string
+	linkDest      *string
+}
+
+func newFlagSet() (*flag.FlagSet, *flagValues) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	return fs, &flagValues{
+		baseURL:       fs.String("baseurl", "", "Base URL (overrides config file)"),
+		drafts:        fs.Bool("drafts", false, "Include draft posts in the build"),
+		draftPreviews: fs.Bool("draft-previews", false, "Build drafts at unguessable preview URLs (see draftPreviews)"),
+		future:        fs.Bool("future", false, "Include posts whose publishDate is in the future"),
+		audience:      fs.String("audience", "", "Build the variant for this audience (pages with a matching audience: frontmatter)"),
+		theme:         fs.String("theme", "", "Theme to use (overrides config file)"),
+		offline:       fs.Bool("offline", false, "Use cached remote data only"),
+		lowMemory:     fs.Bool("low-memory", false, "Build with bounded memory: no in-memory output, spooled search data"),
+		parseWorkers:  fs.Int("parse-workers", 0, "Markdown parsing workers (overrides workers.parse)"),
+		renderWorkers: fs.Int("render-workers", 0, "Page rendering workers (overrides workers.render)"),
+		cardWorkers:   fs.Int("card-workers", 0, "Social card workers (overrides workers.cards)"),
+		imageWorkers:  fs.Int("image-workers", 0, "Image processing workers (overrides imageWorkers)"),
+		only:          fs.String("only", "", "Build only this content subtree, e.g. content/docs/v3/"),
+		slowPages:     fs.Int("slow-pages", 0, "Print the N slowest pages after the build"),
+		report:        fs.String("report", "", "Write a build report to this file (.json or .html)"),
+		maxErrors:     fs.Int("max-errors", 0, "Print only the first N errors; fail the build if there are more"),
+		failFast:      fs.Bool("fail-fast", false, "Stop the build at the first error"),
+		errorSummary:  fs.String("error-summary", "", "Write the errors of the build, grouped by type, to a JSON file"),
+		strict:        fs.Bool("strict", false, "Fail the build on content problems (see strict.checks)"),
+		slowPagesJSON: fs.String("slow-pages-json", "", "Write the slowest pages (with -slow-pages N, default 10) to a JSON file"),
+		linkDest:      fs.String("link-dest", "", "Link files unchanged from this previous output instead of writing them (overrides linkDest)"),
+	}
+}
+
+// FlagNames returns the names of the build flags Load parses, sorted, for
+// shell completion
+func FlagNames() []string {
+	fs, _ := newFlagSet()
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	return names
+}
+
+// SplitArgs separates the leading build flags Load parses from the
+// positional arguments after them
+func SplitArgs(args []string) (flags, rest []string) {
+	fs, _ := newFlagSet()
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return args, nil
+	}
+	rest = fs.Args()
+	return args[:len(args)-len(rest)], rest
+}
+
+// resolveOnly turns the --only argument into an absolute content path. It may
+// be given from the site root (content/docs/v3/) or from the content
+// directory (docs/v3).
+func resolveOnly(contentDir, only string) string {
+	abs, err := filepath.Abs(only)
+	if err != nil {
+		return utils.NormalizePath(filepath.Join(contentDir, only))
+	}
+	abs = utils.NormalizePath(abs)
+	if abs != contentDir && !strings.HasPrefix(abs, contentDir+"/") {
+		abs = utils.NormalizePath(filepath.Join(contentDir, only))
+	}
+	return strings.TrimSuffix(abs, "/")
+}
+
+// InScope reports whether a content file is part of the build. Without
+// --only that's every file; a scoped build covers its subtree plus the
+// section indexes (index.md) of the directories above it, which list it.
+func (cfg *Config) InScope(path string) bool {
+	if cfg.Only == "" {
+		return true
+	}
+	path = utils.NormalizePath(path)
+	if path == cfg.Only || strings.HasPrefix(path, cfg.Only+"/") {
+		return true
+	}
+	if filepath.Base(path) != "index.md" {
+		return false
+	}
+	return strings.HasPrefix(cfg.Only, utils.NormalizePath(filepath.Dir(path))+"/")
+}
+
+// SiteTemplatesDir holds a site's own templates. A file there replaces the
+// theme's file with the same path (templates/partials/footer.html replaces
+// the theme's partials/footer.html), so a site can change single templates
+// without forking its theme.
+const SiteTemplatesDir = "templates"
+
+// TemplateDirs are the directories templates are looked up in, first match
+// wins: the site's templates, then the theme's
+func (cfg *Config) TemplateDirs() []string {
+	if cfg.SiteTemplateDir == "" || cfg.SiteTemplateDir == utils.NormalizePath(cfg.TemplateDir) {
+		return []string{cfg.TemplateDir}
+	}
+	return []string{cfg.SiteTemplateDir, cfg.TemplateDir}
+}
+
+// UseThemeDir points the theme at a directory outside themeDir, such as a
+// checkout of a theme under development (serve --theme-dev). The theme's
+// templates, static files and theme.yaml are all taken from dir.
+func (cfg *Config) UseThemeDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(filepath.Join(abs, "templates")); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a theme: it has no templates directory", dir)
+	}
+	abs = utils.NormalizePath(abs)
+	cfg.ThemeDir = utils.NormalizePath(filepath.Dir(abs))
+	cfg.Theme = filepath.Base(abs)
+	cfg.TemplateDir = filepath.Join(abs, "templates")
+	cfg.StaticDir = filepath.Join(abs, "static")
+	return nil
+}
+
 // SetDevMode is a helper to set development mode on a config pointer
 func SetDevMode(cfg *Config, isDev bool) {
 	cfg.IsDev = isDev
 	isDevMode.Store(isDev)
 }
 
+// MinifyAssets reports whether CSS and JS are minified and fingerprinted:
+// assets.minify, or compressImages when it isn't set
+func (cfg *Config) MinifyAssets() bool {
+	if cfg.Assets.Minify != nil {
+		return *cfg.Assets.Minify
+	}
+	return cfg.CompressImages
+}
+
+// ResponsiveImages reports whether images are published at several widths:
+// images.widths is set and images are compressed
+func (cfg *Config) ResponsiveImages() bool {
+	return cfg.CompressImages && len(cfg.Images.Widths) > 0
+}
+
+// DedupeImages reports whether static images are published under their
+// content hash (images.dedupe). Dev builds keep their paths.
+func (cfg *Config) DedupeImages() bool {
+	return cfg.Images.Dedupe && !cfg.IsDev
+}
+
+// IsLatestVersion reports whether posts of a version belong to the site's
+// own listings: unversioned posts, or those of the latest version
+func (cfg *Config) IsLatestVersion(version string) bool {
+	if version == "" {
+		return true
+	}
+	for _, v := range cfg.Versions {
+		if v.IsLatest && v.Name == version {
+			return true
+		}
+	}
+	return false
+}
+
 // GetVersionsMetadata returns a list of version information for templates
 // currentPath is the current page path (e.g., "getting-started.html") to preserve across version switches
 func (cfg *Config) GetVersionsMetadata(currentVersion, currentPath string) []models.VersionInfo {
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
@@ -195,7 +196,7 @@ baseURL: "https://test.example.com"
 	}
 
 	// Override with CLI flags
-	args := []string{"-baseurl", "https://override.example.com", "-drafts"}
+	args := []string{"-baseurl", "https://override.example.com", "-drafts", "--future"}
 	cfg := Load(args)
 
 	if cfg.BaseURL != "https://override.example.com" {
@@ -205,6 +206,9 @@ baseURL: "https://test.example.com"
 	if !cfg.IncludeDrafts {
 		t.Error("IncludeDrafts should be true")
 	}
+	if !cfg.IncludeFuture {
+		t.Error("IncludeFuture should be true")
+	}
 }
 
 func TestLoad_ThemeOverride(t *testing.T) {
@@ -315,6 +319,38 @@ func TestSetDevMode(t *testing.T) {
 	}
 }
 
+func TestIsLatestVersion(t *testing.T) {
+	cfg := &Config{Versions: []Version{{Name: "v2.0", IsLatest: true}, {Name: "v1.0", Path: "v1.0"}}}
+	for version, want := range map[string]bool{"": true, "v2.0": true, "v1.0": false, "v0.9": false} {
+		if got := cfg.IsLatestVersion(version); got != want {
+			t.Errorf("IsLatestVersion(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestIsScheduled(t *testing.T) {
+	past, future := time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1)
+	tests := []struct {
+		name            string
+		publish, expiry time.Time
+		includeFuture   bool
+		want            bool
+	}{
+		{name: "unscheduled", want: true},
+		{name: "published", publish: past, expiry: future, want: true},
+		{name: "future", publish: future, want: false},
+		{name: "future with -future", publish: future, includeFuture: true, want: true},
+		{name: "expired", expiry: past, want: false},
+		{name: "expired with -future", publish: past, expiry: past, includeFuture: true, want: false},
+	}
+	for _, tt := range tests {
+		cfg := &Config{IncludeFuture: tt.includeFuture}
+		if got := cfg.IsScheduled(tt.publish, tt.expiry); got != tt.want {
+			t.Errorf("%s: IsScheduled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestGetVersionsMetadata(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -518,3 +554,315 @@ func TestConfig_FeaturesConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestEffectiveMarshal(t *testing.T) {
+	cfg := &Config{
+		Title:         "Resolved",
+		BaseURL:       "https://example.com",
+		IncludeDrafts: true,
+		Build:         DefaultBuildConfig(),
+	}
+
+	yamlOut, err := cfg.Effective().Marshal("yaml")
+	if err != nil {
+		t.Fatalf("Marshal(yaml) error = %v", err)
+	}
+	for _, want := range []string{"title: Resolved", "baseURL: https://example.com", "includeDrafts: true", "maxWorkers: 32", "shutdownTimeout: 5s"} {
+		if !strings.Contains(string(yamlOut), want) {
+			t.Errorf("yaml output missing %q:\n%s", want, yamlOut)
+		}
+	}
+
+	jsonOut, err := cfg.Effective().Marshal("json")
+	if err != nil {
+		t.Fatalf("Marshal(json) error = %v", err)
+	}
+	if !strings.Contains(string(jsonOut), `"baseURL": "https://example.com"`) {
+		t.Errorf("json output missing baseURL:\n%s", jsonOut)
+	}
+
+	if _, err := cfg.Effective().Marshal("toml"); err == nil {
+		t.Error("Marshal(toml) should return an error")
+	}
+}
+
+func TestLoad_PaginationPageSize(t *testing.T) {
+	cleanup := changeToTempDir(t)
+	defer cleanup()
+
+	yamlContent := `
+postsPerPage: 20
+pagination:
+  pageSize: 5
+`
+	if err := os.WriteFile("kosh.yaml", []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test kosh.yaml: %v", err)
+	}
+
+	cfg := Load([]string{})
+
+	if cfg.PostsPerPage != 5 {
+		t.Errorf("PostsPerPage = %d, want pagination.pageSize 5", cfg.PostsPerPage)
+	}
+}
+
+func TestLoad_EnvExpansion(t *testing.T) {
+	cleanup := changeToTempDir(t)
+	defer cleanup()
+
+	t.Setenv("KOSH_TEST_BASEURL", "https://env.example.com")
+	t.Setenv("KOSH_TEST_EMPTY", "")
+
+	yamlContent := `
+title: "${KOSH_TEST_UNSET_TITLE:-Default Title}"
+baseURL: ${KOSH_TEST_BASEURL}
+description: "prefix-${KOSH_TEST_EMPTY:-fallback}-suffix"
+postsPerPage: ${KOSH_TEST_UNSET_PER_PAGE:-7}
+language: "${KOSH_TEST_UNSET_LANG}"
+`
+	if err := os.WriteFile("kosh.yaml", []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test kosh.yaml: %v", err)
+	}
+
+	cfg := Load([]string{})
+
+	if cfg.Title != "Default Title" {
+		t.Errorf("Title = %q, want %q", cfg.Title, "Default Title")
+	}
+	if cfg.BaseURL != "https://env.example.com" {
+		t.Errorf("BaseURL = %q, want %q", cfg.BaseURL, "https://env.example.com")
+	}
+	if cfg.Description != "prefix-fallback-suffix" {
+		t.Errorf("Description = %q, want %q", cfg.Description, "prefix-fallback-suffix")
+	}
+	if cfg.PostsPerPage != 7 {
+		t.Errorf("PostsPerPage = %d, want 7", cfg.PostsPerPage)
+	}
+	if cfg.Language != "" {
+		t.Errorf("Language = %q, want empty", cfg.Language)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("KOSH_TEST_SET", "value")
+
+	tests := []struct {
+		input       string
+		want        string
+		wantMissing bool
+	}{
+		{"plain", "plain", false},
+		{"${KOSH_TEST_SET}", "value", false},
+		{"${KOSH_TEST_SET:-other}", "value", false},
+		{"${KOSH_TEST_NOT_SET:-other}", "other", false},
+		{"${KOSH_TEST_NOT_SET:-}", "", false},
+		{"${KOSH_TEST_NOT_SET}", "", true},
+		{"$KOSH_TEST_SET", "$KOSH_TEST_SET", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			missing := make(map[string]bool)
+			if got := expandEnv(tt.input, missing); got != tt.want {
+				t.Errorf("expandEnv(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if (len(missing) > 0) != tt.wantMissing {
+				t.Errorf("expandEnv(%q) missing = %v, want missing=%v", tt.input, missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestLoad_WorkerFlags(t *testing.T) {
+	cleanup := changeToTempDir(t)
+	defer cleanup()
+
+	yamlContent := "imageWorkers: 8\nworkers:\n  parse: 16\n  cards: 2\n"
+	if err := os.WriteFile("kosh.yaml", []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test kosh.yaml: %v", err)
+	}
+
+	cfg := Load([]string{"-render-workers", "6", "-image-workers", "40"})
+
+	want := WorkersConfig{Parse: 16, Render: 6, Cards: 2}
+	if cfg.Workers != want {
+		t.Errorf("Workers = %+v, want %+v", cfg.Workers, want)
+	}
+	if cfg.ImageWorkers != 32 {
+		t.Errorf("ImageWorkers = %d, want flag value capped at 32", cfg.ImageWorkers)
+	}
+}
+
+func TestLoad_OnlyScope(t *testing.T) {
+	cleanup := changeToTempDir(t)
+	defer cleanup()
+
+	for _, arg := range []string{"content/docs/v3/", "docs/v3"} {
+		cfg := Load([]string{"-only", arg})
+		if want := cfg.ContentDir + "/docs/v3"; cfg.Only != want {
+			t.Fatalf("-only %s resolved to %q, want %q", arg, cfg.Only, want)
+		}
+
+		tests := []struct {
+			path string
+			want bool
+		}{
+			{cfg.ContentDir + "/docs/v3/install.md", true},
+			{cfg.ContentDir + "/docs/v3/api/ref.md", true},
+			{cfg.ContentDir + "/docs/v3/index.md", true},
+			{cfg.ContentDir + "/docs/index.md", true}, // Section index above the subtree
+			{cfg.ContentDir + "/index.md", true},
+			{cfg.ContentDir + "/docs/v2/index.md", false},
+			{cfg.ContentDir + "/docs/v2/install.md", false},
+			{cfg.ContentDir + "/docs/v30/install.md", false},
+		}
+		for _, tt := range tests {
+			if got := cfg.InScope(tt.path); got != tt.want {
+				t.Errorf("InScope(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		}
+	}
+
+	if cfg := Load(nil); !cfg.InScope(cfg.ContentDir + "/anything.md") {
+		t.Error("unscoped build should include every file")
+	}
+}
+
+func TestLoad_Audience(t *testing.T) {
+	cleanup := changeToTempDir(t)
+	defer cleanup()
+
+	yamlContent := `
+baseURL: "https://example.com"
+audiences:
+  partners:
+    outputDir: "dist/partners"
+    baseURL: "https://partners.example.com/"
+`
+	if err := os.WriteFile("kosh.yaml", []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	public := Load(nil)
+
+	internal := Load([]string{"-audience", "Internal"})
+	if internal.Audience != "internal" {
+		t.Errorf("Audience = %q, want internal", internal.Audience)
+	}
+	if want := public.OutputDir + "-internal"; internal.OutputDir != want {
+		t.Errorf("OutputDir = %q, want %q", internal.OutputDir, want)
+	}
+	if want := public.CacheDir + "/audiences/internal"; internal.CacheDir != want {
+		t.Errorf("CacheDir = %q, want %q", internal.CacheDir, want)
+	}
+	if internal.BaseURL != "https://example.com" {
+		t.Errorf("BaseURL = %q, want the site baseURL", internal.BaseURL)
+	}
+
+	partners := Load([]string{"-audience", "partners"})
+	if !strings.HasSuffix(partners.OutputDir, "/dist/partners") || partners.BaseURL != "https://partners.example.com" {
+		t.Errorf("partners: OutputDir = %q, BaseURL = %q", partners.OutputDir, partners.BaseURL)
+	}
+	if cfg := Load([]string{"-audience", "partners", "-baseurl", "http://localhost:2604"}); cfg.BaseURL != "http://localhost:2604" {
+		t.Errorf("-baseurl should win over the audience baseURL, got %q", cfg.BaseURL)
+	}
+
+	if cfg := Load([]string{"-audience", "public"}); cfg.Audience != "" || cfg.OutputDir != public.OutputDir {
+		t.Errorf("-audience public should be the default build, got %q in %q", cfg.Audience, cfg.OutputDir)
+	}
+
+	tests := []struct {
+		cfg       *Config
+		audiences []string
+		want      bool
+	}{
+		{public, nil, true},
+		{public, []string{"internal"}, false},
+		{public, []string{"public", "internal"}, true},
+		{internal, nil, true},
+		{internal, []string{"internal"}, true},
+		{internal, []string{" Internal "}, true},
+		{internal, []string{"partners"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.InAudience(tt.audiences); got != tt.want {
+			t.Errorf("audience %q: InAudience(%q) = %v, want %v", tt.cfg.Audience, tt.audiences, got, tt.want)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		args        []string
+		flags, rest []string
+	}{
+		{[]string{"-drafts", "-baseurl", "https://x.org", "index.html", "rss.xml"}, []string{"-drafts", "-baseurl", "https://x.org"}, []string{"index.html", "rss.xml"}},
+		{[]string{"index.html", "-drafts"}, []string{}, []string{"index.html", "-drafts"}},
+		{nil, nil, nil},
+	}
+	for _, tt := range tests {
+		flags, rest := SplitArgs(tt.args)
+		if strings.Join(flags, " ") != strings.Join(tt.flags, " ") || strings.Join(rest, " ") != strings.Join(tt.rest, " ") {
+			t.Errorf("SplitArgs(%q) = %q, %q, want %q, %q", tt.args, flags, rest, tt.flags, tt.rest)
+		}
+	}
+}
+
+func TestUseThemeDir(t *testing.T) {
+	theme := filepath.Join(t.TempDir(), "my-theme")
+	if err := os.MkdirAll(filepath.Join(theme, "templates"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Theme: "blog", ThemeDir: "/site/themes"}
+	if err := cfg.UseThemeDir(theme); err != nil {
+		t.Fatalf("UseThemeDir() error = %v", err)
+	}
+	if cfg.Theme != "my-theme" || filepath.Join(cfg.ThemeDir, cfg.Theme) != utils.NormalizePath(theme) {
+		t.Errorf("UseThemeDir() theme = %q in %q, want %q", cfg.Theme, cfg.ThemeDir, theme)
+	}
+	if cfg.TemplateDir != filepath.Join(theme, "templates") || cfg.StaticDir != filepath.Join(theme, "static") {
+		t.Errorf("UseThemeDir() dirs = %q, %q", cfg.TemplateDir, cfg.StaticDir)
+	}
+
+	if err := cfg.UseThemeDir(filepath.Dir(theme)); err == nil {
+		t.Error("UseThemeDir() accepted a directory without templates")
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	cfg := &Config{BaseURL: "https://example.com", Title: "Docs", Languages: []Language{
+		{Code: "en", Name: "English"},
+		{Code: "ja", Name: "日本語", Title: "ドキュメント"},
+	}}
+
+	if got := cfg.DefaultLanguage(); got != "en" {
+		t.Errorf("DefaultLanguage() = %q, want the first language", got)
+	}
+	for path, want := range map[string][2]string{
+		"en/Guides/Setup.md": {"en", "guides/setup.html"},
+		"ja/guides/setup.md": {"ja", "ja/guides/setup.html"},
+		"about.md":           {"en", "about.html"},
+		"english/intro.md":   {"en", "english/intro.html"},
+	} {
+		if lang, html := cfg.LanguageOf(path), cfg.HTMLPath(path); lang != want[0] || html != want[1] {
+			t.Errorf("%s: language %q, output %q, want %q %q", path, lang, html, want[0], want[1])
+		}
+	}
+	if got := cfg.LanguageOfURL("https://example.com/ja/guides/setup.html"); got != "ja" {
+		t.Errorf("LanguageOfURL() = %q, want ja", got)
+	}
+	if got := cfg.LanguageTitle("ja"); got != "ドキュメント" {
+		t.Errorf("LanguageTitle(ja) = %q", got)
+	}
+	if got := cfg.LanguageTitle("en"); got != "Docs" {
+		t.Errorf("LanguageTitle(en) = %q, want the site title", got)
+	}
+
+	langs := cfg.GetLanguagesMetadata("ja", "guides/setup.md")
+	if len(langs) != 2 || langs[0].URL != "https://example.com/guides/setup.html" || langs[1].URL != "https://example.com/ja/guides/setup.html" || !langs[1].IsCurrent {
+		t.Errorf("GetLanguagesMetadata() = %+v", langs)
+	}
+	if got := (&Config{}).GetLanguagesMetadata("", ""); got != nil {
+		t.Errorf("GetLanguagesMetadata() without languages = %+v, want nil", got)
+	}
+}
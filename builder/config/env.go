@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+)
+
+// envPattern matches ${VAR} and ${VAR:-default}
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces ${VAR} and ${VAR:-default} references in s.
+// The default is used when VAR is unset or empty. Variables that are unset
+// and have no default expand to "" and are reported in missing.
+func expandEnv(s string, missing map[string]bool) string {
+	return envPattern.ReplaceAllStringFunc(s, func(match string) string {
+		idx := envPattern.FindStringSubmatchIndex(match)
+		name := match[idx[2]:idx[3]]
+		hasDefault := idx[4] >= 0
+
+		value, ok := os.LookupEnv(name)
+		switch {
+		case value != "":
+			return value
+		case hasDefault:
+			return match[idx[4]:idx[5]]
+		case !ok && missing != nil:
+			missing[name] = true
+		}
+		return ""
+	})
+}
+
+// expandEnvNode expands environment references in every scalar of a YAML tree.
+// Plain scalars are re-resolved after expansion so `postsPerPage: ${PER_PAGE:-10}`
+// still decodes into an int.
+func expandEnvNode(node *yaml.Node, missing map[string]bool) {
+	if node.Kind == yaml.ScalarNode {
+		if !envPattern.MatchString(node.Value) {
+			return
+		}
+		node.Value = expandEnv(node.Value, missing)
+		if node.Style == 0 || node.Style == yaml.TaggedStyle {
+			node.Tag = ""
+		}
+		return
+	}
+	for _, child := range node.Content {
+		expandEnvNode(child, missing)
+	}
+}
+
+// unmarshalWithEnv decodes YAML into out after expanding environment references
+func unmarshalWithEnv(data []byte, out interface{}) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		return nil // Empty document
+	}
+
+	missing := make(map[string]bool)
+	expandEnvNode(&root, missing)
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		logging.Statusf("⚠️ Environment variables not set (expanded to empty): %v", names)
+	}
+
+	return root.Decode(out)
+}
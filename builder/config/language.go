@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// languageCode keeps language codes usable as directory names and URL
+// prefixes: "en", "pt-br", "zh_hans"
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}([_-][a-z0-9]+)*$`)
+
+// DefaultLanguage is the code of the language built at the site root, ""
+// for a site without languages
+func (cfg *Config) DefaultLanguage() string {
+	for _, l := range cfg.Languages {
+		if l.Default {
+			return l.Code
+		}
+	}
+	if len(cfg.Languages) > 0 {
+		return cfg.Languages[0].Code
+	}
+	return ""
+}
+
+// FindLanguage returns the configured language with the given code
+func (cfg *Config) FindLanguage(code string) (Language, bool) {
+	for _, l := range cfg.Languages {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// LanguageOf is the language of a page from its path relative to the content
+// directory: its top-level folder when that is a language, else the default
+// language
+func (cfg *Config) LanguageOf(relPath string) string {
+	if len(cfg.Languages) == 0 {
+		return ""
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(relPath), "/")
+	if _, ok := cfg.FindLanguage(first); ok {
+		return first
+	}
+	return cfg.DefaultLanguage()
+}
+
+// LanguageOfURL is the language of a page from its permalink
+func (cfg *Config) LanguageOfURL(link string) string {
+	def := cfg.DefaultLanguage()
+	for _, l := range cfg.Languages {
+		if l.Code != def && strings.HasPrefix(link, cfg.LanguageURL(l.Code)+"/") {
+			return l.Code
+		}
+	}
+	return def
+}
+
+// HTMLPath is the output path of a content file relative to the output
+// directory: lowercase, .html, and without the default language's folder
+func (cfg *Config) HTMLPath(relPath string) string {
+	htmlRelPath := strings.ToLower(strings.Replace(filepath.ToSlash(relPath), ".md", ".html", 1))
+	if def := cfg.DefaultLanguage(); def != "" {
+		htmlRelPath = strings.TrimPrefix(htmlRelPath, def+"/")
+	}
+	return htmlRelPath
+}
+
+// LanguageURL is the home page URL of a language, without a trailing slash:
+// the site root for the default language
+func (cfg *Config) LanguageURL(code string) string {
+	if code == "" || code == cfg.DefaultLanguage() {
+		return cfg.BaseURL
+	}
+	return cfg.BaseURL + "/" + code
+}
+
+// LanguageTitle is the site title in a language
+func (cfg *Config) LanguageTitle(code string) string {
+	if l, ok := cfg.FindLanguage(code); ok && l.Title != "" {
+		return l.Title
+	}
+	return cfg.Title
+}
+
+// GetLanguagesMetadata lists the languages for a language switcher. pagePath
+// is the page's path inside its language folder ("guides/setup.md"), "" on
+// list pages; each language links to that page, without checking that it
+// exists (see HasPage).
+func (cfg *Config) GetLanguagesMetadata(current, pagePath string) []models.LanguageInfo {
+	if len(cfg.Languages) == 0 {
+		return nil
+	}
+	var results []models.LanguageInfo
+	for _, l := range cfg.Languages {
+		name := l.Name
+		if name == "" {
+			name = l.Code
+		}
+		url := cfg.LanguageURL(l.Code) + "/"
+		if pagePath != "" {
+			url = utils.BuildURL(cfg.LanguageURL(l.Code), "", strings.ToLower(strings.Replace(filepath.ToSlash(pagePath), ".md", ".html", 1)))
+		}
+		results = append(results, models.LanguageInfo{
+			Code: l.Code, Name: name, URL: url,
+			IsCurrent: l.Code == current, HasPage: true,
+		})
+	}
+	return results
+}
+
+func checkLanguages(doc *yaml.Node, issues *[]Issue) {
+	_, node := lookupKey(doc, "languages")
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return
+	}
+	if _, versions := lookupKey(doc, "versions"); versions != nil && len(versions.Content) > 0 {
+		*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Path: "languages", Message: "languages can't be combined with versions yet"})
+	}
+	seen := map[string]bool{}
+	defaults := 0
+	for _, lang := range node.Content {
+		code, value := lookupKey(lang, "code")
+		switch {
+		case value == nil:
+			*issues = append(*issues, Issue{Line: lang.Line, Column: lang.Column, Path: "languages", Message: "language without a code"})
+			continue
+		case !languageCode.MatchString(value.Value):
+			*issues = append(*issues, Issue{Line: value.Line, Column: value.Column, Path: "languages.code", Message: fmt.Sprintf("invalid language code %q (lowercase, like en or pt-br)", value.Value)})
+		case seen[value.Value]:
+			*issues = append(*issues, Issue{Line: code.Line, Column: code.Column, Path: "languages.code", Message: fmt.Sprintf("language %q is listed twice", value.Value)})
+		}
+		seen[value.Value] = true
+		if key, def := lookupKey(lang, "default"); def != nil && def.Value == "true" {
+			if defaults++; defaults == 2 {
+				*issues = append(*issues, Issue{Line: key.Line, Column: key.Column, Path: "languages.default", Message: "more than one default language"})
+			}
+		}
+	}
+}
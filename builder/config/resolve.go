@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EffectiveConfig is the fully merged configuration (defaults, config file,
+// kosh.build.yaml and CLI flags) as printed by `kosh config resolve`
+type EffectiveConfig struct {
+	Config  `yaml:",inline"`
+	Build   *BuildConfig  `yaml:"build"`
+	Runtime RuntimeConfig `yaml:"runtime"`
+}
+
+// RuntimeConfig exposes the flag-driven fields that are not read from kosh.yaml
+type RuntimeConfig struct {
+	IncludeDrafts bool `yaml:"includeDrafts"`
+	IncludeFuture bool `yaml:"includeFuture"`
+	IsDev         bool `yaml:"isDev"`
+	ForceRebuild  bool `yaml:"forceRebuild"`
+	Offline       bool `yaml:"offline"`
+}
+
+// Effective returns the merged view of cfg
+func (cfg *Config) Effective() *EffectiveConfig {
+	return &EffectiveConfig{
+		Config: *cfg,
+		Build:  cfg.Build,
+		Runtime: RuntimeConfig{
+			IncludeDrafts: cfg.IncludeDrafts,
+			IncludeFuture: cfg.IncludeFuture,
+			IsDev:         cfg.IsDev,
+			ForceRebuild:  cfg.ForceRebuild,
+			Offline:       cfg.Offline,
+		},
+	}
+}
+
+// Marshal encodes the effective config as "yaml" or "json".
+// JSON keys match the YAML keys used in kosh.yaml.
+func (e *EffectiveConfig) Marshal(format string) ([]byte, error) {
+	data, err := yaml.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "", "yaml", "yml":
+		return data, nil
+	case "json":
+		var generic map[string]interface{}
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(generic, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported format %q (use yaml or json)", format)
+	}
+}
@@ -0,0 +1,132 @@
+// Package events is the Builder's typed event bus. Go programs that embed
+// Kosh, and features that shouldn't live inside the services, subscribe to
+// what happens during a build instead of hooking into service internals:
+//
+//	b := run.NewBuilderWithConfig(cfg)
+//	events.Subscribe(b.Events(), func(e events.PageRendered) {
+//		log.Println("wrote", e.Path)
+//	})
+//
+// Handlers run synchronously on the goroutine that publishes, and posts are
+// processed by a worker pool, so a handler must be safe for concurrent use
+// and return quickly.
+package events
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// Event is implemented by every event the builder publishes
+type Event interface {
+	event()
+}
+
+// PostParsed is published when a content file was parsed and rendered to
+// HTML (a cache miss), before its page is written
+type PostParsed struct {
+	Path        string                 // Relative to the content directory, slash-separated
+	Post        models.PostMetadata    // Title, link, tags, dates as listed on index pages
+	Frontmatter map[string]interface{} // Read-only
+	Duration    time.Duration          // Markdown, diagrams and math
+}
+
+// PageRendered is published after a template wrote an HTML page: a post,
+// an index or tag page, the 404 page or the graph
+type PageRendered struct {
+	Path     string // Output file path
+	Template string // "layout", "layouts/<name>", "index", "404" or "graph"
+	Duration time.Duration
+}
+
+// CacheHit is published when a content file is rendered from the build cache
+type CacheHit struct {
+	Path string // Relative to the content directory
+}
+
+// CacheMiss is published when a content file has to be parsed again
+type CacheMiss struct {
+	Path string // Relative to the content directory
+}
+
+// BuildFinished is published at the end of every build, failed or not
+type BuildFinished struct {
+	Duration time.Duration
+	Err      error  // nil when the build succeeded
+	Changed  string // The file an incremental (watch mode) rebuild was for; empty for full builds
+}
+
+func (PostParsed) event()    {}
+func (PageRendered) event()  {}
+func (CacheHit) event()      {}
+func (CacheMiss) event()     {}
+func (BuildFinished) event() {}
+
+type subscription struct {
+	id int
+	fn func(Event)
+}
+
+// Bus delivers events to their subscribers. A nil *Bus is valid and drops
+// every event, so services can publish without checking.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[reflect.Type][]subscription // A nil key holds the SubscribeAll handlers
+	nextID int
+}
+
+// New creates an empty bus
+func New() *Bus {
+	return &Bus{subs: make(map[reflect.Type][]subscription)}
+}
+
+// Subscribe calls fn for every event of type E published on b. The returned
+// function removes the subscription.
+func Subscribe[E Event](b *Bus, fn func(E)) (unsubscribe func()) {
+	var zero E
+	return b.add(reflect.TypeOf(zero), func(e Event) { fn(e.(E)) })
+}
+
+// SubscribeAll calls fn for every event published on b
+func (b *Bus) SubscribeAll(fn func(Event)) (unsubscribe func()) {
+	return b.add(nil, fn)
+}
+
+func (b *Bus) add(key reflect.Type, fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subs[key] = append(b.subs[key], subscription{id: id, fn: fn})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subs := b.subs[key]
+		for i, s := range subs {
+			if s.id == id {
+				b.subs[key] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers e to the subscribers of its type, then to those of every
+// event, in the order they subscribed
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	typed, all := b.subs[reflect.TypeOf(e)], b.subs[nil]
+	b.mu.RUnlock()
+	for _, s := range typed {
+		s.fn(e)
+	}
+	for _, s := range all {
+		s.fn(e)
+	}
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	bus := New()
+	var got []string
+	unsubscribe := Subscribe(bus, func(e CacheMiss) { got = append(got, "miss "+e.Path) })
+	Subscribe(bus, func(e CacheHit) { got = append(got, "hit "+e.Path) })
+	bus.SubscribeAll(func(e Event) {
+		if f, ok := e.(BuildFinished); ok {
+			got = append(got, "all finished "+f.Err.Error())
+		}
+	})
+
+	bus.Publish(CacheMiss{Path: "a.md"})
+	bus.Publish(CacheHit{Path: "b.md"})
+	bus.Publish(BuildFinished{Err: errors.New("boom")})
+	unsubscribe()
+	bus.Publish(CacheMiss{Path: "c.md"})
+
+	want := []string{"miss a.md", "hit b.md", "all finished boom"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestUnsubscribeKeepsOthers(t *testing.T) {
+	bus := New()
+	var calls []int
+	first := Subscribe(bus, func(PageRendered) { calls = append(calls, 1) })
+	Subscribe(bus, func(PageRendered) { calls = append(calls, 2) })
+	Subscribe(bus, func(PageRendered) { calls = append(calls, 3) })
+	first()
+	first() // A second call is a no-op
+
+	bus.Publish(PageRendered{Path: "public/index.html"})
+	if !reflect.DeepEqual(calls, []int{2, 3}) {
+		t.Errorf("calls = %v, want [2 3]", calls)
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(PostParsed{Path: "a.md"}) // Must not panic
+}
+
+func TestConcurrentPublish(t *testing.T) {
+	bus := New()
+	var mu sync.Mutex
+	count := 0
+	Subscribe(bus, func(PostParsed) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				bus.Publish(PostParsed{})
+			}
+		}()
+	}
+	// Subscribing while events are published is safe too
+	Subscribe(bus, func(PostParsed) {})
+	wg.Wait()
+
+	if count != 800 {
+		t.Errorf("handler ran %d times, want 800", count)
+	}
+}
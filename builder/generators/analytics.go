@@ -0,0 +1,92 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// analyticsScript describes the tracking script of a provider
+type analyticsScript struct {
+	src   string
+	attrs map[string]string
+	init  string // Inline JS run alongside the script (GA4's gtag setup)
+}
+
+// AnalyticsSnippet returns the <head> markup for the configured analytics
+// provider, or "" when analytics is disabled. Unless IgnoreDoNotTrack is set,
+// the script is inserted by a small loader that does nothing for visitors
+// with Do Not Track enabled.
+func AnalyticsSnippet(cfg config.AnalyticsConfig) (string, error) {
+	if cfg.Provider == "" {
+		return "", nil
+	}
+	script, err := analyticsScriptFor(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.IgnoreDoNotTrack {
+		var b strings.Builder
+		fmt.Fprintf(&b, `<script async src="%s"`, html.EscapeString(script.src))
+		for _, k := range sortedKeys(script.attrs) {
+			fmt.Fprintf(&b, ` %s="%s"`, k, html.EscapeString(script.attrs[k]))
+		}
+		b.WriteString(`></script>`)
+		if script.init != "" {
+			fmt.Fprintf(&b, `<script>%s</script>`, script.init)
+		}
+		return b.String(), nil
+	}
+
+	src, _ := json.Marshal(script.src)
+	var b strings.Builder
+	b.WriteString(`<script>(function(){if(navigator.doNotTrack==="1"||window.doNotTrack==="1")return;`)
+	fmt.Fprintf(&b, `var s=document.createElement("script");s.async=true;s.src=%s;`, src)
+	for _, k := range sortedKeys(script.attrs) {
+		name, _ := json.Marshal(k)
+		value, _ := json.Marshal(script.attrs[k])
+		fmt.Fprintf(&b, `s.setAttribute(%s,%s);`, name, value)
+	}
+	b.WriteString(`document.head.appendChild(s);`)
+	b.WriteString(script.init)
+	b.WriteString(`})();</script>`)
+	return b.String(), nil
+}
+
+func analyticsScriptFor(cfg config.AnalyticsConfig) (analyticsScript, error) {
+	if cfg.ID == "" {
+		return analyticsScript{}, fmt.Errorf("analytics provider %q needs an id", cfg.Provider)
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "plausible":
+		return analyticsScript{
+			src:   orDefault(cfg.Src, "https://plausible.io/js/script.js"),
+			attrs: map[string]string{"data-domain": cfg.ID},
+		}, nil
+
+	case "umami":
+		return analyticsScript{
+			src:   orDefault(cfg.Src, "https://cloud.umami.is/script.js"),
+			attrs: map[string]string{"data-website-id": cfg.ID},
+		}, nil
+
+	case "goatcounter":
+		return analyticsScript{
+			src:   orDefault(cfg.Src, "https://gc.zgo.at/count.js"),
+			attrs: map[string]string{"data-goatcounter": "https://" + cfg.ID + ".goatcounter.com/count"},
+		}, nil
+
+	case "ga4":
+		id, _ := json.Marshal(cfg.ID)
+		return analyticsScript{
+			src:  "https://www.googletagmanager.com/gtag/js?id=" + cfg.ID,
+			init: fmt.Sprintf(`window.dataLayer=window.dataLayer||[];function gtag(){dataLayer.push(arguments)}gtag("js",new Date());gtag("config",%s);`, id),
+		}, nil
+	}
+	return analyticsScript{}, fmt.Errorf("unknown analytics provider %q (expected plausible, umami, goatcounter or ga4)", cfg.Provider)
+}
@@ -0,0 +1,61 @@
+package generators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestAnalyticsSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.AnalyticsConfig
+		want    []string
+		wantErr bool
+	}{
+		{name: "disabled", cfg: config.AnalyticsConfig{}},
+		{
+			name: "plausible honors DNT",
+			cfg:  config.AnalyticsConfig{Provider: "plausible", ID: "example.com"},
+			want: []string{`navigator.doNotTrack==="1"`, `s.src="https://plausible.io/js/script.js"`, `s.setAttribute("data-domain","example.com")`},
+		},
+		{
+			name: "self-hosted umami without DNT check",
+			cfg:  config.AnalyticsConfig{Provider: "umami", ID: "abc-123", Src: "https://stats.example.com/script.js", IgnoreDoNotTrack: true},
+			want: []string{`<script async src="https://stats.example.com/script.js" data-website-id="abc-123"></script>`},
+		},
+		{
+			name: "goatcounter",
+			cfg:  config.AnalyticsConfig{Provider: "goatcounter", ID: "mysite"},
+			want: []string{`"https://mysite.goatcounter.com/count"`},
+		},
+		{
+			name: "ga4",
+			cfg:  config.AnalyticsConfig{Provider: "GA4", ID: "G-TEST"},
+			want: []string{`gtag/js?id=G-TEST`, `gtag("config","G-TEST")`},
+		},
+		{name: "missing id", cfg: config.AnalyticsConfig{Provider: "plausible"}, wantErr: true},
+		{name: "unknown provider", cfg: config.AnalyticsConfig{Provider: "matomo", ID: "1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AnalyticsSnippet(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AnalyticsSnippet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tt.want) == 0 && got != "" {
+				t.Errorf("AnalyticsSnippet() = %q, want empty", got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("AnalyticsSnippet() = %q, want it to contain %q", got, w)
+				}
+			}
+			if tt.cfg.IgnoreDoNotTrack && strings.Contains(got, "doNotTrack") {
+				t.Error("snippet should not check Do Not Track when ignoreDoNotTrack is set")
+			}
+		})
+	}
+}
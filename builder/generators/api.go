@@ -0,0 +1,90 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// GenerateAPI writes the JSON listing endpoints for client-side themes:
+// /api/posts/page/N.json (all posts in list order, perPage to a page),
+// /api/tags.json and /api/tags/<tag>.json. It returns the written paths.
+func GenerateAPI(destFs afero.Fs, baseURL, outputDir string, posts []models.PostMetadata, tagMap map[string][]models.PostMetadata, perPage int) ([]string, error) {
+	var written []string
+	write := func(rel string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(outputDir, "api", filepath.FromSlash(rel))
+		if err := utils.WriteFileVFS(destFs, path, data); err != nil {
+			return err
+		}
+		written = append(written, path)
+		return nil
+	}
+
+	sorted := append([]models.PostMetadata(nil), posts...)
+	utils.SortPosts(sorted)
+	if perPage <= 0 {
+		perPage = len(sorted)
+	}
+	totalPages := 1
+	if perPage > 0 && len(sorted) > perPage {
+		totalPages = (len(sorted) + perPage - 1) / perPage
+	}
+	pageURL := func(i int) string { return fmt.Sprintf("%s/api/posts/page/%d.json", baseURL, i) }
+	for i := 1; i <= totalPages; i++ {
+		start, end := (i-1)*perPage, min(i*perPage, len(sorted))
+		page := models.APIPage{Page: i, TotalPages: totalPages, TotalPosts: len(sorted), Posts: apiPosts(sorted[start:end])}
+		if i > 1 {
+			page.Prev = pageURL(i - 1)
+		}
+		if i < totalPages {
+			page.Next = pageURL(i + 1)
+		}
+		if err := write(fmt.Sprintf("posts/page/%d.json", i), page); err != nil {
+			return written, err
+		}
+	}
+
+	tags := make([]models.APITag, 0, len(tagMap))
+	for t, tagged := range tagMap {
+		tags = append(tags, models.APITag{
+			Name: t, Count: len(tagged),
+			URL: fmt.Sprintf("%s/tags/%s.html", baseURL, t),
+			API: fmt.Sprintf("%s/api/tags/%s.json", baseURL, t),
+		})
+		sortedTagged := append([]models.PostMetadata(nil), tagged...)
+		utils.SortPosts(sortedTagged)
+		if err := write("tags/"+t+".json", models.APITerm{Tag: t, Count: len(tagged), Posts: apiPosts(sortedTagged)}); err != nil {
+			return written, err
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	if err := write("tags.json", tags); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+func apiPosts(posts []models.PostMetadata) []models.APIPost {
+	out := make([]models.APIPost, len(posts))
+	for i, p := range posts {
+		out[i] = models.APIPost{
+			Title: p.Title, URL: p.Link, Description: p.Description, Tags: p.Tags,
+			ReadingTime: p.ReadingTime, Version: p.Version, Pinned: p.Pinned,
+		}
+		if !p.DateObj.IsZero() {
+			out[i].Date = p.DateObj.Format(time.RFC3339)
+		}
+	}
+	return out
+}
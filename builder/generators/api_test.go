@@ -0,0 +1,85 @@
+package generators
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func readJSON(t *testing.T, fs afero.Fs, path string, v any) {
+	t.Helper()
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+func TestGenerateAPI(t *testing.T) {
+	base := "https://example.com"
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	posts := []models.PostMetadata{
+		{Title: "One", Link: base + "/one.html", Tags: []string{"go"}, DateObj: day(1), ReadingTime: 3},
+		{Title: "Three", Link: base + "/three.html", Tags: []string{"go", "web"}, DateObj: day(3)},
+		{Title: "Two", Link: base + "/two.html", DateObj: day(2), Pinned: true},
+	}
+	tagMap := map[string][]models.PostMetadata{"go": {posts[0], posts[1]}, "web": {posts[1]}}
+
+	fs := afero.NewMemMapFs()
+	written, err := GenerateAPI(fs, base, "public", posts, tagMap, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 5 {
+		t.Errorf("wrote %v, want 2 post pages, 2 tags and tags.json", written)
+	}
+
+	var first, second models.APIPage
+	readJSON(t, fs, "public/api/posts/page/1.json", &first)
+	readJSON(t, fs, "public/api/posts/page/2.json", &second)
+	if first.TotalPages != 2 || first.TotalPosts != 3 || first.Prev != "" || first.Next != base+"/api/posts/page/2.json" {
+		t.Errorf("page 1 = %+v", first)
+	}
+	if second.Prev != base+"/api/posts/page/1.json" || second.Next != "" || len(second.Posts) != 1 || second.Posts[0].Title != "One" {
+		t.Errorf("page 2 = %+v", second)
+	}
+	want := models.APIPost{Title: "Three", URL: base + "/three.html", Date: "2024-01-03T00:00:00Z", Tags: []string{"go", "web"}}
+	if len(first.Posts) != 2 || !reflect.DeepEqual(first.Posts[0], want) || !first.Posts[1].Pinned {
+		t.Errorf("page 1 posts = %+v", first.Posts)
+	}
+
+	var term models.APITerm
+	readJSON(t, fs, "public/api/tags/go.json", &term)
+	if term.Tag != "go" || term.Count != 2 || term.Posts[0].Title != "Three" || term.Posts[1].Title != "One" {
+		t.Errorf("tags/go.json = %+v", term)
+	}
+
+	var tags []models.APITag
+	readJSON(t, fs, "public/api/tags.json", &tags)
+	wantTags := []models.APITag{
+		{Name: "go", Count: 2, URL: base + "/tags/go.html", API: base + "/api/tags/go.json"},
+		{Name: "web", Count: 1, URL: base + "/tags/web.html", API: base + "/api/tags/web.json"},
+	}
+	if !reflect.DeepEqual(tags, wantTags) {
+		t.Errorf("tags.json = %+v, want %+v", tags, wantTags)
+	}
+}
+
+func TestGenerateAPIEmpty(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if _, err := GenerateAPI(fs, "", "public", nil, nil, 10); err != nil {
+		t.Fatal(err)
+	}
+	var page models.APIPage
+	readJSON(t, fs, "public/api/posts/page/1.json", &page)
+	if page.TotalPages != 1 || page.Posts == nil || len(page.Posts) != 0 {
+		t.Errorf("empty site page = %+v, want one page with an empty list", page)
+	}
+}
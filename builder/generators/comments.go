@@ -0,0 +1,182 @@
+package generators
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+const (
+	giscusOrigin     = "https://giscus.app"
+	utterancesOrigin = "https://utteranc.es"
+)
+
+// commentsWidget is the script tag a comments provider is loaded with
+type commentsWidget struct {
+	src       string
+	attrs     map[string]string
+	themeAttr string // Attribute holding the provider theme, "" if themes aren't supported
+	script    string // CSP sources the widget loads scripts, frames and requests from
+	frame     string
+	connect   string
+	extra     string // Markup placed after the script (e.g. the isso thread container)
+}
+
+// CommentsEmbed returns the comment widget markup for the page at pagePath and
+// the CSP directives the widget needs. Both are empty when comments are disabled
+// or the provider is unknown.
+func CommentsEmbed(cfg config.CommentsConfig, pagePath string) (template.HTML, string) {
+	w, ok := commentsWidgetFor(cfg, pagePath)
+	if !ok {
+		return "", ""
+	}
+
+	if len(cfg.ThemeMap) > 0 && w.themeAttr != "" {
+		markup, script := commentsLoader(w, cfg.ThemeMap)
+		return markup, cspDirectives(w.script+" "+scriptHash(script), w.frame, w.connect)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="kosh-comments"><script src="`)
+	b.WriteString(html.EscapeString(w.src))
+	b.WriteString(`"`)
+	for _, k := range sortedKeys(w.attrs) {
+		fmt.Fprintf(&b, ` %s="%s"`, k, html.EscapeString(w.attrs[k]))
+	}
+	b.WriteString(` crossorigin="anonymous" async></script>`)
+	b.WriteString(w.extra)
+	b.WriteString(`</div>`)
+	return template.HTML(b.String()), cspDirectives(w.script, w.frame, w.connect)
+}
+
+func commentsWidgetFor(cfg config.CommentsConfig, pagePath string) (commentsWidget, bool) {
+	mapping := cfg.Mapping
+	if mapping == "" {
+		mapping = "pathname"
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case "giscus":
+		attrs := map[string]string{
+			"data-repo":              cfg.Repo,
+			"data-repo-id":           cfg.RepoID,
+			"data-category":          cfg.Category,
+			"data-category-id":       cfg.CategoryID,
+			"data-mapping":           mapping,
+			"data-reactions-enabled": "1",
+			"data-input-position":    "bottom",
+			"data-theme":             orDefault(cfg.Theme, "preferred_color_scheme"),
+			"data-lang":              orDefault(cfg.Lang, "en"),
+			"data-loading":           "lazy",
+		}
+		return commentsWidget{
+			src:       giscusOrigin + "/client.js",
+			attrs:     attrs,
+			themeAttr: "data-theme",
+			script:    giscusOrigin,
+			frame:     giscusOrigin,
+		}, true
+
+	case "utterances":
+		attrs := map[string]string{
+			"repo":       cfg.Repo,
+			"issue-term": mapping,
+			"theme":      orDefault(cfg.Theme, "preferred-color-scheme"),
+		}
+		if cfg.Label != "" {
+			attrs["label"] = cfg.Label
+		}
+		return commentsWidget{
+			src:       utterancesOrigin + "/client.js",
+			attrs:     attrs,
+			themeAttr: "theme",
+			script:    utterancesOrigin,
+			frame:     utterancesOrigin,
+		}, true
+
+	case "isso":
+		if cfg.URL == "" {
+			return commentsWidget{}, false
+		}
+		base := strings.TrimSuffix(cfg.URL, "/") + "/"
+		attrs := map[string]string{"data-isso": base}
+		if cfg.Lang != "" {
+			attrs["data-isso-lang"] = cfg.Lang
+		}
+		origin := base
+		if u, err := url.Parse(base); err == nil && u.Host != "" {
+			origin = u.Scheme + "://" + u.Host
+		}
+		return commentsWidget{
+			src:     base + "js/embed.min.js",
+			attrs:   attrs,
+			script:  origin,
+			connect: origin,
+			extra:   fmt.Sprintf(`<section id="isso-thread" data-isso-id="%s"></section>`, html.EscapeString(pagePath)),
+		}, true
+	}
+	return commentsWidget{}, false
+}
+
+// commentsLoader renders a small inline loader that picks the provider theme
+// matching the site's current data-theme before the widget script is inserted.
+// It also returns the script body, which the CSP allows by hash.
+func commentsLoader(w commentsWidget, themeMap map[string]string) (template.HTML, string) {
+	attrs, _ := json.Marshal(w.attrs)
+	themes, _ := json.Marshal(themeMap)
+	src, _ := json.Marshal(w.src)
+	attr, _ := json.Marshal(w.themeAttr)
+
+	script := fmt.Sprintf(`(function(){`+
+		`var a=%s,m=%s,t=m[document.documentElement.getAttribute("data-theme")];if(t){a[%s]=t}`+
+		`var s=document.createElement("script");s.src=%s;s.async=true;s.crossOrigin="anonymous";`+
+		`for(var k in a){s.setAttribute(k,a[k])}`+
+		`var c=document.currentScript.previousElementSibling;c.appendChild(s)})();`,
+		attrs, themes, attr, src)
+	return template.HTML(`<div class="kosh-comments"></div><script>` + script + `</script>`), script
+}
+
+// scriptHash returns the CSP source allowing an inline script
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+// cspDirectives builds the Content-Security-Policy sources a widget needs
+func cspDirectives(script, frame, connect string) string {
+	var parts []string
+	if script != "" {
+		parts = append(parts, "script-src "+script)
+	}
+	if frame != "" {
+		parts = append(parts, "frame-src "+frame)
+	}
+	if connect != "" {
+		parts = append(parts, "connect-src "+connect)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
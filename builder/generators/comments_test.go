@@ -0,0 +1,78 @@
+package generators
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestCommentsEmbed(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.CommentsConfig
+		wantHTML []string
+		wantCSP  string
+	}{
+		{
+			name: "disabled",
+			cfg:  config.CommentsConfig{},
+		},
+		{
+			name: "unknown provider",
+			cfg:  config.CommentsConfig{Provider: "disqus"},
+		},
+		{
+			name: "giscus",
+			cfg:  config.CommentsConfig{Provider: "giscus", Repo: "me/site", RepoID: "R_1", Category: "Posts", CategoryID: "C_1"},
+			wantHTML: []string{
+				`src="https://giscus.app/client.js"`,
+				`data-repo="me/site"`,
+				`data-category="Posts"`,
+				`data-mapping="pathname"`,
+				`data-theme="preferred_color_scheme"`,
+			},
+			wantCSP: "script-src https://giscus.app; frame-src https://giscus.app",
+		},
+		{
+			name:     "utterances with theme map",
+			cfg:      config.CommentsConfig{Provider: "utterances", Repo: "me/site", ThemeMap: map[string]string{"dark": "github-dark"}},
+			wantHTML: []string{`"dark":"github-dark"`, `"repo":"me/site"`, `"theme"`, `document.createElement("script")`},
+			wantCSP:  "script-src https://utteranc.es 'sha256-3205CkgHP+8POUT8YDkAcYCWJSnG7klY9Hu+LIQeYR8='; frame-src https://utteranc.es",
+		},
+		{
+			name:     "isso",
+			cfg:      config.CommentsConfig{Provider: "isso", URL: "https://comments.example.com/isso"},
+			wantHTML: []string{`src="https://comments.example.com/isso/js/embed.min.js"`, `data-isso-id="/posts/hello/"`},
+			wantCSP:  "script-src https://comments.example.com; connect-src https://comments.example.com",
+		},
+		{
+			name: "isso without url",
+			cfg:  config.CommentsConfig{Provider: "isso"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, csp := CommentsEmbed(tt.cfg, "/posts/hello/")
+			if len(tt.wantHTML) == 0 && html != "" {
+				t.Errorf("CommentsEmbed() html = %q, want empty", html)
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(string(html), want) {
+					t.Errorf("CommentsEmbed() html = %q, want it to contain %q", html, want)
+				}
+			}
+			if csp != tt.wantCSP {
+				t.Errorf("CommentsEmbed() csp = %q, want %q", csp, tt.wantCSP)
+			}
+			// An inline loader must be allowed by the hash in the CSP
+			if _, script, ok := strings.Cut(string(html), "<script>"); ok {
+				script, _, _ = strings.Cut(script, "</script>")
+				if !strings.Contains(csp, scriptHash(script)) {
+					t.Errorf("CommentsEmbed() csp = %q, want the hash of the inline loader", csp)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// FediverseAccount is a parsed "@user@instance" handle
+type FediverseAccount struct {
+	User     string
+	Instance string
+}
+
+// ParseFediverseHandle parses "@user@instance" (the leading @ is optional)
+func ParseFediverseHandle(handle string) (FediverseAccount, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(handle), "@"), "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.ContainsAny(parts[0]+parts[1], "/: ") {
+		return FediverseAccount{}, fmt.Errorf("invalid fediverse handle %q (expected @user@instance)", handle)
+	}
+	return FediverseAccount{User: parts[0], Instance: strings.ToLower(parts[1])}, nil
+}
+
+// ProfileURL returns the Mastodon-style profile page of the account
+func (a FediverseAccount) ProfileURL() string {
+	return "https://" + a.Instance + "/@" + a.User
+}
+
+// DiscussURL returns a search on the account's instance for posts linking to permalink
+func (a FediverseAccount) DiscussURL(permalink string) string {
+	return "https://" + a.Instance + "/search?q=" + url.QueryEscape(permalink)
+}
+
+type webFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type,omitempty"`
+	Href string `json:"href"`
+}
+
+type webFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases"`
+	Links   []webFingerLink `json:"links"`
+}
+
+// GenerateWebFinger writes .well-known/webfinger pointing at the account, so
+// searching for any address on the site's domain resolves to it. Static hosts
+// ignore the ?resource= query, which is exactly what a single alias needs.
+func GenerateWebFinger(destFs afero.Fs, outputDir string, account FediverseAccount) error {
+	actor := "https://" + account.Instance + "/users/" + account.User
+	doc := webFinger{
+		Subject: "acct:" + account.User + "@" + account.Instance,
+		Aliases: []string{account.ProfileURL(), actor},
+		Links: []webFingerLink{
+			{Rel: "http://webfinger.net/rel/profile-page", Type: "text/html", Href: account.ProfileURL()},
+			{Rel: "self", Type: "application/activity+json", Href: actor},
+		},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(outputDir, ".well-known")
+	if err := destFs.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return afero.WriteFile(destFs, filepath.Join(dir, "webfinger"), data, 0644)
+}
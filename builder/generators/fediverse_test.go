@@ -0,0 +1,55 @@
+package generators
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestParseFediverseHandle(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FediverseAccount
+		wantErr bool
+	}{
+		{in: "@kush@mastodon.social", want: FediverseAccount{User: "kush", Instance: "mastodon.social"}},
+		{in: "kush@Fosstodon.org", want: FediverseAccount{User: "kush", Instance: "fosstodon.org"}},
+		{in: "@kush", wantErr: true},
+		{in: "mastodon.social/@kush", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFediverseHandle(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFediverseHandle(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFediverseHandle(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateWebFinger(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	account := FediverseAccount{User: "kush", Instance: "mastodon.social"}
+	if err := GenerateWebFinger(fs, "public", account); err != nil {
+		t.Fatalf("GenerateWebFinger() error = %v", err)
+	}
+
+	data, err := afero.ReadFile(fs, "public/.well-known/webfinger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc webFinger
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Subject != "acct:kush@mastodon.social" {
+		t.Errorf("subject = %q", doc.Subject)
+	}
+	if len(doc.Links) != 2 || doc.Links[1].Href != "https://mastodon.social/users/kush" {
+		t.Errorf("links = %+v", doc.Links)
+	}
+}
@@ -0,0 +1,210 @@
+package generators
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// FeedFiles maps each feed format to the file it is written to
+var FeedFiles = map[string]string{
+	"rss":  "rss.xml",
+	"atom": "atom.xml",
+	"json": "feed.json",
+}
+
+// Feed is a list of posts syndicated in every configured format
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // URL of the page the feed follows
+	URL         string // URL of the directory the feed files are in, without a trailing slash
+	Author      string // Optional
+	Posts       []models.PostMetadata
+}
+
+// FeedPosts are the posts a feed syndicates: no drafts, newest first and at
+// most limit of them (0 for all)
+func FeedPosts(posts []models.PostMetadata, limit int) []models.PostMetadata {
+	out := make([]models.PostMetadata, 0, len(posts))
+	for _, p := range posts {
+		if !p.Draft {
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DateObj.After(out[j].DateObj) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// GenerateFeeds writes feed into dir in each of formats and returns the paths
+// written
+func GenerateFeeds(destFs afero.Fs, dir string, feed Feed, formats []string) ([]string, error) {
+	var written []string
+	for _, format := range formats {
+		name, ok := FeedFiles[format]
+		if !ok {
+			return written, fmt.Errorf("unknown feed format %q", format)
+		}
+		var data []byte
+		switch format {
+		case "rss":
+			data = rssFeed(feed.Link, feed.Posts, feed.Title, feed.Description)
+		case "atom":
+			data = atomFeed(feed, feed.URL+"/"+name)
+		case "json":
+			data = jsonFeed(feed, feed.URL+"/"+name)
+		}
+		path := filepath.Join(dir, name)
+		if err := utils.WriteFileVFS(destFs, path, data); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Size int64  `xml:"length,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomDoc struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Links    []atomLink  `xml:"link"`
+	Updated  string      `xml:"updated"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// feedUpdated is the date of the newest post, so unchanged feeds are
+// written byte for byte the same
+func feedUpdated(posts []models.PostMetadata) time.Time {
+	var updated time.Time
+	for _, p := range posts {
+		if p.DateObj.After(updated) {
+			updated = p.DateObj
+		}
+	}
+	return updated
+}
+
+// atomFeed renders an Atom 1.0 feed published at self
+func atomFeed(feed Feed, self string) []byte {
+	doc := atomDoc{
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		ID:       feed.Link,
+		Links:    []atomLink{{Href: feed.Link}, {Href: self, Rel: "self", Type: "application/atom+xml"}},
+		Updated:  feedUpdated(feed.Posts).Format(time.RFC3339),
+	}
+	if feed.Author != "" {
+		doc.Author = &atomPerson{Name: feed.Author}
+	}
+	for _, p := range feed.Posts {
+		date := p.DateObj.Format(time.RFC3339)
+		entry := atomEntry{
+			Title: p.Title, ID: p.Link, Links: []atomLink{{Href: p.Link}},
+			Published: date, Updated: date, Summary: p.Description,
+		}
+		if a := p.Audio; a != nil {
+			entry.Links = append(entry.Links, atomLink{Href: a.URL, Rel: "enclosure", Type: a.Type, Size: a.Length})
+		}
+		for _, t := range p.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: t})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	output, _ := xml.MarshalIndent(doc, "", "  ")
+	return []byte(xml.Header + string(output))
+}
+
+type jsonFeedAttachment struct {
+	URL      string `json:"url"`
+	MIMEType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes,omitempty"`
+	Duration int    `json:"duration_in_seconds,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	Summary       string               `json:"summary,omitempty"`
+	ContentText   string               `json:"content_text"`
+	DatePublished string               `json:"date_published"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// jsonFeed renders a JSON Feed 1.1 published at self
+func jsonFeed(feed Feed, self string) []byte {
+	doc := struct {
+		Version     string           `json:"version"`
+		Title       string           `json:"title"`
+		HomePageURL string           `json:"home_page_url"`
+		FeedURL     string           `json:"feed_url"`
+		Description string           `json:"description,omitempty"`
+		Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+		Items       []jsonFeedItem   `json:"items"`
+	}{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     self,
+		Description: feed.Description,
+		Items:       []jsonFeedItem{},
+	}
+	if feed.Author != "" {
+		doc.Authors = []jsonFeedAuthor{{Name: feed.Author}}
+	}
+	for _, p := range feed.Posts {
+		item := jsonFeedItem{
+			ID: p.Link, URL: p.Link, Title: p.Title, Summary: p.Description,
+			ContentText: p.Description, DatePublished: p.DateObj.Format(time.RFC3339), Tags: p.Tags,
+		}
+		if a := p.Audio; a != nil {
+			item.Attachments = []jsonFeedAttachment{{URL: a.URL, MIMEType: a.Type, Size: a.Length, Duration: a.Duration}}
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	output, _ := json.MarshalIndent(doc, "", "  ")
+	return output
+}
@@ -0,0 +1,90 @@
+package generators
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestFeedPosts(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	posts := []models.PostMetadata{
+		{Title: "Old", DateObj: day(1)},
+		{Title: "Draft", DateObj: day(9), Draft: true},
+		{Title: "New", DateObj: day(5)},
+		{Title: "Middle", DateObj: day(3)},
+	}
+	got := FeedPosts(posts, 2)
+	if len(got) != 2 || got[0].Title != "New" || got[1].Title != "Middle" {
+		t.Errorf("FeedPosts = %v", got)
+	}
+	if got := FeedPosts(posts, 0); len(got) != 3 {
+		t.Errorf("FeedPosts without a limit kept %d posts, want 3", len(got))
+	}
+}
+
+func TestGenerateFeeds(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	base := "https://example.com"
+	feed := Feed{
+		Title: "#go | Site", Description: "Desc", Author: "Ada",
+		Link: base + "/tags/go.html", URL: base + "/tags/go",
+		Posts: []models.PostMetadata{
+			{Title: "Post", Link: base + "/post.html", Description: "About Go", Tags: []string{"go"}, DateObj: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	written, err := GenerateFeeds(fs, "public/tags/go", feed, []string{"rss", "atom", "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 3 {
+		t.Fatalf("GenerateFeeds wrote %v", written)
+	}
+
+	rss, _ := afero.ReadFile(fs, filepath.Join("public", "tags", "go", "rss.xml"))
+	if !strings.Contains(string(rss), "<link>https://example.com/tags/go.html</link>") {
+		t.Errorf("rss.xml doesn't link the tag page:\n%s", rss)
+	}
+
+	atom, _ := afero.ReadFile(fs, filepath.Join("public", "tags", "go", "atom.xml"))
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<link href="https://example.com/tags/go/atom.xml" rel="self" type="application/atom+xml"></link>`,
+		`<updated>2024-02-01T00:00:00Z</updated>`,
+		`<name>Ada</name>`,
+		`<category term="go"></category>`,
+	} {
+		if !strings.Contains(string(atom), want) {
+			t.Errorf("atom.xml missing %s:\n%s", want, atom)
+		}
+	}
+
+	data, _ := afero.ReadFile(fs, filepath.Join("public", "tags", "go", "feed.json"))
+	var doc struct {
+		Version string `json:"version"`
+		FeedURL string `json:"feed_url"`
+		Items   []struct {
+			ID            string `json:"id"`
+			DatePublished string `json:"date_published"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "https://jsonfeed.org/version/1.1" || doc.FeedURL != base+"/tags/go/feed.json" {
+		t.Errorf("feed.json = %+v", doc)
+	}
+	if len(doc.Items) != 1 || doc.Items[0].ID != base+"/post.html" || doc.Items[0].DatePublished != "2024-02-01T00:00:00Z" {
+		t.Errorf("feed.json items = %+v", doc.Items)
+	}
+
+	if _, err := GenerateFeeds(fs, "public", feed, []string{"rdf"}); err == nil {
+		t.Error("GenerateFeeds accepted an unknown format")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/spf13/afero"
 
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
@@ -37,6 +38,6 @@ func GenerateGraph(destFs afero.Fs, baseURL string, posts []models.PostMetadata,
 	}
 	output, _ := json.Marshal(models.GraphData{Nodes: nodes, Links: links})
 	if err := utils.WriteFileVFS(destFs, outputPath, output); err != nil {
-		fmt.Printf("⚠️ Failed to write graph.json: %v\n", err)
+		logging.Statusf("⚠️ Failed to write graph.json: %v", err)
 	}
 }
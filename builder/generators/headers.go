@@ -0,0 +1,213 @@
+package generators
+
+import (
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// Classes of output files in the Cache-Control policy
+const (
+	CacheAssets  = "assets"
+	CacheHTML    = "html"
+	CacheFeeds   = "feeds"
+	CacheImages  = "images"
+	CacheDefault = "default"
+)
+
+var (
+	feedExts  = map[string]bool{".xml": true, ".json": true, ".bin": true, ".rss": true, ".atom": true}
+	imageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true, ".svg": true, ".ico": true}
+)
+
+// IsHashedAsset reports whether a file name carries a content hash
+// (name.<8-12 hex digits>.ext, or name.<hash>-480w.ext for the smaller
+// copies of a hashed image), so its content never changes
+func IsHashedAsset(filename string) bool {
+	parts := strings.Split(filename, ".")
+	if len(parts) < 3 {
+		return false
+	}
+	hash := parts[len(parts)-2]
+	if i := strings.LastIndexByte(hash, '-'); i >= 0 && isWidthSuffix(hash[i+1:]) {
+		hash = hash[:i]
+	}
+	if len(hash) < 8 || len(hash) > 12 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isWidthSuffix reports whether s is the width of a responsive copy ("480w")
+func isWidthSuffix(s string) bool {
+	digits, ok := strings.CutSuffix(s, "w")
+	if !ok || digits == "" {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CacheClass is the class of an output file by its path; a path ending in
+// "/" is a page
+func CacheClass(relPath string) string {
+	relPath = filepath.ToSlash(relPath)
+	if relPath == "" || strings.HasSuffix(relPath, "/") {
+		return CacheHTML
+	}
+	name := path.Base(relPath)
+	ext := strings.ToLower(path.Ext(name))
+	switch {
+	case IsHashedAsset(name):
+		return CacheAssets
+	case ext == ".html" || ext == ".htm":
+		return CacheHTML
+	case feedExts[ext]:
+		return CacheFeeds
+	case imageExts[ext]:
+		return CacheImages
+	}
+	return CacheDefault
+}
+
+// CacheControlFor is the Cache-Control value of an output file, "" when the
+// policy sends none
+func CacheControlFor(policy config.CacheControlConfig, relPath string) string {
+	var value string
+	switch CacheClass(relPath) {
+	case CacheAssets:
+		value = policy.Assets
+	case CacheHTML:
+		value = policy.HTML
+	case CacheFeeds:
+		value = policy.Feeds
+	case CacheImages:
+		value = policy.Images
+	default:
+		value = policy.Default
+	}
+	if value == "none" {
+		return ""
+	}
+	return value
+}
+
+// MaxHeaderRules is how many _headers rules Cloudflare Pages reads
+const MaxHeaderRules = 100
+
+// mixedValues marks a directory whose files don't share one Cache-Control
+// value
+const mixedValues = "\x00"
+
+// GenerateHeaders writes outputDir/_headers with the Cache-Control value of
+// every file in the output and the Content-Type of generated files whose
+// extension doesn't give it (ContentTypeFor), and returns how many rules it
+// wrote. A directory whose files all share a value gets one wildcard rule
+// (/static/images/*); the files of the others are listed one by one, pages
+// also by their directory URL. Hosts merge the headers of every rule a URL
+// matches, so no file is under two Cache-Control rules.
+func GenerateHeaders(destFs afero.Fs, outputDir string, policy config.CacheControlConfig) (int, error) {
+	values := map[string]string{} // By file, "" for none
+	dirs := map[string]string{}   // Value shared by the files under a directory ("" is the root), or mixedValues
+	err := afero.Walk(destFs, outputDir, func(file string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outputDir, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "_headers" || rel == "_redirects" {
+			return nil
+		}
+		value := CacheControlFor(policy, rel)
+		values[rel] = value
+		for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+			if dir == "." {
+				dir = ""
+			}
+			if prev, ok := dirs[dir]; !ok {
+				dirs[dir] = value
+			} else if prev != value {
+				dirs[dir] = mixedValues
+			}
+			if dir == "" {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	rules := map[string][]string{} // Header lines by URL pattern
+	add := func(url, header string) {
+		if !slices.Contains(rules[url], header) {
+			rules[url] = append(rules[url], header)
+		}
+	}
+	for rel, value := range values {
+		if contentType := ContentTypeFor(rel); contentType != "" {
+			add("/"+rel, "Content-Type: "+contentType)
+		}
+		if value == "" {
+			continue
+		}
+		if dir, ok := uniformDir(dirs, rel); ok {
+			add(strings.TrimSuffix("/"+dir, "/")+"/*", "Cache-Control: "+value)
+			continue
+		}
+		add("/"+rel, "Cache-Control: "+value)
+		if path.Base(rel) == "index.html" {
+			add(strings.TrimSuffix("/"+rel, "index.html"), "Cache-Control: "+value)
+		}
+	}
+
+	urls := slices.Sorted(maps.Keys(rules))
+	var b strings.Builder
+	for _, url := range urls {
+		b.WriteString(url + "\n")
+		for _, header := range rules[url] {
+			b.WriteString("  " + header + "\n")
+		}
+	}
+	return len(urls), utils.WriteFileVFS(destFs, filepath.Join(outputDir, "_headers"), []byte(b.String()))
+}
+
+// uniformDir is the outermost directory above rel whose files all share one
+// Cache-Control value
+func uniformDir(dirs map[string]string, rel string) (string, bool) {
+	dir := ""
+	for _, segment := range strings.Split(path.Dir(rel), "/") {
+		if dirs[dir] != mixedValues {
+			return dir, true
+		}
+		if segment == "." {
+			break
+		}
+		dir = path.Join(dir, segment)
+	}
+	if dirs[dir] != mixedValues {
+		return dir, true
+	}
+	return "", false
+}
@@ -0,0 +1,75 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestCacheClass(t *testing.T) {
+	tests := map[string]string{
+		"static/css/theme.1a2b3c4d.css":           CacheAssets,
+		"static/js/app.js":                        CacheDefault,
+		"guides/setup.html":                       CacheHTML,
+		"guides/":                                 CacheHTML,
+		"rss.xml":                                 CacheFeeds,
+		"search.bin":                              CacheFeeds,
+		"static/images/cards/home.webp":           CacheImages,
+		"static/images/logo.abcdef12.png":         CacheAssets,
+		"static/media/photo.1a2b3c4d5e-480w.webp": CacheAssets,
+		"static/images/photo-480w.webp":           CacheImages,
+		"static/images/photo.draft-480w.webp":     CacheImages,
+	}
+	for path, want := range tests {
+		if got := CacheClass(path); got != want {
+			t.Errorf("CacheClass(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestGenerateHeaders(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, f := range []string{
+		"public/index.html", "public/guides/index.html", "public/guides/setup/index.html", "public/rss.xml", "public/_redirects",
+		"public/static/css/theme.1a2b3c4d.css", "public/static/css/print.5e6f7a8b.css", "public/static/app.js",
+		"public/blog/index.html", "public/blog/feed.xml",
+		"public/.well-known/webfinger", "public/manifest.webmanifest",
+	} {
+		_ = afero.WriteFile(fs, f, []byte("x"), 0644)
+	}
+	policy := config.CacheControlConfig{Assets: "immutable", HTML: "no-cache", Feeds: "max-age=60", Default: "none"}
+	rules, err := GenerateHeaders(fs, "public", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := afero.ReadFile(fs, "public/_headers")
+	want := `/
+  Cache-Control: no-cache
+/.well-known/webfinger
+  Content-Type: application/jrd+json
+/blog/
+  Cache-Control: no-cache
+/blog/feed.xml
+  Cache-Control: max-age=60
+/blog/index.html
+  Cache-Control: no-cache
+/guides/*
+  Cache-Control: no-cache
+/index.html
+  Cache-Control: no-cache
+/manifest.webmanifest
+  Content-Type: application/manifest+json
+/rss.xml
+  Cache-Control: max-age=60
+/static/css/*
+  Cache-Control: immutable
+`
+	if rules != 10 {
+		t.Errorf("rules = %d, want 10", rules)
+	}
+	if string(got) != want {
+		t.Errorf("_headers =\n%s\nwant\n%s", got, want)
+	}
+}
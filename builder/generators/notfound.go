@@ -0,0 +1,34 @@
+package generators
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/url"
+	"slices"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// NotFoundIndex returns a compact JSON map of URL path to title for the
+// search records of the given versions ("" for unversioned pages), which the
+// 404 page searches client-side to suggest where a broken link was meant to go
+func NotFoundIndex(records []models.PostRecord, baseURL string, versions ...string) template.JS {
+	pages := make(map[string]string)
+	for _, r := range records {
+		if !slices.Contains(versions, r.Version) {
+			continue
+		}
+		u, err := url.Parse(utils.BuildURL(baseURL, "", r.Link))
+		if err != nil || u.Path == "" {
+			continue
+		}
+		pages[u.Path] = r.Title
+	}
+	data, err := json.Marshal(pages)
+	if err != nil {
+		return "{}"
+	}
+	// json.Marshal escapes <, > and &, so the result is safe inside <script>
+	return template.JS(data)
+}
@@ -0,0 +1,33 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestNotFoundIndex(t *testing.T) {
+	records := []models.PostRecord{
+		{Title: "About", Link: "about.html"},
+		{Title: "Install", Link: "v2.0/install.html", Version: "v2.0"},
+		{Title: "Old Install", Link: "v1.0/install.html", Version: "v1.0"},
+		{Title: "<Tags & Things>", Link: "v2.0/tags.html", Version: "v2.0"},
+	}
+
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+	}{
+		{"latest and unversioned", []string{"", "v2.0"}, `{"/docs/about.html":"About","/docs/v2.0/install.html":"Install","/docs/v2.0/tags.html":"\u003cTags \u0026 Things\u003e"}`},
+		{"single version", []string{"v1.0"}, `{"/docs/v1.0/install.html":"Old Install"}`},
+		{"unknown version", []string{"v9.0"}, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(NotFoundIndex(records, "https://example.com/docs", tt.versions...)); got != tt.want {
+				t.Errorf("NotFoundIndex() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
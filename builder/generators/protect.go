@@ -0,0 +1,96 @@
+package generators
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// ProtectIterations is the PBKDF2-SHA256 work factor for password-protected
+// pages (OWASP's recommendation). It is written into the page, so raising it
+// never breaks pages built earlier.
+const ProtectIterations = 600_000
+
+// ProtectContent encrypts a page body with AES-256-GCM under a key derived
+// from password, and returns the markup that replaces it: an unlock form, the
+// ciphertext and a script that decrypts it in the browser with WebCrypto.
+// Salt and nonce are fresh on every call.
+func ProtectContent(body, password string) (string, error) {
+	salt := make([]byte, 16)
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, ProtectIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nil, nonce, []byte(body), nil)
+
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf(protectTemplate, enc(salt), enc(nonce), ProtectIterations, enc(ciphertext)), nil
+}
+
+// protectTemplate is filled with salt, nonce, iterations and ciphertext. The
+// password is kept in sessionStorage for the page, so a reload stays unlocked
+// until the tab is closed.
+const protectTemplate = `<div class="kosh-protected" data-salt="%s" data-iv="%s" data-iter="%d" data-ct="%s">
+<form class="kosh-protected-form">
+<p>This page is password protected.</p>
+<input type="password" name="password" autocomplete="current-password" aria-label="Password" placeholder="Password" required>
+<button type="submit">Unlock</button>
+<p class="kosh-protected-error" role="alert" hidden>Wrong password.</p>
+</form>
+<noscript><p>JavaScript is required to unlock this page.</p></noscript>
+</div>
+<script>
+(function () {
+  var box = document.currentScript.previousElementSibling;
+  var form = box.querySelector('form');
+  var store = 'kosh-pw:' + location.pathname;
+  var b64 = function (s) { return Uint8Array.from(atob(s), function (c) { return c.charCodeAt(0); }); };
+  function unlock(pw) {
+    var d = box.dataset;
+    return crypto.subtle.importKey('raw', new TextEncoder().encode(pw), 'PBKDF2', false, ['deriveKey'])
+      .then(function (base) {
+        return crypto.subtle.deriveKey({ name: 'PBKDF2', salt: b64(d.salt), iterations: +d.iter, hash: 'SHA-256' },
+          base, { name: 'AES-GCM', length: 256 }, false, ['decrypt']);
+      })
+      .then(function (key) { return crypto.subtle.decrypt({ name: 'AES-GCM', iv: b64(d.iv) }, key, b64(d.ct)); })
+      .then(function (plain) {
+        try { sessionStorage.setItem(store, pw); } catch (e) {}
+        var tpl = document.createElement('template');
+        tpl.innerHTML = new TextDecoder().decode(plain);
+        box.replaceWith(tpl.content);
+        document.dispatchEvent(new CustomEvent('kosh:unlocked'));
+      });
+  }
+  form.addEventListener('submit', function (e) {
+    e.preventDefault();
+    form.querySelector('button').disabled = true;
+    unlock(form.password.value).catch(function () {
+      form.querySelector('.kosh-protected-error').hidden = false;
+      form.querySelector('button').disabled = false;
+      form.password.select();
+    });
+  });
+  var saved = null;
+  try { saved = sessionStorage.getItem(store); } catch (e) {}
+  if (saved) unlock(saved).catch(function () { try { sessionStorage.removeItem(store); } catch (e) {} });
+})();
+</script>`
@@ -0,0 +1,69 @@
+package generators
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var protectAttrs = regexp.MustCompile(`data-salt="([^"]+)" data-iv="([^"]+)" data-iter="(\d+)" data-ct="([^"]+)"`)
+
+// decryptProtected does what the unlock script does in the browser
+func decryptProtected(t *testing.T, markup, password string) (string, error) {
+	t.Helper()
+	m := protectAttrs.FindStringSubmatch(markup)
+	if m == nil {
+		t.Fatalf("no encrypted payload in %q", markup)
+	}
+	dec := func(s string) []byte {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	iter, _ := strconv.Atoi(m[3])
+	key, err := pbkdf2.Key(sha256.New, password, dec(m[1]), iter, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, dec(m[2]), dec(m[4]), nil)
+	return string(plain), err
+}
+
+func TestProtectContent(t *testing.T) {
+	body := "<h2 id=\"plan\">The plan</h2><p>Launch on <strong>Friday</strong>.</p>"
+	out, err := ProtectContent(body, "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"The plan", "Friday", "hunter2"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("protected markup contains %q", leak)
+		}
+	}
+	if !strings.Contains(out, `data-iter="600000"`) {
+		t.Error("iteration count missing from the markup")
+	}
+
+	got, err := decryptProtected(t, out, "hunter2")
+	if err != nil || got != body {
+		t.Errorf("decrypt = %q, %v; want the original body", got, err)
+	}
+	if _, err := decryptProtected(t, out, "hunter3"); err == nil {
+		t.Error("wrong password decrypted the page")
+	}
+
+	again, _ := ProtectContent(body, "hunter2")
+	if protectAttrs.FindStringSubmatch(again)[1] == protectAttrs.FindStringSubmatch(out)[1] {
+		t.Error("salt reused across calls")
+	}
+}
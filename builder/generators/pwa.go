@@ -1,16 +1,42 @@
 package generators
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 	"text/template"
 
-	"github.com/disintegration/imaging"
 	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
+// PWAStrategies lists the runtime caching strategies the service worker implements
+var PWAStrategies = []string{"network-first", "cache-first", "stale-while-revalidate", "network-only", "cache-only"}
+
+// defaultPWARoutes is used when the config doesn't define any routes: pages
+// stay fresh, versioned static files come from cache and images are served
+// from cache while being refreshed in the background
+var defaultPWARoutes = []config.PWARoute{
+	{Pattern: `(\.html|/)$`, Strategy: "network-first", CacheName: "pages"},
+	{Pattern: `/static/.*\.(css|js|wasm|woff2?)$`, Strategy: "cache-first", CacheName: "static"},
+	{Pattern: `\.(png|jpe?g|gif|webp|avif|svg|ico)$`, Strategy: "stale-while-revalidate", CacheName: "images", MaxEntries: 100},
+	{Pattern: `\.(json|bin)$`, Strategy: "network-first", CacheName: "data"},
+}
+
+// swRoute is a PWARoute as embedded into the service worker
+type swRoute struct {
+	Pattern    string `json:"pattern"`
+	Strategy   string `json:"strategy"`
+	CacheName  string `json:"cacheName"`
+	MaxEntries int    `json:"maxEntries,omitempty"`
+}
+
 // GenerateSW creates the service worker only if needed (smart build)
-func GenerateSW(destFs afero.Fs, destDir string, buildVersion int64, forceRebuild bool, baseURL string, assets map[string]string) error {
+func GenerateSW(destFs afero.Fs, destDir string, buildVersion int64, forceRebuild bool, baseURL string, assets map[string]string, pwa config.PWAConfig, hooks SWHooks) error {
 	swPath := filepath.Join(destDir, "sw.js")
 
 	// 1. Smart Check: If not forcing rebuild and SW exists, skip
@@ -20,21 +46,183 @@ func GenerateSW(destFs afero.Fs, destDir string, buildVersion int64, forceRebuil
 		}
 	}
 
+	routes := pwa.Routes
+	if len(routes) == 0 {
+		routes = defaultPWARoutes
+	}
+	swRoutes := make([]swRoute, 0, len(routes))
+	for _, r := range routes {
+		if r.Pattern == "" {
+			return fmt.Errorf("pwa route is missing a pattern")
+		}
+		if !slices.Contains(PWAStrategies, r.Strategy) {
+			return fmt.Errorf("pwa route %q: unknown strategy %q", r.Pattern, r.Strategy)
+		}
+		swRoutes = append(swRoutes, swRoute{
+			Pattern:    r.Pattern,
+			Strategy:   r.Strategy,
+			CacheName:  orDefault(r.CacheName, "runtime"),
+			MaxEntries: r.MaxEntries,
+		})
+	}
+	routesJSON, err := json.Marshal(swRoutes)
+	if err != nil {
+		return err
+	}
+	offlineURL := ""
+	if pwa.OfflinePage != "" {
+		offlineURL = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(pwa.OfflinePage, "/")
+	}
+	offlineJSON, _ := json.Marshal(offlineURL)
+
 	swTemplate := `
 const CACHE_NAME = 'kush-blog-cache-v{{ .Version }}';
 const STATIC_CACHE = 'kush-blog-static-v{{ .Version }}';
+const RUNTIME_PREFIX = 'kush-blog-runtime-';
 
 // Dev hostnames to disable caching
 const DEV_HOSTS = ['localhost', '127.0.0.1', '0.0.0.0'];
 
+// Page served when a navigation fails offline ('' disables the fallback)
+const OFFLINE_URL = {{ .OfflineURL }};
+
 // Core app shell assets
 const CORE_ASSETS = [
     '{{ .BaseURL }}/',
     '{{ .BaseURL }}/index.html',
     '{{ .BaseURL }}/404.html',
-    '{{ .BaseURL }}/manifest.json'{{ range .CriticalAssets }},
+    '{{ .BaseURL }}/manifest.webmanifest'{{ range .CriticalAssets }},
     '{{ $.BaseURL }}{{ . }}'{{ end }}
 ];
+
+// Runtime caching rules, first match wins. Invalid patterns are skipped
+// rather than breaking the whole worker.
+const ROUTES = {{ .Routes }}.map((route) => {
+    try {
+        return Object.assign({}, route, {
+            regex: new RegExp(route.pattern),
+            cache: RUNTIME_PREFIX + route.cacheName + '-v{{ .Version }}'
+        });
+    } catch (err) {
+        console.warn('Skipping invalid service worker route', route.pattern, err);
+        return null;
+    }
+}).filter(Boolean);
+
+const isDev = () => DEV_HOSTS.includes(self.location.hostname);
+
+// kosh:hook head
+{{ index .Hooks "head" }}
+
+// User extension points (pwa.hooks). install/activate may return a promise
+// to extend the event; fetch returns true once it has called respondWith.
+const HOOKS = {
+    install: function (event) {
+        // kosh:hook install
+{{ index .Hooks "install" }}
+    },
+    activate: function (event) {
+        // kosh:hook activate
+{{ index .Hooks "activate" }}
+    },
+    fetch: function (event, url) {
+        // kosh:hook fetch
+{{ index .Hooks "fetch" }}
+    }
+};
+
+self.addEventListener('install', (event) => {
+    if (isDev()) {
+        self.skipWaiting();
+        return;
+    }
+    // Assets are added one by one so a single missing file (e.g. a theme
+    // without main.js) doesn't fail the whole install
+    const assets = OFFLINE_URL ? CORE_ASSETS.concat(OFFLINE_URL) : CORE_ASSETS;
+    event.waitUntil(
+        caches.open(CACHE_NAME)
+            .then((cache) => Promise.all(assets.map((asset) => cache.add(asset).catch(() => {}))))
+            .then(() => HOOKS.install(event))
+            .then(() => self.skipWaiting())
+    );
+});
+
+self.addEventListener('activate', (event) => {
+    const keep = [CACHE_NAME, STATIC_CACHE].concat(ROUTES.map((route) => route.cache));
+    event.waitUntil(
+        caches.keys()
+            .then((keys) => Promise.all(keys
+                .filter((key) => key.startsWith('kush-blog-') && !keep.includes(key))
+                .map((key) => caches.delete(key))))
+            .then(() => HOOKS.activate(event))
+            .then(() => self.clients.claim())
+    );
+});
+
+function store(route, request, response) {
+    if (!response || !response.ok) {
+        return;
+    }
+    caches.open(route.cache).then((cache) => cache.put(request, response).then(() => {
+        if (!route.maxEntries) {
+            return;
+        }
+        return cache.keys().then((keys) => Promise.all(
+            keys.slice(0, Math.max(0, keys.length - route.maxEntries)).map((key) => cache.delete(key))
+        ));
+    }));
+}
+
+function fromNetwork(route, request) {
+    return fetch(request).then((response) => {
+        store(route, request, response.clone());
+        return response;
+    });
+}
+
+const STRATEGIES = {
+    'network-first': (route, request) => fromNetwork(route, request).catch(() => caches.match(request)),
+    'cache-first': (route, request) => caches.match(request).then((hit) => hit || fromNetwork(route, request)),
+    'stale-while-revalidate': (route, request) => caches.match(request).then((hit) => {
+        const network = fromNetwork(route, request).catch(() => hit);
+        return hit || network;
+    }),
+    'network-only': (route, request) => fetch(request),
+    'cache-only': (route, request) => caches.match(request)
+};
+
+function fallback(request) {
+    if (request.mode === 'navigate' && OFFLINE_URL) {
+        return caches.match(OFFLINE_URL).then((page) => page || Response.error());
+    }
+    return Response.error();
+}
+
+self.addEventListener('fetch', (event) => {
+    const request = event.request;
+    if (isDev()) {
+        return;
+    }
+    const url = new URL(request.url);
+    if (HOOKS.fetch(event, url) === true) {
+        return;
+    }
+    if (request.method !== 'GET' || url.origin !== self.location.origin) {
+        return;
+    }
+    const route = ROUTES.find((r) => r.regex.test(url.pathname));
+    if (!route) {
+        return;
+    }
+    event.respondWith(
+        STRATEGIES[route.strategy](route, request)
+            .then((response) => response || fallback(request))
+            .catch(() => fallback(request))
+    );
+});
+
+// kosh:hook tail
+{{ index .Hooks "tail" }}
 `
 
 	tmpl, err := template.New("sw").Parse(swTemplate)
@@ -55,9 +243,15 @@ const CORE_ASSETS = [
 		Version        int64
 		BaseURL        string
 		CriticalAssets []string
+		OfflineURL     string
+		Routes         string
+		Hooks          SWHooks
 	}{
-		Version: buildVersion,
-		BaseURL: baseURL,
+		Version:    buildVersion,
+		BaseURL:    baseURL,
+		OfflineURL: string(offlineJSON),
+		Routes:     string(routesJSON),
+		Hooks:      hooks,
 	}
 
 	// Identify critical assets to pre-cache
@@ -81,9 +275,34 @@ const CORE_ASSETS = [
 	return tmpl.Execute(f, data)
 }
 
-// GenerateManifest creates the manifest.json dynamically with a smart build check
-func GenerateManifest(destFs afero.Fs, destDir string, baseURL string, siteTitle string, siteDescription string, forceRebuild bool) error {
-	manifestPath := filepath.Join(destDir, "manifest.json")
+// ManifestFile is the web app manifest written to the output root
+const ManifestFile = "manifest.webmanifest"
+
+// webManifest is the subset of the Web App Manifest spec Kosh fills in
+type webManifest struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	ShortName       string         `json:"short_name"`
+	Description     string         `json:"description,omitempty"`
+	StartURL        string         `json:"start_url"`
+	Scope           string         `json:"scope"`
+	Display         string         `json:"display"`
+	BackgroundColor string         `json:"background_color"`
+	ThemeColor      string         `json:"theme_color"`
+	Icons           []manifestIcon `json:"icons"`
+}
+
+type manifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose"`
+}
+
+// GenerateManifest creates manifest.webmanifest listing the icons written by
+// RenderPWAIcons, with a smart build check
+func GenerateManifest(destFs afero.Fs, destDir, siteTitle, siteDescription string, pwa config.PWAConfig, forceRebuild bool) error {
+	manifestPath := filepath.Join(destDir, ManifestFile)
 
 	// 1. Smart Check: If not forcing rebuild and manifest exists, skip
 	if !forceRebuild {
@@ -92,120 +311,32 @@ func GenerateManifest(destFs afero.Fs, destDir string, baseURL string, siteTitle
 		}
 	}
 
-	manifestTemplate := `{
-    "name": "{{ .Title }}",
-    "short_name": "{{ .Title }}",
-    "start_url": "./",
-    "display": "standalone",
-    "background_color": "#111113",
-    "theme_color": "#111113",
-    "description": "{{ .Description }}",
-    "icons": [
-        {
-            "src": "static/images/icon-192.png",
-            "sizes": "192x192",
-            "type": "image/png",
-            "purpose": "any"
-        },
-        {
-            "src": "static/images/icon-192.png",
-            "sizes": "192x192",
-            "type": "image/png",
-            "purpose": "maskable"
-        },
-        {
-            "src": "static/images/icon-512.png",
-            "sizes": "512x512",
-            "type": "image/png",
-            "purpose": "any"
-        },
-        {
-            "src": "static/images/icon-512.png",
-            "sizes": "512x512",
-            "type": "image/png",
-            "purpose": "maskable"
-        }
-    ],
-    "id": "./",
-    "scope": "./"
-}
-`
-
-	tmpl, err := template.New("manifest").Parse(manifestTemplate)
-	if err != nil {
-		return err
+	manifest := webManifest{
+		ID:              "./",
+		Name:            siteTitle,
+		ShortName:       orDefault(pwa.ShortName, siteTitle),
+		Description:     siteDescription,
+		StartURL:        "./",
+		Scope:           "./",
+		Display:         orDefault(pwa.Display, "standalone"),
+		BackgroundColor: orDefault(pwa.BackgroundColor, DefaultPWAColor),
+		ThemeColor:      orDefault(pwa.ThemeColor, DefaultPWAColor),
 	}
-
-	if err := destFs.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
-		return err
-	}
-	f, err := destFs.Create(manifestPath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-
-	data := struct {
-		Title       string
-		Description string
-		BaseURL     string
-	}{
-		Title:       siteTitle,
-		Description: siteDescription,
-		BaseURL:     baseURL,
-	}
-
-	return tmpl.Execute(f, data)
-}
-
-// GeneratePWAIcons generates 192x192 and 512x512 icons from favicon.png
-func GeneratePWAIcons(srcFs afero.Fs, destFs afero.Fs, srcPath, destDir string) error {
-	// Source must exist
-	srcFile, err := srcFs.Open(srcPath)
-	if err != nil {
-		return fmt.Errorf("source icon not found: %w", err)
+	for _, icon := range pwaIcons {
+		if icon.purpose == "" {
+			continue // Not a manifest icon (favicon, apple-touch-icon)
+		}
+		manifest.Icons = append(manifest.Icons, manifestIcon{
+			Src:     icon.path,
+			Sizes:   fmt.Sprintf("%dx%d", icon.size, icon.size),
+			Type:    "image/png",
+			Purpose: icon.purpose,
+		})
 	}
-	defer func() { _ = srcFile.Close() }()
 
-	// Open source image
-	src, err := imaging.Decode(srcFile)
+	data, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
 		return err
 	}
-
-	// Create dest dir if needed
-	if err := destFs.MkdirAll(destDir, 0755); err != nil {
-		return err
-	}
-
-	sizes := []int{192, 512}
-
-	for _, size := range sizes {
-		destFile := filepath.Join(destDir, fmt.Sprintf("icon-%d.png", size))
-
-		// Check if destination exists in VFS.
-		// Note: We don't check modtime here easily against source stream without Stat'ing source path again.
-		// For simplicity in VFS build (which might be fresh), just generate.
-		// If we want incremental, we rely on checking if file exists in VFS (it won't if VFS is fresh).
-
-		fmt.Printf("   🎨 Generating PWA Icon: %dx%d\n", size, size)
-
-		// Resize
-		dst := imaging.Resize(src, size, size, imaging.Lanczos)
-
-		// Save to VFS
-		f, err := destFs.Create(destFile)
-		if err != nil {
-			return err
-		}
-
-		// Encode as PNG
-		err = imaging.Encode(f, dst, imaging.PNG)
-		_ = f.Close()
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return utils.WriteFileVFS(destFs, manifestPath, data)
 }
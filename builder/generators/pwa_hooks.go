@@ -0,0 +1,52 @@
+package generators
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/spf13/afero"
+)
+
+// SWHookPoints are the extension points of the generated service worker.
+// head and tail are top-level code; install, activate and fetch are function
+// bodies called from the matching event listener.
+var SWHookPoints = []string{"head", "install", "activate", "fetch", "tail"}
+
+// SWHooks maps an extension point to the JavaScript injected there
+type SWHooks map[string]string
+
+// LoadSWHooks reads the hook files configured under pwa.hooks and checks that
+// each one parses as JavaScript in the position it is injected into, so a
+// typo fails the build instead of silently breaking the deployed worker
+func LoadSWHooks(srcFs afero.Fs, paths map[string]string) (SWHooks, error) {
+	hooks := make(SWHooks, len(paths))
+	for point, path := range paths {
+		if !slices.Contains(SWHookPoints, point) {
+			return nil, fmt.Errorf("unknown service worker hook %q (expected %s)", point, strings.Join(SWHookPoints, ", "))
+		}
+		data, err := afero.ReadFile(srcFs, path)
+		if err != nil {
+			return nil, fmt.Errorf("service worker hook %q: %w", point, err)
+		}
+		code := string(data)
+		if err := validateSWHook(point, code); err != nil {
+			return nil, fmt.Errorf("service worker hook %q (%s): %w", point, path, err)
+		}
+		hooks[point] = code
+	}
+	return hooks, nil
+}
+
+// validateSWHook parses code as it will appear in sw.js. Function-body hooks
+// are wrapped in a function so `return` is accepted. The newline before the
+// closing brace keeps a trailing line comment from swallowing it.
+func validateSWHook(point, code string) error {
+	src := code
+	if point != "head" && point != "tail" {
+		src = "(function (event, url) {\n" + code + "\n})"
+	}
+	_, err := goja.Parse(point+".js", src)
+	return err
+}
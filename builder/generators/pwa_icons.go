@@ -0,0 +1,162 @@
+package generators
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// DefaultPWAColor is the manifest theme/background color when none is configured
+const DefaultPWAColor = "#111113"
+
+// pwaIcon is one file produced by RenderPWAIcons. purpose is the manifest
+// purpose, or "" for files that aren't listed in the manifest.
+type pwaIcon struct {
+	path    string
+	size    int
+	purpose string
+}
+
+// maskableSafeZone is the fraction of a maskable icon guaranteed to stay
+// visible under any mask shape
+const maskableSafeZone = 0.8
+
+// pwaIcons lists every icon generated from the source image, relative to the
+// output directory
+var pwaIcons = []pwaIcon{
+	{path: "static/images/icons/icon-48.png", size: 48, purpose: "any"},
+	{path: "static/images/icons/icon-72.png", size: 72, purpose: "any"},
+	{path: "static/images/icons/icon-96.png", size: 96, purpose: "any"},
+	{path: "static/images/icons/icon-144.png", size: 144, purpose: "any"},
+	{path: "static/images/icons/icon-192.png", size: 192, purpose: "any"},
+	{path: "static/images/icons/icon-256.png", size: 256, purpose: "any"},
+	{path: "static/images/icons/icon-384.png", size: 384, purpose: "any"},
+	{path: "static/images/icons/icon-512.png", size: 512, purpose: "any"},
+	{path: "static/images/icons/icon-maskable-192.png", size: 192, purpose: "maskable"},
+	{path: "static/images/icons/icon-maskable-512.png", size: 512, purpose: "maskable"},
+	{path: "apple-touch-icon.png", size: 180},
+}
+
+// faviconSizes are the PNG images bundled into favicon.ico
+var faviconSizes = []int{16, 32, 48}
+
+// PWAIconFiles returns the output-relative paths RenderPWAIcons produces
+func PWAIconFiles() []string {
+	files := make([]string, 0, len(pwaIcons)+1)
+	for _, icon := range pwaIcons {
+		files = append(files, icon.path)
+	}
+	return append(files, "favicon.ico")
+}
+
+// RenderPWAIcons renders every icon size, the maskable variants,
+// apple-touch-icon.png and favicon.ico from a single source image, keyed by
+// output-relative path. Maskable and Apple icons sit on the background color
+// since both platforms show them without transparency.
+func RenderPWAIcons(source []byte, background string) (map[string][]byte, error) {
+	src, err := imaging.Decode(bytes.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode icon source: %w", err)
+	}
+	bg, err := parseHexColor(orDefault(background, DefaultPWAColor))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(pwaIcons)+1)
+	for _, icon := range pwaIcons {
+		var img image.Image
+		switch {
+		case icon.purpose == "maskable":
+			img = placeIcon(src, icon.size, int(float64(icon.size)*maskableSafeZone), bg)
+		case icon.purpose == "":
+			img = placeIcon(src, icon.size, icon.size, bg)
+		default:
+			img = placeIcon(src, icon.size, icon.size, color.Transparent)
+		}
+		data, err := encodePNG(img)
+		if err != nil {
+			return nil, err
+		}
+		files[icon.path] = data
+	}
+
+	ico, err := encodeICO(src, faviconSizes)
+	if err != nil {
+		return nil, err
+	}
+	files["favicon.ico"] = ico
+	return files, nil
+}
+
+// placeIcon fits src into an inner×inner box centered on a size×size canvas
+func placeIcon(src image.Image, size, inner int, bg color.Color) image.Image {
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	fitted := imaging.Fit(src, inner, inner, imaging.Lanczos)
+	offset := image.Pt((size-fitted.Bounds().Dx())/2, (size-fitted.Bounds().Dy())/2)
+	draw.Draw(canvas, fitted.Bounds().Add(offset), fitted, image.Point{}, draw.Over)
+	return canvas
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeICO writes an ICO file with one PNG-compressed image per size,
+// which every browser supporting favicon.ico understands
+func encodeICO(src image.Image, sizes []int) ([]byte, error) {
+	images := make([][]byte, len(sizes))
+	for i, size := range sizes {
+		data, err := encodePNG(placeIcon(src, size, size, color.Transparent))
+		if err != nil {
+			return nil, err
+		}
+		images[i] = data
+	}
+
+	var buf bytes.Buffer
+	// ICONDIR: reserved, type (1 = icon), image count
+	_ = binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(sizes))})
+	offset := 6 + 16*len(sizes)
+	for i, size := range sizes {
+		dim := uint8(size) // 0 means 256
+		if size >= 256 {
+			dim = 0
+		}
+		// ICONDIRENTRY: width, height, palette size, reserved, planes, bpp, data size, data offset
+		buf.Write([]byte{dim, dim, 0, 0})
+		_ = binary.Write(&buf, binary.LittleEndian, [2]uint16{1, 32})
+		_ = binary.Write(&buf, binary.LittleEndian, [2]uint32{uint32(len(images[i])), uint32(offset)})
+		offset += len(images[i])
+	}
+	for _, data := range images {
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseHexColor parses "#rgb" or "#rrggbb"
+func parseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return nil, fmt.Errorf("invalid color %q (expected #rrggbb)", s)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
@@ -0,0 +1,78 @@
+package generators
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestRenderPWAIcons(t *testing.T) {
+	// A wide red rectangle, so fitting and centering are observable
+	src := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			src.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := RenderPWAIcons(buf.Bytes(), "#00f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range PWAIconFiles() {
+		if len(files[rel]) == 0 {
+			t.Errorf("%s not rendered", rel)
+		}
+	}
+
+	tests := []struct {
+		path   string
+		size   int
+		corner color.NRGBA // Top-left pixel: outside the fitted image
+	}{
+		{"static/images/icons/icon-192.png", 192, color.NRGBA{}},
+		{"static/images/icons/icon-maskable-512.png", 512, color.NRGBA{B: 255, A: 255}},
+		{"apple-touch-icon.png", 180, color.NRGBA{B: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			img, err := png.Decode(bytes.NewReader(files[tt.path]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.size || b.Dy() != tt.size {
+				t.Errorf("size = %dx%d, want %d", b.Dx(), b.Dy(), tt.size)
+			}
+			if got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA); got != tt.corner {
+				t.Errorf("corner = %v, want %v", got, tt.corner)
+			}
+			if got := color.NRGBAModel.Convert(img.At(tt.size/2, tt.size/2)).(color.NRGBA); got.R != 255 || got.B != 0 {
+				t.Errorf("center = %v, want the source image", got)
+			}
+		})
+	}
+
+	ico := files["favicon.ico"]
+	var header [3]uint16
+	if err := binary.Read(bytes.NewReader(ico), binary.LittleEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header != [3]uint16{0, 1, uint16(len(faviconSizes))} {
+		t.Errorf("ico header = %v", header)
+	}
+}
+
+func TestRenderPWAIconsBadColor(t *testing.T) {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 8, 8)))
+	if _, err := RenderPWAIcons(buf.Bytes(), "blue"); err == nil {
+		t.Error("expected an error for a non-hex color")
+	}
+}
@@ -0,0 +1,129 @@
+package generators
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestGenerateSW(t *testing.T) {
+	tests := []struct {
+		name    string
+		pwa     config.PWAConfig
+		hooks   SWHooks
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "default routes",
+			want: []string{
+				`"strategy":"network-first","cacheName":"pages"`,
+				`"strategy":"cache-first","cacheName":"static"`,
+				`"strategy":"stale-while-revalidate","cacheName":"images","maxEntries":100`,
+				`const OFFLINE_URL = "";`,
+			},
+		},
+		{
+			name: "custom routes and offline page",
+			pwa: config.PWAConfig{
+				OfflinePage: "/offline.html",
+				Routes:      []config.PWARoute{{Pattern: `^/api/`, Strategy: "network-only"}},
+			},
+			want: []string{
+				`[{"pattern":"^/api/","strategy":"network-only","cacheName":"runtime"}]`,
+				`const OFFLINE_URL = "https://example.com/offline.html";`,
+			},
+		},
+		{
+			name:  "hooks injected at extension points",
+			hooks: SWHooks{"fetch": "if (url.pathname === '/ping') { return true; }", "tail": "self.addEventListener('push', () => {});"},
+			want: []string{
+				"// kosh:hook fetch\nif (url.pathname === '/ping') { return true; }",
+				"// kosh:hook tail\nself.addEventListener('push', () => {});",
+			},
+		},
+		{
+			name:    "unknown strategy",
+			pwa:     config.PWAConfig{Routes: []config.PWARoute{{Pattern: "/", Strategy: "cache-forever"}}},
+			wantErr: true,
+		},
+		{
+			name:    "missing pattern",
+			pwa:     config.PWAConfig{Routes: []config.PWARoute{{Strategy: "cache-first"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			err := GenerateSW(fs, "public", 1, true, "https://example.com", nil, tt.pwa, tt.hooks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateSW() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			data, err := afero.ReadFile(fs, filepath.Join("public", "sw.js"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(string(data), w) {
+					t.Errorf("sw.js missing %s", w)
+				}
+			}
+			if strings.Contains(string(data), "<no value>") {
+				t.Error("sw.js contains an unfilled template field")
+			}
+		})
+	}
+}
+
+func TestLoadSWHooks(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"sw/push.js":   "self.addEventListener('push', (event) => { event.waitUntil(Promise.resolve()); });",
+		"sw/fetch.js":  "if (url.pathname.startsWith('/api/')) {\n  event.respondWith(fetch(event.request));\n  return true;\n} // done",
+		"sw/broken.js": "self.addEventListener('push', (event) => {",
+	}
+	for path, code := range files {
+		_ = afero.WriteFile(fs, path, []byte(code), 0644)
+	}
+
+	tests := []struct {
+		name    string
+		paths   map[string]string
+		wantErr string
+	}{
+		{"top-level hook", map[string]string{"tail": "sw/push.js"}, ""},
+		{"function body hook may return", map[string]string{"fetch": "sw/fetch.js"}, ""},
+		{"return outside a function body", map[string]string{"head": "sw/fetch.js"}, "sw/fetch.js"},
+		{"syntax error", map[string]string{"tail": "sw/broken.js"}, "sw/broken.js"},
+		{"unknown point", map[string]string{"message": "sw/push.js"}, "unknown service worker hook"},
+		{"missing file", map[string]string{"tail": "sw/missing.js"}, "missing.js"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hooks, err := LoadSWHooks(fs, tt.paths)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadSWHooks() error = %v", err)
+				}
+				for point, path := range tt.paths {
+					if hooks[point] != files[path] {
+						t.Errorf("hook %s = %q", point, hooks[point])
+					}
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadSWHooks() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package generators
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// RedirectPage is a static page that sends browsers and crawlers to target:
+// a meta refresh, a canonical link and a plain link for everything else
+func RedirectPage(title, linkText, target string) string {
+	t := html.EscapeString(target)
+	return `<!DOCTYPE html><html><head><meta charset="utf-8"><title>` + html.EscapeString(title) + `</title>` +
+		`<meta http-equiv="refresh" content="0; url=` + t + `"><link rel="canonical" href="` + t + `">` +
+		`</head><body><a href="` + t + `">` + html.EscapeString(linkText) + `</a></body></html>`
+}
+
+// GenerateTagRedirects writes tags/<old>.html redirects for renamed and
+// merged tags (tagRedirects in kosh.yaml), following chains like a→b→c.
+// Tags still in use keep their own page. Returns the paths written.
+func GenerateTagRedirects(destFs afero.Fs, outputDir, baseURL string, redirects map[string]string, inUse func(tag string) bool) ([]string, error) {
+	normalized := make(map[string]string, len(redirects))
+	olds := make([]string, 0, len(redirects))
+	for old, target := range redirects {
+		if old = normalizeTag(old); old != "" {
+			normalized[old] = normalizeTag(target)
+			olds = append(olds, old)
+		}
+	}
+	sort.Strings(olds)
+
+	var written []string
+	for _, old := range olds {
+		if inUse(old) || strings.ContainsAny(old, `/\`) || strings.Contains(old, "..") {
+			continue
+		}
+		target, err := resolveTag(normalized, old)
+		if err != nil {
+			return written, err
+		}
+		page := RedirectPage("#"+target, "Posts tagged #"+target, fmt.Sprintf("%s/tags/%s.html", baseURL, target))
+		path := filepath.Join(outputDir, "tags", old+".html")
+		if err := utils.WriteFileVFS(destFs, path, []byte(page)); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// resolveTag follows normalized redirects from tag to the tag it lands on
+func resolveTag(redirects map[string]string, tag string) (string, error) {
+	seen := map[string]bool{tag: true}
+	target := redirects[tag]
+	for {
+		next, ok := redirects[target]
+		if !ok {
+			return target, nil
+		}
+		if seen[target] {
+			return "", fmt.Errorf("tagRedirects: %s redirects to itself", tag)
+		}
+		seen[target] = true
+		target = next
+	}
+}
+
+// normalizeTag matches how tag pages are named: lower case, trimmed
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Alias is an old URL of a page (`aliases:` frontmatter)
+type Alias struct {
+	Path   string // Site path such as "/old-url/" or "/2019/post.html"
+	Target string // The page's permalink
+	Title  string
+}
+
+// AliasPath normalizes an alias to a site path with a leading slash. Full
+// URLs and paths leaving the site are rejected.
+func AliasPath(alias string) (string, error) {
+	a := strings.TrimSpace(filepath.ToSlash(alias))
+	if a == "" || strings.Contains(a, "://") || strings.HasPrefix(a, "//") {
+		return "", fmt.Errorf("alias %q must be a path on this site", alias)
+	}
+	for _, part := range strings.Split(a, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("alias %q leaves the site", alias)
+		}
+	}
+	clean := path.Clean("/" + a)
+	if clean == "/" {
+		return "", fmt.Errorf("alias %q is the home page", alias)
+	}
+	if strings.HasSuffix(a, "/") || path.Ext(clean) == "" {
+		clean += "/"
+	}
+	return clean, nil
+}
+
+// aliasFile is the page an alias is served from: index.html for directory
+// paths, "" for paths a static host wouldn't serve as HTML (old .php URLs),
+// which only get a _redirects entry
+func aliasFile(alias string) string {
+	switch {
+	case strings.HasSuffix(alias, "/"):
+		return alias + "index.html"
+	case path.Ext(alias) == ".html" || path.Ext(alias) == ".htm":
+		return alias
+	}
+	return ""
+}
+
+// GenerateAliases writes a redirect page at every alias and a _redirects file
+// (Netlify, Cloudflare Pages) with the same moves as permanent redirects, for
+// hosts that can redirect without a page load. Returns the paths written.
+func GenerateAliases(destFs afero.Fs, outputDir string, aliases []Alias) ([]string, error) {
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+	sorted := append([]Alias(nil), aliases...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var written []string
+	var rules strings.Builder
+	rules.WriteString("# Generated by kosh from aliases: frontmatter\n")
+	for _, a := range sorted {
+		fmt.Fprintf(&rules, "%s %s 301\n", a.Path, a.Target)
+		file := aliasFile(a.Path)
+		if file == "" {
+			continue
+		}
+		dest := filepath.Join(outputDir, filepath.FromSlash(strings.TrimPrefix(file, "/")))
+		if err := utils.WriteFileVFS(destFs, dest, []byte(RedirectPage(a.Title, a.Title, a.Target))); err != nil {
+			return written, err
+		}
+		written = append(written, dest)
+	}
+
+	dest := filepath.Join(outputDir, "_redirects")
+	if err := utils.WriteFileVFS(destFs, dest, []byte(rules.String())); err != nil {
+		return written, err
+	}
+	return append(written, dest), nil
+}
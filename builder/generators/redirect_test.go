@@ -0,0 +1,97 @@
+package generators
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestGenerateTagRedirects(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	redirects := map[string]string{"JS": "javascript", "javascript": "Web", "golang": "go", "rust": "rs", "../x": "go"}
+	inUse := func(tag string) bool { return tag == "go" || tag == "web" || tag == "rust" }
+
+	written, err := GenerateTagRedirects(fs, "public", "https://example.com", redirects, inUse)
+	if err != nil {
+		t.Fatalf("GenerateTagRedirects() error = %v", err)
+	}
+	if len(written) != 3 {
+		t.Errorf("wrote %v, want 3 redirects", written)
+	}
+
+	for old, target := range map[string]string{"js": "web", "javascript": "web", "golang": "go"} {
+		data, err := afero.ReadFile(fs, filepath.Join("public", "tags", old+".html"))
+		if err != nil {
+			t.Fatalf("tags/%s.html: %v", old, err)
+		}
+		if want := `url=https://example.com/tags/` + target + `.html"`; !strings.Contains(string(data), want) {
+			t.Errorf("tags/%s.html = %s, want it to contain %s", old, data, want)
+		}
+	}
+	if ok, _ := afero.Exists(fs, filepath.Join("public", "tags", "rust.html")); ok {
+		t.Error("a tag still in use must keep its own page")
+	}
+
+	if _, err := GenerateTagRedirects(fs, "public", "", map[string]string{"a": "b", "b": "a"}, func(string) bool { return false }); err == nil {
+		t.Error("a redirect cycle should be an error")
+	}
+}
+
+func TestAliasPath(t *testing.T) {
+	tests := []struct {
+		alias   string
+		want    string
+		wantErr bool
+	}{
+		{"/old-url/", "/old-url/", false},
+		{"old-url", "/old-url/", false},
+		{"/2019/post.html", "/2019/post.html", false},
+		{"/blog/./index.php", "/blog/index.php", false},
+		{"/", "", true},
+		{"https://example.com/x", "", true},
+		{"//cdn.example.com/x", "", true},
+		{"/a/../../etc", "", true},
+	}
+	for _, tt := range tests {
+		got, err := AliasPath(tt.alias)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("AliasPath(%q) = %q, %v; want %q, err %v", tt.alias, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateAliases(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	target := "https://example.com/guides/setup.html"
+	written, err := GenerateAliases(fs, "public", []Alias{
+		{Path: "/2019/setup.html", Target: target, Title: "Setup"},
+		{Path: "/old-setup/", Target: target, Title: "Setup"},
+		{Path: "/setup.php", Target: target, Title: "Setup"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 3 {
+		t.Errorf("wrote %v, want two pages and _redirects", written)
+	}
+	for _, page := range []string{"2019/setup.html", "old-setup/index.html"} {
+		data, err := afero.ReadFile(fs, filepath.Join("public", page))
+		if err != nil {
+			t.Fatalf("%s: %v", page, err)
+		}
+		if !strings.Contains(string(data), `url=`+target+`"`) {
+			t.Errorf("%s = %s, want a redirect to %s", page, data, target)
+		}
+	}
+	rules, _ := afero.ReadFile(fs, filepath.Join("public", "_redirects"))
+	want := "/2019/setup.html " + target + " 301\n/old-setup/ " + target + " 301\n/setup.php " + target + " 301\n"
+	if !strings.HasSuffix(string(rules), want) {
+		t.Errorf("_redirects = %q, want rules %q", rules, want)
+	}
+
+	if written, _ := GenerateAliases(fs, "out", nil); written != nil {
+		t.Errorf("no aliases wrote %v", written)
+	}
+}
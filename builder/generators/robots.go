@@ -0,0 +1,44 @@
+package generators
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// RobotsTxt renders robots.txt. sitemapURL is listed when set; an empty
+// disallow list allows everything.
+func RobotsTxt(cfg config.RobotsConfig, sitemapURL string) string {
+	var b strings.Builder
+	agent := cfg.UserAgent
+	if agent == "" {
+		agent = "*"
+	}
+	fmt.Fprintf(&b, "User-agent: %s\n", agent)
+	for _, p := range cfg.Allow {
+		fmt.Fprintf(&b, "Allow: %s\n", p)
+	}
+	for _, p := range cfg.Disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", p)
+	}
+	if len(cfg.Disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	if extra := strings.TrimSpace(cfg.Extra); extra != "" {
+		fmt.Fprintf(&b, "\n%s\n", extra)
+	}
+	if sitemapURL != "" {
+		fmt.Fprintf(&b, "\nSitemap: %s\n", sitemapURL)
+	}
+	return b.String()
+}
+
+// GenerateRobots writes robots.txt at the root of outputDir
+func GenerateRobots(destFs afero.Fs, outputDir string, cfg config.RobotsConfig, sitemapURL string) error {
+	return utils.WriteFileVFS(destFs, filepath.Join(outputDir, "robots.txt"), []byte(RobotsTxt(cfg, sitemapURL)))
+}
@@ -0,0 +1,34 @@
+package generators
+
+import (
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.RobotsConfig
+		sitemap string
+		want    string
+	}{
+		{
+			name: "allow everything",
+			want: "User-agent: *\nDisallow:\n",
+		},
+		{
+			name:    "rules, extra and sitemap",
+			cfg:     config.RobotsConfig{UserAgent: "Googlebot", Allow: []string{"/drafts/public/"}, Disallow: []string{"/drafts/"}, Extra: "User-agent: GPTBot\nDisallow: /\n"},
+			sitemap: "https://example.com/sitemap/sitemap.xml",
+			want:    "User-agent: Googlebot\nAllow: /drafts/public/\nDisallow: /drafts/\n\nUser-agent: GPTBot\nDisallow: /\n\nSitemap: https://example.com/sitemap/sitemap.xml\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RobotsTxt(tt.cfg, tt.sitemap); got != tt.want {
+				t.Errorf("RobotsTxt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,40 +1,91 @@
 package generators
 
 import (
+	"encoding/json"
 	"encoding/xml"
-	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/afero"
 
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
+const (
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	podcastNamespace = "https://podcastindex.org/namespace/1.0"
+)
+
 func GenerateRSS(destFs afero.Fs, baseURL string, posts []models.PostMetadata, title, description string, outputPath string) {
-	fmt.Println("📡 Generating RSS feed...")
+	logging.Statusf("📡 Generating RSS feed...")
+	if err := utils.WriteFileVFS(destFs, outputPath, rssFeed(baseURL, posts, title, description)); err != nil {
+		logging.Statusf("⚠️ Failed to write rss.xml: %v", err)
+	}
+}
 
+// rssFeed renders an RSS 2.0 channel linking to link
+func rssFeed(link string, posts []models.PostMetadata, title, description string) []byte {
+	rss := models.Rss{Version: "2.0"}
 	var items []models.Item
 	for _, p := range posts {
-		items = append(items, models.Item{
+		item := models.Item{
 			Title:       p.Title,
 			Link:        p.Link,
 			Description: p.Description,
 			PubDate:     p.DateObj.Format(time.RFC1123),
 			Guid:        p.Link,
-		})
+		}
+		// Posts with `audio:` frontmatter are podcast episodes
+		if a := p.Audio; a != nil {
+			rss.ITunesNS = itunesNamespace
+			item.Enclosure = &models.Enclosure{URL: a.URL, Length: a.Length, Type: a.Type}
+			if a.Duration > 0 {
+				item.Duration = strconv.Itoa(a.Duration)
+			}
+			if a.ChaptersURL != "" {
+				rss.PodcastNS = podcastNamespace
+				item.Chapters = &models.PodcastChapters{URL: a.ChaptersURL, Type: "application/json+chapters"}
+			}
+		}
+		items = append(items, item)
 	}
-	rss := models.Rss{
-		Version: "2.0",
-		Channel: models.Channel{
-			Title:       title,
-			Link:        baseURL,
-			Description: description,
-			Items:       items,
-		},
+	rss.Channel = models.Channel{
+		Title:       title,
+		Link:        link,
+		Description: description,
+		Items:       items,
 	}
 	output, _ := xml.MarshalIndent(rss, "", "  ")
-	if err := utils.WriteFileVFS(destFs, outputPath, []byte(xml.Header+string(output))); err != nil {
-		fmt.Printf("⚠️ Failed to write rss.xml: %v\n", err)
+	return []byte(xml.Header + string(output))
+}
+
+// GenerateChapters writes the Podcasting 2.0 JSON chapters of every episode
+// with chapter markers, from the same frontmatter the page's player shows,
+// and returns the paths written
+func GenerateChapters(destFs afero.Fs, baseURL, outputDir string, posts []models.PostMetadata) []string {
+	var written []string
+	for _, p := range posts {
+		a := p.Audio
+		if a == nil || a.ChaptersURL == "" || !strings.HasPrefix(a.ChaptersURL, baseURL) {
+			continue
+		}
+		data, err := json.MarshalIndent(struct {
+			Version  string           `json:"version"`
+			Chapters []models.Chapter `json:"chapters"`
+		}{"1.2.0", a.Chapters}, "", "  ")
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(outputDir, filepath.FromSlash(strings.TrimPrefix(a.ChaptersURL, baseURL)))
+		if err := utils.WriteFileVFS(destFs, path, data); err != nil {
+			logging.Statusf("⚠️ Failed to write chapters for %s: %v", p.Link, err)
+			continue
+		}
+		written = append(written, path)
 	}
+	return written
 }
@@ -0,0 +1,69 @@
+package generators
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestGenerateRSS_Podcast(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	base := "https://example.com"
+	posts := []models.PostMetadata{
+		{Title: "Plain", Link: base + "/plain.html", DateObj: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{
+			Title: "Episode 1", Link: base + "/ep1.html", DateObj: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			Audio: &models.Audio{
+				URL: base + "/static/ep1.mp3", Type: "audio/mpeg", Length: 1234, Duration: 2530,
+				Chapters:    []models.Chapter{{Start: 0, Title: "Intro"}, {Start: 330, Title: "Interview", URL: "https://example.org"}},
+				ChaptersURL: base + "/ep1.chapters.json",
+			},
+		},
+	}
+
+	GenerateRSS(fs, base, posts, "Site", "Desc", "public/rss.xml")
+	data, err := afero.ReadFile(fs, "public/rss.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := string(data)
+	for _, want := range []string{
+		`xmlns:itunes="` + itunesNamespace + `"`,
+		`xmlns:podcast="` + podcastNamespace + `"`,
+		`<enclosure url="https://example.com/static/ep1.mp3" length="1234" type="audio/mpeg"></enclosure>`,
+		`<itunes:duration>2530</itunes:duration>`,
+		`<podcast:chapters url="https://example.com/ep1.chapters.json" type="application/json+chapters"></podcast:chapters>`,
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed missing %s:\n%s", want, feed)
+		}
+	}
+	if strings.Count(feed, "<enclosure") != 1 {
+		t.Errorf("only the episode should have an enclosure:\n%s", feed)
+	}
+
+	written := GenerateChapters(fs, base, "public", posts)
+	if len(written) != 1 || written[0] != filepath.Join("public", "ep1.chapters.json") {
+		t.Fatalf("GenerateChapters wrote %v", written)
+	}
+	chapters, _ := afero.ReadFile(fs, written[0])
+	for _, want := range []string{`"version": "1.2.0"`, `"startTime": 330`, `"title": "Interview"`, `"url": "https://example.org"`} {
+		if !strings.Contains(string(chapters), want) {
+			t.Errorf("chapters missing %s:\n%s", want, chapters)
+		}
+	}
+}
+
+func TestGenerateRSS_NoPodcast(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	GenerateRSS(fs, "https://example.com", []models.PostMetadata{{Title: "Plain", Link: "https://example.com/p.html"}}, "Site", "Desc", "rss.xml")
+	data, _ := afero.ReadFile(fs, "rss.xml")
+	if strings.Contains(string(data), "itunes") || strings.Contains(string(data), "podcast") {
+		t.Errorf("feed without episodes has podcast markup:\n%s", data)
+	}
+}
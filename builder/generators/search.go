@@ -2,31 +2,48 @@ package generators
 
 import (
 	"compress/gzip"
+	"math"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/vmihailenco/msgpack/v5"
 
+	"github.com/Kush-Singh-26/kosh/builder/config"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/search"
 )
 
-func GenerateSearchIndex(destFs afero.Fs, outputDir string, indexedPosts []models.IndexedPost) error {
+// GenerateSearchIndex writes search.bin. When spool is non-nil the record
+// contents live in it (low-memory mode) and are read back one record at a
+// time, both for the stem map and while the posts are encoded. boost sets
+// the field weights the client scores with and each page's multiplier.
+func GenerateSearchIndex(destFs afero.Fs, outputDir string, indexedPosts []models.IndexedPost, spool *search.ContentSpool, boost config.SearchBoostConfig) error {
 	totalDocs := len(indexedPosts)
 	estimatedUniqueWords := totalDocs * 100
 
 	index := models.SearchIndex{
-		Posts:    make([]models.PostRecord, totalDocs),
 		Inverted: make(map[string]map[int]int, estimatedUniqueWords),
 		DocLens:  make(map[int]int, totalDocs),
 		StemMap:  make(map[string][]string),
 	}
+	if spool == nil {
+		index.Posts = make([]models.PostRecord, totalDocs)
+	}
+	if boost.Title != 1 || boost.Tags != 1 || boost.Body != 1 {
+		index.Weights = &models.SearchWeights{Title: boost.Title, Tags: boost.Tags, Body: boost.Body}
+	}
+	now := time.Now()
 
 	analyzer := search.NewAnalyzer(true, true)
 
 	totalLen := 0
 	for i, ip := range indexedPosts {
-		index.Posts[i] = ip.Record
+		if spool == nil {
+			index.Posts[i] = ip.Record
+			index.Posts[i].Boost = pageBoost(ip, boost, now)
+		}
 		index.DocLens[i] = ip.DocLen
 		totalLen += ip.DocLen
 
@@ -39,8 +56,13 @@ func GenerateSearchIndex(destFs afero.Fs, outputDir string, indexedPosts []model
 			postMap[i] = freq
 		}
 
+		content, err := spool.Content(ip.Record)
+		if err != nil {
+			return err
+		}
+
 		// Build stem map for fuzzy matching
-		stemmed, originals := analyzer.AnalyzeWithOriginals(ip.Record.Content)
+		stemmed, originals := analyzer.AnalyzeWithOriginals(content)
 		for j, stem := range stemmed {
 			if j < len(originals) {
 				orig := originals[j]
@@ -83,5 +105,101 @@ func GenerateSearchIndex(destFs afero.Fs, outputDir string, indexedPosts []model
 	defer func() { _ = gw.Close() }()
 
 	enc := msgpack.NewEncoder(gw)
-	return enc.Encode(&index)
+	if spool == nil {
+		return enc.Encode(&index)
+	}
+	return encodeSpooledIndex(enc, &index, indexedPosts, spool, func(ip models.IndexedPost) float64 {
+		return pageBoost(ip, boost, now)
+	})
+}
+
+// pageBoost is the score multiplier of a page: the boost of the longest
+// section prefix of its link times its recency boost at now. It returns 0
+// (the same as 1, but left out of the index) for pages without a boost.
+func pageBoost(ip models.IndexedPost, opts config.SearchBoostConfig, now time.Time) float64 {
+	boost := 1.0
+	link, longest := strings.TrimPrefix(ip.Record.Link, "/"), -1
+	for prefix, factor := range opts.Sections {
+		prefix = strings.Trim(prefix, "/")
+		if len(prefix) > longest && (link == prefix || strings.HasPrefix(link, prefix+"/")) {
+			boost, longest = factor, len(prefix)
+		}
+	}
+
+	if recency := opts.Recency; recency.Weight > 0 && !ip.Date.IsZero() {
+		halfLife := recency.HalfLife
+		if halfLife <= 0 {
+			halfLife = 180
+		}
+		age := math.Max(0, now.Sub(ip.Date).Hours()/24)
+		boost *= 1 + recency.Weight*math.Pow(0.5, age/float64(halfLife))
+	}
+	if boost == 1 {
+		return 0
+	}
+	return boost
+}
+
+// encodeSpooledIndex writes the same msgpack map as encoding a SearchIndex
+// directly, but streams the posts array so only one record's content is in
+// memory at a time
+func encodeSpooledIndex(enc *msgpack.Encoder, index *models.SearchIndex, indexedPosts []models.IndexedPost, spool *search.ContentSpool, boost func(models.IndexedPost) float64) error {
+	fields := 5
+	if len(index.StemMap) > 0 {
+		fields++
+	}
+	if len(index.NgramIndex) > 0 {
+		fields++
+	}
+	if index.Weights != nil {
+		fields++
+	}
+	if err := enc.EncodeMapLen(fields); err != nil {
+		return err
+	}
+
+	if err := enc.EncodeString("posts"); err != nil {
+		return err
+	}
+	if err := enc.EncodeArrayLen(len(indexedPosts)); err != nil {
+		return err
+	}
+	for _, ip := range indexedPosts {
+		rec := ip.Record
+		content, err := spool.Content(rec)
+		if err != nil {
+			return err
+		}
+		rec.Content = content
+		rec.Boost = boost(ip)
+		if err := enc.Encode(&rec); err != nil {
+			return err
+		}
+	}
+
+	rest := []struct {
+		key   string
+		value any
+		skip  bool
+	}{
+		{"inv", index.Inverted, false},
+		{"lens", index.DocLens, false},
+		{"avg", index.AvgDocLen, false},
+		{"total", index.TotalDocs, false},
+		{"stem", index.StemMap, len(index.StemMap) == 0},
+		{"ngram", index.NgramIndex, len(index.NgramIndex) == 0},
+		{"weights", index.Weights, index.Weights == nil},
+	}
+	for _, field := range rest {
+		if field.skip {
+			continue
+		}
+		if err := enc.EncodeString(field.key); err != nil {
+			return err
+		}
+		if err := enc.Encode(field.value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
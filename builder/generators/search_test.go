@@ -0,0 +1,103 @@
+package generators
+
+import (
+	"compress/gzip"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+)
+
+func readSearchIndex(t *testing.T, fs afero.Fs, dir string) models.SearchIndex {
+	t.Helper()
+	f, err := fs.Open(filepath.Join(dir, "search.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index models.SearchIndex
+	if err := msgpack.NewDecoder(gr).Decode(&index); err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func TestGenerateSearchIndexSpooled(t *testing.T) {
+	posts := func() []models.IndexedPost {
+		return []models.IndexedPost{
+			{Record: models.PostRecord{ID: 0, Title: "Running", Link: "docs/running.html", Content: "running runners ran quickly"}, WordFreqs: map[string]int{"run": 2, "quick": 1}, DocLen: 4},
+			{Record: models.PostRecord{ID: 1, Title: "Empty", Link: "empty.html", Tags: []string{"misc"}}, WordFreqs: map[string]int{}, DocLen: 0},
+		}
+	}
+
+	boost := config.SearchBoostConfig{Title: 2, Tags: 1, Body: 1, Sections: map[string]float64{"docs": 1.5}}
+	fs := afero.NewMemMapFs()
+	if err := GenerateSearchIndex(fs, "inline", posts(), nil, boost); err != nil {
+		t.Fatal(err)
+	}
+
+	spool, err := search.NewContentSpool(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = spool.Close() }()
+	spooled := posts()
+	for i := range spooled {
+		if err := spool.Stash(&spooled[i].Record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := GenerateSearchIndex(fs, "spooled", spooled, spool, boost); err != nil {
+		t.Fatal(err)
+	}
+
+	want := readSearchIndex(t, fs, "inline")
+	got := readSearchIndex(t, fs, "spooled")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spooled index differs:\n got %+v\nwant %+v", got, want)
+	}
+	if got.Posts[0].Content != "running runners ran quickly" {
+		t.Errorf("content not restored from spool: %q", got.Posts[0].Content)
+	}
+	if got.Weights == nil || got.Weights.Title != 2 || got.Posts[0].Boost != 1.5 || got.Posts[1].Boost != 0 {
+		t.Errorf("boosts not written: weights %+v, posts %v and %v", got.Weights, got.Posts[0].Boost, got.Posts[1].Boost)
+	}
+}
+
+func TestPageBoost(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := config.SearchBoostConfig{
+		Recency:  config.RecencyBoostConfig{Weight: 1, HalfLife: 30},
+		Sections: map[string]float64{"docs": 2, "/docs/legacy/": 0.5, "blog": 1},
+	}
+	tests := []struct {
+		link string
+		date time.Time
+		want float64
+	}{
+		{"docs/intro.html", time.Time{}, 2},
+		{"docs/legacy/old.html", time.Time{}, 0.5}, // Longest prefix wins
+		{"docsite/page.html", time.Time{}, 0},      // Whole segments only
+		{"blog/post.html", time.Time{}, 0},
+		{"blog/post.html", now, 2},                      // Dated today: 1+weight
+		{"blog/post.html", now.AddDate(0, 0, -30), 1.5}, // One half-life ago
+		{"docs/new.html", now.AddDate(0, 0, 1), 4},      // Future dates count as today
+	}
+	for _, tt := range tests {
+		ip := models.IndexedPost{Record: models.PostRecord{Link: tt.link}, Date: tt.date}
+		if got := pageBoost(ip, opts, now); got != tt.want {
+			t.Errorf("pageBoost(%s, %v) = %v, want %v", tt.link, tt.date, got, tt.want)
+		}
+	}
+}
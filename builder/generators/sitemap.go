@@ -4,58 +4,186 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/afero"
 
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
-func GenerateSitemap(destFs afero.Fs, baseURL string, posts []models.PostMetadata, tags map[string][]models.PostMetadata, outputPath string) {
-	fmt.Println("🗺️  Generating sitemap...")
+const (
+	sitemapImageNS = "http://www.google.com/schemas/sitemap-image/1.1"
+	sitemapVideoNS = "http://www.google.com/schemas/sitemap-video/1.1"
+)
 
-	var urls []models.Url
+// maxSitemapURLs is the most URLs a sitemap file may list
+const maxSitemapURLs = 50000
+
+// GenerateSitemap writes sitemap.xml. Post entries list the images and videos
+// found in their rendered pages under outputDir; an empty outputDir skips
+// media discovery. A post's lastmod is its source file's modification time,
+// else its date. URLs whose path matches opts.Exclude are left out, and past
+// opts.MaxURLs the entries are split into sitemap-N.xml files next to
+// outputPath, which becomes their sitemap index. It returns the split files.
+func GenerateSitemap(destFs afero.Fs, baseURL, outputDir string, posts []models.PostMetadata, tags map[string][]models.PostMetadata, outputPath string, opts config.SitemapConfig) []string {
+	logging.Statusf("🗺️  Generating sitemap...")
 
-	// 1. Add Home Page
-	urls = append(urls, models.Url{
-		Loc:     baseURL + "/",
-		LastMod: time.Now().Format("2006-01-02"),
-	})
+	var urls []models.Url
+	set := models.UrlSet{}
+	var newest time.Time
+	add := func(entry models.Url) {
+		if !sitemapExcluded(baseURL, entry.Loc, opts.Exclude) {
+			urls = append(urls, entry)
+		}
+	}
 
-	// 2. Add Blog Posts
+	// 1. Add Blog Posts
 	for _, p := range posts {
-		urls = append(urls, models.Url{
+		updated := p.DateObj
+		if p.ModTime.Unix() > 0 {
+			updated = p.ModTime
+		}
+		if updated.After(newest) {
+			newest = updated
+		}
+		entry := models.Url{
 			Loc:     p.Link,
-			LastMod: p.DateObj.Format("2006-01-02"),
-		})
+			LastMod: updated.Format("2006-01-02"),
+		}
+		if outputDir != "" && !sitemapExcluded(baseURL, p.Link, opts.Exclude) {
+			if page, ok := readRenderedPage(destFs, baseURL, outputDir, p.Link); ok {
+				entry.Images, entry.Videos = ExtractMedia(page, p)
+			}
+		}
+		add(entry)
+	}
+
+	// 2. Add Home Page, updated with its newest post
+	if newest.IsZero() {
+		newest = time.Now()
+	}
+	home := models.Url{Loc: baseURL + "/", LastMod: newest.Format("2006-01-02")}
+	if !sitemapExcluded(baseURL, home.Loc, opts.Exclude) {
+		urls = append([]models.Url{home}, urls...)
 	}
 
 	// 3. Add Tag Pages
-	for t, tagPosts := range tags {
+	tagNames := make([]string, 0, len(tags))
+	for t := range tags {
+		tagNames = append(tagNames, t)
+	}
+	sort.Strings(tagNames)
+	for _, t := range tagNames {
 		// Find the latest date among posts with this tag
 		var latest time.Time
-		for _, p := range tagPosts {
+		for _, p := range tags[t] {
 			if p.DateObj.After(latest) {
 				latest = p.DateObj
 			}
 		}
 
-		urls = append(urls, models.Url{
+		add(models.Url{
 			Loc:     fmt.Sprintf("%s/tags/%s.html", baseURL, url.PathEscape(t)),
 			LastMod: latest.Format("2006-01-02"),
 		})
 	}
 
-	// Marshaling
-	output, err := xml.MarshalIndent(models.UrlSet{Urls: urls}, "", "  ")
+	perFile := opts.MaxURLs
+	if perFile <= 0 || perFile > maxSitemapURLs {
+		perFile = maxSitemapURLs
+	}
+	if len(urls) <= perFile {
+		writeURLSet(destFs, set, urls, outputPath)
+		return nil
+	}
+
+	// Split under a sitemap index
+	dirURL := baseURL + "/sitemap"
+	if outputDir != "" {
+		if rel, err := filepath.Rel(outputDir, filepath.Dir(outputPath)); err == nil {
+			dirURL = strings.TrimSuffix(baseURL+"/"+filepath.ToSlash(rel), "/.")
+		}
+	}
+	index := models.SitemapIndex{}
+	var written []string
+	for i := 0; i*perFile < len(urls); i++ {
+		name := fmt.Sprintf("sitemap-%d.xml", i+1)
+		path := filepath.Join(filepath.Dir(outputPath), name)
+		chunk := urls[i*perFile : min((i+1)*perFile, len(urls))]
+		if writeURLSet(destFs, set, chunk, path) {
+			written = append(written, path)
+		}
+		index.Sitemaps = append(index.Sitemaps, models.SitemapRef{Loc: dirURL + "/" + name, LastMod: newest.Format("2006-01-02")})
+	}
+	output, _ := xml.MarshalIndent(index, "", "  ")
+	if err := utils.WriteFileVFS(destFs, outputPath, []byte(xml.Header+string(output))); err != nil {
+		logging.Statusf("⚠️ Failed to write sitemap index: %v", err)
+	}
+	return written
+}
+
+// writeURLSet writes one sitemap file, declaring the media namespaces its
+// entries use
+func writeURLSet(destFs afero.Fs, set models.UrlSet, urls []models.Url, path string) bool {
+	for _, u := range urls {
+		if len(u.Images) > 0 {
+			set.ImageNS = sitemapImageNS
+		}
+		if len(u.Videos) > 0 {
+			set.VideoNS = sitemapVideoNS
+		}
+	}
+	set.Urls = urls
+	output, err := xml.MarshalIndent(set, "", "  ")
 	if err != nil {
-		fmt.Printf("Error marshaling sitemap: %v\n", err)
-		return
+		logging.Statusf("⚠️ Failed to marshal sitemap: %v", err)
+		return false
+	}
+	if err := utils.WriteFileVFS(destFs, path, []byte(xml.Header+string(output))); err != nil {
+		logging.Statusf("⚠️ Failed to write %s: %v", filepath.Base(path), err)
+		return false
 	}
+	return true
+}
 
-	finalOutput := []byte(xml.Header + string(output))
-	if err := utils.WriteFileVFS(destFs, outputPath, finalOutput); err != nil {
-		fmt.Printf("⚠️ Failed to write sitemap.xml: %v\n", err)
+// sitemapExcluded reports whether the path of loc under baseURL matches one
+// of the exclude patterns
+func sitemapExcluded(baseURL, loc string, exclude []string) bool {
+	if len(exclude) == 0 {
+		return false
+	}
+	sitePath := strings.TrimPrefix(loc, strings.TrimSuffix(baseURL, "/"))
+	if !strings.HasPrefix(sitePath, "/") {
+		sitePath = "/" + sitePath
+	}
+	for _, pattern := range exclude {
+		if utils.MatchGlob(pattern, sitePath) || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(sitePath, pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readRenderedPage returns the HTML written for link, preferring this build's
+// output and falling back to the page left on disk by an earlier build
+func readRenderedPage(destFs afero.Fs, baseURL, outputDir, link string) ([]byte, bool) {
+	rel := strings.TrimPrefix(strings.TrimPrefix(link, strings.TrimSuffix(baseURL, "/")), "/")
+	if rel == "" || strings.Contains(rel, "://") {
+		return nil, false
+	}
+	path := filepath.Join(outputDir, filepath.FromSlash(rel))
+	if data, err := afero.ReadFile(destFs, path); err == nil {
+		return data, true
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		return data, true
 	}
+	return nil, false
 }
@@ -0,0 +1,113 @@
+package generators
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// maxSitemapImages is the per-URL image limit of the image sitemap extension
+const maxSitemapImages = 1000
+
+// ExtractMedia finds the images and videos in a rendered post. Only the
+// <article> is scanned when the page has one, so theme chrome such as logos
+// doesn't end up in every entry. Videos without a thumbnail are skipped since
+// search engines reject them.
+func ExtractMedia(page []byte, post models.PostMetadata) ([]models.SitemapImage, []models.SitemapVideo) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, nil
+	}
+	base, err := url.Parse(post.Link)
+	if err != nil {
+		return nil, nil
+	}
+	root := doc.Find("article").First()
+	if root.Length() == 0 {
+		root = doc.Find("body")
+	}
+
+	var images []models.SitemapImage
+	seen := make(map[string]bool)
+	root.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		src := resolveMediaURL(base, s.AttrOr("src", ""))
+		if src == "" || seen[src] || len(images) >= maxSitemapImages {
+			return
+		}
+		seen[src] = true
+		images = append(images, models.SitemapImage{Loc: src})
+	})
+
+	description := post.Description
+	if description == "" {
+		description = post.Title
+	}
+	var videos []models.SitemapVideo
+	root.Find("video").Each(func(_ int, s *goquery.Selection) {
+		src := s.AttrOr("src", "")
+		if src == "" {
+			src = s.Find("source[src]").First().AttrOr("src", "")
+		}
+		content := resolveMediaURL(base, src)
+		thumb := resolveMediaURL(base, s.AttrOr("poster", ""))
+		if content == "" || thumb == "" {
+			return
+		}
+		videos = append(videos, models.SitemapVideo{
+			ThumbnailLoc: thumb,
+			Title:        orDefault(s.AttrOr("title", ""), post.Title),
+			Description:  description,
+			ContentLoc:   content,
+		})
+	})
+	root.Find("iframe[src]").Each(func(_ int, s *goquery.Selection) {
+		player := resolveMediaURL(base, s.AttrOr("src", ""))
+		id := youTubeID(player)
+		if id == "" {
+			return
+		}
+		videos = append(videos, models.SitemapVideo{
+			ThumbnailLoc: "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg",
+			Title:        orDefault(s.AttrOr("title", ""), post.Title),
+			Description:  description,
+			PlayerLoc:    player,
+		})
+	})
+
+	return images, videos
+}
+
+// resolveMediaURL makes ref absolute against the page URL. Inline data URIs
+// have no address to index and resolve to "".
+func resolveMediaURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+// youTubeID returns the video ID of a YouTube embed URL, or ""
+func youTubeID(player string) string {
+	u, err := url.Parse(player)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if host != "youtube.com" && host != "youtube-nocookie.com" {
+		return ""
+	}
+	id, ok := strings.CutPrefix(u.Path, "/embed/")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return ""
+	}
+	return id
+}
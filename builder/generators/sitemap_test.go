@@ -0,0 +1,180 @@
+package generators
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestExtractMedia(t *testing.T) {
+	post := models.PostMetadata{
+		Title:       "Clips",
+		Description: "Some clips",
+		Link:        "https://example.com/posts/clips.html",
+	}
+
+	tests := []struct {
+		name       string
+		page       string
+		wantImages []string
+		wantVideos []models.SitemapVideo
+	}{
+		{
+			name:       "images resolved and deduplicated inside article",
+			page:       `<body><img src="/logo.png"><article><img src="a.png"><img src="/posts/a.png"><img src="data:image/png;base64,AA"></article></body>`,
+			wantImages: []string{"https://example.com/posts/a.png"},
+		},
+		{
+			name:       "whole body without article",
+			page:       `<body><img src="https://cdn.example.com/x.webp"></body>`,
+			wantImages: []string{"https://cdn.example.com/x.webp"},
+		},
+		{
+			name: "video with poster and source",
+			page: `<article><video poster="thumb.jpg" title="Demo"><source src="demo.mp4"></video><video src="nothumb.mp4"></video></article>`,
+			wantVideos: []models.SitemapVideo{{
+				ThumbnailLoc: "https://example.com/posts/thumb.jpg",
+				Title:        "Demo",
+				Description:  "Some clips",
+				ContentLoc:   "https://example.com/posts/demo.mp4",
+			}},
+		},
+		{
+			name: "youtube embed",
+			page: `<article><iframe src="https://www.youtube-nocookie.com/embed/abc123"></iframe><iframe src="https://example.com/widget"></iframe></article>`,
+			wantVideos: []models.SitemapVideo{{
+				ThumbnailLoc: "https://i.ytimg.com/vi/abc123/hqdefault.jpg",
+				Title:        "Clips",
+				Description:  "Some clips",
+				PlayerLoc:    "https://www.youtube-nocookie.com/embed/abc123",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images, videos := ExtractMedia([]byte(tt.page), post)
+			var got []string
+			for _, img := range images {
+				got = append(got, img.Loc)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantImages, ",") {
+				t.Errorf("images = %v, want %v", got, tt.wantImages)
+			}
+			if len(videos) != len(tt.wantVideos) {
+				t.Fatalf("videos = %+v, want %+v", videos, tt.wantVideos)
+			}
+			for i := range videos {
+				if videos[i] != tt.wantVideos[i] {
+					t.Errorf("video %d = %+v, want %+v", i, videos[i], tt.wantVideos[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSitemapMedia(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	outputDir := "public"
+	page := `<article><img src="cover.png"></article>`
+	if err := afero.WriteFile(fs, filepath.Join(outputDir, "posts", "a.html"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	posts := []models.PostMetadata{{Title: "A", Link: "https://example.com/posts/a.html"}}
+
+	out := filepath.Join(outputDir, "sitemap", "sitemap.xml")
+	GenerateSitemap(fs, "https://example.com", outputDir, posts, nil, out, config.SitemapConfig{})
+
+	data, err := afero.ReadFile(fs, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(data)
+	for _, want := range []string{
+		`xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"`,
+		`<image:loc>https://example.com/posts/cover.png</image:loc>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("sitemap missing %q:\n%s", want, xml)
+		}
+	}
+	if strings.Contains(xml, "xmlns:video") {
+		t.Errorf("video namespace declared without videos:\n%s", xml)
+	}
+}
+
+func TestGenerateSitemapExcludeAndLastMod(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	posts := []models.PostMetadata{
+		{Link: "https://example.com/posts/a.html", DateObj: date, ModTime: time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{Link: "https://example.com/posts/b.html", DateObj: date, ModTime: time.Unix(0, 0)},
+		{Link: "https://example.com/internal/c.html", DateObj: date},
+		{Link: "https://example.com/v1.0/posts/a.html", DateObj: date},
+	}
+
+	out := filepath.Join("public", "sitemap", "sitemap.xml")
+	written := GenerateSitemap(fs, "https://example.com", "", posts, nil, out, config.SitemapConfig{Exclude: []string{"/internal/**", "/v1.0/"}})
+	if len(written) != 0 {
+		t.Errorf("written = %v, want no split files", written)
+	}
+	data, err := afero.ReadFile(fs, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(data)
+	for _, want := range []string{
+		"<loc>https://example.com/</loc>\n    <lastmod>2025-03-04</lastmod>",
+		"<loc>https://example.com/posts/a.html</loc>\n    <lastmod>2025-03-04</lastmod>",
+		"<loc>https://example.com/posts/b.html</loc>\n    <lastmod>2024-01-02</lastmod>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("sitemap missing %q:\n%s", want, xml)
+		}
+	}
+	for _, excluded := range []string{"/internal/c.html", "/v1.0/"} {
+		if strings.Contains(xml, excluded) {
+			t.Errorf("sitemap lists excluded %s:\n%s", excluded, xml)
+		}
+	}
+}
+
+func TestGenerateSitemapSplit(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var posts []models.PostMetadata
+	for _, name := range []string{"a", "b", "c", "d"} {
+		posts = append(posts, models.PostMetadata{Link: "https://example.com/posts/" + name + ".html"})
+	}
+
+	out := filepath.Join("public", "sitemap", "sitemap.xml")
+	written := GenerateSitemap(fs, "https://example.com", "public", posts, nil, out, config.SitemapConfig{MaxURLs: 2})
+	want := []string{
+		filepath.Join("public", "sitemap", "sitemap-1.xml"),
+		filepath.Join("public", "sitemap", "sitemap-2.xml"),
+		filepath.Join("public", "sitemap", "sitemap-3.xml"),
+	}
+	if strings.Join(written, ",") != strings.Join(want, ",") {
+		t.Fatalf("written = %v, want %v", written, want)
+	}
+
+	index, err := afero.ReadFile(fs, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), "<sitemapindex") || !strings.Contains(string(index), "<loc>https://example.com/sitemap/sitemap-3.xml</loc>") {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+	last, err := afero.ReadFile(fs, want[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(last), "<url>") != 1 || !strings.Contains(string(last), "/posts/d.html") {
+		t.Errorf("unexpected last file:\n%s", last)
+	}
+}
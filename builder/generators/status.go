@@ -0,0 +1,109 @@
+package generators
+
+import (
+	"bytes"
+	"html/template"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/checks"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// StatusData is what the status page shows
+type StatusData struct {
+	checks.Status
+	Generated   time.Time
+	Latest      *checks.Latest      // The latest check, nil when none was saved
+	BrokenLinks []checks.Finding    // Broken links and refs of Latest
+	URL         func(string) string // URL of a content file's page
+	DraftURL    func(string) string // URL a draft is served at, nil for none
+}
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"day": func(t time.Time) string {
+		if t.IsZero() {
+			return "undated"
+		}
+		return t.Format("2006-01-02")
+	},
+	"dict": func(kv ...interface{}) map[string]interface{} {
+		m := make(map[string]interface{}, len(kv)/2)
+		for i := 0; i+1 < len(kv); i += 2 {
+			m[kv[i].(string)] = kv[i+1]
+		}
+		return m
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<meta name="robots" content="noindex">
+<title>Content Status</title>
+<style>
+body { max-width: 60rem; margin: 2rem auto; padding: 0 1rem; font: 14px/1.5 system-ui, sans-serif; color: #1c1c1e; }
+h1 { font-size: 1.4rem; margin-bottom: .25rem; }
+.meta { color: #888; margin-top: 0; }
+.summary { display: flex; gap: .75rem; flex-wrap: wrap; margin: 1.5rem 0; }
+.summary a { padding: .5rem .9rem; border-radius: 6px; background: #f1f3f5; color: inherit; text-decoration: none; }
+.summary b { display: block; font-size: 1.3rem; }
+.summary .ok b { color: #2b8a3e; }
+.summary .warn b { color: #c77700; }
+h2 { font-size: 1.1rem; margin-top: 2rem; border-bottom: 1px solid #ddd; padding-bottom: .25rem; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: .3rem .5rem; border-bottom: 1px solid #f1f3f5; vertical-align: top; }
+td.date { white-space: nowrap; color: #666; width: 7rem; }
+.path { color: #888; font-size: 12px; }
+.empty { color: #2b8a3e; }
+</style>
+</head>
+<body>
+<h1>Content Status</h1>
+<p class="meta">Updated {{ .Generated.Format "15:04:05" }} by the dev server. Not part of production builds.</p>
+{{ define "count" }}<a href="#{{ .id }}" class="{{ if .n }}warn{{ else }}ok{{ end }}"><b>{{ .n }}</b>{{ .label }}</a>{{ end }}
+<div class="summary">
+{{ template "count" (dict "id" "drafts" "n" (len .Drafts) "label" "drafts") }}
+{{ template "count" (dict "id" "future" "n" (len .Future) "label" "future posts") }}
+{{ if .StaleMonths }}{{ template "count" (dict "id" "stale" "n" (len .Stale) "label" "stale pages") }}{{ end }}
+{{ template "count" (dict "id" "descriptions" "n" (len .NoDescription) "label" "missing descriptions") }}
+{{ template "count" (dict "id" "links" "n" (len .BrokenLinks) "label" "broken links") }}
+</div>
+{{ define "pages" }}{{ if .pages }}<table>{{ range .pages }}
+<tr><td class="date">{{ day .Date }}</td><td>{{ if $.url }}<a href="{{ call $.url .Path }}">{{ or .Title .Path }}</a>{{ else }}{{ or .Title .Path }}{{ end }} <span class="path">{{ .Path }}</span></td></tr>{{ end }}
+</table>{{ else }}<p class="empty">None.</p>{{ end }}{{ end }}
+<h2 id="drafts">Drafts</h2>
+{{ template "pages" (dict "pages" .Drafts "url" .DraftURL) }}
+<h2 id="future">Future posts</h2>
+{{ template "pages" (dict "pages" .Future "url" .URL) }}
+{{ if .StaleMonths }}<h2 id="stale">Not updated in {{ .StaleMonths }} months</h2>
+{{ template "pages" (dict "pages" .Stale "url" .URL) }}{{ end }}
+<h2 id="descriptions">Missing descriptions</h2>
+{{ template "pages" (dict "pages" .NoDescription "url" .URL) }}
+<h2 id="links">Broken links</h2>
+{{ if .Latest }}<p class="meta">From the check of {{ .Latest.At.Format "2006-01-02 15:04" }}.</p>
+{{ if .BrokenLinks }}<table>{{ range .BrokenLinks }}
+<tr><td>{{ .Page }}</td><td>{{ .Message }}</td></tr>{{ end }}
+</table>{{ else }}<p class="empty">None.</p>{{ end }}
+{{ else }}<p class="meta">No check has run yet: run <code>kosh build --strict</code> or start the dev server with <code>--strict</code>.</p>{{ end }}
+</body>
+</html>
+`))
+
+// GenerateStatus writes the content status page to path. BrokenLinks is
+// filled in from Latest.
+func GenerateStatus(destFs afero.Fs, path string, data StatusData) error {
+	if data.Latest != nil {
+		for _, f := range data.Latest.Findings {
+			if f.Class == checks.BrokenLink || f.Class == checks.BrokenRef {
+				data.BrokenLinks = append(data.BrokenLinks, f)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := statusTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+	return utils.WriteFileVFS(destFs, path, buf.Bytes())
+}
@@ -0,0 +1,52 @@
+package generators
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/checks"
+)
+
+func TestGenerateStatus(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	data := StatusData{
+		Status: checks.Status{
+			Drafts:      []checks.StatusPage{{Path: "wip.md", Title: "WIP"}},
+			Stale:       []checks.StatusPage{{Path: "old.md", Title: "Old <page>", Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			StaleMonths: 12,
+		},
+		Latest: &checks.Latest{Findings: []checks.Finding{
+			{Class: checks.BrokenLink, Page: "index.html", Message: "/missing.html"},
+			{Class: checks.MissingDescription, Page: "old.md", Message: "no description"},
+		}},
+		URL:      func(p string) string { return "/" + strings.TrimSuffix(p, ".md") + ".html" },
+		DraftURL: func(p string) string { return "/drafts/" + strings.TrimSuffix(p, ".md") + ".html" },
+	}
+	if err := GenerateStatus(fs, ".kosh-cache/status.html", data); err != nil {
+		t.Fatal(err)
+	}
+	out, err := afero.ReadFile(fs, ".kosh-cache/status.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(out)
+	for _, want := range []string{
+		`<b>1</b>drafts`,
+		`<b>1</b>broken links`,
+		`Not updated in 12 months`,
+		`<a href="/old.html">Old &lt;page&gt;</a>`,
+		`<a href="/drafts/wip.html">WIP</a>`,
+		`2020-01-01`,
+		`/missing.html`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("status page lacks %q", want)
+		}
+	}
+	if strings.Contains(page, "no description") {
+		t.Error("status page lists findings other than broken links")
+	}
+}
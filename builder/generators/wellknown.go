@@ -0,0 +1,116 @@
+package generators
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// generatedContentTypes maps generated files to the content type they must be
+// served with. Most have no extension (or one hosts don't know), so static
+// hosts would otherwise guess application/octet-stream.
+var generatedContentTypes = map[string]string{
+	ManifestFile:                  "application/manifest+json",
+	".well-known/security.txt":    "text/plain; charset=utf-8",
+	".well-known/webfinger":       "application/jrd+json",
+	".well-known/change-password": "text/html; charset=utf-8",
+}
+
+// ContentTypeFor returns the content type a generated file must be served
+// with, or "" when the extension is enough. relPath is relative to the output
+// directory. The dev server and the S3 deploy target send it, and
+// GenerateHeaders writes it to _headers for Netlify and Cloudflare Pages.
+func ContentTypeFor(relPath string) string {
+	return generatedContentTypes[strings.TrimPrefix(filepath.ToSlash(relPath), "/")]
+}
+
+// GenerateWellKnown writes the /.well-known/ files enabled in the config:
+// security.txt, webfinger and the change-password redirect
+func GenerateWellKnown(destFs afero.Fs, outputDir string, cfg *config.Config, now time.Time) error {
+	dir := filepath.Join(outputDir, ".well-known")
+
+	if len(cfg.WellKnown.SecurityTxt.Contact) > 0 {
+		body, err := SecurityTxt(cfg.WellKnown.SecurityTxt, cfg.BaseURL, now)
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteFileVFS(destFs, filepath.Join(dir, "security.txt"), []byte(body)); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Fediverse.WebFinger && cfg.Fediverse.Creator != "" {
+		account, err := ParseFediverseHandle(cfg.Fediverse.Creator)
+		if err != nil {
+			return err
+		}
+		if err := GenerateWebFinger(destFs, outputDir, account); err != nil {
+			return err
+		}
+	}
+
+	if target := cfg.WellKnown.ChangePassword; target != "" {
+		if err := utils.WriteFileVFS(destFs, filepath.Join(dir, "change-password"), []byte(changePasswordPage(target))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SecurityTxt renders an RFC 9116 security.txt. Expires defaults to one year
+// after now, since the RFC asks for less than a year and rebuilding renews it.
+func SecurityTxt(cfg config.SecurityTxtConfig, baseURL string, now time.Time) (string, error) {
+	expires := now.UTC().AddDate(1, 0, 0).Truncate(24 * time.Hour)
+	if cfg.Expires != "" {
+		t, err := parseExpires(cfg.Expires)
+		if err != nil {
+			return "", err
+		}
+		expires = t
+	}
+
+	var b strings.Builder
+	for _, c := range cfg.Contact {
+		if !strings.Contains(c, ":") && strings.Contains(c, "@") {
+			c = "mailto:" + c
+		}
+		fmt.Fprintf(&b, "Contact: %s\n", c)
+	}
+	fmt.Fprintf(&b, "Expires: %s\n", expires.UTC().Format(time.RFC3339))
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+	field("Encryption", cfg.Encryption)
+	field("Acknowledgments", cfg.Acknowledgments)
+	field("Policy", cfg.Policy)
+	field("Hiring", cfg.Hiring)
+	field("Preferred-Languages", strings.Join(cfg.PreferredLanguages, ", "))
+	if baseURL != "" {
+		field("Canonical", strings.TrimSuffix(baseURL, "/")+"/.well-known/security.txt")
+	}
+	return b.String(), nil
+}
+
+func parseExpires(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid security.txt expires %q (expected RFC 3339 or YYYY-MM-DD)", value)
+}
+
+// changePasswordPage redirects to the account's password form, which is what
+// password managers expect behind /.well-known/change-password
+func changePasswordPage(target string) string {
+	return RedirectPage("Change password", "Change your password", target)
+}
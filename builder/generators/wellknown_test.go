@@ -0,0 +1,99 @@
+package generators
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestSecurityTxt(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		cfg     config.SecurityTxtConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			cfg:  config.SecurityTxtConfig{Contact: []string{"security@example.com", "https://example.com/report"}},
+			want: []string{
+				"Contact: mailto:security@example.com\n",
+				"Contact: https://example.com/report\n",
+				"Expires: 2027-03-15T00:00:00Z\n",
+				"Canonical: https://example.com/.well-known/security.txt\n",
+			},
+		},
+		{
+			name: "all fields",
+			cfg: config.SecurityTxtConfig{
+				Contact:            []string{"mailto:sec@example.com"},
+				Expires:            "2026-12-31",
+				Encryption:         "https://example.com/pgp.asc",
+				Policy:             "https://example.com/policy",
+				PreferredLanguages: []string{"en", "de"},
+			},
+			want: []string{
+				"Contact: mailto:sec@example.com\n",
+				"Expires: 2026-12-31T00:00:00Z\n",
+				"Encryption: https://example.com/pgp.asc\n",
+				"Policy: https://example.com/policy\n",
+				"Preferred-Languages: en, de\n",
+			},
+		},
+		{
+			name:    "bad expiry",
+			cfg:     config.SecurityTxtConfig{Contact: []string{"a@b.c"}, Expires: "soon"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SecurityTxt(tt.cfg, "https://example.com/", now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SecurityTxt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("SecurityTxt() missing %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateWellKnown(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := &config.Config{BaseURL: "https://example.com"}
+	cfg.WellKnown.SecurityTxt.Contact = []string{"sec@example.com"}
+	cfg.WellKnown.ChangePassword = "https://accounts.example.com/password?a=1&b=2"
+	cfg.Fediverse = config.FediverseConfig{Creator: "@kush@mastodon.social", WebFinger: true}
+
+	if err := GenerateWellKnown(fs, "public", cfg, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"security.txt", "webfinger", "change-password"} {
+		rel := ".well-known/" + name
+		if ok, _ := afero.Exists(fs, filepath.Join("public", rel)); !ok {
+			t.Errorf("%s not written", rel)
+		}
+		if ContentTypeFor(rel) == "" {
+			t.Errorf("no content type recorded for %s", rel)
+		}
+	}
+
+	page, _ := afero.ReadFile(fs, filepath.Join("public", ".well-known", "change-password"))
+	if !strings.Contains(string(page), `url=https://accounts.example.com/password?a=1&amp;b=2`) {
+		t.Errorf("change-password page doesn't redirect:\n%s", page)
+	}
+	if ContentTypeFor("index.html") != "" {
+		t.Error("ContentTypeFor(index.html) should defer to the extension")
+	}
+}
@@ -0,0 +1,173 @@
+// Package hooks lets Go code that embeds Kosh change what a build writes.
+// Where builder/events only reports what happened, a hook may rewrite it at
+// one of four stages:
+//
+//   - after-parse: a content file's HTML body and listing metadata, before
+//     they are cached and rendered
+//   - before-render: the template data of any page
+//   - after-render: the HTML a template wrote
+//   - after-build: the synced output directory, once per build
+//
+// Plugins register their hooks through run.Builder.Use:
+//
+//	type credits struct{}
+//
+//	func (credits) Name() string    { return "credits" }
+//	func (credits) Version() string { return "1" }
+//	func (credits) Register(r *hooks.Registry) error {
+//		r.AfterParse(func(p *hooks.Post) error {
+//			p.HTML += "<p>Photos by Jo.</p>"
+//			return nil
+//		})
+//		return nil
+//	}
+//
+// Hooks of a stage run in the order they were registered, synchronously on
+// the goroutine that reaches the stage. Posts are parsed and pages rendered
+// by worker pools, so those hooks must be safe for concurrent use.
+package hooks
+
+import (
+	"sync"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// Post is a content file that was just parsed (a cache miss). The hooks'
+// changes to HTML and Meta are cached with the page.
+type Post struct {
+	Path        string                 // Relative to the content directory, slash-separated
+	Frontmatter map[string]interface{} // Read-only
+	Meta        *models.PostMetadata   // Title, link, tags, dates as listed on index pages
+	HTML        string                 // Rendered body
+}
+
+// Build is a finished build whose output is on disk
+type Build struct {
+	OutputDir string
+	Changed   string // The file an incremental (watch mode) rebuild was for; empty for full builds
+}
+
+// Registry holds the hooks of every stage. A nil *Registry is valid and has
+// no hooks, so services can run a stage without checking.
+type Registry struct {
+	mu           sync.RWMutex
+	afterParse   []func(*Post) error
+	beforeRender []func(path string, data *models.PageData) error
+	afterRender  []func(path string, html []byte) ([]byte, error)
+	afterBuild   []func(Build) error
+}
+
+// New creates an empty registry
+func New() *Registry {
+	return &Registry{}
+}
+
+// AfterParse registers fn for every parsed content file
+func (r *Registry) AfterParse(fn func(*Post) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterParse = append(r.afterParse, fn)
+}
+
+// BeforeRender registers fn for every page about to be rendered, with the
+// output file path and the data the template will get
+func (r *Registry) BeforeRender(fn func(path string, data *models.PageData) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beforeRender = append(r.beforeRender, fn)
+}
+
+// AfterRender registers fn for every page a template wrote. fn returns the
+// HTML to keep in its place.
+func (r *Registry) AfterRender(fn func(path string, html []byte) ([]byte, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterRender = append(r.afterRender, fn)
+}
+
+// AfterBuild registers fn for the end of every successful build
+func (r *Registry) AfterBuild(fn func(Build) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.afterBuild = append(r.afterBuild, fn)
+}
+
+// RunAfterParse runs the after-parse hooks on p, stopping at the first error
+func (r *Registry) RunAfterParse(p *Post) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	fns := r.afterParse
+	r.mu.RUnlock()
+	for _, fn := range fns {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunBeforeRender runs the before-render hooks on data, stopping at the
+// first error
+func (r *Registry) RunBeforeRender(path string, data *models.PageData) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	fns := r.beforeRender
+	r.mu.RUnlock()
+	for _, fn := range fns {
+		if err := fn(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasAfterRender reports whether any after-render hook is registered, so
+// pages are only read back when one will look at them
+func (r *Registry) HasAfterRender() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.afterRender) > 0
+}
+
+// RunAfterRender passes html through the after-render hooks. On error it
+// returns the HTML as the hooks before the failing one left it.
+func (r *Registry) RunAfterRender(path string, html []byte) ([]byte, error) {
+	if r == nil {
+		return html, nil
+	}
+	r.mu.RLock()
+	fns := r.afterRender
+	r.mu.RUnlock()
+	for _, fn := range fns {
+		out, err := fn(path, html)
+		if err != nil {
+			return html, err
+		}
+		html = out
+	}
+	return html, nil
+}
+
+// RunAfterBuild runs the after-build hooks, stopping at the first error
+func (r *Registry) RunAfterBuild(b Build) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	fns := r.afterBuild
+	r.mu.RUnlock()
+	for _, fn := range fns {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
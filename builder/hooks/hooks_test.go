@@ -0,0 +1,75 @@
+package hooks
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestRunInOrder(t *testing.T) {
+	r := New()
+	r.AfterParse(func(p *Post) error { p.HTML += " first"; return nil })
+	r.AfterParse(func(p *Post) error { p.HTML += " second"; p.Meta.Title = "Changed"; return nil })
+	r.BeforeRender(func(path string, data *models.PageData) error { data.Title = path; return nil })
+	r.AfterRender(func(path string, html []byte) ([]byte, error) { return append(html, " one"...), nil })
+	r.AfterRender(func(path string, html []byte) ([]byte, error) { return append(html, " two"...), nil })
+	var built []Build
+	r.AfterBuild(func(b Build) error { built = append(built, b); return nil })
+
+	p := &Post{HTML: "<p>body</p>", Meta: &models.PostMetadata{Title: "Title"}}
+	if err := r.RunAfterParse(p); err != nil || p.HTML != "<p>body</p> first second" || p.Meta.Title != "Changed" {
+		t.Errorf("RunAfterParse() = %v, post %q %q", err, p.HTML, p.Meta.Title)
+	}
+	var data models.PageData
+	if err := r.RunBeforeRender("public/a.html", &data); err != nil || data.Title != "public/a.html" {
+		t.Errorf("RunBeforeRender() = %v, title %q", err, data.Title)
+	}
+	if !r.HasAfterRender() {
+		t.Error("HasAfterRender() = false")
+	}
+	if html, err := r.RunAfterRender("public/a.html", []byte("page")); err != nil || string(html) != "page one two" {
+		t.Errorf("RunAfterRender() = %q, %v", html, err)
+	}
+	if err := r.RunAfterBuild(Build{OutputDir: "public"}); err != nil || !reflect.DeepEqual(built, []Build{{OutputDir: "public"}}) {
+		t.Errorf("RunAfterBuild() = %v, built %v", err, built)
+	}
+}
+
+func TestErrorStopsStage(t *testing.T) {
+	boom := errors.New("boom")
+	r := New()
+	r.AfterParse(func(p *Post) error { return boom })
+	r.AfterParse(func(p *Post) error { p.HTML = "not reached"; return nil })
+	r.AfterRender(func(path string, html []byte) ([]byte, error) { return []byte("changed"), nil })
+	r.AfterRender(func(path string, html []byte) ([]byte, error) { return nil, boom })
+
+	p := &Post{HTML: "kept"}
+	if err := r.RunAfterParse(p); !errors.Is(err, boom) || p.HTML != "kept" {
+		t.Errorf("RunAfterParse() = %v, HTML %q", err, p.HTML)
+	}
+	// The page keeps the output of the hooks before the failing one
+	if html, err := r.RunAfterRender("a.html", []byte("page")); !errors.Is(err, boom) || string(html) != "changed" {
+		t.Errorf("RunAfterRender() = %q, %v", html, err)
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+	if err := r.RunAfterParse(&Post{}); err != nil {
+		t.Error(err)
+	}
+	if err := r.RunBeforeRender("a.html", &models.PageData{}); err != nil {
+		t.Error(err)
+	}
+	if r.HasAfterRender() {
+		t.Error("nil registry has after-render hooks")
+	}
+	if html, err := r.RunAfterRender("a.html", []byte("page")); err != nil || string(html) != "page" {
+		t.Errorf("RunAfterRender() = %q, %v", html, err)
+	}
+	if err := r.RunAfterBuild(Build{}); err != nil {
+		t.Error(err)
+	}
+}
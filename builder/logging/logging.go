@@ -0,0 +1,222 @@
+// Package logging holds the process-wide log settings (--log-format,
+// --log-level, --quiet). Structured logs go through slog; the emoji progress
+// lines printed by the build and the server go through Statusf, so with
+// --log-format json every line Kosh writes is a JSON record.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Formats accepted by --log-format
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options are the logging flags shared by every command
+type Options struct {
+	Format string
+	Level  slog.Level
+	Quiet  bool      // Only warnings and errors
+	Output io.Writer // Defaults to stdout
+}
+
+var (
+	mu      sync.RWMutex
+	current = Options{Format: FormatText, Level: slog.LevelInfo, Output: os.Stdout}
+	out     = &terminal{w: os.Stdout}
+)
+
+// ParseArgs removes the logging flags from args wherever they appear, so
+// `kosh --quiet build` and `kosh build --log-format json` both work, and
+// returns them with the remaining arguments
+func ParseArgs(args []string) (Options, []string, error) {
+	opts := Options{Format: FormatText, Level: slog.LevelInfo}
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") {
+			rest = append(rest, args[i])
+			continue
+		}
+		switch name {
+		case "quiet", "q":
+			opts.Quiet = true
+			continue
+		case "log-format", "log-level":
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("--%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "log-format" {
+			value = strings.ToLower(value)
+			if value != FormatText && value != FormatJSON {
+				return opts, nil, fmt.Errorf("unknown log format %q (want text or json)", value)
+			}
+			opts.Format = value
+		} else if err := opts.Level.UnmarshalText([]byte(value)); err != nil {
+			return opts, nil, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", value)
+		}
+	}
+	return opts, rest, nil
+}
+
+// Configure applies opts process-wide and makes slog's default logger use them
+func Configure(opts Options) {
+	if opts.Format == "" {
+		opts.Format = FormatText
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	mu.Lock()
+	current = opts
+	out = &terminal{w: opts.Output}
+	mu.Unlock()
+	slog.SetDefault(slog.New(Handler()))
+}
+
+// level is the lowest level written, with --quiet raising it to warnings
+func level(opts Options) slog.Level {
+	if opts.Quiet && opts.Level < slog.LevelWarn {
+		return slog.LevelWarn
+	}
+	return opts.Level
+}
+
+// Handler returns a slog handler for the configured format and level
+func Handler() slog.Handler {
+	mu.RLock()
+	opts, w := current, out
+	mu.RUnlock()
+
+	handlerOpts := &slog.HandlerOptions{Level: level(opts)}
+	if opts.Format == FormatJSON {
+		return slog.NewJSONHandler(w, handlerOpts)
+	}
+	return slog.NewTextHandler(w, handlerOpts)
+}
+
+// IsJSON reports whether logs are written as JSON
+func IsJSON() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.Format == FormatJSON
+}
+
+// Statusf prints a progress line such as "📦 Building assets...". Lines
+// starting with ❌ count as errors and ⚠️ as warnings; the rest are info.
+// Text output prints the line unchanged; JSON output logs it without the
+// emoji; lines below the configured level (or info under --quiet) are dropped.
+func Statusf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	lvl := statusLevel(msg)
+
+	mu.RLock()
+	opts, w := current, out
+	mu.RUnlock()
+	if lvl < level(opts) {
+		return
+	}
+	if opts.Format == FormatJSON {
+		slog.Default().Log(context.Background(), lvl, cleanMessage(msg))
+		return
+	}
+	_, _ = fmt.Fprintln(w, msg)
+}
+
+// Interactive reports whether progress can be redrawn in place: text output,
+// not quieted, going to a terminal
+func Interactive() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current.Format != FormatText || level(current) > slog.LevelInfo {
+		return false
+	}
+	f, ok := current.Output.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// SetTransient shows line at the bottom of the terminal, redrawn in place
+// and kept below anything else written; "" removes it. Only meaningful when
+// Interactive.
+func SetTransient(line string) {
+	mu.RLock()
+	w := out
+	mu.RUnlock()
+	w.setTransient(line)
+}
+
+// terminal writes log output, clearing the transient line before each write
+// and drawing it again afterwards
+type terminal struct {
+	mu        sync.Mutex
+	w         io.Writer
+	transient string
+}
+
+const clearLine = "\r\033[K"
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.transient == "" {
+		return t.w.Write(p)
+	}
+	_, _ = io.WriteString(t.w, clearLine)
+	n, err := t.w.Write(p)
+	_, _ = io.WriteString(t.w, t.transient)
+	return n, err
+}
+
+func (t *terminal) setTransient(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if line == t.transient {
+		return
+	}
+	if t.transient != "" {
+		_, _ = io.WriteString(t.w, clearLine)
+	}
+	t.transient = line
+	_, _ = io.WriteString(t.w, line)
+}
+
+func statusLevel(msg string) slog.Level {
+	trimmed := strings.TrimSpace(msg)
+	switch {
+	case strings.HasPrefix(trimmed, "❌"):
+		return slog.LevelError
+	case strings.HasPrefix(trimmed, "⚠"):
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// cleanMessage drops the emoji and layout whitespace around a status line
+func cleanMessage(msg string) string {
+	msg = strings.TrimLeftFunc(msg, func(r rune) bool {
+		return r > unicode.MaxASCII || unicode.IsSpace(r)
+	})
+	return strings.TrimSpace(msg)
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    Options
+		rest    []string
+		wantErr bool
+	}{
+		{
+			name: "no logging flags",
+			args: []string{"build", "-drafts"},
+			want: Options{Format: FormatText, Level: slog.LevelInfo},
+			rest: []string{"build", "-drafts"},
+		},
+		{
+			name: "flags before and after the command",
+			args: []string{"--log-format", "json", "build", "--log-level=debug", "-baseurl", "https://x.dev", "--quiet"},
+			want: Options{Format: FormatJSON, Level: slog.LevelDebug, Quiet: true},
+			rest: []string{"build", "-baseurl", "https://x.dev"},
+		},
+		{
+			name: "single dash and short quiet",
+			args: []string{"serve", "-log-level", "WARN", "-q"},
+			want: Options{Format: FormatText, Level: slog.LevelWarn, Quiet: true},
+			rest: []string{"serve"},
+		},
+		{name: "unknown format", args: []string{"--log-format", "xml"}, wantErr: true},
+		{name: "unknown level", args: []string{"--log-level=loud"}, wantErr: true},
+		{name: "missing value", args: []string{"build", "--log-format"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := ParseArgs(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArgs failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("options = %+v, want %+v", got, tt.want)
+			}
+			if !slices.Equal(rest, tt.rest) {
+				t.Errorf("rest = %v, want %v", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestStatusf(t *testing.T) {
+	defer Configure(Options{})
+
+	var out bytes.Buffer
+	Configure(Options{Format: FormatText, Level: slog.LevelInfo, Output: &out})
+	Statusf("📦 Building assets...")
+	if out.String() != "📦 Building assets...\n" {
+		t.Errorf("text status = %q", out.String())
+	}
+
+	out.Reset()
+	Configure(Options{Format: FormatText, Level: slog.LevelInfo, Quiet: true, Output: &out})
+	Statusf("📦 Building assets...")
+	Statusf("⚠️ Failed to write %s", "rss.xml")
+	if out.String() != "⚠️ Failed to write rss.xml\n" {
+		t.Errorf("quiet output = %q, want only the warning", out.String())
+	}
+
+	out.Reset()
+	Configure(Options{Format: FormatJSON, Level: slog.LevelInfo, Output: &out})
+	Statusf("\n❌ Build failed: %v", "boom")
+	Statusf("   📣 Sent %d webmention(s)", 2)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d JSON lines, want 2: %q", len(lines), out.String())
+	}
+	want := []struct{ level, msg string }{
+		{"ERROR", "Build failed: boom"},
+		{"INFO", "Sent 2 webmention(s)"},
+	}
+	for i, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not JSON: %q", i, line)
+		}
+		if record["level"] != want[i].level || record["msg"] != want[i].msg {
+			t.Errorf("line %d = %v, want level %s msg %q", i, record, want[i].level, want[i].msg)
+		}
+	}
+}
+
+func TestSetTransient(t *testing.T) {
+	defer Configure(Options{})
+
+	var out bytes.Buffer
+	Configure(Options{Output: &out})
+	SetTransient("⏳ 1/2")
+	Statusf("📦 Building assets...")
+	SetTransient("⏳ 2/2")
+	SetTransient("")
+
+	want := "⏳ 1/2" + clearLine + "📦 Building assets...\n⏳ 1/2" + clearLine + "⏳ 2/2" + clearLine
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
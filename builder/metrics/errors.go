@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// maxErrorExamples is how many affected pages are kept per error group
+const maxErrorExamples = 3
+
+// exampleAttrs are the attributes tried, in order, to name what an error hit
+var exampleAttrs = []string{"page", "path", "location", "example", "error"}
+
+// errorLimit is the --max-errors / --fail-fast state of a running build
+type errorLimit struct {
+	active   bool
+	max      int // Errors printed before the rest are only counted, 0 = all
+	failFast bool
+	stop     func()
+	start    int // Index of the build's first entry in warnings
+	count    int
+	stopped  bool
+}
+
+// ErrorGroup is every error of one kind logged during a build
+type ErrorGroup struct {
+	Type     string   `json:"type"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// ErrorSummary is the exit summary of a build run with --max-errors or
+// --fail-fast, most frequent errors first
+type ErrorSummary struct {
+	Errors    int          `json:"errors"`
+	Shown     int          `json:"shown"`
+	MaxErrors int          `json:"max_errors,omitempty"`
+	FailFast  bool         `json:"fail_fast,omitempty"`
+	Stopped   bool         `json:"stopped"` // The build was stopped at its first error
+	Groups    []ErrorGroup `json:"groups"`
+}
+
+// LimitErrors applies --max-errors and --fail-fast until the returned func is
+// called: only the first maxErrors errors (0 = all) are printed, and with
+// failFast stop is called at the first one. done returns the summary of the
+// errors logged in between.
+func (m *BuildMetrics) LimitErrors(maxErrors int, failFast bool, stop func()) (done func() ErrorSummary) {
+	m.mu.Lock()
+	m.errorLimit = errorLimit{active: true, max: maxErrors, failFast: failFast, stop: stop, start: len(m.warnings)}
+	m.mu.Unlock()
+
+	return func() ErrorSummary {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		limit := m.errorLimit
+		m.errorLimit = errorLimit{}
+		return summarizeErrors(m.warnings[limit.start:], limit)
+	}
+}
+
+// countError notes an error against the running limit. It reports whether
+// the error should still be printed and returns the func to stop the build
+// with, if this error trips --fail-fast. Callers hold m.mu.
+func (m *BuildMetrics) countError(w Warning) (show bool, stop func()) {
+	limit := &m.errorLimit
+	if !limit.active || w.Level != slog.LevelError.String() {
+		return true, nil
+	}
+	limit.count++
+	if limit.failFast {
+		if limit.stopped {
+			return false, nil
+		}
+		limit.stopped = true
+		return true, limit.stop
+	}
+	return limit.max == 0 || limit.count <= limit.max, nil
+}
+
+func summarizeErrors(warnings []Warning, limit errorLimit) ErrorSummary {
+	s := ErrorSummary{MaxErrors: limit.max, FailFast: limit.failFast, Stopped: limit.stopped, Groups: []ErrorGroup{}}
+	groups := make(map[string]*ErrorGroup)
+	var order []string
+	for _, w := range warnings {
+		if w.Level != slog.LevelError.String() {
+			continue
+		}
+		s.Errors++
+		g, ok := groups[w.Message]
+		if !ok {
+			g = &ErrorGroup{Type: w.Message}
+			groups[w.Message] = g
+			order = append(order, w.Message)
+		}
+		g.Count++
+		if len(g.Examples) < maxErrorExamples {
+			for _, key := range exampleAttrs {
+				if v := w.Attrs[key]; v != "" {
+					g.Examples = append(g.Examples, v)
+					break
+				}
+			}
+		}
+	}
+
+	s.Shown = s.Errors
+	switch {
+	case limit.failFast:
+		s.Shown = min(s.Errors, 1)
+	case limit.max > 0:
+		s.Shown = min(s.Errors, limit.max)
+	}
+	for _, t := range order {
+		s.Groups = append(s.Groups, *groups[t])
+	}
+	sort.SliceStable(s.Groups, func(i, j int) bool { return s.Groups[i].Count > s.Groups[j].Count })
+	return s
+}
+
+// Write saves the summary to path as JSON
+func (s ErrorSummary) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLimitErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxErrors   int
+		failFast    bool
+		wantPrinted int
+		wantStopped bool
+	}{
+		{name: "no limit", wantPrinted: 5},
+		{name: "budget", maxErrors: 2, wantPrinted: 2},
+		{name: "fail fast", failFast: true, wantPrinted: 1, wantStopped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewBuildMetrics()
+			var out bytes.Buffer
+			logger := slog.New(m.LogHandler(slog.NewTextHandler(&out, nil)))
+			logger.Error("Before the build")
+
+			stops := 0
+			done := m.LimitErrors(tt.maxErrors, tt.failFast, func() { stops++ })
+			for _, page := range []string{"a.md", "b.md", "c.md"} {
+				logger.Error("Failed to render markdown", "path", page)
+			}
+			logger.Warn("Slow page", "page", "d.md")
+			logger.Error("Template error", "location", "layout.html:3:5")
+			logger.Error("Template error", "location", "layout.html:3:5")
+			summary := done()
+
+			if printed := strings.Count(out.String(), "level=ERROR") - 1; printed != tt.wantPrinted {
+				t.Errorf("printed %d errors, want %d", printed, tt.wantPrinted)
+			}
+			if !strings.Contains(out.String(), "Slow page") {
+				t.Error("warnings should never be held back")
+			}
+			if summary.Stopped != tt.wantStopped || (stops == 1) != tt.wantStopped {
+				t.Errorf("stopped = %v after %d stop calls, want %v", summary.Stopped, stops, tt.wantStopped)
+			}
+			if summary.Errors != 5 || summary.Shown != tt.wantPrinted {
+				t.Errorf("summary counts %d errors, %d shown; want 5, %d", summary.Errors, summary.Shown, tt.wantPrinted)
+			}
+			if len(summary.Groups) != 2 {
+				t.Fatalf("summary groups = %+v, want 2", summary.Groups)
+			}
+			if g := summary.Groups[0]; g.Type != "Failed to render markdown" || g.Count != 3 || strings.Join(g.Examples, ",") != "a.md,b.md,c.md" {
+				t.Errorf("first group = %+v", g)
+			}
+			if g := summary.Groups[1]; g.Type != "Template error" || g.Count != 2 {
+				t.Errorf("second group = %+v", g)
+			}
+		})
+	}
+}
+
+func TestLimitErrors_EndsWithBuild(t *testing.T) {
+	m := NewBuildMetrics()
+	var out bytes.Buffer
+	logger := slog.New(m.LogHandler(slog.NewTextHandler(&out, nil)))
+
+	done := m.LimitErrors(1, false, nil)
+	logger.Error("first")
+	logger.Error("second")
+	done()
+	logger.Error("Build failed")
+
+	if strings.Contains(out.String(), "second") || !strings.Contains(out.String(), "Build failed") {
+		t.Errorf("only errors during the build should be held back, got:\n%s", out.String())
+	}
+}
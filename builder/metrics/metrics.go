@@ -2,7 +2,11 @@ package metrics
 
 import (
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 )
 
 type BuildMetrics struct {
@@ -11,6 +15,28 @@ type BuildMetrics struct {
 	PostsProcessed int
 	CacheHits      int
 	CacheMisses    int
+	// TemplateCompile is the time spent parsing theme templates, which is
+	// zero for builds that reused the compiled templates
+	TemplateCompile time.Duration
+	// StaticSkipped counts static files left untouched because neither the
+	// source nor the on-disk output changed since the last build
+	StaticSkipped      int
+	StaticSkippedBytes int64
+
+	// Per-page timings, keyed by content path (see pages.go)
+	pagesMu sync.Mutex
+	pages   map[string]*PageTiming
+
+	// Live per-stage counters for the progress display (see progress.go)
+	progress [numStages]stageCounter
+
+	// Phase timings and logged warnings for the build report (see report.go)
+	mu       sync.Mutex
+	phases   []Phase
+	warnings []Warning
+
+	// Error budget of the running build (see errors.go)
+	errorLimit errorLimit
 }
 
 func NewBuildMetrics() *BuildMetrics {
@@ -42,6 +68,17 @@ func (m *BuildMetrics) IncrementCacheMiss() {
 	m.CacheMisses++
 }
 
+// RecordTemplateCompile adds time spent parsing templates
+func (m *BuildMetrics) RecordTemplateCompile(d time.Duration) {
+	m.TemplateCompile += d
+}
+
+// RecordStaticSkipped adds static files that weren't re-copied
+func (m *BuildMetrics) RecordStaticSkipped(files int, bytes int64) {
+	m.StaticSkipped += files
+	m.StaticSkippedBytes += bytes
+}
+
 func (m *BuildMetrics) String() string {
 	duration := m.TotalDuration()
 	total := m.CacheHits + m.CacheMisses
@@ -50,15 +87,65 @@ func (m *BuildMetrics) String() string {
 		hitRate = float64(m.CacheHits) / float64(total) * 100
 	}
 
-	return fmt.Sprintf("📊 Built %d posts in %v (cache: %d/%d hits, %.0f%%)\n",
+	extra := ""
+	if m.TemplateCompile > 0 {
+		extra = fmt.Sprintf(", templates compiled in %v", m.TemplateCompile.Round(time.Microsecond))
+	}
+	if m.StaticSkipped > 0 {
+		extra += fmt.Sprintf(", %d static files unchanged (%s skipped)", m.StaticSkipped, formatBytes(m.StaticSkippedBytes))
+	}
+
+	return fmt.Sprintf("📊 Built %d posts in %v (cache: %d/%d hits, %.0f%%%s)\n",
 		m.PostsProcessed,
 		duration,
 		m.CacheHits,
 		total,
 		hitRate,
+		extra,
 	)
 }
 
+// Print writes the summary line, or a structured record when logging JSON
 func (m *BuildMetrics) Print() {
-	fmt.Println(m.String())
+	if !logging.IsJSON() {
+		logging.Statusf("%s", m.String())
+		return
+	}
+	slog.Info("Build complete",
+		"posts", m.PostsProcessed,
+		"duration_ms", millis(m.TotalDuration()),
+		"cache_hits", m.CacheHits,
+		"cache_misses", m.CacheMisses,
+		"template_compile_ms", millis(m.TemplateCompile),
+		"static_skipped_files", m.StaticSkipped,
+		"static_skipped_bytes", m.StaticSkippedBytes,
+	)
+}
+
+// Add accumulates the counters of another build into m.
+// Used to report combined metrics for multi-site workspace builds.
+func (m *BuildMetrics) Add(other *BuildMetrics) {
+	if other == nil {
+		return
+	}
+	m.PostsProcessed += other.PostsProcessed
+	m.CacheHits += other.CacheHits
+	m.CacheMisses += other.CacheMisses
+	m.TemplateCompile += other.TemplateCompile
+	m.StaticSkipped += other.StaticSkipped
+	m.StaticSkippedBytes += other.StaticSkippedBytes
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
 }
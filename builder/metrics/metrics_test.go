@@ -158,6 +158,22 @@ func TestString(t *testing.T) {
 			},
 			contains: []string{"Built 5 posts", "cache: 0/5 hits", "0%"},
 		},
+		{
+			name: "template compile time",
+			setup: func(m *BuildMetrics) {
+				m.RecordTemplateCompile(1500 * time.Microsecond)
+				m.RecordTemplateCompile(500 * time.Microsecond)
+			},
+			contains: []string{"templates compiled in 2ms"},
+		},
+		{
+			name: "skipped static files",
+			setup: func(m *BuildMetrics) {
+				m.RecordStaticSkipped(3, 1536)
+				m.RecordStaticSkipped(1, 3*1024*1024)
+			},
+			contains: []string{"4 static files unchanged (3.0 MB skipped)"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -204,3 +220,22 @@ func TestStringFormat(t *testing.T) {
 		t.Error("String() should end with '%)")
 	}
 }
+
+func TestAdd(t *testing.T) {
+	total := NewBuildMetrics()
+	site := &BuildMetrics{PostsProcessed: 3, CacheHits: 2, CacheMisses: 1}
+
+	total.Add(site)
+	total.Add(site)
+	total.Add(nil)
+
+	if total.PostsProcessed != 6 {
+		t.Errorf("PostsProcessed = %d, want 6", total.PostsProcessed)
+	}
+	if total.CacheHits != 4 {
+		t.Errorf("CacheHits = %d, want 4", total.CacheHits)
+	}
+	if total.CacheMisses != 2 {
+		t.Errorf("CacheMisses = %d, want 2", total.CacheMisses)
+	}
+}
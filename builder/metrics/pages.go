@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultSlowPages is how many pages the slow-page report lists when only a
+// JSON output file was asked for
+const DefaultSlowPages = 10
+
+// PageTiming breaks down the time the build spent on one page. Parse is the
+// markdown work excluding the diagram and math rendering done along with it;
+// pages served from the cache only have a render time.
+type PageTiming struct {
+	Path    string // Content path relative to the content directory
+	Parse   time.Duration
+	Diagram time.Duration
+	Math    time.Duration
+	Render  time.Duration
+}
+
+// Total is the time spent on the page across all phases
+func (p PageTiming) Total() time.Duration {
+	return p.Parse + p.Diagram + p.Math + p.Render
+}
+
+// MarshalJSON writes durations as milliseconds, which is what people read
+func (p PageTiming) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	return json.Marshal(struct {
+		Path    string  `json:"path"`
+		Total   float64 `json:"total_ms"`
+		Parse   float64 `json:"parse_ms"`
+		Diagram float64 `json:"diagram_ms"`
+		Math    float64 `json:"math_ms"`
+		Render  float64 `json:"render_ms"`
+	}{p.Path, ms(p.Total()), ms(p.Parse), ms(p.Diagram), ms(p.Math), ms(p.Render)})
+}
+
+// RecordPageParse records the markdown phases of a page. Safe for concurrent use.
+func (m *BuildMetrics) RecordPageParse(path string, parse, diagram, math time.Duration) {
+	m.pagesMu.Lock()
+	defer m.pagesMu.Unlock()
+	p := m.page(path)
+	p.Parse += parse
+	p.Diagram += diagram
+	p.Math += math
+}
+
+// RecordPageRender records the template render of a page. Safe for concurrent use.
+func (m *BuildMetrics) RecordPageRender(path string, d time.Duration) {
+	m.pagesMu.Lock()
+	defer m.pagesMu.Unlock()
+	m.page(path).Render += d
+}
+
+// page returns the timing entry for path, creating it. Callers hold pagesMu.
+func (m *BuildMetrics) page(path string) *PageTiming {
+	if m.pages == nil {
+		m.pages = make(map[string]*PageTiming)
+	}
+	p, ok := m.pages[path]
+	if !ok {
+		p = &PageTiming{Path: path}
+		m.pages[path] = p
+	}
+	return p
+}
+
+// SlowestPages returns the n pages that took longest, slowest first.
+// n <= 0 returns every recorded page.
+func (m *BuildMetrics) SlowestPages(n int) []PageTiming {
+	m.pagesMu.Lock()
+	pages := make([]PageTiming, 0, len(m.pages))
+	for _, p := range m.pages {
+		pages = append(pages, *p)
+	}
+	m.pagesMu.Unlock()
+
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].Total() != pages[j].Total() {
+			return pages[i].Total() > pages[j].Total()
+		}
+		return pages[i].Path < pages[j].Path
+	})
+	if n > 0 && len(pages) > n {
+		pages = pages[:n]
+	}
+	return pages
+}
+
+// SlowPagesString formats the n slowest pages, one per line
+func (m *BuildMetrics) SlowPagesString(n int) string {
+	pages := m.SlowestPages(n)
+	if len(pages) == 0 {
+		return ""
+	}
+	round := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🐢 Slowest %d pages:\n", len(pages))
+	for i, p := range pages {
+		fmt.Fprintf(&sb, "   %2d. %-10v %s (parse %v, diagrams %v, math %v, render %v)\n",
+			i+1, round(p.Total()), p.Path, round(p.Parse), round(p.Diagram), round(p.Math), round(p.Render))
+	}
+	return sb.String()
+}
+
+// WriteSlowPages writes the n slowest pages to path as a JSON array
+func (m *BuildMetrics) WriteSlowPages(path string, n int) error {
+	data, err := json.MarshalIndent(m.SlowestPages(n), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlowestPages(t *testing.T) {
+	m := NewBuildMetrics()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.RecordPageRender("cached.md", time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	m.RecordPageParse("diagrams.md", 2*time.Millisecond, 40*time.Millisecond, 0)
+	m.RecordPageRender("diagrams.md", time.Millisecond)
+	m.RecordPageParse("math.md", time.Millisecond, 0, 5*time.Millisecond)
+
+	pages := m.SlowestPages(0)
+	if len(pages) != 3 {
+		t.Fatalf("SlowestPages(0) returned %d pages, want 3", len(pages))
+	}
+	wantOrder := []string{"diagrams.md", "cached.md", "math.md"}
+	for i, want := range wantOrder {
+		if pages[i].Path != want {
+			t.Errorf("pages[%d] = %s, want %s", i, pages[i].Path, want)
+		}
+	}
+	if got := pages[0].Total(); got != 43*time.Millisecond {
+		t.Errorf("diagrams.md total = %v, want 43ms", got)
+	}
+	if got := pages[1].Render; got != 10*time.Millisecond {
+		t.Errorf("concurrent renders summed to %v, want 10ms", got)
+	}
+
+	if top := m.SlowestPages(1); len(top) != 1 || top[0].Path != "diagrams.md" {
+		t.Errorf("SlowestPages(1) = %+v", top)
+	}
+
+	report := m.SlowPagesString(2)
+	for _, want := range []string{"Slowest 2 pages", "diagrams.md", "diagrams 40ms"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("SlowPagesString() = %q, should contain %q", report, want)
+		}
+	}
+	if strings.Contains(report, "math.md") {
+		t.Error("SlowPagesString(2) should only list two pages")
+	}
+}
+
+func TestWriteSlowPages(t *testing.T) {
+	m := NewBuildMetrics()
+	m.RecordPageParse("a.md", 1500*time.Microsecond, 0, 0)
+
+	path := filepath.Join(t.TempDir(), "slow.json")
+	if err := m.WriteSlowPages(path, DefaultSlowPages); err != nil {
+		t.Fatalf("WriteSlowPages failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got) != 1 || got[0]["path"] != "a.md" || got[0]["parse_ms"] != 1.5 || got[0]["total_ms"] != 1.5 {
+		t.Errorf("report = %v", got)
+	}
+}
+
+func TestSlowPagesString_Empty(t *testing.T) {
+	if got := NewBuildMetrics().SlowPagesString(5); got != "" {
+		t.Errorf("SlowPagesString() with no pages = %q, want empty", got)
+	}
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+)
+
+// Stage is a kind of per-item work counted for the progress display
+type Stage int
+
+const (
+	StageParse Stage = iota
+	StageRender
+	StageCards
+	StageImages
+	numStages
+)
+
+var stageNames = [numStages]string{"parsed", "rendered", "cards", "images"}
+
+const (
+	// progressRedraw is how often the terminal progress bar is redrawn
+	progressRedraw = 100 * time.Millisecond
+	// progressLogInterval is how often progress is logged without a terminal
+	progressLogInterval = 5 * time.Second
+	progressBarWidth    = 20
+)
+
+type stageCounter struct {
+	done, total atomic.Int64
+}
+
+// StageProgress is how far the build has got through one stage
+type StageProgress struct {
+	Name        string
+	Done, Total int64
+}
+
+// AddWork adds n items to a stage's total. Safe for concurrent use and on a
+// nil receiver, so callers without metrics needn't check.
+func (m *BuildMetrics) AddWork(s Stage, n int) {
+	if m != nil {
+		m.progress[s].total.Add(int64(n))
+	}
+}
+
+// WorkDone counts one finished item of a stage. Safe for concurrent use and
+// on a nil receiver.
+func (m *BuildMetrics) WorkDone(s Stage) {
+	if m != nil {
+		m.progress[s].done.Add(1)
+	}
+}
+
+// Progress returns the stages that have work, in pipeline order
+func (m *BuildMetrics) Progress() []StageProgress {
+	var stages []StageProgress
+	for s := Stage(0); s < numStages; s++ {
+		if total := m.progress[s].total.Load(); total > 0 {
+			stages = append(stages, StageProgress{Name: stageNames[s], Done: m.progress[s].done.Load(), Total: total})
+		}
+	}
+	return stages
+}
+
+// formatProgress renders stages as "parsed 40/100 · rendered 0/100", led by
+// a bar of the overall completion when bar is set
+func formatProgress(stages []StageProgress, bar bool) string {
+	var done, total int64
+	parts := make([]string, len(stages))
+	for i, st := range stages {
+		done += min(st.Done, st.Total)
+		total += st.Total
+		parts[i] = fmt.Sprintf("%s %d/%d", st.Name, st.Done, st.Total)
+	}
+	counts := strings.Join(parts, " · ")
+	if !bar || total == 0 {
+		return counts
+	}
+	filled := int(done * progressBarWidth / total)
+	return fmt.Sprintf("[%s%s] %3d%%  %s", strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled), done*100/total, counts)
+}
+
+// StartProgress shows build progress until the returned func is called: a
+// bar redrawn in place on a terminal, otherwise a status line every few
+// seconds while counts change
+func (m *BuildMetrics) StartProgress() (stop func()) {
+	interactive := logging.Interactive()
+	interval := progressLogInterval
+	if interactive {
+		interval = progressRedraw
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := ""
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			stages := m.Progress()
+			if len(stages) == 0 {
+				continue
+			}
+			line := formatProgress(stages, interactive)
+			if line == last {
+				continue
+			}
+			last = line
+			if interactive {
+				logging.SetTransient("⏳ " + line)
+			} else {
+				logging.Statusf("⏳ Progress: %s", line)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+			if interactive {
+				logging.SetTransient("")
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	m := NewBuildMetrics()
+	if got := m.Progress(); len(got) != 0 {
+		t.Errorf("Progress() before any work = %+v, want empty", got)
+	}
+
+	m.AddWork(StageParse, 10)
+	m.AddWork(StageCards, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.WorkDone(StageParse)
+		}()
+	}
+	wg.Wait()
+
+	got := m.Progress()
+	want := []StageProgress{{Name: "parsed", Done: 10, Total: 10}, {Name: "cards", Done: 0, Total: 2}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Progress() = %+v, want %+v", got, want)
+	}
+
+	var nilMetrics *BuildMetrics
+	nilMetrics.AddWork(StageImages, 1) // Must not panic
+	nilMetrics.WorkDone(StageImages)
+}
+
+func TestFormatProgress(t *testing.T) {
+	stages := []StageProgress{{Name: "parsed", Done: 3, Total: 4}, {Name: "rendered", Done: 1, Total: 4}}
+
+	if got := formatProgress(stages, false); got != "parsed 3/4 · rendered 1/4" {
+		t.Errorf("formatProgress(line) = %q", got)
+	}
+	bar := formatProgress(stages, true)
+	if !strings.HasPrefix(bar, "["+strings.Repeat("█", 10)+strings.Repeat("░", 10)+"]  50%") {
+		t.Errorf("formatProgress(bar) = %q, want a half-full bar", bar)
+	}
+	if !strings.HasSuffix(bar, "parsed 3/4 · rendered 1/4") {
+		t.Errorf("formatProgress(bar) = %q, should end with the counts", bar)
+	}
+}
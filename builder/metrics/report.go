@@ -0,0 +1,294 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Phase is the time spent in one stage of the build pipeline
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Warning is a warning or error logged while building
+type Warning struct {
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// RecordPhase adds time spent in a build phase. Phases are reported in the
+// order they were first recorded. Safe for concurrent use.
+func (m *BuildMetrics) RecordPhase(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.phases {
+		if m.phases[i].Name == name {
+			m.phases[i].Duration += d
+			return
+		}
+	}
+	m.phases = append(m.phases, Phase{Name: name, Duration: d})
+}
+
+// Phases returns the recorded build phases in order
+func (m *BuildMetrics) Phases() []Phase {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Phase(nil), m.phases...)
+}
+
+// Warnings returns the warnings and errors logged during the build
+func (m *BuildMetrics) Warnings() []Warning {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Warning(nil), m.warnings...)
+}
+
+// RecordWarning adds a warning to the build report without logging it, for
+// callers that print their own summary. Safe for concurrent use.
+func (m *BuildMetrics) RecordWarning(w Warning) {
+	m.recordWarning(w)
+}
+
+// recordWarning stores w and reports whether it should be printed, which
+// is false for errors over the --max-errors budget
+func (m *BuildMetrics) recordWarning(w Warning) bool {
+	m.mu.Lock()
+	m.warnings = append(m.warnings, w)
+	show, stop := m.countError(w)
+	m.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	return show
+}
+
+// LogHandler wraps h so every warning and error logged through it is also
+// recorded for the build report
+func (m *BuildMetrics) LogHandler(h slog.Handler) slog.Handler {
+	return &warningHandler{Handler: h, metrics: m}
+}
+
+type warningHandler struct {
+	slog.Handler
+	metrics *BuildMetrics
+	attrs   []slog.Attr
+}
+
+func (h *warningHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		w := Warning{Level: r.Level.String(), Message: r.Message}
+		add := func(a slog.Attr) bool {
+			if w.Attrs == nil {
+				w.Attrs = make(map[string]string)
+			}
+			w.Attrs[a.Key] = a.Value.String()
+			return true
+		}
+		for _, a := range h.attrs {
+			add(a)
+		}
+		r.Attrs(add)
+		if !h.metrics.recordWarning(w) {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *warningHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &warningHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		metrics: h.metrics,
+		attrs:   append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+func (h *warningHandler) WithGroup(name string) slog.Handler {
+	return &warningHandler{Handler: h.Handler.WithGroup(name), metrics: h.metrics, attrs: h.attrs}
+}
+
+// OutputStat counts the files of one type in the output directory
+type OutputStat struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Report is the machine-readable build summary written by --report
+type Report struct {
+	GeneratedAt        time.Time             `json:"generated_at"`
+	DurationMs         float64               `json:"duration_ms"`
+	Posts              int                   `json:"posts"`
+	CacheHits          int                   `json:"cache_hits"`
+	CacheMisses        int                   `json:"cache_misses"`
+	CacheHitRatio      float64               `json:"cache_hit_ratio"`
+	TemplateMs         float64               `json:"template_compile_ms"`
+	StaticSkipped      int                   `json:"static_skipped_files"`
+	StaticSkippedBytes int64                 `json:"static_skipped_bytes"`
+	Phases             []ReportPhase         `json:"phases"`
+	Output             map[string]OutputStat `json:"output"` // Keyed by file extension
+	OutputTotal        OutputStat            `json:"output_total"`
+	Warnings           []Warning             `json:"warnings"`
+	SlowestPages       []PageTiming          `json:"slowest_pages"`
+}
+
+// CacheLookups is the number of posts looked up in the cache
+func (r *Report) CacheLookups() int {
+	return r.CacheHits + r.CacheMisses
+}
+
+// ReportPhase is a Phase with its duration in milliseconds
+type ReportPhase struct {
+	Name       string  `json:"name"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Report summarises the build, sizing what ended up in outputDir on disk
+func (m *BuildMetrics) Report(outputDir string) (*Report, error) {
+	r := &Report{
+		GeneratedAt:        time.Now().UTC().Truncate(time.Second),
+		DurationMs:         millis(m.TotalDuration()),
+		Posts:              m.PostsProcessed,
+		CacheHits:          m.CacheHits,
+		CacheMisses:        m.CacheMisses,
+		TemplateMs:         millis(m.TemplateCompile),
+		StaticSkipped:      m.StaticSkipped,
+		StaticSkippedBytes: m.StaticSkippedBytes,
+		Output:             make(map[string]OutputStat),
+		Warnings:           m.Warnings(),
+		SlowestPages:       m.SlowestPages(DefaultSlowPages),
+	}
+	if total := m.CacheHits + m.CacheMisses; total > 0 {
+		r.CacheHitRatio = float64(m.CacheHits) / float64(total)
+	}
+	for _, p := range m.Phases() {
+		r.Phases = append(r.Phases, ReportPhase{Name: p.Name, DurationMs: millis(p.Duration)})
+	}
+	if r.Warnings == nil {
+		r.Warnings = []Warning{}
+	}
+
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		return r, nil
+	}
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if ext == "" {
+			ext = "other"
+		}
+		stat := r.Output[ext]
+		stat.Files++
+		stat.Bytes += info.Size()
+		r.Output[ext] = stat
+		r.OutputTotal.Files++
+		r.OutputTotal.Bytes += info.Size()
+		return nil
+	})
+	return r, err
+}
+
+// Write saves the report to path, as HTML when it ends in .html and as JSON
+// otherwise
+func (r *Report) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if strings.EqualFold(filepath.Ext(path), ".html") {
+		return reportTemplate.Execute(f, r)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// OutputTypes returns the output extensions, largest first (for the HTML report)
+func (r *Report) OutputTypes() []string {
+	types := make([]string, 0, len(r.Output))
+	for ext := range r.Output {
+		types = append(types, ext)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if r.Output[types[i]].Bytes != r.Output[types[j]].Bytes {
+			return r.Output[types[i]].Bytes > r.Output[types[j]].Bytes
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes":   formatBytes,
+	"ms":      func(v float64) string { return fmt.Sprintf("%.1f ms", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+	"dur":     func(d time.Duration) string { return d.Round(time.Microsecond).String() },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Kosh build report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: .3rem .6rem; border-bottom: 1px solid #ddd; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.warn { color: #a15c00; } .error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Build report</h1>
+<p>{{.GeneratedAt.Format "2006-01-02 15:04:05 UTC"}}: built {{.Posts}} posts in {{ms .DurationMs}}, cache {{.CacheHits}}/{{.CacheLookups}} hits ({{percent .CacheHitRatio}}).</p>
+
+<h2>Phases</h2>
+<table>
+<tr><th>Phase</th><th>Time</th></tr>
+{{range .Phases}}<tr><td>{{.Name}}</td><td class="num">{{ms .DurationMs}}</td></tr>
+{{end}}<tr><td>Template compile</td><td class="num">{{ms .TemplateMs}}</td></tr>
+</table>
+
+<h2>Output</h2>
+<table>
+<tr><th>Type</th><th>Files</th><th>Size</th></tr>
+{{range $ext := .OutputTypes}}{{with index $.Output $ext}}<tr><td>{{$ext}}</td><td class="num">{{.Files}}</td><td class="num">{{bytes .Bytes}}</td></tr>
+{{end}}{{end}}<tr><th>Total</th><th class="num">{{.OutputTotal.Files}}</th><th class="num">{{bytes .OutputTotal.Bytes}}</th></tr>
+</table>
+{{if .StaticSkipped}}<p>{{.StaticSkipped}} unchanged static files ({{bytes .StaticSkippedBytes}}) were not copied.</p>{{end}}
+
+<h2>Slowest pages</h2>
+<table>
+<tr><th>Page</th><th>Total</th><th>Parse</th><th>Diagrams</th><th>Math</th><th>Render</th></tr>
+{{range .SlowestPages}}<tr><td>{{.Path}}</td><td class="num">{{dur .Total}}</td><td class="num">{{dur .Parse}}</td><td class="num">{{dur .Diagram}}</td><td class="num">{{dur .Math}}</td><td class="num">{{dur .Render}}</td></tr>
+{{end}}</table>
+
+<h2>Warnings ({{len .Warnings}})</h2>
+{{if .Warnings}}<table>
+<tr><th>Level</th><th>Message</th><th>Details</th></tr>
+{{range .Warnings}}<tr class="{{if eq .Level "ERROR"}}error{{else}}warn{{end}}"><td>{{.Level}}</td><td>{{.Message}}</td><td>{{range $k, $v := .Attrs}}{{$k}}={{$v}} {{end}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogHandler_RecordsWarnings(t *testing.T) {
+	m := NewBuildMetrics()
+	var out bytes.Buffer
+	logger := slog.New(m.LogHandler(slog.NewTextHandler(&out, nil)))
+
+	logger.Info("not recorded")
+	logger.With("component", "assets").Warn("Failed to copy", "path", "static/a.png")
+	logger.Error("Build failed", "error", "boom")
+
+	warnings := m.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("recorded %d warnings, want 2: %+v", len(warnings), warnings)
+	}
+	if w := warnings[0]; w.Level != "WARN" || w.Message != "Failed to copy" || w.Attrs["component"] != "assets" || w.Attrs["path"] != "static/a.png" {
+		t.Errorf("warnings[0] = %+v", w)
+	}
+	if w := warnings[1]; w.Level != "ERROR" || w.Attrs["error"] != "boom" {
+		t.Errorf("warnings[1] = %+v", w)
+	}
+	if !strings.Contains(out.String(), "not recorded") {
+		t.Error("wrapped handler should still receive every record")
+	}
+}
+
+func TestRecordPhase(t *testing.T) {
+	m := NewBuildMetrics()
+	m.RecordPhase("assets", time.Millisecond)
+	m.RecordPhase("content", 2*time.Millisecond)
+	m.RecordPhase("assets", time.Millisecond)
+
+	phases := m.Phases()
+	if len(phases) != 2 || phases[0].Name != "assets" || phases[0].Duration != 2*time.Millisecond || phases[1].Name != "content" {
+		t.Errorf("Phases() = %+v", phases)
+	}
+}
+
+func TestReport(t *testing.T) {
+	outputDir := t.TempDir()
+	files := map[string]string{
+		"index.html":           "<html></html>",
+		"docs/a.html":          "<p>a</p>",
+		"static/css/site.css":  "body{}",
+		".nojekyll":            "",
+		"static/wasm/search.X": "x",
+	}
+	for rel, content := range files {
+		path := filepath.Join(outputDir, rel)
+		_ = os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewBuildMetrics()
+	m.PostsProcessed = 4
+	m.CacheHits = 3
+	m.CacheMisses = 1
+	m.RecordPhase("content", 5*time.Millisecond)
+	m.RecordPageRender("a.md", time.Millisecond)
+	slog.New(m.LogHandler(slog.NewTextHandler(&bytes.Buffer{}, nil))).Warn("careful")
+
+	report, err := m.Report(outputDir)
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if report.CacheHitRatio != 0.75 {
+		t.Errorf("CacheHitRatio = %v, want 0.75", report.CacheHitRatio)
+	}
+	if got := report.Output["html"]; got.Files != 2 || got.Bytes != int64(len("<html></html>")+len("<p>a</p>")) {
+		t.Errorf("Output[html] = %+v", got)
+	}
+	if report.Output["x"].Files != 1 || report.Output["css"].Files != 1 {
+		t.Errorf("Output = %+v", report.Output)
+	}
+	if report.OutputTotal.Files != len(files) {
+		t.Errorf("OutputTotal.Files = %d, want %d", report.OutputTotal.Files, len(files))
+	}
+	if types := report.OutputTypes(); types[0] != "html" {
+		t.Errorf("OutputTypes() = %v, want html (largest) first", types)
+	}
+
+	jsonPath := filepath.Join(t.TempDir(), "report.json")
+	if err := report.Write(jsonPath); err != nil {
+		t.Fatalf("Write(json) failed: %v", err)
+	}
+	data, _ := os.ReadFile(jsonPath)
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	for _, key := range []string{"phases", "output", "warnings", "slowest_pages", "cache_hit_ratio"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON report is missing %q", key)
+		}
+	}
+
+	htmlPath := filepath.Join(t.TempDir(), "report.html")
+	if err := report.Write(htmlPath); err != nil {
+		t.Fatalf("Write(html) failed: %v", err)
+	}
+	html, _ := os.ReadFile(htmlPath)
+	for _, want := range []string{"<!DOCTYPE html>", "cache 3/4 hits (75%)", "<td>content</td>", "careful", "a.md"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("HTML report should contain %q", want)
+		}
+	}
+}
+
+func TestReport_MissingOutput(t *testing.T) {
+	report, err := NewBuildMetrics().Report(filepath.Join(t.TempDir(), "public"))
+	if err != nil {
+		t.Fatalf("Report on a missing output dir failed: %v", err)
+	}
+	if report.OutputTotal.Files != 0 || report.Warnings == nil {
+		t.Errorf("report = %+v", report)
+	}
+}
@@ -43,9 +43,19 @@ type NavPage struct {
 type VersionInfo struct {
 	Name      string
 	Path      string // Raw version path (e.g., "v7.0")
-	URL       string
+	URL       string // The page in this version, or the version's home page without it
 	IsLatest  bool
 	IsCurrent bool
+	HasPage   bool // The current page exists in this version
+}
+
+// LanguageInfo is one entry of a page's language switcher
+type LanguageInfo struct {
+	Code      string
+	Name      string
+	URL       string // The page in this language, or the language's home page without it
+	IsCurrent bool
+	HasPage   bool // The current page exists in this language
 }
 
 // PostMetadata represents the frontmatter and derived data of a markdown post.
@@ -59,7 +69,33 @@ type PostMetadata struct {
 	Pinned      bool
 	Draft       bool
 	DateObj     time.Time
-	Version     string // "v2.0", "v1.0", "" for latest
+	PublishDate time.Time // Not built before this (`publishDate:`), zero when unset
+	ExpiryDate  time.Time // Dropped from builds after this (`expiryDate:`), zero when unset
+	ModTime     time.Time // Source file's modification time, zero when unknown
+	Version     string    // "v2.0", "v1.0", "" for latest
+	Lang        string    // Language code, "" on sites without languages
+	Audio       *Audio    // Podcast episode, nil for most posts
+	Aliases     []string  // Old URLs redirecting here (`aliases:`)
+}
+
+// Audio is a post's `audio:` frontmatter: the episode its audio shortcode
+// plays and its feed enclosure
+type Audio struct {
+	Src         string // Path under static/
+	URL         string
+	Type        string // MIME type
+	Length      int64  // Bytes, 0 when the file wasn't found
+	Duration    int    // Seconds, 0 when unknown
+	Title       string // Accessible name of the player
+	Chapters    []Chapter
+	ChaptersURL string // JSON chapters for podcast apps, empty without chapters
+}
+
+// Chapter is a chapter marker of an audio episode
+type Chapter struct {
+	Start int    `json:"startTime"` // Seconds
+	Title string `json:"title"`
+	URL   string `json:"url,omitempty"`
 }
 
 // TagData represents a tag and its frequency.
@@ -69,6 +105,27 @@ type TagData struct {
 	Count int
 }
 
+// List page kinds
+const (
+	ListHome    = "home"
+	ListSection = "section"
+	ListTags    = "tags"
+	ListTerm    = "term"
+)
+
+// ListPage describes a list page for list.html, tags.html and term.html:
+// the home page, a content section, the tags index or a tag (term) page. The
+// posts of the current page are PageData.Posts, its position PageData.Paginator.
+type ListPage struct {
+	Kind        string    // ListHome, ListSection, ListTags or ListTerm
+	Term        string    // Tag of a term page
+	Section     string    // Content directory of a section page, e.g. "docs/guides"
+	Count       int       // Posts listed, over all pages
+	Terms       []TagData // Every tag with its post count
+	Subsections []TagData // Sections directly below this one (home and section pages)
+	RSSLink     string    // RSS feed of the list, the site's or with feeds.tags the tag's; empty without one
+}
+
 // Paginator holds state for pagination
 type Paginator struct {
 	CurrentPage int
@@ -100,20 +157,41 @@ type PageData struct {
 	TOC          []TOCEntry
 	SiteTree     []*TreeNode
 	Paginator    Paginator
+	List         *ListPage // Set on list pages (home, sections, tags)
 	Assets       map[string]string
 	Weight       int
 	ReadingTime  int
+	SourcePath   string // Content file the page is rendered from, empty for generated pages
+	NoIndex      bool   // Adds <meta name="robots" content="noindex"> (draft previews)
+	Layout       string // Named layout (layouts/<name>.html) a post is rendered with, "" for layout.html
 
 	// Navigation
 	Breadcrumbs []Breadcrumb
 	PrevPage    *NavPage
 	NextPage    *NavPage
+	Related     []PostMetadata // `related:` frontmatter, in the order given
+
+	// Languages
+	Lang      string         // Language code of the page, "" on sites without languages
+	LangURL   string         // Home page of the page's language
+	Languages []LanguageInfo // Language switcher
 
 	// Versioning
 	CurrentVersion string
 	Versions       []VersionInfo
 	IsOutdated     bool
 
+	// Comments
+	Comments    template.HTML // Comment widget markup, empty when disabled
+	CommentsCSP string        // CSP directives the widget needs, merged into contentSecurityPolicy
+
+	// Fediverse
+	FediverseCreator string // "@user@instance" for the fediverse:creator meta tag
+	DiscussURL       string // "Discuss on Mastodon" link, empty when disabled
+
+	// 404
+	NotFoundPages template.JS // JSON map of page path to title, used to suggest pages
+
 	// Config-driven fields
 	Config interface{} // To access Config fields in templates (Menu, Author, etc.)
 }
@@ -122,20 +200,52 @@ type PageData struct {
 
 type UrlSet struct {
 	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	ImageNS string   `xml:"xmlns:image,attr,omitempty"`
+	VideoNS string   `xml:"xmlns:video,attr,omitempty"`
 	Urls    []Url    `xml:"url"`
 }
 
-type Url struct {
+// SitemapIndex lists the files of a sitemap split past its URL limit
+type SitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []SitemapRef `xml:"sitemap"`
+}
+
+type SitemapRef struct {
 	Loc     string `xml:"loc"`
 	LastMod string `xml:"lastmod,omitempty"`
 }
 
+type Url struct {
+	Loc     string         `xml:"loc"`
+	LastMod string         `xml:"lastmod,omitempty"`
+	Images  []SitemapImage `xml:"image:image,omitempty"`
+	Videos  []SitemapVideo `xml:"video:video,omitempty"`
+}
+
+// SitemapImage is an image:image entry of the image sitemap extension
+type SitemapImage struct {
+	Loc string `xml:"image:loc"`
+}
+
+// SitemapVideo is a video:video entry of the video sitemap extension.
+// Either ContentLoc or PlayerLoc must be set.
+type SitemapVideo struct {
+	ThumbnailLoc string `xml:"video:thumbnail_loc"`
+	Title        string `xml:"video:title"`
+	Description  string `xml:"video:description"`
+	ContentLoc   string `xml:"video:content_loc,omitempty"`
+	PlayerLoc    string `xml:"video:player_loc,omitempty"`
+}
+
 // --- RSS Structures ---
 
 type Rss struct {
-	XMLName xml.Name `xml:"rss"`
-	Version string   `xml:"version,attr"`
-	Channel Channel  `xml:"channel"`
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	ITunesNS  string   `xml:"xmlns:itunes,attr,omitempty"`  // Set when the feed has episodes
+	PodcastNS string   `xml:"xmlns:podcast,attr,omitempty"` // Set when episodes have chapters
+	Channel   Channel  `xml:"channel"`
 }
 
 type Channel struct {
@@ -151,6 +261,23 @@ type Item struct {
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
 	Guid        string `xml:"guid"`
+
+	Enclosure *Enclosure       `xml:"enclosure,omitempty"`
+	Duration  string           `xml:"itunes:duration,omitempty"`
+	Chapters  *PodcastChapters `xml:"podcast:chapters,omitempty"`
+}
+
+// Enclosure is the media file of a podcast episode
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// PodcastChapters links an episode's JSON chapters (Podcasting 2.0)
+type PodcastChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
 }
 
 // --- Graph Data Structures ---
@@ -173,6 +300,45 @@ type GraphData struct {
 	Links []GraphLink `json:"links"`
 }
 
+// --- JSON API Structures ---
+
+// APIPost is a post in the /api/ listing endpoints
+type APIPost struct {
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Date        string   `json:"date,omitempty"` // RFC 3339
+	Tags        []string `json:"tags,omitempty"`
+	ReadingTime int      `json:"readingTime"` // Minutes
+	Version     string   `json:"version,omitempty"`
+	Pinned      bool     `json:"pinned,omitempty"`
+}
+
+// APIPage is one page of /api/posts/page/N.json
+type APIPage struct {
+	Page       int       `json:"page"`
+	TotalPages int       `json:"totalPages"`
+	TotalPosts int       `json:"totalPosts"`
+	Prev       string    `json:"prev,omitempty"` // URL of the previous page's JSON
+	Next       string    `json:"next,omitempty"`
+	Posts      []APIPost `json:"posts"`
+}
+
+// APITag is a tag in /api/tags.json
+type APITag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	URL   string `json:"url"` // Tag page
+	API   string `json:"api"` // The tag's /api/tags/<tag>.json
+}
+
+// APITerm is /api/tags/<tag>.json: every post with the tag
+type APITerm struct {
+	Tag   string    `json:"tag"`
+	Count int       `json:"count"`
+	Posts []APIPost `json:"posts"`
+}
+
 // --- Search Structures ---
 
 type PostRecord struct {
@@ -182,9 +348,10 @@ type PostRecord struct {
 	Link            string   `msgpack:"link"`
 	Description     string   `msgpack:"desc"`
 	Tags            []string `msgpack:"tags"`
-	NormalizedTags  []string `msgpack:"norm_tags"` // Lowercase tags for search
-	Content         string   `msgpack:"content"`   // Raw plain text for snippet extraction
-	Version         string   `msgpack:"ver"`       // Version scoping
+	NormalizedTags  []string `msgpack:"norm_tags"`       // Lowercase tags for search
+	Content         string   `msgpack:"content"`         // Raw plain text for snippet extraction
+	Version         string   `msgpack:"ver"`             // Version scoping
+	Boost           float64  `msgpack:"boost,omitempty"` // Score multiplier from recency and section boosts (0 = 1)
 }
 
 // IndexedPost bundles a search record with pre-computed word frequencies for BM25
@@ -192,6 +359,7 @@ type IndexedPost struct {
 	Record    PostRecord     `msgpack:"rec"`
 	WordFreqs map[string]int `msgpack:"freqs"`
 	DocLen    int            `msgpack:"len"`
+	Date      time.Time      `msgpack:"date"` // Page date, for the recency boost
 }
 
 type SearchIndex struct {
@@ -200,6 +368,34 @@ type SearchIndex struct {
 	DocLens    map[int]int            `msgpack:"lens"` // postID -> word count
 	AvgDocLen  float64                `msgpack:"avg"`
 	TotalDocs  int                    `msgpack:"total"`
-	StemMap    map[string][]string    `msgpack:"stem,omitempty"`  // stemmed -> original forms
-	NgramIndex map[string][]string    `msgpack:"ngram,omitempty"` // trigram -> terms (for fuzzy search)
+	StemMap    map[string][]string    `msgpack:"stem,omitempty"`    // stemmed -> original forms
+	NgramIndex map[string][]string    `msgpack:"ngram,omitempty"`   // trigram -> terms (for fuzzy search)
+	Weights    *SearchWeights         `msgpack:"weights,omitempty"` // nil weighs every field 1
+}
+
+// SearchWeights are the field weights the client scores matches with
+type SearchWeights struct {
+	Title float64 `msgpack:"title"`
+	Tags  float64 `msgpack:"tags"`
+	Body  float64 `msgpack:"body"`
+}
+
+// --- Webmention Structures ---
+
+// Webmention is a received mention of a page, as reported by webmention.io
+type Webmention struct {
+	ID        int              `json:"id"`
+	Type      string           `json:"type"` // in-reply-to, like-of, repost-of, bookmark-of or mention-of
+	Source    string           `json:"source"`
+	Target    string           `json:"target"`
+	URL       string           `json:"url"`
+	Published time.Time        `json:"published"`
+	Content   string           `json:"content,omitempty"` // Plain text
+	Author    WebmentionAuthor `json:"author"`
+}
+
+type WebmentionAuthor struct {
+	Name  string `json:"name"`
+	Photo string `json:"photo,omitempty"`
+	URL   string `json:"url,omitempty"`
 }
@@ -0,0 +1,113 @@
+// Package modules fetches remote content modules (git repositories) into the
+// cache directory so they can be mounted into the content tree at build time.
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// Dir is the subdirectory of the cache directory that holds module checkouts
+const Dir = "modules"
+
+// CheckoutDir returns where a module is checked out. Each URL+ref pair gets its
+// own directory, so changing the ref in kosh.yaml fetches a fresh copy.
+func CheckoutDir(cacheDir string, m config.ContentModule) string {
+	id := cache.HashString(m.URL + "@" + m.Ref)[:16]
+	return filepath.Join(cacheDir, Dir, RepoName(m.URL)+"-"+id)
+}
+
+// RepoName derives a directory name from a git URL
+// (e.g. "https://github.com/org/docs.git" -> "docs")
+func RepoName(url string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	name = path.Base(strings.ReplaceAll(name, ":", "/"))
+	if name == "" || name == "." || name == "/" {
+		return "module"
+	}
+	return name
+}
+
+// Target returns the directory under contentDir that the module is mounted at
+func Target(m config.ContentModule) string {
+	if m.Target != "" {
+		return m.Target
+	}
+	return RepoName(m.URL)
+}
+
+// Ensure returns the module's content directory, cloning it first if it is not
+// cached yet. Existing checkouts are reused; use Update to refresh them.
+func Ensure(ctx context.Context, cacheDir string, m config.ContentModule) (string, error) {
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	dir := CheckoutDir(cacheDir, m)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := fetch(ctx, dir, m); err != nil {
+			return "", err
+		}
+	}
+
+	contentDir := filepath.Join(dir, filepath.FromSlash(m.Path))
+	if info, err := os.Stat(contentDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("path %q not found in %s", m.Path, m.URL)
+	}
+	return contentDir, nil
+}
+
+// Update discards the cached checkout of a module and fetches it again
+func Update(ctx context.Context, cacheDir string, m config.ContentModule) (string, error) {
+	if err := os.RemoveAll(CheckoutDir(cacheDir, m)); err != nil {
+		return "", err
+	}
+	return Ensure(ctx, cacheDir, m)
+}
+
+// fetch does a shallow fetch of a single ref into dir.
+// It clones into a temporary directory first so an interrupted fetch never
+// leaves a half-populated checkout behind.
+func fetch(ctx context.Context, dir string, m config.ContentModule) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is required for content modules: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".fetch-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	ref := m.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "--", "origin", m.URL},
+		{"fetch", "--quiet", "--depth", "1", "--", "origin", ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = tmp
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s failed for %s: %w: %s", args[0], m.URL, err, strings.TrimSpace(string(out)))
+		}
+	}
+
+	return os.Rename(tmp, dir)
+}
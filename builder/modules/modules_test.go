@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestRepoName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/org/docs.git", "docs"},
+		{"https://github.com/org/docs/", "docs"},
+		{"git@github.com:org/product-a.git", "product-a"},
+		{"/srv/git/local", "local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := RepoName(tt.url); got != tt.want {
+				t.Errorf("RepoName(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckoutDirDependsOnRef(t *testing.T) {
+	a := CheckoutDir("/cache", config.ContentModule{URL: "https://example.com/docs.git", Ref: "v1"})
+	b := CheckoutDir("/cache", config.ContentModule{URL: "https://example.com/docs.git", Ref: "v2"})
+	if a == b {
+		t.Errorf("CheckoutDir() should differ per ref, got %q for both", a)
+	}
+}
+
+func TestEnsureLocalRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "docs", "intro.md"), []byte("# Intro"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	cacheDir := t.TempDir()
+	m := config.ContentModule{URL: repo, Ref: "main", Path: "docs"}
+
+	dir, err := Ensure(context.Background(), cacheDir, m)
+	if err != nil {
+		t.Fatalf("Ensure() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "intro.md")); err != nil {
+		t.Errorf("expected intro.md in checkout: %v", err)
+	}
+
+	// Missing subpath is reported
+	m.Path = "missing"
+	if _, err := Ensure(context.Background(), cacheDir, m); err == nil {
+		t.Error("Ensure() should fail for a missing path")
+	}
+
+	// A ref git would parse as an option is never run
+	m = config.ContentModule{URL: repo, Ref: "--upload-pack=false"}
+	if _, err := Ensure(context.Background(), cacheDir, m); err == nil || !strings.Contains(err.Error(), "must not start with") {
+		t.Errorf("Ensure() with an option as ref = %v, want it rejected", err)
+	}
+}
@@ -0,0 +1,541 @@
+package openapi
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// maxSchemaDepth bounds inline objects nested in schema tables
+const maxSchemaDepth = 4
+
+// Reference is a rendered API reference
+type Reference struct {
+	HTML string
+	TOC  []models.TOCEntry // Tags, operations and the schemas section
+}
+
+// Render writes the API reference of a spec: the servers, each operation
+// grouped by its first tag, then the reusable schemas, which $refs link to.
+// markdown renders the CommonMark descriptions of the spec to HTML; nil
+// escapes them as text.
+func Render(spec *Spec, markdown func(string) string) Reference {
+	r := &renderer{spec: spec, markdown: markdown, ids: map[string]int{}}
+	r.b.WriteString(`<section class="openapi">` + "\n")
+	r.info()
+	r.operations()
+	r.schemas()
+	r.b.WriteString("</section>\n")
+	return Reference{HTML: r.b.String(), TOC: r.toc}
+}
+
+type renderer struct {
+	spec     *Spec
+	markdown func(string) string
+	b        strings.Builder
+	toc      []models.TOCEntry
+	ids      map[string]int
+}
+
+// operation is an operation with where it lives
+type operation struct {
+	method, path string
+	op           *Operation
+	shared       []*Parameter
+}
+
+func (r *renderer) info() {
+	if r.spec.Info.Version != "" {
+		fmt.Fprintf(&r.b, `<p class="openapi-version">Version <code>%s</code></p>`+"\n", html.EscapeString(r.spec.Info.Version))
+	}
+	r.description(r.spec.Info.Description)
+	if servers := r.spec.serverURLs(); len(servers) > 0 {
+		r.b.WriteString(`<ul class="openapi-servers">` + "\n")
+		for _, s := range servers {
+			fmt.Fprintf(&r.b, "<li><code>%s</code>", html.EscapeString(s.URL))
+			if s.Description != "" {
+				fmt.Fprintf(&r.b, " — %s", html.EscapeString(s.Description))
+			}
+			r.b.WriteString("</li>\n")
+		}
+		r.b.WriteString("</ul>\n")
+	}
+}
+
+// groups sorts the operations by their first tag: declared tags in their
+// order, then undeclared ones by name, then untagged operations
+func (r *renderer) groups() ([]string, map[string][]operation) {
+	byTag := map[string][]operation{}
+	for _, path := range slices.Sorted(mapKeys(r.spec.Paths)) {
+		item := r.spec.Paths[path]
+		for _, o := range item.operations() {
+			tag := ""
+			if len(o.Op.Tags) > 0 {
+				tag = o.Op.Tags[0]
+			}
+			byTag[tag] = append(byTag[tag], operation{method: o.Method, path: path, op: o.Op, shared: item.Parameters})
+		}
+	}
+	var order []string
+	for _, t := range r.spec.Tags {
+		if _, ok := byTag[t.Name]; ok && !slices.Contains(order, t.Name) {
+			order = append(order, t.Name)
+		}
+	}
+	for _, t := range slices.Sorted(mapKeys(byTag)) {
+		if t != "" && !slices.Contains(order, t) {
+			order = append(order, t)
+		}
+	}
+	if _, ok := byTag[""]; ok {
+		order = append(order, "")
+	}
+	return order, byTag
+}
+
+func (r *renderer) operations() {
+	order, byTag := r.groups()
+	tagged := len(order) > 1 || (len(order) == 1 && order[0] != "")
+	level := 2
+	if tagged {
+		level = 3
+	}
+	for _, tag := range order {
+		if tagged {
+			name := tag
+			if name == "" {
+				name = "Other"
+			}
+			r.heading(2, "tag-"+name, html.EscapeString(name), name)
+			for _, t := range r.spec.Tags {
+				if t.Name == tag {
+					r.description(t.Description)
+				}
+			}
+		}
+		for _, o := range byTag[tag] {
+			r.operation(o, level)
+		}
+	}
+}
+
+func (r *renderer) operation(o operation, level int) {
+	title := o.op.Summary
+	if title == "" {
+		title = o.method + " " + o.path
+	}
+	idSource := o.op.OperationID
+	if idSource == "" {
+		idSource = o.method + " " + o.path
+	}
+	fmt.Fprintf(&r.b, `<div class="openapi-operation%s">`+"\n", map[bool]string{true: " openapi-deprecated", false: ""}[o.op.Deprecated])
+	r.heading(level, "op-"+idSource, html.EscapeString(title), title)
+	fmt.Fprintf(&r.b, `<p class="openapi-endpoint"><span class="openapi-method openapi-method-%s">%s</span> <code>%s</code>`, strings.ToLower(o.method), o.method, html.EscapeString(o.path))
+	if o.op.Deprecated {
+		r.b.WriteString(` <span class="openapi-badge">deprecated</span>`)
+	}
+	r.b.WriteString("</p>\n")
+	r.description(o.op.Description)
+
+	var params []*Parameter
+	var body *Parameter // Swagger 2 body parameter
+	for _, p := range mergeParameters(r.spec, o.shared, o.op.Parameters) {
+		if p.In == "body" {
+			body = p
+			continue
+		}
+		params = append(params, p)
+	}
+	if len(params) > 0 {
+		r.parameters(params)
+	}
+
+	if rb := r.spec.requestBody(o.op.RequestBody); rb != nil {
+		r.b.WriteString("<h4>Request body</h4>\n")
+		r.description(rb.Description)
+		r.content(rb.Content)
+	} else if body != nil {
+		r.b.WriteString("<h4>Request body</h4>\n")
+		r.description(body.Description)
+		r.content(swaggerContent(body.Schema, o.op.Consumes))
+	}
+
+	if len(o.op.Responses) > 0 {
+		r.b.WriteString("<h4>Responses</h4>\n")
+		for _, code := range sortedStatuses(o.op.Responses) {
+			resp := r.spec.response(o.op.Responses[code])
+			if resp == nil {
+				continue
+			}
+			fmt.Fprintf(&r.b, `<div class="openapi-response"><p><span class="openapi-status openapi-status-%s">%s</span> %s</p>`+"\n",
+				statusClass(code), html.EscapeString(code), r.inline(resp.Description))
+			content := resp.Content
+			if content == nil && resp.Schema != nil {
+				content = swaggerContent(resp.Schema, o.op.Produces)
+			}
+			r.content(content)
+			r.b.WriteString("</div>\n")
+		}
+	}
+	r.b.WriteString("</div>\n")
+}
+
+func (r *renderer) parameters(params []*Parameter) {
+	r.b.WriteString("<h4>Parameters</h4>\n")
+	r.b.WriteString(`<table class="openapi-params"><thead><tr><th>Name</th><th>In</th><th>Type</th><th>Description</th></tr></thead><tbody>` + "\n")
+	for _, p := range params {
+		s := p.Schema
+		if s == nil {
+			s = &Schema{Type: p.Type, Format: p.Format, Items: p.Items, Enum: p.Enum, Default: p.Default}
+		}
+		fmt.Fprintf(&r.b, "<tr><td><code>%s</code>%s</td><td>%s</td><td>%s</td><td>%s%s</td></tr>\n",
+			html.EscapeString(p.Name), flags(p.Required, p.Deprecated), html.EscapeString(p.In),
+			r.typeLabel(s), r.inline(p.Description), r.constraints(s))
+	}
+	r.b.WriteString("</tbody></table>\n")
+}
+
+// content writes the schema of a body per content type
+func (r *renderer) content(content map[string]MediaType) {
+	for _, mime := range slices.Sorted(mapKeys(content)) {
+		media := content[mime]
+		fmt.Fprintf(&r.b, `<p class="openapi-media"><code>%s</code></p>`+"\n", html.EscapeString(mime))
+		if media.Schema != nil {
+			r.schemaBody(media.Schema, 0)
+		}
+	}
+}
+
+// schemaBody writes what a schema holds: a table of properties for
+// objects, or its type otherwise
+func (r *renderer) schemaBody(s *Schema, depth int) {
+	label := r.typeLabel(s)
+	props, required := r.properties(s)
+	if len(props) == 0 {
+		if s.Items != nil {
+			if items, _ := r.properties(s.Items); len(items) > 0 && s.Items.Ref == "" && depth < maxSchemaDepth {
+				fmt.Fprintf(&r.b, `<p class="openapi-type">%s</p>`+"\n", label)
+				r.schemaBody(s.Items, depth+1)
+				return
+			}
+		}
+		fmt.Fprintf(&r.b, `<p class="openapi-type">%s</p>`+"\n", label)
+		return
+	}
+	if s.Ref != "" {
+		fmt.Fprintf(&r.b, `<p class="openapi-type">%s</p>`+"\n", label)
+	}
+	r.b.WriteString(`<table class="openapi-schema"><thead><tr><th>Field</th><th>Type</th><th>Description</th></tr></thead><tbody>` + "\n")
+	for _, name := range slices.Sorted(mapKeys(props)) {
+		p := props[name]
+		fmt.Fprintf(&r.b, "<tr><td><code>%s</code>%s</td><td>%s</td><td>%s%s",
+			html.EscapeString(name), flags(slices.Contains(required, name), p.Deprecated), r.typeLabel(p), r.inline(p.Description), r.constraints(p))
+		if nested, _ := r.properties(p); len(nested) > 0 && p.Ref == "" && depth < maxSchemaDepth {
+			r.schemaBody(p, depth+1)
+		} else if p.Items != nil && p.Items.Ref == "" && depth < maxSchemaDepth {
+			if nested, _ := r.properties(p.Items); len(nested) > 0 {
+				r.schemaBody(p.Items, depth+1)
+			}
+		}
+		r.b.WriteString("</td></tr>\n")
+	}
+	r.b.WriteString("</tbody></table>\n")
+}
+
+// properties returns the properties of an object schema, following its
+// $ref and merging allOf
+func (r *renderer) properties(s *Schema) (map[string]*Schema, []string) {
+	props := map[string]*Schema{}
+	var required []string
+	var walk func(s *Schema, depth int)
+	walk = func(s *Schema, depth int) {
+		if s == nil || depth > 8 {
+			return
+		}
+		if s.Ref != "" {
+			walk(r.spec.schema(s.Ref), depth+1)
+			return
+		}
+		for name, p := range s.Properties {
+			props[name] = p
+		}
+		required = append(required, s.Required...)
+		for _, part := range s.AllOf {
+			walk(part, depth+1)
+		}
+	}
+	walk(s, 0)
+	return props, required
+}
+
+// typeLabel describes a schema's type in a few words, linking $refs to the
+// schemas section
+func (r *renderer) typeLabel(s *Schema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Ref != "" {
+		name := html.EscapeString(refName(s.Ref))
+		if r.spec.schema(s.Ref) == nil {
+			return name
+		}
+		return fmt.Sprintf(`<a href="#%s">%s</a>`, slug("schema-"+refName(s.Ref)), name)
+	}
+	var label string
+	switch {
+	case len(s.OneOf) > 0:
+		label = r.union(s.OneOf)
+	case len(s.AnyOf) > 0:
+		label = r.union(s.AnyOf)
+	case len(s.AllOf) == 1:
+		label = r.typeLabel(s.AllOf[0])
+	default:
+		types := schemaTypes(s)
+		for i, t := range types {
+			switch {
+			case t == "array" && s.Items != nil:
+				types[i] = "array of " + r.typeLabel(s.Items)
+			case t == "object" && len(s.Properties) == 0:
+				if extra, ok := s.AdditionalProperties.(map[string]any); ok {
+					types[i] = "map of " + r.typeLabel(schemaFromMap(extra))
+				}
+			case s.Format != "" && t != "null":
+				types[i] = t + " (" + html.EscapeString(s.Format) + ")"
+			}
+		}
+		label = strings.Join(types, " | ")
+	}
+	if s.Nullable {
+		label += " | null"
+	}
+	return label
+}
+
+func (r *renderer) union(parts []*Schema) string {
+	labels := make([]string, len(parts))
+	for i, p := range parts {
+		labels[i] = r.typeLabel(p)
+	}
+	return strings.Join(labels, " | ")
+}
+
+// constraints lists a schema's allowed values, default and access
+func (r *renderer) constraints(s *Schema) string {
+	var parts []string
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = "<code>" + html.EscapeString(fmt.Sprint(v)) + "</code>"
+		}
+		parts = append(parts, "One of "+strings.Join(values, ", ")+".")
+	}
+	if s.Default != nil {
+		parts = append(parts, "Default <code>"+html.EscapeString(fmt.Sprint(s.Default))+"</code>.")
+	}
+	if s.ReadOnly {
+		parts = append(parts, "Read-only.")
+	}
+	if s.WriteOnly {
+		parts = append(parts, "Write-only.")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ` <span class="openapi-constraints">` + strings.Join(parts, " ") + "</span>"
+}
+
+func (r *renderer) schemas() {
+	schemas := r.spec.Components.Schemas
+	if len(schemas) == 0 {
+		schemas = r.spec.Definitions
+	}
+	if len(schemas) == 0 {
+		return
+	}
+	r.heading(2, "schemas", "Schemas", "Schemas")
+	for _, name := range slices.Sorted(mapKeys(schemas)) {
+		s := schemas[name]
+		if s == nil {
+			continue
+		}
+		r.b.WriteString(`<div class="openapi-model">` + "\n")
+		r.heading(3, "schema-"+name, html.EscapeString(name), name)
+		r.description(s.Description)
+		r.schemaBody(s, 0)
+		r.b.WriteString("</div>\n")
+	}
+}
+
+// heading writes a heading with a unique ID and adds it to the TOC
+func (r *renderer) heading(level int, idSource, inner, text string) {
+	id := slug(idSource)
+	if n := r.ids[id]; n > 0 {
+		r.ids[id]++
+		id = fmt.Sprintf("%s-%d", id, n)
+	} else {
+		r.ids[id] = 1
+	}
+	fmt.Fprintf(&r.b, `<h%d id="%s">%s</h%d>`+"\n", level, id, inner, level)
+	r.toc = append(r.toc, models.TOCEntry{ID: id, Text: text, Level: level})
+}
+
+// description writes a block description
+func (r *renderer) description(text string) {
+	if text = strings.TrimSpace(text); text == "" {
+		return
+	}
+	if r.markdown == nil {
+		fmt.Fprintf(&r.b, "<p>%s</p>\n", html.EscapeString(text))
+		return
+	}
+	r.b.WriteString(`<div class="openapi-description">`)
+	r.b.WriteString(r.markdown(text))
+	r.b.WriteString("</div>\n")
+}
+
+// inline renders a description inside a table cell or line, without the
+// paragraph Markdown wraps it in
+func (r *renderer) inline(text string) string {
+	if text = strings.TrimSpace(text); text == "" {
+		return ""
+	}
+	if r.markdown == nil {
+		return html.EscapeString(text)
+	}
+	out := strings.TrimSpace(r.markdown(text))
+	if inner, ok := strings.CutPrefix(out, "<p>"); ok && strings.Count(out, "<p>") == 1 {
+		out = strings.TrimSuffix(inner, "</p>")
+	}
+	return out
+}
+
+// mergeParameters resolves the parameters of an operation, which override
+// the path's by name and location
+func mergeParameters(spec *Spec, shared, own []*Parameter) []*Parameter {
+	var params []*Parameter
+	index := map[string]int{}
+	for _, list := range [][]*Parameter{shared, own} {
+		for _, p := range list {
+			if p = spec.parameter(p); p == nil {
+				continue
+			}
+			key := p.In + ":" + p.Name
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// swaggerContent gives a Swagger 2 body schema a content type
+func swaggerContent(s *Schema, mimes []string) map[string]MediaType {
+	if s == nil {
+		return nil
+	}
+	mime := "application/json"
+	if len(mimes) > 0 {
+		mime = mimes[0]
+	}
+	return map[string]MediaType{mime: {Schema: s}}
+}
+
+// sortedStatuses orders response codes numerically, "default" last
+func sortedStatuses(responses map[string]*Response) []string {
+	codes := slices.Sorted(mapKeys(responses))
+	slices.SortStableFunc(codes, func(a, b string) int {
+		switch {
+		case a == "default" && b != "default":
+			return 1
+		case b == "default" && a != "default":
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	return codes
+}
+
+// statusClass is 2xx, 4xx... for a code, or "default"
+func statusClass(code string) string {
+	if len(code) == 3 && code[0] >= '1' && code[0] <= '5' {
+		return code[:1] + "xx"
+	}
+	return "default"
+}
+
+// flags marks a field required or deprecated
+func flags(required, deprecated bool) string {
+	var s string
+	if required {
+		s += ` <span class="openapi-required">required</span>`
+	}
+	if deprecated {
+		s += ` <span class="openapi-badge">deprecated</span>`
+	}
+	return s
+}
+
+// schemaTypes returns the type names of a schema, inferring object for
+// schemas with properties
+func schemaTypes(s *Schema) []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			types = append(types, fmt.Sprint(v))
+		}
+		return types
+	}
+	if len(s.Properties) > 0 || len(s.AllOf) > 0 {
+		return []string{"object"}
+	}
+	if s.Items != nil {
+		return []string{"array"}
+	}
+	return []string{"any"}
+}
+
+// schemaFromMap reads the few schema fields typeLabel needs from an
+// additionalProperties value, which YAML decodes as a plain map
+func schemaFromMap(m map[string]any) *Schema {
+	s := &Schema{Type: m["type"]}
+	s.Ref, _ = m["$ref"].(string)
+	s.Format, _ = m["format"].(string)
+	return s
+}
+
+// slug makes an HTML ID: lowercase letters and digits separated by dashes
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(c)
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+func mapKeys[V any](m map[string]V) func(func(string) bool) {
+	return func(yield func(string) bool) {
+		for k := range m {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.2.0
+  description: Manage the <pets> of the store.
+servers:
+  - url: https://api.example.com/v1
+    description: Production
+tags:
+  - name: pets
+    description: Everything about pets
+  - name: store
+paths:
+  /pets/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: string}}
+    get:
+      tags: [pets]
+      summary: Get a pet
+      operationId: getPet
+      parameters:
+        - {name: fields, in: query, schema: {type: array, items: {type: string}}}
+      responses:
+        "404": {$ref: "#/components/responses/NotFound"}
+        "200":
+          description: The pet
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+        default: {description: Unexpected error}
+  /pets:
+    post:
+      tags: [pets]
+      summary: Add a pet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              allOf:
+                - $ref: "#/components/schemas/Pet"
+                - type: object
+                  required: [owner]
+                  properties:
+                    owner:
+                      type: object
+                      properties:
+                        email: {type: string, format: email}
+      responses:
+        "201": {description: Created}
+  /health:
+    get:
+      deprecated: true
+      responses:
+        "200": {description: OK}
+components:
+  responses:
+    NotFound: {description: No such pet}
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, description: The pet's name}
+        status: {type: string, enum: [available, sold], default: available}
+        tags: {type: array, items: {$ref: "#/components/schemas/Tag"}}
+        parent: {$ref: "#/components/schemas/Pet"}
+    Tag:
+      type: object
+      properties:
+        label: {type: [string, "null"]}
+`
+
+func TestRender(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := Render(spec, nil)
+	out := ref.HTML
+
+	for _, want := range []string{
+		`<p class="openapi-version">Version <code>1.2.0</code></p>`,
+		`<p>Manage the &lt;pets&gt; of the store.</p>`,
+		`<li><code>https://api.example.com/v1</code> — Production</li>`,
+		`<h2 id="tag-pets">pets</h2>`,
+		`<h3 id="op-getpet">Get a pet</h3>`,
+		`<span class="openapi-method openapi-method-get">GET</span> <code>/pets/{id}</code>`,
+		// Path parameters come with the operation's own
+		`<code>id</code> <span class="openapi-required">required</span></td><td>path</td><td>string</td>`,
+		`<td>array of string</td>`,
+		`No such pet`,
+		`<a href="#schema-pet">Pet</a>`,
+		// allOf merges the referenced schema with the inline one
+		`<code>owner</code> <span class="openapi-required">required</span>`,
+		`<code>email</code></td><td>string (email)</td>`,
+		`One of <code>available</code>, <code>sold</code>. Default <code>available</code>.`,
+		`<h3 id="op-get-health">GET /health</h3>`,
+		`<span class="openapi-badge">deprecated</span>`,
+		`<h2 id="schemas">Schemas</h2>`,
+		`<td>array of <a href="#schema-tag">Tag</a></td>`,
+		`<td>string | null</td>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s", want)
+		}
+	}
+
+	// Operations of declared tags come first, by path, untagged ones last;
+	// responses by code with default last
+	order := []string{`id="tag-pets"`, `id="op-post-pets"`, `id="op-getpet"`, `id="tag-other"`, `id="op-get-health"`, `id="schema-pet"`, `id="schema-tag"`}
+	last := -1
+	for _, id := range order {
+		i := strings.Index(out, id)
+		if i < last {
+			t.Errorf("%s is out of order", id)
+		}
+		last = i
+	}
+	if i, j, k := strings.Index(out, ">200<"), strings.Index(out, ">404<"), strings.Index(out, ">default<"); !(i < j && j < k) {
+		t.Errorf("responses out of order: 200 at %d, 404 at %d, default at %d", i, j, k)
+	}
+	if strings.Contains(out, `id="tag-store"`) {
+		t.Error("a tag without operations got a heading")
+	}
+
+	if len(ref.TOC) == 0 || ref.TOC[0].ID != "tag-pets" || ref.TOC[0].Level != 2 {
+		t.Errorf("TOC = %+v", ref.TOC)
+	}
+}
+
+func TestRenderSwagger2Body(t *testing.T) {
+	spec, err := Parse([]byte(`
+swagger: "2.0"
+paths:
+  /pets:
+    post:
+      operationId: addPet
+      consumes: [application/xml]
+      parameters:
+        - {name: body, in: body, schema: {$ref: "#/definitions/Pet"}}
+        - {name: X-Trace, in: header, type: string}
+      responses:
+        "200": {description: OK, schema: {type: array, items: {$ref: "#/definitions/Pet"}}}
+definitions:
+  Pet:
+    properties:
+      name: {type: string}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := Render(spec, func(s string) string { return "<p>" + s + "</p>\n" }).HTML
+	for _, want := range []string{
+		`<h2 id="op-addpet">POST /pets</h2>`,
+		"<h4>Request body</h4>\n" + `<p class="openapi-media"><code>application/xml</code></p>`,
+		`<code>X-Trace</code></td><td>header</td><td>string</td>`,
+		`array of <a href="#schema-pet">Pet</a>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `<code>body</code>`) {
+		t.Error("the body parameter is listed with the parameters")
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"op-GET /pets/{id}": "op-get-pets-id",
+		"tag-Pet Store":     "tag-pet-store",
+		"--a__b--":          "a-b",
+	}
+	for in, want := range tests {
+		if got := slug(in); got != want {
+			t.Errorf("slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package openapi renders OpenAPI 3 and Swagger 2 specifications as a static
+// API reference, for pages with `openapi: path/to/spec.yaml` frontmatter.
+// Only what a reader needs is modelled: operations, parameters, request
+// bodies, responses and their schemas, with local $refs resolved.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is an OpenAPI 3.x or Swagger 2.0 document. JSON specs parse too,
+// JSON being YAML.
+type Spec struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Swagger    string              `yaml:"swagger"`
+	Info       Info                `yaml:"info"`
+	Servers    []Server            `yaml:"servers"`
+	Host       string              `yaml:"host"`     // Swagger 2
+	BasePath   string              `yaml:"basePath"` // Swagger 2
+	Schemes    []string            `yaml:"schemes"`  // Swagger 2
+	Tags       []Tag               `yaml:"tags"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components Components          `yaml:"components"`
+
+	// Swagger 2 keeps reusable objects at the top level
+	Definitions map[string]*Schema    `yaml:"definitions"`
+	Parameters  map[string]*Parameter `yaml:"parameters"`
+	Responses   map[string]*Response  `yaml:"responses"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `yaml:"title"`
+	Version     string `yaml:"version"`
+	Description string `yaml:"description"`
+}
+
+// Server is a base URL of the API
+type Server struct {
+	URL         string `yaml:"url"`
+	Description string `yaml:"description"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// Components holds the reusable objects of an OpenAPI 3 spec
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Responses     map[string]*Response    `yaml:"responses"`
+}
+
+// PathItem lists the operations on a path
+type PathItem struct {
+	Summary     string       `yaml:"summary"`
+	Description string       `yaml:"description"`
+	Parameters  []*Parameter `yaml:"parameters"` // Shared by every operation
+	Get         *Operation   `yaml:"get"`
+	Post        *Operation   `yaml:"post"`
+	Put         *Operation   `yaml:"put"`
+	Patch       *Operation   `yaml:"patch"`
+	Delete      *Operation   `yaml:"delete"`
+	Head        *Operation   `yaml:"head"`
+	Options     *Operation   `yaml:"options"`
+	Trace       *Operation   `yaml:"trace"`
+}
+
+// operations returns the operations of a path in reading order
+func (p PathItem) operations() []struct {
+	Method string
+	Op     *Operation
+} {
+	all := []struct {
+		Method string
+		Op     *Operation
+	}{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options}, {"TRACE", p.Trace},
+	}
+	ops := all[:0]
+	for _, o := range all {
+		if o.Op != nil {
+			ops = append(ops, o)
+		}
+	}
+	return ops
+}
+
+// Operation is one method on a path
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Description string               `yaml:"description"`
+	Tags        []string             `yaml:"tags"`
+	Deprecated  bool                 `yaml:"deprecated"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+	Consumes    []string             `yaml:"consumes"` // Swagger 2
+	Produces    []string             `yaml:"produces"` // Swagger 2
+}
+
+// Parameter is a path, query, header or cookie parameter (or, in Swagger
+// 2, the request body or a form field)
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Deprecated  bool    `yaml:"deprecated"`
+	Schema      *Schema `yaml:"schema"`
+	Type        string  `yaml:"type"`   // Swagger 2, outside a schema
+	Format      string  `yaml:"format"` // Swagger 2
+	Items       *Schema `yaml:"items"`  // Swagger 2
+	Enum        []any   `yaml:"enum"`   // Swagger 2
+	Default     any     `yaml:"default"`
+}
+
+// RequestBody is the body of an OpenAPI 3 operation
+type RequestBody struct {
+	Ref         string               `yaml:"$ref"`
+	Description string               `yaml:"description"`
+	Required    bool                 `yaml:"required"`
+	Content     map[string]MediaType `yaml:"content"`
+}
+
+// Response is the response to a status code
+type Response struct {
+	Ref         string               `yaml:"$ref"`
+	Description string               `yaml:"description"`
+	Content     map[string]MediaType `yaml:"content"`
+	Schema      *Schema              `yaml:"schema"` // Swagger 2
+}
+
+// MediaType is the schema of a body in one content type
+type MediaType struct {
+	Schema  *Schema `yaml:"schema"`
+	Example any     `yaml:"example"`
+}
+
+// Schema is a JSON Schema as OpenAPI uses it
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Title                string             `yaml:"title"`
+	Description          string             `yaml:"description"`
+	Type                 any                `yaml:"type"` // A string, or a list in OpenAPI 3.1
+	Format               string             `yaml:"format"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	Items                *Schema            `yaml:"items"`
+	AdditionalProperties any                `yaml:"additionalProperties"`
+	AllOf                []*Schema          `yaml:"allOf"`
+	OneOf                []*Schema          `yaml:"oneOf"`
+	AnyOf                []*Schema          `yaml:"anyOf"`
+	Enum                 []any              `yaml:"enum"`
+	Default              any                `yaml:"default"`
+	Example              any                `yaml:"example"`
+	Nullable             bool               `yaml:"nullable"`
+	Deprecated           bool               `yaml:"deprecated"`
+	ReadOnly             bool               `yaml:"readOnly"`
+	WriteOnly            bool               `yaml:"writeOnly"`
+}
+
+// ErrNotSpec is returned for YAML or JSON that isn't an API description
+var ErrNotSpec = errors.New("not an OpenAPI 3 or Swagger 2 document (no openapi or swagger version)")
+
+// Parse reads an OpenAPI 3 or Swagger 2 document
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	if spec.OpenAPI == "" && spec.Swagger == "" {
+		return nil, ErrNotSpec
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") && !strings.HasPrefix(spec.Swagger, "2.") {
+		return nil, fmt.Errorf("unsupported version (openapi %q, swagger %q): only OpenAPI 3 and Swagger 2 are rendered", spec.OpenAPI, spec.Swagger)
+	}
+	return &spec, nil
+}
+
+// refName returns the last segment of a local $ref
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// schema resolves a schema $ref. External refs and unknown names return
+// nil.
+func (s *Spec) schema(ref string) *Schema {
+	switch {
+	case strings.HasPrefix(ref, "#/components/schemas/"):
+		return s.Components.Schemas[refName(ref)]
+	case strings.HasPrefix(ref, "#/definitions/"):
+		return s.Definitions[refName(ref)]
+	}
+	return nil
+}
+
+// parameter follows a parameter's $ref
+func (s *Spec) parameter(p *Parameter) *Parameter {
+	for i := 0; p != nil && p.Ref != "" && i < 8; i++ {
+		switch {
+		case strings.HasPrefix(p.Ref, "#/components/parameters/"):
+			p = s.Components.Parameters[refName(p.Ref)]
+		case strings.HasPrefix(p.Ref, "#/parameters/"):
+			p = s.Parameters[refName(p.Ref)]
+		default:
+			return nil
+		}
+	}
+	return p
+}
+
+// requestBody follows a request body's $ref
+func (s *Spec) requestBody(b *RequestBody) *RequestBody {
+	for i := 0; b != nil && b.Ref != "" && i < 8; i++ {
+		if !strings.HasPrefix(b.Ref, "#/components/requestBodies/") {
+			return nil
+		}
+		b = s.Components.RequestBodies[refName(b.Ref)]
+	}
+	return b
+}
+
+// response follows a response's $ref
+func (s *Spec) response(r *Response) *Response {
+	for i := 0; r != nil && r.Ref != "" && i < 8; i++ {
+		switch {
+		case strings.HasPrefix(r.Ref, "#/components/responses/"):
+			r = s.Components.Responses[refName(r.Ref)]
+		case strings.HasPrefix(r.Ref, "#/responses/"):
+			r = s.Responses[refName(r.Ref)]
+		default:
+			return nil
+		}
+	}
+	return r
+}
+
+// serverURLs lists the base URLs of the API
+func (s *Spec) serverURLs() []Server {
+	if len(s.Servers) > 0 || s.Host == "" {
+		return s.Servers
+	}
+	schemes := s.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	servers := make([]Server, len(schemes))
+	for i, scheme := range schemes {
+		servers[i] = Server{URL: scheme + "://" + s.Host + s.BasePath}
+	}
+	return servers
+}
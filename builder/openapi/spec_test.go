@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name, data string
+		wantErr    bool
+		notSpec    bool
+	}{
+		{name: "openapi 3", data: "openapi: 3.1.0\ninfo: {title: Pets, version: '1'}\npaths: {}\n"},
+		{name: "swagger 2 as JSON", data: `{"swagger": "2.0", "info": {"title": "Pets"}, "paths": {}}`},
+		{name: "plain YAML", data: "title: Not an API\n", wantErr: true, notSpec: true},
+		{name: "unsupported version", data: "swagger: '1.2'\n", wantErr: true},
+		{name: "invalid YAML", data: "openapi: [3\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotSpec) != tt.notSpec {
+				t.Errorf("errors.Is(err, ErrNotSpec) = %v for %v", !tt.notSpec, err)
+			}
+		})
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	spec, err := Parse([]byte(`
+swagger: "2.0"
+host: api.example.com
+basePath: /v1
+schemes: [http, https]
+parameters:
+  limit: {name: limit, in: query, type: integer}
+  alias: {$ref: "#/parameters/limit"}
+responses:
+  NotFound: {description: Not found}
+definitions:
+  Pet: {type: object}
+paths: {}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := spec.parameter(&Parameter{Ref: "#/parameters/alias"}); p == nil || p.Name != "limit" {
+		t.Errorf("parameter ref through an alias = %+v", p)
+	}
+	if p := spec.parameter(&Parameter{Ref: "other.yaml#/limit"}); p != nil {
+		t.Errorf("external parameter ref = %+v, want nil", p)
+	}
+	if r := spec.response(&Response{Ref: "#/responses/NotFound"}); r == nil || r.Description != "Not found" {
+		t.Errorf("response ref = %+v", r)
+	}
+	if s := spec.schema("#/definitions/Pet"); s == nil {
+		t.Error("definitions ref didn't resolve")
+	}
+
+	servers := spec.serverURLs()
+	if len(servers) != 2 || servers[0].URL != "http://api.example.com/v1" || servers[1].URL != "https://api.example.com/v1" {
+		t.Errorf("serverURLs() = %+v", servers)
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// KindHeadingAnchor is the node kind of a heading's permalink anchor
+var KindHeadingAnchor = ast.NewNodeKind("HeadingAnchor")
+
+// HeadingAnchor links to the heading it is in
+type HeadingAnchor struct {
+	ast.BaseInline
+	ID    string
+	Label string // aria-label
+}
+
+func (n *HeadingAnchor) Kind() ast.NodeKind { return KindHeadingAnchor }
+
+func (n *HeadingAnchor) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"ID": n.ID}, nil)
+}
+
+// resolveHeadingAnchors fills in the defaults of the headingAnchors config
+func resolveHeadingAnchors(cfg config.HeadingAnchorsConfig) config.HeadingAnchorsConfig {
+	if cfg.Position != "before" {
+		cfg.Position = "after"
+	}
+	if cfg.Symbol == "" {
+		cfg.Symbol = "#"
+	}
+	if cfg.Class == "" {
+		cfg.Class = "heading-anchor"
+	}
+	if cfg.AriaLabel == "" {
+		cfg.AriaLabel = "Link to this section: {heading}"
+	}
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = []int{2, 3, 4, 5, 6}
+	}
+	return cfg
+}
+
+// headingAnchorTransformer adds a HeadingAnchor to every heading with an id.
+// It runs after the TOC has read the heading text and uses the same ids.
+type headingAnchorTransformer struct {
+	cfg config.HeadingAnchorsConfig
+}
+
+func (t *headingAnchorTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, ok := heading.AttributeString("id")
+		if !ok || !slices.Contains(t.cfg.Levels, heading.Level) {
+			return ast.WalkSkipChildren, nil
+		}
+		anchor := &HeadingAnchor{ID: string(id.([]byte))}
+		anchor.Label = strings.ReplaceAll(t.cfg.AriaLabel, "{heading}", strings.TrimSpace(string(headingText(heading, reader.Source()))))
+		if t.cfg.Position == "before" && heading.FirstChild() != nil {
+			heading.InsertBefore(heading, heading.FirstChild(), anchor)
+		} else {
+			heading.AppendChild(heading, anchor)
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// headingText is the text of a heading without its markup
+func headingText(heading ast.Node, source []byte) []byte {
+	var buf []byte
+	_ = ast.Walk(heading, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			buf = append(buf, n.Segment.Value(source)...)
+		case *ast.String:
+			buf = append(buf, n.Value...)
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf
+}
+
+type headingAnchorRenderer struct {
+	cfg config.HeadingAnchorsConfig
+}
+
+func (r *headingAnchorRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindHeadingAnchor, r.render)
+}
+
+func (r *headingAnchorRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*HeadingAnchor)
+	// The symbol is site configuration, written as is so it can be an icon
+	anchor := `<a class="` + html.EscapeString(r.cfg.Class) + `" href="#` + html.EscapeString(n.ID) +
+		`" aria-label="` + html.EscapeString(n.Label) + `">` + r.cfg.Symbol + `</a>`
+	if r.cfg.Position == "before" {
+		_, _ = w.WriteString(anchor + " ")
+	} else {
+		_, _ = w.WriteString(" " + anchor)
+	}
+	return ast.WalkContinue, nil
+}
+
+// headingAnchorExtension enables markdown.headingAnchors
+type headingAnchorExtension struct {
+	cfg config.HeadingAnchorsConfig
+}
+
+func (e *headingAnchorExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(&headingAnchorTransformer{cfg: e.cfg}, 250)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&headingAnchorRenderer{cfg: e.cfg}, 500)))
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yuin/goldmark/parser"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestHeadingAnchors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.HeadingAnchorsConfig
+		input string
+		want  string
+	}{
+		{
+			name:  "defaults",
+			cfg:   config.HeadingAnchorsConfig{Enabled: true},
+			input: "# Title\n\n## Install *now*\n\n## Install *now*",
+			want: `<h1 id="title">Title</h1>
+<h2 id="install-now">Install <em>now</em> <a class="heading-anchor" href="#install-now" aria-label="Link to this section: Install now">#</a></h2>
+<h2 id="install-now-1">Install <em>now</em> <a class="heading-anchor" href="#install-now-1" aria-label="Link to this section: Install now">#</a></h2>`,
+		},
+		{
+			name: "configured markup",
+			cfg: config.HeadingAnchorsConfig{
+				Enabled: true, Position: "before", Symbol: `<svg aria-hidden="true"></svg>`,
+				Class: "anchor", AriaLabel: `Permalink to "{heading}"`, Levels: []int{1, 2},
+			},
+			input: "# Title\n\n### Deep",
+			want: `<h1 id="title"><a class="anchor" href="#title" aria-label="Permalink to &#34;Title&#34;"><svg aria-hidden="true"></svg></a> Title</h1>
+<h3 id="deep">Deep</h3>`,
+		},
+		{
+			name:  "disabled",
+			input: "## Plain",
+			want:  `<h2 id="plain">Plain</h2>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &config.Config{Markdown: config.MarkdownConfig{HeadingAnchors: tt.cfg}}
+			var buf bytes.Buffer
+			if err := New(site, nil, &sync.Map{}, Media{}).Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchorsKeepTOC(t *testing.T) {
+	site := &config.Config{Markdown: config.MarkdownConfig{HeadingAnchors: config.HeadingAnchorsConfig{Enabled: true}}}
+	pc := parser.NewContext()
+	var buf bytes.Buffer
+	if err := New(site, nil, &sync.Map{}, Media{}).Convert([]byte("## Setup\n\n### Linux"), &buf, parser.WithContext(pc)); err != nil {
+		t.Fatal(err)
+	}
+	toc := GetTOC(pc)
+	if len(toc) != 2 || toc[0].ID != "setup" || toc[0].Text != "Setup" || toc[1].Text != "Linux" {
+		t.Errorf("TOC = %+v, want the headings without anchor text", toc)
+	}
+}
@@ -0,0 +1,272 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+var audioShortcode = regexp.MustCompile(`^\{\{<\s*audio\b(.*?)>\}\}\s*$`)
+
+var audioTypes = map[string]string{
+	".mp3": "audio/mpeg", ".m4a": "audio/mp4", ".aac": "audio/aac", ".ogg": "audio/ogg", ".oga": "audio/ogg",
+	".opus": "audio/ogg", ".wav": "audio/wav", ".flac": "audio/flac", ".webm": "audio/webm",
+}
+
+// PageAudio reads a post's `audio:` frontmatter, either a path or
+//
+//	audio:
+//	  src: static/episodes/01.mp3
+//	  duration: "42:10"
+//	  chapters:
+//	    - {start: "0:00", title: Intro}
+//	    - {start: "5:30", title: Interview, url: "https://example.com"}
+//
+// It returns nil without audio. Chapters are sorted by start time; Length and
+// ChaptersURL are left for the caller, which knows the file system and the
+// page's URL.
+func PageAudio(metaData map[string]interface{}, baseURL string) (*models.Audio, error) {
+	v, ok := metaData["audio"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+	fields := stringMap(v)
+	if fields == nil {
+		fields = map[string]interface{}{"src": v}
+	}
+
+	a, err := newAudio(fmt.Sprint(fields["src"]), baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := fields["title"]; ok {
+		a.Title = fmt.Sprint(t)
+	}
+	if d, ok := fields["duration"]; ok {
+		if a.Duration, err = parseTimestamp(fmt.Sprint(d)); err != nil {
+			return nil, fmt.Errorf("audio duration: %w", err)
+		}
+	}
+	list, _ := fields["chapters"].([]interface{})
+	for i, item := range list {
+		c := stringMap(item)
+		if c == nil || c["title"] == nil {
+			return nil, fmt.Errorf("audio chapter %d needs a start and a title", i+1)
+		}
+		start, err := parseTimestamp(fmt.Sprint(c["start"]))
+		if err != nil {
+			return nil, fmt.Errorf("audio chapter %d: %w", i+1, err)
+		}
+		ch := models.Chapter{Start: start, Title: fmt.Sprint(c["title"])}
+		if u, ok := c["url"]; ok {
+			ch.URL = fmt.Sprint(u)
+		}
+		a.Chapters = append(a.Chapters, ch)
+	}
+	sort.SliceStable(a.Chapters, func(i, j int) bool { return a.Chapters[i].Start < a.Chapters[j].Start })
+	return a, nil
+}
+
+// newAudio resolves an audio src: a path under static/ or an absolute URL
+func newAudio(src, baseURL string) (*models.Audio, error) {
+	if src == "" || src == "<nil>" {
+		return nil, errors.New("audio needs a src")
+	}
+	typ := audioTypes[strings.ToLower(path.Ext(src))]
+	if typ == "" {
+		return nil, fmt.Errorf("unsupported audio format %q", path.Ext(src))
+	}
+	a := &models.Audio{Src: src, URL: src, Type: typ}
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		a.Src = path.Clean(strings.TrimPrefix(src, "/"))
+		if !strings.HasPrefix(a.Src, "static/") {
+			return nil, errors.New(`audio src must be inside the site's static/ directory, e.g. "static/episodes/01.mp3"`)
+		}
+		a.URL = strings.TrimSuffix(baseURL, "/") + "/" + a.Src
+	}
+	return a, nil
+}
+
+// stringMap returns a YAML mapping as a map, whether it came from the YAML
+// parser or from the msgpack build cache
+func stringMap(v interface{}) map[string]interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			out[fmt.Sprint(k)] = val
+		}
+		return out
+	}
+	return nil
+}
+
+// parseTimestamp reads seconds ("330") or a clock time ("5:30", "1:05:30")
+func parseTimestamp(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	total := 0.0
+	for _, p := range parts {
+		n, err := strconv.ParseFloat(p, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + n
+	}
+	return int(total), nil
+}
+
+// FormatTimestamp writes seconds as "5:30" or "1:05:30"
+func FormatTimestamp(sec int) string {
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+	}
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
+
+// KindAudio is the node kind of an audio shortcode
+var KindAudio = ast.NewNodeKind("Audio")
+
+// Audio is an `{{< audio >}}` shortcode: the page's frontmatter episode, or
+// the file of its src=
+type Audio struct {
+	ast.BaseBlock
+	Audio *models.Audio
+	Err   error
+}
+
+func (n *Audio) Kind() ast.NodeKind { return KindAudio }
+
+func (n *Audio) Dump(source []byte, level int) {
+	src := ""
+	if n.Audio != nil {
+		src = n.Audio.Src
+	}
+	ast.DumpHelper(n, source, level, map[string]string{"Src": src}, nil)
+}
+
+type audioParser struct {
+	baseURL string
+}
+
+func (p *audioParser) Trigger() []byte { return []byte{'{'} }
+
+func (p *audioParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	m := audioShortcode.FindSubmatch(util.TrimRightSpace(line))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	attrs := parseShortcodeAttrs(string(m[1]))
+	node := &Audio{}
+	if src := attrs["src"]; src != "" {
+		node.Audio, node.Err = newAudio(src, p.baseURL)
+	} else {
+		// The frontmatter block is closed, and in the context, by now
+		node.Audio, node.Err = PageAudio(meta.Get(pc), p.baseURL)
+		if node.Audio == nil && node.Err == nil {
+			node.Err = errors.New("no src= and no audio: in the frontmatter")
+		}
+	}
+	if node.Audio != nil && attrs["title"] != "" {
+		node.Audio.Title = attrs["title"]
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *audioParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return parser.Close
+}
+
+func (p *audioParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+func (p *audioParser) CanInterruptParagraph() bool                                { return true }
+func (p *audioParser) CanAcceptIndentedLine() bool                                { return false }
+
+type audioRenderer struct{}
+
+func (r *audioRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindAudio, r.render)
+}
+
+func (r *audioRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Audio)
+	if n.Err != nil {
+		log.Printf("   ⚠️  Audio: %v", n.Err)
+		_, _ = fmt.Fprintf(w, "<!-- audio: %s -->\n", html.EscapeString(n.Err.Error()))
+		return ast.WalkContinue, nil
+	}
+	a := n.Audio
+	url := html.EscapeString(a.URL)
+	label := ""
+	if a.Title != "" {
+		label = fmt.Sprintf(` aria-label="%s"`, html.EscapeString(a.Title))
+	}
+	_, _ = w.WriteString(`<figure class="audio-player">`)
+	_, _ = fmt.Fprintf(w, `<audio controls preload="metadata"%s style="display:block;width:100%%"><source src="%s" type="%s"><a href="%s">Download the audio</a></audio>`,
+		label, url, html.EscapeString(a.Type), url)
+	if len(a.Chapters) > 0 {
+		_, _ = w.WriteString("\n" + `<nav class="audio-chapters" aria-label="Chapters"><ol>` + "\n")
+		for _, c := range a.Chapters {
+			at := FormatTimestamp(c.Start)
+			title := html.EscapeString(c.Title)
+			_, _ = fmt.Fprintf(w, `<li><button type="button" data-start="%d" aria-label="Play from %s: %s"><time datetime="PT%dS">%s</time> %s</button></li>`+"\n",
+				c.Start, at, title, c.Start, at, title)
+		}
+		_, _ = w.WriteString("</ol></nav>\n" + audioChaptersScript)
+	}
+	_, _ = w.WriteString("</figure>\n")
+	return ast.WalkContinue, nil
+}
+
+// audioChaptersScript seeks on chapter clicks and marks the playing chapter
+// with aria-current
+const audioChaptersScript = `<script>
+(function () {
+  var fig = document.currentScript.parentElement;
+  var audio = fig.querySelector('audio');
+  var buttons = [].slice.call(fig.querySelectorAll('.audio-chapters button'));
+  buttons.forEach(function (b) {
+    b.addEventListener('click', function () { audio.currentTime = +b.dataset.start; audio.play(); });
+  });
+  audio.addEventListener('timeupdate', function () {
+    var current = null;
+    buttons.forEach(function (b) { if (+b.dataset.start <= audio.currentTime) current = b; });
+    buttons.forEach(function (b) { if (b === current) b.setAttribute('aria-current', 'true'); else b.removeAttribute('aria-current'); });
+  });
+})();
+</script>
+`
+
+// audioExtension adds the audio shortcode
+type audioExtension struct {
+	baseURL string
+}
+
+func (e *audioExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(util.Prioritized(&audioParser{baseURL: e.baseURL}, 150)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&audioRenderer{}, 500)))
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestPageAudio(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    map[string]interface{}
+		want    *models.Audio
+		wantErr bool
+	}{
+		{name: "none", meta: map[string]interface{}{"title": "x"}},
+		{
+			name: "path shorthand",
+			meta: map[string]interface{}{"audio": "static/ep.mp3"},
+			want: &models.Audio{Src: "static/ep.mp3", URL: "https://example.com/static/ep.mp3", Type: "audio/mpeg"},
+		},
+		{
+			name: "chapters from YAML, sorted",
+			meta: map[string]interface{}{"audio": map[interface{}]interface{}{
+				"src": "/static/ep.m4a", "duration": "1:02:03", "title": "Episode",
+				"chapters": []interface{}{
+					map[interface{}]interface{}{"start": "5:30", "title": "Interview", "url": "https://example.org"},
+					map[interface{}]interface{}{"start": 0, "title": "Intro"},
+				},
+			}},
+			want: &models.Audio{
+				Src: "static/ep.m4a", URL: "https://example.com/static/ep.m4a", Type: "audio/mp4", Duration: 3723, Title: "Episode",
+				Chapters: []models.Chapter{{Start: 0, Title: "Intro"}, {Start: 330, Title: "Interview", URL: "https://example.org"}},
+			},
+		},
+		{
+			name: "from the msgpack cache",
+			meta: map[string]interface{}{"audio": map[string]interface{}{"src": "https://cdn.example.com/ep.ogg", "duration": int64(90)}},
+			want: &models.Audio{Src: "https://cdn.example.com/ep.ogg", URL: "https://cdn.example.com/ep.ogg", Type: "audio/ogg", Duration: 90},
+		},
+		{name: "outside static", meta: map[string]interface{}{"audio": "../secret.mp3"}, wantErr: true},
+		{name: "unknown format", meta: map[string]interface{}{"audio": "static/ep.xyz"}, wantErr: true},
+		{
+			name:    "bad chapter time",
+			meta:    map[string]interface{}{"audio": map[string]interface{}{"src": "static/a.mp3", "chapters": []interface{}{map[string]interface{}{"start": "soon", "title": "x"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PageAudio(tt.meta, "https://example.com/")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("got %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Src != tt.want.Src || got.URL != tt.want.URL || got.Type != tt.want.Type ||
+				got.Duration != tt.want.Duration || got.Title != tt.want.Title || len(got.Chapters) != len(tt.want.Chapters) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got.Chapters {
+				if got.Chapters[i] != tt.want.Chapters[i] {
+					t.Errorf("chapter %d = %+v, want %+v", i, got.Chapters[i], tt.want.Chapters[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAudioShortcode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "frontmatter episode with chapters",
+			input: "---\naudio:\n  src: static/ep1.mp3\n  chapters:\n    - {start: \"1:05:00\", title: \"Q&A\"}\n    - {start: 0, title: Intro}\n---\n\n{{< audio title=\"Episode 1\" >}}\n",
+			want: []string{
+				`<audio controls preload="metadata" aria-label="Episode 1"`,
+				`<source src="https://example.com/static/ep1.mp3" type="audio/mpeg">`,
+				`<nav class="audio-chapters" aria-label="Chapters">`,
+				`<button type="button" data-start="0" aria-label="Play from 0:00: Intro"><time datetime="PT0S">0:00</time> Intro</button>`,
+				`data-start="3900" aria-label="Play from 1:05:00: Q&amp;A"`,
+				"<script>",
+			},
+		},
+		{
+			name:    "standalone file",
+			input:   "{{< audio src=\"static/clip.wav\" >}}",
+			want:    []string{`type="audio/wav"`},
+			notWant: []string{"audio-chapters", "<script>", "aria-label"},
+		},
+		{
+			name:    "no audio",
+			input:   "{{< audio >}}",
+			want:    []string{"<!-- audio: no src= and no audio: in the frontmatter -->"},
+			notWant: []string{"<audio"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := goldmark.New(goldmark.WithExtensions(meta.Meta, &audioExtension{baseURL: "https://example.com"}))
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output missing %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
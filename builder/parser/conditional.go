@@ -0,0 +1,304 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// conditionOpen matches the first line of a conditional block:
+// `:::version >=v3 <v4` or `:::audience internal !partners`
+var conditionOpen = regexp.MustCompile(`^[ \t]*:::[ \t]*(version|audience)\b(.*)$`)
+
+// directiveOpen and directiveClose match any ::: block, so the blocks of
+// other Markdown extensions pair up with their own closing line
+var (
+	directiveOpen  = regexp.MustCompile(`^[ \t]*:::+[ \t]*\S`)
+	directiveClose = regexp.MustCompile(`^[ \t]*:::+[ \t]*$`)
+)
+
+// Conditions is what conditional blocks of a page are evaluated against
+type Conditions struct {
+	Version  string // The page's documentation version; "" when the site has none
+	Latest   bool   // The page belongs to the latest version (or the site isn't versioned)
+	Audience string // The build's audience, config.PublicAudience by default
+}
+
+// PageConditions returns the conditions of a page of the given version (the
+// content directory it is in, "" for unversioned pages). Unversioned pages
+// take the name of the latest version.
+func PageConditions(cfg *config.Config, version string) Conditions {
+	c := Conditions{Version: version, Audience: cfg.Audience}
+	if c.Audience == "" {
+		c.Audience = config.PublicAudience
+	}
+	latest := -1
+	for i, v := range cfg.Versions {
+		if v.IsLatest {
+			latest = i
+			break
+		}
+	}
+	switch {
+	case version == "":
+		c.Latest = true
+		if latest >= 0 {
+			c.Version = cfg.Versions[latest].Name
+		}
+	case latest >= 0:
+		v := cfg.Versions[latest]
+		c.Latest = version == v.Path || version == v.Name
+	}
+	return c
+}
+
+// HasConditionals reports whether a page has version or audience blocks
+func HasConditionals(source []byte) bool {
+	if !bytes.Contains(source, []byte(":::")) {
+		return false
+	}
+	for _, line := range bytes.Split(source, []byte("\n")) {
+		if conditionOpen.Match(bytes.TrimRight(line, "\r")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyConditions keeps the conditional blocks of a page that hold for c,
+// without their ::: lines, and drops the others:
+//
+//	:::version >=v3
+//	Only in v3 and later.
+//	:::
+//
+// Version blocks take constraints (=, !=, >, >=, <, <=; = by default) that
+// must all hold, on versions compared number by number ("v2.10" > "v2.9"),
+// or `latest`. A page without a version counts as newer than any. Audience
+// blocks list the audiences they are for, and `!name` the ones they aren't.
+// Blocks nest; lines in fenced code are left alone. It returns an error per
+// block that is unclosed or has an invalid condition; those stay as written.
+func ApplyConditions(source []byte, c Conditions) ([]byte, []error) {
+	if !HasConditionals(source) {
+		return source, nil
+	}
+	lines := bytes.SplitAfter(source, []byte("\n"))
+	drop := make([]bool, len(lines))
+	var errs []error
+
+	type block struct {
+		line int
+		kind string // "version", "audience", or "" for other ::: blocks
+		args string
+	}
+	var stack []block
+	var fence []byte
+	for i, raw := range lines {
+		line := bytes.TrimRight(raw, "\r\n")
+		if marker := fenceMarker(line); marker != nil {
+			switch {
+			case fence == nil:
+				fence = marker
+			case marker[0] == fence[0] && len(marker) >= len(fence) && len(bytes.TrimSpace(line)) == len(marker):
+				fence = nil
+			}
+			continue
+		}
+		if fence != nil {
+			continue
+		}
+
+		if directiveClose.Match(line) {
+			if len(stack) == 0 {
+				continue // Not ours
+			}
+			b := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if b.kind == "" {
+				continue
+			}
+			keep, err := evalCondition(b.kind, b.args, c)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: %w", b.line+1, err))
+				continue
+			}
+			drop[b.line], drop[i] = true, true
+			if !keep {
+				for j := b.line + 1; j < i; j++ {
+					drop[j] = true
+				}
+			}
+			continue
+		}
+		if m := conditionOpen.FindSubmatch(line); m != nil {
+			stack = append(stack, block{line: i, kind: string(m[1]), args: strings.TrimSpace(string(m[2]))})
+		} else if directiveOpen.Match(line) {
+			stack = append(stack, block{line: i})
+		}
+	}
+	for _, b := range stack {
+		if b.kind != "" {
+			errs = append(errs, fmt.Errorf("line %d: :::%s block is not closed", b.line+1, b.kind))
+		}
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(source))
+	for i, line := range lines {
+		if !drop[i] {
+			out.Write(line)
+		}
+	}
+	return out.Bytes(), errs
+}
+
+// fenceMarker returns the ``` or ~~~ run opening line, or nil when it isn't
+// a code fence
+func fenceMarker(line []byte) []byte {
+	line = bytes.TrimLeft(line, " \t")
+	if len(line) < 3 || (line[0] != '`' && line[0] != '~') {
+		return nil
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return nil
+	}
+	return line[:n]
+}
+
+// evalCondition reports whether a block's condition holds for c
+func evalCondition(kind, args string, c Conditions) (bool, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return false, fmt.Errorf(":::%s needs a condition", kind)
+	}
+	if kind == "audience" {
+		return evalAudience(fields, c.Audience), nil
+	}
+	for _, f := range fields {
+		ok, err := evalVersion(f, c)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evalAudience reports whether audience is one of those listed (or none are
+// listed, only excluded) and not one excluded with !name
+func evalAudience(names []string, audience string) bool {
+	listed, included := false, false
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if excluded, ok := strings.CutPrefix(name, "!"); ok {
+			if excluded == audience {
+				return false
+			}
+			continue
+		}
+		listed = true
+		if name == audience {
+			included = true
+		}
+	}
+	return !listed || included
+}
+
+// evalVersion checks one version constraint, like ">=v3" or "latest"
+func evalVersion(constraint string, c Conditions) (bool, error) {
+	op, want := "=", constraint
+	for _, o := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(constraint, o); ok {
+			op, want = o, rest
+			break
+		}
+	}
+	if want == "" {
+		return false, fmt.Errorf("invalid version constraint %q", constraint)
+	}
+	if strings.EqualFold(want, "latest") {
+		switch op {
+		case "=":
+			return c.Latest, nil
+		case "!=":
+			return !c.Latest, nil
+		}
+		return false, fmt.Errorf("invalid version constraint %q (latest takes = or !=)", constraint)
+	}
+
+	cmp := compareVersions(c.Version, want)
+	switch op {
+	case ">=":
+		return cmp >= 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "!=":
+		return cmp != 0, nil
+	}
+	return cmp == 0, nil
+}
+
+// compareVersions orders two version names number by number, ignoring a
+// leading v ("v2" = "2.0", "v2.10" > "v2.9"); names that aren't numbers are
+// compared as text. An empty version is newer than any.
+func compareVersions(a, b string) int {
+	if a == "" || b == "" {
+		switch {
+		case a == b:
+			return 0
+		case a == "":
+			return 1
+		}
+		return -1
+	}
+	pa, okA := versionNumbers(a)
+	pb, okB := versionNumbers(b)
+	if !okA || !okB {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionNumbers splits "v2.10.1" into 2, 10, 1
+func versionNumbers(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestPageConditions(t *testing.T) {
+	cfg := &config.Config{Versions: []config.Version{
+		{Name: "v3.0", Path: "", IsLatest: true},
+		{Name: "v2.0", Path: "v2.0"},
+	}}
+	tests := []struct {
+		version string
+		want    Conditions
+	}{
+		{"", Conditions{Version: "v3.0", Latest: true, Audience: config.PublicAudience}},
+		{"v2.0", Conditions{Version: "v2.0", Audience: config.PublicAudience}},
+	}
+	for _, tt := range tests {
+		if got := PageConditions(cfg, tt.version); got != tt.want {
+			t.Errorf("PageConditions(%q) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+
+	unversioned := PageConditions(&config.Config{Audience: "internal"}, "")
+	if want := (Conditions{Latest: true, Audience: "internal"}); unversioned != want {
+		t.Errorf("unversioned site: got %+v, want %+v", unversioned, want)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v3", "v3.0", 0},
+		{"v2.10", "v2.9", 1},
+		{"v1.0", "2", -1},
+		{"", "v9", 1},
+		{"beta", "alpha", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestApplyConditions(t *testing.T) {
+	v2 := Conditions{Version: "v2.0", Audience: "public"}
+	v3 := Conditions{Version: "v3.1", Latest: true, Audience: "internal"}
+
+	tests := []struct {
+		name, source string
+		c            Conditions
+		want         string
+		errs         int
+	}{
+		{
+			name:   "version kept",
+			source: "Intro\n:::version >=v3\nNew API\n:::\nEnd\n",
+			c:      v3,
+			want:   "Intro\nNew API\nEnd\n",
+		},
+		{
+			name:   "version dropped",
+			source: "Intro\n:::version >=v3\nNew API\n:::\nEnd\n",
+			c:      v2,
+			want:   "Intro\nEnd\n",
+		},
+		{
+			name:   "range and latest",
+			source: ":::version >=v2 <v3\nTwo\n:::\n:::version latest\nLatest\n:::\n:::version !=latest\nOld\n:::\n",
+			c:      v2,
+			want:   "Two\nOld\n",
+		},
+		{
+			name:   "audience list and exclusion",
+			source: ":::audience internal partners\nStaff only\n:::\n:::audience !internal\nPublic note\n:::\n",
+			c:      v3,
+			want:   "Staff only\n",
+		},
+		{
+			name:   "nested",
+			source: ":::version >=v3\nA\n:::audience public\nB\n:::\nC\n:::\n",
+			c:      v3,
+			want:   "A\nC\n",
+		},
+		{
+			name:   "other directives pair up",
+			source: ":::audience internal\n:::note\nInside\n:::\n:::\n",
+			c:      v2,
+			want:   "",
+		},
+		{
+			name:   "fenced code untouched",
+			source: "```markdown\n:::version >=v3\n:::\n```\n",
+			c:      v2,
+			want:   "```markdown\n:::version >=v3\n:::\n```\n",
+		},
+		{
+			name:   "invalid condition left as written",
+			source: ":::version >=\nText\n:::\n",
+			c:      v2,
+			want:   ":::version >=\nText\n:::\n",
+			errs:   1,
+		},
+		{
+			name:   "unclosed block",
+			source: "Intro\n:::audience internal\nText\n",
+			c:      v2,
+			want:   "Intro\n:::audience internal\nText\n",
+			errs:   1,
+		},
+		{
+			name:   "CRLF",
+			source: "A\r\n:::version <v3\r\nB\r\n:::\r\n",
+			c:      v3,
+			want:   "A\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := ApplyConditions([]byte(tt.source), tt.c)
+			if string(got) != tt.want {
+				t.Errorf("source = %q, want %q", got, tt.want)
+			}
+			if len(errs) != tt.errs {
+				t.Errorf("got %d errors (%v), want %d", len(errs), errs, tt.errs)
+			}
+		})
+	}
+}
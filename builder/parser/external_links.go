@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// resolveExternalLinks fills in the defaults of the externalLinks config
+func resolveExternalLinks(cfg config.ExternalLinksConfig) config.ExternalLinksConfig {
+	if cfg.Target == "" {
+		cfg.Target = "_blank"
+	}
+	if cfg.Rel == "" {
+		cfg.Rel = "noopener noreferrer"
+	}
+	internal := make([]string, len(cfg.Internal))
+	for i, domain := range cfg.Internal {
+		internal[i] = strings.ToLower(strings.TrimPrefix(domain, "."))
+	}
+	cfg.Internal = internal
+	return cfg
+}
+
+// externalLinkTransformer sets the target, rel and class of links and bare
+// URLs to other sites. It runs after urlTransformer, which has turned
+// root-relative links into full URLs of the site by then.
+type externalLinkTransformer struct {
+	BaseURL string
+	cfg     config.ExternalLinksConfig
+}
+
+func (t *externalLinkTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch link := n.(type) {
+		case *ast.Link:
+			if t.isExternal(string(link.Destination)) {
+				t.decorate(link)
+			}
+		case *ast.AutoLink:
+			if link.AutoLinkType != ast.AutoLinkURL {
+				return ast.WalkContinue, nil
+			}
+			href := string(link.URL(reader.Source()))
+			if !strings.Contains(href, "://") {
+				href = "http://" + href // Linkify's www. links, as goldmark renders them
+			}
+			if t.isExternal(href) {
+				t.decorate(link)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// isExternal reports whether href is an http(s) URL outside the site and
+// its internal domains
+func (t *externalLinkTransformer) isExternal(href string) bool {
+	if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") || isSiteURL(t.BaseURL, href) {
+		return false
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range t.cfg.Internal {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *externalLinkTransformer) decorate(n ast.Node) {
+	if t.cfg.Target != "none" {
+		n.SetAttribute([]byte("target"), []byte(t.cfg.Target))
+	}
+	if t.cfg.Rel != "none" {
+		n.SetAttribute([]byte("rel"), []byte(t.cfg.Rel))
+	}
+	if t.cfg.Class != "" {
+		class := t.cfg.Class
+		if existing, ok := n.AttributeString("class"); ok {
+			class = string(existing.([]byte)) + " " + class
+		}
+		n.SetAttribute([]byte("class"), []byte(class))
+	}
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestExternalLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.ExternalLinksConfig
+		input string
+		want  string
+	}{
+		{
+			name:  "defaults",
+			input: "[out](https://other.org/a) [home](https://example.com/blog/x.html) [rel](/about) https://bare.org",
+			want:  `<p><a href="https://other.org/a" target="_blank" rel="noopener noreferrer">out</a> <a href="https://example.com/blog/x.html">home</a> <a href="https://example.com/blog/about">rel</a> <a href="https://bare.org" target="_blank" rel="noopener noreferrer">https://bare.org</a></p>`,
+		},
+		{
+			name: "configured",
+			cfg: config.ExternalLinksConfig{
+				Target: "none", Rel: "noopener nofollow", Class: "external",
+				Internal: []string{"Example.NET"},
+			},
+			input: "[out](https://other.org/) [docs](https://docs.example.net/) www.other.org",
+			want:  `<p><a href="https://other.org/" rel="noopener nofollow" class="external">out</a> <a href="https://docs.example.net/">docs</a> <a href="http://www.other.org" rel="noopener nofollow" class="external">www.other.org</a></p>`,
+		},
+		{
+			name:  "no rel",
+			cfg:   config.ExternalLinksConfig{Rel: "none"},
+			input: "[out](http://other.org/)",
+			want:  `<p><a href="http://other.org/" target="_blank">out</a></p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &config.Config{BaseURL: "https://example.com/blog", Markdown: config.MarkdownConfig{Linkify: true, ExternalLinks: tt.cfg}}
+			var buf bytes.Buffer
+			if err := New(site, nil, &sync.Map{}, Media{}).Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
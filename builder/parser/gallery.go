@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultThumbWidth is the thumbnail width of a gallery without size=
+const DefaultThumbWidth = 400
+
+var (
+	galleryShortcode = regexp.MustCompile(`^\{\{<\s*gallery\b(.*?)>\}\}\s*$`)
+	shortcodeAttr    = regexp.MustCompile(`(\w+)\s*=\s*"([^"]*)"`)
+)
+
+// GalleryImage is one picture of a gallery
+type GalleryImage struct {
+	Name        string // File name
+	URL         string // Published image
+	Width       int    // Of the published image
+	Height      int
+	ThumbURL    string
+	ThumbWidth  int
+	ThumbHeight int
+	Taken       time.Time // EXIF capture date, zero when unknown
+}
+
+// GalleryProvider lists the images of a gallery directory and makes sure
+// their thumbnails exist in the output
+type GalleryProvider interface {
+	Gallery(dir string, thumbWidth int) ([]GalleryImage, error)
+}
+
+// KindGallery is the node kind of a gallery shortcode
+var KindGallery = ast.NewNodeKind("Gallery")
+
+// Gallery is a `{{< gallery dir="static/photos/trip" >}}` shortcode
+type Gallery struct {
+	ast.BaseBlock
+	Dir   string
+	Sort  string // "name" (default) or "date"
+	Width int    // Thumbnail width
+}
+
+func (n *Gallery) Kind() ast.NodeKind { return KindGallery }
+
+func (n *Gallery) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Dir": n.Dir, "Sort": n.Sort}, nil)
+}
+
+// parseShortcodeAttrs reads key="value" pairs
+func parseShortcodeAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range shortcodeAttr.FindAllStringSubmatch(s, -1) {
+		attrs[strings.ToLower(m[1])] = m[2]
+	}
+	return attrs
+}
+
+type galleryParser struct{}
+
+func (p *galleryParser) Trigger() []byte { return []byte{'{'} }
+
+func (p *galleryParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	m := galleryShortcode.FindSubmatch(util.TrimRightSpace(line))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	attrs := parseShortcodeAttrs(string(m[1]))
+	node := &Gallery{Dir: attrs["dir"], Sort: attrs["sort"], Width: DefaultThumbWidth}
+	if w, err := strconv.Atoi(attrs["size"]); err == nil {
+		node.Width = min(max(w, 64), 1600)
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *galleryParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return parser.Close
+}
+
+func (p *galleryParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+func (p *galleryParser) CanInterruptParagraph() bool                                { return true }
+func (p *galleryParser) CanAcceptIndentedLine() bool                                { return false }
+
+type galleryRenderer struct {
+	provider GalleryProvider
+}
+
+func (r *galleryRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindGallery, r.render)
+}
+
+func (r *galleryRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	g := node.(*Gallery)
+	if r.provider == nil {
+		return ast.WalkContinue, nil
+	}
+	if g.Dir == "" {
+		log.Printf("   ⚠️  Gallery without dir=")
+		_, _ = w.WriteString("<!-- gallery: missing dir -->\n")
+		return ast.WalkContinue, nil
+	}
+	images, err := r.provider.Gallery(g.Dir, g.Width)
+	if err != nil {
+		log.Printf("   ⚠️  Gallery %s: %v", g.Dir, err)
+		_, _ = fmt.Fprintf(w, "<!-- gallery %s: %s -->\n", html.EscapeString(g.Dir), html.EscapeString(err.Error()))
+		return ast.WalkContinue, nil
+	}
+	sortGallery(images, g.Sort)
+
+	// Inline grid styles keep the gallery usable in themes without gallery
+	// CSS; data-pswp-* are the full-size dimensions lightboxes such as
+	// PhotoSwipe read
+	_, _ = fmt.Fprintf(w, `<div class="gallery" style="display:grid;grid-template-columns:repeat(auto-fill,minmax(min(%dpx,100%%),1fr));gap:.5rem">`+"\n", g.Width/2)
+	for _, img := range images {
+		alt := html.EscapeString(altFromName(img.Name))
+		_, _ = fmt.Fprintf(w, `<a class="gallery-item" href="%s" data-pswp-width="%d" data-pswp-height="%d"`,
+			html.EscapeString(img.URL), img.Width, img.Height)
+		if !img.Taken.IsZero() {
+			_, _ = fmt.Fprintf(w, ` data-taken="%s"`, img.Taken.Format(time.RFC3339))
+		}
+		_, _ = fmt.Fprintf(w, `><img src="%s" width="%d" height="%d" alt="%s" loading="lazy" decoding="async" style="display:block;width:100%%;height:100%%;aspect-ratio:1;object-fit:cover"></a>`+"\n",
+			html.EscapeString(img.ThumbURL), img.ThumbWidth, img.ThumbHeight, alt)
+	}
+	_, _ = w.WriteString("</div>\n")
+	return ast.WalkContinue, nil
+}
+
+// sortGallery orders images by file name, or with "date" by EXIF capture
+// date (oldest first) with undated images last
+func sortGallery(images []GalleryImage, by string) {
+	sort.SliceStable(images, func(i, j int) bool {
+		a, b := images[i], images[j]
+		if by == "date" && !a.Taken.Equal(b.Taken) {
+			if a.Taken.IsZero() || b.Taken.IsZero() {
+				return b.Taken.IsZero()
+			}
+			return a.Taken.Before(b.Taken)
+		}
+		return a.Name < b.Name
+	})
+}
+
+// altFromName turns "beach-at-dawn_2.jpg" into "beach at dawn 2"
+func altFromName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == ' ' }), " ")
+}
+
+// galleryExtension adds the gallery shortcode
+type galleryExtension struct {
+	provider GalleryProvider
+}
+
+func (e *galleryExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(util.Prioritized(&galleryParser{}, 150)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&galleryRenderer{provider: e.provider}, 500)))
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+type fakeGallery struct {
+	dir   string
+	width int
+}
+
+func (f *fakeGallery) Gallery(dir string, thumbWidth int) ([]GalleryImage, error) {
+	f.dir, f.width = dir, thumbWidth
+	if dir == "static/missing" {
+		return nil, errors.New("no such directory")
+	}
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	return []GalleryImage{
+		{Name: "c-late.jpg", URL: "/c.webp", ThumbURL: "/t/c.webp", Taken: day(3)},
+		{Name: "a-undated.png", URL: "/a.webp", ThumbURL: "/t/a.webp"},
+		{Name: "b-early.jpg", URL: "/b.webp", ThumbURL: "/t/b.webp", Width: 1200, Height: 800, Taken: day(1)},
+	}, nil
+}
+
+func TestGalleryShortcode(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantDir   string
+		wantWidth int
+		wantOrder []string // Thumbnails in order
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:      "by name",
+			input:     "Intro\n\n{{< gallery dir=\"static/photos/trip\" >}}\n\nOutro",
+			wantDir:   "static/photos/trip",
+			wantWidth: DefaultThumbWidth,
+			wantOrder: []string{"/t/a.webp", "/t/b.webp", "/t/c.webp"},
+			want:      []string{`<div class="gallery"`, `href="/b.webp" data-pswp-width="1200" data-pswp-height="800" data-taken="2024-05-01T00:00:00Z"`, `alt="b early"`, `loading="lazy"`, "<p>Outro</p>"},
+			notWant:   []string{"{{&lt;"},
+		},
+		{
+			name:      "by date, custom size",
+			input:     "{{<gallery dir=\"static/p\" sort=\"date\" size=\"10\">}}",
+			wantDir:   "static/p",
+			wantWidth: 64,
+			wantOrder: []string{"/t/b.webp", "/t/c.webp", "/t/a.webp"},
+		},
+		{
+			name:    "provider error",
+			input:   "{{< gallery dir=\"static/missing\" >}}",
+			wantDir: "static/missing",
+			want:    []string{"<!-- gallery static/missing: no such directory -->"},
+			notWant: []string{`class="gallery"`},
+		},
+		{
+			name:    "inline mention is text",
+			input:   "Use `{{< gallery >}}` or {{< gallery dir=\"x\" >}} inline.",
+			notWant: []string{`class="gallery"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeGallery{}
+			md := goldmark.New(goldmark.WithExtensions(&galleryExtension{provider: provider}))
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if provider.dir != tt.wantDir || (tt.wantWidth != 0 && provider.width != tt.wantWidth) {
+				t.Errorf("provider called with %q, %d; want %q, %d", provider.dir, provider.width, tt.wantDir, tt.wantWidth)
+			}
+			last := -1
+			for _, thumb := range tt.wantOrder {
+				i := strings.Index(out, `src="`+thumb+`"`)
+				if i < last {
+					t.Errorf("%s out of order in %s", thumb, out)
+				}
+				last = i
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output missing %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// headingIDOptions returns the parser options that give headings their ids.
+// {#id} after a heading is always read. The default style without section
+// prefixes is goldmark's own generator, so existing ids don't change.
+func headingIDOptions(cfg config.HeadingIDsConfig) []parser.Option {
+	options := []parser.Option{parser.WithHeadingAttribute()}
+	if (cfg.Style == "" || cfg.Style == "default") && !cfg.SectionPrefix {
+		return append(options, parser.WithAutoHeadingID())
+	}
+	// Before the typographer, so ids don't depend on its punctuation
+	return append(options, parser.WithASTTransformers(util.Prioritized(&headingIDTransformer{cfg: cfg}, 140)))
+}
+
+// headingIDTransformer gives every heading without an explicit id one made
+// with markdown.headingIDs. Explicit ids are collected first, so a generated
+// id never takes one written further down the page.
+type headingIDTransformer struct {
+	cfg config.HeadingIDsConfig
+}
+
+func (t *headingIDTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var headings []*ast.Heading
+	used := make(map[string]bool)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		headings = append(headings, heading)
+		if id, ok := heading.AttributeString("id"); ok {
+			if b, ok := id.([]byte); ok {
+				used[string(b)] = true
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	})
+
+	// Enclosing headings, outermost first. The page title (h1) isn't one.
+	type section struct {
+		level int
+		id    string
+	}
+	var sections []section
+	for _, heading := range headings {
+		for len(sections) > 0 && sections[len(sections)-1].level >= heading.Level {
+			sections = sections[:len(sections)-1]
+		}
+
+		var id string
+		if v, ok := heading.AttributeString("id"); ok {
+			b, _ := v.([]byte)
+			id = string(b)
+		} else {
+			var slug string
+			if t.cfg.Style == "github" || t.cfg.Style == "unicode" {
+				slug = Slug(t.cfg.Style, string(headingText(heading, reader.Source())))
+			} else {
+				// goldmark's generator reads the heading's markdown
+				var line []byte
+				if lines := heading.Lines(); lines.Len() > 0 {
+					last := lines.At(lines.Len() - 1)
+					line = last.Value(reader.Source())
+				}
+				slug = Slug(t.cfg.Style, string(line))
+			}
+			if t.cfg.SectionPrefix && len(sections) > 0 {
+				slug = sections[len(sections)-1].id + "-" + slug
+			}
+			id = uniqueID(slug, used)
+			heading.SetAttributeString("id", []byte(id))
+		}
+		if heading.Level > 1 {
+			sections = append(sections, section{heading.Level, id})
+		}
+	}
+}
+
+// uniqueID returns slug, or slug-1, slug-2... when it is taken, and marks
+// the result as taken
+func uniqueID(slug string, used map[string]bool) string {
+	id := slug
+	for i := 1; used[id]; i++ {
+		id = slug + "-" + strconv.Itoa(i)
+	}
+	used[id] = true
+	return id
+}
+
+// Slug turns heading text into an id in one of the markdown.headingIDs
+// styles, without making it unique on the page:
+//
+//   - "default": lowercase ASCII letters and digits, with spaces, "-" and
+//     "_" as "-" and everything else dropped ("Café au lait" → "caf-au-lait")
+//   - "github": like GitHub's anchors, letters and digits of any script,
+//     "-" and "_" kept, spaces as "-" ("Café au lait" → "café-au-lait",
+//     "A & B" → "a--b")
+//   - "unicode": letters and digits of any script, each run of anything
+//     else as one "-" ("A & B" → "a-b")
+//
+// Text that leaves nothing is "heading".
+func Slug(style, s string) string {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	switch style {
+	case "github":
+		for _, r := range strings.ToLower(s) {
+			switch {
+			case unicode.IsLetter(r), unicode.IsNumber(r), unicode.IsMark(r), r == '-', r == '_':
+				b.WriteRune(r)
+			case r == ' ':
+				b.WriteByte('-')
+			}
+		}
+	case "unicode":
+		dash := false
+		for _, r := range strings.ToLower(s) {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) {
+				if dash && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				dash = false
+				b.WriteRune(r)
+			} else {
+				dash = true
+			}
+		}
+	default:
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c >= 0x80: // Part of a multi-byte character
+			case 'A' <= c && c <= 'Z':
+				b.WriteByte(c + 'a' - 'A')
+			case 'a' <= c && c <= 'z', '0' <= c && c <= '9':
+				b.WriteByte(c)
+			case c == ' ', c == '\t', c == '\n', c == '\v', c == '\f', c == '\r', c == '-', c == '_':
+				b.WriteByte('-')
+			}
+		}
+	}
+	if b.Len() == 0 {
+		return "heading"
+	}
+	return b.String()
+}
+
+// HeadingIDs returns the ids the headings of a content file get in a build
+// of site, in page order. Includes, shortcodes and refs aren't expanded, so
+// headings they add are missing.
+func HeadingIDs(site *config.Config, source []byte) []string {
+	md := goldmark.New(
+		goldmark.WithExtensions(meta.Meta), // Frontmatter isn't a setext heading
+		goldmark.WithParserOptions(headingIDOptions(site.Markdown.HeadingIDs)...),
+	)
+	doc := md.Parser().Parse(text.NewReader(source))
+	var ids []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if heading, ok := n.(*ast.Heading); ok && entering {
+			if id, ok := heading.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					ids = append(ids, string(b))
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return ids
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/yuin/goldmark/parser"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		style, text, want string
+	}{
+		{"default", "Café au lait", "caf-au-lait"},
+		{"default", "Go 1.22 & modules", "go-122--modules"},
+		{"github", "Café au lait", "café-au-lait"},
+		{"github", "A & B", "a--b"},
+		{"github", "snake_case -- flags", "snake_case----flags"},
+		{"github", "Привет, мир!", "привет-мир"},
+		{"unicode", "A & B", "a-b"},
+		{"unicode", "  «Привет», мир!  ", "привет-мир"},
+		{"unicode", "日本語の見出し", "日本語の見出し"},
+		{"unicode", "🎉", "heading"},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.style, tt.text); got != tt.want {
+			t.Errorf("Slug(%q, %q) = %q, want %q", tt.style, tt.text, got, tt.want)
+		}
+	}
+}
+
+var headingID = regexp.MustCompile(`<h\d id="([^"]*)"`)
+
+func TestHeadingIDStyles(t *testing.T) {
+	input := "# Guide\n\n## Install *now*\n\n### Linux\n\n### macOS {#mac}\n\n## Configure\n\n### Linux\n\n## Café\n\n## Café"
+	tests := []struct {
+		name string
+		cfg  config.HeadingIDsConfig
+		want []string
+	}{
+		{"default", config.HeadingIDsConfig{}, []string{"guide", "install-now", "linux", "mac", "configure", "linux-1", "caf", "caf-1"}},
+		{"github", config.HeadingIDsConfig{Style: "github"}, []string{"guide", "install-now", "linux", "mac", "configure", "linux-1", "café", "café-1"}},
+		{"section prefix", config.HeadingIDsConfig{SectionPrefix: true}, []string{"guide", "install-now", "install-now-linux", "mac", "configure", "configure-linux", "caf", "caf-1"}},
+		{"unicode section prefix", config.HeadingIDsConfig{Style: "unicode", SectionPrefix: true}, []string{"guide", "install-now", "install-now-linux", "mac", "configure", "configure-linux", "café", "café-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &config.Config{Markdown: config.MarkdownConfig{HeadingIDs: tt.cfg}}
+			var buf bytes.Buffer
+			pc := parser.NewContext()
+			if err := New(site, nil, &sync.Map{}, Media{}).Convert([]byte(input), &buf, parser.WithContext(pc)); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range headingID.FindAllStringSubmatch(buf.String(), -1) {
+				got = append(got, m[1])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ids = %q, want %q", got, tt.want)
+			}
+			if toc := GetTOC(pc); len(toc) != 7 || toc[1].ID != tt.want[2] || toc[2].Text != "macOS" {
+				t.Errorf("TOC = %+v, want the same ids without {#mac}", toc)
+			}
+			if ids := HeadingIDs(site, []byte("---\ntitle: Guide\n---\n"+input)); !slices.Equal(ids, tt.want) {
+				t.Errorf("HeadingIDs = %q, want %q", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplicitIDsAreReserved(t *testing.T) {
+	site := &config.Config{Markdown: config.MarkdownConfig{HeadingIDs: config.HeadingIDsConfig{Style: "unicode"}}}
+	// The generated id of the first heading would be the explicit one below
+	ids := HeadingIDs(site, []byte("## Setup\n\n## Install {#setup}\n"))
+	if !slices.Equal(ids, []string{"setup-1", "setup"}) {
+		t.Errorf("ids = %q", ids)
+	}
+}
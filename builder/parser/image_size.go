@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// ImageSize is the size an image is published at, with the smaller copies
+// of it browsers can pick from
+type ImageSize struct {
+	Width  int
+	Height int
+	Srcset string // WebP copies by width ("a-480w.webp 480w, a.webp 1200w"); "" for none
+	AVIF   string // AVIF copies by width, offered in a <picture> before the WebP ones
+	Sizes  string // The sizes attribute that goes with the srcsets
+}
+
+// ImageProvider measures the images markdown links to. src is the
+// destination as written in the page; ok is false for images it can't
+// measure (remote, missing, not under static/).
+type ImageProvider interface {
+	ImageSize(src string) (size ImageSize, ok bool)
+}
+
+// imageSizeTransformer gives markdown images their width and height, so
+// browsers reserve their space before they load, and decoding="async".
+// It runs before urlTransformer rewrites the destinations.
+type imageSizeTransformer struct {
+	provider ImageProvider
+}
+
+func (t *imageSizeTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if _, sized := img.AttributeString("width"); sized {
+			return ast.WalkContinue, nil
+		}
+		size, ok := t.provider.ImageSize(string(img.Destination))
+		if !ok || size.Width <= 0 || size.Height <= 0 {
+			return ast.WalkContinue, nil
+		}
+		img.SetAttributeString("width", []byte(strconv.Itoa(size.Width)))
+		img.SetAttributeString("height", []byte(strconv.Itoa(size.Height)))
+		img.SetAttributeString("decoding", []byte("async"))
+		if size.Srcset != "" {
+			img.SetAttributeString("srcset", []byte(size.Srcset))
+			img.SetAttributeString("sizes", []byte(size.Sizes))
+		}
+		if size.AVIF != "" {
+			img.SetAttributeString("data-avif-srcset", []byte(size.AVIF))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// avifImg matches an image with AVIF copies, as imageSizeTransformer marks it
+var avifImg = regexp.MustCompile(`<img [^>]*?( data-avif-srcset="([^"]*)")[^>]*>`)
+
+// imgSizes reads an image's sizes attribute
+var imgSizes = regexp.MustCompile(` sizes="([^"]*)"`)
+
+// WrapPictures puts the rendered images that have AVIF copies in a <picture>
+// offering those first, for browsers that decode AVIF; the others keep the
+// <img> and its WebP srcset.
+func WrapPictures(html string) string {
+	if !strings.Contains(html, "data-avif-srcset=") {
+		return html
+	}
+	return avifImg.ReplaceAllStringFunc(html, func(tag string) string {
+		m := avifImg.FindStringSubmatch(tag)
+		source := `<source type="image/avif" srcset="` + m[2] + `"`
+		if sizes := imgSizes.FindStringSubmatch(tag); sizes != nil {
+			source += ` sizes="` + sizes[1] + `"`
+		}
+		return "<picture>" + source + ">" + strings.Replace(tag, m[1], "", 1) + "</picture>"
+	})
+}
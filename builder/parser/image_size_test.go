@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+type fakeImages struct {
+	asked []string
+}
+
+func (f *fakeImages) ImageSize(src string) (ImageSize, bool) {
+	f.asked = append(f.asked, src)
+	if src == "/static/images/photo.png" {
+		return ImageSize{Width: 1200, Height: 800}, true
+	}
+	if src == "/static/images/hero.png" {
+		return ImageSize{Width: 1200, Height: 600, Srcset: "/static/images/hero-480w.webp 480w, /static/images/hero.webp 1200w", Sizes: "100vw"}, true
+	}
+	return ImageSize{}, false
+}
+
+func TestImageSizes(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantAsked string
+		want      string
+	}{
+		{
+			name:      "measured image",
+			input:     "![Photo](/static/images/photo.png)",
+			wantAsked: "/static/images/photo.png",
+			want:      `<p><img src="https://example.com/static/images/photo.webp" alt="Photo" width="1200" height="800" decoding="async" loading="lazy"></p>`,
+		},
+		{
+			name:      "responsive image",
+			input:     "![Hero](/static/images/hero.png)",
+			wantAsked: "/static/images/hero.png",
+			want:      `<p><img src="https://example.com/static/images/hero.webp" alt="Hero" width="1200" height="600" decoding="async" srcset="/static/images/hero-480w.webp 480w, /static/images/hero.webp 1200w" sizes="100vw" loading="lazy"></p>`,
+		},
+		{
+			name:      "unknown image stays unsized",
+			input:     "![Remote](https://cdn.example.org/a.png)",
+			wantAsked: "https://cdn.example.org/a.png",
+			want:      `<p><img src="https://cdn.example.org/a.png" alt="Remote" loading="lazy"></p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images := &fakeImages{}
+			site := &config.Config{BaseURL: "https://example.com"}
+			var buf bytes.Buffer
+			if err := New(site, nil, &sync.Map{}, Media{Images: images}).Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			if len(images.asked) != 1 || images.asked[0] != tt.wantAsked {
+				t.Errorf("provider asked for %q, want %q", images.asked, tt.wantAsked)
+			}
+		})
+	}
+}
+
+func TestWrapPictures(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{
+			name: "AVIF copies",
+			html: `<p><img src="/a.webp" alt="A" srcset="/a-480w.webp 480w, /a.webp 800w" sizes="100vw" data-avif-srcset="/a-480w.avif 480w, /a.avif 800w" loading="lazy"></p>`,
+			want: `<p><picture><source type="image/avif" srcset="/a-480w.avif 480w, /a.avif 800w" sizes="100vw"><img src="/a.webp" alt="A" srcset="/a-480w.webp 480w, /a.webp 800w" sizes="100vw" loading="lazy"></picture></p>`,
+		},
+		{
+			name: "plain image untouched",
+			html: `<p><img src="/a.webp" alt="A"></p>`,
+			want: `<p><img src="/a.webp" alt="A"></p>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WrapPictures(tt.html); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// includeShortcode matches `{{< include "snippets/warning.md" >}}`
+var includeShortcode = regexp.MustCompile(`\{\{<\s*include\s+"([^"]*)"\s*>\}\}`)
+
+// maxIncludeDepth bounds fragments including fragments
+const maxIncludeDepth = 8
+
+// HasIncludes reports whether a page has include shortcodes
+func HasIncludes(source []byte) bool {
+	return bytes.Contains(source, []byte("include")) && includeShortcode.Match(source)
+}
+
+// IncludeName cleans the name of an included fragment: a slash-separated
+// path in the includes directory. Names leaving it are refused.
+func IncludeName(ref string) (string, bool) {
+	name := path.Clean(strings.TrimPrefix(strings.TrimSpace(strings.ReplaceAll(ref, `\`, "/")), "/"))
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, true
+}
+
+// ExpandIncludes replaces the include shortcodes of a page with the Markdown
+// fragment they name, read by read. Fragments may include other fragments;
+// their frontmatter is dropped, and when the shortcode is indented (in a
+// list item) the fragment's lines are indented the same. It returns the
+// expanded source, the names of the fragments used, sorted, and an error per
+// fragment that can't be read or includes itself; those shortcodes are left
+// in place.
+func ExpandIncludes(source []byte, read func(name string) ([]byte, error)) ([]byte, []string, []error) {
+	var used []string
+	var errs []error
+	var expand func(src []byte, stack []string) []byte
+	expand = func(src []byte, stack []string) []byte {
+		matches := includeShortcode.FindAllSubmatchIndex(src, -1)
+		if matches == nil {
+			return src
+		}
+		var out bytes.Buffer
+		last := 0
+		for _, m := range matches {
+			out.Write(src[last:m[0]])
+			last = m[1]
+			shortcode := src[m[0]:m[1]]
+			ref := string(src[m[2]:m[3]])
+
+			name, ok := IncludeName(ref)
+			if !ok {
+				errs = append(errs, fmt.Errorf("include %q: outside the includes directory", ref))
+				out.Write(shortcode)
+				continue
+			}
+			if slices.Contains(stack, name) {
+				errs = append(errs, fmt.Errorf("include %q: includes itself (%s)", ref, strings.Join(append(stack, name), " → ")))
+				out.Write(shortcode)
+				continue
+			}
+			if len(stack) >= maxIncludeDepth {
+				errs = append(errs, fmt.Errorf("include %q: nested more than %d deep", ref, maxIncludeDepth))
+				out.Write(shortcode)
+				continue
+			}
+			fragment, err := read(name)
+			if !slices.Contains(used, name) {
+				used = append(used, name) // A missing fragment is a dependency too: creating it fixes the page
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("include %q: %w", ref, err))
+				out.Write(shortcode)
+				continue
+			}
+			fragment = bytes.TrimRight(stripFrontmatter(fragment), "\r\n")
+			fragment = expand(fragment, append(stack, name))
+			out.Write(indentFragment(fragment, lineIndent(src, m[0])))
+		}
+		out.Write(src[last:])
+		return out.Bytes()
+	}
+	out := expand(source, nil)
+	slices.Sort(used)
+	return out, used, errs
+}
+
+// stripFrontmatter drops a leading YAML frontmatter block
+func stripFrontmatter(src []byte) []byte {
+	rest, ok := bytes.CutPrefix(src, []byte("---"))
+	if !ok {
+		return src
+	}
+	rest = bytes.TrimLeft(rest, " \t")
+	if !bytes.HasPrefix(rest, []byte("\n")) && !bytes.HasPrefix(rest, []byte("\r\n")) {
+		return src // A thematic break, not frontmatter
+	}
+	if i := bytes.Index(rest, []byte("\n---")); i >= 0 {
+		body := rest[i+len("\n---"):]
+		if nl := bytes.IndexByte(body, '\n'); nl >= 0 {
+			return body[nl+1:]
+		}
+		return nil
+	}
+	return src
+}
+
+// lineIndent returns the whitespace before pos on its line, or "" when
+// anything else precedes it
+func lineIndent(src []byte, pos int) []byte {
+	start := bytes.LastIndexByte(src[:pos], '\n') + 1
+	prefix := src[start:pos]
+	if len(bytes.TrimLeft(prefix, " \t")) > 0 {
+		return nil
+	}
+	return prefix
+}
+
+// indentFragment prefixes every line but the first (which takes the
+// shortcode's place) with indent
+func indentFragment(fragment, indent []byte) []byte {
+	if len(indent) == 0 || !bytes.Contains(fragment, []byte("\n")) {
+		return fragment
+	}
+	lines := bytes.Split(fragment, []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		if len(bytes.TrimSpace(lines[i])) > 0 {
+			lines[i] = append(slices.Clip(indent), lines[i]...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIncludeName(t *testing.T) {
+	tests := []struct {
+		ref, want string
+		ok        bool
+	}{
+		{"snippets/warning.md", "snippets/warning.md", true},
+		{"/snippets/./warning.md", "snippets/warning.md", true},
+		{`snippets\warning.md`, "snippets/warning.md", true},
+		{"snippets/../warning.md", "warning.md", true},
+		{"../secret.md", "", false},
+		{"..", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := IncludeName(tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("IncludeName(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestHasIncludes(t *testing.T) {
+	if !HasIncludes([]byte(`Intro {{< include "a.md" >}}`)) {
+		t.Error("HasIncludes missed an include shortcode")
+	}
+	if HasIncludes([]byte("We include nothing here.")) {
+		t.Error("HasIncludes matched plain text")
+	}
+}
+
+func TestExpandIncludes(t *testing.T) {
+	fragments := map[string]string{
+		"warning.md":  "> **Warning:** back up first.\n",
+		"install.md":  "---\ntitle: ignored\n---\nRun `kosh build`.\n\n{{< include \"warning.md\" >}}\n",
+		"steps.md":    "Download it\nUnpack it",
+		"loop-a.md":   "A {{< include \"loop-b.md\" >}}",
+		"loop-b.md":   "B {{< include \"loop-a.md\" >}}",
+		"rule.md":     "---\n\nAfter a rule",
+		"nested/x.md": "X",
+	}
+	read := func(name string) ([]byte, error) {
+		if f, ok := fragments[name]; ok {
+			return []byte(f), nil
+		}
+		return nil, fs.ErrNotExist
+	}
+
+	tests := []struct {
+		name, source, want string
+		used               []string
+		errs               int
+	}{
+		{
+			name:   "nested, frontmatter dropped",
+			source: "# Setup\n\n{{< include \"install.md\" >}}\n",
+			want:   "# Setup\n\nRun `kosh build`.\n\n> **Warning:** back up first.\n",
+			used:   []string{"install.md", "warning.md"},
+		},
+		{
+			name:   "indented in a list item",
+			source: "1. First\n   {{< include \"steps.md\" >}}\n",
+			want:   "1. First\n   Download it\n   Unpack it\n",
+			used:   []string{"steps.md"},
+		},
+		{
+			name:   "thematic break kept",
+			source: `{{< include "rule.md" >}}`,
+			want:   "---\n\nAfter a rule",
+			used:   []string{"rule.md"},
+		},
+		{
+			name:   "subdirectory",
+			source: `{{<include "/nested/x.md">}}`,
+			want:   "X",
+			used:   []string{"nested/x.md"},
+		},
+		{
+			name:   "missing fragment left in place",
+			source: `See {{< include "gone.md" >}}`,
+			want:   `See {{< include "gone.md" >}}`,
+			used:   []string{"gone.md"},
+			errs:   1,
+		},
+		{
+			name:   "outside the includes directory",
+			source: `{{< include "../config.md" >}}`,
+			want:   `{{< include "../config.md" >}}`,
+			errs:   1,
+		},
+		{
+			name:   "cycle",
+			source: `{{< include "loop-a.md" >}}`,
+			want:   `A B {{< include "loop-a.md" >}}`,
+			used:   []string{"loop-a.md", "loop-b.md"},
+			errs:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used, errs := ExpandIncludes([]byte(tt.source), read)
+			if string(got) != tt.want {
+				t.Errorf("source = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(used, tt.used) {
+				t.Errorf("used = %q, want %q", used, tt.used)
+			}
+			if len(errs) != tt.errs {
+				t.Errorf("got %d errors (%v), want %d", len(errs), errs, tt.errs)
+			}
+		})
+	}
+}
+
+func TestExpandIncludesCycleError(t *testing.T) {
+	read := func(name string) ([]byte, error) {
+		return []byte(`{{< include "self.md" >}}`), nil
+	}
+	_, _, errs := ExpandIncludes([]byte(`{{< include "self.md" >}}`), read)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "self.md → self.md") {
+		t.Errorf("errs = %v, want a cycle through self.md", errs)
+	}
+}
@@ -0,0 +1,24 @@
+package parser
+
+import "regexp"
+
+// fileShortcode matches shortcodes whose output is built from other files
+var fileShortcode = regexp.MustCompile(`\{\{<\s*(gallery|video)\b`)
+
+// Media serves the shortcodes that publish files from static/ and the
+// theme's shortcode templates, and measures images. A nil provider leaves its
+// shortcode unrendered (images unsized; only built-in template shortcodes).
+type Media struct {
+	Gallery    GalleryProvider
+	Video      VideoProvider
+	Images     ImageProvider
+	Shortcodes ShortcodeProvider
+}
+
+// DependsOnFiles reports whether a page renders content read from other files
+// (a gallery lists a directory, a video gets a poster frame, a ref needs its
+// target to exist), so its cached HTML can't be reused: the files may have
+// changed while the page didn't.
+func DependsOnFiles(source []byte) bool {
+	return fileShortcode.Match(source) || HasRefs(source)
+}
@@ -0,0 +1,20 @@
+package parser
+
+import "testing"
+
+func TestDependsOnFiles(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"x\n{{< gallery dir=\"static/a\" >}}\n", true},
+		{"{{<video src=\"static/v.mp4\">}}", true},
+		{"# Plain post", false},
+		{"{{< videos >}}", false},
+	}
+	for _, tt := range tests {
+		if got := DependsOnFiles([]byte(tt.source)); got != tt.want {
+			t.Errorf("DependsOnFiles(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
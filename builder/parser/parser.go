@@ -14,9 +14,11 @@ import (
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/util"
 
+	"github.com/Kush-Singh-26/kosh/builder/config"
 	"github.com/Kush-Singh-26/kosh/builder/renderer/native"
 )
 
@@ -80,37 +82,116 @@ func ExtractPlainText(node ast.Node, source []byte) string {
 	return out.String()
 }
 
-// New creates a new Goldmark markdown parser with SSR support for diagrams
-func New(baseURL string, renderer *native.Renderer, diagramCache *sync.Map) goldmark.Markdown {
+// New creates a new Goldmark markdown parser with SSR support for diagrams.
+// site.Markdown selects the optional extensions, media serves the gallery,
+// video and template shortcodes and sizes images.
+func New(site *config.Config, renderer *native.Renderer, diagramCache *sync.Map, media Media) goldmark.Markdown {
+	baseURL, opts := site.BaseURL, site.Markdown
+	extensions := []goldmark.Extender{
+		meta.Meta,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle("nord"),
+			highlighting.WithFormatOptions(
+				chroma_html.WithClasses(true),
+			),
+			highlighting.WithWrapperRenderer(codeBlockWrapper),
+		),
+		passthrough.New(passthrough.Config{
+			InlineDelimiters: []passthrough.Delimiters{{Open: "$", Close: "$"}, {Open: "\\(", Close: "\\)"}},
+			BlockDelimiters:  []passthrough.Delimiters{{Open: "$$", Close: "$$"}, {Open: "\\[", Close: "\\]"}},
+		}),
+		&admonitions.Extender{},
+		&galleryExtension{provider: media.Gallery},
+		&videoExtension{provider: media.Video},
+		&audioExtension{baseURL: baseURL},
+		&shortcodeExtension{provider: media.Shortcodes, baseURL: baseURL},
+	}
+	extensions = append(extensions, markdownExtensions(opts)...)
+
+	transformers := []util.PrioritizedValue{
+		util.Prioritized(&urlTransformer{BaseURL: baseURL}, 100),
+		util.Prioritized(&externalLinkTransformer{BaseURL: baseURL, cfg: resolveExternalLinks(opts.ExternalLinks)}, 110),
+		util.Prioritized(&tocTransformer{}, 200),
+		util.Prioritized(&ssrTransformer{
+			Renderer: renderer,
+			Cache:    diagramCache,
+		}, 50), // Run SSR early (lower priority = runs first)
+	}
+	if media.Images != nil {
+		transformers = append(transformers, util.Prioritized(&imageSizeTransformer{provider: media.Images}, 90))
+	}
+	if opts.Typographer {
+		// Before the TOC picks up heading text
+		transformers = append(transformers, util.Prioritized(&typographyTransformer{language: site.Language, configured: opts.Typography}, 150))
+	}
+
+	parserOptions := append([]parser.Option{parser.WithASTTransformers(transformers...)}, headingIDOptions(opts.HeadingIDs)...)
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithRendererOptions(markdownRendererOptions(opts)...),
+	)
+}
+
+// markdownExtensions returns the optional goldmark extensions opts enables
+func markdownExtensions(opts config.MarkdownConfig) []goldmark.Extender {
+	var extensions []goldmark.Extender
+	for _, ext := range []struct {
+		on  bool
+		ext goldmark.Extender
+	}{
+		{opts.Tables, extension.Table},
+		{opts.Strikethrough, extension.Strikethrough},
+		{opts.TaskLists, extension.TaskList},
+		{opts.Linkify, extension.Linkify},
+		{opts.DefinitionLists, extension.DefinitionList},
+		{opts.Footnotes, extension.Footnote},
+		{opts.Typographer, extension.NewTypographer(extension.WithTypographicSubstitutions(typographerMarks))},
+	} {
+		if ext.on {
+			extensions = append(extensions, ext.ext)
+		}
+	}
+	if opts.RawHTML == "sanitize" {
+		extensions = append(extensions, &sanitizeExtension{policy: newSanitizePolicy(opts.Sanitize)})
+	}
+	if opts.HeadingAnchors.Enabled {
+		extensions = append(extensions, &headingAnchorExtension{cfg: resolveHeadingAnchors(opts.HeadingAnchors)})
+	}
+	return extensions
+}
+
+// markdownRendererOptions returns the HTML renderer options for opts. Raw
+// HTML is passed through unless the policy is "omit" or "sanitize"; the
+// latter also keeps goldmark's filter for javascript: links.
+func markdownRendererOptions(opts config.MarkdownConfig) []renderer.Option {
+	var options []renderer.Option
+	if opts.RawHTML != "omit" && opts.RawHTML != "sanitize" {
+		options = append(options, html.WithUnsafe())
+	}
+	if opts.HardWraps {
+		options = append(options, html.WithHardWraps())
+	}
+	return options
+}
+
+// NewEmail creates a parser for email export. Code is highlighted with inline
+// styles because mail clients drop stylesheets, and no SSR or URL rewriting is
+// done: the exporter resolves links against the post's permalink itself.
+func NewEmail() goldmark.Markdown {
 	return goldmark.New(
 		goldmark.WithExtensions(
 			extension.GFM,
 			meta.Meta,
 			highlighting.NewHighlighting(
-				highlighting.WithStyle("nord"),
+				highlighting.WithStyle("github"),
 				highlighting.WithFormatOptions(
-					chroma_html.WithClasses(true),
+					chroma_html.WithClasses(false),
 				),
-				highlighting.WithWrapperRenderer(codeBlockWrapper),
 			),
-			passthrough.New(passthrough.Config{
-				InlineDelimiters: []passthrough.Delimiters{{Open: "$", Close: "$"}, {Open: "\\(", Close: "\\)"}},
-				BlockDelimiters:  []passthrough.Delimiters{{Open: "$$", Close: "$$"}, {Open: "\\[", Close: "\\]"}},
-			}),
 			&admonitions.Extender{},
 		),
-		goldmark.WithParserOptions(
-			// Register Transformers
-			parser.WithASTTransformers(
-				util.Prioritized(&urlTransformer{BaseURL: baseURL}, 100),
-				util.Prioritized(&tocTransformer{}, 200),
-				util.Prioritized(&ssrTransformer{
-					Renderer: renderer,
-					Cache:    diagramCache,
-				}, 50), // Run SSR early (lower priority = runs first)
-			),
-			parser.WithAutoHeadingID(),
-		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
 		goldmark.WithRendererOptions(html.WithUnsafe()),
 	)
 }
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestNewMarkdownOptions(t *testing.T) {
+	gfm := config.MarkdownConfig{Tables: true, Strikethrough: true, TaskLists: true, Linkify: true, RawHTML: "allow"}
+	withAll := gfm
+	withAll.DefinitionLists, withAll.Footnotes, withAll.Typographer, withAll.HardWraps = true, true, true, true
+
+	input := `| A |
+|---|
+| 1 |
+
+~~gone~~ - [x] done https://example.com
+
+Term
+: Definition
+
+Note[^1] "quoted" -- dash
+next line
+
+<span class="raw">raw</span>
+
+[^1]: The footnote
+`
+	tests := []struct {
+		name    string
+		opts    config.MarkdownConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "defaults",
+			opts:    gfm,
+			want:    []string{"<table>", "<del>gone</del>", `<a href="https://example.com"`, `<span class="raw">`},
+			notWant: []string{"<dl>", "footnote-ref", "“", "<br>"},
+		},
+		{
+			name: "everything on",
+			opts: withAll,
+			want: []string{"<table>", "<dl>", "<dd>Definition</dd>", "footnote-ref", "“quoted”", "–", "<br>"},
+		},
+		{
+			name:    "plain markdown, raw HTML omitted",
+			opts:    config.MarkdownConfig{RawHTML: "omit"},
+			want:    []string{"<!-- raw HTML omitted -->"},
+			notWant: []string{"<table>", "<del>", `<a href="https://example.com"`, `<span class="raw">`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := New(&config.Config{Markdown: tt.opts}, nil, &sync.Map{}, Media{}).Convert([]byte(input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output missing %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
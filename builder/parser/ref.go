@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+var (
+	// refShortcode matches `{{< ref "guides/install.md" >}}` and relref, which
+	// may appear anywhere, typically as a link destination
+	refShortcode = regexp.MustCompile(`\{\{<\s*(ref|relref)\s+"([^"]*)"\s*>\}\}`)
+	// versionRefShortcode matches `{{< versionref "v1.0" >}}`, the current
+	// page in another version
+	versionRefShortcode = regexp.MustCompile(`\{\{<\s*versionref\s+"([^"]*)"\s*>\}\}`)
+)
+
+// HasRefs reports whether a page has ref, relref or versionref shortcodes
+func HasRefs(source []byte) bool {
+	return bytes.Contains(source, []byte("ref")) && (refShortcode.Match(source) || versionRefShortcode.Match(source))
+}
+
+// ContentRef turns a reference to a content file into its path under the
+// content directory: "./x.md" and "../x.md" are relative to page, anything
+// else to the content root ("/content/" and "content/" prefixes allowed);
+// ".md" is added when missing
+func ContentRef(page, ref string) string {
+	rel := strings.TrimSpace(strings.ReplaceAll(ref, `\`, "/"))
+	if strings.HasPrefix(rel, ".") {
+		rel = path.Join(path.Dir(strings.ReplaceAll(page, `\`, "/")), rel)
+	} else {
+		rel = path.Clean(strings.TrimPrefix(strings.TrimPrefix(rel, "/"), "content/"))
+	}
+	if path.Ext(rel) != ".md" {
+		rel += ".md"
+	}
+	return rel
+}
+
+// RefCandidates lists the content files a reference from page may mean, best
+// first: a root-relative reference from a versioned page prefers the page's
+// own version
+func RefCandidates(page, ref string) []string {
+	rel := ContentRef(page, ref)
+	version, _ := utils.GetVersionFromPath("content/" + strings.ReplaceAll(page, `\`, "/"))
+	if version == "" || strings.HasPrefix(strings.TrimSpace(ref), ".") || strings.HasPrefix(rel, version+"/") {
+		return []string{rel}
+	}
+	return []string{version + "/" + rel, rel}
+}
+
+// ResolveRefs replaces the ref and relref shortcodes of a page with the
+// permalink of their target, found by resolve (content path to permalink);
+// relref keeps only the path. A "#section" suffix is carried over. Targets
+// resolve can't find are returned, and their shortcodes are left in place.
+func ResolveRefs(source []byte, page string, resolve func(relPath string) (string, bool)) ([]byte, []string) {
+	var broken []string
+	out := refShortcode.ReplaceAllFunc(source, func(m []byte) []byte {
+		sub := refShortcode.FindSubmatch(m)
+		kind, target := string(sub[1]), string(sub[2])
+		ref, fragment, _ := strings.Cut(target, "#")
+
+		link, ok := "", false
+		if ref == "" {
+			link, ok = "", fragment != "" // "#section" of the page itself
+		} else {
+			for _, candidate := range RefCandidates(page, ref) {
+				if link, ok = resolve(candidate); ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			broken = append(broken, target)
+			return m
+		}
+		if kind == "relref" {
+			if u, err := url.Parse(link); err == nil && u.Host != "" {
+				link = u.EscapedPath()
+			}
+		}
+		if fragment != "" {
+			link += "#" + fragment
+		}
+		return []byte(link)
+	})
+	return out, broken
+}
+
+// ResolveVersionRefs replaces the versionref shortcodes of a page with the
+// URL of the page in the named version ("latest" for the latest version),
+// found by lookup. A version without the page links to its home page. Names
+// lookup doesn't know are returned, and their shortcodes are left in place.
+func ResolveVersionRefs(source []byte, lookup func(name string) (models.VersionInfo, bool)) ([]byte, []string) {
+	var unknown []string
+	out := versionRefShortcode.ReplaceAllFunc(source, func(m []byte) []byte {
+		name := string(versionRefShortcode.FindSubmatch(m)[1])
+		v, ok := lookup(name)
+		if !ok {
+			unknown = append(unknown, name)
+			return m
+		}
+		return []byte(v.URL)
+	})
+	return out, unknown
+}
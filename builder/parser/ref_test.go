@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestContentRef(t *testing.T) {
+	tests := []struct {
+		page, ref, want string
+	}{
+		{"blog/post.md", "guides/install.md", "guides/install.md"},
+		{"blog/post.md", "guides/install", "guides/install.md"},
+		{"blog/post.md", "/content/guides/install.md", "guides/install.md"},
+		{"blog/post.md", "./other.md", "blog/other.md"},
+		{"blog/post.md", "../about", "about.md"},
+		{`blog\post.md`, `guides\install.md`, "guides/install.md"},
+	}
+	for _, tt := range tests {
+		if got := ContentRef(tt.page, tt.ref); got != tt.want {
+			t.Errorf("ContentRef(%q, %q) = %q, want %q", tt.page, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestRefCandidates(t *testing.T) {
+	tests := []struct {
+		page, ref string
+		want      []string
+	}{
+		{"guides/setup.md", "guides/install.md", []string{"guides/install.md"}},
+		{"v2.0/guides/setup.md", "guides/install.md", []string{"v2.0/guides/install.md", "guides/install.md"}},
+		{"v2.0/guides/setup.md", "v2.0/guides/install.md", []string{"v2.0/guides/install.md"}},
+		{"v2.0/guides/setup.md", "./install.md", []string{"v2.0/guides/install.md"}},
+	}
+	for _, tt := range tests {
+		if got := RefCandidates(tt.page, tt.ref); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RefCandidates(%q, %q) = %q, want %q", tt.page, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	pages := map[string]string{
+		"guides/install.md":      "https://example.com/guides/install.html",
+		"v2.0/guides/install.md": "https://example.com/v2.0/guides/install.html",
+	}
+	resolve := func(rel string) (string, bool) {
+		link, ok := pages[rel]
+		return link, ok
+	}
+
+	tests := []struct {
+		name       string
+		page       string
+		source     string
+		want       string
+		wantBroken []string
+	}{
+		{
+			name:   "ref",
+			page:   "blog/post.md",
+			source: `[Install]({{< ref "guides/install.md" >}})`,
+			want:   `[Install](https://example.com/guides/install.html)`,
+		},
+		{
+			name:   "relref with fragment",
+			page:   "blog/post.md",
+			source: `[Linux]({{< relref "guides/install#linux" >}})`,
+			want:   `[Linux](/guides/install.html#linux)`,
+		},
+		{
+			name:   "same page fragment",
+			page:   "blog/post.md",
+			source: `[Up]({{<relref "#top">}})`,
+			want:   `[Up](#top)`,
+		},
+		{
+			name:   "own version first",
+			page:   "v2.0/guides/setup.md",
+			source: `{{< ref "guides/install.md" >}}`,
+			want:   `https://example.com/v2.0/guides/install.html`,
+		},
+		{
+			name:       "broken",
+			page:       "blog/post.md",
+			source:     `[Gone]({{< ref "guides/gone.md" >}}) and {{< ref "guides/install.md" >}}`,
+			want:       `[Gone]({{< ref "guides/gone.md" >}}) and https://example.com/guides/install.html`,
+			wantBroken: []string{"guides/gone.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, broken := ResolveRefs([]byte(tt.source), tt.page, resolve)
+			if string(got) != tt.want {
+				t.Errorf("ResolveRefs() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(broken, tt.wantBroken) {
+				t.Errorf("ResolveRefs() broken = %q, want %q", broken, tt.wantBroken)
+			}
+			if !HasRefs([]byte(tt.source)) {
+				t.Errorf("HasRefs(%q) = false", tt.source)
+			}
+		})
+	}
+}
+
+func TestResolveVersionRefs(t *testing.T) {
+	lookup := func(name string) (models.VersionInfo, bool) {
+		if name == "v1.0" {
+			return models.VersionInfo{URL: "https://example.com/v1.0/guide.html"}, true
+		}
+		return models.VersionInfo{}, false
+	}
+	source := `[Old]({{< versionref "v1.0" >}}) [Gone]({{<versionref "v0.1">}})`
+	got, unknown := ResolveVersionRefs([]byte(source), lookup)
+	if want := `[Old](https://example.com/v1.0/guide.html) [Gone]({{<versionref "v0.1">}})`; string(got) != want {
+		t.Errorf("ResolveVersionRefs() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(unknown, []string{"v0.1"}) {
+		t.Errorf("ResolveVersionRefs() unknown = %q, want [v0.1]", unknown)
+	}
+	if !HasRefs([]byte(source)) {
+		t.Errorf("HasRefs(%q) = false", source)
+	}
+}
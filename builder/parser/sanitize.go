@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+	"golang.org/x/net/html"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// defaultSanitizeTags are the elements rawHTML: sanitize keeps: text
+// formatting, tables, lists and images, nothing that runs code or embeds
+// other documents
+var defaultSanitizeTags = []string{
+	"a", "abbr", "b", "blockquote", "br", "caption", "cite", "code", "col", "colgroup",
+	"dd", "del", "details", "dfn", "div", "dl", "dt", "em", "figcaption", "figure",
+	"h1", "h2", "h3", "h4", "h5", "h6", "hr", "i", "img", "ins", "kbd", "li", "mark",
+	"ol", "p", "pre", "q", "rp", "rt", "ruby", "s", "samp", "small", "span", "strong",
+	"sub", "summary", "sup", "table", "tbody", "td", "tfoot", "th", "thead", "time",
+	"tr", "u", "ul", "var", "wbr",
+}
+
+var defaultSanitizeAttributes = map[string][]string{
+	"*":          {"id", "class", "title", "lang", "dir"},
+	"a":          {"href", "rel"},
+	"img":        {"src", "alt", "width", "height", "loading"},
+	"blockquote": {"cite"},
+	"q":          {"cite"},
+	"del":        {"cite", "datetime"},
+	"ins":        {"cite", "datetime"},
+	"time":       {"datetime"},
+	"details":    {"open"},
+	"ol":         {"start", "reversed", "type"},
+	"li":         {"value"},
+	"col":        {"span"},
+	"colgroup":   {"span"},
+	"td":         {"colspan", "rowspan", "align"},
+	"th":         {"colspan", "rowspan", "align", "scope"},
+}
+
+// urlAttributes are checked for a safe scheme
+var urlAttributes = map[string]bool{"href": true, "src": true, "cite": true, "action": true, "formaction": true, "poster": true}
+
+// sanitizePolicy is a resolved allowlist
+type sanitizePolicy struct {
+	tags       map[string]bool
+	attributes map[string]map[string]bool
+}
+
+// newSanitizePolicy layers the configured allowlist over the built-in one
+func newSanitizePolicy(cfg config.SanitizeConfig) *sanitizePolicy {
+	p := &sanitizePolicy{tags: make(map[string]bool), attributes: make(map[string]map[string]bool)}
+	tags := defaultSanitizeTags
+	if len(cfg.Tags) > 0 {
+		tags = cfg.Tags
+	}
+	for _, tag := range tags {
+		p.tags[strings.ToLower(tag)] = true
+	}
+	attributes := make(map[string][]string, len(defaultSanitizeAttributes))
+	for tag, attrs := range defaultSanitizeAttributes {
+		attributes[tag] = attrs
+	}
+	for tag, attrs := range cfg.Attributes {
+		attributes[strings.ToLower(tag)] = attrs
+	}
+	for tag, attrs := range attributes {
+		allowed := make(map[string]bool, len(attrs))
+		for _, a := range attrs {
+			allowed[strings.ToLower(a)] = true
+		}
+		p.attributes[tag] = allowed
+	}
+	return p
+}
+
+func (p *sanitizePolicy) allowsAttribute(tag string, attr html.Attribute) bool {
+	key := strings.ToLower(attr.Key)
+	if attr.Namespace != "" || strings.HasPrefix(key, "on") {
+		return false
+	}
+	if !p.attributes[tag][key] && !p.attributes["*"][key] {
+		return false
+	}
+	return !urlAttributes[key] || safeURL(attr.Val)
+}
+
+// safeURL reports whether u is relative or uses http, https or mailto
+func safeURL(u string) bool {
+	// Browsers ignore whitespace and control characters in the scheme
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, u)
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	return slices.Contains([]string{"http", "https", "mailto"}, strings.ToLower(u[:i]))
+}
+
+// sanitize writes the allowlisted parts of the HTML fragment src to buf.
+// Text is kept (escaped) when its element is removed, except inside raw
+// text elements like script and style; comments are dropped.
+func (p *sanitizePolicy) sanitize(buf *bytes.Buffer, src []byte) {
+	z := html.NewTokenizer(bytes.NewReader(src))
+	skip := ""
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		tok := z.Token()
+		if skip != "" {
+			if tt == html.EndTagToken && tok.Data == skip {
+				skip = ""
+			}
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			buf.WriteString(html.EscapeString(tok.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if !p.tags[tok.Data] {
+				switch tok.Data {
+				case "script", "style", "textarea", "title", "xmp", "iframe", "noembed", "noframes", "noscript", "plaintext":
+					if tt == html.StartTagToken {
+						skip = tok.Data
+					}
+				}
+				continue
+			}
+			attrs := tok.Attr[:0]
+			for _, a := range tok.Attr {
+				if p.allowsAttribute(tok.Data, a) {
+					attrs = append(attrs, a)
+				}
+			}
+			tok.Attr = attrs
+			buf.WriteString(tok.String())
+		case html.EndTagToken:
+			if p.tags[tok.Data] {
+				buf.WriteString(tok.String())
+			}
+		}
+	}
+}
+
+// sanitizeRenderer renders raw HTML in markdown through a sanitizePolicy
+type sanitizeRenderer struct {
+	policy *sanitizePolicy
+}
+
+func (r *sanitizeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, r.renderHTMLBlock)
+	reg.Register(ast.KindRawHTML, r.renderRawHTML)
+}
+
+func (r *sanitizeRenderer) renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.HTMLBlock)
+	var src, out bytes.Buffer
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		src.Write(line.Value(source))
+	}
+	if n.HasClosure() {
+		src.Write(n.ClosureLine.Value(source))
+	}
+	r.policy.sanitize(&out, src.Bytes())
+	_, _ = w.Write(out.Bytes())
+	return ast.WalkContinue, nil
+}
+
+func (r *sanitizeRenderer) renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkSkipChildren, nil
+	}
+	n := node.(*ast.RawHTML)
+	var src, out bytes.Buffer
+	for i := 0; i < n.Segments.Len(); i++ {
+		segment := n.Segments.At(i)
+		src.Write(segment.Value(source))
+	}
+	r.policy.sanitize(&out, src.Bytes())
+	_, _ = w.Write(out.Bytes())
+	return ast.WalkSkipChildren, nil
+}
+
+// sanitizeExtension enables rawHTML: sanitize
+type sanitizeExtension struct {
+	policy *sanitizePolicy
+}
+
+func (e *sanitizeExtension) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&sanitizeRenderer{policy: e.policy}, 500)))
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy config.SanitizeConfig
+		input  string
+		want   string
+	}{
+		{
+			name:  "allowed block keeps allowed attributes",
+			input: "<div class=\"note\" style=\"color:red\" onclick=\"steal()\">\n<b>Hi</b>\n</div>",
+			want:  "<div class=\"note\">\n<b>Hi</b>\n</div>",
+		},
+		{
+			name:  "script and its content are removed",
+			input: "<script>alert(1)</script>\n\nText",
+			want:  "<p>Text</p>",
+		},
+		{
+			name:  "unknown element keeps its text",
+			input: "<marquee>Moving</marquee>",
+			want:  "<p>Moving</p>",
+		},
+		{
+			name:  "inline HTML",
+			input: `A <span title="t" onmouseover="x()">word</span><iframe src="https://evil.example"></iframe>`,
+			want:  `<p>A <span title="t">word</span></p>`,
+		},
+		{
+			name:  "unsafe URLs",
+			input: `<a href=" JavaScript:alert(1)">x</a> <a href="/docs/?a=1#b">y</a> <img src="data:text/html;base64,xx" alt="i"> [z](javascript:alert(1))`,
+			want:  `<p><a>x</a> <a href="/docs/?a=1#b">y</a> <img alt="i"> <a href="">z</a></p>`,
+		},
+		{
+			name:  "comments are dropped",
+			input: "<!-- <script>x</script> -->\n\nText",
+			want:  "<p>Text</p>",
+		},
+		{
+			name:   "configured allowlist",
+			policy: config.SanitizeConfig{Tags: []string{"iframe", "b"}, Attributes: map[string][]string{"iframe": {"src", "onload"}}},
+			input:  `<iframe src="https://www.youtube.com/embed/x" onload="x()"></iframe> <b class="c">b</b> <i>i</i>`,
+			want:   `<iframe src="https://www.youtube.com/embed/x"></iframe> <b class="c">b</b> i`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &config.Config{Markdown: config.MarkdownConfig{RawHTML: "sanitize", Sanitize: tt.policy}}
+			var buf bytes.Buffer
+			if err := New(site, nil, &sync.Map{}, Media{}).Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	for u, want := range map[string]bool{
+		"https://example.com":   true,
+		"mailto:a@example.com":  true,
+		"/docs/page/":           true,
+		"#top":                  true,
+		"page?x=a:b":            true,
+		"javascript:alert(1)":   false,
+		"java\tscript:alert(1)": false,
+		"VBScript:x":            false,
+		"data:image/png;base64": false,
+	} {
+		if got := safeURL(u); got != want {
+			t.Errorf("safeURL(%q) = %v, want %v", u, got, want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	// templateShortcode is a shortcode on a line of its own: {{< name args >}}
+	templateShortcode = regexp.MustCompile(`^\{\{<\s*([\w-]+)(.*?)>\}\}\s*$`)
+	// shortcodeName finds the shortcodes a page uses, closing tags excluded
+	shortcodeName = regexp.MustCompile(`\{\{<\s*([\w-]+)`)
+	// shortcodeArg is one argument: name=value or a positional value, each
+	// quoted, backquoted or bare
+	shortcodeArg = regexp.MustCompile("(?:([\\w-]+)=)?(\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`|[^\\s\"`]+)")
+)
+
+// builtinShortcodes are parsed by their own extensions (or, for refs and
+// includes, resolved before parsing) and never rendered from a template
+var builtinShortcodes = []string{"gallery", "video", "audio", "ref", "relref", "versionref", "include"}
+
+// ShortcodeData is what a shortcode template is executed with
+type ShortcodeData struct {
+	Name    string
+	Params  map[string]string      // Named arguments
+	Args    []string               // Positional arguments
+	Inner   string                 // Text between the tags of a paired shortcode, as written
+	Page    map[string]interface{} // Frontmatter of the page
+	BaseURL string
+}
+
+// Get returns a positional argument by index or a named one by name, "" when
+// missing, like Hugo's .Get
+func (d ShortcodeData) Get(key interface{}) string {
+	switch k := key.(type) {
+	case int:
+		if k >= 0 && k < len(d.Args) {
+			return d.Args[k]
+		}
+	case string:
+		return d.Params[k]
+	}
+	return ""
+}
+
+// ShortcodeProvider renders a shortcode from the theme's templates. found is
+// false when the theme has no template of that name.
+type ShortcodeProvider interface {
+	Shortcode(name string, data ShortcodeData) (out string, found bool, err error)
+}
+
+// ShortcodeNames lists the shortcodes a page uses, without the ones built
+// into their own extensions, in order of first use
+func ShortcodeNames(source []byte) []string {
+	var names []string
+	for _, m := range shortcodeName.FindAllSubmatch(source, -1) {
+		name := string(m[1])
+		if !slices.Contains(builtinShortcodes, name) && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseShortcodeArgs splits the arguments of a shortcode into named and
+// positional ones
+func parseShortcodeArgs(s string) (map[string]string, []string) {
+	params := make(map[string]string)
+	var args []string
+	for _, m := range shortcodeArg.FindAllStringSubmatch(s, -1) {
+		value := m[2]
+		switch {
+		case strings.HasPrefix(value, `"`):
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else {
+				value = strings.Trim(value, `"`)
+			}
+		case strings.HasPrefix(value, "`"):
+			value = strings.Trim(value, "`")
+		}
+		if m[1] != "" {
+			params[m[1]] = value
+		} else {
+			args = append(args, value)
+		}
+	}
+	return params, args
+}
+
+// KindShortcode is the node kind of a template shortcode
+var KindShortcode = ast.NewNodeKind("Shortcode")
+
+// Shortcode is a `{{< name args >}}` shortcode rendered from
+// templates/shortcodes/<name>.html or a built-in (youtube, figure). A paired
+// shortcode keeps the lines up to its `{{< /name >}}` as Inner.
+type Shortcode struct {
+	ast.BaseBlock
+	Data   ShortcodeData
+	closer *regexp.Regexp // Closing tag line of a paired shortcode, nil when self-closing
+}
+
+func (n *Shortcode) Kind() ast.NodeKind { return KindShortcode }
+
+// IsRaw keeps the inner lines from being parsed as markdown
+func (n *Shortcode) IsRaw() bool { return true }
+
+func (n *Shortcode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.Data.Name, "Inner": n.Data.Inner}, nil)
+}
+
+type shortcodeParser struct {
+	baseURL string
+}
+
+func (p *shortcodeParser) Trigger() []byte { return []byte{'{'} }
+
+func (p *shortcodeParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	m := templateShortcode.FindSubmatch(util.TrimRightSpace(line))
+	if m == nil || slices.Contains(builtinShortcodes, string(m[1])) {
+		return nil, parser.NoChildren
+	}
+	name := string(m[1])
+	params, args := parseShortcodeArgs(string(m[2]))
+	node := &Shortcode{Data: ShortcodeData{Name: name, Params: params, Args: args, BaseURL: p.baseURL}}
+	reader.Advance(segment.Len() - 1)
+
+	// Paired when a closing tag follows on a line of its own
+	closer := `^[ \t]*\{\{<\s*/` + regexp.QuoteMeta(name) + `\s*>\}\}[ \t]*$`
+	if regexp.MustCompile("(?m)" + closer).Match(reader.Source()[segment.Stop:]) {
+		node.closer = regexp.MustCompile(closer)
+	}
+	return node, parser.NoChildren
+}
+
+func (p *shortcodeParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*Shortcode)
+	if n.closer == nil {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	// Up to the newline, which the block parser consumes itself
+	reader.Advance(len(util.TrimRightSpace(line)))
+	if n.closer.Match(util.TrimRightSpace(line)) {
+		return parser.Close
+	}
+	n.Lines().Append(segment)
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *shortcodeParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	n := node.(*Shortcode)
+	var inner strings.Builder
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		inner.Write(line.Value(reader.Source()))
+	}
+	n.Data.Inner = strings.TrimSuffix(inner.String(), "\n")
+}
+
+func (p *shortcodeParser) CanInterruptParagraph() bool { return true }
+
+// shortcodePageTransformer hands every shortcode the page's frontmatter once
+// the document is parsed: a shortcode right below the frontmatter opens
+// before the metadata is stored.
+type shortcodePageTransformer struct{}
+
+func (t *shortcodePageTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	page := meta.Get(pc)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if sc, ok := n.(*Shortcode); ok && entering {
+			sc.Data.Page = page
+		}
+		return ast.WalkContinue, nil
+	})
+}
+func (p *shortcodeParser) CanAcceptIndentedLine() bool { return false }
+
+type shortcodeRenderer struct {
+	provider ShortcodeProvider
+}
+
+func (r *shortcodeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindShortcode, r.render)
+}
+
+func (r *shortcodeRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	data := node.(*Shortcode).Data
+	if r.provider != nil {
+		out, found, err := r.provider.Shortcode(data.Name, data)
+		if err != nil {
+			log.Printf("   ⚠️  Shortcode %s: %v", data.Name, err)
+			_, _ = fmt.Fprintf(w, "<!-- shortcode %s: %s -->\n", html.EscapeString(data.Name), html.EscapeString(err.Error()))
+			return ast.WalkSkipChildren, nil
+		}
+		if found {
+			_, _ = w.WriteString(out)
+			return ast.WalkSkipChildren, nil
+		}
+	}
+	switch data.Name {
+	case "youtube":
+		renderYouTube(w, data)
+	case "figure":
+		renderFigure(w, data)
+	default:
+		log.Printf("   ⚠️  Unknown shortcode %s (no templates/shortcodes/%s.html)", data.Name, data.Name)
+		_, _ = fmt.Fprintf(w, "<!-- shortcode %s: not found -->\n", html.EscapeString(data.Name))
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// renderYouTube embeds a video without tracking cookies:
+// {{< youtube id >}} or {{< youtube id="..." title="..." start="30" >}}
+func renderYouTube(w util.BufWriter, data ShortcodeData) {
+	id := data.Get("id")
+	if id == "" {
+		id = data.Get(0)
+	}
+	if id == "" {
+		log.Printf("   ⚠️  YouTube shortcode without an id")
+		_, _ = w.WriteString("<!-- youtube: missing id -->\n")
+		return
+	}
+	src := "https://www.youtube-nocookie.com/embed/" + id
+	if start, err := strconv.Atoi(data.Get("start")); err == nil && start > 0 {
+		src += "?start=" + strconv.Itoa(start)
+	}
+	title := data.Get("title")
+	if title == "" {
+		title = "YouTube video"
+	}
+	_, _ = fmt.Fprintf(w, `<div class="video-embed" style="position:relative;aspect-ratio:16/9"><iframe src="%s" title="%s" style="position:absolute;inset:0;width:100%%;height:100%%;border:0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture" allowfullscreen loading="lazy"></iframe></div>`+"\n",
+		html.EscapeString(src), html.EscapeString(title))
+}
+
+// renderFigure writes an image with an optional caption:
+// {{< figure src="/static/images/a.webp" alt="..." caption="..." link="..." >}}
+func renderFigure(w util.BufWriter, data ShortcodeData) {
+	src := data.Get("src")
+	if src == "" {
+		log.Printf("   ⚠️  Figure shortcode without src=")
+		_, _ = w.WriteString("<!-- figure: missing src -->\n")
+		return
+	}
+	// Root-relative paths are made absolute like markdown images
+	if strings.HasPrefix(src, "/") && !strings.HasPrefix(src, "//") {
+		src = data.BaseURL + src
+	}
+	class := data.Get("class")
+	if class != "" {
+		_, _ = fmt.Fprintf(w, `<figure class="%s">`, html.EscapeString(class))
+	} else {
+		_, _ = w.WriteString("<figure>")
+	}
+	alt := data.Get("alt")
+	if alt == "" {
+		alt = data.Get("caption")
+	}
+	img := fmt.Sprintf(`<img src="%s" alt="%s" loading="lazy" decoding="async"`, html.EscapeString(src), html.EscapeString(alt))
+	for _, attr := range []string{"width", "height", "title"} {
+		if v := data.Get(attr); v != "" {
+			img += fmt.Sprintf(` %s="%s"`, attr, html.EscapeString(v))
+		}
+	}
+	img += ">"
+	if link := data.Get("link"); link != "" {
+		img = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link), img)
+	}
+	_, _ = w.WriteString(img)
+	if caption := data.Get("caption"); caption != "" {
+		_, _ = fmt.Fprintf(w, "<figcaption>%s</figcaption>", html.EscapeString(caption))
+	}
+	_, _ = w.WriteString("</figure>\n")
+}
+
+// shortcodeExtension adds template shortcodes. It runs after the gallery,
+// video and audio parsers, which keep their own names.
+type shortcodeExtension struct {
+	provider ShortcodeProvider
+	baseURL  string
+}
+
+func (e *shortcodeExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&shortcodeParser{baseURL: e.baseURL}, 160)),
+		parser.WithASTTransformers(util.Prioritized(&shortcodePageTransformer{}, 0)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&shortcodeRenderer{provider: e.provider}, 500)))
+}
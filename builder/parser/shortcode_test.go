@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	meta "github.com/yuin/goldmark-meta"
+)
+
+type fakeShortcodes struct {
+	calls []ShortcodeData
+}
+
+func (f *fakeShortcodes) Shortcode(name string, data ShortcodeData) (string, bool, error) {
+	f.calls = append(f.calls, data)
+	switch name {
+	case "note":
+		title, _ := data.Page["title"].(string)
+		return fmt.Sprintf("<aside class=%q>%s|%s</aside>\n", data.Get("type"), data.Inner, title), true, nil
+	case "broken":
+		return "", true, errors.New("template failed")
+	}
+	return "", false, nil
+}
+
+func TestTemplateShortcodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "theme template with inner text and page frontmatter",
+			input:   "---\ntitle: Guide\n---\n{{< note type=\"warning\" >}}\nMind the **gap**.\n{{< /note >}}\n\nAfter",
+			want:    []string{`<aside class="warning">Mind the **gap**.|Guide</aside>`, "<p>After</p>"},
+			notWant: []string{"{{&lt;"},
+		},
+		{
+			name:  "self-closing template",
+			input: "{{< note type=\"info\" >}}\n\nText",
+			want:  []string{`<aside class="info">|</aside>`, "<p>Text</p>"},
+		},
+		{
+			name:  "built-in youtube",
+			input: "{{< youtube abc123 >}}",
+			want:  []string{`src="https://www.youtube-nocookie.com/embed/abc123"`, `title="YouTube video"`},
+		},
+		{
+			name:  "built-in figure",
+			input: `{{< figure src="/static/a.webp" caption="A <b>" link="https://example.org" >}}`,
+			want:  []string{`<figure><a href="https://example.org"><img src="https://example.com/static/a.webp" alt="A &lt;b&gt;"`, "<figcaption>A &lt;b&gt;</figcaption></figure>"},
+		},
+		{
+			name:  "template error",
+			input: "{{< broken >}}",
+			want:  []string{"<!-- shortcode broken: template failed -->"},
+		},
+		{
+			name:  "unknown shortcode",
+			input: "{{< missing >}}",
+			want:  []string{"<!-- shortcode missing: not found -->"},
+		},
+		{
+			name:    "media shortcodes are left to their extensions",
+			input:   "{{< gallery dir=\"x\" >}}",
+			notWant: []string{"<!-- shortcode"},
+		},
+		{
+			name:    "inline mention is text",
+			input:   "Use `{{< note >}}` to warn.",
+			notWant: []string{"<aside"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := goldmark.New(goldmark.WithExtensions(meta.Meta, &shortcodeExtension{provider: &fakeShortcodes{}, baseURL: "https://example.com"}))
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output missing %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestParseShortcodeArgs(t *testing.T) {
+	params, args := parseShortcodeArgs(` abc "two words" title="Say \"hi\"" start=30 code=` + "`a b`")
+	if want := []string{"abc", "two words"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %q, want %q", args, want)
+	}
+	if want := map[string]string{"title": `Say "hi"`, "start": "30", "code": "a b"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %q, want %q", params, want)
+	}
+}
+
+func TestShortcodeNames(t *testing.T) {
+	source := []byte("{{< note >}}\nx\n{{< /note >}}\n{{< youtube id >}}\n{{< gallery dir=\"a\" >}}\n[x]({{< ref \"a.md\" >}})\n{{<note>}}")
+	if got, want := ShortcodeNames(source), []string{"note", "youtube"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ShortcodeNames() = %q, want %q", got, want)
+	}
+}
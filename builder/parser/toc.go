@@ -2,6 +2,7 @@ package parser
 
 import (
 	"strings"
+	"time"
 
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/parser"
@@ -13,6 +14,7 @@ import (
 var tocKey = parser.NewContextKey()
 var d2OrderedKey = parser.NewContextKey()
 var ssrHashesKey = parser.NewContextKey()
+var d2DurationKey = parser.NewContextKey()
 
 func GetTOC(pc parser.Context) []models.TOCEntry {
 	if v := pc.Get(tocKey); v != nil {
@@ -36,6 +38,14 @@ func GetSSRHashes(pc parser.Context) []string {
 	return nil
 }
 
+// GetD2Duration returns the time spent rendering the page's D2 diagrams
+func GetD2Duration(pc parser.Context) time.Duration {
+	if v := pc.Get(d2DurationKey); v != nil {
+		return v.(time.Duration)
+	}
+	return 0
+}
+
 // AddSSRHash adds an SSR input hash to the context
 func AddSSRHash(pc parser.Context, hash string) {
 	var hashes []string
@@ -5,6 +5,7 @@ import (
 	"log"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/parser"
@@ -61,6 +62,8 @@ func (t *ssrTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 	}
 
 	// 2. Render all blocks in parallel (or use cache)
+	start := time.Now()
+	defer func() { pc.Set(d2DurationKey, time.Since(start)) }()
 	results := make([]D2SVGPair, len(d2Blocks))
 	var wg sync.WaitGroup
 
@@ -35,13 +35,8 @@ func (t *urlTransformer) Transform(node *ast.Document, reader text.Reader, pc pa
 func (t *urlTransformer) processDestination(n ast.Node, dest []byte, pc parser.Context) {
 	href := string(dest)
 
-	// Handle External Links
-	if strings.HasPrefix(href, "http") {
-		if _, isLink := n.(*ast.Link); isLink {
-			n.SetAttribute([]byte("target"), []byte("_blank"))
-			n.SetAttribute([]byte("rel"), []byte("noopener noreferrer"))
-		}
-	} else {
+	// External links are decorated by externalLinkTransformer
+	if !strings.HasPrefix(href, "http") {
 		ext := strings.ToLower(filepath.Ext(href))
 		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" {
 			href = href[:len(href)-len(ext)] + ".webp"
@@ -113,6 +108,11 @@ func (t *urlTransformer) processDestination(n ast.Node, dest []byte, pc parser.C
 	}
 }
 
+// isSiteURL reports whether href is a full URL under the site's base URL
+func isSiteURL(baseURL, href string) bool {
+	return baseURL != "" && (href == baseURL || strings.HasPrefix(href, strings.TrimSuffix(baseURL, "/")+"/"))
+}
+
 // extractVersionFromPath extracts version from file path like "content/v2.0/page.md"
 func extractVersionFromPath(path string) string {
 	path = filepath.ToSlash(path)
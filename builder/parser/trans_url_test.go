@@ -266,3 +266,20 @@ func TestURLTransformer_VersionAwareLinking(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSiteURL(t *testing.T) {
+	tests := []struct {
+		href string
+		want bool
+	}{
+		{"https://example.com/docs/guides/install.html", true},
+		{"https://example.com/docs", true},
+		{"https://example.com/docsite/", false},
+		{"https://other.org/docs/", false},
+	}
+	for _, tt := range tests {
+		if got := isSiteURL("https://example.com/docs", tt.href); got != tt.want {
+			t.Errorf("isSiteURL(%q) = %v, want %v", tt.href, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"strings"
+
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+const (
+	nbsp       = "\u00a0" // Before : and inside « »
+	narrowNbsp = "\u202f" // Before ; ! ?
+)
+
+// The typographer marks what it replaced with these entities, which render
+// the English punctuation if left alone. The apostrophe gets a numeric
+// entity so it can be told apart from a closing single quote.
+// typographyTransformer swaps them all for characters.
+var typographerMarks = map[extension.TypographicPunctuation]string{
+	extension.LeftDoubleQuote:  "&ldquo;",
+	extension.RightDoubleQuote: "&rdquo;",
+	extension.LeftSingleQuote:  "&lsquo;",
+	extension.RightSingleQuote: "&rsquo;",
+	extension.Apostrophe:       "&#8217;",
+	extension.EnDash:           "&ndash;",
+	extension.EmDash:           "&mdash;",
+	extension.Ellipsis:         "&hellip;",
+	extension.LeftAngleQuote:   "&laquo;",
+	extension.RightAngleQuote:  "&raquo;",
+}
+
+// defaultTypography is how Kosh punctuates the languages it knows; the
+// markdown.typography config is applied on top
+var defaultTypography = map[string]config.TypographyConfig{
+	"en": {Quotes: "“”‘’"},
+	"de": {Quotes: "„“‚‘"},
+	"fr": {Quotes: "«»‹›", NBSP: boolPtr(true)},
+	"es": {Quotes: "«»“”", Dashes: "em"},
+	"it": {Quotes: "«»“”"},
+	"ru": {Quotes: "«»„“", Dashes: "em"},
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// resolveTypography merges the built-in and configured settings for lang,
+// trying the full code before the base language ("fr-CA", then "fr")
+func resolveTypography(configured map[string]config.TypographyConfig, lang string) config.TypographyConfig {
+	lang = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+	base, _, _ := strings.Cut(lang, "-")
+	if base == "" {
+		base = "en"
+	}
+
+	t := defaultTypography["en"]
+	for _, layer := range []config.TypographyConfig{defaultTypography[base], defaultTypography[lang], configured[base], configured[lang]} {
+		if layer.Quotes != "" {
+			t.Quotes = layer.Quotes
+		}
+		if layer.Dashes != "" {
+			t.Dashes = layer.Dashes
+		}
+		if layer.NBSP != nil {
+			t.NBSP = layer.NBSP
+		}
+	}
+	if len([]rune(t.Quotes)) != 4 {
+		t.Quotes = defaultTypography["en"].Quotes
+	}
+	return t
+}
+
+// typographyTransformer localizes the typographer's punctuation for the
+// page's language (lang frontmatter, else the site language)
+type typographyTransformer struct {
+	language   string
+	configured map[string]config.TypographyConfig
+}
+
+func (t *typographyTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	lang := t.language
+	if l, ok := meta.Get(pc)["lang"].(string); ok && l != "" {
+		lang = l
+	}
+	typo := resolveTypography(t.configured, lang)
+	withNBSP := typo.NBSP != nil && *typo.NBSP
+	quotes := []rune(typo.Quotes)
+
+	replace := map[string]string{
+		typographerMarks[extension.LeftDoubleQuote]:  string(quotes[0]),
+		typographerMarks[extension.RightDoubleQuote]: string(quotes[1]),
+		typographerMarks[extension.LeftSingleQuote]:  string(quotes[2]),
+		typographerMarks[extension.RightSingleQuote]: string(quotes[3]),
+		typographerMarks[extension.Apostrophe]:       "’",
+		typographerMarks[extension.EnDash]:           "–",
+		typographerMarks[extension.EmDash]:           "—",
+		typographerMarks[extension.Ellipsis]:         "…",
+		typographerMarks[extension.LeftAngleQuote]:   "«",
+		typographerMarks[extension.RightAngleQuote]:  "»",
+	}
+	switch typo.Dashes {
+	case "em":
+		replace[typographerMarks[extension.EnDash]] = "—"
+	case "none":
+		replace[typographerMarks[extension.EnDash]] = "--"
+		replace[typographerMarks[extension.EmDash]] = "---"
+	}
+	if withNBSP {
+		for mark, q := range replace {
+			switch q {
+			case "«", "‹":
+				replace[mark] = q + nbsp
+			case "»", "›":
+				replace[mark] = nbsp + q
+			}
+		}
+	}
+
+	var texts []*ast.Text
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan, *ast.CodeBlock, *ast.FencedCodeBlock:
+			return ast.WalkSkipChildren, nil
+		case *ast.String:
+			if r, ok := replace[string(n.Value)]; ok && n.IsCode() {
+				n.Value = []byte(r)
+			}
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	if withNBSP {
+		for _, n := range texts {
+			frenchSpacing(n, reader.Source())
+		}
+	}
+}
+
+// frenchSpacing turns the spaces a French text puts before ; : ! ? » and
+// after « into non-breaking ones, splitting the text node around them
+func frenchSpacing(n *ast.Text, source []byte) {
+	parent := n.Parent()
+	if parent == nil {
+		return
+	}
+	value := n.Segment.Value(source)
+	start := 0
+	var parts []ast.Node
+	for i := 0; i < len(value); i++ {
+		if value[i] != ' ' {
+			continue
+		}
+		var space string
+		rest, before := string(value[i+1:]), string(value[:i])
+		// Inline parsers split text at their trigger characters ("!" for
+		// images), so the punctuation may start the next node
+		if rest == "" && !n.SoftLineBreak() && !n.HardLineBreak() {
+			rest = inlineText(n.NextSibling(), source)
+		}
+		if before == "" {
+			before = inlineText(n.PreviousSibling(), source)
+		}
+		switch {
+		case strings.HasPrefix(rest, ";"), strings.HasPrefix(rest, "!"), strings.HasPrefix(rest, "?"):
+			space = narrowNbsp
+		case strings.HasPrefix(rest, ":"), strings.HasPrefix(rest, "»"), strings.HasPrefix(rest, "›"),
+			strings.HasSuffix(before, "«"), strings.HasSuffix(before, "‹"):
+			space = nbsp
+		default:
+			continue
+		}
+		if i > start {
+			parts = append(parts, ast.NewTextSegment(text.NewSegment(n.Segment.Start+start, n.Segment.Start+i)))
+		}
+		s := ast.NewString([]byte(space))
+		s.SetCode(true)
+		parts = append(parts, s)
+		start = i + 1
+	}
+	if parts == nil {
+		return
+	}
+
+	tail := ast.NewTextSegment(text.NewSegment(n.Segment.Start+start, n.Segment.Stop))
+	tail.SetSoftLineBreak(n.SoftLineBreak())
+	tail.SetHardLineBreak(n.HardLineBreak())
+	tail.SetRaw(n.IsRaw())
+	for _, p := range append(parts, tail) {
+		parent.InsertBefore(parent, n, p)
+	}
+	parent.RemoveChild(parent, n)
+}
+
+// inlineText returns the text of a Text or String node, "" for other nodes
+func inlineText(n ast.Node, source []byte) string {
+	switch n := n.(type) {
+	case *ast.Text:
+		return string(n.Segment.Value(source))
+	case *ast.String:
+		return string(n.Value)
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestTypography(t *testing.T) {
+	off := false
+	tests := []struct {
+		name     string
+		language string
+		custom   map[string]config.TypographyConfig
+		input    string
+		want     string
+	}{
+		{
+			name:  "english",
+			input: `"Quoted" and 'single' -- it's --- done...`,
+			want:  `<p>“Quoted” and ‘single’ – it’s — done…</p>`,
+		},
+		{
+			name:     "german",
+			language: "de-DE",
+			input:    `"Zitat" und 'halb'`,
+			want:     `<p>„Zitat“ und ‚halb‘</p>`,
+		},
+		{
+			name:     "french",
+			language: "fr",
+			input:    `Il dit "bonjour" : oui ! Vraiment ? « Déjà » ; c'est ça.`,
+			want:     "<p>Il dit «\u00a0bonjour\u00a0»\u00a0: oui\u202f! Vraiment\u202f? «\u00a0Déjà\u00a0»\u202f; c’est ça.</p>",
+		},
+		{
+			name:     "page language wins",
+			language: "en",
+			input:    "---\nlang: fr-CA\n---\nOui !",
+			want:     "<p>Oui\u202f!</p>",
+		},
+		{
+			name:     "configured override",
+			language: "fr",
+			custom:   map[string]config.TypographyConfig{"fr": {Quotes: "“”‘’", Dashes: "none", NBSP: &off}},
+			input:    `"Oui" -- non !`,
+			want:     `<p>“Oui” -- non !</p>`,
+		},
+		{
+			name:     "code is left alone",
+			language: "fr",
+			input:    "`a ? \"b\"` -- c",
+			want:     `<p><code>a ? &quot;b&quot;</code> – c</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := &config.Config{Language: tt.language, Markdown: config.MarkdownConfig{Typographer: true, Typography: tt.custom}}
+			var buf bytes.Buffer
+			if err := New(site, nil, &sync.Map{}, Media{}).Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
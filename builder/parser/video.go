@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var videoShortcode = regexp.MustCompile(`^\{\{<\s*video\b(.*?)>\}\}\s*$`)
+
+// VideoInfo is a published video
+type VideoInfo struct {
+	URL       string // Playable in browsers: the source itself or a transcode
+	Type      string // MIME type of URL
+	PosterURL string // Empty when no poster could be made
+	Width     int    // Of the video, 0 when unknown
+	Height    int
+}
+
+// VideoProvider publishes the video of a video shortcode and its poster
+// frame. poster is the shortcode's own poster= image, if any.
+type VideoProvider interface {
+	Video(src, poster string) (VideoInfo, error)
+}
+
+// KindVideo is the node kind of a video shortcode
+var KindVideo = ast.NewNodeKind("Video")
+
+// Video is a `{{< video src="static/videos/demo.mp4" >}}` shortcode
+type Video struct {
+	ast.BaseBlock
+	Src      string
+	Poster   string
+	Title    string // Accessible name
+	Autoplay bool   // Implies muted: browsers block autoplay with sound
+	Loop     bool
+	Muted    bool
+	Controls bool
+}
+
+func (n *Video) Kind() ast.NodeKind { return KindVideo }
+
+func (n *Video) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Src": n.Src, "Poster": n.Poster}, nil)
+}
+
+type videoParser struct{}
+
+func (p *videoParser) Trigger() []byte { return []byte{'{'} }
+
+func (p *videoParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	m := videoShortcode.FindSubmatch(util.TrimRightSpace(line))
+	if m == nil {
+		return nil, parser.NoChildren
+	}
+	attrs := parseShortcodeAttrs(string(m[1]))
+	flag := func(name string, def bool) bool {
+		if b, err := strconv.ParseBool(attrs[name]); err == nil {
+			return b
+		}
+		return def
+	}
+	node := &Video{
+		Src:      attrs["src"],
+		Poster:   attrs["poster"],
+		Title:    attrs["title"],
+		Autoplay: flag("autoplay", false),
+		Loop:     flag("loop", false),
+		Muted:    flag("muted", false),
+		Controls: flag("controls", true),
+	}
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *videoParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	return parser.Close
+}
+
+func (p *videoParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+func (p *videoParser) CanInterruptParagraph() bool                                { return true }
+func (p *videoParser) CanAcceptIndentedLine() bool                                { return false }
+
+type videoRenderer struct {
+	provider VideoProvider
+}
+
+func (r *videoRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindVideo, r.render)
+}
+
+func (r *videoRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	v := node.(*Video)
+	if r.provider == nil {
+		return ast.WalkContinue, nil
+	}
+	if v.Src == "" {
+		log.Printf("   ⚠️  Video without src=")
+		_, _ = w.WriteString("<!-- video: missing src -->\n")
+		return ast.WalkContinue, nil
+	}
+	info, err := r.provider.Video(v.Src, v.Poster)
+	if err != nil {
+		log.Printf("   ⚠️  Video %s: %v", v.Src, err)
+		_, _ = fmt.Fprintf(w, "<!-- video %s: %s -->\n", html.EscapeString(v.Src), html.EscapeString(err.Error()))
+		return ast.WalkContinue, nil
+	}
+
+	attrs := []string{"playsinline"}
+	if v.Controls {
+		attrs = append(attrs, "controls")
+	}
+	if v.Autoplay {
+		attrs = append(attrs, "autoplay", "muted")
+	} else if v.Muted {
+		attrs = append(attrs, "muted")
+	}
+	if v.Loop {
+		attrs = append(attrs, "loop")
+	}
+	// Nothing is downloaded until play when there is a poster to show;
+	// without one, metadata gives the browser the first frame and size
+	switch {
+	case v.Autoplay:
+	case info.PosterURL != "":
+		attrs = append(attrs, `preload="none"`)
+	default:
+		attrs = append(attrs, `preload="metadata"`)
+	}
+	if info.PosterURL != "" {
+		attrs = append(attrs, fmt.Sprintf(`poster="%s"`, html.EscapeString(info.PosterURL)))
+	}
+	if info.Width > 0 && info.Height > 0 {
+		attrs = append(attrs, fmt.Sprintf(`width="%d" height="%d"`, info.Width, info.Height))
+	}
+	if v.Title != "" {
+		attrs = append(attrs, fmt.Sprintf(`aria-label="%s"`, html.EscapeString(v.Title)))
+	}
+
+	url := html.EscapeString(info.URL)
+	_, _ = fmt.Fprintf(w, `<figure class="video"><video %s style="display:block;max-width:100%%;height:auto">`, strings.Join(attrs, " "))
+	_, _ = fmt.Fprintf(w, `<source src="%s" type="%s"><a href="%s">Download the video</a></video></figure>`+"\n", url, html.EscapeString(info.Type), url)
+	return ast.WalkContinue, nil
+}
+
+// videoExtension adds the video shortcode
+type videoExtension struct {
+	provider VideoProvider
+}
+
+func (e *videoExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(util.Prioritized(&videoParser{}, 150)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&videoRenderer{provider: e.provider}, 500)))
+}
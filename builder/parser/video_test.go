@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+type fakeVideo struct {
+	src, poster string
+}
+
+func (f *fakeVideo) Video(src, poster string) (VideoInfo, error) {
+	f.src, f.poster = src, poster
+	switch src {
+	case "static/missing.mp4":
+		return VideoInfo{}, errors.New("no such file")
+	case "static/noposter.webm":
+		return VideoInfo{URL: "/static/noposter.webm", Type: "video/webm"}, nil
+	}
+	return VideoInfo{URL: "/static/demo.mp4", Type: "video/mp4", PosterURL: "/static/posters/demo.webp", Width: 1280, Height: 720}, nil
+}
+
+func TestVideoShortcode(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantSrc    string
+		wantPoster string
+		want       []string
+		notWant    []string
+	}{
+		{
+			name:    "poster frame",
+			input:   "Intro\n\n{{< video src=\"static/demo.mp4\" title=\"Demo\" >}}\n\nOutro",
+			wantSrc: "static/demo.mp4",
+			want: []string{
+				`<video playsinline controls preload="none" poster="/static/posters/demo.webp" width="1280" height="720" aria-label="Demo"`,
+				`<source src="/static/demo.mp4" type="video/mp4">`,
+				"<p>Outro</p>",
+			},
+			notWant: []string{"{{&lt;", "autoplay"},
+		},
+		{
+			name:       "own poster, autoplay loop",
+			input:      "{{<video src=\"static/demo.mp4\" poster=\"static/p.jpg\" autoplay=\"true\" loop=\"true\" controls=\"false\">}}",
+			wantSrc:    "static/demo.mp4",
+			wantPoster: "static/p.jpg",
+			want:       []string{`<video playsinline autoplay muted loop poster=`},
+			notWant:    []string{"controls", "preload"},
+		},
+		{
+			name:    "no poster loads metadata",
+			input:   "{{< video src=\"static/noposter.webm\" >}}",
+			wantSrc: "static/noposter.webm",
+			want:    []string{`preload="metadata"`, `type="video/webm"`},
+			notWant: []string{"poster=", "width="},
+		},
+		{
+			name:    "provider error",
+			input:   "{{< video src=\"static/missing.mp4\" >}}",
+			wantSrc: "static/missing.mp4",
+			want:    []string{"<!-- video static/missing.mp4: no such file -->"},
+			notWant: []string{"<video"},
+		},
+		{
+			name:    "missing src",
+			input:   "{{< video >}}",
+			want:    []string{"<!-- video: missing src -->"},
+			notWant: []string{"<video"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeVideo{}
+			md := goldmark.New(goldmark.WithExtensions(&videoExtension{provider: provider}))
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(tt.input), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			if provider.src != tt.wantSrc || provider.poster != tt.wantPoster {
+				t.Errorf("provider called with %q, %q; want %q, %q", provider.src, provider.poster, tt.wantSrc, tt.wantPoster)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output missing %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
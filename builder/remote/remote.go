@@ -0,0 +1,221 @@
+// Package remote fetches data over HTTP at build time for the getRemote,
+// getJSON and data template functions. Responses are cached on disk and
+// revalidated with ETag/Last-Modified, so unchanged data costs a 304.
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+)
+
+// Dir is the subdirectory of the cache directory that holds remote responses
+const Dir = "remote"
+
+// maxBodySize caps a single response to keep a bad URL from exhausting memory
+const maxBodySize = 20 * 1024 * 1024
+
+// ErrOffline is returned in offline mode when a URL has never been fetched
+var ErrOffline = errors.New("offline mode: no cached response")
+
+// entryMeta is stored next to each cached body
+type entryMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+// Client fetches and caches remote data. Each URL is fetched at most once per
+// Client, so templates may call getJSON on every page without extra requests.
+// Pages rendering in parallel wait for a fetch of the same URL in progress,
+// never for fetches of other URLs.
+type Client struct {
+	dir     string
+	offline bool
+	http    *http.Client
+	sources map[string]string
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	memo     map[string][]byte
+	inflight map[string]*call
+}
+
+// call is a fetch in progress; done is closed once body and err are set
+type call struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// New creates a Client caching under cacheDir/remote.
+// sources maps data source names to URLs for Data.
+func New(cacheDir string, timeout time.Duration, offline bool, sources map[string]string, logger *slog.Logger) *Client {
+	return &Client{
+		dir:      filepath.Join(cacheDir, Dir),
+		offline:  offline,
+		http:     &http.Client{Timeout: timeout},
+		sources:  sources,
+		logger:   logger,
+		memo:     make(map[string][]byte),
+		inflight: make(map[string]*call),
+	}
+}
+
+// GetRemote returns the response body of url as a string
+func (c *Client) GetRemote(url string) (string, error) {
+	body, err := c.get(url)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// GetJSON fetches url and decodes the JSON response
+func (c *Client) GetJSON(url string) (interface{}, error) {
+	body, err := c.get(url)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("invalid JSON from %s: %w", url, err)
+	}
+	return v, nil
+}
+
+// Data fetches a configured data source by name and decodes it as JSON
+func (c *Client) Data(name string) (interface{}, error) {
+	url, ok := c.sources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown data source %q", name)
+	}
+	return c.GetJSON(url)
+}
+
+func (c *Client) get(url string) ([]byte, error) {
+	c.mu.Lock()
+	if body, ok := c.memo[url]; ok {
+		c.mu.Unlock()
+		return body, nil
+	}
+	if running, ok := c.inflight[url]; ok {
+		c.mu.Unlock()
+		<-running.done
+		return running.body, running.err
+	}
+	fetch := &call{done: make(chan struct{})}
+	c.inflight[url] = fetch
+	c.mu.Unlock()
+
+	fetch.body, fetch.err = c.fetch(url)
+
+	c.mu.Lock()
+	delete(c.inflight, url)
+	if fetch.err == nil {
+		c.memo[url] = fetch.body
+	}
+	c.mu.Unlock()
+	close(fetch.done)
+	return fetch.body, fetch.err
+}
+
+// fetch revalidates the cached copy of url. Network failures fall back to the
+// cached body when there is one, so a flaky API doesn't break the build.
+func (c *Client) fetch(url string) ([]byte, error) {
+	key := cache.HashString(url)
+	bodyPath := filepath.Join(c.dir, key+".body")
+	metaPath := filepath.Join(c.dir, key+".json")
+
+	cached, cachedErr := os.ReadFile(bodyPath)
+	var meta entryMeta
+	if cachedErr == nil {
+		if data, err := os.ReadFile(metaPath); err == nil {
+			_ = json.Unmarshal(data, &meta)
+		}
+	}
+
+	if c.offline {
+		if cachedErr != nil {
+			return nil, fmt.Errorf("%w for %s", ErrOffline, url)
+		}
+		return cached, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cachedErr == nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return c.fallback(url, cached, cachedErr, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotModified && cachedErr == nil {
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return c.fallback(url, cached, cachedErr, fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return c.fallback(url, cached, cachedErr, err)
+	}
+	if len(body) > maxBodySize {
+		return nil, fmt.Errorf("response from %s too large (over %d MB)", url, maxBodySize>>20)
+	}
+
+	meta = entryMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	if err := c.store(bodyPath, metaPath, body, meta); err != nil {
+		c.logger.Warn("Failed to cache remote response", "url", url, "error", err)
+	}
+	return body, nil
+}
+
+func (c *Client) fallback(url string, cached []byte, cachedErr, fetchErr error) ([]byte, error) {
+	if cachedErr != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, fetchErr)
+	}
+	c.logger.Warn("Remote fetch failed, using cached response", "url", url, "error", fetchErr)
+	return cached, nil
+}
+
+func (c *Client) store(bodyPath, metaPath string, body []byte, meta entryMeta) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(bodyPath, body, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, data, 0644)
+}
@@ -0,0 +1,167 @@
+package remote
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestClientETagRevalidation(t *testing.T) {
+	var hits, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"version":"1.2.3"}`))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	logger := newTestLogger()
+
+	c := New(cacheDir, time.Second, false, map[string]string{"release": srv.URL}, logger)
+	v, err := c.Data("release")
+	if err != nil {
+		t.Fatalf("Data() error = %v", err)
+	}
+	if v.(map[string]interface{})["version"] != "1.2.3" {
+		t.Errorf("Data() = %v", v)
+	}
+
+	// Same client memoizes
+	if _, err := c.GetJSON(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("hits = %d, want 1 (memoized)", hits.Load())
+	}
+
+	// A new build revalidates with the stored ETag
+	c2 := New(cacheDir, time.Second, false, nil, logger)
+	body, err := c2.GetRemote(srv.URL)
+	if err != nil {
+		t.Fatalf("GetRemote() error = %v", err)
+	}
+	if body != `{"version":"1.2.3"}` {
+		t.Errorf("GetRemote() = %q", body)
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("notModified = %d, want 1", notModified.Load())
+	}
+}
+
+func TestClientOffline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	logger := newTestLogger()
+
+	offline := New(cacheDir, time.Second, true, nil, logger)
+	if _, err := offline.GetRemote(srv.URL); !errors.Is(err, ErrOffline) {
+		t.Fatalf("GetRemote() offline without cache error = %v, want ErrOffline", err)
+	}
+
+	if _, err := New(cacheDir, time.Second, false, nil, logger).GetRemote(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	body, err := New(cacheDir, time.Second, true, nil, logger).GetRemote(srv.URL)
+	if err != nil || body != "hello" {
+		t.Errorf("GetRemote() offline with cache = %q, %v", body, err)
+	}
+}
+
+func TestClientFallbackOnError(t *testing.T) {
+	fail := atomic.Bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("cached"))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	logger := newTestLogger()
+
+	if _, err := New(cacheDir, time.Second, false, nil, logger).GetRemote(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	fail.Store(true)
+
+	body, err := New(cacheDir, time.Second, false, nil, logger).GetRemote(srv.URL)
+	if err != nil || body != "cached" {
+		t.Errorf("GetRemote() after server error = %q, %v; want cached body", body, err)
+	}
+
+	if _, err := New(t.TempDir(), time.Second, false, nil, logger).GetRemote(srv.URL); err == nil {
+		t.Error("GetRemote() should fail without a cached copy")
+	}
+}
+
+func TestClientDedupesConcurrentFetches(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			hits.Add(1)
+			<-release
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	c := New(t.TempDir(), 5*time.Second, false, nil, newTestLogger())
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := c.GetRemote(srv.URL + "/slow"); err != nil || body != "/slow" {
+				t.Errorf("GetRemote(/slow) = %q, %v", body, err)
+			}
+		}()
+	}
+
+	// Another URL isn't held up by the slow fetch
+	if body, err := c.GetRemote(srv.URL + "/fast"); err != nil || body != "/fast" {
+		t.Errorf("GetRemote(/fast) = %q, %v", body, err)
+	}
+	close(release)
+	wg.Wait()
+	if hits.Load() != 1 {
+		t.Errorf("/slow fetched %d times, want 1", hits.Load())
+	}
+}
+
+func TestClientRejectsLargeResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, maxBodySize+1))
+	}))
+	defer srv.Close()
+
+	_, err := New(t.TempDir(), 5*time.Second, false, nil, newTestLogger()).GetRemote(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("GetRemote() error = %v, want a response too large error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package renderer
+
+import (
+	"html"
+	"slices"
+	"strings"
+)
+
+// SetContentSecurityPolicy sets the policy emitted as a
+// <meta http-equiv="Content-Security-Policy"> tag on every rendered page.
+// Pages with a comment widget get the widget's sources added. An empty
+// policy emits no tag, as a policy made only of widget sources would block
+// the site's own scripts.
+func (r *Renderer) SetContentSecurityPolicy(policy string) {
+	r.csp = strings.TrimSpace(policy)
+}
+
+// cspMeta returns the meta tag for the site policy merged with directives,
+// nil when no site policy is set
+func (r *Renderer) cspMeta(directives string) []byte {
+	if r.csp == "" {
+		return nil
+	}
+	return []byte(`<meta http-equiv="Content-Security-Policy" content="` +
+		html.EscapeString(mergeCSP(r.csp, directives)) + `">`)
+}
+
+type cspDirective struct {
+	name    string
+	sources []string
+}
+
+// mergeCSP adds the sources of directives to policy. A directive missing from
+// policy starts from the default-src sources, since that is what the browser
+// falls back to; without default-src the directive is unrestricted and left
+// out. 'none' is dropped once a source is added.
+func mergeCSP(policy, directives string) string {
+	base := parseCSP(policy)
+	for _, add := range parseCSP(directives) {
+		i := slices.IndexFunc(base, func(d cspDirective) bool { return d.name == add.name })
+		if i < 0 {
+			def := slices.IndexFunc(base, func(d cspDirective) bool { return d.name == "default-src" })
+			if def < 0 {
+				continue
+			}
+			base = append(base, cspDirective{name: add.name, sources: slices.Clone(base[def].sources)})
+			i = len(base) - 1
+		}
+		d := &base[i]
+		d.sources = slices.DeleteFunc(d.sources, func(s string) bool { return s == "'none'" })
+		for _, s := range add.sources {
+			if !slices.Contains(d.sources, s) {
+				d.sources = append(d.sources, s)
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(base))
+	for _, d := range base {
+		parts = append(parts, strings.Join(append([]string{d.name}, d.sources...), " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func parseCSP(policy string) []cspDirective {
+	var out []cspDirective
+	for _, part := range strings.Split(policy, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		out = append(out, cspDirective{name: strings.ToLower(fields[0]), sources: fields[1:]})
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestMergeCSP(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		directives string
+		want       string
+	}{
+		{
+			name:   "no widget",
+			policy: "default-src 'self'",
+			want:   "default-src 'self'",
+		},
+		{
+			name:       "directive from default-src",
+			policy:     "default-src 'self'; img-src 'self' data:",
+			directives: "script-src https://giscus.app; frame-src https://giscus.app",
+			want:       "default-src 'self'; img-src 'self' data:; script-src 'self' https://giscus.app; frame-src 'self' https://giscus.app",
+		},
+		{
+			name:       "existing directive",
+			policy:     "default-src 'self'; script-src 'self' https://giscus.app; frame-src 'none'",
+			directives: "script-src https://giscus.app; frame-src https://giscus.app",
+			want:       "default-src 'self'; script-src 'self' https://giscus.app; frame-src https://giscus.app",
+		},
+		{
+			name:       "unrestricted directive",
+			policy:     "img-src 'self'",
+			directives: "script-src https://utteranc.es",
+			want:       "img-src 'self'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCSP(tt.policy, tt.directives); got != tt.want {
+				t.Errorf("mergeCSP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPageCSP(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `<html><head><title>{{ .Title }}</title></head><body>{{ .Comments }}</body></html>`)
+
+	fs := afero.NewMemMapFs()
+	r := New(false, fs, []string{dir}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	data := models.PageData{Title: "Post", CommentsCSP: "script-src https://giscus.app; frame-src https://giscus.app"}
+
+	r.RenderPage("public/none.html", data)
+	if got, _ := afero.ReadFile(fs, "public/none.html"); string(got) != `<html><head><title>Post</title></head><body></body></html>` {
+		t.Errorf("page without a site policy = %q, want no CSP tag", got)
+	}
+
+	r.SetContentSecurityPolicy("default-src 'self'")
+	r.SetHeadSnippet("<script>s</script>")
+	r.RenderPage("public/post.html", data)
+	want := `<html><head><title>Post</title>` +
+		`<meta http-equiv="Content-Security-Policy" content="default-src &#39;self&#39;; script-src &#39;self&#39; https://giscus.app; frame-src &#39;self&#39; https://giscus.app">` +
+		`<script>s</script></head><body></body></html>`
+	if got, _ := afero.ReadFile(fs, "public/post.html"); string(got) != want {
+		t.Errorf("post page = %q, want %q", got, want)
+	}
+
+	r.RenderIndex("public/index.html", models.PageData{Title: "Home"})
+	want = `<html><head><title>Home</title>` +
+		`<meta http-equiv="Content-Security-Policy" content="default-src &#39;self&#39;">` +
+		`<script>s</script></head><body></body></html>`
+	if got, _ := afero.ReadFile(fs, "public/index.html"); string(got) != want {
+		t.Errorf("list page = %q, want %q", got, want)
+	}
+}
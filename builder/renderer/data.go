@@ -0,0 +1,33 @@
+package renderer
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// DataFetcher backs the getRemote, getJSON and data template functions
+type DataFetcher interface {
+	GetRemote(url string) (string, error)
+	GetJSON(url string) (interface{}, error)
+	Data(name string) (interface{}, error)
+}
+
+type fetcherHolder struct {
+	fetcher DataFetcher
+}
+
+// activeFetcher is process-wide because parsed templates (and the functions
+// bound to them) are shared through the global template cache
+var activeFetcher atomic.Value
+
+var errNoDataFetcher = errors.New("remote data is not available in this build")
+
+// SetDataFetcher sets the fetcher used by templates for the current build
+func SetDataFetcher(f DataFetcher) {
+	activeFetcher.Store(fetcherHolder{fetcher: f})
+}
+
+func currentDataFetcher() DataFetcher {
+	h, _ := activeFetcher.Load().(fetcherHolder)
+	return h.fetcher
+}
@@ -0,0 +1,75 @@
+package renderer
+
+import (
+	"bytes"
+	"io"
+)
+
+var headClose = []byte("</head>")
+
+// noIndexMeta keeps search engines away from pages that must not be listed
+var noIndexMeta = []byte(`<meta name="robots" content="noindex, nofollow">`)
+
+// headInjector inserts a snippet right before the first </head> written
+// through it. Output is held back only until </head> has been seen.
+type headInjector struct {
+	w       io.Writer
+	snippet []byte
+	pending []byte
+	done    bool
+}
+
+// SetHeadSnippet sets markup injected into the <head> of every rendered page
+// (e.g. the analytics script). An empty snippet disables injection.
+func (r *Renderer) SetHeadSnippet(snippet string) {
+	r.headSnippet = []byte(snippet)
+}
+
+// wrapHead returns w wrapped with head injection and a flush func that must
+// run after the template executes. csp holds the comment widget's directives
+// for the page, merged into the site policy; the policy tag goes first so
+// it covers the head snippet.
+func (r *Renderer) wrapHead(w io.Writer, csp string) (io.Writer, func()) {
+	snippet := r.cspMeta(csp)
+	if len(snippet) == 0 {
+		snippet = r.headSnippet
+	} else {
+		snippet = append(snippet, r.headSnippet...)
+	}
+	if len(snippet) == 0 {
+		return w, func() {}
+	}
+	h := &headInjector{w: w, snippet: snippet}
+	return h, h.flush
+}
+
+func (h *headInjector) Write(p []byte) (int, error) {
+	if h.done {
+		return h.w.Write(p)
+	}
+
+	h.pending = append(h.pending, p...)
+	idx := bytes.Index(h.pending, headClose)
+	if idx < 0 {
+		return len(p), nil
+	}
+
+	h.done = true
+	out := make([]byte, 0, len(h.pending)+len(h.snippet))
+	out = append(out, h.pending[:idx]...)
+	out = append(out, h.snippet...)
+	out = append(out, h.pending[idx:]...)
+	h.pending = nil
+	if _, err := h.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush writes held-back output of a page that had no </head>
+func (h *headInjector) flush() {
+	if !h.done && len(h.pending) > 0 {
+		_, _ = h.w.Write(h.pending)
+		h.pending = nil
+	}
+}
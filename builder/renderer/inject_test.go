@@ -0,0 +1,48 @@
+package renderer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHeadInjector(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "split closing tag",
+			chunks: []string{"<html><head><title>x</title></he", "ad><body>hi</body></html>"},
+			want:   "<html><head><title>x</title><script>s</script></head><body>hi</body></html>",
+		},
+		{
+			name:   "only first head",
+			chunks: []string{"<head></head>", "<pre></head></pre>"},
+			want:   "<head><script>s</script></head><pre></head></pre>",
+		},
+		{
+			name:   "no head",
+			chunks: []string{"<p>", "fragment</p>"},
+			want:   "<p>fragment</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r := &Renderer{}
+			r.SetHeadSnippet("<script>s</script>")
+			w, flush := r.wrapHead(&out, "")
+			for _, c := range tt.chunks {
+				if _, err := w.Write([]byte(c)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			flush()
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
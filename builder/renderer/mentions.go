@@ -0,0 +1,30 @@
+package renderer
+
+import (
+	"sync/atomic"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// MentionSource backs the webmentions template function
+type MentionSource interface {
+	Mentions(target string) []models.Webmention
+}
+
+type mentionHolder struct {
+	source MentionSource
+}
+
+// activeMentions is process-wide for the same reason as activeFetcher
+var activeMentions atomic.Value
+
+// SetMentionSource sets the webmention source for the current build.
+// A nil source makes the webmentions function return no mentions.
+func SetMentionSource(m MentionSource) {
+	activeMentions.Store(mentionHolder{source: m})
+}
+
+func currentMentionSource() MentionSource {
+	h, _ := activeMentions.Load().(mentionHolder)
+	return h.source
+}
@@ -0,0 +1,303 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	xhtml "golang.org/x/net/html"
+)
+
+var (
+	fontFaceRe = regexp.MustCompile(`(?s)@font-face\s*\{[^}]*\}`)
+	cssURLRe   = regexp.MustCompile(`url\(\s*['"]?([^'")\s]+)['"]?\s*\)`)
+)
+
+// preloadHint is one <link rel="preload"> (or modulepreload) in a page's head
+type preloadHint struct {
+	Href   string
+	As     string // "style", "font", "image", "script", "fetch", or "module" for modulepreload
+	Type   string
+	SrcSet string
+	Sizes  string
+}
+
+func (h preloadHint) markup() string {
+	if h.As == "module" {
+		return `<link rel="modulepreload" href="` + html.EscapeString(h.Href) + `">`
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<link rel="preload" href="%s" as="%s"`, html.EscapeString(h.Href), html.EscapeString(h.As))
+	if h.Type != "" {
+		fmt.Fprintf(&b, ` type="%s"`, html.EscapeString(h.Type))
+	}
+	if h.SrcSet != "" {
+		fmt.Fprintf(&b, ` imagesrcset="%s"`, html.EscapeString(h.SrcSet))
+		if h.Sizes != "" {
+			fmt.Fprintf(&b, ` imagesizes="%s"`, html.EscapeString(h.Sizes))
+		}
+	}
+	if h.As == "font" || h.As == "fetch" {
+		b.WriteString(" crossorigin") // Fonts and fetches are CORS requests; without it the preload is wasted
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// hintFor guesses how a URL is preloaded from its extension
+func hintFor(href string) preloadHint {
+	ext := strings.ToLower(path.Ext(strings.SplitN(strings.SplitN(href, "?", 2)[0], "#", 2)[0]))
+	switch ext {
+	case ".css":
+		return preloadHint{Href: href, As: "style"}
+	case ".js":
+		return preloadHint{Href: href, As: "script"}
+	case ".mjs":
+		return preloadHint{Href: href, As: "module"}
+	case ".woff2", ".woff", ".ttf", ".otf":
+		return preloadHint{Href: href, As: "font", Type: "font/" + ext[1:]}
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg":
+		return preloadHint{Href: href, As: "image"}
+	}
+	return preloadHint{Href: href, As: "fetch"}
+}
+
+// preloader works out the preload hints of rendered pages
+type preloader struct {
+	fs        afero.Fs
+	outputDir string
+	maxFonts  int
+	fonts     sync.Map // Stylesheet file → fonts of its @font-face rules
+}
+
+// EnablePreload makes RenderPage add preload hints for each page's critical
+// resources. Stylesheets are read from outputDir for their fonts, so assets
+// must be built before pages are rendered.
+func (r *Renderer) EnablePreload(outputDir string, maxFonts int) {
+	r.preload = &preloader{fs: r.DestFs, outputDir: outputDir, maxFonts: maxFonts}
+}
+
+// pageHints returns where the hints go in page and the hints: the first
+// local stylesheet, fonts it declares, the hero image (an image in <main> or
+// <article> before its first <h2>), module scripts, the search index on
+// search pages, then the page's own preload: frontmatter. URLs the head
+// already preloads are left out.
+func (p *preloader) pageHints(page []byte, baseURL, layout string, extra []preloadHint) (int, []preloadHint) {
+	var (
+		hints    []preloadHint
+		preloads = map[string]bool{}
+		insertAt = -1
+		headEnd  = -1
+		offset   int
+		inHead   bool
+		content  int  // Depth of <main>/<article>
+		pastTop  bool // A content <h2> or image was seen
+		styled   bool
+		fonts    int
+	)
+	z := xhtml.NewTokenizer(bytes.NewReader(page))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+		start := offset
+		offset += len(z.Raw())
+		if tt != xhtml.StartTagToken && tt != xhtml.SelfClosingTagToken && tt != xhtml.EndTagToken {
+			continue
+		}
+		name, _ := z.TagName()
+		tag := string(name)
+		if tt == xhtml.EndTagToken {
+			switch tag {
+			case "head":
+				inHead, headEnd = false, start
+			case "main", "article":
+				content--
+			}
+			continue
+		}
+		attrs := tagAttrs(z)
+		switch {
+		case tag == "head":
+			inHead = true
+		case inHead && (tag == "link" || tag == "script" || tag == "style"):
+			if insertAt < 0 {
+				insertAt = start
+			}
+			rel := strings.ToLower(attrs["rel"])
+			switch {
+			case tag == "link" && (rel == "preload" || rel == "modulepreload"):
+				preloads[attrs["href"]] = true
+			case tag == "link" && rel == "stylesheet" && isLocalURL(attrs["href"], baseURL):
+				if !styled {
+					styled = true
+					hints = append(hints, preloadHint{Href: attrs["href"], As: "style"})
+				}
+				for _, font := range p.stylesheetFonts(attrs["href"], baseURL) {
+					if fonts < p.maxFonts {
+						hints = append(hints, hintFor(font))
+						fonts++
+					}
+				}
+			case tag == "script" && attrs["type"] == "module" && attrs["src"] != "":
+				hints = append(hints, preloadHint{Href: attrs["src"], As: "module"})
+			}
+		case tag == "main" || tag == "article":
+			content++
+		case content > 0 && tag == "h2":
+			pastTop = true
+		case content > 0 && tag == "img" && !pastTop:
+			pastTop = true
+			if src := attrs["src"]; src != "" && !strings.HasPrefix(src, "data:") {
+				hints = append(hints, preloadHint{Href: src, As: "image", SrcSet: attrs["srcset"], Sizes: attrs["sizes"]})
+			}
+		}
+	}
+	if layout == "search" {
+		hints = append(hints, preloadHint{Href: baseURL + "/search.bin", As: "fetch"})
+	}
+	hints = append(hints, extra...)
+
+	if insertAt < 0 {
+		insertAt = headEnd
+	}
+	if insertAt < 0 {
+		return -1, nil
+	}
+	var out []preloadHint
+	for _, h := range hints {
+		if h.Href != "" && !preloads[h.Href] {
+			preloads[h.Href] = true
+			out = append(out, h)
+		}
+	}
+	return insertAt, out
+}
+
+// stylesheetFonts returns the fonts a built stylesheet declares, the first
+// woff2 (else any) source of each @font-face rule, as URLs
+func (p *preloader) stylesheetFonts(href, baseURL string) []string {
+	urlPath := strings.SplitN(strings.TrimPrefix(href, baseURL), "?", 2)[0]
+	file := filepath.Join(p.outputDir, filepath.FromSlash(urlPath))
+	info, err := p.fs.Stat(file)
+	if err != nil {
+		return nil
+	}
+	key := fmt.Sprintf("%s:%d:%d", file, info.Size(), info.ModTime().UnixNano())
+	if fonts, ok := p.fonts.Load(key); ok {
+		return fonts.([]string)
+	}
+	css, err := afero.ReadFile(p.fs, file)
+	if err != nil {
+		return nil
+	}
+	var fonts []string
+	for _, face := range fontFaceRe.FindAll(css, -1) {
+		var src string
+		for _, m := range cssURLRe.FindAllSubmatch(face, -1) {
+			u := string(m[1])
+			if strings.HasPrefix(u, "data:") {
+				continue
+			}
+			if src == "" || strings.HasSuffix(strings.ToLower(u), ".woff2") && !strings.HasSuffix(strings.ToLower(src), ".woff2") {
+				src = u
+			}
+		}
+		switch {
+		case src == "":
+			continue
+		case strings.Contains(src, "://") || strings.HasPrefix(src, "//"):
+		case strings.HasPrefix(src, "/"):
+			src = baseURL + src
+		default:
+			src = baseURL + path.Join(path.Dir(urlPath), src)
+		}
+		fonts = append(fonts, src)
+	}
+	p.fonts.Store(key, fonts)
+	return fonts
+}
+
+// isLocalURL reports whether href is served from the site's output
+func isLocalURL(href, baseURL string) bool {
+	if baseURL != "" && strings.HasPrefix(href, strings.TrimSuffix(baseURL, "/")+"/") {
+		return true
+	}
+	return strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//")
+}
+
+func tagAttrs(z *xhtml.Tokenizer) map[string]string {
+	attrs := map[string]string{}
+	for {
+		key, val, more := z.TagAttr()
+		if len(key) > 0 {
+			attrs[string(key)] = string(val)
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
+
+// frontmatterPreloads reads a page's preload: frontmatter: false turns the
+// hints off, a list adds URLs (a string, or a map with href, as and type)
+func frontmatterPreloads(meta map[string]interface{}) ([]preloadHint, bool) {
+	switch v := meta["preload"].(type) {
+	case bool:
+		return nil, v
+	case string:
+		return []preloadHint{hintFor(v)}, true
+	case []interface{}:
+		var hints []preloadHint
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				hints = append(hints, hintFor(item))
+			case map[string]interface{}:
+				href, _ := item["href"].(string)
+				h := hintFor(href)
+				if as, ok := item["as"].(string); ok && as != "" {
+					h.As, h.Type = as, ""
+				}
+				if typ, ok := item["type"].(string); ok {
+					h.Type = typ
+				}
+				hints = append(hints, h)
+			}
+		}
+		return hints, true
+	}
+	return nil, true
+}
+
+// withPreloads returns page with its preload hints inserted into the head
+func (p *preloader) withPreloads(page []byte, baseURL, layout string, meta map[string]interface{}) []byte {
+	extra, on := frontmatterPreloads(meta)
+	if !on {
+		return page
+	}
+	for i, h := range extra {
+		if strings.HasPrefix(h.Href, "/") && !strings.HasPrefix(h.Href, "//") {
+			extra[i].Href = baseURL + h.Href
+		}
+	}
+	at, hints := p.pageHints(page, baseURL, layout, extra)
+	if len(hints) == 0 {
+		return page
+	}
+	var markup strings.Builder
+	for _, h := range hints {
+		markup.WriteString(h.markup())
+	}
+	out := make([]byte, 0, len(page)+markup.Len())
+	out = append(out, page[:at]...)
+	out = append(out, markup.String()...)
+	return append(out, page[at:]...)
+}
@@ -0,0 +1,90 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestWithPreloads(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	css := `body{}@font-face{font-family:Inter;src:url(../fonts/inter.woff) format("woff"),url(../fonts/inter.woff2) format("woff2")}
+@font-face{font-family:Mono;src:url("/static/fonts/mono.ttf")}@font-face{font-family:Third;src:url(third.woff2)}`
+	if err := afero.WriteFile(fs, "public/static/css/theme.123.css", []byte(css), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := &preloader{fs: fs, outputDir: "public", maxFonts: 2}
+
+	const base = "https://example.com"
+	page := `<html><head><meta charset="utf-8"><title>T</title>` +
+		`<link rel="preload" href="https://example.com/static/wasm/search.wasm" as="fetch" crossorigin>` +
+		`<link rel="stylesheet" href="https://example.com/static/css/theme.123.css">` +
+		`<link rel="stylesheet" href="https://fonts.example.org/css">` +
+		`<script type="module" src="https://example.com/static/js/app.mjs"></script></head>` +
+		`<body><header><img src="/logo.png"></header><main><p>Intro</p><img src="https://example.com/static/hero.webp" srcset="a.webp 1x, b.webp 2x" loading="lazy"><h2>Next</h2><img src="/later.webp"></main></body></html>`
+
+	tests := []struct {
+		name   string
+		layout string
+		meta   map[string]interface{}
+		want   string
+	}{
+		{
+			name: "analyzed",
+			want: `<link rel="preload" href="https://example.com/static/css/theme.123.css" as="style">` +
+				`<link rel="preload" href="https://example.com/static/fonts/inter.woff2" as="font" type="font/woff2" crossorigin>` +
+				`<link rel="preload" href="https://example.com/static/fonts/mono.ttf" as="font" type="font/ttf" crossorigin>` +
+				`<link rel="modulepreload" href="https://example.com/static/js/app.mjs">` +
+				`<link rel="preload" href="https://example.com/static/hero.webp" as="image" imagesrcset="a.webp 1x, b.webp 2x">`,
+		},
+		{
+			name:   "search page and frontmatter",
+			layout: "search",
+			meta: map[string]interface{}{"preload": []interface{}{
+				"/static/data/extra.json",
+				map[string]interface{}{"href": "/static/wasm/search.wasm", "as": "fetch"},
+				map[string]interface{}{"href": "/static/img/bg", "as": "image", "type": "image/avif"},
+			}},
+			want: `<link rel="preload" href="https://example.com/static/css/theme.123.css" as="style">` +
+				`<link rel="preload" href="https://example.com/static/fonts/inter.woff2" as="font" type="font/woff2" crossorigin>` +
+				`<link rel="preload" href="https://example.com/static/fonts/mono.ttf" as="font" type="font/ttf" crossorigin>` +
+				`<link rel="modulepreload" href="https://example.com/static/js/app.mjs">` +
+				`<link rel="preload" href="https://example.com/static/hero.webp" as="image" imagesrcset="a.webp 1x, b.webp 2x">` +
+				`<link rel="preload" href="https://example.com/search.bin" as="fetch" crossorigin>` +
+				`<link rel="preload" href="https://example.com/static/data/extra.json" as="fetch" crossorigin>` +
+				`<link rel="preload" href="https://example.com/static/img/bg" as="image" type="image/avif">`,
+		},
+		{
+			name: "turned off",
+			meta: map[string]interface{}{"preload": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(p.withPreloads([]byte(page), base, tt.layout, tt.meta))
+			want := strings.Replace(page, "</title>", "</title>"+tt.want, 1)
+			if got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestHintFor(t *testing.T) {
+	tests := []struct {
+		href, as, typ string
+	}{
+		{"/a.css?v=1", "style", ""},
+		{"/a.js", "script", ""},
+		{"/a.mjs", "module", ""},
+		{"/f.woff2#x", "font", "font/woff2"},
+		{"/i.AVIF", "image", ""},
+		{"/search.bin", "fetch", ""},
+	}
+	for _, tt := range tests {
+		if h := hintFor(tt.href); h.As != tt.as || h.Type != tt.typ {
+			t.Errorf("hintFor(%q) = %q %q, want %q %q", tt.href, h.As, h.Type, tt.as, tt.typ)
+		}
+	}
+}
@@ -37,8 +37,32 @@ func (r *Renderer) RenderPage(path string, data models.PageData) {
 		w = mw
 	}
 
-	if err := r.Layout.Execute(w, data); err != nil {
-		r.logger.Error("Failed to render layout", "path", path, "error", err)
+	w, flushHead := r.wrapHead(w, data.CommentsCSP)
+	defer flushHead()
+	if data.NoIndex {
+		h := &headInjector{w: w, snippet: noIndexMeta}
+		w = h
+		defer h.flush()
+	}
+
+	tmpl, file := r.pageLayout(data.Layout)
+	if r.preload != nil {
+		// The whole page is needed to find its hero image
+		buf := utils.SharedBufferPool.Get()
+		defer utils.SharedBufferPool.Put(buf)
+		if err := tmpl.Execute(buf, data); err != nil {
+			r.recordExecError(file, path, data, err)
+			return
+		}
+		if _, err := w.Write(r.preload.withPreloads(buf.Bytes(), data.BaseURL, data.Layout, data.Meta)); err != nil {
+			r.logger.Error("Failed to write page", "path", path, "error", err)
+			return
+		}
+		r.RegisterFile(path)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		r.recordExecError(file, path, data, err)
 	} else {
 		r.RegisterFile(path)
 	}
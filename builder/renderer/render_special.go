@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"bufio"
+	"html/template"
 	"io"
 	"path/filepath"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
+// RenderIndex renders a list page: the home page, a section, the tags index
+// or a tag page, depending on data.List
 func (r *Renderer) RenderIndex(path string, data models.PageData) {
 	data.Assets = r.Assets
 
@@ -34,19 +37,35 @@ func (r *Renderer) RenderIndex(path string, data models.PageData) {
 		w = mw
 	}
 
-	var errExec error
-	if r.Index != nil {
-		errExec = r.Index.Execute(w, data)
-	} else {
-		errExec = r.Layout.Execute(w, data)
-	}
-	if errExec != nil {
-		r.logger.Error("Failed to render index", "path", path, "error", errExec)
+	w, flushHead := r.wrapHead(w, data.CommentsCSP)
+	defer flushHead()
+
+	tmpl, file := r.listTemplate(data.List)
+	if err := tmpl.Execute(w, data); err != nil {
+		r.recordExecError(file, path, data, err)
 	} else {
 		r.RegisterFile(path)
 	}
 }
 
+// listTemplate returns the template a list page is rendered with: the first
+// of listTemplates for its kind the theme has (a nil list is the home
+// page), else layout.html
+func (r *Renderer) listTemplate(list *models.ListPage) (*template.Template, string) {
+	kind := models.ListHome
+	if list != nil {
+		kind = list.Kind
+	}
+	if r.templates != nil {
+		for _, name := range listTemplates[kind] {
+			if tmpl, ok := r.templates.templates[name]; ok {
+				return tmpl, name + ".html"
+			}
+		}
+	}
+	return r.Layout, "layout.html"
+}
+
 func (r *Renderer) RenderGraph(path string, data models.PageData) {
 	if r.Graph == nil {
 		return
@@ -75,8 +94,11 @@ func (r *Renderer) RenderGraph(path string, data models.PageData) {
 		w = mw
 	}
 
+	w, flushHead := r.wrapHead(w, data.CommentsCSP)
+	defer flushHead()
+
 	if err := r.Graph.Execute(w, data); err != nil {
-		r.logger.Error("Failed to render graph", "path", path, "error", err)
+		r.recordExecError("graph.html", path, data, err)
 	} else {
 		r.RegisterFile(path)
 	}
@@ -107,14 +129,19 @@ func (r *Renderer) Render404(path string, data models.PageData) {
 		w = mw
 	}
 
+	w, flushHead := r.wrapHead(w, data.CommentsCSP)
+	defer flushHead()
+
 	var errExec error
+	tmpl := "404.html"
 	if r.NotFound != nil {
 		errExec = r.NotFound.Execute(w, data)
 	} else {
+		tmpl = "layout.html"
 		errExec = r.Layout.Execute(w, data)
 	}
 	if errExec != nil {
-		r.logger.Error("Failed to render 404", "path", path, "error", errExec)
+		r.recordExecError(tmpl, path, data, errExec)
 	} else {
 		r.RegisterFile(path)
 	}
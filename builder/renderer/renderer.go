@@ -5,116 +5,211 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+
 	"github.com/spf13/afero"
 )
 
 type Renderer struct {
-	Layout      *template.Template
-	Index       *template.Template
-	Graph       *template.Template
-	NotFound    *template.Template
-	Assets      map[string]string
-	AssetsMu    sync.RWMutex
-	Compress    bool
-	DestFs      afero.Fs
-	RenderedMu  sync.RWMutex
-	RenderedSet map[string]bool
-	logger      *slog.Logger
+	Layout         *template.Template
+	Index          *template.Template
+	Graph          *template.Template
+	NotFound       *template.Template
+	Assets         map[string]string
+	AssetsMu       sync.RWMutex
+	Compress       bool
+	DestFs         afero.Fs
+	RenderedMu     sync.RWMutex
+	RenderedSet    map[string]bool
+	headSnippet    []byte
+	csp            string     // Site Content-Security-Policy, "" to emit none
+	preload        *preloader // Preload hints, nil when disabled
+	templateDirs   templateDirs
+	funcMap        template.FuncMap
+	templates      *templateSet
+	execErrors     templateErrors
+	missingLayouts sync.Map // Layout names already warned about
+	logger         *slog.Logger
 }
 
-func New(compress bool, destFs afero.Fs, templateDir string, logger *slog.Logger) *Renderer {
-	funcMap := template.FuncMap{
-		"lower":     strings.ToLower,
-		"hasPrefix": strings.HasPrefix,
-		"replace": func(from, to, input string) string {
-			return strings.ReplaceAll(input, from, to)
-		},
-		"now": time.Now,
-	}
-
-	tc := getGlobalCache(templateDir)
-
-	tc.mu.RLock()
-	cacheValid := len(tc.templates) > 0 && !tc.hasTemplatesChanged()
-	if cacheValid {
-		r := &Renderer{
-			Layout:      tc.templates["layout"],
-			Index:       tc.templates["index"],
-			Graph:       tc.templates["graph"],
-			NotFound:    tc.templates["404"],
-			Compress:    compress,
-			DestFs:      destFs,
-			RenderedSet: make(map[string]bool),
-			logger:      logger,
-		}
-		tc.mu.RUnlock()
-		return r
+// New creates a renderer for the templates in templateDirs, where a file
+// shadows the files of the same name in the directories after it
+func New(compress bool, destFs afero.Fs, templateDirs []string, logger *slog.Logger) *Renderer {
+	r := &Renderer{
+		Compress:     compress,
+		DestFs:       destFs,
+		RenderedSet:  make(map[string]bool),
+		templateDirs: templateDirs,
+		funcMap:      templateFuncs(),
+		logger:       logger,
 	}
-	tc.mu.RUnlock()
-
-	layoutPath := filepath.Join(templateDir, "layout.html")
-	tmpl, err := template.New("layout.html").Funcs(funcMap).ParseFiles(layoutPath)
-	if err != nil {
-		logger.Error("Failed to parse layout template", "path", layoutPath, "error", err)
+	if _, err := r.CompileTemplates(); err != nil {
+		logger.Error("Failed to parse templates", "dirs", r.templateDirs.String(), "error", err)
 		// Check if error might be due to template cycle
 		if strings.Contains(err.Error(), "template") && strings.Contains(err.Error(), "not defined") {
 			logger.Error("Possible template cycle detected - check for circular {{ template }} references")
 		}
 		os.Exit(1)
 	}
-	layoutInfo, _ := os.Stat(layoutPath)
-	if layoutInfo != nil {
-		tc.setTemplate("layout", tmpl, layoutInfo.ModTime())
-	}
+	return r
+}
 
-	indexPath := filepath.Join(templateDir, "index.html")
-	indexTmpl, err := template.New("index.html").Funcs(funcMap).ParseFiles(indexPath)
-	if err != nil {
-		logger.Warn("Index template not found, falling back to layout", "dir", templateDir, "error", err)
-		indexTmpl = nil
-	} else {
-		indexInfo, _ := os.Stat(indexPath)
-		if indexInfo != nil {
-			tc.setTemplate("index", indexTmpl, indexInfo.ModTime())
+// CompileTemplates points the renderer at the current templates of its theme.
+// They are parsed only when a template file changed since the last compile
+// (templates are shared by every renderer of the same directory); the result
+// reports whether parsing happened. On error the previous templates stay.
+func (r *Renderer) CompileTemplates() (bool, error) {
+	tc := getGlobalCache(r.templateDirs)
+	compiled := false
+	if tc.hasTemplatesChanged() {
+		set, err := compileTemplates(r.templateDirs, r.funcMap, r.logger.Warn)
+		if err != nil {
+			return false, err
 		}
+		tc.store(set)
+		compiled = true
 	}
 
-	graphPath := filepath.Join(templateDir, "graph.html")
-	graphTmpl, err := template.ParseFiles(graphPath)
-	if err != nil {
-		logger.Warn("Graph template not found, skipping graph page", "dir", templateDir, "error", err)
-	} else {
-		graphInfo, _ := os.Stat(graphPath)
-		if graphInfo != nil {
-			tc.setTemplate("graph", graphTmpl, graphInfo.ModTime())
-		}
+	set := tc.current()
+	r.Layout = set.templates["layout"]
+	r.Index = set.templates["index"]
+	r.Graph = set.templates["graph"]
+	r.NotFound = set.templates["404"]
+	r.templates = set
+	return compiled, nil
+}
+
+// Templates returns the hash of the compiled template set and the metadata
+// of each template file
+func (r *Renderer) Templates() (string, map[string]*cache.TemplateMeta) {
+	if r.templates == nil {
+		return "", nil
 	}
+	return r.templates.hash, r.templates.info
+}
 
-	notFoundPath := filepath.Join(templateDir, "404.html")
-	notFoundTmpl, err := template.New("404.html").Funcs(funcMap).ParseFiles(notFoundPath)
-	if err != nil {
-		logger.Warn("404 template not found, falling back to layout", "dir", templateDir, "error", err)
-		notFoundTmpl = nil
-	} else {
-		notFoundInfo, _ := os.Stat(notFoundPath)
-		if notFoundInfo != nil {
-			tc.setTemplate("404", notFoundTmpl, notFoundInfo.ModTime())
+// TemplateDeps returns the partials a template file includes, transitively
+func (r *Renderer) TemplateDeps(file string) []string {
+	if r.templates == nil {
+		return nil
+	}
+	return r.templates.deps(file)
+}
+
+// TemplateUsers returns the page templates (layout.html, index.html,
+// layouts/docs.html, …) and shortcodes that include the given partial,
+// transitively
+func (r *Renderer) TemplateUsers(partial string) []string {
+	if r.templates == nil {
+		return nil
+	}
+	var users []string
+	for _, file := range r.templates.pageFiles() {
+		if slices.Contains(r.templates.deps(file), partial) {
+			users = append(users, file)
+		}
+	}
+	var shortcodes []string
+	for name := range r.templates.shortcodes {
+		if file := ShortcodeFile(name); slices.Contains(r.templates.deps(file), partial) {
+			shortcodes = append(shortcodes, file)
 		}
 	}
+	slices.Sort(shortcodes)
+	return append(users, shortcodes...)
+}
+
+// Shortcode renders a markdown shortcode with the theme's
+// shortcodes/<name>.html. found is false when the theme has none.
+func (r *Renderer) Shortcode(name string, data any) (string, bool, error) {
+	if r.templates == nil {
+		return "", false, nil
+	}
+	tmpl, ok := r.templates.shortcodes[name]
+	if !ok {
+		return "", false, nil
+	}
+	var buf strings.Builder
+	if err := tmpl.ExecuteTemplate(&buf, ShortcodeFile(name), data); err != nil {
+		return "", true, err
+	}
+	return buf.String(), true, nil
+}
 
-	return &Renderer{
-		Layout:      tmpl,
-		Index:       indexTmpl,
-		Graph:       graphTmpl,
-		NotFound:    notFoundTmpl,
-		Compress:    compress,
-		DestFs:      destFs,
-		RenderedSet: make(map[string]bool),
-		logger:      logger,
+// pageLayout returns the template a post is rendered with: its named layout,
+// or layout.html when it has none or the theme lacks it (warned once per name)
+func (r *Renderer) pageLayout(name string) (*template.Template, string) {
+	if name == "" || r.templates == nil {
+		return r.Layout, "layout.html"
+	}
+	if tmpl, ok := r.templates.layouts[name]; ok {
+		return tmpl, LayoutFile(name)
+	}
+	if _, warned := r.missingLayouts.LoadOrStore(name, true); !warned {
+		r.logger.Warn("Layout not found in the theme, using layout.html", "layout", name, "file", LayoutFile(name))
+	}
+	return r.Layout, "layout.html"
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"lower":     strings.ToLower,
+		"hasPrefix": strings.HasPrefix,
+		"replace": func(from, to, input string) string {
+			return strings.ReplaceAll(input, from, to)
+		},
+		"now": time.Now,
+		// versionURL is the page in another version (a path, a name or
+		// "latest"), its home page there when the page is missing, or "" for
+		// an unknown version: {{ versionURL .Versions "v1.0" }}
+		"versionURL": func(versions []models.VersionInfo, name string) string {
+			v, _ := utils.FindVersion(versions, name)
+			return v.URL
+		},
+		// asset is the published path of a CSS or JS file of the asset map,
+		// fingerprinted in minified builds, or the path itself when it isn't
+		// one: {{ .BaseURL }}{{ asset .Assets "/static/css/main.css" }}
+		"asset": func(assets map[string]string, path string) string {
+			if p, ok := assets[path]; ok {
+				return p
+			}
+			return path
+		},
+		"getRemote": func(url string) (string, error) {
+			f := currentDataFetcher()
+			if f == nil {
+				return "", errNoDataFetcher
+			}
+			return f.GetRemote(url)
+		},
+		"getJSON": func(url string) (interface{}, error) {
+			f := currentDataFetcher()
+			if f == nil {
+				return nil, errNoDataFetcher
+			}
+			return f.GetJSON(url)
+		},
+		"data": func(name string) (interface{}, error) {
+			f := currentDataFetcher()
+			if f == nil {
+				return nil, errNoDataFetcher
+			}
+			return f.Data(name)
+		},
+		"webmentions": func(target string) []models.Webmention {
+			src := currentMentionSource()
+			if src == nil {
+				return nil
+			}
+			return src.Mentions(target)
+		},
 	}
 }
 
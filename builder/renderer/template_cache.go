@@ -1,76 +1,89 @@
 package renderer
 
 import (
-	"html/template"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// templateCache holds the compiled templates of one list of template
+// directories. Compiling is skipped while no template file was added,
+// removed, modified or shadowed.
 type templateCache struct {
-	templates   map[string]*template.Template
-	mtimes      map[string]time.Time
-	templateDir string
-	mu          sync.RWMutex
-	lastCheck   time.Time
-	checkTTL    time.Duration // How often to re-check mtimes
+	set    *templateSet
+	mtimes map[string]time.Time // keyed by the path each file was read from
+	dirs   templateDirs
+	mu     sync.RWMutex
 }
 
 var (
-	globalCache     *templateCache
-	globalCacheOnce sync.Once
+	// globalCaches holds one template cache per list of template directories
+	// so that several sites (e.g. a multi-site workspace) can share a process
+	globalCaches   = make(map[string]*templateCache)
+	globalCachesMu sync.Mutex
 )
 
-func getGlobalCache(templateDir string) *templateCache {
-	globalCacheOnce.Do(func() {
-		globalCache = &templateCache{
-			templates:   make(map[string]*template.Template),
-			mtimes:      make(map[string]time.Time),
-			templateDir: templateDir,
-			checkTTL:    2 * time.Second, // Only check mtimes every 2s
+func getGlobalCache(dirs templateDirs) *templateCache {
+	globalCachesMu.Lock()
+	defer globalCachesMu.Unlock()
+
+	key := strings.Join(dirs, string(os.PathListSeparator))
+	tc, ok := globalCaches[key]
+	if !ok {
+		tc = &templateCache{
+			mtimes: make(map[string]time.Time),
+			dirs:   dirs,
 		}
-	})
-	return globalCache
+		globalCaches[key] = tc
+	}
+	return tc
 }
 
+// hasTemplatesChanged reports whether a template file was added, removed,
+// modified or shadowed since the cached set was compiled. It only stats a
+// handful of files, so it runs on every build.
 func (tc *templateCache) hasTemplatesChanged() bool {
-	now := time.Now()
+	files := templateFiles(tc.dirs)
 
 	tc.mu.RLock()
-	if now.Sub(tc.lastCheck) < tc.checkTTL {
-		tc.mu.RUnlock()
-		return false // Skip check, assume unchanged within TTL
+	defer tc.mu.RUnlock()
+	if tc.set == nil || len(files) != len(tc.mtimes) {
+		return true
 	}
-	tc.mu.RUnlock()
-
-	templateFiles := []string{"layout.html", "index.html", "graph.html", "404.html"}
-	changed := false
-
-	for _, fname := range templateFiles {
-		path := filepath.Join(tc.templateDir, fname)
+	for _, rel := range files {
+		path := tc.dirs.path(rel)
 		info, err := os.Stat(path)
 		if err != nil {
-			continue
+			return true
 		}
-
-		cachedMtime, exists := tc.mtimes[fname]
-		if !exists || info.ModTime().After(cachedMtime) {
-			changed = true
-			break
+		cachedMtime, exists := tc.mtimes[path]
+		if !exists || !info.ModTime().Equal(cachedMtime) {
+			return true
 		}
 	}
+	return false
+}
 
-	tc.mu.Lock()
-	tc.lastCheck = now
-	tc.mu.Unlock()
-
-	return changed
+// current returns the cached template set, or nil
+func (tc *templateCache) current() *templateSet {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return tc.set
 }
 
-func (tc *templateCache) setTemplate(name string, tmpl *template.Template, mtime time.Time) {
+// store records a freshly compiled set along with the mtimes of its files
+func (tc *templateCache) store(set *templateSet) {
+	mtimes := make(map[string]time.Time, len(set.info))
+	for rel := range set.info {
+		path := tc.dirs.path(rel)
+		if info, err := os.Stat(path); err == nil {
+			mtimes[path] = info.ModTime()
+		}
+	}
+
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
-	tc.templates[name] = tmpl
-	tc.mtimes[name] = mtime
+	tc.set = set
+	tc.mtimes = mtimes
 }
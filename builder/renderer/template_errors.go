@@ -0,0 +1,131 @@
+package renderer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// TemplateError is one distinct template execution failure together with
+// every page that hit it. The same broken partial usually fails on hundreds
+// of pages, so failures are collected during the build and reported once.
+type TemplateError struct {
+	Template string   // Template file the failing action is in, e.g. "partials/nav.html"
+	Line     int      // 0 when the error carries no position
+	Col      int      // 0 when the error carries no column
+	Expr     string   // The failing action, e.g. ".Meta.author.name"
+	Message  string   // What went wrong, without the location prefix
+	Pages    []string // Content files (output paths for generated pages), sorted
+}
+
+// Location formats the template position as file:line:col
+func (e TemplateError) Location() string {
+	switch {
+	case e.Line == 0:
+		return e.Template
+	case e.Col == 0:
+		return fmt.Sprintf("%s:%d", e.Template, e.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", e.Template, e.Line, e.Col)
+}
+
+// execErrorPattern matches text/template and html/template execution errors:
+//
+//	template: layout.html:12:5: executing "layout.html" at <.Foo.Bar>: can't evaluate field Bar
+//	html/template:layout.html:3:14: no such template "missing"
+var execErrorPattern = regexp.MustCompile(`^(?:html/)?template: ?([^:]+):(\d+)(?::(\d+))?: (?:executing "[^"]*" at <(.*?)>: )?(.*)$`)
+
+// parseExecError splits a template execution error into its parts. Errors in
+// an unknown format keep their full text as the message.
+func parseExecError(template string, err error) TemplateError {
+	m := execErrorPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return TemplateError{Template: template, Message: err.Error()}
+	}
+	line, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	return TemplateError{Template: m[1], Line: line, Col: col, Expr: m[4], Message: m[5]}
+}
+
+// templateErrors collects execution failures across the render workers
+type templateErrors struct {
+	mu     sync.Mutex
+	byKey  map[string]*TemplateError
+	pageOf map[string]map[string]bool
+}
+
+func (t *templateErrors) add(template, page string, err error) {
+	e := parseExecError(template, err)
+	key := e.Location() + "\x00" + e.Expr + "\x00" + e.Message
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.byKey == nil {
+		t.byKey = make(map[string]*TemplateError)
+		t.pageOf = make(map[string]map[string]bool)
+	}
+	if _, ok := t.byKey[key]; !ok {
+		t.byKey[key] = &e
+		t.pageOf[key] = make(map[string]bool)
+	}
+	if !t.pageOf[key][page] {
+		t.pageOf[key][page] = true
+		t.byKey[key].Pages = append(t.byKey[key].Pages, page)
+	}
+}
+
+// take returns the collected errors, most widespread first, and resets
+func (t *templateErrors) take() []TemplateError {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	errs := make([]TemplateError, 0, len(t.byKey))
+	for _, e := range t.byKey {
+		sort.Strings(e.Pages)
+		errs = append(errs, *e)
+	}
+	t.byKey, t.pageOf = nil, nil
+
+	sort.Slice(errs, func(i, j int) bool {
+		if len(errs[i].Pages) != len(errs[j].Pages) {
+			return len(errs[i].Pages) > len(errs[j].Pages)
+		}
+		return errs[i].Location() < errs[j].Location()
+	})
+	return errs
+}
+
+// recordExecError notes that executing template failed for the page at path
+func (r *Renderer) recordExecError(template, path string, data models.PageData, err error) {
+	page := data.SourcePath
+	if page == "" {
+		page = displayPath(path)
+	}
+	r.execErrors.add(template, page, err)
+}
+
+// displayPath shortens an output path to be relative to the site root (the
+// working directory) when it lies inside it
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// TakeTemplateErrors returns the distinct template execution errors since the
+// last call, most widespread first
+func (r *Renderer) TakeTemplateErrors() []TemplateError {
+	return r.execErrors.take()
+}
@@ -0,0 +1,95 @@
+package renderer
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"slices"
+	"testing"
+)
+
+func execError(t *testing.T, name, text string, data any) error {
+	t.Helper()
+	tmpl := template.Must(template.New(name).Parse(text))
+	err := tmpl.Execute(io.Discard, data)
+	if err == nil {
+		t.Fatalf("executing %q succeeded, want an error", text)
+	}
+	return err
+}
+
+func TestParseExecError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want TemplateError
+	}{
+		{
+			name: "field on a map value",
+			err:  execError(t, "layout.html", "<p>\n{{ .Meta.author.name }}</p>", map[string]any{"Meta": map[string]any{"author": "x"}}),
+			want: TemplateError{Template: "layout.html", Line: 2, Col: 8, Expr: ".Meta.author.name", Message: "can't evaluate field name in type interface {}"},
+		},
+		{
+			name: "missing template",
+			err:  execError(t, "index.html", `{{ template "nav.html" }}`, nil),
+			want: TemplateError{Template: "index.html", Line: 1, Col: 12, Message: `no such template "nav.html"`},
+		},
+		{
+			name: "unknown format",
+			err:  errors.New("write: broken pipe"),
+			want: TemplateError{Template: "graph.html", Message: "write: broken pipe"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseExecError("graph.html", tt.err)
+			if got.Template != tt.want.Template || got.Line != tt.want.Line || got.Col != tt.want.Col ||
+				got.Expr != tt.want.Expr || got.Message != tt.want.Message {
+				t.Errorf("parseExecError(%q) = %+v, want %+v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateErrorsAggregate(t *testing.T) {
+	data := map[string]any{"Meta": map[string]any{"author": "x"}}
+	broken := execError(t, "layout.html", "{{ .Meta.author.name }}", data)
+	other := execError(t, "404.html", `{{ template "missing" }}`, nil)
+
+	var errs templateErrors
+	errs.add("layout.html", "b.md", broken)
+	errs.add("layout.html", "a.md", broken)
+	errs.add("layout.html", "a.md", broken)
+	errs.add("404.html", "404.html", other)
+
+	got := errs.take()
+	if len(got) != 2 {
+		t.Fatalf("take() returned %d errors, want 2: %+v", len(got), got)
+	}
+	if got[0].Location() != "layout.html:1:8" || !slices.Equal(got[0].Pages, []string{"a.md", "b.md"}) {
+		t.Errorf("first error = %s on %v, want layout.html:1:8 on [a.md b.md]", got[0].Location(), got[0].Pages)
+	}
+	if got[1].Template != "404.html" || !slices.Equal(got[1].Pages, []string{"404.html"}) {
+		t.Errorf("second error = %s on %v, want 404.html on [404.html]", got[1].Location(), got[1].Pages)
+	}
+	if again := errs.take(); len(again) != 0 {
+		t.Errorf("take() after take() = %+v, want nothing", again)
+	}
+}
+
+func TestTemplateErrorLocation(t *testing.T) {
+	tests := []struct {
+		err  TemplateError
+		want string
+	}{
+		{TemplateError{Template: "layout.html"}, "layout.html"},
+		{TemplateError{Template: "layout.html", Line: 4}, "layout.html:4"},
+		{TemplateError{Template: "partials/nav.html", Line: 4, Col: 9}, "partials/nav.html:4:9"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Location(); got != tt.want {
+			t.Errorf("Location() = %q, want %q", got, tt.want)
+		}
+	}
+}
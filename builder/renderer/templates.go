@@ -0,0 +1,368 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// PartialsDir holds templates shared by every page template. They are parsed
+// once per compile and cloned into layout, index, 404 and graph.
+const PartialsDir = "partials"
+
+// LayoutsDir holds alternative post templates: layouts/docs.html renders the
+// pages that ask for the "docs" layout instead of layout.html
+const LayoutsDir = "layouts"
+
+// ShortcodesDir holds the templates of markdown shortcodes:
+// shortcodes/note.html renders {{< note >}}
+const ShortcodesDir = "shortcodes"
+
+// ShortcodeFile is the template file of a shortcode
+func ShortcodeFile(name string) string {
+	return ShortcodesDir + "/" + name + ".html"
+}
+
+// LayoutFile is the template file of a named layout
+func LayoutFile(name string) string {
+	return LayoutsDir + "/" + name + ".html"
+}
+
+// pageTemplates are the page-level templates, keyed by their cache name
+var pageTemplates = []struct {
+	name     string
+	file     string
+	required bool
+	missing  string // Warning logged when an optional template doesn't exist, if any
+}{
+	{"layout", "layout.html", true, ""},
+	{"index", "index.html", false, "Index template not found, falling back to layout"},
+	{"graph", "graph.html", false, "Graph template not found, skipping graph page"},
+	{"404", "404.html", false, "404 template not found, falling back to layout"},
+	{"list", "list.html", false, ""},
+	{"tags", "tags.html", false, ""},
+	{"term", "term.html", false, ""},
+}
+
+// listTemplates are the templates a list page of each kind tries in order
+// before layout.html
+var listTemplates = map[string][]string{
+	models.ListHome:    {"index", "list"},
+	models.ListSection: {"list"},
+	models.ListTags:    {"tags"},
+	models.ListTerm:    {"term", "list"},
+}
+
+// templateSet is one compiled generation of a theme's templates
+type templateSet struct {
+	templates  map[string]*template.Template  // By pageTemplates name (missing ones are absent)
+	layouts    map[string]*template.Template  // Named layouts from LayoutsDir
+	shortcodes map[string]*template.Template  // Shortcodes from ShortcodesDir
+	info       map[string]*cache.TemplateMeta // keyed by slash path relative to the template dir
+	hash       string                         // Hash over all files, changes when any template does
+}
+
+// templateDirs are the directories templates are looked up in, in order: a
+// file shadows the files with the same relative path in the directories
+// after it, so a site's own templates/ can replace single templates of its
+// theme. Directories that don't exist are skipped.
+type templateDirs []string
+
+// path is where the template file rel is found: in the first directory that
+// has it, or else (for messages) in the last one
+func (dirs templateDirs) path(rel string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dirs[len(dirs)-1], filepath.FromSlash(rel))
+}
+
+func (dirs templateDirs) String() string {
+	return strings.Join(dirs, ", ")
+}
+
+// templateFiles lists the page templates, partials, layouts and shortcodes
+// that exist in any of dirs as slash paths relative to it, sorted
+func templateFiles(dirs templateDirs) []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(rel string) {
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	for _, dir := range dirs {
+		for _, page := range pageTemplates {
+			if _, err := os.Stat(filepath.Join(dir, page.file)); err == nil {
+				add(page.file)
+			}
+		}
+		for _, sub := range []string{PartialsDir, LayoutsDir, ShortcodesDir} {
+			matches, _ := filepath.Glob(filepath.Join(dir, sub, "*.html"))
+			for _, m := range matches {
+				add(sub + "/" + filepath.Base(m))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// compileTemplates parses every template in dirs. Partials are parsed once
+// into a base set that each page template is cloned from, so the parse trees
+// are shared instead of re-read per page template. Only a missing or broken
+// layout.html is an error; other page templates and layouts are skipped with
+// a warning.
+func compileTemplates(dirs templateDirs, funcMap template.FuncMap, warn func(msg string, args ...any)) (*templateSet, error) {
+	set := &templateSet{
+		templates:  make(map[string]*template.Template),
+		layouts:    make(map[string]*template.Template),
+		shortcodes: make(map[string]*template.Template),
+		info:       make(map[string]*cache.TemplateMeta),
+	}
+
+	files := templateFiles(dirs)
+	sources := make(map[string]string, len(files))
+	var combined strings.Builder
+	for _, rel := range files {
+		data, err := os.ReadFile(dirs.path(rel))
+		if err != nil {
+			return nil, err
+		}
+		info, err := inspectTemplate(rel, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		sources[rel] = string(data)
+		set.info[rel] = info
+		combined.WriteString(rel + ":" + info.Hash + "\n")
+	}
+	set.hash = cache.HashString(combined.String())
+
+	base := template.New("").Funcs(funcMap)
+	for _, rel := range files {
+		if !strings.HasPrefix(rel, PartialsDir+"/") {
+			continue
+		}
+		if _, err := base.New(rel).Parse(sources[rel]); err != nil {
+			return nil, fmt.Errorf("failed to parse partial %s: %w", rel, err)
+		}
+	}
+
+	for _, page := range pageTemplates {
+		src, ok := sources[page.file]
+		if !ok {
+			if page.required {
+				return nil, fmt.Errorf("%s not found in %s", page.file, dirs)
+			}
+			if page.missing != "" {
+				warn(page.missing, "dir", dirs.String())
+			}
+			continue
+		}
+		tmpl, err := base.Clone()
+		if err == nil {
+			tmpl, err = tmpl.New(page.file).Parse(src)
+		}
+		if err != nil {
+			if page.required {
+				return nil, fmt.Errorf("failed to parse %s: %w", page.file, err)
+			}
+			warn("Failed to parse template, skipping", "template", page.file, "error", err)
+			continue
+		}
+		set.templates[page.name] = tmpl
+	}
+
+	for _, rel := range files {
+		if !strings.HasPrefix(rel, LayoutsDir+"/") {
+			continue
+		}
+		tmpl, err := base.Clone()
+		if err == nil {
+			tmpl, err = tmpl.New(rel).Parse(sources[rel])
+		}
+		if err != nil {
+			warn("Failed to parse layout, its pages use layout.html", "template", rel, "error", err)
+			continue
+		}
+		set.layouts[strings.TrimSuffix(strings.TrimPrefix(rel, LayoutsDir+"/"), ".html")] = tmpl
+	}
+
+	for _, rel := range files {
+		if !strings.HasPrefix(rel, ShortcodesDir+"/") {
+			continue
+		}
+		tmpl, err := base.Clone()
+		if err == nil {
+			tmpl, err = tmpl.New(rel).Parse(sources[rel])
+		}
+		if err != nil {
+			warn("Failed to parse shortcode, skipping", "template", rel, "error", err)
+			continue
+		}
+		set.shortcodes[strings.TrimSuffix(strings.TrimPrefix(rel, ShortcodesDir+"/"), ".html")] = tmpl
+	}
+	return set, nil
+}
+
+// pageFiles lists the template files pages are rendered with: the page
+// templates, then the layouts by name
+func (set *templateSet) pageFiles() []string {
+	var layouts []string
+	for rel := range set.info {
+		if strings.HasPrefix(rel, LayoutsDir+"/") {
+			layouts = append(layouts, rel)
+		}
+	}
+	slices.Sort(layouts)
+	files := make([]string, 0, len(pageTemplates)+len(layouts))
+	for _, page := range pageTemplates {
+		files = append(files, page.file)
+	}
+	return append(files, layouts...)
+}
+
+// ExecuteTemplate renders one template of dir with data, outside a build and
+// without minification: a page template by file name ("layout.html",
+// "layouts/docs.html"), or a partial by path ("partials/card.html") or by a
+// name it defines
+func ExecuteTemplate(dir, name string, data any) ([]byte, error) {
+	set, err := compileTemplates(templateDirs{dir}, templateFuncs(), func(string, ...any) {})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, page := range pageTemplates {
+		if page.file != name {
+			continue
+		}
+		tmpl, ok := set.templates[page.name]
+		if !ok {
+			return nil, fmt.Errorf("%s not found in %s", name, dir)
+		}
+		err = tmpl.Execute(&buf, data)
+		return buf.Bytes(), err
+	}
+	if layout, ok := strings.CutPrefix(name, LayoutsDir+"/"); ok {
+		tmpl, ok := set.layouts[strings.TrimSuffix(layout, ".html")]
+		if !ok {
+			return nil, fmt.Errorf("%s not found in %s", name, dir)
+		}
+		err = tmpl.Execute(&buf, data)
+		return buf.Bytes(), err
+	}
+	if set.templates["layout"].Lookup(name) == nil {
+		return nil, fmt.Errorf("template %q not found in %s", name, dir)
+	}
+	err = set.templates["layout"].ExecuteTemplate(&buf, name, data)
+	return buf.Bytes(), err
+}
+
+// inspectTemplate hashes a template file and lists the templates it defines
+// and invokes, without resolving functions (they aren't needed for that)
+func inspectTemplate(name, src string) (*cache.TemplateMeta, error) {
+	info := &cache.TemplateMeta{Hash: cache.HashString(src)}
+
+	trees := make(map[string]*parse.Tree)
+	t := parse.New(name)
+	t.Mode = parse.SkipFuncCheck
+	if _, err := t.Parse(src, "", "", trees); err != nil {
+		return info, err
+	}
+
+	uses := make(map[string]bool)
+	for treeName, tree := range trees {
+		if treeName != name {
+			info.Defines = append(info.Defines, treeName)
+		}
+		if tree.Root != nil {
+			collectTemplateUses(tree.Root, uses)
+		}
+	}
+	for use := range uses {
+		info.Uses = append(info.Uses, use)
+	}
+	slices.Sort(info.Defines)
+	slices.Sort(info.Uses)
+	return info, nil
+}
+
+func collectTemplateUses(node parse.Node, uses map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectTemplateUses(child, uses)
+		}
+	case *parse.TemplateNode:
+		uses[n.Name] = true
+	case *parse.IfNode:
+		collectTemplateUses(n.List, uses)
+		collectTemplateUses(n.ElseList, uses)
+	case *parse.RangeNode:
+		collectTemplateUses(n.List, uses)
+		collectTemplateUses(n.ElseList, uses)
+	case *parse.WithNode:
+		collectTemplateUses(n.List, uses)
+		collectTemplateUses(n.ElseList, uses)
+	}
+}
+
+// deps returns the template files that file includes, directly or through
+// other partials. {{ template }} calls resolve to a partial either by its
+// path or by a name it defines; names defined in file itself are internal.
+func (set *templateSet) deps(file string) []string {
+	seen := map[string]bool{file: true}
+	queue := []string{file}
+	var out []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		meta, ok := set.info[current]
+		if !ok {
+			continue
+		}
+		for _, use := range meta.Uses {
+			for _, dep := range set.resolve(use) {
+				if !seen[dep] {
+					seen[dep] = true
+					out = append(out, dep)
+					queue = append(queue, dep)
+				}
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// resolve maps a {{ template }} name to the partial files providing it
+func (set *templateSet) resolve(name string) []string {
+	if _, ok := set.info[name]; ok && strings.HasPrefix(name, PartialsDir+"/") {
+		return []string{name}
+	}
+	var files []string
+	for rel, meta := range set.info {
+		if strings.HasPrefix(rel, PartialsDir+"/") && slices.Contains(meta.Defines, name) {
+			files = append(files, rel)
+		}
+	}
+	return files
+}
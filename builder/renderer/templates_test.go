@@ -0,0 +1,305 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func writeTemplate(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompileTemplatesSharesPartials(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `<main>{{ template "partials/nav.html" . }}{{ template "footer" . }}</main>`)
+	writeTemplate(t, dir, "404.html", `{{ if .Title }}{{ template "partials/nav.html" . }}{{ end }}missing`)
+	writeTemplate(t, dir, "partials/nav.html", `<nav>{{ .Title | lower }}</nav>`)
+	writeTemplate(t, dir, "partials/footer.html", `{{ define "footer" }}<footer>{{ block "credits" . }}kosh{{ end }}</footer>{{ end }}`)
+
+	var warnings []string
+	set, err := compileTemplates(templateDirs{dir}, templateFuncs(), func(msg string, _ ...any) { warnings = append(warnings, msg) })
+	if err != nil {
+		t.Fatalf("compileTemplates failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := set.templates["layout"].Execute(&out, struct{ Title string }{"Home"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "<main><nav>home</nav><footer>kosh</footer></main>"; out.String() != want {
+		t.Errorf("layout rendered %q, want %q", out.String(), want)
+	}
+	if set.templates["404"] == nil {
+		t.Error("404 template not compiled")
+	}
+	if len(warnings) != 2 {
+		t.Errorf("expected warnings for missing index and graph, got %v", warnings)
+	}
+
+	layout := set.info["layout.html"]
+	if !slices.Equal(layout.Uses, []string{"footer", "partials/nav.html"}) {
+		t.Errorf("layout uses = %v", layout.Uses)
+	}
+	if uses := set.info["404.html"].Uses; !slices.Equal(uses, []string{"partials/nav.html"}) {
+		t.Errorf("404 uses = %v (templates inside {{ if }} must be found)", uses)
+	}
+	footer := set.info["partials/footer.html"]
+	if !slices.Equal(footer.Defines, []string{"credits", "footer"}) || !slices.Equal(footer.Uses, []string{"credits"}) {
+		t.Errorf("footer = %+v", footer)
+	}
+}
+
+func TestCompileTemplatesRequiresLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "index.html", `index`)
+	if _, err := compileTemplates(templateDirs{dir}, templateFuncs(), func(string, ...any) {}); err == nil {
+		t.Error("expected an error without layout.html")
+	}
+
+	writeTemplate(t, dir, "layout.html", `{{ template "partials/missing.html" . }`)
+	if _, err := compileTemplates(templateDirs{dir}, templateFuncs(), func(string, ...any) {}); err == nil {
+		t.Error("expected an error for a broken layout.html")
+	}
+}
+
+func TestTemplateCacheDetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `layout`)
+	tc := &templateCache{dirs: templateDirs{dir}}
+
+	if !tc.hasTemplatesChanged() {
+		t.Fatal("empty cache should report a change")
+	}
+	set, err := compileTemplates(templateDirs{dir}, templateFuncs(), func(string, ...any) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.store(set)
+	if tc.hasTemplatesChanged() {
+		t.Error("unchanged templates reported as changed")
+	}
+
+	writeTemplate(t, dir, "partials/new.html", `new`)
+	if !tc.hasTemplatesChanged() {
+		t.Error("added partial not detected")
+	}
+	set, _ = compileTemplates(templateDirs{dir}, templateFuncs(), func(string, ...any) {})
+	tc.store(set)
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "layout.html"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !tc.hasTemplatesChanged() {
+		t.Error("modified layout not detected")
+	}
+}
+
+func TestSiteTemplatesShadowTheme(t *testing.T) {
+	theme, site := t.TempDir(), t.TempDir()
+	writeTemplate(t, theme, "layout.html", `<main>{{ template "partials/nav.html" . }}</main>`)
+	writeTemplate(t, theme, "partials/nav.html", `theme nav`)
+	writeTemplate(t, theme, "partials/footer.html", `theme footer`)
+	dirs := templateDirs{site, theme}
+	tc := &templateCache{dirs: dirs}
+
+	set, err := compileTemplates(dirs, templateFuncs(), func(string, ...any) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc.store(set)
+
+	// An override older than the theme's file still replaces it
+	writeTemplate(t, site, "partials/nav.html", `site nav`)
+	earlier := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(site, "partials/nav.html"), earlier, earlier); err != nil {
+		t.Fatal(err)
+	}
+	if !tc.hasTemplatesChanged() {
+		t.Error("shadowing partial not detected")
+	}
+	set, err = compileTemplates(dirs, templateFuncs(), func(string, ...any) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := set.templates["layout"].Execute(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "<main>site nav</main>" {
+		t.Errorf("layout rendered %q, want the site's nav", out.String())
+	}
+	if len(set.info) != 3 {
+		t.Errorf("compiled %d files, want layout and both partials", len(set.info))
+	}
+}
+
+func TestTemplateDeps(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `{{ define "local" }}x{{ end }}{{ template "local" . }}{{ template "partials/sidebar.html" . }}`)
+	writeTemplate(t, dir, "index.html", `{{ template "footer" . }}`)
+	writeTemplate(t, dir, "partials/sidebar.html", `{{ template "partials/tree.html" . }}`)
+	writeTemplate(t, dir, "partials/tree.html", `{{ template "footer" . }}`)
+	writeTemplate(t, dir, "partials/footer.html", `{{ define "footer" }}f{{ end }}`)
+	writeTemplate(t, dir, "partials/unused.html", `unused`)
+
+	set, err := compileTemplates(templateDirs{dir}, templateFuncs(), func(string, ...any) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Renderer{templates: set}
+
+	want := []string{"partials/footer.html", "partials/sidebar.html", "partials/tree.html"}
+	if got := r.TemplateDeps("layout.html"); !slices.Equal(got, want) {
+		t.Errorf("TemplateDeps(layout.html) = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		partial string
+		want    []string
+	}{
+		{"partials/tree.html", []string{"layout.html"}},
+		{"partials/footer.html", []string{"layout.html", "index.html"}},
+		{"partials/unused.html", nil},
+	}
+	for _, tt := range tests {
+		if got := r.TemplateUsers(tt.partial); !slices.Equal(got, tt.want) {
+			t.Errorf("TemplateUsers(%q) = %v, want %v", tt.partial, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `<main>{{ template "partials/nav.html" . }}</main>`)
+	writeTemplate(t, dir, "partials/nav.html", `<nav>{{ .Title | lower }}</nav>`)
+	writeTemplate(t, dir, "partials/footer.html", `{{ define "footer" }}<footer>{{ .Title }}</footer>{{ end }}`)
+	data := struct{ Title string }{"Home"}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"layout.html", "<main><nav>home</nav></main>", false},
+		{"partials/nav.html", "<nav>home</nav>", false},
+		{"footer", "<footer>Home</footer>", false},
+		{"index.html", "", true},
+		{"partials/missing.html", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ExecuteTemplate(dir, tt.name, data)
+		if (err != nil) != tt.wantErr || string(got) != tt.want {
+			t.Errorf("ExecuteTemplate(%q) = %q, %v, want %q (error: %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPageLayouts(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `<main>{{ .Title }}</main>`)
+	writeTemplate(t, dir, "layouts/docs.html", `<article>{{ template "partials/toc.html" . }}{{ .Title }}</article>`)
+	writeTemplate(t, dir, "layouts/broken.html", `{{ undefinedFunc }}`)
+	writeTemplate(t, dir, "partials/toc.html", `<nav>toc</nav>`)
+
+	fs := afero.NewMemMapFs()
+	r := New(false, fs, []string{dir}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"", "<main>Page</main>"},
+		{"docs", "<article><nav>toc</nav>Page</article>"},
+		{"missing", "<main>Page</main>"},
+		{"broken", "<main>Page</main>"}, // Skipped with a warning at compile time
+	}
+	for _, tt := range tests {
+		path := "public/" + tt.layout + ".html"
+		r.RenderPage(path, models.PageData{Title: "Page", Layout: tt.layout})
+		if got, _ := afero.ReadFile(fs, path); string(got) != tt.want {
+			t.Errorf("layout %q rendered %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+
+	if got := r.TemplateUsers("partials/toc.html"); !slices.Equal(got, []string{"layouts/docs.html"}) {
+		t.Errorf("TemplateUsers(partials/toc.html) = %v, want [layouts/docs.html]", got)
+	}
+	if got, err := ExecuteTemplate(dir, "layouts/docs.html", models.PageData{Title: "X"}); err != nil || string(got) != "<article><nav>toc</nav>X</article>" {
+		t.Errorf("ExecuteTemplate(layouts/docs.html) = %q, %v", got, err)
+	}
+}
+
+func TestShortcodeTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `layout`)
+	writeTemplate(t, dir, "shortcodes/note.html", `{{ template "partials/icon.html" . }}<aside>{{ .Inner }}</aside>`)
+	writeTemplate(t, dir, "shortcodes/broken.html", `{{ undefinedFunc }}`)
+	writeTemplate(t, dir, "partials/icon.html", `<i>{{ .Name }}</i>`)
+
+	r := New(false, afero.NewMemMapFs(), []string{dir}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	data := struct{ Name, Inner string }{"note", "<b>"}
+	if got, found, err := r.Shortcode("note", data); err != nil || !found || got != "<i>note</i><aside>&lt;b&gt;</aside>" {
+		t.Errorf("Shortcode(note) = %q, %v, %v", got, found, err)
+	}
+	for _, name := range []string{"broken", "missing"} {
+		if _, found, _ := r.Shortcode(name, data); found {
+			t.Errorf("Shortcode(%s) found", name)
+		}
+	}
+	if got := r.TemplateUsers("partials/icon.html"); !slices.Equal(got, []string{"shortcodes/note.html"}) {
+		t.Errorf("TemplateUsers(partials/icon.html) = %v, want [shortcodes/note.html]", got)
+	}
+}
+
+func TestListTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "layout.html", `layout`)
+	writeTemplate(t, dir, "list.html", `list {{ .List.Kind }} {{ .List.Count }}`)
+	writeTemplate(t, dir, "tags.html", `tags {{ len .List.Terms }}`)
+
+	fs := afero.NewMemMapFs()
+	r := New(false, fs, []string{dir}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tests := []struct {
+		list *models.ListPage
+		want string
+	}{
+		{&models.ListPage{Kind: models.ListHome, Count: 5}, "list home 5"}, // No index.html
+		{&models.ListPage{Kind: models.ListSection, Count: 3}, "list section 3"},
+		{&models.ListPage{Kind: models.ListTags, Terms: make([]models.TagData, 2)}, "tags 2"},
+		{&models.ListPage{Kind: models.ListTerm, Count: 1}, "list term 1"}, // No term.html
+	}
+	for i, tt := range tests {
+		path := fmt.Sprintf("public/%d.html", i)
+		r.RenderIndex(path, models.PageData{List: tt.list})
+		if got, _ := afero.ReadFile(fs, path); string(got) != tt.want {
+			t.Errorf("%s page rendered %q, want %q", tt.list.Kind, got, tt.want)
+		}
+	}
+
+	// Without list templates every list page uses layout.html
+	bare := t.TempDir()
+	writeTemplate(t, bare, "layout.html", `layout`)
+	r = New(false, fs, []string{bare}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	r.RenderIndex("public/term.html", models.PageData{List: &models.ListPage{Kind: models.ListTerm}})
+	if got, _ := afero.ReadFile(fs, "public/term.html"); string(got) != "layout" {
+		t.Errorf("term page rendered %q without term.html, want layout", got)
+	}
+}
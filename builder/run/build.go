@@ -2,7 +2,7 @@ package run
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,12 +10,24 @@ import (
 	"time"
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+	"github.com/Kush-Singh-26/kosh/builder/services"
+	"github.com/Kush-Singh-26/kosh/builder/telemetry"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
 // Build executes a single build pass
-func (b *Builder) Build(ctx context.Context) error {
+func (b *Builder) Build(ctx context.Context) (err error) {
+	start := time.Now()
+	// Deferred first so it sees the error as finishErrors leaves it
+	defer func() { b.events.Publish(events.BuildFinished{Duration: time.Since(start), Err: err}) }()
+	interrupted := ctx.Done() // Not the error limit's cancellation below
+	ctx, finishErrors := b.limitErrors(ctx)
+	defer func() { err = finishErrors(err) }()
+
 	// Check for cancellation early
 	select {
 	case <-ctx.Done():
@@ -39,9 +51,16 @@ func (b *Builder) Build(ctx context.Context) error {
 	}
 
 	cfg := b.cfg
+	// A scoped build (--only) renders one content subtree and leaves every
+	// global page (home, tags, graph, search, feeds, PWA) as it is on disk
+	scoped := cfg.Only != ""
 	// Build started - minimal logging
 
+	ctx, span := telemetry.Start(ctx, "build")
+	defer span.End()
+
 	// 1. Setup & Cache Invalidation
+	_, endPhase := b.startPhase(ctx, "setup")
 	var setupWg sync.WaitGroup
 	setupWg.Add(1)
 	go func() {
@@ -54,24 +73,54 @@ func (b *Builder) Build(ctx context.Context) error {
 		}
 	}()
 
-	globalDependencies := []string{
-		filepath.Join(cfg.TemplateDir, "layout.html"),
-		filepath.Join(cfg.TemplateDir, "index.html"),
-		filepath.Join(cfg.TemplateDir, "404.html"),
-		filepath.Join(cfg.TemplateDir, "graph.html"),
+	var globalDependencies []string
+	for _, dir := range cfg.TemplateDirs() {
+		for _, page := range []string{"layout.html", "index.html", "404.html", "graph.html", "list.html", "tags.html", "term.html"} {
+			globalDependencies = append(globalDependencies, filepath.Join(dir, page))
+		}
+	}
+	globalDependencies = append(globalDependencies,
 		filepath.Join(cfg.StaticDir, "css/layout.css"),
 		filepath.Join(cfg.StaticDir, "css/theme.css"),
 		"kosh.yaml",
 		"builder/generators/pwa.go",
-	}
+	)
+	// Templates are compiled once here and shared by every render of this build
+	changedPartials := b.compileTemplates()
+
 	forceSocialRebuild := false
 	shouldForce := b.cfg.ForceRebuild
+	if b.pluginsChanged() {
+		b.logger.Info("🔌 Plugins changed, triggering rebuild")
+		shouldForce = true
+	}
+	b.largeFiles = b.scanLargeFiles()
+	largeFilesChanged := b.largeFilesChanged(b.largeFiles)
+	if largeFilesChanged {
+		b.logger.Info("🗄️  Large files changed, triggering rebuild")
+		shouldForce = true
+	}
 	var affectedPosts []string
+
+	// Partials and layouts aren't covered by the mtime checks below: map each
+	// changed one to the posts that use it (or a full rebuild if global pages do)
+	for _, partial := range changedPartials {
+		affected := b.invalidateForTemplate(filepath.Join(cfg.TemplateDir, filepath.FromSlash(partial)))
+		if affected == nil {
+			shouldForce = true
+		}
+		affectedPosts = append(affectedPosts, affected...)
+		b.logger.Info("🧩 Template changed", "template", partial, "posts", len(affected), "full", affected == nil)
+	}
 	var lastBuildTime time.Time
 
 	if indexInfo, err := os.Stat(filepath.Join(b.cfg.OutputDir, "index.html")); err == nil {
 		lastBuildTime = indexInfo.ModTime()
+	}
 
+	// Scoped builds re-render their whole subtree instead, and don't touch
+	// index.html, so the next full build still detects these changes
+	if !lastBuildTime.IsZero() && !scoped {
 		// Parallelize dependency checks for better performance
 		var depMu sync.Mutex
 		var depWg sync.WaitGroup
@@ -112,10 +161,28 @@ func (b *Builder) Build(ctx context.Context) error {
 		b.logger.Error("Failed to create sitemap directory", "error", err)
 	}
 
+	endPhase()
+
+	stopProgress := b.metrics.StartProgress()
+	defer stopProgress()
+
+	if b.cfg.Audience != "" {
+		logging.Statusf("👥 Audience: %s → %s", b.cfg.Audience, b.cfg.OutputDir)
+	}
+
 	// 2. Static Assets (MUST complete before posts to populate Assets map)
-	fmt.Println("📦 Building assets...")
-	b.copyStaticAndBuildAssets(ctx)
+	phaseCtx, endPhase := b.startPhase(ctx, "assets")
+	logging.Statusf("📦 Building assets...")
+	b.copyStaticAndBuildAssets(phaseCtx)
 	_ = utils.WriteFileVFS(b.DestFs, filepath.Join(b.cfg.OutputDir, ".nojekyll"), []byte(""))
+	endPhase()
+
+	// Pages in the cache link the images by the names they had
+	b.media = b.assetService.Media()
+	if b.recordChanged(mediaRecord, b.media) {
+		b.logger.Info("🖼️  Image names changed, triggering rebuild")
+		shouldForce = true
+	}
 
 	if len(affectedPosts) > 0 && b.cacheService != nil {
 		for _, postPath := range affectedPosts {
@@ -133,14 +200,16 @@ func (b *Builder) Build(ctx context.Context) error {
 		allPosts, pinnedPosts []models.PostMetadata
 		tagMap                map[string][]models.PostMetadata
 		indexedPosts          []models.IndexedPost
+		searchSpool           *search.ContentSpool
 		anyPostChanged        bool
 		has404                bool
+		languages             = &services.PostResult{} // Only its Languages and VersionPosts
 	)
 
 	// Template-only change detection logic
 	isTemplateOnly := false // Default to false to ensure content changes are detected
 
-	if shouldForce || len(affectedPosts) > 0 {
+	if shouldForce || len(affectedPosts) > 0 || scoped {
 		isTemplateOnly = false
 	} else if len(globalDependencies) > 0 {
 		for _, dep := range globalDependencies {
@@ -165,112 +234,74 @@ func (b *Builder) Build(ctx context.Context) error {
 		}
 	}
 
+	phaseCtx, endPhase = b.startPhase(ctx, "content")
 	// Use fast path if:
 	// 1. Template-only changes AND we have a valid lastBuildTime, OR
 	// 2. Output is missing (cleaned) AND we have cached data
 	outputMissing := lastBuildTime.IsZero()
 	if isTemplateOnly && ((!lastBuildTime.IsZero()) || outputMissing) && cachedCount > 0 {
-		fmt.Println("📝 Rehydrating from cache...")
+		logging.Statusf("📝 Rehydrating from cache...")
 		b.renderCachedPosts()
 
-		// Hydrate data for global pages from cache
-		tagMap = make(map[string][]models.PostMetadata)
-		ids, _ := b.cacheService.ListAllPosts()
-
-		// Batch fetch all posts and search records in single transactions (avoids N+1 queries)
-		cachedPosts, _ := b.cacheService.GetPostsByIDs(ids)
-		searchRecords, _ := b.cacheService.GetSearchRecords(ids)
-
-		for _, id := range ids {
-			cached, ok := cachedPosts[id]
-			if !ok || cached == nil {
-				continue
-			}
-
-			// Reconstruct models.PostMetadata
-			post := models.PostMetadata{
-				Title:       cached.Title,
-				Link:        cached.Link,
-				Description: cached.Description,
-				Tags:        cached.Tags,
-				ReadingTime: cached.ReadingTime,
-				Pinned:      cached.Pinned,
-				Draft:       cached.Draft,
-				DateObj:     cached.Date,
-				Version:     cached.Version,
-			}
-
-			if post.Pinned {
-				pinnedPosts = append(pinnedPosts, post)
-			} else {
-				allPosts = append(allPosts, post)
-			}
-			for _, t := range post.Tags {
-				tagMap[strings.ToLower(strings.TrimSpace(t))] = append(tagMap[strings.ToLower(strings.TrimSpace(t))], post)
-			}
-
-			// Indexed Posts - use batch-fetched search records
-			if searchMeta, ok := searchRecords[id]; ok && searchMeta != nil {
-				// Reconstruct PostRecord with relative link (not full URL)
-				relLink := strings.ToLower(strings.Replace(cached.Path, ".md", ".html", 1))
-
-				// Pre-compute normalized fields
-				normalizedTags := make([]string, len(cached.Tags))
-				for i, t := range cached.Tags {
-					normalizedTags[i] = strings.ToLower(t)
-				}
-
-				rec := models.PostRecord{
-					Title:           searchMeta.Title,
-					NormalizedTitle: searchMeta.NormalizedTitle,
-					Link:            relLink,
-					Description:     cached.Description,
-					Tags:            cached.Tags,
-					NormalizedTags:  normalizedTags,
-					Content:         searchMeta.Content,
-					Version:         cached.Version,
-				}
-				rec.ID = len(indexedPosts)
+		result := b.postsFromCache()
+		allPosts, pinnedPosts, tagMap, indexedPosts, languages = result.AllPosts, result.PinnedPosts, result.TagMap, result.IndexedPosts, result
+		anyPostChanged = true
+	} else {
+		logging.Statusf("📝 Processing content...")
+		result := b.processPosts(phaseCtx, shouldForce, forceSocialRebuild, outputMissing)
+		allPosts, pinnedPosts, tagMap, indexedPosts, searchSpool = result.AllPosts, result.PinnedPosts, result.TagMap, result.IndexedPosts, result.SearchSpool
+		anyPostChanged, has404, languages = result.AnyPostChanged, result.Has404, result
+		defer func() { _ = searchSpool.Close() }()
+		logging.Statusf("   ✅ Content processed.")
+	}
+	endPhase()
 
-				indexedPosts = append(indexedPosts, models.IndexedPost{
-					Record:    rec,
-					WordFreqs: searchMeta.BM25Data,
-					DocLen:    searchMeta.DocLen,
-				})
-			}
+	// Interrupted: the parsed pages are already checkpointed in the cache.
+	// Rendering global pages over a partial site and syncing it would only
+	// leave a half-built output behind.
+	if ctx.Err() != nil {
+		stopProgress()
+		select {
+		case <-interrupted:
+			b.reportInterrupted()
+		default:
 		}
+		return ctx.Err()
+	}
 
-		utils.SortPosts(allPosts)
-		utils.SortPosts(pinnedPosts)
-		anyPostChanged = true
-	} else {
-		fmt.Println("📝 Processing content...")
-		allPosts, pinnedPosts, tagMap, indexedPosts, anyPostChanged, has404 = b.processPosts(ctx, shouldForce, forceSocialRebuild, outputMissing)
-		fmt.Println("   ✅ Content processed.")
+	if scoped {
+		rel, _ := utils.SafeRel(cfg.ContentDir, cfg.Only)
+		logging.Statusf("🎯 Scoped build of %s: global pages, search and feeds left as they are", filepath.ToSlash(filepath.Join(filepath.Base(cfg.ContentDir), rel)))
+		if cachedCount == 0 {
+			b.logger.Warn("Scoped build without a cache: navigation only lists the built subtree, run a full build first")
+		}
 	}
 
 	// 4. Generate Global Pages
-	if shouldForce || anyPostChanged {
-		fmt.Println("📄 Rendering pagination...")
+	_, endPhase = b.startPhase(ctx, "pages")
+	if !scoped && (shouldForce || anyPostChanged) {
+		logging.Statusf("📄 Rendering pagination...")
 		b.renderPagination(allPosts, pinnedPosts, shouldForce)
+		b.renderSections(append(allPosts, pinnedPosts...))
+		b.renderLanguages(languages.Languages)
 	}
 
-	if !has404 {
-		b.renderService.Render404(filepath.Join(b.cfg.OutputDir, "404.html"), models.PageData{
-			BaseURL:      cfg.BaseURL,
-			BuildVersion: cfg.BuildVersion,
-			Config:       cfg,
-			TabTitle:     "404 - Page Not Found | " + cfg.Title,
-		})
+	if !has404 && !scoped {
+		b.render404s(indexedPosts)
 	}
 
-	if shouldForce || anyPostChanged || forceSocialRebuild {
-		fmt.Println("🏷️  Rendering tags...")
+	if !scoped && (shouldForce || anyPostChanged || forceSocialRebuild || b.termIndexChanged(lastBuildTime)) {
+		logging.Statusf("🏷️  Rendering tags...")
 		b.renderTags(tagMap, forceSocialRebuild)
+		b.renderLanguageTags(languages.Languages)
 	}
 
-	if shouldForce || anyPostChanged {
-		fmt.Println("🕸️  Rendering graph and metadata...")
+	endPhase()
+
+	_, endPhase = b.startPhase(ctx, "metadata")
+	metadataChanged := !scoped && (shouldForce || anyPostChanged)
+	if metadataChanged {
+		logging.Statusf("🕸️  Rendering graph and metadata...")
 		b.renderService.RenderGraph(filepath.Join(b.cfg.OutputDir, "graph.html"), models.PageData{
 			Title:        "Graph View",
 			TabTitle:     "Knowledge Graph | " + cfg.Title,
@@ -279,11 +310,14 @@ func (b *Builder) Build(ctx context.Context) error {
 			Config:       cfg,
 		})
 		allContent := append(allPosts, pinnedPosts...)
-		b.generateMetadata(allContent, tagMap, indexedPosts, shouldForce)
+		b.generateMetadata(allContent, tagMap, languages.VersionPosts, indexedPosts, searchSpool, shouldForce)
+		b.exportSearch(ctx, indexedPosts, searchSpool)
+		b.generateLanguageMetadata(languages.Languages)
 	}
+	endPhase()
 
 	// 5. PWA (Run concurrently)
-	if cfg.Features.Generators.PWA {
+	if cfg.Features.Generators.PWA && !scoped {
 		setupWg.Add(1)
 		go func() {
 			defer setupWg.Done()
@@ -291,8 +325,10 @@ func (b *Builder) Build(ctx context.Context) error {
 			case <-ctx.Done():
 				return
 			default:
-				fmt.Println("📱 Generating PWA...")
+				logging.Statusf("📱 Generating PWA...")
+				_, endPWA := b.startPhase(ctx, "pwa")
 				b.generatePWA(shouldForce)
+				endPWA()
 			}
 		}()
 	}
@@ -300,15 +336,82 @@ func (b *Builder) Build(ctx context.Context) error {
 	// Ensure setup tasks (WASM check + PWA) are complete
 	setupWg.Wait()
 
+	stopProgress()
+
 	// Now sync VFS to disk (includes completed social cards)
-	fmt.Println("💾 Syncing to disk...")
-	if err := utils.SyncVFS(b.DestFs, b.cfg.OutputDir, b.renderService.GetRenderedFiles()); err != nil {
-		b.logger.Error("Failed to sync VFS to disk", "error", err)
+	_, endPhase = b.startPhase(ctx, "sync")
+	if !b.cfg.LowMemory {
+		logging.Statusf("💾 Syncing to disk...")
+	}
+	rendered := b.renderService.GetRenderedFiles()
+	syncErr := b.syncOutput(rendered)
+	if syncErr != nil {
+		b.logger.Error("Failed to sync VFS to disk", "error", syncErr)
+	} else if b.cacheService != nil && !scoped {
+		// Every checkpointed page is on disk now
+		if err := b.cacheService.ClearPending(); err != nil {
+			b.logger.Warn("Failed to clear pending pages", "error", err)
+		}
+	}
+	endPhase()
+	var largeFilesErr error
+	if len(b.largeFiles) > 0 || largeFilesChanged {
+		_, endPhase = b.startPhase(ctx, "large-files")
+		largeFilesErr = b.uploadLargeFiles(ctx, b.largeFiles)
+		if largeFilesErr != nil {
+			b.logger.Error("Failed to upload large files", "error", largeFilesErr)
+		}
+		endPhase()
+	}
+	b.writeHeaders()
+	b.sendWebmentions(ctx, rendered)
+	if metadataChanged {
+		b.runPagefind(ctx)
 	}
 	b.renderService.ClearRenderedFiles()
+	b.reportTemplateErrors()
+
+	var checkErr error
+	if b.cfg.StrictMode {
+		_, endPhase = b.startPhase(ctx, "checks")
+		checkErr = b.runStrictChecks()
+		endPhase()
+	}
+	b.writeStatus()
+
+	if syncErr == nil {
+		if err := b.afterBuild(""); err != nil {
+			return errors.Join(checkErr, largeFilesErr, err)
+		}
+	}
 
 	// Build complete
-	return nil
+	return errors.Join(checkErr, largeFilesErr)
+}
+
+// reportInterrupted tells how much of a cancelled build the next one keeps
+func (b *Builder) reportInterrupted() {
+	saved := 0
+	if b.cacheService != nil {
+		pending, _ := b.cacheService.PendingPaths()
+		saved = len(pending)
+	}
+	if saved == 0 {
+		logging.Statusf("⏸️  Build interrupted")
+		return
+	}
+	logging.Statusf("⏸️  Build interrupted: %d parsed pages are saved in the cache, the next build resumes from them", saved)
+}
+
+// startPhase opens a trace span for a build phase. The returned func ends it
+// and records the phase time for the build report.
+func (b *Builder) startPhase(ctx context.Context, name string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := telemetry.Start(ctx, "build."+name)
+	return ctx, func() {
+		span.End()
+		b.metrics.RecordPhase(name, time.Since(start))
+	}
 }
 
 func (b *Builder) copyStaticAndBuildAssets(ctx context.Context) {
@@ -317,13 +420,141 @@ func (b *Builder) copyStaticAndBuildAssets(ctx context.Context) {
 	}
 }
 
-func (b *Builder) processPosts(ctx context.Context, shouldForce, forceSocialRebuild, outputMissing bool) ([]models.PostMetadata, []models.PostMetadata, map[string][]models.PostMetadata, []models.IndexedPost, bool, bool) {
+// syncOutput writes the in-memory output to disk. In low-memory mode DestFs
+// already is the disk, so there is nothing to sync.
+func (b *Builder) syncOutput(rendered map[string]bool) error {
+	if b.cfg.LowMemory {
+		if b.cfg.LinkDest != "" {
+			b.logger.Warn("linkDest is ignored in low-memory mode: output is written in place")
+		}
+		return nil
+	}
+	synced, err := utils.SyncVFS(b.DestFs, b.cfg.OutputDir, b.cfg.LinkDest, rendered)
+	if err != nil {
+		return err
+	}
+	// kosh deploy reuses the hashes instead of reading the output again
+	if b.cacheService != nil {
+		if err := b.cacheService.UpdateOutputFiles(synced); err != nil {
+			b.logger.Warn("Failed to record output hashes", "error", err)
+		}
+	}
+	return nil
+}
+
+func (b *Builder) processPosts(ctx context.Context, shouldForce, forceSocialRebuild, outputMissing bool) *services.PostResult {
+	if b.cfg.IsDev {
+		// Every draft is rendered again, so published and deleted ones go
+		_ = b.DestFs.RemoveAll(b.cfg.DevDraftsDir())
+	}
 	result, err := b.postService.Process(ctx, shouldForce, forceSocialRebuild, outputMissing)
 	if err != nil {
 		b.logger.Error("Failed to process posts", "error", err)
-		return nil, nil, nil, nil, false, false
+		return &services.PostResult{}
+	}
+	return result
+}
+
+// postsFromCache rebuilds the listings and search records of the global
+// pages from the cache, for builds that only re-render templates. Like
+// Process, it leaves out drafts and posts their schedule doesn't publish,
+// which a -drafts or -future build may have cached.
+func (b *Builder) postsFromCache() *services.PostResult {
+	cfg := b.cfg
+	result := &services.PostResult{TagMap: make(map[string][]models.PostMetadata)}
+	defaultLang := cfg.DefaultLanguage()
+	ids, _ := b.cacheService.ListAllPosts()
+
+	// Batch fetch all posts and search records in single transactions (avoids N+1 queries)
+	cachedPosts, _ := b.cacheService.GetPostsByIDs(ids)
+	searchRecords, _ := b.cacheService.GetSearchRecords(ids)
+
+	for _, id := range ids {
+		cached, ok := cachedPosts[id]
+		if !ok || cached == nil {
+			continue
+		}
+		if (cached.Draft && !cfg.IncludeDrafts) || !cfg.IsScheduled(cached.PublishDate, cached.ExpiryDate) {
+			continue
+		}
+
+		// Reconstruct models.PostMetadata
+		post := models.PostMetadata{
+			Title:       cached.Title,
+			Link:        cached.Link,
+			Description: cached.Description,
+			Tags:        cached.Tags,
+			ReadingTime: cached.ReadingTime,
+			Pinned:      cached.Pinned,
+			Draft:       cached.Draft,
+			DateObj:     cached.Date,
+			PublishDate: cached.PublishDate,
+			ExpiryDate:  cached.ExpiryDate,
+			ModTime:     time.Unix(cached.ModTime, 0),
+			Version:     cached.Version,
+			Lang:        cfg.LanguageOf(cached.Path),
+		}
+
+		// Other languages are listed under their own home page
+		posts, pinned, tags, indexed := &result.AllPosts, &result.PinnedPosts, result.TagMap, &result.IndexedPosts
+		if post.Lang != defaultLang {
+			lp := result.Language(post.Lang)
+			posts, pinned, tags, indexed = &lp.AllPosts, &lp.PinnedPosts, lp.TagMap, &lp.IndexedPosts
+		}
+
+		if !cfg.IsLatestVersion(post.Version) && post.Lang == defaultLang {
+			result.AddVersionPost(post)
+		} else if post.Pinned {
+			*pinned = append(*pinned, post)
+		} else {
+			*posts = append(*posts, post)
+		}
+		for _, t := range post.Tags {
+			tags[strings.ToLower(strings.TrimSpace(t))] = append(tags[strings.ToLower(strings.TrimSpace(t))], post)
+		}
+
+		// Indexed Posts - use batch-fetched search records
+		if searchMeta, ok := searchRecords[id]; ok && searchMeta != nil {
+			// Reconstruct PostRecord with relative link (not full URL)
+			relLink := cfg.HTMLPath(cached.Path)
+
+			// Pre-compute normalized fields
+			normalizedTags := make([]string, len(cached.Tags))
+			for i, t := range cached.Tags {
+				normalizedTags[i] = strings.ToLower(t)
+			}
+
+			rec := models.PostRecord{
+				Title:           searchMeta.Title,
+				NormalizedTitle: searchMeta.NormalizedTitle,
+				Link:            relLink,
+				Description:     cached.Description,
+				Tags:            cached.Tags,
+				NormalizedTags:  normalizedTags,
+				Content:         searchMeta.Content,
+				Version:         cached.Version,
+			}
+			rec.ID = len(*indexed)
+
+			*indexed = append(*indexed, models.IndexedPost{
+				Record:    rec,
+				WordFreqs: searchMeta.BM25Data,
+				DocLen:    searchMeta.DocLen,
+				Date:      cached.Date,
+			})
+		}
+	}
+
+	utils.SortPosts(result.AllPosts)
+	utils.SortPosts(result.PinnedPosts)
+	for _, lp := range result.Languages {
+		utils.SortPosts(lp.AllPosts)
+		utils.SortPosts(lp.PinnedPosts)
+	}
+	for _, posts := range result.VersionPosts {
+		utils.SortPosts(posts)
 	}
-	return result.AllPosts, result.PinnedPosts, result.TagMap, result.IndexedPosts, result.AnyPostChanged, result.Has404
+	return result
 }
 
 func (b *Builder) renderCachedPosts() {
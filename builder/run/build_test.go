@@ -0,0 +1,63 @@
+package run
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/services/mocks"
+)
+
+func TestPostsFromCache(t *testing.T) {
+	past, future := time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1)
+	cacheSvc := mocks.NewMockCacheService()
+	for _, meta := range []*cache.PostMeta{
+		{PostID: "live", Path: "live.md", Title: "Live", Tags: []string{"go"}, PublishDate: past, ExpiryDate: future},
+		{PostID: "future", Path: "future.md", Title: "Future", Tags: []string{"go"}, PublishDate: future},
+		{PostID: "expired", Path: "expired.md", Title: "Expired", Tags: []string{"go"}, ExpiryDate: past},
+		{PostID: "draft", Path: "draft.md", Title: "Draft", Tags: []string{"go"}, Draft: true},
+	} {
+		cacheSvc.Posts[meta.PostID] = meta
+		cacheSvc.SearchRecords[meta.PostID] = &cache.SearchRecord{Title: meta.Title}
+	}
+
+	titles := func(includeFuture bool) (listed, tagged, indexed []string) {
+		b := &Builder{cfg: &config.Config{IncludeFuture: includeFuture}, cacheService: cacheSvc}
+		result := b.postsFromCache()
+		for _, p := range result.AllPosts {
+			listed = append(listed, p.Title)
+		}
+		for _, p := range result.TagMap["go"] {
+			tagged = append(tagged, p.Title)
+		}
+		for _, p := range result.IndexedPosts {
+			indexed = append(indexed, p.Record.Title)
+		}
+		slices.Sort(listed)
+		slices.Sort(tagged)
+		slices.Sort(indexed)
+		return listed, tagged, indexed
+	}
+
+	for _, tt := range []struct {
+		includeFuture bool
+		want          []string
+	}{
+		{false, []string{"Live"}},
+		{true, []string{"Future", "Live"}},
+	} {
+		listed, tagged, indexed := titles(tt.includeFuture)
+		for name, got := range map[string][]string{"listed": listed, "tagged": tagged, "indexed": indexed} {
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("includeFuture=%v: %s = %q, want %q", tt.includeFuture, name, got, tt.want)
+			}
+		}
+	}
+
+	b := &Builder{cfg: &config.Config{}, cacheService: cacheSvc}
+	if p := b.postsFromCache().AllPosts[0]; !p.PublishDate.Equal(past) || !p.ExpiryDate.Equal(future) {
+		t.Errorf("schedule = %v, %v, want %v, %v", p.PublishDate, p.ExpiryDate, past, future)
+	}
+}
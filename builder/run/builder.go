@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/yuin/goldmark"
@@ -16,12 +18,19 @@ import (
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/metrics"
+	"github.com/Kush-Singh-26/kosh/builder/modules"
 	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/remote"
 	"github.com/Kush-Singh-26/kosh/builder/renderer"
 	"github.com/Kush-Singh-26/kosh/builder/renderer/native"
 	"github.com/Kush-Singh-26/kosh/builder/services"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
+	"github.com/Kush-Singh-26/kosh/builder/webmention"
 	"github.com/Kush-Singh-26/kosh/internal/build"
 )
 
@@ -51,6 +60,18 @@ type Builder struct {
 	// Shared markdown parser for reuse in incremental builds
 	md goldmark.Markdown
 
+	// Typed events for embedders and plugins (see Events)
+	events *events.Bus
+
+	// Hooks registered by plugins (see Use), and their name@version
+	hooks   *hooks.Registry
+	plugins []string
+
+	// Static files this build moves to largeFiles.storage (see scanLargeFiles)
+	largeFiles map[string]string
+	// Content-hash paths of static images with images.dedupe (AssetService.Media)
+	media map[string]string
+
 	// Build coordination - prevents concurrent builds during watch mode
 	buildMu sync.Mutex
 }
@@ -70,10 +91,16 @@ func NewBuilderWithConfig(cfg *config.Config) *Builder {
 func newBuilderWithConfig(cfg *config.Config) *Builder {
 	utils.InitMinifier()
 
+	// Initialize build metrics first: the logger records warnings into them
+	buildMetrics := metrics.NewBuildMetrics()
+
 	// Initialize structured logger early
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	logger := slog.New(buildMetrics.LogHandler(logging.Handler()))
+
+	if cfg.Audience != "" && !config.ValidAudience(cfg.Audience) {
+		logger.Error("Invalid audience", "audience", cfg.Audience, "hint", "Use lowercase letters, digits, '-' and '_'")
+		os.Exit(1)
+	}
 
 	// Verify Theme Exists (Early Fail)
 	themePath := filepath.Join(cfg.ThemeDir, cfg.Theme)
@@ -104,9 +131,6 @@ func newBuilderWithConfig(cfg *config.Config) *Builder {
 		_ = os.MkdirAll(staticPath, 0755)
 	}
 
-	// Initialize build metrics
-	buildMetrics := metrics.NewBuildMetrics()
-
 	// Create cache directory if it doesn't exist
 	if err := os.MkdirAll(cfg.CacheDir, 0755); err != nil {
 		logger.Error("Failed to create cache directory", "path", cfg.CacheDir, "error", err)
@@ -165,8 +189,17 @@ func newBuilderWithConfig(cfg *config.Config) *Builder {
 	nativeRenderer := native.New()
 
 	// Initialize Filesystems
-	sourceFs := afero.NewOsFs()
-	destFs := afero.NewMemMapFs()
+	var sourceFs afero.Fs = afero.NewOsFs()
+	if points := mountPoints(cfg, logger); len(points) > 0 {
+		sourceFs = utils.NewMountFs(sourceFs, points)
+	}
+	// Output is staged in memory and synced to disk after the build, unless
+	// --low-memory asks for it to be written straight to disk
+	var destFs afero.Fs = afero.NewMemMapFs()
+	if cfg.LowMemory {
+		utils.SetLowMemory(true)
+		destFs = afero.NewOsFs()
+	}
 
 	// 3. Load theme metadata
 	themeMetadata := config.ThemeConfig{
@@ -185,8 +218,19 @@ func newBuilderWithConfig(cfg *config.Config) *Builder {
 	diagramCache := &sync.Map{}
 
 	// Create core components
-	md := mdParser.New(cfg.BaseURL, nativeRenderer, diagramCache)
-	rnd := renderer.New(cfg.CompressImages, destFs, cfg.TemplateDir, logger)
+	renderer.SetDataFetcher(remote.New(cfg.CacheDir, cfg.Build.RemoteTimeout, cfg.Offline, dataSources(cfg), logger))
+	renderer.SetMentionSource(mentionSource(cfg, logger))
+	templateStart := time.Now()
+	rnd := renderer.New(cfg.CompressImages, destFs, cfg.TemplateDirs(), logger)
+	buildMetrics.RecordTemplateCompile(time.Since(templateStart))
+	rnd.SetHeadSnippet(analyticsSnippet(cfg, logger))
+	if !cfg.IsDev {
+		// The dev server's live reload script is inline
+		rnd.SetContentSecurityPolicy(cfg.CSP)
+	}
+	if cfg.Preload.Enabled {
+		rnd.EnablePreload(cfg.OutputDir, cfg.Preload.Fonts)
+	}
 
 	// Create Services
 	var cacheSvc services.CacheService
@@ -194,9 +238,17 @@ func newBuilderWithConfig(cfg *config.Config) *Builder {
 		cacheSvc = services.NewCacheService(cacheManager, logger)
 	}
 
-	renderSvc := services.NewRenderService(rnd, logger)
-	assetSvc := services.NewAssetService(sourceFs, destFs, cfg, renderSvc, logger)
-	postSvc := services.NewPostService(cfg, cacheSvc, renderSvc, logger, buildMetrics, md, nativeRenderer, sourceFs, destFs, diagramAdapter)
+	bus := events.New()
+	hookRegistry := hooks.New()
+	renderSvc := services.NewRenderService(rnd, logger, bus, hookRegistry)
+	md := mdParser.New(cfg, nativeRenderer, diagramCache, mdParser.Media{
+		Gallery:    services.NewGalleryProvider(cfg, sourceFs, destFs, renderSvc, logger),
+		Video:      services.NewVideoProvider(cfg, sourceFs, destFs, renderSvc, logger),
+		Images:     services.NewImageProvider(cfg, sourceFs, logger),
+		Shortcodes: renderSvc,
+	})
+	assetSvc := services.NewAssetService(sourceFs, destFs, cfg, cacheSvc, renderSvc, logger, buildMetrics)
+	postSvc := services.NewPostService(cfg, cacheSvc, renderSvc, logger, buildMetrics, md, nativeRenderer, sourceFs, destFs, diagramAdapter, bus, hookRegistry)
 
 	builder := &Builder{
 		cfg:            cfg,
@@ -210,11 +262,89 @@ func newBuilderWithConfig(cfg *config.Config) *Builder {
 		SourceFs:       sourceFs,
 		DestFs:         destFs,
 		md:             md,
+		events:         bus,
+		hooks:          hookRegistry,
+	}
+	if cfg.LargeFiles.URL != "" && cfg.LargeFiles.Storage.Type != "" && !cfg.IsDev {
+		hookRegistry.AfterRender(builder.rewriteLargeFileLinks)
+	}
+	if cfg.DedupeImages() {
+		hookRegistry.AfterRender(builder.rewriteMediaLinks)
 	}
 
 	return builder
 }
 
+// mountPoints collects configured mounts and git content modules.
+// Modules are fetched into the cache on first use; a module that fails to
+// fetch is skipped so the rest of the site still builds.
+func mountPoints(cfg *config.Config, logger *slog.Logger) []utils.MountPoint {
+	points := make([]utils.MountPoint, 0, len(cfg.Mounts)+len(cfg.Modules))
+	for _, m := range cfg.Mounts {
+		if m.Source == "" || m.Target == "" {
+			continue
+		}
+		points = append(points, utils.MountPoint{Source: m.Source, Target: m.Target})
+	}
+
+	for _, m := range cfg.Modules {
+		dir, err := modules.Ensure(context.Background(), cfg.CacheDir, m)
+		if err != nil {
+			logger.Error("Failed to fetch content module", "url", m.URL, "ref", m.Ref, "error", err)
+			continue
+		}
+		points = append(points, utils.MountPoint{
+			Source: dir,
+			Target: filepath.Join(cfg.ContentDir, modules.Target(m)),
+		})
+	}
+	return points
+}
+
+func dataSources(cfg *config.Config) map[string]string {
+	sources := make(map[string]string, len(cfg.Data))
+	for _, ds := range cfg.Data {
+		sources[ds.Name] = ds.URL
+	}
+	return sources
+}
+
+// mentionSource returns the webmention.io client, or nil when webmentions aren't configured
+func mentionSource(cfg *config.Config, logger *slog.Logger) renderer.MentionSource {
+	if cfg.Webmentions.Domain == "" && cfg.Webmentions.Token == "" {
+		return nil
+	}
+	return webmention.New(cfg.CacheDir, cfg.Webmentions, cfg.BaseURL, cfg.Build.RemoteTimeout, cfg.Offline, logger)
+}
+
+// analyticsSnippet returns the analytics markup, which is only injected into
+// production builds so dev and draft previews never count as visits
+func analyticsSnippet(cfg *config.Config, logger *slog.Logger) string {
+	if cfg.IsDev || cfg.IncludeDrafts {
+		return ""
+	}
+	snippet, err := generators.AnalyticsSnippet(cfg.Analytics)
+	if err != nil {
+		logger.Warn("Analytics disabled", "error", err)
+		return ""
+	}
+	return snippet
+}
+
+// WatchPaths returns the paths the dev watcher should follow, including mount sources
+func (b *Builder) WatchPaths() []string {
+	paths := []string{b.cfg.ContentDir, b.cfg.IncludesDir, b.cfg.StaticDir, "kosh.yaml"}
+	paths = append(paths, b.cfg.TemplateDirs()...)
+	for _, m := range b.cfg.Mounts {
+		if m.Source != "" {
+			paths = append(paths, m.Source)
+		}
+	}
+	// Sass partials outside the theme
+	paths = append(paths, b.cfg.Sass.LoadPaths...)
+	return paths
+}
+
 // generateCacheID creates a fingerprint of all dependencies that affect output
 func generateCacheID(cfg *config.Config) string {
 	// Combine versions of all SSR dependencies
@@ -223,6 +353,11 @@ func generateCacheID(cfg *config.Config) string {
 		"goldmark:1.7",
 		"d2:0.7",
 		"katex:embedded",
+		"markdown:" + cfg.Markdown.Fingerprint(),
+	}
+	// Responsive images change every page with an image
+	if images := cfg.Images.Fingerprint(); images != "" && cfg.CompressImages {
+		components = append(components, "images:"+images)
 	}
 
 	combined := ""
@@ -238,6 +373,11 @@ func (b *Builder) Config() *config.Config {
 	return b.cfg
 }
 
+// Metrics returns the metrics recorded by the builder's last build
+func (b *Builder) Metrics() *metrics.BuildMetrics {
+	return b.metrics
+}
+
 // getFaviconPath returns the favicon path - uses custom logo if set, otherwise defaults to theme favicon
 func (b *Builder) getFaviconPath() string {
 	if b.cfg.Logo != "" {
@@ -314,6 +454,13 @@ func (b *Builder) checkWasmUpdate() {
 	}
 }
 
+// Events returns the builder's event bus. Subscribe before calling Build:
+//
+//	events.Subscribe(b.Events(), func(e events.CacheMiss) { ... })
+func (b *Builder) Events() *events.Bus {
+	return b.events
+}
+
 // SetDevMode enables/disables development mode (affects CSS hashing)
 func (b *Builder) SetDevMode(isDev bool) {
 	b.cfg.IsDev = isDev
@@ -342,11 +489,93 @@ func (b *Builder) SaveCaches() {
 	// Only print metrics in non-dev mode or on full builds
 	if !b.cfg.IsDev {
 		b.metrics.Print()
+		b.reportSlowPages()
+	}
+	if b.cfg.Report != "" {
+		b.writeReport()
 	}
 
 	b.logger.Info("Saved caches", "path", b.cfg.CacheDir)
 }
 
+// reportSlowPages prints and/or writes the slowest pages when asked to
+func (b *Builder) reportSlowPages() {
+	if b.cfg.SlowPages > 0 {
+		if logging.IsJSON() {
+			for _, p := range b.metrics.SlowestPages(b.cfg.SlowPages) {
+				b.logger.Info("Slow page", "page", p)
+			}
+		} else if report := b.metrics.SlowPagesString(b.cfg.SlowPages); report != "" {
+			logging.Statusf("%s", strings.TrimSuffix(report, "\n"))
+		}
+	}
+	if b.cfg.SlowPagesJSON != "" {
+		n := b.cfg.SlowPages
+		if n == 0 {
+			n = metrics.DefaultSlowPages
+		}
+		if err := b.metrics.WriteSlowPages(b.cfg.SlowPagesJSON, n); err != nil {
+			b.logger.Warn("Failed to write slow page report", "path", b.cfg.SlowPagesJSON, "error", err)
+		}
+	}
+}
+
+// maxErrorPages is how many affected pages are listed per template error
+const maxErrorPages = 3
+
+// reportTemplateErrors prints each distinct template failure of the last
+// render once, with the pages it broke, instead of one line per page
+func (b *Builder) reportTemplateErrors() {
+	errs := b.renderService.TakeTemplateErrors()
+	if len(errs) == 0 {
+		return
+	}
+	if logging.IsJSON() {
+		for _, e := range errs {
+			b.logger.Error("Template error", "location", e.Location(), "expr", e.Expr,
+				"error", e.Message, "pages", len(e.Pages), "example", e.Pages[0])
+		}
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "❌ %d template error(s):", len(errs))
+	for _, e := range errs {
+		b.metrics.RecordWarning(metrics.Warning{
+			Level:   slog.LevelError.String(),
+			Message: "Template error",
+			Attrs: map[string]string{
+				"location": e.Location(), "expr": e.Expr, "error": e.Message,
+				"pages": fmt.Sprint(len(e.Pages)), "example": e.Pages[0],
+			},
+		})
+
+		fmt.Fprintf(&sb, "\n   %s", e.Location())
+		if e.Expr != "" {
+			fmt.Fprintf(&sb, " at <%s>", e.Expr)
+		}
+		fmt.Fprintf(&sb, ": %s\n      %d page(s): %s", e.Message, len(e.Pages),
+			strings.Join(e.Pages[:min(len(e.Pages), maxErrorPages)], ", "))
+		if len(e.Pages) > maxErrorPages {
+			fmt.Fprintf(&sb, " (+%d more)", len(e.Pages)-maxErrorPages)
+		}
+	}
+	logging.Statusf("%s", sb.String())
+}
+
+// writeReport saves the machine-readable build report (--report)
+func (b *Builder) writeReport() {
+	report, err := b.metrics.Report(b.cfg.OutputDir)
+	if err != nil {
+		b.logger.Warn("Failed to size build output for the report", "error", err)
+	}
+	if err := report.Write(b.cfg.Report); err != nil {
+		b.logger.Warn("Failed to write build report", "path", b.cfg.Report, "error", err)
+		return
+	}
+	logging.Statusf("📋 Build report written to %s", b.cfg.Report)
+}
+
 // Close cleans up resources
 func (b *Builder) Close() {
 	if b.cacheService != nil {
@@ -354,12 +583,18 @@ func (b *Builder) Close() {
 	}
 }
 
-// Run executes the main build logic
-func Run(args []string) {
+// Run executes the main build logic and returns the build error, if any.
+// Cancelling ctx stops the build; what it parsed so far stays in the cache.
+func Run(ctx context.Context, args []string) error {
 	b := NewBuilder(args)
 	defer b.Close()
 	defer b.SaveCaches()
-	if err := b.Build(context.Background()); err != nil {
+	if err := b.Build(ctx); err != nil {
+		if ctx.Err() != nil {
+			return err // Reported by Build
+		}
 		b.logger.Error("Build failed", "error", err)
+		return err
 	}
+	return nil
 }
@@ -3,14 +3,18 @@ package run
 import (
 	"context"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	gParser "github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/events"
 	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/renderer"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 
 	"github.com/spf13/afero"
@@ -20,14 +24,30 @@ import (
 // invalidateForTemplate determines which posts to invalidate based on changed template
 func (b *Builder) invalidateForTemplate(templatePath string) []string {
 	tp := filepath.ToSlash(templatePath)
-	if strings.HasPrefix(tp, filepath.ToSlash(b.cfg.TemplateDir)) {
-		relTmpl, _ := utils.SafeRel(b.cfg.TemplateDir, tp)
+	for _, dir := range b.cfg.TemplateDirs() {
+		if !strings.HasPrefix(tp, filepath.ToSlash(dir)+"/") {
+			continue
+		}
+		relTmpl, _ := utils.SafeRel(dir, tp)
 		relTmpl = filepath.ToSlash(relTmpl)
 
 		if relTmpl == "layout.html" {
 			return nil // Layout changes affect everything
 		}
 
+		isPartial := strings.HasPrefix(relTmpl, renderer.PartialsDir+"/")
+		if isPartial {
+			users := b.renderService.TemplateUsers(relTmpl)
+			if len(users) == 0 {
+				return []string{} // Not included anywhere
+			}
+			for _, user := range users {
+				if user != "layout.html" && !isPageScoped(user) {
+					return nil // Index, 404 or graph include it: global pages change too
+				}
+			}
+		}
+
 		if b.cacheService != nil {
 			ids, err := b.cacheService.GetPostsByTemplate(relTmpl)
 			if err == nil && len(ids) > 0 {
@@ -35,12 +55,15 @@ func (b *Builder) invalidateForTemplate(templatePath string) []string {
 				if err == nil && len(posts) > 0 {
 					paths := make([]string, 0, len(posts))
 					for _, post := range posts {
-						paths = append(paths, post.Path)
+						paths = append(paths, filepath.Join(b.cfg.ContentDir, post.Path))
 					}
 					return paths
 				}
 			}
 		}
+		if isPartial {
+			return nil // Included by the layout but not recorded per post yet
+		}
 		return []string{}
 	}
 	if strings.HasPrefix(tp, filepath.ToSlash(b.cfg.StaticDir)) {
@@ -68,6 +91,11 @@ func (b *Builder) BuildChanged(ctx context.Context, changedPath string, op fsnot
 	default:
 	}
 
+	// Events from mounted directories arrive with their real path
+	if mfs, ok := b.SourceFs.(*utils.MountFs); ok {
+		changedPath = mfs.VirtualPath(changedPath)
+	}
+
 	b.logger.Info("⚡ Change detected", "path", changedPath, "op", op.String())
 
 	// Handle file deletion - remove from cache
@@ -83,26 +111,40 @@ func (b *Builder) BuildChanged(ctx context.Context, changedPath string, op fsnot
 		}
 	}
 
+	// Handle data files pages are built from (OpenAPI specs) - rebuild those pages
+	if b.rebuildFileUsers(ctx, changedPath) {
+		return
+	}
+
+	// Handle included fragments - rebuild the pages that include them
+	if b.isIncludePath(changedPath) {
+		b.rebuildIncluders(ctx, changedPath)
+		return
+	}
+
 	// Handle markdown files - single post rebuild
 	if strings.HasSuffix(changedPath, ".md") && strings.HasPrefix(changedPath, b.cfg.ContentDir) {
+		start := time.Now()
 		b.buildSinglePost(ctx, changedPath)
-		if err := utils.SyncVFS(b.DestFs, b.cfg.OutputDir, b.renderService.GetRenderedFiles()); err != nil {
+		b.reportTemplateErrors()
+		err := b.syncOutput(b.renderService.GetRenderedFiles())
+		if err != nil {
 			b.logger.Error("Sync failed", "error", err)
+		} else if err = b.afterBuild(changedPath); err != nil {
+			b.logger.Error("Build failed", "error", err)
+		}
+		b.events.Publish(events.BuildFinished{Duration: time.Since(start), Err: err, Changed: changedPath})
+		if err != nil {
 			return
 		}
 		b.renderService.ClearRenderedFiles()
+		b.writeStatus()
 		return
 	}
 
-	// Handle CSS/JS changes - do full rebuild to update HTML with new asset hashes
-	ext := strings.ToLower(filepath.Ext(changedPath))
-	if (ext == ".css" || ext == ".js") && b.isAssetPath(changedPath) {
-		b.logger.Info("🎨 CSS/JS changed, running full rebuild...")
-		if err := b.Build(ctx); err != nil {
-			b.logger.Error("Build failed", "error", err)
-			return
-		}
-		b.SaveCaches()
+	// Handle stylesheet (Sass partials included) and script changes - rebuild the bundles only
+	if b.isBundledPath(changedPath) {
+		b.rebuildAssets(ctx, changedPath)
 		return
 	}
 
@@ -123,6 +165,156 @@ func (b *Builder) isAssetPath(path string) bool {
 	return strings.HasPrefix(path, staticDir) || strings.HasPrefix(path, siteStaticDir)
 }
 
+// isIncludePath checks if a path is in the includes directory
+func (b *Builder) isIncludePath(path string) bool {
+	return strings.HasPrefix(filepath.ToSlash(path), filepath.ToSlash(b.cfg.IncludesDir)+"/")
+}
+
+// rebuildIncluders handles a changed, added or removed fragment: the pages
+// including it are re-processed, the rest of the site is left alone
+func (b *Builder) rebuildIncluders(ctx context.Context, changedPath string) {
+	if b.cacheService == nil {
+		if err := b.Build(ctx); err != nil {
+			b.logger.Error("Build failed", "error", err)
+		}
+		return
+	}
+	rel, _ := utils.SafeRel(b.cfg.IncludesDir, changedPath)
+	name := filepath.ToSlash(rel)
+	ids, err := b.cacheService.GetPostsByInclude(name)
+	if err != nil {
+		b.logger.Error("Failed to look up pages including fragment", "include", name, "error", err)
+		return
+	}
+	if len(ids) == 0 {
+		b.logger.Info("🧩 Fragment changed, no page includes it", "include", name)
+		return
+	}
+	b.logger.Info("🧩 Fragment changed, rebuilding the pages including it", "include", name, "pages", len(ids))
+	b.rebuildPages(ctx, changedPath, ids)
+}
+
+// rebuildFileUsers handles a changed data file, like an OpenAPI spec: the
+// pages built from it are re-processed. It reports false when no page is,
+// leaving the change to a full rebuild.
+func (b *Builder) rebuildFileUsers(ctx context.Context, changedPath string) bool {
+	if b.cacheService == nil {
+		return false
+	}
+	name := filepath.ToSlash(changedPath)
+	ids, err := b.cacheService.GetPostsByFile(name)
+	if err != nil || len(ids) == 0 {
+		return false
+	}
+	b.logger.Info("📄 Data file changed, rebuilding the pages built from it", "file", name, "pages", len(ids))
+	b.rebuildPages(ctx, changedPath, ids)
+	return true
+}
+
+// rebuildPages re-processes the cached pages with the given IDs after a
+// change to something they depend on, then syncs the output
+func (b *Builder) rebuildPages(ctx context.Context, changedPath string, ids []string) {
+	posts, err := b.cacheService.GetPostsByIDs(ids)
+	if err != nil {
+		b.logger.Error("Failed to look up dependent pages", "path", changedPath, "error", err)
+		return
+	}
+	paths := make([]string, 0, len(posts))
+	for _, post := range posts {
+		paths = append(paths, filepath.Join(b.cfg.ContentDir, post.Path))
+	}
+	slices.Sort(paths)
+
+	start := time.Now()
+	for _, path := range paths {
+		if err := b.postService.ProcessSingle(ctx, path); err != nil {
+			b.logger.Error("Failed to process single post", "path", path, "error", err)
+			if err := b.Build(ctx); err != nil {
+				b.logger.Error("Build failed", "error", err)
+				return
+			}
+			b.SaveCaches()
+			return
+		}
+	}
+	b.SaveCaches()
+	b.reportTemplateErrors()
+	err = b.syncOutput(b.renderService.GetRenderedFiles())
+	if err != nil {
+		b.logger.Error("Sync failed", "error", err)
+	} else if err = b.afterBuild(changedPath); err != nil {
+		b.logger.Error("Build failed", "error", err)
+	}
+	b.events.Publish(events.BuildFinished{Duration: time.Since(start), Err: err, Changed: changedPath})
+	if err != nil {
+		return
+	}
+	b.renderService.ClearRenderedFiles()
+	b.writeStatus()
+}
+
+// isBundledPath checks if a path is built by esbuild: a stylesheet among
+// the static assets or in a Sass load path, or a script of the static
+// assets other than those copied as they are
+func (b *Builder) isBundledPath(path string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".js") {
+		switch filepath.Base(path) {
+		case "wasm_exec.js", "wasm_engine.js", "engine.js":
+			return false
+		}
+		return b.isAssetPath(path)
+	}
+	if !utils.IsStylesheet(path) {
+		return false
+	}
+	if b.isAssetPath(path) {
+		return true
+	}
+	path = filepath.ToSlash(path)
+	for _, dir := range b.cfg.Sass.LoadPaths {
+		if dir = strings.TrimSuffix(filepath.ToSlash(dir), "/"); strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// rebuildAssets handles a changed stylesheet, Sass partial or script: only
+// the CSS and JS bundles are rebuilt and synced. Pages are re-rendered only
+// when a bundle's published path changed, as hashed names do in minified
+// builds.
+func (b *Builder) rebuildAssets(ctx context.Context, changedPath string) {
+	start := time.Now()
+	pathsChanged, err := b.assetService.BuildBundles(ctx)
+	if err != nil {
+		b.logger.Error("Failed to build assets", "error", err)
+		b.events.Publish(events.BuildFinished{Duration: time.Since(start), Err: err, Changed: changedPath})
+		return
+	}
+	if pathsChanged {
+		b.logger.Info("🎨 Asset paths changed, running full rebuild...")
+		if err := b.Build(ctx); err != nil {
+			b.logger.Error("Build failed", "error", err)
+			return
+		}
+		b.SaveCaches()
+		return
+	}
+
+	b.logger.Info("🎨 Assets rebuilt")
+	err = b.syncOutput(b.renderService.GetRenderedFiles())
+	if err != nil {
+		b.logger.Error("Sync failed", "error", err)
+	} else if err = b.afterBuild(changedPath); err != nil {
+		b.logger.Error("Build failed", "error", err)
+	}
+	b.events.Publish(events.BuildFinished{Duration: time.Since(start), Err: err, Changed: changedPath})
+	if err != nil {
+		return
+	}
+	b.renderService.ClearRenderedFiles()
+}
+
 // buildSinglePost rebuilds only the changed post with smart change detection
 func (b *Builder) buildSinglePost(ctx context.Context, path string) {
 	source, err := afero.ReadFile(b.SourceFs, path)
@@ -140,7 +332,9 @@ func (b *Builder) buildSinglePost(ctx context.Context, path string) {
 	b.md.Parser().Parse(reader, gParser.WithContext(context))
 	metaData := meta.Get(context)
 	newFrontmatterHash, _ := utils.GetFrontmatterHash(metaData)
-	newBodyHash := utils.GetBodyHash(source)
+	version, _ := utils.GetVersionFromPath(path)
+	conditioned, _ := mdParser.ApplyConditions(source, mdParser.PageConditions(b.cfg, version))
+	newBodyHash := utils.GetBodyHash(conditioned) // Hashed like PostService does
 
 	relPath, _ := utils.SafeRel(b.cfg.ContentDir, path)
 
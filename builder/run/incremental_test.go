@@ -1,9 +1,14 @@
 package run
 
 import (
+	"path/filepath"
+	"slices"
 	"testing"
 
+	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/services"
+	"github.com/Kush-Singh-26/kosh/builder/services/mocks"
 )
 
 func TestIsAssetPath(t *testing.T) {
@@ -61,6 +66,31 @@ func TestIsAssetPath(t *testing.T) {
 	}
 }
 
+func TestIsBundledPath(t *testing.T) {
+	b := &Builder{cfg: &config.Config{
+		StaticDir: "themes/test-theme/static",
+		Sass:      config.SassConfig{LoadPaths: []string{"node_modules/bootstrap/scss/"}},
+	}}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"themes/test-theme/static/css/_vars.scss", true},
+		{"node_modules/bootstrap/scss/_buttons.scss", true},
+		{"node_modules/bootstrap/scss-extra/_buttons.scss", false},
+		{"themes/test-theme/static/js/search.js", true},
+		{"themes/test-theme/static/js/wasm_exec.js", false}, // Copied, not bundled
+		{"static/js/main.js", true},                         // The site's scripts are built over the theme's
+		{"themes/test-theme/static/images/logo.png", false},
+		{"content/post.md", false},
+	}
+	for _, tt := range tests {
+		if got := b.isBundledPath(tt.path); got != tt.want {
+			t.Errorf("isBundledPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestInvalidateForTemplate(t *testing.T) {
 	templateDir := "themes/test-theme/templates"
 	staticDir := "themes/test-theme/static"
@@ -116,3 +146,61 @@ func TestInvalidateForTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestInvalidateForPartial(t *testing.T) {
+	templateDir := "themes/test-theme/templates"
+
+	renderSvc := mocks.NewMockRenderService()
+	renderSvc.TemplateDepsMap = map[string][]string{
+		"layout.html":          {"partials/footer.html", "partials/sidebar.html"},
+		"index.html":           {"partials/footer.html"},
+		"layouts/docs.html":    {"partials/sidebar.html", "partials/toc.html"},
+		"shortcodes/note.html": {"partials/icon.html"},
+	}
+	cacheSvc := mocks.NewMockCacheService()
+	cacheSvc.Posts["p1"] = &cache.PostMeta{PostID: "p1", Path: "v1.0/intro.md"}
+	cacheSvc.Posts["p2"] = &cache.PostMeta{PostID: "p2", Path: "docs/setup.md"}
+	cacheSvc.PostsByTemplate = map[string][]string{
+		"partials/sidebar.html": {"p1"},
+		"partials/toc.html":     {"p2"},
+		"layouts/docs.html":     {"p2"},
+		"shortcodes/note.html":  {"p1"},
+		"partials/icon.html":    {"p1"},
+	}
+
+	tests := []struct {
+		name    string
+		partial string
+		cache   services.CacheService
+		wantNil bool
+		want    []string
+	}{
+		{"layout-only partial re-renders recorded posts", "partials/sidebar.html", cacheSvc, false, []string{filepath.Join("content", "v1.0/intro.md")}},
+		{"partial used by global pages forces a rebuild", "partials/footer.html", cacheSvc, true, nil},
+		{"unused partial affects nothing", "partials/unused.html", cacheSvc, false, []string{}},
+		{"partial of a layout re-renders its posts", "partials/toc.html", cacheSvc, false, []string{filepath.Join("content", "docs/setup.md")}},
+		{"layout re-renders its posts", "layouts/docs.html", cacheSvc, false, []string{filepath.Join("content", "docs/setup.md")}},
+		{"unused layout affects nothing", "layouts/landing.html", cacheSvc, false, []string{}},
+		{"shortcode re-renders the posts using it", "shortcodes/note.html", cacheSvc, false, []string{filepath.Join("content", "v1.0/intro.md")}},
+		{"partial of a shortcode re-renders its posts", "partials/icon.html", cacheSvc, false, []string{filepath.Join("content", "v1.0/intro.md")}},
+		{"unused shortcode affects nothing", "shortcodes/unused.html", cacheSvc, false, []string{}},
+		{"no recorded deps falls back to a rebuild", "partials/sidebar.html", mocks.NewMockCacheService(), true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Builder{
+				cfg:           &config.Config{TemplateDir: templateDir, ContentDir: "content"},
+				renderService: renderSvc,
+				cacheService:  tt.cache,
+			}
+			got := b.invalidateForTemplate(filepath.Join(templateDir, tt.partial))
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("invalidateForTemplate(%q) returned nil=%v, want nil=%v", tt.partial, got == nil, tt.wantNil)
+			}
+			if !tt.wantNil && !slices.Equal(got, tt.want) {
+				t.Errorf("invalidateForTemplate(%q) = %v, want %v", tt.partial, got, tt.want)
+			}
+		})
+	}
+}
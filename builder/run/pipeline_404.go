@@ -0,0 +1,51 @@
+package run
+
+import (
+	"path/filepath"
+
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// render404s renders the site 404 page plus one per documentation version,
+// so hosts that resolve 404.html per directory keep visitors inside the
+// version they were browsing. Suggestions come from the search records, which
+// cover every version; each page only suggests pages of its own version.
+func (b *Builder) render404s(indexedPosts []models.IndexedPost) {
+	cfg := b.cfg
+
+	records := make([]models.PostRecord, len(indexedPosts))
+	for i, p := range indexedPosts {
+		records[i] = p.Record
+	}
+	rootVersions := []string{""}
+	for _, v := range cfg.Versions {
+		if v.IsLatest && v.Path != "" {
+			rootVersions = append(rootVersions, v.Path)
+		}
+	}
+
+	b.renderService.Render404(filepath.Join(cfg.OutputDir, "404.html"), models.PageData{
+		BaseURL:       cfg.BaseURL,
+		BuildVersion:  cfg.BuildVersion,
+		Config:        cfg,
+		TabTitle:      "404 - Page Not Found | " + cfg.Title,
+		Versions:      cfg.GetVersionsMetadata("", ""),
+		NotFoundPages: generators.NotFoundIndex(records, cfg.BaseURL, rootVersions...),
+	})
+
+	for _, v := range cfg.Versions {
+		if v.Path == "" {
+			continue
+		}
+		b.renderService.Render404(filepath.Join(cfg.OutputDir, v.Path, "404.html"), models.PageData{
+			BaseURL:        cfg.BaseURL,
+			BuildVersion:   cfg.BuildVersion,
+			Config:         cfg,
+			TabTitle:       "404 - Page Not Found | " + v.Name + " | " + cfg.Title,
+			CurrentVersion: v.Path,
+			Versions:       cfg.GetVersionsMetadata(v.Path, ""),
+			NotFoundPages:  generators.NotFoundIndex(records, cfg.BaseURL, v.Path),
+		})
+	}
+}
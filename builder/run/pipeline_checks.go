@@ -0,0 +1,110 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/checks"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/metrics"
+)
+
+// maxFindingsShown is how many findings of each class are listed
+const maxFindingsShown = 10
+
+// runStrictChecks checks the synced output and its content for --strict.
+// Every finding is reported; only classes in strict.checks (all by default)
+// fail the build.
+func (b *Builder) runStrictChecks() error {
+	findings, err := checks.Run(checks.Options{
+		ContentFs:     b.SourceFs,
+		ContentDir:    b.cfg.ContentDir,
+		OutputFs:      afero.NewOsFs(), // Synced output, including pages this build skipped
+		OutputDir:     b.cfg.OutputDir,
+		BaseURL:       b.cfg.BaseURL,
+		MaxImageBytes: int64(b.cfg.Strict.MaxImageKB) * 1024,
+		IncludeDrafts: b.cfg.IncludeDrafts,
+		Prose:         b.cfg.Strict.Prose,
+	})
+	if err != nil {
+		return fmt.Errorf("strict checks: %w", err)
+	}
+	if err := checks.SaveLatest(b.cfg.CacheDir, findings, time.Now()); err != nil {
+		b.logger.Warn("Failed to save check findings", "error", err)
+	}
+
+	failing := b.cfg.Strict.Checks
+	if len(failing) == 0 {
+		for _, c := range checks.Classes {
+			failing = append(failing, string(c))
+		}
+	}
+
+	byClass := make(map[checks.Class][]checks.Finding)
+	for _, f := range findings {
+		byClass[f.Class] = append(byClass[f.Class], f)
+	}
+
+	var failed []string
+	failures := 0
+	for _, class := range checks.Classes {
+		found := byClass[class]
+		if len(found) == 0 {
+			continue
+		}
+		fails := slices.Contains(failing, string(class))
+		if fails {
+			failed = append(failed, string(class))
+			failures += len(found)
+		}
+		b.reportFindings(class, found, fails)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("strict mode: %d problem(s) (%s)", failures, strings.Join(failed, ", "))
+	}
+	if len(findings) == 0 {
+		logging.Statusf("✅ Strict checks passed")
+	}
+	return nil
+}
+
+// reportFindings prints the findings of one class as an error when the class
+// fails the build, as a warning otherwise
+func (b *Builder) reportFindings(class checks.Class, found []checks.Finding, fails bool) {
+	level := slog.LevelWarn
+	icon := "⚠️"
+	if fails {
+		level, icon = slog.LevelError, "❌"
+	}
+
+	if logging.IsJSON() {
+		for _, f := range found {
+			b.logger.Log(context.Background(), level, "Check failed", "check", string(class), "page", f.Page, "problem", f.Message)
+		}
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s: %d problem(s)", icon, class, len(found))
+	for i, f := range found {
+		b.metrics.RecordWarning(metrics.Warning{
+			Level:   level.String(),
+			Message: "Check failed",
+			Attrs:   map[string]string{"check": string(class), "page": f.Page, "problem": f.Message},
+		})
+		if i < maxFindingsShown {
+			fmt.Fprintf(&sb, "\n   %s: %s", f.Page, f.Message)
+		}
+	}
+	if len(found) > maxFindingsShown {
+		fmt.Fprintf(&sb, "\n   (+%d more)", len(found)-maxFindingsShown)
+	}
+	logging.Statusf("%s", sb.String())
+}
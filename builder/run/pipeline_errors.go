@@ -0,0 +1,79 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/metrics"
+)
+
+// limitErrors applies --max-errors and --fail-fast to one build. The returned
+// context is cancelled at the first error when failing fast; finish prints
+// the grouped error summary and turns a stopped build or an exceeded budget
+// into the build's error.
+func (b *Builder) limitErrors(ctx context.Context) (context.Context, func(error) error) {
+	if b.cfg.MaxErrors <= 0 && !b.cfg.FailFast && b.cfg.ErrorSummary == "" {
+		return ctx, func(err error) error { return err }
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := b.metrics.LimitErrors(b.cfg.MaxErrors, b.cfg.FailFast, cancel)
+	return ctx, func(err error) error {
+		cancel()
+		summary := done()
+		b.printErrorSummary(summary)
+		if b.cfg.ErrorSummary != "" {
+			if werr := summary.Write(b.cfg.ErrorSummary); werr != nil {
+				logging.Statusf("⚠️ Failed to write error summary %s: %v", b.cfg.ErrorSummary, werr)
+			}
+		}
+
+		switch {
+		case summary.Stopped:
+			return errors.New("stopped at the first error (--fail-fast)")
+		case err != nil:
+			return err
+		case b.cfg.MaxErrors > 0 && summary.Errors > b.cfg.MaxErrors:
+			return fmt.Errorf("%d errors, more than --max-errors %d", summary.Errors, b.cfg.MaxErrors)
+		}
+		return nil
+	}
+}
+
+// printErrorSummary lists the build's errors grouped by type, with a few of
+// the pages each one hit
+func (b *Builder) printErrorSummary(s metrics.ErrorSummary) {
+	if s.Errors == 0 {
+		return
+	}
+	if logging.IsJSON() {
+		// Not through b.logger: the summary isn't an error of its own
+		slog.Default().Error("Error summary", "errors", s.Errors, "shown", s.Shown, "stopped", s.Stopped, "groups", s.Groups)
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "❌ %d error(s)", s.Errors)
+	if s.Shown < s.Errors {
+		fmt.Fprintf(&sb, ", %d shown", s.Shown)
+	}
+	if s.Stopped {
+		sb.WriteString(", build stopped at the first one")
+	}
+	sb.WriteString(":")
+	for _, g := range s.Groups {
+		fmt.Fprintf(&sb, "\n   %5d× %s", g.Count, g.Type)
+		if len(g.Examples) > 0 {
+			fmt.Fprintf(&sb, " (%s", strings.Join(g.Examples, ", "))
+			if g.Count > len(g.Examples) {
+				sb.WriteString(", …")
+			}
+			sb.WriteString(")")
+		}
+	}
+	logging.Statusf("%s", sb.String())
+}
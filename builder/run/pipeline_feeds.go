@@ -0,0 +1,78 @@
+package run
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// rssLink is the URL of the RSS feed in the feed directory dirURL, "" when
+// no RSS feed is written
+func (b *Builder) rssLink(dirURL string) string {
+	if !b.cfg.Features.Generators.RSS || !slices.Contains(b.cfg.Feeds.Formats, "rss") {
+		return ""
+	}
+	return dirURL + "/rss.xml"
+}
+
+// writeFeed writes a feed in every configured format into dir and registers
+// its files for the sync
+func (b *Builder) writeFeed(dir string, feed generators.Feed) {
+	feed.Posts = generators.FeedPosts(feed.Posts, b.cfg.Feeds.Limit)
+	if feed.Author == "" {
+		feed.Author = b.cfg.Author.Name
+	}
+	written, err := generators.GenerateFeeds(b.DestFs, dir, feed, b.cfg.Feeds.Formats)
+	if err != nil {
+		b.logger.Error("Failed to write feed", "dir", dir, "error", err)
+	}
+	for _, path := range written {
+		b.renderService.RegisterFile(path)
+	}
+}
+
+// generateFeeds writes the site's feed, of the latest version, and with
+// feeds.tags and feeds.versions one per tag and per older version
+func (b *Builder) generateFeeds(allContent []models.PostMetadata, tagMap, versionPosts map[string][]models.PostMetadata) {
+	cfg := b.cfg
+	logging.Statusf("📡 Generating feeds...")
+	b.writeFeed(cfg.OutputDir, generators.Feed{
+		Title: cfg.Title, Description: cfg.Description,
+		Link: cfg.BaseURL, URL: cfg.BaseURL, Posts: allContent,
+	})
+
+	if cfg.Feeds.Tags {
+		for t, posts := range tagMap {
+			var latest []models.PostMetadata
+			for _, p := range posts {
+				if cfg.IsLatestVersion(p.Version) {
+					latest = append(latest, p)
+				}
+			}
+			if len(latest) == 0 {
+				continue
+			}
+			b.writeFeed(filepath.Join(cfg.OutputDir, "tags", t), generators.Feed{
+				Title: "#" + t + " | " + cfg.Title, Description: cfg.Description,
+				Link: cfg.BaseURL + "/tags/" + t + ".html", URL: cfg.BaseURL + "/tags/" + t, Posts: latest,
+			})
+		}
+	}
+
+	if cfg.Feeds.Versions {
+		for _, v := range cfg.Versions {
+			posts := versionPosts[v.Name]
+			if v.Path == "" || len(posts) == 0 {
+				continue
+			}
+			home := cfg.BaseURL + "/" + v.Path
+			b.writeFeed(filepath.Join(cfg.OutputDir, v.Path), generators.Feed{
+				Title: cfg.Title + " " + v.Name, Description: cfg.Description,
+				Link: home + "/", URL: home, Posts: posts,
+			})
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/services"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// languageCodes returns the codes of languages in a stable order
+func languageCodes(languages map[string]*services.LanguagePosts) []string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// languagePage fills in what every list page of a language shares. Assets
+// keep loading from the site root: only links point into /<code>/.
+func (b *Builder) languagePage(code string, data models.PageData) models.PageData {
+	data.BaseURL, data.BuildVersion, data.Config = b.cfg.BaseURL, b.cfg.BuildVersion, b.cfg
+	data.Lang, data.LangURL = code, b.cfg.LanguageURL(code)
+	data.Languages = b.cfg.GetLanguagesMetadata(code, "")
+	if data.Description == "" {
+		data.Description = b.cfg.Description
+	}
+	return data
+}
+
+// renderLanguages writes the home pages of every language but the default
+// one at /<code>/, paginated like the site's own
+func (b *Builder) renderLanguages(languages map[string]*services.LanguagePosts) {
+	cfg := b.cfg
+	for _, code := range languageCodes(languages) {
+		lp := languages[code]
+		home, outDir, title := cfg.LanguageURL(code), filepath.Join(cfg.OutputDir, code), cfg.LanguageTitle(code)
+
+		list := b.newListPage(models.ListHome, len(lp.AllPosts))
+		list.RSSLink = b.rssLink(home)
+		siteTree := utils.BuildSiteTree(lp.AllPosts, "")
+		pages := paginateList(lp.AllPosts, cfg.PostsPerPage, func(i int) (string, string) {
+			if i == 1 {
+				return filepath.Join(outDir, "index.html"), home + "/"
+			}
+			return filepath.Join(outDir, "page", fmt.Sprint(i), "index.html"), fmt.Sprintf("%s/page/%d/", home, i)
+		})
+		for i, page := range pages {
+			var pinned []models.PostMetadata
+			if i == 0 {
+				pinned = lp.PinnedPosts
+			}
+			_ = b.DestFs.MkdirAll(filepath.Dir(page.destPath), 0755)
+			b.renderService.RenderIndex(page.destPath, b.languagePage(code, models.PageData{
+				Title: title, TabTitle: title, Posts: page.posts, PinnedPosts: pinned,
+				Permalink: page.permalink, Paginator: page.paginator,
+				Image:    cfg.BaseURL + "/static/images/cards/home.webp",
+				SiteTree: siteTree, List: list,
+			}))
+		}
+	}
+}
+
+// renderLanguageTags writes the tag pages of every language but the default
+// one under /<code>/tags/. They reuse the site's tag index card.
+func (b *Builder) renderLanguageTags(languages map[string]*services.LanguagePosts) {
+	cfg := b.cfg
+	perPage := 0
+	if b.hasTemplate("term.html") || b.hasTemplate("list.html") {
+		perPage = cfg.PostsPerPage
+	}
+	for _, code := range languageCodes(languages) {
+		lp := languages[code]
+		home, outDir, title := cfg.LanguageURL(code), filepath.Join(cfg.OutputDir, code), cfg.LanguageTitle(code)
+		image := cfg.BaseURL + "/static/images/cards/tags/index.webp"
+
+		var allTags []models.TagData
+		for t, posts := range lp.TagMap {
+			allTags = append(allTags, models.TagData{Name: t, Count: len(posts), Link: fmt.Sprintf("%s/tags/%s.html", home, t)})
+		}
+		sort.Slice(allTags, func(i, j int) bool { return allTags[i].Name < allTags[j].Name })
+
+		tagsList := b.newListPage(models.ListTags, len(lp.TagMap))
+		tagsList.Terms = allTags
+		_ = b.DestFs.MkdirAll(filepath.Join(outDir, "tags"), 0755)
+		b.renderService.RenderIndex(filepath.Join(outDir, "tags", "index.html"), b.languagePage(code, models.PageData{
+			Title: "All Tags", IsTagsIndex: true, AllTags: allTags, List: tagsList,
+			Permalink: home + "/tags/index.html", Image: image,
+			TabTitle: "All Topics | " + title,
+		}))
+
+		for t, posts := range lp.TagMap {
+			utils.SortPosts(posts)
+			list := b.newListPage(models.ListTerm, len(posts))
+			list.Term, list.Terms = t, allTags
+			pages := paginateList(posts, perPage, func(i int) (string, string) {
+				if i == 1 {
+					return filepath.Join(outDir, "tags", t+".html"), fmt.Sprintf("%s/tags/%s.html", home, t)
+				}
+				return filepath.Join(outDir, "tags", t, "page", fmt.Sprintf("%d.html", i)), fmt.Sprintf("%s/tags/%s/page/%d.html", home, t, i)
+			})
+			for _, page := range pages {
+				_ = b.DestFs.MkdirAll(filepath.Dir(page.destPath), 0755)
+				b.renderService.RenderIndex(page.destPath, b.languagePage(code, models.PageData{
+					Title: "#" + t, IsIndex: true, Posts: page.posts, List: list,
+					Permalink: page.permalink, Paginator: page.paginator, Image: image,
+					TabTitle: "#" + t + " | " + title,
+				}))
+			}
+		}
+	}
+}
+
+// generateLanguageMetadata writes the search index, feeds and sitemap of
+// every language but the default one under /<code>/
+func (b *Builder) generateLanguageMetadata(languages map[string]*services.LanguagePosts) {
+	cfg := b.cfg
+	for _, code := range languageCodes(languages) {
+		lp := languages[code]
+		home, outDir := cfg.LanguageURL(code), filepath.Join(cfg.OutputDir, code)
+		allContent := append(append([]models.PostMetadata{}, lp.AllPosts...), lp.PinnedPosts...)
+		_ = b.DestFs.MkdirAll(outDir, 0755)
+
+		// Unlike the site's own, these aren't synced unconditionally
+		if cfg.Features.Generators.Sitemap {
+			path := filepath.Join(outDir, "sitemap", "sitemap.xml")
+			written := generators.GenerateSitemap(b.DestFs, home, outDir, allContent, lp.TagMap, path, cfg.Sitemap)
+			for _, p := range append(written, path) {
+				b.renderService.RegisterFile(p)
+			}
+		}
+		if cfg.Features.Generators.RSS {
+			b.writeFeed(outDir, generators.Feed{
+				Title: cfg.LanguageTitle(code), Description: cfg.Description,
+				Link: home, URL: home, Posts: allContent,
+			})
+		}
+		if cfg.Features.Generators.Search {
+			if err := generators.GenerateSearchIndex(b.DestFs, outDir, lp.IndexedPosts, nil, cfg.Search.Boost); err != nil {
+				b.logger.Error("Failed to generate search index", "language", code, "error", err)
+			} else {
+				b.renderService.RegisterFile(filepath.Join(outDir, "search.bin"))
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+	"github.com/Kush-Singh-26/kosh/internal/deploy"
+)
+
+// largeFilesRecord lists, in the cache, the files the last build moved to
+// largeFiles.storage
+const largeFilesRecord = "large-files.json"
+
+// scanLargeFiles returns the static files that go to largeFiles.storage,
+// keyed by their slash-separated output path ("static/video/intro.mp4"),
+// with the source each is read from. A site file overrides the theme's.
+func (b *Builder) scanLargeFiles() map[string]string {
+	files := make(map[string]string)
+	if b.cfg.LargeFiles.URL == "" || b.cfg.LargeFiles.Storage.Type == "" || b.cfg.IsDev {
+		return files
+	}
+	for _, dir := range []string{b.cfg.StaticDir, "static"} {
+		_ = afero.Walk(b.SourceFs, dir, func(path string, info fs.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			rel, err := utils.SafeRel(dir, path)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if b.cfg.LargeFile(rel, info.Size()) {
+				files["static/"+rel] = path
+			} else {
+				delete(files, "static/"+rel)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// largeFilesChanged reports whether the files moved to storage differ from
+// the last build's, whose pages link the others, and records the current set
+func (b *Builder) largeFilesChanged(files map[string]string) bool {
+	return b.recordChanged(largeFilesRecord, slices.Sorted(maps.Keys(files)))
+}
+
+// uploadLargeFiles puts the large files in a staging directory in the cache
+// and publishes it to largeFiles.storage, which keeps a deploy manifest like
+// any deploy target: only changed files are uploaded, and files no longer
+// large (or gone) are deleted. Copies an earlier build left in the output
+// are removed.
+func (b *Builder) uploadLargeFiles(ctx context.Context, files map[string]string) error {
+	for key := range files {
+		target := filepath.Join(b.cfg.OutputDir, filepath.FromSlash(key))
+		_ = b.DestFs.Remove(target)
+		if !b.cfg.LowMemory {
+			_ = os.Remove(target)
+		}
+	}
+	if b.cfg.LargeFiles.URL == "" || b.cfg.LargeFiles.Storage.Type == "" || b.cfg.IsDev {
+		return nil
+	}
+	if b.cfg.Offline {
+		b.logger.Warn("Offline: large files not uploaded", "files", len(files))
+		return nil
+	}
+
+	stage := filepath.Join(b.cfg.CacheDir, "large-files")
+	if err := os.RemoveAll(stage); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", stage, err)
+	}
+	if err := os.MkdirAll(stage, 0755); err != nil {
+		return err
+	}
+	for key, src := range files {
+		if err := b.stageLargeFile(src, filepath.Join(stage, filepath.FromSlash(key))); err != nil {
+			return err
+		}
+	}
+
+	storage := b.cfg.LargeFiles.Storage
+	target, err := deploy.NewTarget(b.cfg, storage)
+	if err != nil {
+		return fmt.Errorf("large files: %w", err)
+	}
+	plan, err := deploy.Deploy(ctx, target, stage, deploy.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to upload large files to %s: %w", storage.DisplayName(), err)
+	}
+	if !plan.Empty() {
+		logging.Statusf("   🗄️  Large files on %s: %d uploaded, %d deleted, %d unchanged", storage.DisplayName(), len(plan.Upload), len(plan.Delete), plan.Unchanged)
+	}
+	return nil
+}
+
+// stageLargeFile hard-links src into the staging directory, or copies it
+// where it can't be linked
+func (b *Builder) stageLargeFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := b.SourceFs.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to stage %s: %w", src, err)
+	}
+	return out.Close()
+}
+
+// rewriteLargeFileLinks points the links of a page to files in
+// b.largeFiles, with or without the base URL, at largeFiles.url. It is the
+// after-render hook registered when largeFiles is set.
+func (b *Builder) rewriteLargeFileLinks(_ string, html []byte) ([]byte, error) {
+	if len(b.largeFiles) == 0 {
+		return html, nil
+	}
+	storageURL := strings.TrimSuffix(b.cfg.LargeFiles.URL, "/")
+	return rewriteStaticLinks(html, b.cfg.BaseURL, func(rel string) (string, bool) {
+		if _, ok := b.largeFiles[rel]; !ok {
+			return "", false
+		}
+		return storageURL + "/" + (&url.URL{Path: rel}).EscapedPath(), true
+	}), nil
+}
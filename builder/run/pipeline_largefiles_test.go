@@ -0,0 +1,82 @@
+package run
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestScanLargeFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	big := make([]byte, 2048)
+	for path, size := range map[string]int{
+		"themes/blog/static/video/intro.mp4":     2048,
+		"themes/blog/static/video/demo.mp4":      2048, // The site's is small
+		"themes/blog/static/js/huge.js":          2048, // Bundled
+		"themes/blog/static/wasm/search.wasm.gz": 2048, // Fetched by search
+		"static/video/demo.mp4":                  10,
+		"static/files/Release Notes.pdf":         2048,
+		"static/images/photo.png":                2048, // Compressed
+		"static/files/notes.txt":                 2048, // Not in extensions
+	} {
+		if err := afero.WriteFile(fs, path, big[:size], 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := &Builder{
+		cfg: &config.Config{
+			StaticDir:      "themes/blog/static",
+			CompressImages: true,
+			LargeFiles: config.LargeFilesConfig{
+				ThresholdKB: 1,
+				Extensions:  []string{".mp4", "pdf", ".js", ".png", ".gz"},
+				URL:         "https://media.example.com/",
+				Storage:     config.DeployTarget{Type: "rsync", Dest: "media:/srv"},
+			},
+		},
+		SourceFs: fs,
+		logger:   slog.New(slog.DiscardHandler),
+	}
+
+	files := b.scanLargeFiles()
+	want := []string{"static/files/Release Notes.pdf", "static/video/intro.mp4"}
+	if got := slices.Sorted(maps.Keys(files)); !slices.Equal(got, want) {
+		t.Fatalf("large files = %q, want %q", got, want)
+	}
+	if files["static/video/intro.mp4"] != "themes/blog/static/video/intro.mp4" {
+		t.Errorf("source = %q", files["static/video/intro.mp4"])
+	}
+
+	b.cfg.IsDev = true
+	if files := b.scanLargeFiles(); len(files) != 0 {
+		t.Errorf("dev build moved %d files", len(files))
+	}
+}
+
+func TestRewriteLargeFiles(t *testing.T) {
+	files := map[string]string{"static/video/intro.mp4": "", "static/files/Release Notes.pdf": ""}
+	html := `<video src="/blog/static/video/intro.mp4#t=5" poster='/blog/static/video/intro.jpg'></video>
+<a href="https://example.com/blog/static/files/Release%20Notes.pdf?v=2">notes</a>
+<a href="/static/video/intro.mp4">outside the base path</a>
+<a href="//example.com/blog/static/video/intro.mp4">other host</a>
+<meta property="og:video" content="https://example.com/blog/static/video/intro.mp4">
+<p><video src=/blog/static/video/intro.mp4 controls></video>`
+	want := `<video src="https://media.example.com/static/video/intro.mp4#t=5" poster='/blog/static/video/intro.jpg'></video>
+<a href="https://media.example.com/static/files/Release%20Notes.pdf?v=2">notes</a>
+<a href="/static/video/intro.mp4">outside the base path</a>
+<a href="//example.com/blog/static/video/intro.mp4">other host</a>
+<meta property="og:video" content="https://media.example.com/static/video/intro.mp4">
+<p><video src=https://media.example.com/static/video/intro.mp4 controls></video>`
+	b := &Builder{
+		cfg:        &config.Config{BaseURL: "https://example.com/blog/", LargeFiles: config.LargeFilesConfig{URL: "https://media.example.com/"}},
+		largeFiles: files,
+	}
+	if got, _ := b.rewriteLargeFileLinks("", []byte(html)); string(got) != want {
+		t.Errorf("rewritten =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package run
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// hasTemplate reports whether the theme has the given template file
+func (b *Builder) hasTemplate(file string) bool {
+	_, info := b.renderService.Templates()
+	return info[file] != nil
+}
+
+// newListPage starts the list context of a page of the given kind
+func (b *Builder) newListPage(kind string, count int) *models.ListPage {
+	return &models.ListPage{Kind: kind, Count: count, RSSLink: b.rssLink(b.cfg.BaseURL)}
+}
+
+// listPage is one page of a paginated list
+type listPage struct {
+	posts     []models.PostMetadata
+	destPath  string
+	permalink string
+	paginator models.Paginator
+}
+
+// paginateList splits posts into pages of perPage. pageAt returns the output
+// path and URL of page i (1-based); a list without posts still has a page.
+func paginateList(posts []models.PostMetadata, perPage int, pageAt func(i int) (string, string)) []listPage {
+	if perPage <= 0 {
+		perPage = len(posts)
+	}
+	total := 1
+	if perPage > 0 && len(posts) > perPage {
+		total = (len(posts) + perPage - 1) / perPage
+	}
+	pages := make([]listPage, total)
+	for i := 1; i <= total; i++ {
+		start, end := (i-1)*perPage, min(i*perPage, len(posts))
+		destPath, permalink := pageAt(i)
+		p := models.Paginator{CurrentPage: i, TotalPages: total, HasPrev: i > 1, HasNext: i < total}
+		_, p.FirstURL = pageAt(1)
+		_, p.LastURL = pageAt(total)
+		if i > 1 {
+			_, p.PrevURL = pageAt(i - 1)
+		}
+		if i < total {
+			_, p.NextURL = pageAt(i + 1)
+		}
+		pages[i-1] = listPage{posts: posts[start:end], destPath: destPath, permalink: permalink, paginator: p}
+	}
+	return pages
+}
+
+// contentSections groups posts by the directories their pages are in: a
+// post is listed in its directory and every one above it. Directories that
+// render a page of their own (an index.md) are left out.
+func (b *Builder) contentSections(posts []models.PostMetadata) map[string][]models.PostMetadata {
+	sections := make(map[string][]models.PostMetadata)
+	taken := make(map[string]bool)
+	for _, p := range posts {
+		rel := strings.TrimPrefix(strings.TrimPrefix(p.Link, b.cfg.BaseURL), "/")
+		if dir, ok := strings.CutSuffix(rel, "/index.html"); ok {
+			taken[dir] = true
+		}
+		for dir := path.Dir(rel); dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+			sections[dir] = append(sections[dir], p)
+		}
+	}
+	for dir := range taken {
+		delete(sections, dir)
+	}
+	return sections
+}
+
+// subsections lists the sections directly below parent ("" for the top level)
+func (b *Builder) subsections(sections map[string][]models.PostMetadata, parent string) []models.TagData {
+	var subs []models.TagData
+	for dir, posts := range sections {
+		if p := path.Dir(dir); p == parent || (parent == "" && p == ".") {
+			subs = append(subs, models.TagData{Name: path.Base(dir), Link: b.cfg.BaseURL + "/" + dir + "/", Count: len(posts)})
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Name < subs[j].Name })
+	return subs
+}
+
+// renderSections writes a paginated list page at <dir>/index.html for every
+// content section, when the theme has a list.html to render them with
+func (b *Builder) renderSections(allPosts []models.PostMetadata) {
+	if !b.hasTemplate("list.html") {
+		return
+	}
+	cfg := b.cfg
+	sections := b.contentSections(allPosts)
+	for dir, posts := range sections {
+		utils.SortPosts(posts)
+		list := b.newListPage(models.ListSection, len(posts))
+		list.Section = dir
+		list.Subsections = b.subsections(sections, dir)
+		title := []rune(strings.ReplaceAll(path.Base(dir), "-", " "))
+		title[0] = unicode.ToUpper(title[0])
+
+		pages := paginateList(posts, cfg.PostsPerPage, func(i int) (string, string) {
+			if i == 1 {
+				return filepath.Join(cfg.OutputDir, filepath.FromSlash(dir), "index.html"), cfg.BaseURL + "/" + dir + "/"
+			}
+			return filepath.Join(cfg.OutputDir, filepath.FromSlash(dir), "page", fmt.Sprint(i), "index.html"), fmt.Sprintf("%s/%s/page/%d/", cfg.BaseURL, dir, i)
+		})
+		for _, page := range pages {
+			b.renderService.RenderIndex(page.destPath, models.PageData{
+				Title: string(title), IsIndex: true, Posts: page.posts, List: list,
+				BaseURL: cfg.BaseURL, BuildVersion: cfg.BuildVersion,
+				TabTitle: string(title) + " | " + cfg.Title, Description: cfg.Description,
+				Permalink: page.permalink, Paginator: page.paginator,
+				Image:  cfg.BaseURL + "/static/images/cards/home.webp",
+				Config: cfg,
+			})
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package run
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestPaginateList(t *testing.T) {
+	posts := make([]models.PostMetadata, 5)
+	pageAt := func(i int) (string, string) {
+		return fmt.Sprintf("out/%d.html", i), fmt.Sprintf("/p/%d", i)
+	}
+
+	pages := paginateList(posts, 2, pageAt)
+	if len(pages) != 3 {
+		t.Fatalf("got %d pages, want 3", len(pages))
+	}
+	if len(pages[0].posts) != 2 || len(pages[2].posts) != 1 {
+		t.Errorf("page sizes = %d, %d, %d, want 2, 2, 1", len(pages[0].posts), len(pages[1].posts), len(pages[2].posts))
+	}
+	want := models.Paginator{CurrentPage: 2, TotalPages: 3, HasPrev: true, HasNext: true, FirstURL: "/p/1", LastURL: "/p/3", PrevURL: "/p/1", NextURL: "/p/3"}
+	if pages[1].paginator != want || pages[1].destPath != "out/2.html" {
+		t.Errorf("page 2 = %+v at %s, want %+v", pages[1].paginator, pages[1].destPath, want)
+	}
+
+	for _, perPage := range []int{0, 10} {
+		if pages := paginateList(posts, perPage, pageAt); len(pages) != 1 || len(pages[0].posts) != 5 {
+			t.Errorf("perPage %d: got %d pages, want 1 with every post", perPage, len(pages))
+		}
+	}
+	if pages := paginateList(nil, 2, pageAt); len(pages) != 1 || pages[0].paginator.HasNext {
+		t.Errorf("empty list: got %+v, want one page", pages)
+	}
+}
+
+func TestContentSections(t *testing.T) {
+	b := &Builder{cfg: &config.Config{BaseURL: "https://example.com"}}
+	post := func(link string) models.PostMetadata { return models.PostMetadata{Link: "https://example.com/" + link} }
+	sections := b.contentSections([]models.PostMetadata{
+		post("about.html"),
+		post("docs/intro.html"),
+		post("docs/guides/setup.html"),
+		post("docs/guides/deploy.html"),
+		post("blog/index.html"), // blog renders its own page
+		post("blog/hello.html"),
+	})
+
+	counts := make(map[string]int)
+	for dir, posts := range sections {
+		counts[dir] = len(posts)
+	}
+	if want := map[string]int{"docs": 3, "docs/guides": 2}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("sections = %v, want %v", counts, want)
+	}
+
+	var names []string
+	for _, s := range b.subsections(sections, "") {
+		names = append(names, s.Name+" "+s.Link)
+	}
+	sort.Strings(names)
+	if want := []string{"docs https://example.com/docs/"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("top-level sections = %v, want %v", names, want)
+	}
+	if subs := b.subsections(sections, "docs"); len(subs) != 1 || subs[0].Name != "guides" || subs[0].Count != 2 {
+		t.Errorf("subsections of docs = %+v, want guides (2)", subs)
+	}
+}
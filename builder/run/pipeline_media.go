@@ -0,0 +1,184 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// mediaRecord is the media map of the last build, in the cache
+const mediaRecord = "media.json"
+
+// recordChanged reports whether current differs from the value the last
+// build recorded in the cache file name, and records it. Nothing recorded
+// and an empty value are the same.
+func (b *Builder) recordChanged(name string, current any) bool {
+	record := filepath.Join(b.cfg.CacheDir, name)
+	data, _ := json.Marshal(current)
+	recorded, _ := os.ReadFile(record)
+	if bytes.Equal(recorded, data) || (emptyJSON(recorded) && emptyJSON(data)) {
+		return false
+	}
+	if err := os.WriteFile(record, data, 0644); err != nil {
+		b.logger.Warn("Failed to write build record", "path", record, "error", err)
+	}
+	return true
+}
+
+func emptyJSON(data []byte) bool {
+	switch string(data) {
+	case "", "null", "[]", "{}":
+		return true
+	}
+	return false
+}
+
+// rewriteMediaLinks points the links of a page to static images at their
+// content-hash names. It is the after-render hook registered with
+// images.dedupe.
+func (b *Builder) rewriteMediaLinks(_ string, html []byte) ([]byte, error) {
+	if len(b.media) == 0 {
+		return html, nil
+	}
+	return rewriteStaticLinks(html, b.cfg.BaseURL, func(rel string) (string, bool) {
+		return mediaLink(b.media, rel)
+	}), nil
+}
+
+// variantSuffix matches the width ImageVariant gives smaller copies
+var variantSuffix = regexp.MustCompile(`-(\d+)w$`)
+
+// mediaLink is the content-hash path of an output path in media (as the
+// asset service's Media), also for the smaller and AVIF copies of a
+// compressed image
+func mediaLink(media map[string]string, rel string) (string, bool) {
+	if to, ok := media["/"+rel]; ok {
+		return strings.TrimPrefix(to, "/"), true
+	}
+	ext := path.Ext(rel)
+	if ext != ".webp" && ext != ".avif" {
+		return "", false
+	}
+	stem := strings.TrimSuffix(rel, ext)
+	width := 0
+	if m := variantSuffix.FindStringSubmatch(stem); m != nil {
+		width, _ = strconv.Atoi(m[1])
+		stem = strings.TrimSuffix(stem, m[0])
+	}
+	to, ok := media["/"+stem+".webp"]
+	if !ok || (width == 0 && ext == ".webp") {
+		return "", false
+	}
+	return utils.ImageVariant(strings.TrimPrefix(to, "/"), width, ext[1:]), true
+}
+
+// linkAttr matches the attributes pages link files with, quoted or not
+// (minified pages)
+var linkAttr = regexp.MustCompile(`\b(src|href|poster|data|content|srcset|imagesrcset)=("[^"]*"|'[^']*'|[^\s"'=<>\x60]+)`)
+
+// cssURL matches the url()s of inline styles
+var cssURL = regexp.MustCompile(`\burl\(\s*(["']?)([^"'()\s]+)(["']?)\s*\)`)
+
+// codeBlock matches the code samples of a page, whose text is left alone
+var codeBlock = regexp.MustCompile(`(?is)<pre\b.*?</pre\s*>|<code\b.*?</code\s*>`)
+
+// rewriteStaticLinks replaces the links of a page to output paths lookup
+// knows, written as absolute URLs under baseURL or root-relative ones, with
+// what it returns for the unescaped path: a URL, or a path that keeps the
+// link's base. Link attributes and the url()s of inline styles are
+// rewritten, outside <pre> and <code>. Queries, fragments and srcset
+// descriptors are kept.
+func rewriteStaticLinks(html []byte, baseURL string, lookup func(rel string) (string, bool)) []byte {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	basePath := ""
+	if u, err := url.Parse(baseURL); err == nil {
+		basePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	rewrite := func(link string) string {
+		suffix := ""
+		if i := strings.IndexAny(link, "?#"); i >= 0 {
+			link, suffix = link[:i], link[i:]
+		}
+		var base string
+		switch {
+		case baseURL != "" && strings.HasPrefix(link, baseURL+"/"):
+			base = baseURL
+		case strings.HasPrefix(link, basePath+"/") && !strings.HasPrefix(link, "//"):
+			base = basePath
+		default:
+			return ""
+		}
+		rel, err := url.PathUnescape(strings.TrimPrefix(link, base+"/"))
+		if err != nil {
+			return ""
+		}
+		to, ok := lookup(rel)
+		if !ok {
+			return ""
+		}
+		if !strings.Contains(to, "://") {
+			to = base + "/" + to
+		}
+		return to + suffix
+	}
+
+	rewriteAttr := func(m []byte) []byte {
+		sub := linkAttr.FindSubmatch(m)
+		attr, value, quote := string(sub[1]), string(sub[2]), ""
+		if value[0] == '"' || value[0] == '\'' {
+			value, quote = value[1:len(value)-1], value[:1]
+		}
+
+		changed := false
+		if attr == "srcset" || attr == "imagesrcset" {
+			candidates := strings.Split(value, ",")
+			for i, candidate := range candidates {
+				trimmed := strings.TrimLeft(candidate, " \t\n")
+				link, descriptor, _ := strings.Cut(trimmed, " ")
+				if to := rewrite(link); to != "" {
+					candidates[i] = candidate[:len(candidate)-len(trimmed)] + to
+					if descriptor != "" {
+						candidates[i] += " " + descriptor
+					}
+					changed = true
+				}
+			}
+			value = strings.Join(candidates, ",")
+		} else if to := rewrite(value); to != "" {
+			value, changed = to, true
+		}
+		if !changed {
+			return m
+		}
+		return []byte(attr + "=" + quote + value + quote)
+	}
+
+	rewriteURL := func(m []byte) []byte {
+		sub := cssURL.FindSubmatch(m)
+		to := rewrite(string(sub[2]))
+		if to == "" {
+			return m
+		}
+		return []byte("url(" + string(sub[1]) + to + string(sub[3]) + ")")
+	}
+
+	var out []byte
+	last := 0
+	for _, loc := range append(codeBlock.FindAllIndex(html, -1), []int{len(html), len(html)}) {
+		text := linkAttr.ReplaceAllFunc(html[last:loc[0]], rewriteAttr)
+		text = cssURL.ReplaceAllFunc(text, rewriteURL)
+		out = append(out, text...)
+		out = append(out, html[loc[0]:loc[1]]...)
+		last = loc[1]
+	}
+	return out
+}
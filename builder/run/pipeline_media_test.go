@@ -0,0 +1,34 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestRewriteMediaLinks(t *testing.T) {
+	b := &Builder{
+		cfg: &config.Config{BaseURL: "https://example.com"},
+		media: map[string]string{
+			"/static/posts/a/photo.webp": "/static/media/photo.1a2b3c4d5e.webp",
+			"/static/images/logo.svg":    "/static/media/logo.0f0f0f0f0f.svg",
+		},
+	}
+	html := `<picture><source type="image/avif" srcset="https://example.com/static/posts/a/photo-480w.avif 480w, https://example.com/static/posts/a/photo.avif 1200w">` +
+		`<img src="https://example.com/static/posts/a/photo.webp" srcset="https://example.com/static/posts/a/photo-480w.webp 480w, https://example.com/static/posts/a/photo.webp 1200w" sizes="100vw"></picture>` +
+		`<img src=/static/images/logo.svg alt=Logo><img src="/static/images/logo-480w.webp"><a href="/static/posts/a/photo.png">original</a>` +
+		`<div style="background: url('/static/images/logo.svg')"></div><style>.hero{background:url(/static/posts/a/photo.webp)}</style>` +
+		`<pre><code>&lt;img src=/static/images/logo.svg&gt;</code></pre><p>Use <code>src=/static/images/logo.svg</code></p>`
+	want := `<picture><source type="image/avif" srcset="https://example.com/static/media/photo.1a2b3c4d5e-480w.avif 480w, https://example.com/static/media/photo.1a2b3c4d5e.avif 1200w">` +
+		`<img src="https://example.com/static/media/photo.1a2b3c4d5e.webp" srcset="https://example.com/static/media/photo.1a2b3c4d5e-480w.webp 480w, https://example.com/static/media/photo.1a2b3c4d5e.webp 1200w" sizes="100vw"></picture>` +
+		`<img src=/static/media/logo.0f0f0f0f0f.svg alt=Logo><img src="/static/images/logo-480w.webp"><a href="/static/posts/a/photo.png">original</a>` +
+		`<div style="background: url('/static/media/logo.0f0f0f0f0f.svg')"></div><style>.hero{background:url(/static/media/photo.1a2b3c4d5e.webp)}</style>` +
+		`<pre><code>&lt;img src=/static/images/logo.svg&gt;</code></pre><p>Use <code>src=/static/images/logo.svg</code></p>`
+	got, err := b.rewriteMediaLinks("", []byte(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("rewritten =\n%s\nwant\n%s", got, want)
+	}
+}
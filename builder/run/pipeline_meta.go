@@ -4,13 +4,19 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/checks"
+	"github.com/Kush-Singh-26/kosh/builder/config"
 	"github.com/Kush-Singh-26/kosh/builder/generators"
 	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/search"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
-func (b *Builder) generateMetadata(allContent []models.PostMetadata, tagMap map[string][]models.PostMetadata, indexedPosts []models.IndexedPost, shouldForce bool) {
+func (b *Builder) generateMetadata(allContent []models.PostMetadata, tagMap, versionPosts map[string][]models.PostMetadata, indexedPosts []models.IndexedPost, searchSpool *search.ContentSpool, shouldForce bool) {
 	cfg := b.cfg
 	var genWg sync.WaitGroup
 	outputDir := cfg.OutputDir
@@ -19,15 +25,35 @@ func (b *Builder) generateMetadata(allContent []models.PostMetadata, tagMap map[
 		genWg.Add(1)
 		go func() {
 			defer genWg.Done()
-			generators.GenerateSitemap(b.DestFs, cfg.BaseURL, allContent, tagMap, filepath.Join(outputDir, "sitemap", "sitemap.xml"))
+			// Older documentation versions are listed under their own paths
+			posts := allContent
+			for _, vp := range versionPosts {
+				posts = append(posts[:len(posts):len(posts)], vp...)
+			}
+			for _, path := range generators.GenerateSitemap(b.DestFs, cfg.BaseURL, outputDir, posts, tagMap, filepath.Join(outputDir, "sitemap", "sitemap.xml"), cfg.Sitemap) {
+				b.renderService.RegisterFile(path)
+			}
 		}()
 	}
 
+	if cfg.Robots.Enabled {
+		sitemapURL := ""
+		if cfg.Features.Generators.Sitemap {
+			sitemapURL = cfg.BaseURL + "/sitemap/sitemap.xml"
+		}
+		if err := generators.GenerateRobots(b.DestFs, outputDir, cfg.Robots, sitemapURL); err != nil {
+			b.logger.Error("Failed to generate robots.txt", "error", err)
+		}
+	}
+
 	if cfg.Features.Generators.RSS {
 		genWg.Add(1)
 		go func() {
 			defer genWg.Done()
-			generators.GenerateRSS(b.DestFs, cfg.BaseURL, allContent, cfg.Title, cfg.Description, filepath.Join(outputDir, "rss.xml"))
+			b.generateFeeds(allContent, tagMap, versionPosts)
+			for _, path := range generators.GenerateChapters(b.DestFs, cfg.BaseURL, outputDir, allContent) {
+				b.renderService.RegisterFile(path)
+			}
 		}()
 	}
 
@@ -35,12 +61,34 @@ func (b *Builder) generateMetadata(allContent []models.PostMetadata, tagMap map[
 		genWg.Add(1)
 		go func() {
 			defer genWg.Done()
-			if err := generators.GenerateSearchIndex(b.DestFs, outputDir, indexedPosts); err != nil {
+			if err := generators.GenerateSearchIndex(b.DestFs, outputDir, indexedPosts, searchSpool, cfg.Search.Boost); err != nil {
 				b.logger.Error("Failed to generate search index", "error", err)
 			}
 		}()
 	}
 
+	if cfg.Features.Generators.API {
+		genWg.Add(1)
+		go func() {
+			defer genWg.Done()
+			written, err := generators.GenerateAPI(b.DestFs, cfg.BaseURL, outputDir, allContent, tagMap, cfg.PostsPerPage)
+			if err != nil {
+				b.logger.Error("Failed to generate JSON API", "error", err)
+			}
+			for _, path := range written {
+				b.renderService.RegisterFile(path)
+			}
+		}()
+	}
+
+	genWg.Add(1)
+	go func() {
+		defer genWg.Done()
+		if err := generators.GenerateWellKnown(b.DestFs, outputDir, cfg, time.Now()); err != nil {
+			b.logger.Error("Failed to generate .well-known files", "error", err)
+		}
+	}()
+
 	if cfg.Features.Generators.Graph {
 		graphHash, _ := utils.GetGraphHash(allContent)
 		cachedGraphHash := ""
@@ -67,3 +115,57 @@ func (b *Builder) generateMetadata(allContent []models.PostMetadata, tagMap map[
 	}
 	genWg.Wait()
 }
+
+// writeHeaders writes the _headers file of the cache-control policy. It runs
+// after the sync, on disk: the file lists the whole output, not just what
+// this build rendered.
+func (b *Builder) writeHeaders() {
+	if !b.cfg.CacheControl.Headers || b.cfg.IsDev {
+		return
+	}
+	rules, err := generators.GenerateHeaders(afero.NewOsFs(), b.cfg.OutputDir, b.cfg.CacheControl)
+	if err != nil {
+		b.logger.Warn("Failed to write _headers", "error", err)
+	} else if rules > generators.MaxHeaderRules {
+		b.logger.Warn("_headers has more rules than Cloudflare Pages reads", "rules", rules, "limit", generators.MaxHeaderRules)
+	}
+}
+
+// writeStatus writes the dev server's content status page to the cache
+// directory. Like _headers it is written on disk after the sync, so a build
+// that skips pages still lists them. Other builds remove the page older
+// versions wrote to the output directory.
+func (b *Builder) writeStatus() {
+	cfg := b.cfg
+	if !cfg.IsDev {
+		if err := os.RemoveAll(filepath.Join(cfg.OutputDir, "__status")); err != nil {
+			b.logger.Warn("Failed to remove the status page from the output", "error", err)
+		}
+		return
+	}
+	if cfg.StatusPage.Disabled {
+		return
+	}
+	status, err := checks.ContentStatus(b.SourceFs, cfg.ContentDir, time.Now(), cfg.StatusPage.StaleMonths)
+	if err != nil {
+		b.logger.Warn("Failed to write the status page", "error", err)
+		return
+	}
+	data := generators.StatusData{
+		Status:    status,
+		Generated: time.Now(),
+		URL:       func(relPath string) string { return cfg.BaseURL + "/" + cfg.HTMLPath(relPath) },
+	}
+	data.DraftURL = data.URL
+	if !cfg.IncludeDrafts {
+		data.DraftURL = func(relPath string) string { return cfg.BaseURL + config.DevDraftsPath + cfg.HTMLPath(relPath) }
+	}
+	if latest, ok, err := checks.LoadLatest(cfg.CacheDir); err != nil {
+		b.logger.Warn("Failed to read the latest check", "error", err)
+	} else if ok {
+		data.Latest = &latest
+	}
+	if err := generators.GenerateStatus(afero.NewOsFs(), cfg.DevStatusFile(), data); err != nil {
+		b.logger.Warn("Failed to write the status page", "error", err)
+	}
+}
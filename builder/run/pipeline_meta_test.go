@@ -0,0 +1,45 @@
+package run
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestWriteStatus(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{ContentDir: filepath.Join(dir, "content"), OutputDir: filepath.Join(dir, "public"), CacheDir: filepath.Join(dir, ".kosh-cache")}
+	if err := os.MkdirAll(cfg.ContentDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	b := &Builder{cfg: cfg, SourceFs: afero.NewOsFs(), logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	cfg.IsDev = true
+	b.writeStatus()
+	if _, err := os.Stat(cfg.DevStatusFile()); err != nil {
+		t.Errorf("dev build didn't write the status page: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "__status")); !os.IsNotExist(err) {
+		t.Error("dev build wrote the status page to the output directory")
+	}
+
+	// A page left in the output by an older dev build is removed
+	leftover := filepath.Join(cfg.OutputDir, "__status", "index.html")
+	if err := os.MkdirAll(filepath.Dir(leftover), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(leftover, []byte("drafts"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.IsDev = false
+	b.writeStatus()
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Error("production build kept the status page in the output directory")
+	}
+}
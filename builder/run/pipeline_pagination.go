@@ -79,6 +79,8 @@ func (b *Builder) renderPagination(allPosts, pinnedPosts []models.PostMetadata,
 
 	// Build SiteTree once before the loop (optimization: avoids recalculating for each page)
 	siteTree := utils.BuildSiteTree(latestPosts, "")
+	list := b.newListPage(models.ListHome, len(latestPosts))
+	list.Subsections = b.subsections(b.contentSections(latestPosts), "")
 
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, runtime.NumCPU())
@@ -113,7 +115,7 @@ func (b *Builder) renderPagination(allPosts, pinnedPosts []models.PostMetadata,
 				curPinned = pinnedPosts
 			}
 
-			b.renderService.RenderIndex(destPath, models.PageData{Title: cfg.Title, Posts: pagePosts, PinnedPosts: curPinned, BaseURL: cfg.BaseURL, BuildVersion: cfg.BuildVersion, TabTitle: cfg.Title, Description: cfg.Description, Permalink: permalink, Image: cfg.BaseURL + "/static/images/cards/home.webp", Paginator: paginator, SiteTree: siteTree, Config: cfg, Versions: cfg.GetVersionsMetadata("", "")})
+			b.renderService.RenderIndex(destPath, models.PageData{Title: cfg.Title, Posts: pagePosts, PinnedPosts: curPinned, BaseURL: cfg.BaseURL, BuildVersion: cfg.BuildVersion, TabTitle: cfg.Title, Description: cfg.Description, Permalink: permalink, Image: cfg.BaseURL + "/static/images/cards/home.webp", Paginator: paginator, SiteTree: siteTree, List: list, Config: cfg, Versions: cfg.GetVersionsMetadata("", "")})
 		}(i)
 	}
 	wg.Wait()
@@ -154,14 +156,25 @@ func (b *Builder) renderTags(tagMap map[string][]models.PostMetadata, forceSocia
 
 	// Generate Tags Index
 	// Force Weight: 0 so layout doesn't crash
-	b.renderService.RenderPage(filepath.Join(b.cfg.OutputDir, "tags/index.html"), models.PageData{
-		Title: "All Tags", IsTagsIndex: true, AllTags: allTags,
+	tagsList := b.newListPage(models.ListTags, len(tagMap))
+	tagsList.Terms = allTags
+	tagsData := models.PageData{
+		Title: "All Tags", IsTagsIndex: true, AllTags: allTags, List: tagsList,
 		BaseURL: b.cfg.BaseURL, BuildVersion: b.cfg.BuildVersion,
 		Permalink: b.cfg.BaseURL + "/tags/index.html",
 		Image:     b.cfg.BaseURL + "/static/images/cards/tags/index.webp",
 		TabTitle:  "All Topics | " + b.cfg.Title, Config: b.cfg,
 		Weight: 0, // Fix for docs theme layout
-	})
+	}
+	b.loadTermIndex("").apply(&tagsData, b.cfg.Title)
+	b.renderService.RenderIndex(filepath.Join(b.cfg.OutputDir, "tags/index.html"), tagsData)
+
+	// Tag pages are paginated when the theme has a list-aware template for
+	// them; layout.html gets every post on one page as before
+	perPage := 0
+	if b.hasTemplate("term.html") || b.hasTemplate("list.html") {
+		perPage = b.cfg.PostsPerPage
+	}
 
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, runtime.NumCPU())
@@ -200,15 +213,44 @@ func (b *Builder) renderTags(tagMap map[string][]models.PostMetadata, forceSocia
 			}
 
 			utils.SortPosts(posts)
-			b.renderService.RenderPage(filepath.Join(b.cfg.OutputDir, fmt.Sprintf("tags/%s.html", t)), models.PageData{
-				Title: "#" + t, IsIndex: true, Posts: posts,
-				BaseURL: b.cfg.BaseURL, BuildVersion: b.cfg.BuildVersion,
-				Permalink: fmt.Sprintf("%s/tags/%s.html", b.cfg.BaseURL, t),
-				Image:     fmt.Sprintf("%s/static/images/cards/tags/%s.webp", b.cfg.BaseURL, strings.ToLower(t)),
-				TabTitle:  "#" + t + " | " + b.cfg.Title, Config: b.cfg,
-				Weight: 0, // Fix for docs theme layout
+			list := b.newListPage(models.ListTerm, len(posts))
+			list.Term, list.Terms = t, allTags
+			if b.cfg.Feeds.Tags {
+				list.RSSLink = b.rssLink(b.cfg.BaseURL + "/tags/" + t)
+			}
+			pages := paginateList(posts, perPage, func(i int) (string, string) {
+				if i == 1 {
+					return filepath.Join(b.cfg.OutputDir, fmt.Sprintf("tags/%s.html", t)), fmt.Sprintf("%s/tags/%s.html", b.cfg.BaseURL, t)
+				}
+				return filepath.Join(b.cfg.OutputDir, fmt.Sprintf("tags/%s/page/%d.html", t, i)), fmt.Sprintf("%s/tags/%s/page/%d.html", b.cfg.BaseURL, t, i)
 			})
+			// content/tags/<tag>/_index.md adds a title, description, image and body
+			idx := b.loadTermIndex(t)
+			for _, page := range pages {
+				data := models.PageData{
+					Title: "#" + t, IsIndex: true, Posts: page.posts, List: list,
+					BaseURL: b.cfg.BaseURL, BuildVersion: b.cfg.BuildVersion,
+					Permalink: page.permalink, Paginator: page.paginator,
+					Image:    fmt.Sprintf("%s/static/images/cards/tags/%s.webp", b.cfg.BaseURL, strings.ToLower(t)),
+					TabTitle: "#" + t + " | " + b.cfg.Title, Config: b.cfg,
+					Weight: 0, // Fix for docs theme layout
+				}
+				idx.apply(&data, b.cfg.Title)
+				b.renderService.RenderIndex(page.destPath, data)
+			}
 		}(t, posts)
 	}
 	wg.Wait()
+
+	// Old pages of renamed and merged tags point at their replacement
+	if len(b.cfg.TagRedirects) > 0 {
+		inUse := func(tag string) bool { _, ok := tagMap[tag]; return ok }
+		written, err := generators.GenerateTagRedirects(b.DestFs, b.cfg.OutputDir, b.cfg.BaseURL, b.cfg.TagRedirects, inUse)
+		if err != nil {
+			b.logger.Warn("Failed to write tag redirects", "error", err)
+		}
+		for _, path := range written {
+			b.renderService.RegisterFile(path)
+		}
+	}
 }
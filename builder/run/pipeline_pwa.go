@@ -1,18 +1,23 @@
 package run
 
 import (
-	"fmt"
-	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"os"
 	"path/filepath"
-
-	"github.com/Kush-Singh-26/kosh/builder/generators"
 	"sync"
 
 	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
 func (b *Builder) generatePWA(shouldForce bool) {
+	// The smart checks below skip files already in DestFs. In low-memory mode
+	// DestFs is the output directory, where they are left over from the last
+	// build, so they have to be regenerated every time.
+	regenerate := shouldForce || b.cfg.LowMemory
 	var wg sync.WaitGroup
 	wg.Add(3)
 	go func() {
@@ -20,75 +25,92 @@ func (b *Builder) generatePWA(shouldForce bool) {
 		if b.cfg.IsDev {
 			return
 		}
-		_ = generators.GenerateSW(b.DestFs, b.cfg.OutputDir, b.cfg.BuildVersion, shouldForce, b.cfg.BaseURL, b.renderService.GetAssets())
+		if page := b.cfg.PWA.OfflinePage; page != "" {
+			path := filepath.Join(b.cfg.OutputDir, filepath.FromSlash(page))
+			inBuild, _ := afero.Exists(b.DestFs, path)
+			if _, err := os.Stat(path); !inBuild && err != nil {
+				b.logger.Warn("PWA offline page not found in output", "page", page)
+			}
+		}
+		hooks, err := generators.LoadSWHooks(b.SourceFs, b.cfg.PWA.Hooks)
+		if err != nil {
+			b.logger.Error("Invalid service worker hook", "error", err)
+			return
+		}
+		if err := generators.GenerateSW(b.DestFs, b.cfg.OutputDir, b.cfg.BuildVersion, regenerate, b.cfg.BaseURL, b.renderService.GetAssets(), b.cfg.PWA, hooks); err != nil {
+			b.logger.Error("Failed to generate service worker", "error", err)
+		}
 	}()
 	go func() {
 		defer wg.Done()
 		if b.cfg.IsDev {
 			return
 		}
-		_ = generators.GenerateManifest(b.DestFs, b.cfg.OutputDir, b.cfg.BaseURL, b.cfg.Title, b.cfg.Description, shouldForce)
+		if err := generators.GenerateManifest(b.DestFs, b.cfg.OutputDir, b.cfg.Title, b.cfg.Description, b.cfg.PWA, regenerate); err != nil {
+			b.logger.Error("Failed to generate web app manifest", "error", err)
+		}
 	}()
 	go func() {
 		defer wg.Done()
 		if b.cfg.IsDev {
 			return
 		}
-		faviconPath := b.getFaviconPath()
-
-		// Ensure info is available
-		if exists, _ := afero.Exists(b.SourceFs, faviconPath); !exists {
-			return
+		if err := b.generatePWAIcons(shouldForce); err != nil {
+			b.logger.Error("Failed to generate PWA icons", "error", err)
 		}
-		srcInfo, _ := b.SourceFs.Stat(faviconPath)
-
-		// Calculate hash based on favicon mtime and size
-		hashContent := fmt.Sprintf("%s-%d-%d", faviconPath, srcInfo.Size(), srcInfo.ModTime().UnixNano())
-		currentHash := cache.HashString(hashContent)
-
-		// Check cache
-		cacheDir := filepath.Join(b.cfg.CacheDir, "pwa-icons")
-		cacheHashFile := filepath.Join(cacheDir, currentHash+".hash")
-
-		// Check if cached icons exist and are valid
-		needsGeneration := false
-		_, hashErr := os.Stat(cacheHashFile)
-		_, icon192Err := os.Stat(filepath.Join(b.cfg.OutputDir, "static/images/icon-192.png"))
-		_, icon512Err := os.Stat(filepath.Join(b.cfg.OutputDir, "static/images/icon-512.png"))
+	}()
+	wg.Wait()
+}
 
-		// Generate if: force=true, OR hash file missing, OR icons missing
-		if shouldForce || os.IsNotExist(hashErr) || os.IsNotExist(icon192Err) || os.IsNotExist(icon512Err) {
-			needsGeneration = true
+// generatePWAIcons renders the icon set from pwa.icon (or the favicon). The
+// rendered files are cached by a hash of the source image and background
+// color, so unchanged icons are copied instead of resized on every build.
+func (b *Builder) generatePWAIcons(shouldForce bool) error {
+	iconPath := b.cfg.PWA.Icon
+	if iconPath == "" {
+		iconPath = b.getFaviconPath()
+	}
+	source, err := afero.ReadFile(b.SourceFs, iconPath)
+	if err != nil {
+		if os.IsNotExist(err) && b.cfg.PWA.Icon == "" {
+			return nil // No favicon: nothing to generate from
 		}
+		return err
+	}
 
-		if needsGeneration {
-			// Generate icons only if source is newer or cache is missing
-			err := generators.GeneratePWAIcons(b.SourceFs, b.DestFs, faviconPath, filepath.Join(b.cfg.OutputDir, "static/images"))
-			if err == nil {
-				// Save hash to cache
-				_ = os.WriteFile(cacheHashFile, []byte(currentHash), 0644)
+	hash := cache.HashString(cache.HashContent(source) + "|" + b.cfg.PWA.BackgroundColor)
+	cacheDir := filepath.Join(b.cfg.CacheDir, "pwa-icons", hash)
 
-				// Copy generated icons to cache for future reuse
-				if data, err := afero.ReadFile(b.DestFs, filepath.Join(b.cfg.OutputDir, "static/images/icon-192.png")); err == nil {
-					_ = os.WriteFile(filepath.Join(cacheDir, currentHash+"-192.png"), data, 0644)
-				}
-				if data, err := afero.ReadFile(b.DestFs, filepath.Join(b.cfg.OutputDir, "static/images/icon-512.png")); err == nil {
-					_ = os.WriteFile(filepath.Join(cacheDir, currentHash+"-512.png"), data, 0644)
-				}
+	files := make(map[string][]byte)
+	if !shouldForce {
+		for _, rel := range generators.PWAIconFiles() {
+			data, err := os.ReadFile(filepath.Join(cacheDir, filepath.FromSlash(rel)))
+			if err != nil {
+				files = nil
+				break
 			}
-		} else {
-			// Copy from cache to destination
-			cache192 := filepath.Join(cacheDir, currentHash+"-192.png")
-			cache512 := filepath.Join(cacheDir, currentHash+"-512.png")
+			files[rel] = data
+		}
+	}
 
-			// Copy cached icons to VFS
-			if data, err := os.ReadFile(cache192); err == nil {
-				_ = afero.WriteFile(b.DestFs, filepath.Join(b.cfg.OutputDir, "static/images/icon-192.png"), data, 0644)
-			}
-			if data, err := os.ReadFile(cache512); err == nil {
-				_ = afero.WriteFile(b.DestFs, filepath.Join(b.cfg.OutputDir, "static/images/icon-512.png"), data, 0644)
+	if len(files) == 0 {
+		logging.Statusf("   🎨 Generating PWA icons...")
+		files, err = generators.RenderPWAIcons(source, b.cfg.PWA.BackgroundColor)
+		if err != nil {
+			return err
+		}
+		for rel, data := range files {
+			cached := filepath.Join(cacheDir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(cached), 0755); err == nil {
+				_ = os.WriteFile(cached, data, 0644)
 			}
 		}
-	}()
-	wg.Wait()
+	}
+
+	for rel, data := range files {
+		if err := utils.WriteFileVFS(b.DestFs, filepath.Join(b.cfg.OutputDir, filepath.FromSlash(rel)), data); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,68 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+	"github.com/Kush-Singh-26/kosh/builder/searchexport"
+)
+
+// exportSearch runs the Lunr and search server exporters of search.exporters.
+// Servers are only updated by production builds with network access.
+func (b *Builder) exportSearch(ctx context.Context, indexedPosts []models.IndexedPost, spool *search.ContentSpool) {
+	cfg := b.cfg
+	src := searchexport.Source{BaseURL: cfg.BaseURL, Posts: indexedPosts, Spool: spool}
+	for _, exp := range cfg.Search.Exporters {
+		switch exp.Type {
+		case searchexport.Lunr:
+			output := exp.Output
+			if output == "" {
+				output = searchexport.DefaultLunrOutput
+			}
+			path := filepath.Join(cfg.OutputDir, filepath.FromSlash(output))
+			if _, err := searchexport.WriteLunr(b.DestFs, path, src); err != nil {
+				b.logger.Error("Failed to export search index", "exporter", exp.Type, "error", err)
+				continue
+			}
+			b.renderService.RegisterFile(path)
+		case searchexport.Meilisearch, searchexport.Typesense:
+			if cfg.IsDev || cfg.Offline {
+				continue
+			}
+			client := &http.Client{Timeout: cfg.Build.RemoteTimeout}
+			n, err := searchexport.Push(ctx, client, exp, src, cfg.BuildVersion)
+			if err != nil {
+				b.logger.Warn("Failed to push search documents", "exporter", exp.Type, "url", exp.URL, "error", err)
+				continue
+			}
+			logging.Statusf("   🔎 Pushed %d document(s) to %s", n, exp.Type)
+		}
+	}
+}
+
+// runPagefind indexes the synced output with Pagefind for every pagefind
+// exporter. The dev server skips it to keep rebuilds fast.
+func (b *Builder) runPagefind(ctx context.Context) {
+	if b.cfg.IsDev {
+		return
+	}
+	for _, exp := range b.cfg.Search.Exporters {
+		if exp.Type != searchexport.Pagefind {
+			continue
+		}
+		if err := searchexport.RunPagefind(ctx, b.cfg.OutputDir, exp.Output); err != nil {
+			if errors.Is(err, searchexport.ErrNoPagefind) {
+				b.logger.Warn("Skipping Pagefind export", "error", err)
+			} else {
+				b.logger.Error("Failed to export search index", "exporter", exp.Type, "error", err)
+			}
+			continue
+		}
+		logging.Statusf("   🔎 Wrote the Pagefind bundle")
+	}
+}
@@ -0,0 +1,80 @@
+package run
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/renderer"
+)
+
+// compileTemplates makes sure the build renders with the current theme
+// templates, parsing them at most once per build. The metadata of the parsed
+// set is recorded in the cache so the next run can tell which template files
+// changed in between. It returns the partials and layouts that changed,
+// appeared or disappeared since the recorded set, and the shortcodes that
+// did: unlike page templates they aren't covered by the mtime checks in Build.
+// Page templates that changed or appeared are returned too, as a site
+// override appearing or going away changes them without a newer mtime.
+func (b *Builder) compileTemplates() []string {
+	start := time.Now()
+	compiled, err := b.renderService.CompileTemplates()
+	if err != nil {
+		b.logger.Error("Failed to compile templates, keeping the previous ones", "error", err)
+		return nil
+	}
+	if compiled {
+		b.metrics.RecordTemplateCompile(time.Since(start))
+	}
+
+	// A scoped build (--only) renders its pages with the current templates
+	// but leaves the record alone, so the next full build still sees changes
+	if b.cacheService == nil || b.cfg.Only != "" {
+		return nil
+	}
+	_, current := b.renderService.Templates()
+	recorded, err := b.cacheService.GetTemplateMetas()
+	if err != nil {
+		b.logger.Warn("Failed to read recorded templates", "error", err)
+		return nil
+	}
+
+	var changedPartials []string
+	differs := len(recorded) != len(current)
+	for rel, meta := range current {
+		old, ok := recorded[rel]
+		if ok && old.Hash == meta.Hash {
+			continue
+		}
+		differs = true
+		// A new layout or shortcode matters to the posts that asked for it
+		// before it existed, a new page template to the pages it renders; a
+		// new partial only to templates that changed to include it
+		if ok || !strings.HasPrefix(rel, renderer.PartialsDir+"/") {
+			changedPartials = append(changedPartials, rel)
+		}
+	}
+	for rel := range recorded {
+		if _, ok := current[rel]; !ok && (strings.HasPrefix(rel, renderer.PartialsDir+"/") || isPageScoped(rel)) {
+			changedPartials = append(changedPartials, rel)
+		}
+	}
+
+	if differs {
+		if err := b.cacheService.SetTemplateMetas(current); err != nil {
+			b.logger.Warn("Failed to record templates", "error", err)
+		}
+	}
+	// Nothing recorded yet (first build with this cache): no baseline to compare
+	if len(recorded) == 0 {
+		return nil
+	}
+	slices.Sort(changedPartials)
+	return changedPartials
+}
+
+// isPageScoped reports whether a template file only affects the posts that
+// use it, as recorded in their dependencies: a layout or a shortcode
+func isPageScoped(rel string) bool {
+	return strings.HasPrefix(rel, renderer.LayoutsDir+"/") || strings.HasPrefix(rel, renderer.ShortcodesDir+"/")
+}
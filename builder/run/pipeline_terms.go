@@ -0,0 +1,102 @@
+package run
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark-meta"
+	gParser "github.com/yuin/goldmark/parser"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+)
+
+// termIndex is what a taxonomy page's _index.md adds to the generated page
+type termIndex struct {
+	title       string
+	description string
+	image       string
+	content     template.HTML
+	meta        map[string]interface{}
+}
+
+// termIndexPath is the _index.md of a tag page, or of the tags index for ""
+func (b *Builder) termIndexPath(term string) string {
+	return filepath.Join(b.cfg.ContentDir, "tags", term, "_index.md")
+}
+
+// loadTermIndex reads and renders the _index.md of a tag page (the tags
+// index for ""). It returns nil when there is none.
+func (b *Builder) loadTermIndex(term string) *termIndex {
+	path := b.termIndexPath(term)
+	source, err := afero.ReadFile(b.SourceFs, path)
+	if err != nil {
+		return nil
+	}
+
+	ctx := gParser.NewContext()
+	ctx.Set(mdParser.ContextKeyFilePath, path)
+	var buf bytes.Buffer
+	if err := b.md.Convert(source, &buf, gParser.WithContext(ctx)); err != nil {
+		b.logger.Warn("Failed to render taxonomy page", "path", path, "error", err)
+		return nil
+	}
+
+	metaData := meta.Get(ctx)
+	idx := &termIndex{content: template.HTML(buf.String()), meta: metaData}
+	idx.title, _ = metaData["title"].(string)
+	idx.description, _ = metaData["description"].(string)
+	if img, ok := metaData["image"].(string); ok && img != "" {
+		if strings.HasPrefix(img, "http") {
+			idx.image = img
+		} else {
+			if b.cfg.CompressImages {
+				if ext := filepath.Ext(img); ext == ".png" || ext == ".jpg" || ext == ".jpeg" {
+					img = img[:len(img)-len(ext)] + ".webp"
+				}
+			}
+			idx.image = b.cfg.BaseURL + img
+		}
+	}
+	return idx
+}
+
+// apply merges the _index.md into a taxonomy page's data
+func (idx *termIndex) apply(data *models.PageData, siteTitle string) {
+	if idx == nil {
+		return
+	}
+	if idx.title != "" {
+		data.Title = idx.title
+		data.TabTitle = idx.title + " | " + siteTitle
+	}
+	if idx.description != "" {
+		data.Description = idx.description
+	}
+	if idx.image != "" {
+		data.Image = idx.image
+	}
+	data.Content = idx.content
+	data.Meta = idx.meta
+}
+
+// termIndexChanged reports whether a taxonomy _index.md was edited after the
+// last build, which re-renders the tag pages even when no post changed
+func (b *Builder) termIndexChanged(since time.Time) bool {
+	changed := false
+	_ = afero.Walk(b.SourceFs, filepath.Join(b.cfg.ContentDir, "tags"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || changed {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == "_index.md" && info.ModTime().After(since) {
+			changed = true
+		}
+		return nil
+	})
+	return changed
+}
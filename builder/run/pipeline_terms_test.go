@@ -0,0 +1,52 @@
+package run
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark-meta"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestTermIndex(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	index := "---\ntitle: Go\ndescription: Posts about Go\nimage: /static/images/go.png\n---\nEverything **Go**.\n"
+	if err := afero.WriteFile(fs, filepath.Join("content", "tags", "go", "_index.md"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b := &Builder{
+		cfg:      &config.Config{BaseURL: "https://example.com", ContentDir: "content", CompressImages: true},
+		SourceFs: fs,
+		md:       goldmark.New(goldmark.WithExtensions(meta.Meta)),
+		logger:   slog.New(slog.DiscardHandler),
+	}
+
+	data := models.PageData{Title: "#go", TabTitle: "#go | Site", Image: "card.webp"}
+	b.loadTermIndex("go").apply(&data, "Site")
+	if data.Title != "Go" || data.TabTitle != "Go | Site" || data.Description != "Posts about Go" {
+		t.Errorf("title %q, tab %q, description %q", data.Title, data.TabTitle, data.Description)
+	}
+	if data.Image != "https://example.com/static/images/go.webp" {
+		t.Errorf("image = %q", data.Image)
+	}
+	if !strings.Contains(string(data.Content), "<strong>Go</strong>") {
+		t.Errorf("content = %q", data.Content)
+	}
+
+	bare := models.PageData{Title: "#rust"}
+	b.loadTermIndex("rust").apply(&bare, "Site")
+	if bare.Title != "#rust" || bare.Content != "" {
+		t.Errorf("tag without _index.md changed: %+v", bare)
+	}
+
+	if !b.termIndexChanged(time.Time{}) || b.termIndexChanged(time.Now().Add(time.Hour)) {
+		t.Error("termIndexChanged doesn't follow the _index.md modification time")
+	}
+}
@@ -0,0 +1,53 @@
+package run
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+	"github.com/Kush-Singh-26/kosh/builder/webmention"
+
+	"github.com/spf13/afero"
+)
+
+// sendWebmentions notifies sites linked from pages rendered in this build.
+// Links handled by earlier builds are skipped using the sent record in the cache.
+func (b *Builder) sendWebmentions(ctx context.Context, rendered map[string]bool) {
+	if !b.cfg.Webmentions.Send || b.cfg.IsDev || b.cfg.Offline || b.cfg.BaseURL == "" {
+		return
+	}
+
+	pages := make(map[string][]string)
+	for path := range rendered {
+		if !strings.HasSuffix(path, ".html") {
+			continue
+		}
+		data, err := afero.ReadFile(b.DestFs, filepath.FromSlash(path))
+		if err != nil {
+			continue
+		}
+		links := webmention.ExternalLinks(data, b.cfg.BaseURL)
+		if len(links) == 0 {
+			continue
+		}
+		rel, err := utils.SafeRel(b.cfg.OutputDir, filepath.FromSlash(path))
+		if err != nil {
+			continue
+		}
+		pages[utils.BuildURL(b.cfg.BaseURL, "", filepath.ToSlash(rel))] = links
+	}
+	if len(pages) == 0 {
+		return
+	}
+
+	sender := webmention.NewSender(b.cfg.CacheDir, b.cfg.Build.RemoteTimeout, b.logger)
+	sent, err := sender.SendNew(ctx, pages)
+	if err != nil {
+		b.logger.Warn("Failed to record sent webmentions", "error", err)
+	}
+	if sent > 0 {
+		logging.Statusf("   📣 Sent %d webmention(s)", sent)
+	}
+}
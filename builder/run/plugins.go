@@ -0,0 +1,68 @@
+package run
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
+)
+
+// Plugin extends builds with hooks (see package hooks). Pages changed by
+// after-parse hooks are cached, so a plugin must bump its Version whenever
+// its output changes: a different set of plugin names and versions than the
+// cache was built with forces a full rebuild.
+type Plugin interface {
+	Name() string
+	Version() string
+	Register(r *hooks.Registry) error
+}
+
+// Use registers plugins with the builder. Call it before the first Build:
+//
+//	b := run.NewBuilderWithConfig(cfg)
+//	if err := b.Use(credits{}); err != nil { ... }
+func (b *Builder) Use(plugins ...Plugin) error {
+	for _, p := range plugins {
+		if err := p.Register(b.hooks); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+		b.plugins = append(b.plugins, p.Name()+"@"+p.Version())
+	}
+	return nil
+}
+
+// pluginsChanged reports whether the registered plugins differ from the ones
+// the cache was built with, and records the current set
+func (b *Builder) pluginsChanged() bool {
+	if b.cacheService == nil {
+		return false
+	}
+	current := ""
+	if len(b.plugins) > 0 {
+		names := slices.Clone(b.plugins)
+		slices.Sort(names)
+		current = cache.HashString(strings.Join(names, "\n"))
+	}
+	recorded, err := b.cacheService.GetPluginsHash()
+	if err != nil {
+		b.logger.Warn("Failed to read recorded plugins", "error", err)
+		return false
+	}
+	if recorded == current {
+		return false
+	}
+	if err := b.cacheService.SetPluginsHash(current); err != nil {
+		b.logger.Warn("Failed to record plugins", "error", err)
+	}
+	return true
+}
+
+// afterBuild runs the after-build hooks once the output is on disk
+func (b *Builder) afterBuild(changed string) error {
+	if err := b.hooks.RunAfterBuild(hooks.Build{OutputDir: b.cfg.OutputDir, Changed: changed}); err != nil {
+		return fmt.Errorf("after-build hook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package run
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
+	"github.com/Kush-Singh-26/kosh/builder/services/mocks"
+)
+
+type testPlugin struct {
+	name, version string
+	err           error
+}
+
+func (p testPlugin) Name() string    { return p.name }
+func (p testPlugin) Version() string { return p.version }
+func (p testPlugin) Register(r *hooks.Registry) error {
+	r.AfterParse(func(post *hooks.Post) error {
+		post.HTML += "<!-- " + p.name + " -->"
+		return nil
+	})
+	return p.err
+}
+
+func TestUsePlugins(t *testing.T) {
+	cacheSvc := mocks.NewMockCacheService()
+	b := &Builder{hooks: hooks.New(), cacheService: cacheSvc, logger: slog.New(slog.DiscardHandler)}
+
+	if b.pluginsChanged() {
+		t.Error("no plugins and none recorded: changed")
+	}
+	if err := b.Use(testPlugin{name: "a", version: "1"}, testPlugin{name: "b", version: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	post := &hooks.Post{}
+	if err := b.hooks.RunAfterParse(post); err != nil || post.HTML != "<!-- a --><!-- b -->" {
+		t.Errorf("hooks ran as %q, %v", post.HTML, err)
+	}
+	if !b.pluginsChanged() {
+		t.Error("first build with plugins: not changed")
+	}
+	if b.pluginsChanged() {
+		t.Error("same plugins: changed")
+	}
+
+	// A new version of a plugin means its cached output may be stale
+	b.plugins = []string{"b@1", "a@2"}
+	if !b.pluginsChanged() {
+		t.Error("new plugin version: not changed")
+	}
+
+	err := b.Use(testPlugin{name: "broken", err: errors.New("no config")})
+	if err == nil || !strings.Contains(err.Error(), "plugin broken: no config") {
+		t.Errorf("Use(broken) = %v", err)
+	}
+}
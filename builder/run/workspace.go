@@ -0,0 +1,105 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/metrics"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// WorkspaceSitesDir is the directory scanned for sites by `kosh build --all`
+const WorkspaceSitesDir = "sites"
+
+// FindWorkspaceSites returns the directories under root/sites that contain a kosh.yaml,
+// sorted by name so builds are deterministic.
+func FindWorkspaceSites(root string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(root, WorkspaceSitesDir, "*", "kosh.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	sites := make([]string, 0, len(matches))
+	for _, m := range matches {
+		sites = append(sites, filepath.Dir(m))
+	}
+	sort.Strings(sites)
+	return sites, nil
+}
+
+// workspaceCacheDir returns the per-site cache namespace inside the workspace cache.
+// Sites that configure their own cacheDir keep it.
+func workspaceCacheDir(cfg *config.Config, root, siteDir string) string {
+	defaultDir, err := filepath.Abs(filepath.Join(siteDir, ".kosh-cache"))
+	if err != nil || cfg.CacheDir != utils.NormalizePath(defaultDir) {
+		return cfg.CacheDir
+	}
+	return utils.NormalizePath(filepath.Join(root, ".kosh-cache", "sites", filepath.Base(siteDir)))
+}
+
+// RunAll builds every site of a multi-site workspace in a single process.
+// Each site is built from its own directory so relative paths in its kosh.yaml
+// resolve as usual, and combined metrics are printed at the end.
+func RunAll(ctx context.Context, args []string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	sites, err := FindWorkspaceSites(root)
+	if err != nil {
+		return err
+	}
+	if len(sites) == 0 {
+		return fmt.Errorf("no sites found (expected %s/<name>/kosh.yaml)", WorkspaceSitesDir)
+	}
+
+	total := metrics.NewBuildMetrics()
+	var failed []string
+
+	for _, siteDir := range sites {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		name := filepath.Base(siteDir)
+		logging.Statusf("\n🏗️  Building site: %s", name)
+
+		if err := buildWorkspaceSite(ctx, root, siteDir, args, total); err != nil {
+			logging.Statusf("❌ Site %s failed: %v", name, err)
+			failed = append(failed, name)
+		}
+	}
+
+	total.RecordEnd()
+	logging.Statusf("\n🌐 Workspace: %d/%d sites built", len(sites)-len(failed), len(sites))
+	total.Print()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d site(s) failed: %v", len(failed), failed)
+	}
+	return nil
+}
+
+func buildWorkspaceSite(ctx context.Context, root, siteDir string, args []string, total *metrics.BuildMetrics) error {
+	if err := os.Chdir(siteDir); err != nil {
+		return err
+	}
+	defer func() { _ = os.Chdir(root) }()
+
+	cfg := config.Load(args)
+	cfg.CacheDir = workspaceCacheDir(cfg, root, siteDir)
+
+	b := newBuilderWithConfig(cfg)
+	defer b.Close()
+
+	buildErr := b.Build(ctx)
+	b.SaveCaches()
+	total.Add(b.metrics)
+	return buildErr
+}
@@ -0,0 +1,69 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+func TestFindWorkspaceSites(t *testing.T) {
+	root := t.TempDir()
+	for _, site := range []string{"docs", "blog"} {
+		dir := filepath.Join(root, WorkspaceSitesDir, site)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "kosh.yaml"), []byte("title: "+site), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory without kosh.yaml is not a site
+	if err := os.MkdirAll(filepath.Join(root, WorkspaceSitesDir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	sites, err := FindWorkspaceSites(root)
+	if err != nil {
+		t.Fatalf("FindWorkspaceSites() error = %v", err)
+	}
+	if len(sites) != 2 {
+		t.Fatalf("FindWorkspaceSites() = %v, want 2 sites", sites)
+	}
+	if filepath.Base(sites[0]) != "blog" || filepath.Base(sites[1]) != "docs" {
+		t.Errorf("FindWorkspaceSites() = %v, want sorted [blog docs]", sites)
+	}
+}
+
+func TestWorkspaceCacheDir(t *testing.T) {
+	root := t.TempDir()
+	siteDir := filepath.Join(root, WorkspaceSitesDir, "blog")
+
+	tests := []struct {
+		name     string
+		cacheDir string
+		want     string
+	}{
+		{
+			name:     "default cache is namespaced",
+			cacheDir: utils.NormalizePath(filepath.Join(siteDir, ".kosh-cache")),
+			want:     utils.NormalizePath(filepath.Join(root, ".kosh-cache", "sites", "blog")),
+		},
+		{
+			name:     "custom cache is kept",
+			cacheDir: "/var/cache/blog",
+			want:     "/var/cache/blog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{CacheDir: tt.cacheDir}
+			if got := workspaceCacheDir(cfg, root, siteDir); got != tt.want {
+				t.Errorf("workspaceCacheDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -86,6 +86,11 @@ func PerformSearch(index *models.SearchIndex, query string, versionFilter string
 	k1 := 1.2
 	b := 0.75
 
+	weights := models.SearchWeights{Title: 1, Tags: 1, Body: 1}
+	if index.Weights != nil {
+		weights = *index.Weights
+	}
+
 	postCache := make(map[int]*models.PostRecord, maxResults)
 
 	// Process individual terms with BM25
@@ -111,7 +116,7 @@ func PerformSearch(index *models.SearchIndex, query string, versionFilter string
 
 				docLen := float64(index.DocLens[postID])
 				score := idf * (float64(freq) * (k1 + 1)) / (float64(freq) + k1*(1-b+b*(docLen/index.AvgDocLen)))
-				scores[postID] += score
+				scores[postID] += score * weights.Body
 			}
 		} else {
 			// Try fuzzy matching if exact term not found
@@ -145,7 +150,7 @@ func PerformSearch(index *models.SearchIndex, query string, versionFilter string
 						docLen := float64(index.DocLens[postID])
 						score := idf * (float64(freq) * (k1 + 1)) / (float64(freq) + k1*(1-b+b*(docLen/index.AvgDocLen)))
 						// Reduce score for fuzzy matches
-						scores[postID] += score * ScoreFuzzyModifier
+						scores[postID] += score * ScoreFuzzyModifier * weights.Body
 					}
 				}
 			}
@@ -161,13 +166,13 @@ func PerformSearch(index *models.SearchIndex, query string, versionFilter string
 
 			// Check if phrase appears in title (highest score)
 			if strings.Contains(post.NormalizedTitle, phrase) {
-				scores[i] += ScorePhraseMatch * 2
+				scores[i] += ScorePhraseMatch * 2 * weights.Title
 				continue
 			}
 
 			// Check if phrase appears in content
 			if strings.Contains(strings.ToLower(post.Content), phrase) {
-				scores[i] += ScorePhraseMatch
+				scores[i] += ScorePhraseMatch * weights.Body
 			}
 		}
 	}
@@ -192,20 +197,29 @@ func PerformSearch(index *models.SearchIndex, query string, versionFilter string
 
 		// Title match boost
 		if originalQuery != "" && strings.Contains(post.NormalizedTitle, originalQuery) {
-			scores[id] += ScoreTitleMatch
+			scores[id] += ScoreTitleMatch * weights.Title
 		}
 
 		// Tag match boost
 		for _, tag := range post.NormalizedTags {
 			if tag == originalQuery || tag == tagFilter {
-				scores[id] += ScoreTagMatch
+				scores[id] += ScoreTagMatch * weights.Tags
 			}
 		}
+
+		// Recency and section boosts, computed when the index was built
+		if post.Boost > 0 {
+			scores[id] *= post.Boost
+		}
 	}
 
 	// Build results
 	results := make([]Result, 0, len(scores))
 	for id, score := range scores {
+		// Only matched in fields weighted 0
+		if score <= 0 {
+			continue
+		}
 		post := index.Posts[id]
 		title := post.Title
 		if versionFilter == "all" && post.Version != "" {
@@ -236,3 +236,46 @@ func TestHasTagNormalized(t *testing.T) {
 		t.Error("HasTagNormalized should be case sensitive (it expects pre-normalized input)")
 	}
 }
+
+func TestPerformSearchBoosts(t *testing.T) {
+	// "install" is in the title of 0 and a tag of 1, and once in both texts
+	newIndex := func() *models.SearchIndex {
+		return &models.SearchIndex{
+			Posts: []models.PostRecord{
+				{ID: 0, Title: "Install", NormalizedTitle: "install", Content: "install steps"},
+				{ID: 1, Title: "Notes", NormalizedTitle: "notes", Content: "install notes", NormalizedTags: []string{"install"}},
+			},
+			Inverted:  map[string]map[int]int{"install": {0: 1, 1: 1}},
+			DocLens:   map[int]int{0: 2, 1: 2},
+			TotalDocs: 2,
+			AvgDocLen: 2,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		weights *models.SearchWeights
+		boost   float64 // Of post 1
+		wantIDs []int
+	}{
+		{"default weights favour titles", nil, 0, []int{0, 1}},
+		{"titles ignored", &models.SearchWeights{Title: 0, Tags: 1, Body: 1}, 0, []int{1, 0}},
+		{"page boost", nil, 3, []int{1, 0}},
+		{"every field ignored", &models.SearchWeights{}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := newIndex()
+			index.Weights = tt.weights
+			index.Posts[1].Boost = tt.boost
+
+			var gotIDs []int
+			for _, r := range PerformSearch(index, "install", "all") {
+				gotIDs = append(gotIDs, r.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("PerformSearch() = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
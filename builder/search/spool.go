@@ -0,0 +1,84 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// ContentSpool moves the plain-text content of search records into a temp
+// file. Content is by far the largest part of a record, so spooling it keeps
+// the records of huge sites small until the index is written out.
+type ContentSpool struct {
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	entries map[int]spoolEntry
+}
+
+type spoolEntry struct {
+	offset int64
+	length int
+}
+
+// NewContentSpool creates a spool backed by a temp file in dir
+func NewContentSpool(dir string) (*ContentSpool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, "search-spool-*")
+	if err != nil {
+		return nil, err
+	}
+	return &ContentSpool{file: f, entries: make(map[int]spoolEntry)}, nil
+}
+
+// Stash writes rec.Content to the spool under rec.ID and clears it on the
+// record. A nil spool leaves the record untouched.
+func (s *ContentSpool) Stash(rec *models.PostRecord) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.file.WriteAt([]byte(rec.Content), s.size)
+	if err != nil {
+		return err
+	}
+	s.entries[rec.ID] = spoolEntry{offset: s.size, length: n}
+	s.size += int64(n)
+	rec.Content = ""
+	return nil
+}
+
+// Content returns the content of rec, reading it back from the spool when
+// it was stashed
+func (s *ContentSpool) Content(rec models.PostRecord) (string, error) {
+	if s == nil {
+		return rec.Content, nil
+	}
+	s.mu.Lock()
+	entry, ok := s.entries[rec.ID]
+	s.mu.Unlock()
+	if !ok {
+		return rec.Content, nil
+	}
+	buf := make([]byte, entry.length)
+	if _, err := s.file.ReadAt(buf, entry.offset); err != nil {
+		return "", fmt.Errorf("failed to read spooled content of record %d: %w", rec.ID, err)
+	}
+	return string(buf), nil
+}
+
+// Close removes the spool file
+func (s *ContentSpool) Close() error {
+	if s == nil {
+		return nil
+	}
+	name := s.file.Name()
+	_ = s.file.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestContentSpool(t *testing.T) {
+	spool, err := NewContentSpool(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []models.PostRecord{
+		{ID: 0, Content: "first post body"},
+		{ID: 1, Content: ""},
+		{ID: 2, Content: "ünïcode content ✓"},
+	}
+	for i := range records {
+		want := records[i].Content
+		if err := spool.Stash(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+		if records[i].Content != "" {
+			t.Errorf("record %d content not cleared", i)
+		}
+		got, err := spool.Content(records[i])
+		if err != nil || got != want {
+			t.Errorf("Content(%d) = %q, %v; want %q", i, got, err, want)
+		}
+	}
+
+	// Records that were never stashed keep their inline content
+	if got, _ := spool.Content(models.PostRecord{ID: 9, Content: "inline"}); got != "inline" {
+		t.Errorf("Content(unstashed) = %q", got)
+	}
+
+	name := spool.file.Name()
+	if err := spool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Error("spool file not removed")
+	}
+
+	var none *ContentSpool
+	rec := models.PostRecord{Content: "kept"}
+	if err := none.Stash(&rec); err != nil || rec.Content != "kept" {
+		t.Error("nil spool should leave records alone")
+	}
+}
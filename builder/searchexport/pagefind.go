@@ -0,0 +1,30 @@
+package searchexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoPagefind is returned when the pagefind binary isn't on the PATH
+var ErrNoPagefind = errors.New("pagefind not found on PATH (install it with `npm i -g pagefind` or `pip install 'pagefind[bin]'`)")
+
+// RunPagefind writes a Pagefind bundle for the site in outputDir to the
+// output subdirectory. Pagefind's bundle is a binary format only its own
+// indexer writes, so this runs the pagefind CLI over the rendered pages.
+func RunPagefind(ctx context.Context, outputDir, output string) error {
+	bin, err := exec.LookPath("pagefind")
+	if err != nil {
+		return ErrNoPagefind
+	}
+	if output == "" {
+		output = DefaultPagefindOutput
+	}
+	cmd := exec.CommandContext(ctx, bin, "--site", outputDir, "--output-subdir", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("pagefind failed: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
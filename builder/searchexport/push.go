@@ -0,0 +1,227 @@
+package searchexport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// batchSize is how many documents go into one request
+const batchSize = 500
+
+// pushedDocument is a Document stamped with the build that pushed it, so
+// documents of removed pages can be found afterwards
+type pushedDocument struct {
+	Document
+	Build int64 `json:"build"`
+}
+
+// Push sends the documents to the Meilisearch or Typesense server of exp,
+// replacing what earlier builds pushed, and returns how many were sent
+func Push(ctx context.Context, client *http.Client, exp config.SearchExporter, src Source, build int64) (int, error) {
+	p := pusher{client: client, base: strings.TrimSuffix(exp.URL, "/"), index: exp.Index}
+	if p.index == "" {
+		p.index = DefaultIndex
+	}
+	switch exp.Type {
+	case Meilisearch:
+		if exp.APIKey != "" {
+			p.authHeader, p.authValue = "Authorization", "Bearer "+exp.APIKey
+		}
+		return p.meilisearch(ctx, src, build)
+	case Typesense:
+		p.authHeader, p.authValue = "X-TYPESENSE-API-KEY", exp.APIKey
+		return p.typesense(ctx, src, build)
+	}
+	return 0, fmt.Errorf("search exporter %q can't push documents", exp.Type)
+}
+
+type pusher struct {
+	client     *http.Client
+	base       string
+	index      string
+	authHeader string
+	authValue  string
+}
+
+// batches calls send with the documents of src in groups of batchSize
+func batches(src Source, build int64, send func([]pushedDocument) error) (int, error) {
+	var batch []pushedDocument
+	count := 0
+	err := src.Each(func(doc Document) error {
+		batch = append(batch, pushedDocument{Document: doc, Build: build})
+		count++
+		if len(batch) < batchSize {
+			return nil
+		}
+		err := send(batch)
+		batch = batch[:0]
+		return err
+	})
+	if err == nil && len(batch) > 0 {
+		err = send(batch)
+	}
+	return count, err
+}
+
+// meilisearch adds the documents to the index (creating it on first use) and
+// then deletes the ones no longer on the site. Meilisearch queues both
+// tasks, so searches keep working while the index is updated.
+func (p pusher) meilisearch(ctx context.Context, src Source, build int64) (int, error) {
+	docsURL := p.base + "/indexes/" + url.PathEscape(p.index) + "/documents"
+	current := make(map[string]bool, len(src.Posts))
+	count, err := batches(src, build, func(docs []pushedDocument) error {
+		for _, d := range docs {
+			current[d.ID] = true
+		}
+		body, err := json.Marshal(docs)
+		if err != nil {
+			return err
+		}
+		_, err = p.do(ctx, http.MethodPost, docsURL+"?primaryKey=id", "application/json", body)
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+
+	var stale []string
+	for offset := 0; ; offset += 1000 {
+		resp, err := p.do(ctx, http.MethodGet, fmt.Sprintf("%s?fields=id&limit=1000&offset=%d", docsURL, offset), "", nil)
+		if err != nil {
+			return count, err
+		}
+		var page struct {
+			Results []struct {
+				ID string `json:"id"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return count, fmt.Errorf("failed to read meilisearch documents: %w", err)
+		}
+		for _, r := range page.Results {
+			if !current[r.ID] {
+				stale = append(stale, r.ID)
+			}
+		}
+		if len(page.Results) < 1000 {
+			break
+		}
+	}
+	if len(stale) > 0 {
+		body, _ := json.Marshal(stale)
+		if _, err := p.do(ctx, http.MethodPost, docsURL+"/delete-batch", "application/json", body); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// typesense upserts the documents into the collection (creating it with an
+// auto-detected schema on first use), then deletes those an earlier build pushed
+func (p pusher) typesense(ctx context.Context, src Source, build int64) (int, error) {
+	collection := p.base + "/collections/" + url.PathEscape(p.index)
+	schema, _ := json.Marshal(map[string]any{
+		"name":   p.index,
+		"fields": []map[string]string{{"name": ".*", "type": "auto"}},
+	})
+	if _, err := p.do(ctx, http.MethodPost, p.base+"/collections", "application/json", schema); err != nil && !isStatus(err, http.StatusConflict) {
+		return 0, err
+	}
+
+	count, err := batches(src, build, func(docs []pushedDocument) error {
+		var body bytes.Buffer
+		enc := json.NewEncoder(&body)
+		for _, d := range docs {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
+		resp, err := p.do(ctx, http.MethodPost, collection+"/documents/import?action=upsert", "text/plain", body.Bytes())
+		if err != nil {
+			return err
+		}
+		// Typesense answers 200 with one result line per document
+		lines := bufio.NewScanner(bytes.NewReader(resp))
+		lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for lines.Scan() {
+			var result struct {
+				Success bool   `json:"success"`
+				Error   string `json:"error"`
+			}
+			if json.Unmarshal(lines.Bytes(), &result) == nil && !result.Success {
+				return fmt.Errorf("typesense rejected a document: %s", result.Error)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	filter := url.QueryEscape(fmt.Sprintf("build:<%d", build))
+	if _, err := p.do(ctx, http.MethodDelete, collection+"/documents?filter_by="+filter, "", nil); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// statusError is a response outside 2xx
+type statusError struct {
+	method, url string
+	code        int
+	body        string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.method, e.url, e.code, e.body)
+}
+
+func isStatus(err error, code int) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == code
+}
+
+func (p pusher) do(ctx context.Context, method, target, contentType string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if p.authValue != "" {
+		req.Header.Set(p.authHeader, p.authValue)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return nil, &statusError{method: method, url: target, code: resp.StatusCode, body: msg}
+	}
+	return data, nil
+}
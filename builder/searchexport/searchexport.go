@@ -0,0 +1,115 @@
+// Package searchexport hands the posts Kosh indexed for its own search to
+// other search frontends: a Lunr document file, a Pagefind bundle, or a
+// Meilisearch/Typesense server.
+package searchexport
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// Exporter types
+const (
+	Lunr        = "lunr"
+	Pagefind    = "pagefind"
+	Meilisearch = "meilisearch"
+	Typesense   = "typesense"
+)
+
+// DefaultLunrOutput is where the Lunr documents are written, relative to the output directory
+const DefaultLunrOutput = "search/lunr.json"
+
+// DefaultPagefindOutput is the Pagefind bundle directory, relative to the output directory
+const DefaultPagefindOutput = "pagefind"
+
+// DefaultIndex is the Meilisearch index or Typesense collection documents are pushed to
+const DefaultIndex = "kosh"
+
+// Document is a post as the exporters publish it
+type Document struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Content     string   `json:"content"`
+	Version     string   `json:"version,omitempty"`
+}
+
+// Source is the search data of a build. When Spool is non-nil the record
+// contents live in it (low-memory mode) and are read back one at a time.
+type Source struct {
+	BaseURL string
+	Posts   []models.IndexedPost
+	Spool   *search.ContentSpool
+}
+
+// Each calls fn with the document of every indexed post, in index order
+func (s Source) Each(fn func(Document) error) error {
+	for _, ip := range s.Posts {
+		content, err := s.Spool.Content(ip.Record)
+		if err != nil {
+			return err
+		}
+		doc := Document{
+			ID:          DocumentID(ip.Record.Link),
+			Title:       ip.Record.Title,
+			URL:         utils.BuildURL(s.BaseURL, "", ip.Record.Link),
+			Description: ip.Record.Description,
+			Tags:        ip.Record.Tags,
+			Content:     content,
+			Version:     ip.Record.Version,
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DocumentID derives a stable ID from a page's path. Search servers only
+// accept letters, digits, - and _ in IDs, so the path itself can't be used.
+func DocumentID(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return hex.EncodeToString(sum[:8])
+}
+
+// WriteLunr writes the documents as a JSON array, streamed so only one
+// post's content is in memory at a time. Lunr builds its index from them in
+// the browser with ref "id" and the title, description, tags and content fields.
+func WriteLunr(destFs afero.Fs, path string, src Source) (int, error) {
+	if err := destFs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	f, err := destFs.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	count := 0
+	_ = w.WriteByte('[')
+	err = src.Each(func(doc Document) error {
+		if count > 0 {
+			_ = w.WriteByte(',')
+		}
+		count++
+		return enc.Encode(doc)
+	})
+	if err != nil {
+		return 0, err
+	}
+	_ = w.WriteByte(']')
+	return count, w.Flush()
+}
@@ -0,0 +1,175 @@
+package searchexport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+)
+
+func testSource(t *testing.T, spooled bool) Source {
+	t.Helper()
+	posts := []models.IndexedPost{
+		{Record: models.PostRecord{ID: 0, Title: "Running", Link: "posts/running.html", Tags: []string{"sport"}, Content: "running runners ran"}},
+		{Record: models.PostRecord{ID: 1, Title: "Install", Link: "v2/docs/install.html", Description: "Setup", Content: "go install", Version: "v2"}},
+	}
+	src := Source{BaseURL: "https://example.com/", Posts: posts}
+	if spooled {
+		spool, err := search.NewContentSpool(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = spool.Close() })
+		for i := range posts {
+			if err := spool.Stash(&posts[i].Record); err != nil {
+				t.Fatal(err)
+			}
+		}
+		src.Spool = spool
+	}
+	return src
+}
+
+func TestWriteLunr(t *testing.T) {
+	want := []Document{
+		{ID: DocumentID("posts/running.html"), Title: "Running", URL: "https://example.com/posts/running.html", Tags: []string{"sport"}, Content: "running runners ran"},
+		{ID: DocumentID("v2/docs/install.html"), Title: "Install", URL: "https://example.com/v2/docs/install.html", Description: "Setup", Content: "go install", Version: "v2"},
+	}
+	for _, spooled := range []bool{false, true} {
+		fs := afero.NewMemMapFs()
+		n, err := WriteLunr(fs, "public/search/lunr.json", testSource(t, spooled))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := afero.ReadFile(fs, "public/search/lunr.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []Document
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("invalid JSON %s: %v", data, err)
+		}
+		if n != 2 || !reflect.DeepEqual(got, want) {
+			t.Errorf("spooled=%v: wrote %d documents %+v, want %+v", spooled, n, got, want)
+		}
+	}
+}
+
+func TestDocumentID(t *testing.T) {
+	id := DocumentID("posts/a.html")
+	if id != DocumentID("posts/a.html") || id == DocumentID("posts-a.html") {
+		t.Errorf("DocumentID is not stable and distinct: %q", id)
+	}
+	if strings.Trim(id, "0123456789abcdef") != "" {
+		t.Errorf("DocumentID(%q) = %q, want hex", "posts/a.html", id)
+	}
+}
+
+// fakeServer records requests and answers them with the handler's response
+func fakeServer(t *testing.T, respond func(r *http.Request, body string) (int, string)) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		mu.Unlock()
+		code, resp := respond(r, string(body))
+		w.WriteHeader(code)
+		_, _ = io.WriteString(w, resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPushMeilisearch(t *testing.T) {
+	var pushed []pushedDocument
+	srv, requests := fakeServer(t, func(r *http.Request, body string) (int, string) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			return http.StatusUnauthorized, `{"message":"missing key"}`
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/indexes/docs/documents":
+			_ = json.Unmarshal([]byte(body), &pushed)
+		case r.Method == http.MethodGet:
+			return http.StatusOK, `{"results":[{"id":"` + DocumentID("posts/running.html") + `"},{"id":"gone"}]}`
+		case r.URL.Path == "/indexes/docs/documents/delete-batch" && body != `["gone"]`:
+			t.Errorf("deleted %s, want [\"gone\"]", body)
+		}
+		return http.StatusAccepted, `{"taskUid":1}`
+	})
+
+	exp := config.SearchExporter{Type: Meilisearch, URL: srv.URL + "/", Index: "docs", APIKey: "secret"}
+	n, err := Push(context.Background(), srv.Client(), exp, testSource(t, false), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(pushed) != 2 || pushed[1].Build != 42 || pushed[1].URL != "https://example.com/v2/docs/install.html" {
+		t.Errorf("pushed %d documents %+v", n, pushed)
+	}
+	want := []string{
+		"POST /indexes/docs/documents?primaryKey=id",
+		"GET /indexes/docs/documents?fields=id&limit=1000&offset=0",
+		"POST /indexes/docs/documents/delete-batch",
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("requests = %q, want %q", *requests, want)
+	}
+}
+
+func TestPushTypesense(t *testing.T) {
+	srv, requests := fakeServer(t, func(r *http.Request, body string) (int, string) {
+		if r.Header.Get("X-TYPESENSE-API-KEY") != "secret" {
+			return http.StatusUnauthorized, `{"message":"missing key"}`
+		}
+		switch {
+		case r.URL.Path == "/collections":
+			return http.StatusConflict, `{"message":"already exists"}`
+		case strings.HasSuffix(r.URL.Path, "/import"):
+			if lines := strings.Count(body, "\n"); lines != 2 {
+				t.Errorf("imported %d lines, want 2", lines)
+			}
+			return http.StatusOK, "{\"success\":true}\n{\"success\":true}"
+		}
+		return http.StatusOK, `{"num_deleted":1}`
+	})
+
+	exp := config.SearchExporter{Type: Typesense, URL: srv.URL, APIKey: "secret"}
+	n, err := Push(context.Background(), srv.Client(), exp, testSource(t, true), 42)
+	if err != nil || n != 2 {
+		t.Fatalf("Push() = %d, %v", n, err)
+	}
+	want := []string{
+		"POST /collections",
+		"POST /collections/kosh/documents/import?action=upsert",
+		"DELETE /collections/kosh/documents?filter_by=build%3A%3C42",
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("requests = %q, want %q", *requests, want)
+	}
+}
+
+func TestPushTypesenseRejected(t *testing.T) {
+	srv, _ := fakeServer(t, func(r *http.Request, body string) (int, string) {
+		if strings.HasSuffix(r.URL.Path, "/import") {
+			return http.StatusOK, "{\"success\":true}\n{\"success\":false,\"error\":\"bad field\"}"
+		}
+		return http.StatusCreated, `{}`
+	})
+	exp := config.SearchExporter{Type: Typesense, URL: srv.URL}
+	if _, err := Push(context.Background(), srv.Client(), exp, testSource(t, false), 1); err == nil || !strings.Contains(err.Error(), "bad field") {
+		t.Errorf("Push() error = %v, want the rejected document", err)
+	}
+}
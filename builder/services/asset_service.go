@@ -2,36 +2,138 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/spf13/afero"
 
 	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/metrics"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
+// manifestFile is the asset map published with assets.manifest
+const manifestFile = "asset-manifest.json"
+
+// mediaManifestFile maps image paths to their content-hash names, published
+// with images.dedupe
+const mediaManifestFile = "media-manifest.json"
+
+// bundledExts are built by esbuild rather than copied as they are
+var bundledExts = []string{".css", ".scss", ".sass", ".js"}
+
 type assetServiceImpl struct {
 	sourceFs afero.Fs
 	destFs   afero.Fs
 	cfg      *config.Config
+	cache    CacheService // Optional, enables skipping unchanged static files
 	renderer RenderService
 	logger   *slog.Logger
+	metrics  *metrics.BuildMetrics
+	media    *utils.MediaNames // Set by Build with images.dedupe
 }
 
-func NewAssetService(sourceFs, destFs afero.Fs, cfg *config.Config, renderer RenderService, logger *slog.Logger) AssetService {
+func NewAssetService(sourceFs, destFs afero.Fs, cfg *config.Config, cache CacheService, renderer RenderService, logger *slog.Logger, metrics *metrics.BuildMetrics) AssetService {
 	return &assetServiceImpl{
 		sourceFs: sourceFs,
 		destFs:   destFs,
 		cfg:      cfg,
+		cache:    cache,
 		renderer: renderer,
 		logger:   logger,
+		metrics:  metrics,
+	}
+}
+
+// staticIndex loads the static files recorded by the last build, or returns
+// nil (copy everything) when there is no cache
+func (s *assetServiceImpl) staticIndex() *utils.StaticIndex {
+	if s.cache == nil {
+		return nil
+	}
+	files, err := s.cache.GetStaticFiles()
+	if err != nil {
+		s.logger.Warn("Failed to load static file records", "error", err)
+		files = nil
+	}
+	return utils.NewStaticIndex(files)
+}
+
+// saveStaticIndex records the copied static files for the next build
+func (s *assetServiceImpl) saveStaticIndex(index *utils.StaticIndex) {
+	if index == nil {
+		return
+	}
+	if err := s.cache.SetStaticFiles(index.Files()); err != nil {
+		s.logger.Warn("Failed to save static file records", "error", err)
+	}
+	if s.metrics != nil {
+		s.metrics.RecordStaticSkipped(index.Skipped())
+	}
+}
+
+// copyExcluded reports the static files the copy leaves out: the ones
+// esbuild builds and the ones uploaded to largeFiles.storage instead
+func (s *assetServiceImpl) copyExcluded(relPath string, info fs.FileInfo) bool {
+	return slices.Contains(bundledExts, strings.ToLower(filepath.Ext(relPath))) || s.cfg.LargeFile(relPath, info.Size())
+}
+
+// copyDest is where the static copy of srcDir writes each file: nowhere for
+// excluded files, the content-hash name for images with images.dedupe (only
+// once for identical ones), else the file's own path
+func (s *assetServiceImpl) copyDest(srcDir string) func(relPath string, info fs.FileInfo) (string, bool) {
+	return func(relPath string, info fs.FileInfo) (string, bool) {
+		if s.copyExcluded(relPath, info) {
+			return "", false
+		}
+		return s.media.Dest(srcDir, relPath)
+	}
+}
+
+// Media maps the URL paths of static images to their content-hash paths
+// with images.dedupe; nil without
+func (s *assetServiceImpl) Media() map[string]string {
+	return s.media.Links()
+}
+
+// nameMedia names the static images by content for images.dedupe and
+// publishes the mapping
+func (s *assetServiceImpl) nameMedia(index *utils.StaticIndex, images utils.ImageOptions) {
+	s.media = nil
+	if !s.cfg.DedupeImages() {
+		return
+	}
+	media, err := utils.NewMediaNames(s.sourceFs, []string{s.cfg.StaticDir, "static"}, images, index, s.copyExcluded)
+	if err != nil {
+		s.logger.Warn("Failed to name images by content: publishing them at their paths", "error", err)
+		return
 	}
+	s.media = media
+
+	data, _ := json.MarshalIndent(media.Links(), "", "  ")
+	destStaticDir := filepath.Join(s.cfg.OutputDir, "static")
+	path := filepath.Join(destStaticDir, mediaManifestFile)
+	if err := utils.WriteFileVFS(s.destFs, path, append(data, '\n')); err != nil {
+		s.logger.Warn("Failed to write media manifest", "error", err)
+		return
+	}
+	s.renderer.RegisterFile(path)
 }
 
 func (s *assetServiceImpl) Build(ctx context.Context) error {
+	index := s.staticIndex()
+	images := imageOptions(s.cfg)
+	// Before the bundles, which publish the names in the asset map
+	s.nameMedia(index, images)
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -47,11 +149,15 @@ func (s *assetServiceImpl) Build(ctx context.Context) error {
 		default:
 		}
 
+		if s.cfg.ResponsiveImages() && slices.Contains(s.cfg.Images.Formats, "avif") && images.FFmpeg == "" {
+			s.logger.Warn("ffmpeg not found: images get no AVIF copies")
+		}
+
 		// Theme Static
 		if exists, _ := afero.Exists(s.sourceFs, s.cfg.StaticDir); exists {
-			// Exclude .css and .js files from raw copy (they're handled by esbuild)
+			// Exclude stylesheets and .js files from raw copy (they're handled by esbuild)
 			destStaticDir := filepath.Join(s.cfg.OutputDir, "static")
-			if err := utils.CopyDirVFS(s.sourceFs, s.destFs, s.cfg.StaticDir, destStaticDir, s.cfg.CompressImages, []string{".css", ".js"}, s.renderer.RegisterFile, s.cfg.CacheDir+"/images", s.cfg.ImageWorkers); err != nil {
+			if err := utils.CopyDirVFS(s.sourceFs, s.destFs, s.cfg.StaticDir, destStaticDir, images, s.copyDest(s.cfg.StaticDir), s.renderer.RegisterFile, s.cfg.CacheDir+"/images", s.cfg.ImageWorkers, index, s.metrics); err != nil {
 				s.logger.Warn("Failed to copy theme static assets", "error", err)
 			}
 		}
@@ -66,10 +172,11 @@ func (s *assetServiceImpl) Build(ctx context.Context) error {
 		// Site Static (Root 'static' folder)
 		if exists, _ := afero.Exists(s.sourceFs, "static"); exists {
 			destStaticDir := filepath.Join(s.cfg.OutputDir, "static")
-			if err := utils.CopyDirVFS(s.sourceFs, s.destFs, "static", destStaticDir, s.cfg.CompressImages, []string{".css", ".js"}, s.renderer.RegisterFile, s.cfg.CacheDir+"/images", s.cfg.ImageWorkers); err != nil {
+			if err := utils.CopyDirVFS(s.sourceFs, s.destFs, "static", destStaticDir, images, s.copyDest("static"), s.renderer.RegisterFile, s.cfg.CacheDir+"/images", s.cfg.ImageWorkers, index, s.metrics); err != nil {
 				s.logger.Warn("Failed to copy site static assets", "error", err)
 			}
 		}
+		s.saveStaticIndex(index)
 
 		// Copy wasm_exec.js separately (it's needed by the WASM search but shouldn't be processed by esbuild)
 		wasmExecPath := s.cfg.StaticDir + "/js/wasm_exec.js"
@@ -238,7 +345,7 @@ func (s *assetServiceImpl) Build(ctx context.Context) error {
 		}
 	}()
 
-	// 2. Esbuild Bundling (CSS/JS)
+	// 2. Esbuild Bundling (CSS/Sass/JS)
 	go func() {
 		defer wg.Done()
 
@@ -250,15 +357,9 @@ func (s *assetServiceImpl) Build(ctx context.Context) error {
 		default:
 		}
 
-		destStaticDir := filepath.Join(s.cfg.OutputDir, "static")
-		// Force rebuild in dev mode to ensure changes are picked up
-		force := s.cfg.IsDev
-		assets, assetErr := utils.BuildAssetsEsbuild(s.sourceFs, s.destFs, s.cfg.StaticDir, destStaticDir, s.cfg.CompressImages, s.renderer.RegisterFile, s.cfg.CacheDir+"/assets", force)
-		if assetErr != nil {
-			s.logger.Error("Failed to build assets", "error", assetErr)
-			return
+		if _, err := s.BuildBundles(ctx); err != nil {
+			s.logger.Error("Failed to build assets", "error", err)
 		}
-		s.renderer.SetAssets(assets)
 	}()
 
 	// Wait for both goroutines or context cancellation
@@ -276,3 +377,59 @@ func (s *assetServiceImpl) Build(ctx context.Context) error {
 		return nil
 	}
 }
+
+// BuildBundles builds the CSS, Sass and JS bundles and reports whether their
+// published paths changed, in which case pages linking them must be
+// re-rendered
+func (s *assetServiceImpl) BuildBundles(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	destStaticDir := filepath.Join(s.cfg.OutputDir, "static")
+	// Force rebuild in dev mode to ensure changes are picked up
+	force := s.cfg.IsDev
+	opts := utils.AssetOptions{
+		Minify:   s.cfg.MinifyAssets(),
+		BundleJS: s.cfg.Assets.BundleJS,
+		Bundles:  s.cfg.Assets.Bundles,
+		Sass:     utils.SassOptions{Binary: s.cfg.Sass.Binary, LoadPaths: s.cfg.Sass.LoadPaths},
+		Media:    s.media.Links(),
+	}
+	// The site's static/ is copied over the theme's; its stylesheets and
+	// scripts are built alongside
+	if siteDir, err := filepath.Abs("static"); err == nil {
+		opts.SiteDir = siteDir
+	}
+	assets, err := utils.BuildAssetsEsbuild(s.sourceFs, s.destFs, s.cfg.StaticDir, destStaticDir, s.renderer.RegisterFile, s.cfg.CacheDir+"/assets", force, opts)
+	if err != nil {
+		return false, err
+	}
+	// Templates look images up like stylesheets: {{ asset .Assets "/static/images/a.webp" }}
+	maps.Copy(assets, s.media.Links())
+	if s.cfg.Assets.Manifest {
+		if err := s.writeManifest(destStaticDir, assets); err != nil {
+			return false, err
+		}
+	}
+	changed := !maps.Equal(assets, s.renderer.GetAssets())
+	s.renderer.SetAssets(assets)
+	return changed, nil
+}
+
+// writeManifest publishes the asset map, for scripts and tools outside the
+// templates that need the fingerprinted names
+func (s *assetServiceImpl) writeManifest(destStaticDir string, assets map[string]string) error {
+	data, err := json.MarshalIndent(assets, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(destStaticDir, manifestFile)
+	if err := s.destFs.MkdirAll(destStaticDir, 0755); err != nil {
+		return err
+	}
+	if err := afero.WriteFile(s.destFs, path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write asset manifest: %w", err)
+	}
+	s.renderer.RegisterFile(path)
+	return nil
+}
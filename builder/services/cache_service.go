@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
 // cacheServiceImpl implements CacheService
@@ -43,6 +44,14 @@ func (s *cacheServiceImpl) GetPostsByTemplate(templatePath string) ([]string, er
 	return s.manager.GetPostsByTemplate(templatePath)
 }
 
+func (s *cacheServiceImpl) GetPostsByInclude(name string) ([]string, error) {
+	return s.manager.GetPostsByInclude(name)
+}
+
+func (s *cacheServiceImpl) GetPostsByFile(path string) ([]string, error) {
+	return s.manager.GetPostsByFile(path)
+}
+
 func (s *cacheServiceImpl) GetSearchRecords(ids []string) (map[string]*cache.SearchRecord, error) {
 	return s.manager.GetSearchRecords(ids)
 }
@@ -71,6 +80,26 @@ func (s *cacheServiceImpl) SetGraphHash(hash string) error {
 	return s.manager.SetGraphHash(hash)
 }
 
+func (s *cacheServiceImpl) GetTemplateMetas() (map[string]*cache.TemplateMeta, error) {
+	return s.manager.GetTemplateMetas()
+}
+
+func (s *cacheServiceImpl) SetTemplateMetas(metas map[string]*cache.TemplateMeta) error {
+	return s.manager.SetTemplateMetas(metas)
+}
+
+func (s *cacheServiceImpl) GetStaticFiles() (map[string]utils.StaticFile, error) {
+	return s.manager.GetStaticFiles()
+}
+
+func (s *cacheServiceImpl) SetStaticFiles(files map[string]utils.StaticFile) error {
+	return s.manager.SetStaticFiles(files)
+}
+
+func (s *cacheServiceImpl) UpdateOutputFiles(files map[string]utils.OutputFile) error {
+	return s.manager.UpdateOutputFiles(files)
+}
+
 func (s *cacheServiceImpl) GetWasmHash() (string, error) {
 	return s.manager.GetWasmHash()
 }
@@ -79,6 +108,14 @@ func (s *cacheServiceImpl) SetWasmHash(hash string) error {
 	return s.manager.SetWasmHash(hash)
 }
 
+func (s *cacheServiceImpl) GetPluginsHash() (string, error) {
+	return s.manager.GetPluginsHash()
+}
+
+func (s *cacheServiceImpl) SetPluginsHash(hash string) error {
+	return s.manager.SetPluginsHash(hash)
+}
+
 func (s *cacheServiceImpl) StoreHTML(content []byte) (string, error) {
 	return s.manager.StoreHTML(content)
 }
@@ -110,6 +147,18 @@ func (s *cacheServiceImpl) IsDirty(postID string) bool {
 	return ok && dirty
 }
 
+func (s *cacheServiceImpl) MarkPending(paths []string) error {
+	return s.manager.MarkPending(paths)
+}
+
+func (s *cacheServiceImpl) PendingPaths() (map[string]bool, error) {
+	return s.manager.PendingPaths()
+}
+
+func (s *cacheServiceImpl) ClearPending() error {
+	return s.manager.ClearPending()
+}
+
 func (s *cacheServiceImpl) ClearDirty() {
 	// Fresh map allocation is faster than Range+Delete for bulk clear
 	s.dirty = sync.Map{}
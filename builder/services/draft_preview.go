@@ -0,0 +1,107 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// PreviewSecretEnv sets the secret draft preview URLs are derived from. CI
+// should set it so links stay the same across deploys.
+const PreviewSecretEnv = "KOSH_PREVIEW_SECRET"
+
+// previewKeyFile holds the generated secret in the cache directory when
+// PreviewSecretEnv is not set
+const previewKeyFile = "preview.key"
+
+// PreviewSecret returns the key draft preview tokens are derived from:
+// PreviewSecretEnv when set, otherwise a random key generated once and kept
+// in the cache directory
+func PreviewSecret(cacheDir string) ([]byte, error) {
+	if s := os.Getenv(PreviewSecretEnv); s != "" {
+		sum := blake3.Sum256([]byte(s))
+		return sum[:], nil
+	}
+
+	path := filepath.Join(cacheDir, previewKeyFile)
+	if data, err := os.ReadFile(path); err == nil {
+		if key, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil && len(key) == 32 {
+			return key, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// PreviewToken is the unguessable file name of a draft's preview: a keyed
+// BLAKE3 hash of its content path, so the link is stable across builds but
+// can't be derived without the secret
+func PreviewToken(secret []byte, relPath string) string {
+	if len(secret) != 32 {
+		sum := blake3.Sum256(secret) // Keyed BLAKE3 takes exactly 32 bytes
+		secret = sum[:]
+	}
+	h, _ := blake3.NewKeyed(secret)
+	_, _ = h.WriteString(filepath.ToSlash(relPath))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// draftPreviewJob renders a draft at <dir>/<token>.html, or in dev builds
+// at its usual path under DevDraftsDir, which the dev server serves at
+// DevDraftsPath. The page is marked noindex and left out of every listing:
+// it only reaches the render pool, never the post metadata, search index,
+// sitemap or feeds.
+func (s *postServiceImpl) draftPreviewJob(relPath, body string, data models.PageData) (*renderJob, error) {
+	data.NoIndex = true
+	if s.cfg.IsDev {
+		htmlRelPath := s.cfg.HTMLPath(relPath)
+		data.Permalink = s.cfg.BaseURL + config.DevDraftsPath + htmlRelPath
+		return &renderJob{
+			source:   relPath,
+			destPath: filepath.Join(s.cfg.DevDraftsDir(), filepath.FromSlash(htmlRelPath)),
+			data:     data,
+			body:     body,
+			preview:  true,
+		}, nil
+	}
+
+	s.previewOnce.Do(func() {
+		s.previewSecret, s.previewErr = PreviewSecret(s.cfg.CacheDir)
+	})
+	if s.previewErr != nil {
+		return nil, s.previewErr
+	}
+
+	dir := filepath.Clean("/" + s.cfg.DraftPreviews.Dir) // Never outside the output directory
+	name := PreviewToken(s.previewSecret, relPath) + ".html"
+	data.Permalink = utils.BuildURL(s.cfg.BaseURL, "", filepath.ToSlash(filepath.Join(dir, name)))
+
+	return &renderJob{
+		source:   relPath,
+		destPath: filepath.Join(s.cfg.OutputDir, dir, name),
+		data:     data,
+		body:     body,
+		preview:  true,
+	}, nil
+}
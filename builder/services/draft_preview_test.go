@@ -0,0 +1,86 @@
+package services
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestPreviewToken(t *testing.T) {
+	a := bytes.Repeat([]byte{1}, 32)
+	b := bytes.Repeat([]byte{2}, 32)
+
+	tok := PreviewToken(a, "posts/draft.md")
+	if len(tok) != 32 {
+		t.Fatalf("token %q: want 32 hex chars", tok)
+	}
+	tests := []struct {
+		name   string
+		secret []byte
+		path   string
+		same   bool
+	}{
+		{"stable", a, "posts/draft.md", true},
+		{"os separators", a, filepath.Join("posts", "draft.md"), true},
+		{"other path", a, "posts/other.md", false},
+		{"other secret", b, "posts/draft.md", false},
+		{"short secret", []byte("s"), "posts/draft.md", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PreviewToken(tt.secret, tt.path); (got == tok) != tt.same {
+				t.Errorf("PreviewToken = %q, base %q, want same=%v", got, tok, tt.same)
+			}
+		})
+	}
+}
+
+func TestPreviewSecret(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(PreviewSecretEnv, "")
+
+	first, err := PreviewSecret(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 32 {
+		t.Fatalf("generated key is %d bytes, want 32", len(first))
+	}
+	info, err := os.Stat(filepath.Join(dir, previewKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		t.Errorf("key file mode %v is readable by others", info.Mode().Perm())
+	}
+	again, err := PreviewSecret(dir)
+	if err != nil || !bytes.Equal(first, again) {
+		t.Errorf("second call = %x, %v; want the stored key %x", again, err, first)
+	}
+
+	t.Setenv(PreviewSecretEnv, "ci-secret")
+	env, err := PreviewSecret(t.TempDir())
+	if err != nil || bytes.Equal(env, first) || len(env) != 32 {
+		t.Errorf("env secret = %x, %v", env, err)
+	}
+}
+
+func TestDevDraftJob(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:2604", CacheDir: ".kosh-cache", OutputDir: "public", IsDev: true}
+	s := &postServiceImpl{cfg: cfg}
+
+	job, err := s.draftPreviewJob(filepath.Join("posts", "WIP.md"), "<p>wip</p>", models.PageData{Title: "WIP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(".kosh-cache", "drafts", "posts", "wip.html"); job.destPath != want {
+		t.Errorf("destPath = %q, want %q outside the output directory", job.destPath, want)
+	}
+	if job.data.Permalink != "http://localhost:2604/drafts/posts/wip.html" || !job.data.NoIndex || !job.preview {
+		t.Errorf("job = %+v", job)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// maxCompressedWidth is the width CopyDirVFS scales WebP-converted images to
+const maxCompressedWidth = 1200
+
+var galleryExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+type galleryProviderImpl struct {
+	cfg      *config.Config
+	sourceFs afero.Fs
+	destFs   afero.Fs
+	renderer RenderService
+	logger   *slog.Logger
+}
+
+// NewGalleryProvider serves gallery shortcodes. Thumbnails are WebP files
+// written next to the images as <dir>/thumbs/<name>-<width>.webp and cached
+// under <cacheDir>/gallery, so re-rendering a gallery only decodes new or
+// changed images.
+func NewGalleryProvider(cfg *config.Config, sourceFs, destFs afero.Fs, renderer RenderService, logger *slog.Logger) mdParser.GalleryProvider {
+	return &galleryProviderImpl{cfg: cfg, sourceFs: sourceFs, destFs: destFs, renderer: renderer, logger: logger}
+}
+
+// galleryEntry is what the thumbnail cache keeps next to each thumbnail
+type galleryEntry struct {
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	ThumbWidth  int       `json:"thumbWidth"`
+	ThumbHeight int       `json:"thumbHeight"`
+	Taken       time.Time `json:"taken"`
+}
+
+func (g *galleryProviderImpl) Gallery(dir string, thumbWidth int) ([]mdParser.GalleryImage, error) {
+	dir, ok := staticPath(dir)
+	if !ok {
+		return nil, errors.New(`dir must be inside the site's static/ directory, e.g. "static/photos/trip"`)
+	}
+	entries, err := afero.ReadDir(g.sourceFs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []mdParser.GalleryImage
+	for _, info := range entries {
+		ext := strings.ToLower(filepath.Ext(info.Name()))
+		if info.IsDir() || !galleryExts[ext] {
+			continue
+		}
+		img, err := g.image(dir, info, thumbWidth)
+		if err != nil {
+			g.logger.Warn("Skipping gallery image", "path", path.Join(dir, info.Name()), "error", err)
+			continue
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func (g *galleryProviderImpl) image(dir string, info os.FileInfo, thumbWidth int) (mdParser.GalleryImage, error) {
+	name := info.Name()
+	src := path.Join(dir, name)
+	published, compressed := publishedImage(name, g.cfg.CompressImages)
+	thumbRel := path.Join(dir, "thumbs", fmt.Sprintf("%s-%d.webp", strings.TrimSuffix(name, filepath.Ext(name)), thumbWidth))
+
+	key := blake3.Sum256(fmt.Appendf(nil, "%s-%d-%d-%d-%v", src, info.Size(), info.ModTime().UnixNano(), thumbWidth, compressed))
+	cacheBase := filepath.Join(g.cfg.CacheDir, "gallery", hex.EncodeToString(key[:16]))
+
+	var entry galleryEntry
+	thumb, err := os.ReadFile(cacheBase + ".webp")
+	if err == nil {
+		var meta []byte
+		if meta, err = os.ReadFile(cacheBase + ".json"); err == nil {
+			err = json.Unmarshal(meta, &entry)
+		}
+	}
+	if err != nil {
+		if thumb, entry, err = g.makeThumb(src, thumbWidth, compressed); err != nil {
+			return mdParser.GalleryImage{}, err
+		}
+		if err := os.MkdirAll(filepath.Dir(cacheBase), 0755); err == nil {
+			meta, _ := json.Marshal(entry)
+			_ = os.WriteFile(cacheBase+".webp", thumb, 0644)
+			_ = os.WriteFile(cacheBase+".json", meta, 0644)
+		}
+	}
+
+	dest := filepath.Join(g.cfg.OutputDir, filepath.FromSlash(thumbRel))
+	if err := utils.WriteFileVFS(g.destFs, dest, thumb); err != nil {
+		return mdParser.GalleryImage{}, err
+	}
+	g.renderer.RegisterFile(dest)
+
+	return mdParser.GalleryImage{
+		Name:        name,
+		URL:         g.cfg.BaseURL + "/" + path.Join(dir, published),
+		Width:       entry.Width,
+		Height:      entry.Height,
+		ThumbURL:    g.cfg.BaseURL + "/" + thumbRel,
+		ThumbWidth:  entry.ThumbWidth,
+		ThumbHeight: entry.ThumbHeight,
+		Taken:       entry.Taken,
+	}, nil
+}
+
+// makeThumb decodes an image, reads its capture date and encodes its thumbnail
+func (g *galleryProviderImpl) makeThumb(src string, thumbWidth int, compressed bool) ([]byte, galleryEntry, error) {
+	var entry galleryEntry
+	data, err := afero.ReadFile(g.sourceFs, src)
+	if err != nil {
+		return nil, entry, err
+	}
+	entry.Taken, _ = utils.ExifDate(data)
+
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, entry, err
+	}
+	entry.Width, entry.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	if compressed && entry.Width > maxCompressedWidth {
+		entry.Width, entry.Height = maxCompressedWidth, scaledHeight(entry.Width, entry.Height, maxCompressedWidth)
+	}
+
+	if img.Bounds().Dx() > thumbWidth {
+		img = imaging.Resize(img, thumbWidth, 0, imaging.Lanczos)
+	}
+	entry.ThumbWidth, entry.ThumbHeight = img.Bounds().Dx(), img.Bounds().Dy()
+
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, &webp.Options{Quality: 75}); err != nil {
+		return nil, entry, err
+	}
+	return buf.Bytes(), entry, nil
+}
+
+// staticPath cleans a shortcode's path and reports whether it is inside static/
+func staticPath(p string) (string, bool) {
+	p = path.Clean(filepath.ToSlash(p))
+	return p, strings.HasPrefix(p, "static/")
+}
+
+// publishedImage is the name CopyDirVFS publishes an image under: JPEG and
+// PNG become WebP when images are compressed
+func publishedImage(name string, compress bool) (string, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if compress && (ext == ".jpg" || ext == ".jpeg" || ext == ".png") {
+		return strings.TrimSuffix(name, filepath.Ext(name)) + ".webp", true
+	}
+	return name, false
+}
+
+// scaledHeight is the height imaging.Resize gives an image scaled to width
+func scaledHeight(w, h, width int) int {
+	return int(math.Max(1, math.Floor(float64(width)*float64(h)/float64(w)+0.5)))
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+type imageProviderImpl struct {
+	cfg      *config.Config
+	sourceFs afero.Fs
+	logger   *slog.Logger
+	sizes    sync.Map // Cache key → mdParser.ImageSize, for images used on several pages
+}
+
+// NewImageProvider measures the static/ images markdown links to. Only the
+// image header is decoded; sizes are cached under <cacheDir>/imagesize by
+// image path, size and modification time, and account for the downscaling
+// of compressed images.
+func NewImageProvider(cfg *config.Config, sourceFs afero.Fs, logger *slog.Logger) mdParser.ImageProvider {
+	return &imageProviderImpl{cfg: cfg, sourceFs: sourceFs, logger: logger}
+}
+
+func (p *imageProviderImpl) ImageSize(src string) (mdParser.ImageSize, bool) {
+	var size mdParser.ImageSize
+	src, ok := p.staticSource(src)
+	if !ok || !galleryExts[strings.ToLower(path.Ext(src))] {
+		return size, false
+	}
+	info, err := p.sourceFs.Stat(src)
+	if err != nil {
+		return size, false
+	}
+	_, compressed := publishedImage(path.Base(src), p.cfg.CompressImages)
+	key := blake3.Sum256(fmt.Appendf(nil, "%s-%d-%d-%v", src, info.Size(), info.ModTime().UnixNano(), compressed))
+	keyHex := hex.EncodeToString(key[:16])
+	if cached, ok := p.sizes.Load(keyHex); ok {
+		size = cached.(mdParser.ImageSize)
+		if compressed {
+			p.addSrcsets(&size, src)
+		}
+		return size, true
+	}
+
+	cacheFile := filepath.Join(p.cfg.CacheDir, "imagesize", keyHex+".json")
+	data, err := os.ReadFile(cacheFile)
+	if err == nil {
+		err = json.Unmarshal(data, &size)
+	}
+	if err != nil {
+		if size, err = p.measure(src, compressed); err != nil {
+			p.logger.Warn("Failed to read image size", "path", src, "error", err)
+			return size, false
+		}
+		if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err == nil {
+			data, _ := json.Marshal(size)
+			_ = os.WriteFile(cacheFile, data, 0644)
+		}
+	}
+	p.sizes.Store(keyHex, size)
+	if compressed {
+		p.addSrcsets(&size, src)
+	}
+	return size, true
+}
+
+// addSrcsets lists the copies CopyDirVFS publishes of a compressed image
+// when images are responsive
+func (p *imageProviderImpl) addSrcsets(size *mdParser.ImageSize, src string) {
+	opts := imageOptions(p.cfg)
+	widths := utils.VariantWidths(size.Width, opts.Widths)
+	if len(widths) == 0 {
+		return
+	}
+	published, _ := publishedImage(path.Base(src), true)
+	published = path.Join(path.Dir(src), published)
+	srcset := func(format string) string {
+		entries := make([]string, 0, len(widths)+1)
+		for _, w := range widths {
+			entries = append(entries, fmt.Sprintf("%s/%s %dw", p.cfg.BaseURL, utils.ImageVariant(published, w, format), w))
+		}
+		entries = append(entries, fmt.Sprintf("%s/%s %dw", p.cfg.BaseURL, utils.ImageVariant(published, 0, format), size.Width))
+		return strings.Join(entries, ", ")
+	}
+	size.Srcset = srcset("webp")
+	if opts.FFmpeg != "" {
+		size.AVIF = srcset("avif")
+	}
+	size.Sizes = p.cfg.Images.Sizes
+	if size.Sizes == "" {
+		size.Sizes = fmt.Sprintf("(max-width: %dpx) 100vw, %dpx", size.Width, size.Width)
+	}
+}
+
+// ffmpegPath finds ffmpeg on the PATH once; "" when it isn't installed
+var ffmpegPath = sync.OnceValue(func() string {
+	p, _ := exec.LookPath("ffmpeg")
+	return p
+})
+
+// imageOptions is how the static directories publish images: converted to
+// WebP when compressImages is on and, with images.widths, also at smaller
+// widths and, with "avif" among images.formats and ffmpeg installed, as AVIF
+func imageOptions(cfg *config.Config) utils.ImageOptions {
+	opts := utils.ImageOptions{Compress: cfg.CompressImages, Quality: cfg.Images.Quality}
+	if !cfg.ResponsiveImages() {
+		return opts
+	}
+	opts.Widths = cfg.Images.Widths
+	if slices.Contains(cfg.Images.Formats, "avif") {
+		opts.FFmpeg = ffmpegPath()
+	}
+	return opts
+}
+
+// measure reads the dimensions from the image header, scaled like CopyDirVFS
+// scales images it converts to WebP
+func (p *imageProviderImpl) measure(src string, compressed bool) (mdParser.ImageSize, error) {
+	f, err := p.sourceFs.Open(src)
+	if err != nil {
+		return mdParser.ImageSize{}, err
+	}
+	defer func() { _ = f.Close() }()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return mdParser.ImageSize{}, err
+	}
+	size := mdParser.ImageSize{Width: cfg.Width, Height: cfg.Height}
+	if compressed && size.Width > maxCompressedWidth {
+		size.Height = scaledHeight(size.Width, size.Height, maxCompressedWidth)
+		size.Width = maxCompressedWidth
+	}
+	return size, nil
+}
+
+// staticSource maps an image destination ("/static/img/a.png", "static/img/a.png"
+// or a full URL of the site) to its path in the source tree
+func (p *imageProviderImpl) staticSource(dest string) (string, bool) {
+	if base := strings.TrimSuffix(p.cfg.BaseURL, "/"); base != "" && strings.HasPrefix(dest, base+"/") {
+		dest = strings.TrimPrefix(dest, base)
+	}
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", false
+	}
+	return staticPath(strings.TrimPrefix(u.Path, "/"))
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+)
+
+func writePNG(t *testing.T, fs afero.Fs, path string, w, h int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImageProvider(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writePNG(t, fs, "static/images/small.png", 300, 200)
+	writePNG(t, fs, "static/images/wide.png", 2400, 1000)
+	cacheDir := t.TempDir()
+	cfg := &config.Config{BaseURL: "https://example.com", CacheDir: cacheDir, CompressImages: true}
+	provider := NewImageProvider(cfg, fs, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		src  string
+		want mdParser.ImageSize
+		ok   bool
+	}{
+		{"/static/images/small.png", mdParser.ImageSize{Width: 300, Height: 200}, true},
+		{"static/images/small.png?v=2", mdParser.ImageSize{Width: 300, Height: 200}, true},
+		{"https://example.com/static/images/small.png", mdParser.ImageSize{Width: 300, Height: 200}, true},
+		{"/static/images/wide.png", mdParser.ImageSize{Width: 1200, Height: 500}, true}, // Scaled like its WebP
+		{"/static/images/missing.png", mdParser.ImageSize{}, false},
+		{"https://cdn.example.org/static/images/small.png", mdParser.ImageSize{}, false},
+		{"/content/small.png", mdParser.ImageSize{}, false},
+		{"/static/../content/small.png", mdParser.ImageSize{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := provider.ImageSize(tt.src)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ImageSize(%q) = %+v, %v; want %+v, %v", tt.src, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// Responsive images list the copies published next to the WebP
+	cfg.Images = config.ImagesConfig{Widths: []int{480, 1600}}
+	got, _ := provider.ImageSize("/static/images/wide.png")
+	if want := "https://example.com/static/images/wide-480w.webp 480w, https://example.com/static/images/wide.webp 1200w"; got.Srcset != want {
+		t.Errorf("Srcset = %q, want %q", got.Srcset, want)
+	}
+	if got.Sizes != "(max-width: 1200px) 100vw, 1200px" || got.AVIF != "" {
+		t.Errorf("Sizes = %q, AVIF = %q", got.Sizes, got.AVIF)
+	}
+	if got, _ := provider.ImageSize("/static/images/small.png"); got.Srcset != "" {
+		t.Errorf("an image narrower than every width got srcset %q", got.Srcset)
+	}
+
+	// Sizes survive in the cache directory for the next build
+	entries, err := os.ReadDir(filepath.Join(cacheDir, "imagesize"))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("cached sizes = %v, %v; want 2 files", entries, err)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"github.com/Kush-Singh-26/kosh/builder/models"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/renderer"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
 // PostResult contains the aggregated results of post processing
@@ -14,6 +18,45 @@ type PostResult struct {
 	IndexedPosts   []models.IndexedPost
 	AnyPostChanged bool
 	Has404         bool
+	// SearchSpool holds the record contents of IndexedPosts in low-memory
+	// mode (nil otherwise). The caller closes it once the index is written.
+	SearchSpool *search.ContentSpool
+	// Languages holds the listings of every language but the default one,
+	// whose posts are the fields above, by language code
+	Languages map[string]*LanguagePosts
+	// VersionPosts holds the posts of every documentation version but the
+	// latest, whose posts are in AllPosts and PinnedPosts, by version name
+	VersionPosts map[string][]models.PostMetadata
+}
+
+// AddVersionPost lists a post of an older version
+func (r *PostResult) AddVersionPost(p models.PostMetadata) {
+	if r.VersionPosts == nil {
+		r.VersionPosts = make(map[string][]models.PostMetadata)
+	}
+	r.VersionPosts[p.Version] = append(r.VersionPosts[p.Version], p)
+}
+
+// LanguagePosts are the listings of one language of a multilingual site:
+// its home page, tags and search index. Records are never spooled.
+type LanguagePosts struct {
+	AllPosts     []models.PostMetadata
+	PinnedPosts  []models.PostMetadata
+	TagMap       map[string][]models.PostMetadata
+	IndexedPosts []models.IndexedPost
+}
+
+// Language returns the listings of a language, adding them if needed
+func (r *PostResult) Language(code string) *LanguagePosts {
+	if r.Languages == nil {
+		r.Languages = make(map[string]*LanguagePosts)
+	}
+	lp, ok := r.Languages[code]
+	if !ok {
+		lp = &LanguagePosts{TagMap: make(map[string][]models.PostMetadata)}
+		r.Languages[code] = lp
+	}
+	return lp
 }
 
 // PostService defines operations for processing markdown posts
@@ -30,6 +73,8 @@ type CacheService interface {
 	GetPostByPath(path string) (*cache.PostMeta, error)
 	GetPostsByIDs(ids []string) (map[string]*cache.PostMeta, error)
 	GetPostsByTemplate(templatePath string) ([]string, error)
+	GetPostsByInclude(name string) ([]string, error)
+	GetPostsByFile(path string) ([]string, error)
 	GetSearchRecords(ids []string) (map[string]*cache.SearchRecord, error)
 	GetSearchRecord(id string) (*cache.SearchRecord, error)
 	GetHTMLContent(post *cache.PostMeta) ([]byte, error)
@@ -39,6 +84,13 @@ type CacheService interface {
 	SetGraphHash(hash string) error
 	GetWasmHash() (string, error)
 	SetWasmHash(hash string) error
+	GetPluginsHash() (string, error)
+	SetPluginsHash(hash string) error
+	GetTemplateMetas() (map[string]*cache.TemplateMeta, error)
+	SetTemplateMetas(metas map[string]*cache.TemplateMeta) error
+	GetStaticFiles() (map[string]utils.StaticFile, error)
+	SetStaticFiles(files map[string]utils.StaticFile) error
+	UpdateOutputFiles(files map[string]utils.OutputFile) error
 	GetPostsMetadataByVersion(version string) ([]cache.PostListMeta, error)
 
 	// Write operations
@@ -51,6 +103,11 @@ type CacheService interface {
 	MarkDirty(postID string)
 	IsDirty(postID string) bool
 
+	// Pages committed before their output was synced (see cache.MarkPending)
+	MarkPending(paths []string) error
+	PendingPaths() (map[string]bool, error)
+	ClearPending() error
+
 	// Lifecycle
 	Stats() (*cache.CacheStats, error)
 	IncrementBuildCount() error
@@ -60,6 +117,12 @@ type CacheService interface {
 // AssetService handles static asset processing
 type AssetService interface {
 	Build(ctx context.Context) error
+	// BuildBundles rebuilds only the CSS, Sass and JS bundles and reports
+	// whether their published paths changed
+	BuildBundles(ctx context.Context) (bool, error)
+	// Media maps the URL paths of static images to their content-hash
+	// paths when images.dedupe is on, after Build; nil otherwise
+	Media() map[string]string
 }
 
 // RenderService handles rendering logic
@@ -73,4 +136,10 @@ type RenderService interface {
 	GetAssets() map[string]string
 	GetRenderedFiles() map[string]bool
 	ClearRenderedFiles()
+	CompileTemplates() (bool, error)
+	Templates() (string, map[string]*cache.TemplateMeta)
+	TemplateDeps(file string) []string
+	TemplateUsers(partial string) []string
+	Shortcode(name string, data mdParser.ShortcodeData) (string, bool, error)
+	TakeTemplateErrors() []renderer.TemplateError
 }
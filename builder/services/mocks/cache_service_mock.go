@@ -2,6 +2,8 @@
 package mocks
 
 import (
+	"maps"
+
 	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
@@ -16,11 +18,19 @@ type MockCacheService struct {
 	SocialCardHashes   map[string]string
 	GraphHash          string
 	WasmHash           string
+	PluginsHash        string
+	TemplateMetas      map[string]*cache.TemplateMeta
+	PostsByTemplate    map[string][]string // template path -> PostIDs
+	PostsByInclude     map[string][]string // include name -> PostIDs
+	PostsByFile        map[string][]string // data file path -> PostIDs
+	StaticFiles        map[string]utils.StaticFile
+	OutputFiles        map[string]utils.OutputFile
 	Err                error
 	CallCount          map[string]int
 	BatchCommitPosts   []*cache.PostMeta
 	BatchCommitRecords map[string]*cache.SearchRecord
 	BatchCommitDeps    map[string]*cache.Dependencies
+	Pending            map[string]bool
 }
 
 // NewMockCacheService creates a new mock cache service
@@ -35,6 +45,7 @@ func NewMockCacheService() *MockCacheService {
 		CallCount:          make(map[string]int),
 		BatchCommitRecords: make(map[string]*cache.SearchRecord),
 		BatchCommitDeps:    make(map[string]*cache.Dependencies),
+		Pending:            make(map[string]bool),
 	}
 }
 
@@ -97,6 +108,33 @@ func (m *MockCacheService) GetPostsByTemplate(templatePath string) ([]string, er
 	if m.Err != nil {
 		return nil, m.Err
 	}
+	if ids, ok := m.PostsByTemplate[templatePath]; ok {
+		return ids, nil
+	}
+	return []string{}, nil
+}
+
+// GetPostsByInclude returns posts including a fragment
+func (m *MockCacheService) GetPostsByInclude(name string) ([]string, error) {
+	m.recordCall("GetPostsByInclude")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if ids, ok := m.PostsByInclude[name]; ok {
+		return ids, nil
+	}
+	return []string{}, nil
+}
+
+// GetPostsByFile returns posts built from a data file
+func (m *MockCacheService) GetPostsByFile(path string) ([]string, error) {
+	m.recordCall("GetPostsByFile")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if ids, ok := m.PostsByFile[path]; ok {
+		return ids, nil
+	}
 	return []string{}, nil
 }
 
@@ -196,6 +234,76 @@ func (m *MockCacheService) SetWasmHash(hash string) error {
 	return nil
 }
 
+// GetPluginsHash returns the plugins fingerprint
+func (m *MockCacheService) GetPluginsHash() (string, error) {
+	m.recordCall("GetPluginsHash")
+	if m.Err != nil {
+		return "", m.Err
+	}
+	return m.PluginsHash, nil
+}
+
+// SetPluginsHash sets the plugins fingerprint
+func (m *MockCacheService) SetPluginsHash(hash string) error {
+	m.recordCall("SetPluginsHash")
+	if m.Err != nil {
+		return m.Err
+	}
+	m.PluginsHash = hash
+	return nil
+}
+
+// GetTemplateMetas returns the recorded template files
+func (m *MockCacheService) GetTemplateMetas() (map[string]*cache.TemplateMeta, error) {
+	m.recordCall("GetTemplateMetas")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.TemplateMetas, nil
+}
+
+// SetTemplateMetas replaces the recorded template files
+func (m *MockCacheService) SetTemplateMetas(metas map[string]*cache.TemplateMeta) error {
+	m.recordCall("SetTemplateMetas")
+	if m.Err != nil {
+		return m.Err
+	}
+	m.TemplateMetas = metas
+	return nil
+}
+
+// GetStaticFiles returns the recorded static files
+func (m *MockCacheService) GetStaticFiles() (map[string]utils.StaticFile, error) {
+	m.recordCall("GetStaticFiles")
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.StaticFiles, nil
+}
+
+// SetStaticFiles replaces the recorded static files
+func (m *MockCacheService) SetStaticFiles(files map[string]utils.StaticFile) error {
+	m.recordCall("SetStaticFiles")
+	if m.Err != nil {
+		return m.Err
+	}
+	m.StaticFiles = files
+	return nil
+}
+
+// UpdateOutputFiles records the synced output files
+func (m *MockCacheService) UpdateOutputFiles(files map[string]utils.OutputFile) error {
+	m.recordCall("UpdateOutputFiles")
+	if m.Err != nil {
+		return m.Err
+	}
+	if m.OutputFiles == nil {
+		m.OutputFiles = make(map[string]utils.OutputFile)
+	}
+	maps.Copy(m.OutputFiles, files)
+	return nil
+}
+
 // StoreHTML stores HTML and returns its hash
 func (m *MockCacheService) StoreHTML(content []byte) (string, error) {
 	m.recordCall("StoreHTML")
@@ -264,6 +372,35 @@ func (m *MockCacheService) IsDirty(postID string) bool {
 	return m.Dirty[postID]
 }
 
+// MarkPending records paths whose output isn't synced yet
+func (m *MockCacheService) MarkPending(paths []string) error {
+	m.recordCall("MarkPending")
+	if m.Err != nil {
+		return m.Err
+	}
+	for _, p := range paths {
+		m.Pending[p] = true
+	}
+	return nil
+}
+
+// PendingPaths returns the pending paths
+func (m *MockCacheService) PendingPaths() (map[string]bool, error) {
+	m.recordCall("PendingPaths")
+	pending := make(map[string]bool, len(m.Pending))
+	for p := range m.Pending {
+		pending[p] = true
+	}
+	return pending, m.Err
+}
+
+// ClearPending forgets the pending paths
+func (m *MockCacheService) ClearPending() error {
+	m.recordCall("ClearPending")
+	m.Pending = make(map[string]bool)
+	return m.Err
+}
+
 // Stats returns cache statistics
 func (m *MockCacheService) Stats() (*cache.CacheStats, error) {
 	m.recordCall("Stats")
@@ -295,11 +432,13 @@ func (m *MockCacheService) GetPostsMetadataByVersion(version string) ([]cache.Po
 	for _, post := range m.Posts {
 		if post.Version == version {
 			result = append(result, cache.PostListMeta{
-				Title:   post.Title,
-				Link:    post.Link,
-				Weight:  post.Weight,
-				Version: post.Version,
-				Date:    post.Date,
+				Title:       post.Title,
+				Link:        post.Link,
+				Weight:      post.Weight,
+				Version:     post.Version,
+				Date:        post.Date,
+				PublishDate: post.PublishDate,
+				ExpiryDate:  post.ExpiryDate,
 			})
 		}
 	}
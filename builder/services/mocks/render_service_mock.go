@@ -1,7 +1,10 @@
 package mocks
 
 import (
+	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"github.com/Kush-Singh-26/kosh/builder/models"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/renderer"
 )
 
 // MockRenderService is a mock implementation of services.RenderService
@@ -12,6 +15,11 @@ type MockRenderService struct {
 	RenderedGraph   map[string]models.PageData
 	RegisteredFiles map[string]bool
 	Assets          map[string]string
+	TemplateHash    string
+	TemplateMetas   map[string]*cache.TemplateMeta
+	TemplateDepsMap map[string][]string // template file -> partials it includes
+	Shortcodes      map[string]string   // shortcode name -> rendered output
+	TemplateErrors  []renderer.TemplateError
 	CallCount       map[string]int
 }
 
@@ -88,3 +96,50 @@ func (m *MockRenderService) ClearRenderedFiles() {
 	m.recordCall("ClearRenderedFiles")
 	m.RegisteredFiles = make(map[string]bool)
 }
+
+// CompileTemplates records the call; the mock has no templates to parse
+func (m *MockRenderService) CompileTemplates() (bool, error) {
+	m.recordCall("CompileTemplates")
+	return false, nil
+}
+
+// Templates returns the configured template set
+func (m *MockRenderService) Templates() (string, map[string]*cache.TemplateMeta) {
+	m.recordCall("Templates")
+	return m.TemplateHash, m.TemplateMetas
+}
+
+// TemplateDeps returns the configured partials of a template file
+func (m *MockRenderService) TemplateDeps(file string) []string {
+	m.recordCall("TemplateDeps")
+	return m.TemplateDepsMap[file]
+}
+
+// TemplateUsers returns the templates whose configured deps include partial
+func (m *MockRenderService) TemplateUsers(partial string) []string {
+	m.recordCall("TemplateUsers")
+	var users []string
+	for file, deps := range m.TemplateDepsMap {
+		for _, dep := range deps {
+			if dep == partial {
+				users = append(users, file)
+			}
+		}
+	}
+	return users
+}
+
+// Shortcode renders the configured output of a shortcode
+func (m *MockRenderService) Shortcode(name string, data mdParser.ShortcodeData) (string, bool, error) {
+	m.recordCall("Shortcode")
+	out, ok := m.Shortcodes[name]
+	return out, ok, nil
+}
+
+// TakeTemplateErrors returns and clears the configured template errors
+func (m *MockRenderService) TakeTemplateErrors() []renderer.TemplateError {
+	m.recordCall("TakeTemplateErrors")
+	errs := m.TemplateErrors
+	m.TemplateErrors = nil
+	return errs
+}
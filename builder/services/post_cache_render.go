@@ -11,6 +11,8 @@ import (
 	"github.com/spf13/afero"
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/metrics"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
@@ -39,7 +41,8 @@ func (s *postServiceImpl) RenderCachedPosts() {
 	}
 
 	cachedData := make(map[string]*CachedPostData, len(ids))
-	postsByVersion := make(map[string][]models.PostMetadata)
+	postsByGroup := make(map[postGroup][]models.PostMetadata)
+	postsByPath := make(map[string]models.PostMetadata, len(ids)) // For `related:`
 
 	cachedPostsMap, err := s.cache.GetPostsByIDs(ids)
 	if err != nil {
@@ -48,6 +51,9 @@ func (s *postServiceImpl) RenderCachedPosts() {
 	}
 
 	for id, meta := range cachedPostsMap {
+		if (meta.Draft && !s.cfg.IncludeDrafts) || !s.cfg.InAudience(pageAudiences(meta.Meta)) || !s.cfg.IsScheduled(meta.PublishDate, meta.ExpiryDate) {
+			continue
+		}
 		htmlBytes, _ := s.cache.GetHTMLContent(meta)
 		if htmlBytes == nil {
 			continue
@@ -55,7 +61,7 @@ func (s *postServiceImpl) RenderCachedPosts() {
 		cachedData[id] = &CachedPostData{Meta: meta, HTML: htmlBytes}
 
 		// Regenerate Link from current baseURL
-		htmlRelPath := strings.ToLower(strings.Replace(meta.Path, ".md", ".html", 1))
+		htmlRelPath := s.cfg.HTMLPath(meta.Path)
 		cleanHtmlRelPath := htmlRelPath
 		if meta.Version != "" {
 			cleanHtmlRelPath = strings.TrimPrefix(htmlRelPath, strings.ToLower(meta.Version)+"/")
@@ -64,30 +70,37 @@ func (s *postServiceImpl) RenderCachedPosts() {
 
 		post := models.PostMetadata{
 			Title: meta.Title, Link: regeneratedLink, Weight: meta.Weight, Version: meta.Version,
-			DateObj: meta.Date,
+			Lang: s.cfg.LanguageOf(meta.Path), DateObj: meta.Date,
 		}
-		postsByVersion[meta.Version] = append(postsByVersion[meta.Version], post)
+		group := postGroup{lang: post.Lang, version: meta.Version}
+		postsByGroup[group] = append(postsByGroup[group], post)
+
+		post.Description, post.Tags, post.ReadingTime = meta.Description, meta.Tags, meta.ReadingTime
+		postsByPath[filepath.ToSlash(meta.Path)] = post
 	}
 
-	siteTrees := make(map[string][]*models.TreeNode)
-	for ver, posts := range postsByVersion {
+	siteTrees := make(map[postGroup][]*models.TreeNode)
+	for group, posts := range postsByGroup {
 		utils.SortPosts(posts)
-		siteTrees[ver] = utils.BuildSiteTree(posts, "")
+		siteTrees[group] = utils.BuildSiteTree(posts, "")
 	}
 
 	numWorkers := runtime.NumCPU()
 	sem := make(chan struct{}, numWorkers)
 	var wg sync.WaitGroup
 
+	s.metrics.AddWork(metrics.StageRender, len(cachedData))
 	for id, data := range cachedData {
 		wg.Add(1)
 		sem <- struct{}{}
 		go func(postID string, cp *CachedPostData) {
 			defer wg.Done()
 			defer func() { <-sem }()
+			defer s.metrics.WorkDone(metrics.StageRender)
 
 			relPath := cp.Meta.Path
-			htmlRelPath := strings.ToLower(strings.Replace(relPath, ".md", ".html", 1))
+			htmlRelPath := s.cfg.HTMLPath(relPath)
+			group := postGroup{lang: s.cfg.LanguageOf(relPath), version: cp.Meta.Version}
 
 			cleanHtmlRelPath := htmlRelPath
 			if cp.Meta.Version != "" {
@@ -104,7 +117,7 @@ func (s *postServiceImpl) RenderCachedPosts() {
 				destPath = filepath.Join(s.cfg.OutputDir, htmlRelPath)
 			}
 
-			if s.cfg.Features.RawMarkdown {
+			if s.cfg.Features.RawMarkdown && pagePassword(cp.Meta.Meta) == "" {
 				mdDestPath := destPath[:len(destPath)-len(filepath.Ext(destPath))] + ".md"
 				if _, err := os.Stat(mdDestPath); os.IsNotExist(err) {
 					sourcePath := filepath.Join(s.cfg.ContentDir, relPath)
@@ -132,28 +145,33 @@ func (s *postServiceImpl) RenderCachedPosts() {
 				toc = append(toc, models.TOCEntry{ID: t.ID, Text: t.Text, Level: t.Level})
 			}
 
-			versionPosts := postsByVersion[cp.Meta.Version]
+			versionPosts := postsByGroup[group]
 			currentPost := models.PostMetadata{
 				Title: cp.Meta.Title, Link: regeneratedLink, Weight: cp.Meta.Weight, Version: cp.Meta.Version,
-				DateObj: cp.Meta.Date,
+				Lang: group.lang, DateObj: cp.Meta.Date,
 			}
 			prev, next := utils.FindPrevNext(currentPost, versionPosts)
 
-			s.renderer.RenderPage(destPath, models.PageData{
+			s.renderer.RenderPage(destPath, s.protectPage(s.withPostExtras(models.PageData{
 				Title: cp.Meta.Title, Description: cp.Meta.Description, Content: template.HTML(string(cp.HTML)),
 				Meta: cp.Meta.Meta, BaseURL: s.cfg.BaseURL, BuildVersion: s.cfg.BuildVersion,
-				TabTitle: cp.Meta.Title + " | " + s.cfg.Title, Permalink: regeneratedLink, Image: imagePath,
-				TOC: toc, Config: s.cfg,
-				SiteTree:       siteTrees[cp.Meta.Version],
+				TabTitle: cp.Meta.Title + " | " + s.cfg.LanguageTitle(group.lang), Permalink: regeneratedLink, Image: imagePath,
+				TOC: toc, Config: s.cfg, SourcePath: cp.Meta.Path,
+				SiteTree:       siteTrees[group],
 				CurrentVersion: cp.Meta.Version,
 				IsOutdated:     s.isOutdatedVersion(cp.Meta.Version),
-				Versions:       s.cfg.GetVersionsMetadata(cp.Meta.Version, cleanHtmlRelPath),
+				Versions:       s.versionLinks(relPath),
 				PrevPage:       prev,
 				NextPage:       next,
-			})
+				Related: s.relatedPosts(relPath, cp.Meta.Meta, func(rel string) (models.PostMetadata, bool) {
+					p, ok := postsByPath[rel]
+					return p, ok
+				}),
+			})))
 
 			s.metrics.IncrementPostsProcessed()
 			s.metrics.IncrementCacheHit()
+			s.events.Publish(events.CacheHit{Path: relPath})
 		}(id, data)
 	}
 	wg.Wait()
@@ -1,5 +1,27 @@
 package services
 
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/openapi"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/renderer"
+	"github.com/Kush-Singh-26/kosh/builder/search"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
 const wordsPerMinute = 120.0
 
 type socialCardTask struct {
@@ -8,6 +30,106 @@ type socialCardTask struct {
 	frontmatterHash             string
 }
 
+// renderJob is a post waiting for the site-wide context (sidebar tree,
+// prev/next) before it can be rendered. The body isn't held while waiting:
+// it is reloaded from its cache entry when the job runs, so pending jobs cost
+// metadata rather than HTML. Only builds without a cache keep body inline.
+type renderJob struct {
+	source   string // Content path relative to the content dir, for page timings
+	destPath string
+	version  string
+	lang     string
+	data     models.PageData
+	bodyMeta *cache.PostMeta
+	body     string
+	preview  bool // Draft preview: no prev/next, not part of any listing
+}
+
+func (j renderJob) loadBody(c CacheService) (string, error) {
+	if j.bodyMeta == nil || c == nil {
+		return j.body, nil
+	}
+	body, err := c.GetHTMLContent(j.bodyMeta)
+	return string(body), err
+}
+
+// parsedPost is what a parse worker hands to the collector in Process
+type parsedPost struct {
+	indexed models.IndexedPost
+	render  *renderJob
+	meta    *cache.PostMeta     // New cache entry, committed by a checkpoint
+	search  *cache.SearchRecord // Search data for meta
+	lang    string
+	// Shortcode templates the page uses, recorded with meta's template deps
+	shortcodes []string
+	includes   []string // Fragments the page includes, recorded with meta's deps
+	files      []string // Data files the page is built from, recorded with meta's deps
+}
+
+// postGroup is a set of posts sharing a sidebar and prev/next: one version
+// of a versioned site, one language of a multilingual one
+type postGroup struct {
+	lang, version string
+}
+
+// checkpointEvery is how many parsed posts Process collects before
+// committing them to the cache
+const checkpointEvery = 256
+
+// checkpoint batches the new cache entries of a build. Committing while the
+// build runs, rather than once at the end, means an interrupted or crashed
+// cold build keeps the pages it already parsed: the next build renders them
+// from the cache. Committed paths are marked pending until the output sync,
+// so a stale page left on disk isn't mistaken for their output.
+type checkpoint struct {
+	cache   CacheService
+	every   int
+	posts   []*cache.PostMeta
+	records map[string]*cache.SearchRecord
+	deps    map[string]*cache.Dependencies
+}
+
+func newCheckpoint(c CacheService, every int) *checkpoint {
+	return &checkpoint{
+		cache:   c,
+		every:   every,
+		records: make(map[string]*cache.SearchRecord),
+		deps:    make(map[string]*cache.Dependencies),
+	}
+}
+
+// add queues a post and commits the batch once it is full
+func (c *checkpoint) add(meta *cache.PostMeta, record *cache.SearchRecord, deps *cache.Dependencies) error {
+	c.posts = append(c.posts, meta)
+	c.records[meta.PostID] = record
+	c.deps[meta.PostID] = deps
+	if len(c.posts) < c.every {
+		return nil
+	}
+	return c.flush()
+}
+
+// flush commits the queued posts
+func (c *checkpoint) flush() error {
+	if c.cache == nil || len(c.posts) == 0 {
+		return nil
+	}
+	paths := make([]string, len(c.posts))
+	for i, p := range c.posts {
+		paths[i] = p.Path
+	}
+	if err := c.cache.MarkPending(paths); err != nil {
+		return err
+	}
+	if err := c.cache.BatchCommit(c.posts, c.records, c.deps); err != nil {
+		return err
+	}
+	c.posts = nil
+	clear(c.records)
+	clear(c.deps)
+	return nil
+}
+
 func (s *postServiceImpl) isOutdatedVersion(version string) bool {
 	if version == "" {
 		return false
@@ -19,3 +141,440 @@ func (s *postServiceImpl) isOutdatedVersion(version string) bool {
 	}
 	return true
 }
+
+// withPostExtras fills in the per-post fields that depend on site config and
+// frontmatter: the layout, the comment widget and fediverse attribution.
+// Setting `comments: false` in frontmatter turns comments off for that page.
+func (s *postServiceImpl) withPostExtras(data models.PageData) models.PageData {
+	data.Layout = s.pageLayout(data.Meta, data.SourcePath)
+	if enabled, ok := data.Meta["comments"].(bool); !ok || enabled {
+		data.Comments, data.CommentsCSP = generators.CommentsEmbed(s.cfg.Comments, data.Permalink)
+	}
+
+	fedi := s.cfg.Fediverse
+	data.FediverseCreator = fedi.Creator
+	if creator := utils.GetString(data.Meta, "fediverseCreator"); creator != "" {
+		data.FediverseCreator = creator
+	}
+	if toot := utils.GetString(data.Meta, "mastodon"); toot != "" {
+		data.DiscussURL = toot
+	} else if fedi.DiscussLinks {
+		if account, err := generators.ParseFediverseHandle(fedi.Creator); err == nil {
+			data.DiscussURL = account.DiscussURL(data.Permalink)
+		}
+	}
+	if len(s.cfg.Languages) > 0 {
+		data.Lang = s.cfg.LanguageOf(data.SourcePath)
+		data.LangURL = s.cfg.LanguageURL(data.Lang)
+		data.Languages = s.languageLinks(data.SourcePath)
+	}
+	return data
+}
+
+// postTemplate is the page template posts without a layout are rendered with
+const postTemplate = "layout.html"
+
+// pageLayout is the named layout a post is rendered with: its layout or type
+// frontmatter, else the default of the deepest section in the layouts config
+// containing it. "" means layout.html.
+func (s *postServiceImpl) pageLayout(meta map[string]interface{}, relPath string) string {
+	for _, key := range []string{"layout", "type"} {
+		if name := strings.TrimSuffix(utils.GetString(meta, key), ".html"); name != "" {
+			return name
+		}
+	}
+	dir := path.Dir(filepath.ToSlash(relPath))
+	for dir != "." && dir != "/" && dir != "" {
+		if name, ok := s.cfg.Layouts[dir]; ok {
+			return strings.TrimSuffix(name, ".html")
+		}
+		dir = path.Dir(dir)
+	}
+	return ""
+}
+
+// postTemplateDeps lists the template files a post page depends on: the
+// template it is rendered with and every partial that includes. Recorded per
+// post in the cache so GetPostsByTemplate can find the pages affected by a
+// template edit. A layout the theme lacks is recorded next to layout.html,
+// so adding it re-renders the posts asking for it.
+func postTemplateDeps(r RenderService, layout string) []string {
+	if layout == "" {
+		return append([]string{postTemplate}, r.TemplateDeps(postTemplate)...)
+	}
+	file := renderer.LayoutFile(layout)
+	if _, info := r.Templates(); info[file] != nil {
+		return append([]string{file}, r.TemplateDeps(file)...)
+	}
+	return append([]string{postTemplate, file}, r.TemplateDeps(postTemplate)...)
+}
+
+// shortcodeTemplateDeps lists the shortcode templates a page uses and the
+// partials they include. Templates the theme lacks are listed too, so adding
+// one re-renders the pages that asked for it.
+func shortcodeTemplateDeps(r RenderService, source []byte) []string {
+	var deps []string
+	for _, name := range mdParser.ShortcodeNames(source) {
+		file := renderer.ShortcodeFile(name)
+		deps = append(append(deps, file), r.TemplateDeps(file)...)
+	}
+	return deps
+}
+
+// searchTerms tokenizes a search record (stemming, stop word removal) and
+// counts the terms of two letters or more for BM25
+func searchTerms(rec models.PostRecord) ([]string, map[string]int) {
+	var sb strings.Builder
+	sb.Grow(len(rec.Title) + len(rec.Description) + len(rec.Content) + 200)
+	sb.WriteString(rec.Title)
+	sb.WriteByte(' ')
+	sb.WriteString(rec.Description)
+	sb.WriteByte(' ')
+	for _, t := range rec.Tags {
+		sb.WriteString(t)
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(rec.Content)
+
+	words := search.DefaultAnalyzer.Analyze(sb.String())
+	freqs := make(map[string]int)
+	for _, w := range words {
+		if len(w) >= 2 {
+			freqs[w]++
+		}
+	}
+	return words, freqs
+}
+
+// pagePassword returns the `password:` frontmatter of a page, "" when the page
+// is public. YAML reads `password: 1234` as a number, so scalars are accepted.
+func pagePassword(meta map[string]interface{}) string {
+	switch v := meta["password"].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// pageAudiences returns the `audience:` frontmatter of a page, which may be a
+// single name or a list
+func pageAudiences(meta map[string]interface{}) []string {
+	return stringList(meta, "audience")
+}
+
+// stringList reads frontmatter that may be a single string or a list
+func stringList(meta map[string]interface{}, key string) []string {
+	if v, ok := meta[key].(string); ok {
+		return []string{v}
+	}
+	return utils.GetSlice(meta, key)
+}
+
+// protectPage replaces the body of a password-protected page with its
+// encrypted form. The TOC would give the headings away and the password must
+// not reach templates, so both are dropped.
+func (s *postServiceImpl) protectPage(data models.PageData) models.PageData {
+	password := pagePassword(data.Meta)
+	if password == "" {
+		return data
+	}
+	body, err := generators.ProtectContent(string(data.Content), password)
+	if err != nil {
+		// Never fall back to publishing the plaintext
+		s.logger.Error("Failed to encrypt protected page", "path", data.SourcePath, "error", err)
+		body = ""
+	}
+	data.Content = template.HTML(body)
+	data.TOC = nil
+
+	meta := make(map[string]interface{}, len(data.Meta))
+	for k, v := range data.Meta {
+		if k != "password" {
+			meta[k] = v
+		}
+	}
+	meta["protected"] = true
+	data.Meta = meta
+	return data
+}
+
+// pageAudio resolves a post's `audio:` frontmatter for its feed entry: the
+// file size for the enclosure and, when there are chapters, the JSON chapters
+// URL next to the page (written by generators.GenerateChapters)
+func (s *postServiceImpl) pageAudio(meta map[string]interface{}, link string) *models.Audio {
+	a, err := mdParser.PageAudio(meta, s.cfg.BaseURL)
+	if err != nil || a == nil {
+		return nil // The shortcode reports the error on the page
+	}
+	if info, err := s.sourceFs.Stat(a.Src); err == nil {
+		a.Length = info.Size()
+	}
+	if len(a.Chapters) > 0 {
+		a.ChaptersURL = strings.TrimSuffix(link, ".html") + ".chapters.json"
+	}
+	return a
+}
+
+// contentLink is the permalink of the page built from a content path
+func (s *postServiceImpl) contentLink(relPath string) string {
+	version, _ := utils.GetVersionFromPath(filepath.Join(s.cfg.ContentDir, relPath))
+	htmlRelPath := s.cfg.HTMLPath(relPath)
+	if version != "" {
+		htmlRelPath = strings.TrimPrefix(htmlRelPath, strings.ToLower(version)+"/")
+	}
+	return utils.BuildURL(s.cfg.BaseURL, version, htmlRelPath)
+}
+
+// versionLinks lists the configured versions for a page's version selector:
+// each links to the page in that version, or to the version's home page when
+// the page doesn't exist there
+func (s *postServiceImpl) versionLinks(relPath string) []models.VersionInfo {
+	version, pagePath := utils.GetVersionFromPath("content/" + filepath.ToSlash(relPath))
+	versions := s.cfg.GetVersionsMetadata(version, strings.ToLower(strings.Replace(pagePath, ".md", ".html", 1)))
+	for i, v := range versions {
+		versions[i].HasPage, _ = afero.Exists(s.sourceFs, filepath.Join(s.cfg.ContentDir, v.Path, filepath.FromSlash(pagePath)))
+		if !versions[i].HasPage {
+			versions[i].URL = utils.BuildURL(s.cfg.BaseURL, v.Path, "")
+		}
+	}
+	return versions
+}
+
+// languageLinks lists the configured languages for a page's language
+// switcher: each links to the page in that language's folder, or to the
+// language's home page when it isn't translated
+func (s *postServiceImpl) languageLinks(relPath string) []models.LanguageInfo {
+	relPath = filepath.ToSlash(relPath)
+	lang := s.cfg.LanguageOf(relPath)
+	pagePath := relPath
+	if first, rest, ok := strings.Cut(relPath, "/"); ok && first == lang {
+		pagePath = rest
+	}
+	languages := s.cfg.GetLanguagesMetadata(lang, pagePath)
+	for i, l := range languages {
+		var candidates []string
+		if l.Code != lang {
+			candidates = append(candidates, filepath.Join(s.cfg.ContentDir, l.Code, filepath.FromSlash(pagePath)))
+			if l.Code == s.cfg.DefaultLanguage() {
+				candidates = append(candidates, filepath.Join(s.cfg.ContentDir, filepath.FromSlash(pagePath)))
+			}
+		}
+		languages[i].HasPage = l.Code == lang
+		for _, c := range candidates {
+			if ok, _ := afero.Exists(s.sourceFs, c); ok {
+				languages[i].HasPage = true
+			}
+		}
+		if !languages[i].HasPage {
+			languages[i].URL = s.cfg.LanguageURL(l.Code) + "/"
+		}
+	}
+	return languages
+}
+
+// relatedPosts resolves a page's `related:` frontmatter, a list of content
+// paths ("guides/setup.md", or "./other.md" relative to the page), in the
+// order given. lookup finds a published page by content path; references it
+// can't find are reported and skipped.
+func (s *postServiceImpl) relatedPosts(source string, meta map[string]interface{}, lookup func(relPath string) (models.PostMetadata, bool)) []models.PostMetadata {
+	refs := stringList(meta, "related")
+	if len(refs) == 0 {
+		return nil
+	}
+
+	self := filepath.ToSlash(source)
+	var related []models.PostMetadata
+	seen := map[string]bool{self: true}
+	for _, ref := range refs {
+		var rel string
+		var p models.PostMetadata
+		found := false
+		for _, rel = range mdParser.RefCandidates(self, ref) {
+			if p, found = lookup(rel); found {
+				break
+			}
+		}
+		if found && seen[rel] {
+			continue // The page itself or a duplicate
+		}
+		if !found || (p.Draft && !s.cfg.IncludeDrafts) || !s.cfg.IsScheduled(p.PublishDate, p.ExpiryDate) {
+			s.logger.Warn("Broken related reference", "page", source, "related", ref)
+			continue
+		}
+		seen[rel] = true
+		related = append(related, p)
+	}
+	return related
+}
+
+// resolveRefs replaces the ref, relref and versionref shortcodes of a page
+// with the URLs of their targets. A ref target is any content file: a
+// reference to a draft still breaks in builds without drafts, which the link
+// check reports.
+func (s *postServiceImpl) resolveRefs(relPath string, source []byte) []byte {
+	if !mdParser.HasRefs(source) {
+		return source
+	}
+	out, broken := mdParser.ResolveRefs(source, filepath.ToSlash(relPath), func(rel string) (string, bool) {
+		if ok, _ := afero.Exists(s.sourceFs, filepath.Join(s.cfg.ContentDir, filepath.FromSlash(rel))); !ok {
+			return "", false
+		}
+		return s.contentLink(rel), true
+	})
+	for _, ref := range broken {
+		s.logger.Warn("Broken ref", "page", relPath, "ref", ref)
+	}
+
+	var versions []models.VersionInfo
+	out, unknown := mdParser.ResolveVersionRefs(out, func(name string) (models.VersionInfo, bool) {
+		if versions == nil {
+			versions = s.versionLinks(relPath)
+		}
+		return utils.FindVersion(versions, name)
+	})
+	for _, name := range unknown {
+		s.logger.Warn("Unknown version in versionref", "page", relPath, "version", name)
+	}
+	return out
+}
+
+// expandIncludes inlines the include shortcodes of a page. It returns the
+// expanded source, with the conditional blocks of the fragments applied, the
+// fragments it names (even missing ones, so creating them rebuilds the page)
+// and a hash of the expanded body for the cache; a page without includes
+// comes back as it is, with no hash.
+func (s *postServiceImpl) expandIncludes(relPath, version string, source []byte) ([]byte, []string, string) {
+	if !mdParser.HasIncludes(source) {
+		return source, nil, ""
+	}
+	out, includes, errs := mdParser.ExpandIncludes(source, func(name string) ([]byte, error) {
+		return afero.ReadFile(s.sourceFs, filepath.Join(s.cfg.IncludesDir, filepath.FromSlash(name)))
+	})
+	for _, err := range errs {
+		s.logger.Error("Failed to include fragment", "page", relPath, "error", err)
+	}
+	out = s.applyConditions(relPath, version, out)
+	return out, includes, utils.GetBodyHash(out)
+}
+
+// pageFiles lists the data files a page is built from, relative to the site
+// root: the OpenAPI spec of its `openapi:` frontmatter
+func pageFiles(metaData map[string]interface{}) []string {
+	spec := strings.TrimPrefix(filepath.ToSlash(utils.GetString(metaData, "openapi")), "/")
+	if spec == "" {
+		return nil
+	}
+	return []string{path.Clean(spec)}
+}
+
+// filesHash hashes the data files of a page for the cache. A missing file
+// hashes as empty, so creating it rebuilds the page.
+func (s *postServiceImpl) filesHash(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, name := range files {
+		data, _ := afero.ReadFile(s.sourceFs, filepath.FromSlash(name))
+		buf.WriteString(name)
+		buf.WriteByte(0)
+		buf.Write(data)
+		buf.WriteByte(0)
+	}
+	return utils.GetBodyHash(buf.Bytes())
+}
+
+// apiReference renders the OpenAPI spec of a page as HTML to append to its
+// body, with the TOC entries of its headings. A page without one, or with a
+// spec that can't be read, gets nothing; the error is logged.
+func (s *postServiceImpl) apiReference(relPath string, metaData map[string]interface{}) (string, []models.TOCEntry) {
+	files := pageFiles(metaData)
+	if len(files) == 0 {
+		return "", nil
+	}
+	data, err := afero.ReadFile(s.sourceFs, filepath.FromSlash(files[0]))
+	var spec *openapi.Spec
+	if err == nil {
+		spec, err = openapi.Parse(data)
+	}
+	if err != nil {
+		s.logger.Error("Failed to render OpenAPI spec", "page", relPath, "spec", files[0], "error", err)
+		return "", nil
+	}
+	ref := openapi.Render(spec, func(description string) string {
+		var buf bytes.Buffer
+		if err := s.md.Convert([]byte(description), &buf); err != nil {
+			return template.HTMLEscapeString(description)
+		}
+		return buf.String()
+	})
+	return ref.HTML, ref.TOC
+}
+
+// applyConditions keeps the :::version and :::audience blocks of a page that
+// hold for its version and the build's audience. Invalid blocks are logged
+// and left as written.
+func (s *postServiceImpl) applyConditions(relPath, version string, source []byte) []byte {
+	out, errs := mdParser.ApplyConditions(source, mdParser.PageConditions(s.cfg, version))
+	for _, err := range errs {
+		s.logger.Error("Invalid conditional block", "page", relPath, "error", err)
+	}
+	return out
+}
+
+// writeAliases publishes the `aliases:` redirects of the listed pages. An
+// alias claimed by two pages, or at the URL of a page, is reported and skipped.
+func (s *postServiceImpl) writeAliases(posts []models.PostMetadata) {
+	sort.Slice(posts, func(i, j int) bool { return posts[i].Link < posts[j].Link })
+	links := make(map[string]bool, len(posts))
+	for _, p := range posts {
+		links[p.Link] = true
+	}
+
+	base := strings.TrimSuffix(s.cfg.BaseURL, "/")
+	claimed := make(map[string]string)
+	var aliases []generators.Alias
+	for _, p := range posts {
+		for _, a := range p.Aliases {
+			aliasPath, err := generators.AliasPath(a)
+			if err != nil {
+				s.logger.Warn("Invalid alias", "page", p.Link, "error", err)
+				continue
+			}
+			if url := base + aliasPath; links[url] || links[url+"index.html"] {
+				s.logger.Warn("Alias is the URL of a page, skipped", "page", p.Link, "alias", aliasPath)
+				continue
+			}
+			if other, ok := claimed[aliasPath]; ok {
+				if other != p.Link {
+					s.logger.Warn("Alias claimed by two pages, skipped", "alias", aliasPath, "page", p.Link, "kept", other)
+				}
+				continue
+			}
+			claimed[aliasPath] = p.Link
+			aliases = append(aliases, generators.Alias{Path: aliasPath, Target: p.Link, Title: p.Title})
+		}
+	}
+
+	written, err := generators.GenerateAliases(s.destFs, s.cfg.OutputDir, aliases)
+	if err != nil {
+		s.logger.Warn("Failed to write alias redirects", "error", err)
+	}
+	for _, path := range written {
+		s.renderer.RegisterFile(path)
+	}
+}
+
+// afterParse runs the after-parse hooks on a freshly parsed post and returns
+// its HTML as they left it. A failing hook is logged; the post keeps the
+// changes of the hooks before it.
+func (s *postServiceImpl) afterParse(relPath string, frontmatter map[string]interface{}, post *models.PostMetadata, html string) string {
+	p := &hooks.Post{Path: relPath, Frontmatter: frontmatter, Meta: post, HTML: html}
+	if err := s.hooks.RunAfterParse(p); err != nil {
+		s.logger.Error("After-parse hook failed", "path", relPath, "error", err)
+	}
+	return p.HTML
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/services/mocks"
+)
+
+func TestRelatedPosts(t *testing.T) {
+	s := &postServiceImpl{
+		cfg:    &config.Config{ContentDir: "content", BaseURL: "https://example.com"},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	pages := map[string]models.PostMetadata{
+		"guides/setup.md":  {Title: "Setup"},
+		"guides/deploy.md": {Title: "Deploy"},
+		"faq.md":           {Title: "FAQ"},
+		"wip.md":           {Title: "WIP", Draft: true},
+	}
+	lookup := func(relPath string) (models.PostMetadata, bool) {
+		p, ok := pages[relPath]
+		return p, ok
+	}
+
+	tests := []struct {
+		name    string
+		related interface{}
+		want    []string
+	}{
+		{"none", nil, nil},
+		{"single path", "faq.md", []string{"FAQ"}},
+		{
+			name:    "order kept, forms normalized",
+			related: []interface{}{"/content/faq", "./deploy.md", "guides/setup.md", "faq.md"},
+			want:    []string{"FAQ", "Deploy"}, // setup.md is the page itself, faq.md a duplicate
+		},
+		{"broken and draft skipped", []interface{}{"missing.md", "wip.md", "../faq.md"}, []string{"FAQ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := map[string]interface{}{}
+			if tt.related != nil {
+				meta["related"] = tt.related
+			}
+			var got []string
+			for _, p := range s.relatedPosts("guides/setup.md", meta, lookup) {
+				got = append(got, p.Title)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("relatedPosts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentLink(t *testing.T) {
+	s := &postServiceImpl{cfg: &config.Config{ContentDir: "content", BaseURL: "https://example.com"}}
+	tests := map[string]string{
+		"guides/Setup.md":   "https://example.com/guides/setup.html",
+		"v2.0/guides/a.md":  "https://example.com/v2.0/guides/a.html",
+		"index-of-terms.md": "https://example.com/index-of-terms.html",
+	}
+	for rel, want := range tests {
+		if got := s.contentLink(rel); got != want {
+			t.Errorf("contentLink(%q) = %q, want %q", rel, got, want)
+		}
+	}
+}
+
+func TestPageFiles(t *testing.T) {
+	tests := []struct {
+		meta map[string]interface{}
+		want []string
+	}{
+		{map[string]interface{}{"title": "Guide"}, nil},
+		{map[string]interface{}{"openapi": "content/api/../api/petstore.yaml"}, []string{"content/api/petstore.yaml"}},
+		{map[string]interface{}{"openapi": "/specs/petstore.json"}, []string{"specs/petstore.json"}},
+	}
+	for _, tt := range tests {
+		if got := pageFiles(tt.meta); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pageFiles(%v) = %v, want %v", tt.meta, got, tt.want)
+		}
+	}
+}
+
+func TestPageLayout(t *testing.T) {
+	s := &postServiceImpl{cfg: &config.Config{Layouts: map[string]string{"docs": "docs", "docs/api": "bare.html"}}}
+	tests := []struct {
+		name    string
+		meta    map[string]interface{}
+		relPath string
+		want    string
+	}{
+		{"no layout", nil, "blog/post.md", ""},
+		{"layout frontmatter", map[string]interface{}{"layout": "landing", "type": "post"}, "docs/index.md", "landing"},
+		{"type frontmatter", map[string]interface{}{"type": "post.html"}, "docs/intro.md", "post"},
+		{"section default", nil, "docs/guides/setup.md", "docs"},
+		{"deepest section wins", nil, "docs/api/users.md", "bare"},
+		{"root page", nil, "about.md", ""},
+	}
+	for _, tt := range tests {
+		if got := s.pageLayout(tt.meta, tt.relPath); got != tt.want {
+			t.Errorf("%s: pageLayout(%v, %q) = %q, want %q", tt.name, tt.meta, tt.relPath, got, tt.want)
+		}
+	}
+
+	rnd := mocks.NewMockRenderService()
+	rnd.TemplateMetas = map[string]*cache.TemplateMeta{"layout.html": {}, "layouts/docs.html": {}}
+	rnd.TemplateDepsMap = map[string][]string{"layout.html": {"partials/nav.html"}, "layouts/docs.html": {"partials/toc.html"}}
+	for layout, want := range map[string][]string{
+		"":     {"layout.html", "partials/nav.html"},
+		"docs": {"layouts/docs.html", "partials/toc.html"},
+		"bare": {"layout.html", "layouts/bare.html", "partials/nav.html"}, // Not in the theme: rendered with layout.html
+	} {
+		if got := postTemplateDeps(rnd, layout); !reflect.DeepEqual(got, want) {
+			t.Errorf("postTemplateDeps(%q) = %v, want %v", layout, got, want)
+		}
+	}
+}
+
+func TestLanguageLinks(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, f := range []string{"content/en/guides/setup.md", "content/ja/guides/setup.md", "content/de/intro.md"} {
+		_ = afero.WriteFile(fs, f, []byte("# Page\n"), 0644)
+	}
+	s := &postServiceImpl{
+		cfg: &config.Config{ContentDir: "content", BaseURL: "https://example.com", Languages: []config.Language{
+			{Code: "en"}, {Code: "ja"}, {Code: "de"},
+		}},
+		sourceFs: fs,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := []struct {
+		page string
+		want []string // "URL HasPage" per language
+	}{
+		{"en/guides/setup.md", []string{"https://example.com/guides/setup.html true", "https://example.com/ja/guides/setup.html true", "https://example.com/de/ false"}},
+		{"de/intro.md", []string{"https://example.com/ false", "https://example.com/ja/ false", "https://example.com/de/intro.html true"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, l := range s.languageLinks(tt.page) {
+			got = append(got, l.URL+" "+strconv.FormatBool(l.HasPage))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("languageLinks(%q) = %q, want %q", tt.page, got, tt.want)
+		}
+	}
+}
+
+func TestVersionLinks(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, f := range []string{"content/guides/setup.md", "content/v1.0/guides/setup.md", "content/v1.0/guides/legacy.md"} {
+		_ = afero.WriteFile(fs, f, []byte("# Page\n"), 0644)
+	}
+	s := &postServiceImpl{
+		cfg: &config.Config{ContentDir: "content", BaseURL: "https://example.com", Versions: []config.Version{
+			{Name: "v2.0", Path: "", IsLatest: true},
+			{Name: "v1.0", Path: "v1.0"},
+		}},
+		sourceFs: fs,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := []struct {
+		page string
+		want []string // "URL HasPage" per version
+	}{
+		{"guides/setup.md", []string{"https://example.com/guides/setup.html true", "https://example.com/v1.0/guides/setup.html true"}},
+		{"v1.0/guides/legacy.md", []string{"https://example.com/ false", "https://example.com/v1.0/guides/legacy.html true"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, v := range s.versionLinks(tt.page) {
+			got = append(got, v.URL+" "+strconv.FormatBool(v.HasPage))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("versionLinks(%q) = %q, want %q", tt.page, got, tt.want)
+		}
+	}
+
+	source := []byte(`[Old]({{< versionref "v1.0" >}}) [New]({{< versionref "latest" >}}) {{< versionref "v9" >}}`)
+	if got, want := string(s.resolveRefs("v1.0/guides/legacy.md", source)), `[Old](https://example.com/v1.0/guides/legacy.html) [New](https://example.com/) {{< versionref "v9" >}}`; got != want {
+		t.Errorf("resolveRefs() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteAliases(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	rnd := mocks.NewMockRenderService()
+	s := &postServiceImpl{
+		cfg:      &config.Config{OutputDir: "public", BaseURL: "https://example.com"},
+		destFs:   fs,
+		renderer: rnd,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	s.writeAliases([]models.PostMetadata{
+		{Title: "B", Link: "https://example.com/b.html", Aliases: []string{"/shared/", "/a.html", "../x"}},
+		{Title: "A", Link: "https://example.com/a.html", Aliases: []string{"shared", "/old-a/"}},
+	})
+
+	rules, err := afero.ReadFile(fs, filepath.Join("public", "_redirects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"/old-a/ https://example.com/a.html 301", "/shared/ https://example.com/a.html 301"} {
+		if !strings.Contains(string(rules), want) {
+			t.Errorf("_redirects missing %q:\n%s", want, rules)
+		}
+	}
+	// The first page by URL keeps a shared alias; an alias naming a page and
+	// one leaving the site are dropped
+	if strings.Contains(string(rules), "b.html") {
+		t.Errorf("_redirects has rules for b.html:\n%s", rules)
+	}
+	if !rnd.RegisteredFiles[filepath.Join("public", "_redirects")] || !rnd.RegisteredFiles[filepath.Join("public", "old-a", "index.html")] {
+		t.Errorf("alias files not registered: %v", rnd.RegisteredFiles)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	mock := mocks.NewMockCacheService()
+	commits := newCheckpoint(mock, 2)
+	for i := 0; i < 5; i++ {
+		meta := &cache.PostMeta{PostID: strconv.Itoa(i), Path: "posts/" + strconv.Itoa(i) + ".md"}
+		if err := commits.add(meta, &cache.SearchRecord{}, &cache.Dependencies{}); err != nil {
+			t.Fatalf("add() error = %v", err)
+		}
+	}
+	if got := mock.CallCount["BatchCommit"]; got != 2 {
+		t.Errorf("BatchCommit called %d times for 5 posts in batches of 2, want 2", got)
+	}
+	if len(mock.Posts) != 4 || len(mock.Pending) != 4 {
+		t.Errorf("committed %d posts, %d pending, want 4 and 4", len(mock.Posts), len(mock.Pending))
+	}
+
+	if err := commits.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+	if len(mock.Posts) != 5 || !mock.Pending["posts/4.md"] {
+		t.Errorf("flush() left %d posts committed, pending %v", len(mock.Posts), mock.Pending)
+	}
+	if err := commits.flush(); err != nil || mock.CallCount["BatchCommit"] != 3 {
+		t.Errorf("flushing an empty batch committed again: %d commits, error %v", mock.CallCount["BatchCommit"], err)
+	}
+}
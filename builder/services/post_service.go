@@ -9,6 +9,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -19,14 +20,18 @@ import (
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
 	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
 	"github.com/Kush-Singh-26/kosh/builder/metrics"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
 	"github.com/Kush-Singh-26/kosh/builder/renderer/native"
 	"github.com/Kush-Singh-26/kosh/builder/search"
+	"github.com/Kush-Singh-26/kosh/builder/telemetry"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
 )
 
@@ -41,9 +46,16 @@ type postServiceImpl struct {
 	sourceFs       afero.Fs
 	destFs         afero.Fs
 	diagramAdapter *cache.DiagramCacheAdapter // Kept as specific type or interface?
+	events         *events.Bus
+	hooks          *hooks.Registry
 
 	// Mutex for D2/Math rendering safety if needed
 	mu sync.Mutex
+
+	// Draft previews, loaded on the first draft
+	previewOnce   sync.Once
+	previewSecret []byte
+	previewErr    error
 }
 
 func NewPostService(
@@ -56,6 +68,8 @@ func NewPostService(
 	nativeRenderer *native.Renderer,
 	sourceFs, destFs afero.Fs,
 	diagramAdapter *cache.DiagramCacheAdapter,
+	bus *events.Bus,
+	hookRegistry *hooks.Registry,
 ) PostService {
 	return &postServiceImpl{
 		cfg:            cfg,
@@ -68,6 +82,8 @@ func NewPostService(
 		sourceFs:       sourceFs,
 		destFs:         destFs,
 		diagramAdapter: diagramAdapter,
+		events:         bus,
+		hooks:          hookRegistry,
 	}
 }
 
@@ -77,11 +93,11 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		pinnedPosts    []models.PostMetadata
 		tagMap         = make(map[string][]models.PostMetadata)
 		tagMapMu       sync.Mutex
-		postsByVersion = make(map[string][]models.PostMetadata)
+		postsByGroup   = make(map[postGroup][]models.PostMetadata)
+		result         = &PostResult{}
 		has404         bool
 		anyPostChanged atomic.Bool
 		processedCount int32
-		mu             sync.Mutex
 	)
 
 	var files []string
@@ -111,6 +127,19 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		existingFiles[relPath] = true
 	}
 
+	// A scoped build (--only) parses just its subtree: everything else keeps
+	// its cached metadata for sidebars and neighbours, and its output on disk
+	if s.cfg.Only != "" {
+		var scopedFiles, scopedVersions []string
+		for i, f := range files {
+			if s.cfg.InScope(f) {
+				scopedFiles = append(scopedFiles, f)
+				scopedVersions = append(scopedVersions, fileVersions[i])
+			}
+		}
+		files, fileVersions = scopedFiles, scopedVersions
+	}
+
 	if s.cache != nil {
 		if lister, ok := s.cache.(interface{ ListAllPosts() ([]string, error) }); ok {
 			ids, _ := lister.ListAllPosts()
@@ -130,27 +159,83 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 	var allMetadataMap sync.Map
 
 	var (
-		batchMu          sync.Mutex
-		newPostsMeta     []*cache.PostMeta
-		newSearchRecords = make(map[string]*cache.SearchRecord)
-		newDeps          = make(map[string]*cache.Dependencies)
+		commits      = newCheckpoint(s.cache, checkpointEvery)
+		indexedPosts = make([]models.IndexedPost, 0, len(files))
+		defaultLang  = s.cfg.DefaultLanguage()
+		renderJobs   []renderJob
 	)
 
-	type RenderContext struct {
-		DestPath string
-		Data     models.PageData
-		Version  string
+	// Pages parsed by an interrupted build have a cache entry but maybe not
+	// their output: render them even if an older page exists
+	var pending map[string]bool
+	if s.cache != nil {
+		pending, _ = s.cache.PendingPaths()
 	}
 
-	// Pre-allocate indexed posts slice and use atomic index for lock-free writes
-	indexedPosts := make([]models.IndexedPost, len(files))
-	var indexedPostIdx int32 = -1 // Start at -1 so first AddInt32 returns 0
+	numWorkers := utils.WorkerCount(s.cfg.Workers.Parse)
 
-	renderQueue := make([]RenderContext, len(files))
+	// Every post is expected to render; the estimate is corrected once parsing
+	// knows which ones (drafts, failures) won't
+	s.metrics.AddWork(metrics.StageParse, len(files))
+	s.metrics.AddWork(metrics.StageRender, len(files))
 
-	numWorkers := utils.GetDefaultWorkerCount()
+	// In low-memory mode search contents go to a temp file as they arrive
+	// instead of accumulating with the records
+	var spool *search.ContentSpool
+	if s.cfg.LowMemory {
+		var err error
+		if spool, err = search.NewContentSpool(filepath.Join(s.cfg.CacheDir, "tmp")); err != nil {
+			s.logger.Warn("Failed to create search spool, keeping contents in memory", "error", err)
+		}
+	}
 
-	cardPool := utils.NewWorkerPool(ctx, numWorkers, func(task socialCardTask) {
+	// Parse workers stream their results to a single collector, so nothing is
+	// sized by the whole site up front and no lock is needed for the batches.
+	// Pending render jobs only keep metadata: the page body lives in the cache
+	// until the job runs (see renderJob).
+	results := make(chan parsedPost, numWorkers*2)
+	collected := make(chan struct{})
+	templateDeps := make(map[string][]string) // By layout
+	go func() {
+		defer close(collected)
+		for r := range results {
+			if r.render != nil && r.render.preview {
+				renderJobs = append(renderJobs, *r.render)
+				continue
+			}
+			if r.lang != defaultLang {
+				// Other languages have their own index, so their own record IDs
+				lp := result.Language(r.lang)
+				r.indexed.Record.ID = len(lp.IndexedPosts)
+				lp.IndexedPosts = append(lp.IndexedPosts, r.indexed)
+			} else {
+				r.indexed.Record.ID = len(indexedPosts)
+				if err := spool.Stash(&r.indexed.Record); err != nil {
+					s.logger.Warn("Failed to spool search content", "link", r.indexed.Record.Link, "error", err)
+				}
+				indexedPosts = append(indexedPosts, r.indexed)
+			}
+			if r.render != nil {
+				renderJobs = append(renderJobs, *r.render)
+				anyPostChanged.Store(true)
+			}
+			if r.meta != nil {
+				layout := s.pageLayout(r.meta.Meta, r.meta.Path)
+				if _, ok := templateDeps[layout]; !ok {
+					templateDeps[layout] = postTemplateDeps(s.renderer, layout)
+				}
+				deps := &cache.Dependencies{Tags: r.meta.Tags, Templates: slices.Concat(templateDeps[layout], r.shortcodes), Includes: r.includes, Files: r.files}
+				if err := commits.add(r.meta, r.search, deps); err != nil {
+					s.logger.Warn("Failed to commit cache batch", "error", err)
+				}
+			}
+		}
+	}()
+
+	cardPool := utils.NewWorkerPool(ctx, utils.WorkerCount(s.cfg.Workers.Cards), func(task socialCardTask) {
+		_, span := telemetry.Start(ctx, "post.social_card", attribute.String("kosh.path", task.relPath))
+		defer span.End()
+		defer s.metrics.WorkDone(metrics.StageCards)
 		s.generateSocialCard(task)
 	})
 	cardPool.Start()
@@ -162,23 +247,27 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			cachedPosts, _ := s.cache.GetPostsByIDs(ids)
 			for _, cp := range cachedPosts {
 				allMetadataMap.Store(cp.Link, models.PostMetadata{
-					Title: cp.Title, Link: cp.Link, Weight: cp.Weight, Version: cp.Version,
-					DateObj: cp.Date, ReadingTime: cp.ReadingTime, Description: cp.Description,
-					Tags: cp.Tags, Pinned: cp.Pinned, Draft: cp.Draft,
+					Title: cp.Title, Link: cp.Link, Weight: cp.Weight, Version: cp.Version, Lang: s.cfg.LanguageOf(cp.Path),
+					DateObj: cp.Date, PublishDate: cp.PublishDate, ExpiryDate: cp.ExpiryDate, ModTime: time.Unix(cp.ModTime, 0), ReadingTime: cp.ReadingTime, Description: cp.Description,
+					Tags: cp.Tags, Pinned: cp.Pinned, Draft: cp.Draft, Audio: s.pageAudio(cp.Meta, cp.Link),
+					Aliases: stringList(cp.Meta, "aliases"),
 				})
 			}
 		}
 	}
 
 	parsePool := utils.NewWorkerPool(ctx, numWorkers, func(pt struct {
-		idx     int
 		path    string
 		version string
 	}) {
-		idx, path, version := pt.idx, pt.path, pt.version
+		path, version := pt.path, pt.version
 
 		relPath, _ := utils.SafeRel(s.cfg.ContentDir, path)
-		htmlRelPath := strings.ToLower(strings.Replace(relPath, ".md", ".html", 1))
+		htmlRelPath := s.cfg.HTMLPath(relPath)
+		lang := s.cfg.LanguageOf(relPath)
+		_, span := telemetry.Start(ctx, "post.parse", attribute.String("kosh.path", relPath))
+		defer span.End()
+		defer s.metrics.WorkDone(metrics.StageParse)
 
 		cleanHtmlRelPath := htmlRelPath
 		if version != "" {
@@ -223,6 +312,9 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			return
 		}
 		source, _ = afero.ReadFile(s.sourceFs, path)
+		// Blocks for other versions and audiences are gone before hashing, so
+		// the hash changes when the outcome does
+		source = s.applyConditions(relPath, version, source)
 		bodyHash = utils.GetBodyHash(source)
 
 		// Invalidate cache if body content changed (regardless of ModTime)
@@ -230,7 +322,17 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			exists = false
 		}
 
-		useCache := exists && !shouldForce
+		// Included fragments are part of the page: a changed one invalidates it too
+		expanded, includes, includeHash := s.expandIncludes(relPath, version, source)
+		if exists && cachedMeta != nil && cachedMeta.IncludeHash != includeHash {
+			exists = false
+		}
+		// So are the data files it's built from, like an OpenAPI spec
+		if exists && cachedMeta != nil && cachedMeta.FilesHash != s.filesHash(pageFiles(cachedMeta.Meta)) {
+			exists = false
+		}
+
+		useCache := exists && !shouldForce && !mdParser.DependsOnFiles(expanded)
 
 		var cachedHash string
 		if s.cache != nil && !useCache {
@@ -244,7 +346,7 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		var post models.PostMetadata
 		var searchRecord models.PostRecord
 		var wordFreqs map[string]int
-		var docLen int
+		var docLen, wordCount int
 		var words []string
 		var toc []models.TOCEntry
 		var frontmatterHash string
@@ -265,6 +367,7 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 
 		if useCache {
 			s.metrics.IncrementCacheHit()
+			s.events.Publish(events.CacheHit{Path: relPath})
 			htmlContent = string(cachedHTML)
 			metaData = cachedMeta.Meta
 			frontmatterHash = cachedMeta.ContentHash
@@ -294,28 +397,19 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			wordFreqs = cachedSearch.BM25Data
 		} else {
 			s.metrics.IncrementCacheMiss()
+			s.events.Publish(events.CacheMiss{Path: relPath})
 
-			// Copy raw markdown to output for "View Source" feature
-			if s.cfg.Features.RawMarkdown {
-				// Use filepath to handle OS-specific path separators correctly
-				mdDestPath := destPath[:len(destPath)-len(filepath.Ext(destPath))] + ".md"
-				if err := s.destFs.MkdirAll(filepath.Dir(mdDestPath), 0755); err != nil {
-					s.logger.Error("Failed to create markdown directory", "path", filepath.Dir(mdDestPath), "error", err)
-				}
-				if err := afero.WriteFile(s.destFs, mdDestPath, source, 0644); err != nil {
-					s.logger.Error("Failed to write markdown file", "path", mdDestPath, "error", err)
-				}
-			}
-
+			parseStart := time.Now()
+			body := s.resolveRefs(relPath, expanded) // Parsed; source stays as written
 			ctx := parser.NewContext()
 			ctx.Set(mdParser.ContextKeyFilePath, path)
-			docNode := s.md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+			docNode := s.md.Parser().Parse(text.NewReader(body), parser.WithContext(ctx))
 
 			// Use BufferPool
 			buf := utils.SharedBufferPool.Get()
 			defer utils.SharedBufferPool.Put(buf)
 
-			if err := s.md.Renderer().Render(buf, source, docNode); err != nil {
+			if err := s.md.Renderer().Render(buf, body, docNode); err != nil {
 				s.logger.Error("Failed to render markdown", "path", path, "error", err)
 				return
 			}
@@ -331,14 +425,21 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			}
 
 			ssrHashes = mdParser.GetSSRHashes(ctx)
+			parseTime := time.Since(parseStart)
 
-			if bytes.Contains(source, []byte("$")) || bytes.Contains(source, []byte("\\(")) {
+			var mathTime time.Duration
+			if bytes.Contains(expanded, []byte("$")) || bytes.Contains(expanded, []byte("\\(")) {
+				mathStart := time.Now()
 				var mathHashes []string
 				htmlContent, mathHashes = mdParser.RenderMathForHTML(htmlContent, s.nativeRenderer, diagramCache, &s.mu)
 				ssrHashes = append(ssrHashes, mathHashes...)
+				mathTime = time.Since(mathStart)
 			}
+			diagramTime := mdParser.GetD2Duration(ctx)
+			s.metrics.RecordPageParse(relPath, parseTime-diagramTime, diagramTime, mathTime)
 			if s.cfg.CompressImages {
 				htmlContent = utils.ReplaceToWebP(htmlContent)
+				htmlContent = mdParser.WrapPictures(htmlContent)
 			}
 
 			metaData = meta.Get(ctx)
@@ -349,8 +450,12 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			if w, ok := metaData["weight"].(float64); ok && weight == 0 {
 				weight = int(w)
 			}
-			wordCount := len(strings.Fields(string(source)))
+			wordCount = len(strings.Fields(string(expanded)))
 			toc = mdParser.GetTOC(ctx)
+			if ref, refTOC := s.apiReference(relPath, metaData); ref != "" {
+				htmlContent += ref
+				toc = append(toc, refTOC...)
+			}
 
 			postLink := utils.BuildURL(s.cfg.BaseURL, version, cleanHtmlRelPath)
 
@@ -358,10 +463,17 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 				Title: utils.GetString(metaData, "title"), Link: postLink,
 				Description: utils.GetString(metaData, "description"), Tags: utils.GetSlice(metaData, "tags"),
 				ReadingTime: int(math.Ceil(float64(wordCount) / wordsPerMinute)), Pinned: isPinned, Weight: weight,
-				DateObj: dateObj, Draft: utils.GetBool(metaData, "draft"), Version: version,
+				DateObj: dateObj, Draft: utils.GetBool(metaData, "draft"), Version: version, Lang: lang,
+				PublishDate: utils.GetTime(metaData, "publishDate"), ExpiryDate: utils.GetTime(metaData, "expiryDate"),
+				Audio: s.pageAudio(metaData, postLink), Aliases: stringList(metaData, "aliases"),
+			}
+			if info != nil {
+				post.ModTime = info.ModTime()
 			}
+			htmlContent = s.afterParse(relPath, metaData, &post, htmlContent)
+			s.events.Publish(events.PostParsed{Path: relPath, Post: post, Frontmatter: metaData, Duration: parseTime + mathTime})
 
-			plainText = mdParser.ExtractPlainText(docNode, source)
+			plainText = mdParser.ExtractPlainText(docNode, body)
 
 			// Pre-compute normalized fields for search
 			normalizedTags := make([]string, len(post.Tags))
@@ -380,32 +492,71 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 				Version:         version,
 			}
 
-			// Use analyzer for tokenization with stemming and stop word removal
-			var sb strings.Builder
-			sb.Grow(len(searchRecord.Title) + len(searchRecord.Description) + len(searchRecord.Content) + 200)
-			sb.WriteString(searchRecord.Title)
-			sb.WriteByte(' ')
-			sb.WriteString(searchRecord.Description)
-			sb.WriteByte(' ')
-			for _, t := range searchRecord.Tags {
-				sb.WriteString(t)
-				sb.WriteByte(' ')
+			words, wordFreqs = searchTerms(searchRecord)
+			docLen = len(words)
+			frontmatterHash, _ = utils.GetFrontmatterHash(metaData)
+
+			// Copy raw markdown to output for "View Source" feature
+			if s.cfg.Features.RawMarkdown && (!post.Draft || s.cfg.IncludeDrafts) && pagePassword(metaData) == "" && s.cfg.InAudience(pageAudiences(metaData)) && s.cfg.IsScheduled(post.PublishDate, post.ExpiryDate) {
+				// Use filepath to handle OS-specific path separators correctly
+				mdDestPath := destPath[:len(destPath)-len(filepath.Ext(destPath))] + ".md"
+				if err := s.destFs.MkdirAll(filepath.Dir(mdDestPath), 0755); err != nil {
+					s.logger.Error("Failed to create markdown directory", "path", filepath.Dir(mdDestPath), "error", err)
+				}
+				if err := afero.WriteFile(s.destFs, mdDestPath, source, 0644); err != nil {
+					s.logger.Error("Failed to write markdown file", "path", mdDestPath, "error", err)
+				}
 			}
-			sb.WriteString(searchRecord.Content)
+		}
 
-			// Analyze with stemming and stop words
-			words = search.DefaultAnalyzer.Analyze(sb.String())
-			docLen = len(words)
-			wordFreqs = make(map[string]int)
-			for _, w := range words {
-				if len(w) >= 2 {
-					wordFreqs[w]++
+		// unlist drops a page this build doesn't publish. When the last build
+		// listed it, the listings are regenerated, and its cache entry and the
+		// output it left go.
+		unlist := func() {
+			if prev, loaded := allMetadataMap.LoadAndDelete(post.Link); loaded && !prev.(models.PostMetadata).Draft {
+				anyPostChanged.Store(true)
+				if s.cache != nil {
+					_ = s.cache.DeletePost(cache.GeneratePostID("", relPath))
+				}
+				mdDestPath := destPath[:len(destPath)-len(filepath.Ext(destPath))] + ".md"
+				for _, p := range []string{destPath, mdDestPath} {
+					_ = s.destFs.Remove(p)
+					if !s.cfg.LowMemory {
+						_ = os.Remove(p)
+					}
 				}
 			}
-			frontmatterHash, _ = utils.GetFrontmatterHash(metaData)
+		}
+
+		if !s.cfg.InAudience(pageAudiences(metaData)) {
+			unlist()
+			return
 		}
 
 		if post.Draft && !s.cfg.IncludeDrafts {
+			unlist()
+			if s.cfg.IsDev || s.cfg.DraftPreviews.Enabled {
+				job, err := s.draftPreviewJob(relPath, htmlContent, s.withPostExtras(models.PageData{
+					Title: post.Title, Description: post.Description,
+					Meta: metaData, BaseURL: s.cfg.BaseURL, BuildVersion: s.cfg.BuildVersion,
+					TabTitle: post.Title + " | " + s.cfg.LanguageTitle(lang), TOC: toc, Config: s.cfg, SourcePath: relPath,
+					CurrentVersion: version,
+				}))
+				if err != nil {
+					s.logger.Error("Failed to set up draft preview", "path", relPath, "error", err)
+					return
+				}
+				job.lang = lang
+				s.logger.Info("🔗 Draft preview", "path", relPath, "url", job.data.Permalink)
+				results <- parsedPost{render: job}
+			}
+			return
+		}
+
+		if !s.cfg.IsScheduled(post.PublishDate, post.ExpiryDate) {
+			// Not yet published (publishDate) or expired (expiryDate). Nothing
+			// is cached, so the build after its publishDate parses it anew.
+			unlist()
 			return
 		}
 
@@ -425,6 +576,7 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		}
 
 		if forceSocialRebuild || (cachedHash != frontmatterHash || !cardExists) {
+			s.metrics.AddWork(metrics.StageCards, 1)
 			cardPool.Submit(socialCardTask{
 				path:            relPath,
 				relPath:         strings.TrimSuffix(htmlRelPath, ".html") + ".webp",
@@ -452,12 +604,18 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		}
 
 		willRender := false
-		if outputMissing {
+		if outputMissing || shouldForce || s.cfg.Only != "" {
+			// Forced builds re-render every page: the templates may have changed.
+			// So do scoped builds, which skip template change detection.
 			willRender = true
 		} else if useCache {
-			if _, err := os.Stat(destPath); os.IsNotExist(err) {
+			if _, err := os.Stat(destPath); os.IsNotExist(err) || pending[utils.NormalizePath(relPath)] {
 				willRender = true
 			}
+		} else if s.cache != nil {
+			// Cache miss: the post is new, changed, or was invalidated because
+			// a template it depends on changed
+			willRender = true
 		} else {
 			if info == nil {
 				info, _ = s.sourceFs.Stat(path)
@@ -468,7 +626,7 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		}
 
 		// Copy raw markdown to output for "View Source" feature (for cached posts too)
-		if s.cfg.Features.RawMarkdown {
+		if s.cfg.Features.RawMarkdown && pagePassword(metaData) == "" {
 			mdDestPath := destPath[:len(destPath)-len(filepath.Ext(destPath))] + ".md"
 			if _, err := os.Stat(mdDestPath); os.IsNotExist(err) {
 				sourceBytes, err := afero.ReadFile(s.sourceFs, path)
@@ -485,33 +643,9 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 			}
 		}
 
-		if willRender {
-			renderQueue[idx] = RenderContext{
-				DestPath: destPath,
-				Version:  version,
-				Data: models.PageData{
-					Title: post.Title, Description: post.Description, Content: template.HTML(htmlContent),
-					Meta: metaData, BaseURL: s.cfg.BaseURL, BuildVersion: s.cfg.BuildVersion,
-					TabTitle: post.Title + " | " + s.cfg.Title, Permalink: post.Link, Image: imagePath,
-					TOC: toc, Config: s.cfg,
-					CurrentVersion: version,
-					IsOutdated:     s.isOutdatedVersion(version),
-					Versions:       s.cfg.GetVersionsMetadata(version, cleanHtmlRelPath),
-				},
-			}
-			mu.Lock()
-			anyPostChanged.Store(true)
-			mu.Unlock()
-		}
-
 		// Use sync.Map for metadata (optimization: lock-free concurrent access)
 		allMetadataMap.Store(post.Link, post)
 
-		// Lock-free indexed post assignment using atomic index
-		id := int(atomic.AddInt32(&indexedPostIdx, 1))
-		searchRecord.ID = id
-		indexedPosts[id] = models.IndexedPost{Record: searchRecord, WordFreqs: wordFreqs, DocLen: docLen}
-
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
@@ -519,33 +653,74 @@ func (s *postServiceImpl) Process(ctx context.Context, shouldForce, forceSocialR
 		default:
 		}
 
+		if pagePassword(metaData) != "" {
+			// Only the title, description and tags of a protected page are searchable
+			searchRecord.Content, plainText = "", ""
+			words, wordFreqs = searchTerms(searchRecord)
+			docLen = len(words)
+		}
+
+		parsed := parsedPost{
+			indexed: models.IndexedPost{Record: searchRecord, WordFreqs: wordFreqs, DocLen: docLen, Date: post.DateObj},
+			lang:    lang,
+		}
+
+		// The cache entry doubles as the render job's handle on the page body
+		bodyMeta := cachedMeta
 		if !useCache && s.cache != nil {
 			postID := cache.GeneratePostID("", relPath)
 			newMeta := &cache.PostMeta{
 				PostID: postID, Path: relPath, ModTime: info.ModTime().Unix(),
-				ContentHash: frontmatterHash, BodyHash: bodyHash, Title: post.Title, Date: post.DateObj,
-				Tags: post.Tags, ReadingTime: post.ReadingTime, Description: post.Description,
+				ContentHash: frontmatterHash, BodyHash: bodyHash, IncludeHash: includeHash, FilesHash: s.filesHash(pageFiles(metaData)),
+				Title: post.Title, Date: post.DateObj,
+				Tags: post.Tags, WordCount: wordCount, ReadingTime: post.ReadingTime, Description: post.Description,
 				Link: post.Link, Pinned: post.Pinned, Weight: post.Weight, Draft: post.Draft,
+				PublishDate: post.PublishDate, ExpiryDate: post.ExpiryDate,
 				Meta: metaData, TOC: toc, Version: version,
 				SSRInputHashes: ssrHashes,
 			}
+			bodyMeta = newMeta
 			if err := s.cache.StoreHTMLForPost(newMeta, []byte(htmlContent)); err != nil {
 				s.logger.Error("Failed to store HTML in cache", "path", relPath, "error", err)
+				bodyMeta = nil
 			}
-			newSearch := &cache.SearchRecord{
+			parsed.meta = newMeta
+			parsed.shortcodes = shortcodeTemplateDeps(s.renderer, expanded)
+			parsed.includes = includes
+			parsed.files = pageFiles(metaData)
+			parsed.search = &cache.SearchRecord{
 				Title: post.Title, NormalizedTitle: searchRecord.NormalizedTitle,
 				BM25Data: wordFreqs, DocLen: docLen, Content: plainText,
 				NormalizedTags: searchRecord.NormalizedTags,
 			}
-			newDep := &cache.Dependencies{Tags: post.Tags}
+		}
 
-			batchMu.Lock()
-			newPostsMeta = append(newPostsMeta, newMeta)
-			newSearchRecords[postID] = newSearch
-			newDeps[postID] = newDep
-			batchMu.Unlock()
+		if willRender {
+			job := &renderJob{
+				source:   relPath,
+				destPath: destPath,
+				version:  version,
+				lang:     lang,
+				data: s.withPostExtras(models.PageData{
+					Title: post.Title, Description: post.Description,
+					Meta: metaData, BaseURL: s.cfg.BaseURL, BuildVersion: s.cfg.BuildVersion,
+					TabTitle: post.Title + " | " + s.cfg.LanguageTitle(lang), Permalink: post.Link, Image: imagePath,
+					TOC: toc, Config: s.cfg, SourcePath: relPath,
+					CurrentVersion: version,
+					IsOutdated:     s.isOutdatedVersion(version),
+					Versions:       s.versionLinks(relPath),
+				}),
+			}
+			if bodyMeta != nil {
+				job.bodyMeta = bodyMeta
+			} else {
+				job.body = htmlContent
+			}
+			parsed.render = job
 		}
 
+		results <- parsed
+
 		s.metrics.IncrementPostsProcessed()
 		_ = atomic.AddInt32(&processedCount, 1)
 	})
@@ -558,19 +733,39 @@ Loop:
 			break Loop
 		default:
 			parsePool.Submit(struct {
-				idx     int
 				path    string
 				version string
-			}{i, path, fileVersions[i]})
+			}{path, fileVersions[i]})
 		}
 	}
 	parsePool.Stop()
+	close(results)
+	<-collected
 	cardPool.Stop() // Wait for all social card generation to complete
 
 	// Final Metadata Grouping (merges Cache + Source)
 	allMetadataMap.Range(func(key, value interface{}) bool {
 		p := value.(models.PostMetadata)
-		postsByVersion[p.Version] = append(postsByVersion[p.Version], p)
+		if (p.Draft && !s.cfg.IncludeDrafts) || !s.cfg.IsScheduled(p.PublishDate, p.ExpiryDate) {
+			return true // Loaded from a cache written by an earlier -drafts or -future build
+		}
+		group := postGroup{lang: p.Lang, version: p.Version}
+		postsByGroup[group] = append(postsByGroup[group], p)
+
+		if p.Lang != defaultLang {
+			// Other languages list their posts under their own home page
+			lp := result.Language(p.Lang)
+			for _, t := range p.Tags {
+				key := strings.ToLower(strings.TrimSpace(t))
+				lp.TagMap[key] = append(lp.TagMap[key], p)
+			}
+			if p.Pinned {
+				lp.PinnedPosts = append(lp.PinnedPosts, p)
+			} else {
+				lp.AllPosts = append(lp.AllPosts, p)
+			}
+			return true
+		}
 
 		// Add to tagMap for all versions (not just unversioned)
 		for _, t := range p.Tags {
@@ -580,85 +775,105 @@ Loop:
 			tagMapMu.Unlock()
 		}
 
-		// Determine if this post belongs to the main feed:
-		// - Unversioned posts for non-versioned sites
-		// - Latest version posts for versioned sites
-		isLatestOrUnversioned := p.Version == ""
-		if len(s.cfg.Versions) > 0 {
-			for _, v := range s.cfg.Versions {
-				if v.IsLatest && p.Version == v.Name {
-					isLatestOrUnversioned = true
-					break
-				}
-			}
-		}
-
-		if isLatestOrUnversioned {
-			if p.Pinned {
-				pinnedPosts = append(pinnedPosts, p)
-			} else {
-				allPosts = append(allPosts, p)
-			}
+		// The main listings and feed hold unversioned posts and those of the
+		// latest version; older versions are listed by version
+		if !s.cfg.IsLatestVersion(p.Version) {
+			result.AddVersionPost(p)
+		} else if p.Pinned {
+			pinnedPosts = append(pinnedPosts, p)
+		} else {
+			allPosts = append(allPosts, p)
 		}
 		return true
 	})
 
-	siteTrees := make(map[string][]*models.TreeNode)
-	for ver, posts := range postsByVersion {
+	siteTrees := make(map[postGroup][]*models.TreeNode)
+	var listed []models.PostMetadata
+	for group, posts := range postsByGroup {
 		utils.SortPosts(posts)
-		siteTrees[ver] = utils.BuildSiteTree(posts, "")
+		siteTrees[group] = utils.BuildSiteTree(posts, "")
+		listed = append(listed, posts...)
 	}
-
-	renderPool := utils.NewWorkerPool(ctx, numWorkers, func(t RenderContext) {
-		t.Data.SiteTree = siteTrees[t.Version]
-		s.renderer.RenderPage(t.DestPath, t.Data)
+	s.writeAliases(listed)
+
+	// Rendering has to wait for every post's metadata (sidebar tree and
+	// prev/next span the whole version). From here each job loads its body,
+	// renders and writes, so only as many page bodies as render workers are in memory at once.
+	renderPool := utils.NewWorkerPool(ctx, utils.WorkerCount(s.cfg.Workers.Render), func(job renderJob) {
+		_, span := telemetry.Start(ctx, "post.render", attribute.String("kosh.path", job.source))
+		defer span.End()
+		defer s.metrics.WorkDone(metrics.StageRender)
+		body, err := job.loadBody(s.cache)
+		if err != nil {
+			s.logger.Error("Failed to load rendered markdown", "path", job.destPath, "error", err)
+			return
+		}
+		job.data.Content = template.HTML(body)
+		job.data.SiteTree = siteTrees[postGroup{lang: job.lang, version: job.version}]
+		job.data = s.protectPage(job.data)
+		start := time.Now()
+		s.renderer.RenderPage(job.destPath, job.data)
+		s.metrics.RecordPageRender(job.source, time.Since(start))
 	})
 	renderPool.Start()
+	s.metrics.AddWork(metrics.StageRender, len(renderJobs)-len(files))
 
-	for i := range renderQueue {
-		task := &renderQueue[i]
-		if task.DestPath == "" {
-			continue
-		}
+	for i := range renderJobs {
+		job := renderJobs[i]
+		renderJobs[i] = renderJob{} // Let the pool own the job
 
 		// Inject neighbors (Prev/Next)
-		versionPosts := postsByVersion[task.Version]
+		versionPosts := postsByGroup[postGroup{lang: job.lang, version: job.version}]
 		currentPost := models.PostMetadata{
-			Title: task.Data.Title, Link: task.Data.Permalink, Weight: task.Data.Weight, Version: task.Version,
+			Title: job.data.Title, Link: job.data.Permalink, Weight: job.data.Weight, Version: job.version, Lang: job.lang,
 		}
 
 		// Ensure we match the actual metadata object to get DateObj for sorting if needed
 		for _, p := range versionPosts {
-			if p.Link == task.Data.Permalink {
+			if p.Link == job.data.Permalink {
 				currentPost = p
 				break
 			}
 		}
 
-		prev, next := utils.FindPrevNext(currentPost, versionPosts)
-		task.Data.PrevPage = prev
-		task.Data.NextPage = next
+		if !job.preview {
+			prev, next := utils.FindPrevNext(currentPost, versionPosts)
+			job.data.PrevPage = prev
+			job.data.NextPage = next
+		}
+		job.data.Related = s.relatedPosts(job.source, job.data.Meta, func(relPath string) (models.PostMetadata, bool) {
+			v, ok := allMetadataMap.Load(s.contentLink(relPath))
+			if !ok {
+				return models.PostMetadata{}, false
+			}
+			return v.(models.PostMetadata), true
+		})
 
-		renderPool.Submit(*task)
+		renderPool.Submit(job)
 	}
 	renderPool.Stop()
 
-	if s.cache != nil && len(newPostsMeta) > 0 {
-		if err := s.cache.BatchCommit(newPostsMeta, newSearchRecords, newDeps); err != nil {
-			s.logger.Warn("Failed to commit cache batch", "error", err)
-		}
+	if err := commits.flush(); err != nil {
+		s.logger.Warn("Failed to commit cache batch", "error", err)
 	}
 
 	// Sort posts to ensure consistent ordering
 	utils.SortPosts(allPosts)
 	utils.SortPosts(pinnedPosts)
+	for _, lp := range result.Languages {
+		utils.SortPosts(lp.AllPosts)
+		utils.SortPosts(lp.PinnedPosts)
+	}
+	for _, posts := range result.VersionPosts {
+		utils.SortPosts(posts)
+	}
 
-	return &PostResult{
-		AllPosts:       allPosts,
-		PinnedPosts:    pinnedPosts,
-		TagMap:         tagMap,
-		IndexedPosts:   indexedPosts,
-		AnyPostChanged: anyPostChanged.Load(),
-		Has404:         has404,
-	}, nil
+	result.AllPosts = allPosts
+	result.PinnedPosts = pinnedPosts
+	result.TagMap = tagMap
+	result.IndexedPosts = indexedPosts
+	result.AnyPostChanged = anyPostChanged.Load()
+	result.Has404 = has404
+	result.SearchSpool = spool
+	return result, nil
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/yuin/goldmark/text"
 
 	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/events"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
 	"github.com/Kush-Singh-26/kosh/builder/utils"
@@ -41,7 +42,7 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 	}
 
 	version, relPath := utils.GetVersionFromPath(path)
-	htmlRelPath := strings.ToLower(strings.Replace(relPath, ".md", ".html", 1))
+	htmlRelPath := s.cfg.HTMLPath(relPath)
 
 	cleanHtmlRelPath := htmlRelPath
 	if version != "" {
@@ -56,15 +57,24 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 	}
 	fullLink := utils.BuildURL(s.cfg.BaseURL, version, cleanHtmlRelPath)
 
+	contentRel, err := utils.SafeRel(s.cfg.ContentDir, path)
+	if err != nil {
+		contentRel = relPath
+	}
+	source = s.applyConditions(contentRel, version, source)
+	expanded, includes, includeHash := s.expandIncludes(contentRel, version, source)
+	body := s.resolveRefs(contentRel, expanded) // Parsed; source stays as written
+	parseStart := time.Now()
+
 	context := gParser.NewContext()
 	context.Set(mdParser.ContextKeyFilePath, path)
-	reader := text.NewReader(source)
+	reader := text.NewReader(body)
 	docNode := s.md.Parser().Parse(reader, gParser.WithContext(context))
 
 	buf := utils.SharedBufferPool.Get()
 	defer utils.SharedBufferPool.Put(buf)
 
-	if err := s.md.Renderer().Render(buf, source, docNode); err != nil {
+	if err := s.md.Renderer().Render(buf, body, docNode); err != nil {
 		s.logger.Error("Failed to render markdown", "path", path, "error", err)
 		return err
 	}
@@ -81,24 +91,35 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 
 	ssrHashes := mdParser.GetSSRHashes(context)
 
-	if bytes.Contains(source, []byte("$")) || bytes.Contains(source, []byte("\\(")) {
+	if bytes.Contains(expanded, []byte("$")) || bytes.Contains(expanded, []byte("\\(")) {
 		var mathHashes []string
 		htmlContent, mathHashes = mdParser.RenderMathForHTML(htmlContent, s.nativeRenderer, diagramCache, &s.mu)
 		ssrHashes = append(ssrHashes, mathHashes...)
 	}
 	if s.cfg.CompressImages {
 		htmlContent = utils.ReplaceToWebP(htmlContent)
+		htmlContent = mdParser.WrapPictures(htmlContent)
 	}
 
-	if s.cfg.Features.RawMarkdown {
+	metaData := meta.Get(context)
+	if !s.cfg.InAudience(pageAudiences(metaData)) {
+		return nil // Written for another audience (--audience)
+	}
+	publishDate, expiryDate := utils.GetTime(metaData, "publishDate"), utils.GetTime(metaData, "expiryDate")
+	if !s.cfg.IsScheduled(publishDate, expiryDate) {
+		return nil // Not published yet, or expired
+	}
+	if s.cfg.Features.RawMarkdown && pagePassword(metaData) == "" {
 		mdDestPath := destPath[:len(destPath)-len(filepath.Ext(destPath))] + ".md"
 		_ = s.destFs.MkdirAll(filepath.Dir(mdDestPath), 0755)
 		_ = afero.WriteFile(s.destFs, mdDestPath, source, 0644)
 	}
 
-	metaData := meta.Get(context)
-	plainText := mdParser.ExtractPlainText(docNode, source)
-	wordCount := len(strings.Fields(string(source)))
+	plainText := mdParser.ExtractPlainText(docNode, body)
+	if pagePassword(metaData) != "" {
+		plainText = "" // Protected pages aren't full-text searchable
+	}
+	wordCount := len(strings.Fields(string(expanded)))
 	readTime := int(math.Ceil(float64(wordCount) / 120.0))
 	isPinned, _ := metaData["pinned"].(bool)
 	dateStr := utils.GetString(metaData, "date")
@@ -106,6 +127,10 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 	isDraft := utils.GetBool(metaData, "draft")
 
 	toc := mdParser.GetTOC(context)
+	if ref, refTOC := s.apiReference(filepath.ToSlash(contentRel), metaData); ref != "" {
+		htmlContent += ref
+		toc = append(toc, refTOC...)
+	}
 
 	post := models.PostMetadata{
 		Title:       utils.GetString(metaData, "title"),
@@ -116,23 +141,35 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 		Pinned:      isPinned,
 		Draft:       isDraft,
 		DateObj:     dateObj,
+		PublishDate: publishDate,
+		ExpiryDate:  expiryDate,
 		Version:     version,
+		Lang:        s.cfg.LanguageOf(contentRel),
 	}
+	htmlContent = s.afterParse(filepath.ToSlash(contentRel), metaData, &post, htmlContent)
+	s.events.Publish(events.PostParsed{Path: filepath.ToSlash(contentRel), Post: post, Frontmatter: metaData, Duration: time.Since(parseStart)})
 
 	var versionPosts []models.PostMetadata
 	if s.cache != nil {
 		// Use optimized version query instead of loading all posts
 		versionMetas, err := s.cache.GetPostsMetadataByVersion(version)
 		if err == nil {
-			versionPosts = make([]models.PostMetadata, len(versionMetas))
-			for i, m := range versionMetas {
-				versionPosts[i] = models.PostMetadata{
+			versionPosts = make([]models.PostMetadata, 0, len(versionMetas))
+			for _, m := range versionMetas {
+				if s.cfg.LanguageOfURL(m.Link) != post.Lang {
+					continue // Each language has its own sidebar
+				}
+				if !s.cfg.IsScheduled(m.PublishDate, m.ExpiryDate) {
+					continue
+				}
+				versionPosts = append(versionPosts, models.PostMetadata{
 					Title:   m.Title,
 					Link:    m.Link,
 					Weight:  m.Weight,
 					Version: m.Version,
+					Lang:    post.Lang,
 					DateObj: m.Date,
-				}
+				})
 			}
 		}
 	}
@@ -167,11 +204,12 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 
 		newMeta := &cache.PostMeta{
 			PostID: postID, Path: relPath, ModTime: info.ModTime().Unix(),
-			ContentHash: frontmatterHash, BodyHash: bodyHash, HTMLHash: htmlHash,
+			ContentHash: frontmatterHash, BodyHash: bodyHash, IncludeHash: includeHash, FilesHash: s.filesHash(pageFiles(metaData)), HTMLHash: htmlHash,
 			Title: post.Title, Date: post.DateObj, Tags: post.Tags,
-			ReadingTime: post.ReadingTime, Description: post.Description,
+			WordCount: wordCount, ReadingTime: post.ReadingTime, Description: post.Description,
 			Link: post.Link, Pinned: post.Pinned, Weight: post.Weight,
-			Draft: post.Draft, Meta: metaData, TOC: cacheTOC, Version: version,
+			Draft: post.Draft, PublishDate: post.PublishDate, ExpiryDate: post.ExpiryDate,
+			Meta: metaData, TOC: cacheTOC, Version: version,
 			SSRInputHashes: ssrHashes,
 		}
 
@@ -185,7 +223,7 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 			BM25Data: make(map[string]int), DocLen: wordCount, Content: plainText,
 			NormalizedTags: normalizedTags,
 		}
-		newDep := &cache.Dependencies{Tags: post.Tags}
+		newDep := &cache.Dependencies{Tags: post.Tags, Templates: append(postTemplateDeps(s.renderer, s.pageLayout(metaData, relPath)), shortcodeTemplateDeps(s.renderer, expanded)...), Includes: includes, Files: pageFiles(metaData)}
 		_ = s.cache.BatchCommit([]*cache.PostMeta{newMeta}, map[string]*cache.SearchRecord{postID: newSearch}, map[string]*cache.Dependencies{postID: newDep})
 	}
 
@@ -201,15 +239,34 @@ func (s *postServiceImpl) ProcessSingle(ctx context.Context, path string) error
 		imagePath = s.cfg.BaseURL + img
 	}
 
-	s.renderer.RenderPage(destPath, models.PageData{
+	s.renderer.RenderPage(destPath, s.protectPage(s.withPostExtras(models.PageData{
 		Title: post.Title, Description: post.Description, Content: template.HTML(htmlContent),
 		Meta: metaData, BaseURL: s.cfg.BaseURL, BuildVersion: s.cfg.BuildVersion,
-		TabTitle: post.Title + " | " + s.cfg.Title, Permalink: post.Link, Image: imagePath,
-		TOC: toc, Config: s.cfg, SiteTree: siteTree,
+		TabTitle: post.Title + " | " + s.cfg.LanguageTitle(post.Lang), Permalink: post.Link, Image: imagePath,
+		TOC: toc, Config: s.cfg, SiteTree: siteTree, SourcePath: relPath,
 		CurrentVersion: version, IsOutdated: s.isOutdatedVersion(version),
-		Versions: s.cfg.GetVersionsMetadata(version, cleanHtmlRelPath),
+		Versions: s.versionLinks(contentRel),
 		PrevPage: prev, NextPage: next,
-	})
+		Related: s.relatedPosts(contentRel, metaData, s.cachedPost),
+	})))
 
 	return nil
 }
+
+// cachedPost looks a page up by content path in the build cache
+func (s *postServiceImpl) cachedPost(relPath string) (models.PostMetadata, bool) {
+	if s.cache == nil {
+		return models.PostMetadata{}, false
+	}
+	id := cache.GeneratePostID("", filepath.FromSlash(relPath))
+	metas, err := s.cache.GetPostsByIDs([]string{id})
+	if err != nil || metas[id] == nil {
+		return models.PostMetadata{}, false
+	}
+	m := metas[id]
+	return models.PostMetadata{
+		Title: m.Title, Link: s.contentLink(m.Path), Description: m.Description, Tags: m.Tags,
+		ReadingTime: m.ReadingTime, Pinned: m.Pinned, Draft: m.Draft, DateObj: m.Date, Version: m.Version,
+		PublishDate: m.PublishDate, ExpiryDate: m.ExpiryDate,
+	}, true
+}
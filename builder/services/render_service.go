@@ -1,38 +1,88 @@
 package services
 
 import (
+	"bytes"
 	"log/slog"
+	"time"
 
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
 	"github.com/Kush-Singh-26/kosh/builder/models"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
 	"github.com/Kush-Singh-26/kosh/builder/renderer"
 )
 
 type renderServiceImpl struct {
 	rnd    *renderer.Renderer
 	logger *slog.Logger
+	events *events.Bus
+	hooks  *hooks.Registry
 }
 
-func NewRenderService(rnd *renderer.Renderer, logger *slog.Logger) RenderService {
+func NewRenderService(rnd *renderer.Renderer, logger *slog.Logger, bus *events.Bus, hookRegistry *hooks.Registry) RenderService {
 	return &renderServiceImpl{
 		rnd:    rnd,
 		logger: logger,
+		events: bus,
+		hooks:  hookRegistry,
 	}
 }
 
 func (s *renderServiceImpl) RenderPage(path string, data models.PageData) {
-	s.rnd.RenderPage(path, data)
+	template := "layout"
+	if data.Layout != "" {
+		template = renderer.LayoutsDir + "/" + data.Layout
+	}
+	s.render(path, template, data, s.rnd.RenderPage)
 }
 
 func (s *renderServiceImpl) RenderIndex(path string, data models.PageData) {
-	s.rnd.RenderIndex(path, data)
+	s.render(path, "index", data, s.rnd.RenderIndex)
 }
 
 func (s *renderServiceImpl) Render404(path string, data models.PageData) {
-	s.rnd.Render404(path, data)
+	s.render(path, "404", data, s.rnd.Render404)
 }
 
 func (s *renderServiceImpl) RenderGraph(path string, data models.PageData) {
-	s.rnd.RenderGraph(path, data)
+	s.render(path, "graph", data, s.rnd.RenderGraph)
+}
+
+// render writes a page with write between the before- and after-render
+// hooks and publishes PageRendered. A failing hook is logged and the page
+// is written with the changes of the hooks before it.
+func (s *renderServiceImpl) render(path, template string, data models.PageData, write func(string, models.PageData)) {
+	start := time.Now()
+	if err := s.hooks.RunBeforeRender(path, &data); err != nil {
+		s.logger.Error("Before-render hook failed", "path", path, "error", err)
+	}
+	write(path, data)
+	if s.hooks.HasAfterRender() {
+		s.afterRender(path)
+	}
+	s.events.Publish(events.PageRendered{Path: path, Template: template, Duration: time.Since(start)})
+}
+
+// afterRender passes a written page through the after-render hooks and
+// writes back what they return
+func (s *renderServiceImpl) afterRender(path string) {
+	html, err := afero.ReadFile(s.rnd.DestFs, path)
+	if err != nil {
+		return // Not written: the template failed
+	}
+	out, err := s.hooks.RunAfterRender(path, html)
+	if err != nil {
+		s.logger.Error("After-render hook failed", "path", path, "error", err)
+	}
+	if bytes.Equal(out, html) {
+		return
+	}
+	if err := afero.WriteFile(s.rnd.DestFs, path, out, 0644); err != nil {
+		s.logger.Error("Failed to write page", "path", path, "error", err)
+	}
 }
 
 func (s *renderServiceImpl) RegisterFile(path string) {
@@ -54,3 +104,28 @@ func (s *renderServiceImpl) GetRenderedFiles() map[string]bool {
 func (s *renderServiceImpl) ClearRenderedFiles() {
 	s.rnd.ClearRenderedFiles()
 }
+
+func (s *renderServiceImpl) CompileTemplates() (bool, error) {
+	return s.rnd.CompileTemplates()
+}
+
+func (s *renderServiceImpl) Templates() (string, map[string]*cache.TemplateMeta) {
+	return s.rnd.Templates()
+}
+
+func (s *renderServiceImpl) TemplateDeps(file string) []string {
+	return s.rnd.TemplateDeps(file)
+}
+
+func (s *renderServiceImpl) TemplateUsers(partial string) []string {
+	return s.rnd.TemplateUsers(partial)
+}
+
+// Shortcode renders a markdown shortcode from the theme's templates
+func (s *renderServiceImpl) Shortcode(name string, data mdParser.ShortcodeData) (string, bool, error) {
+	return s.rnd.Shortcode(name, data)
+}
+
+func (s *renderServiceImpl) TakeTemplateErrors() []renderer.TemplateError {
+	return s.rnd.TakeTemplateErrors()
+}
@@ -1,12 +1,17 @@
 package services
 
 import (
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
 
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/hooks"
 	"github.com/Kush-Singh-26/kosh/builder/models"
 	"github.com/Kush-Singh-26/kosh/builder/renderer"
 )
@@ -25,7 +30,7 @@ func setupRenderServiceTest(t *testing.T) (*renderServiceImpl, afero.Fs) {
 		Compress:    false,
 	}
 
-	service := NewRenderService(rnd, logger).(*renderServiceImpl)
+	service := NewRenderService(rnd, logger, nil, nil).(*renderServiceImpl)
 	return service, destFs
 }
 
@@ -38,7 +43,7 @@ func TestNewRenderService(t *testing.T) {
 		RenderedSet: make(map[string]bool),
 	}
 
-	service := NewRenderService(rnd, logger)
+	service := NewRenderService(rnd, logger, nil, nil)
 
 	if service == nil {
 		t.Fatal("NewRenderService should not return nil")
@@ -248,3 +253,34 @@ func TestRenderService_RenderGraph(t *testing.T) {
 
 	service.RenderGraph("graph.html", data)
 }
+
+func TestRenderService_Hooks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "layout.html"), []byte(`<h1>{{ .Title }}</h1>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	destFs := afero.NewMemMapFs()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	registry := hooks.New()
+	registry.BeforeRender(func(path string, data *models.PageData) error {
+		data.Title = strings.ToUpper(data.Title)
+		return nil
+	})
+	registry.AfterRender(func(path string, html []byte) ([]byte, error) {
+		return append(html, "<!-- "+path+" -->"...), nil
+	})
+	bus := events.New()
+	var rendered []string
+	events.Subscribe(bus, func(e events.PageRendered) { rendered = append(rendered, e.Path) })
+
+	service := NewRenderService(renderer.New(false, destFs, []string{dir}, logger), logger, bus, registry)
+	service.RenderPage("public/post.html", models.PageData{Title: "hello"})
+
+	got, _ := afero.ReadFile(destFs, "public/post.html")
+	if want := "<h1>HELLO</h1><!-- public/post.html -->"; string(got) != want {
+		t.Errorf("page = %q, want %q", got, want)
+	}
+	if len(rendered) != 1 {
+		t.Errorf("PageRendered published %d times, want 1", len(rendered))
+	}
+}
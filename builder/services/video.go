@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// posterWidth is the widest poster frame kept
+const posterWidth = 1280
+
+// videoTypes are the formats browsers play as they are
+var videoTypes = map[string]string{".mp4": "video/mp4", ".m4v": "video/mp4", ".webm": "video/webm", ".ogv": "video/ogg"}
+
+// transcodeExts are formats transcoded to H.264 MP4 with ffmpeg
+var transcodeExts = map[string]bool{".mov": true, ".mkv": true, ".avi": true, ".wmv": true, ".flv": true, ".mpg": true, ".mpeg": true, ".3gp": true}
+
+type videoProviderImpl struct {
+	cfg      *config.Config
+	sourceFs afero.Fs
+	destFs   afero.Fs
+	renderer RenderService
+	logger   *slog.Logger
+
+	ffmpegOnce sync.Once
+	ffmpeg     string // Empty when ffmpeg is not installed
+}
+
+// NewVideoProvider serves video shortcodes. With ffmpeg on the PATH a poster
+// frame is taken from each video (<dir>/posters/<name>.webp) and formats
+// browsers can't play are transcoded to MP4 next to the source; both are
+// cached under <cacheDir>/video. Without ffmpeg, browser formats are still
+// embedded, just without a generated poster.
+func NewVideoProvider(cfg *config.Config, sourceFs, destFs afero.Fs, renderer RenderService, logger *slog.Logger) mdParser.VideoProvider {
+	return &videoProviderImpl{cfg: cfg, sourceFs: sourceFs, destFs: destFs, renderer: renderer, logger: logger}
+}
+
+// videoEntry is what the poster cache keeps next to each poster
+type videoEntry struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func (v *videoProviderImpl) findFFmpeg() string {
+	v.ffmpegOnce.Do(func() {
+		if p, err := exec.LookPath("ffmpeg"); err == nil {
+			v.ffmpeg = p
+		} else {
+			v.logger.Warn("ffmpeg not found: videos get no poster frame and only browser formats can be embedded")
+		}
+	})
+	return v.ffmpeg
+}
+
+func (v *videoProviderImpl) Video(src, poster string) (mdParser.VideoInfo, error) {
+	var out mdParser.VideoInfo
+	src, ok := staticPath(src)
+	if !ok {
+		return out, errors.New(`src must be inside the site's static/ directory, e.g. "static/videos/demo.mp4"`)
+	}
+	info, err := v.sourceFs.Stat(src)
+	if err != nil {
+		return out, err
+	}
+	ext := strings.ToLower(path.Ext(src))
+	key := blake3.Sum256(fmt.Appendf(nil, "%s-%d-%d", src, info.Size(), info.ModTime().UnixNano()))
+	cacheBase := filepath.Join(v.cfg.CacheDir, "video", hex.EncodeToString(key[:16]))
+	stem := strings.TrimSuffix(path.Base(src), path.Ext(src))
+
+	switch {
+	case videoTypes[ext] != "":
+		out.URL, out.Type = v.cfg.BaseURL+"/"+src, videoTypes[ext]
+	case transcodeExts[ext]:
+		if v.findFFmpeg() == "" {
+			return out, fmt.Errorf("ffmpeg is required to play %s files in browsers", ext)
+		}
+		rel := path.Join(path.Dir(src), stem+".mp4")
+		if err := v.publish(cacheBase+".mp4", rel, func(dest string) error { return v.transcode(src, dest) }); err != nil {
+			return out, fmt.Errorf("transcoding: %w", err)
+		}
+		out.URL, out.Type = v.cfg.BaseURL+"/"+rel, "video/mp4"
+	default:
+		return out, fmt.Errorf("unsupported video format %q", ext)
+	}
+
+	if poster != "" {
+		if poster, ok = staticPath(poster); !ok {
+			return out, errors.New("poster must be inside the site's static/ directory")
+		}
+		name, _ := publishedImage(path.Base(poster), v.cfg.CompressImages)
+		out.PosterURL = v.cfg.BaseURL + "/" + path.Join(path.Dir(poster), name)
+	}
+	if v.findFFmpeg() == "" {
+		return out, nil
+	}
+
+	// The frame gives the video's dimensions even when the page brings its
+	// own poster; it is only published when it is used
+	var entry videoEntry
+	frame, err := os.ReadFile(cacheBase + ".webp")
+	if err == nil {
+		var meta []byte
+		if meta, err = os.ReadFile(cacheBase + ".json"); err == nil {
+			err = json.Unmarshal(meta, &entry)
+		}
+	}
+	if err != nil {
+		if frame, entry, err = v.posterFrame(src); err != nil {
+			v.logger.Warn("No poster frame for video", "path", src, "error", err)
+			return out, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(cacheBase), 0755); err == nil {
+			meta, _ := json.Marshal(entry)
+			_ = os.WriteFile(cacheBase+".webp", frame, 0644)
+			_ = os.WriteFile(cacheBase+".json", meta, 0644)
+		}
+	}
+	out.Width, out.Height = entry.Width, entry.Height
+	if out.PosterURL == "" {
+		rel := path.Join(path.Dir(src), "posters", stem+".webp")
+		dest := filepath.Join(v.cfg.OutputDir, filepath.FromSlash(rel))
+		if err := utils.WriteFileVFS(v.destFs, dest, frame); err != nil {
+			return out, err
+		}
+		v.renderer.RegisterFile(dest)
+		out.PosterURL = v.cfg.BaseURL + "/" + rel
+	}
+	return out, nil
+}
+
+// publish copies a cached file to rel in the output, making it with build first
+// when it isn't cached yet
+func (v *videoProviderImpl) publish(cached, rel string, build func(dest string) error) error {
+	if _, err := os.Stat(cached); err != nil {
+		if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+			return err
+		}
+		tmp := cached + ".tmp"
+		if err := build(tmp); err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, cached); err != nil {
+			return err
+		}
+	}
+
+	in, err := os.Open(cached)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	dest := filepath.Join(v.cfg.OutputDir, filepath.FromSlash(rel))
+	if err := v.destFs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := v.destFs.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, in); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	v.renderer.RegisterFile(dest)
+	return nil
+}
+
+// transcode converts src to an H.264/AAC MP4 that starts playing before it
+// has fully downloaded
+func (v *videoProviderImpl) transcode(src, dest string) error {
+	in, cleanup, err := v.localFile(src)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return v.run(nil, "-i", in, "-c:v", "libx264", "-preset", "medium", "-crf", "23", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "128k", "-movflags", "+faststart", "-f", "mp4", "-y", dest)
+}
+
+// posterFrame grabs the frame one second in (the first frame is often black),
+// falling back to the first for shorter clips
+func (v *videoProviderImpl) posterFrame(src string) ([]byte, videoEntry, error) {
+	var entry videoEntry
+	in, cleanup, err := v.localFile(src)
+	if err != nil {
+		return nil, entry, err
+	}
+	defer cleanup()
+
+	var png bytes.Buffer
+	for _, at := range []string{"1", "0"} {
+		png.Reset()
+		if err := v.run(&png, "-ss", at, "-i", in, "-frames:v", "1", "-f", "image2pipe", "-c:v", "png", "-"); err != nil {
+			return nil, entry, err
+		}
+		if png.Len() > 0 {
+			break
+		}
+	}
+	img, err := imaging.Decode(&png)
+	if err != nil {
+		return nil, entry, err
+	}
+	entry.Width, entry.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	if entry.Width > posterWidth {
+		img = imaging.Resize(img, posterWidth, 0, imaging.Lanczos)
+	}
+
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, &webp.Options{Quality: 80}); err != nil {
+		return nil, entry, err
+	}
+	return buf.Bytes(), entry, nil
+}
+
+// run runs ffmpeg, sending its output to stdout when given
+func (v *videoProviderImpl) run(stdout io.Writer, args ...string) error {
+	cmd := exec.Command(v.ffmpeg, append([]string{"-hide_banner", "-loglevel", "error", "-nostdin"}, args...)...)
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// localFile returns a path ffmpeg can read src from, copying it out of
+// virtual filesystems (content mounts) into a temporary file
+func (v *videoProviderImpl) localFile(src string) (string, func(), error) {
+	if _, ok := v.sourceFs.(*afero.OsFs); ok {
+		return src, func() {}, nil
+	}
+	in, err := v.sourceFs.Open(src)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() { _ = in.Close() }()
+	tmp, err := os.CreateTemp("", "kosh-video-*"+path.Ext(src))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.Remove(tmp.Name()) }
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
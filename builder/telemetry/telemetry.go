@@ -0,0 +1,83 @@
+// Package telemetry exports OpenTelemetry traces of builds. Tracing is off
+// unless KOSH_OTEL_ENDPOINT names an OTLP/HTTP collector (Jaeger, Tempo, an
+// OpenTelemetry Collector, ...); without it every span is a no-op.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EnvEndpoint is the environment variable holding the OTLP/HTTP collector's
+// base URL, e.g. http://localhost:4318. Like OTEL_EXPORTER_OTLP_ENDPOINT,
+// /v1/traces is appended unless the URL already names it.
+const EnvEndpoint = "KOSH_OTEL_ENDPOINT"
+
+const tracerName = "github.com/Kush-Singh-26/kosh/builder"
+
+var (
+	setupOnce sync.Once
+	provider  *sdktrace.TracerProvider
+	setupErr  error
+)
+
+// Setup installs the OTLP trace exporter when KOSH_OTEL_ENDPOINT is set. It
+// runs once per process (a workspace build shares one exporter across
+// sites); call Shutdown before exiting to flush pending spans.
+func Setup() error {
+	endpoint := os.Getenv(EnvEndpoint)
+	if endpoint == "" {
+		return nil
+	}
+	setupOnce.Do(func() {
+		exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(tracesURL(endpoint)))
+		if err != nil {
+			setupErr = fmt.Errorf("failed to create OTLP exporter for %s: %w", endpoint, err)
+			return
+		}
+		// Schemaless, so merging never conflicts with the SDK's default schema
+		res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName("kosh")))
+		if err != nil {
+			res = resource.Default()
+		}
+		provider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(provider)
+	})
+	return setupErr
+}
+
+// tracesURL turns a collector base URL into its trace ingest URL
+func tracesURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if strings.HasSuffix(endpoint, "/v1/traces") {
+		return endpoint
+	}
+	return endpoint + "/v1/traces"
+}
+
+// Shutdown flushes and stops the exporter. It is a no-op when tracing is off.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Start opens a span named name as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
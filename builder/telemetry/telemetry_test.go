@@ -0,0 +1,42 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetup_DisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv(EnvEndpoint, "")
+
+	if err := Setup(); err != nil {
+		t.Fatalf("Setup() without %s = %v, want nil", EnvEndpoint, err)
+	}
+	ctx, span := Start(context.Background(), "build")
+	defer span.End()
+	if span.SpanContext().IsValid() {
+		t.Error("spans should be no-ops when tracing is off")
+	}
+	if ctx == nil {
+		t.Error("Start returned a nil context")
+	}
+	if err := Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() = %v", err)
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"http://localhost:4318", "http://localhost:4318/v1/traces"},
+		{"http://localhost:4318/", "http://localhost:4318/v1/traces"},
+		{"https://tempo.example.com/otlp", "https://tempo.example.com/otlp/v1/traces"},
+		{"http://localhost:4318/v1/traces", "http://localhost:4318/v1/traces"},
+	}
+	for _, tt := range tests {
+		if got := tracesURL(tt.endpoint); got != tt.want {
+			t.Errorf("tracesURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/evanw/esbuild/pkg/api"
@@ -15,50 +18,154 @@ import (
 	"github.com/zeebo/blake3"
 )
 
-func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string, minify bool, onWrite func(string), cacheDir string, force bool) (map[string]string, error) {
+// AssetOptions tells BuildAssetsEsbuild how to build the CSS and JS
+type AssetOptions struct {
+	Minify   bool                // Minify, and add a content hash to file names
+	BundleJS bool                // Inline the imports of JS files
+	Bundles  map[string][]string // Outputs joining several files, all relative to srcDir
+	Sass     SassOptions
+	// SiteDir is a site's own static directory, laid over srcDir: its
+	// stylesheets and scripts are built too, and replace those of srcDir
+	// with the same path
+	SiteDir string
+	// Media maps image URL paths to their content-addressed ones
+	// (images.dedupe); stylesheets and scripts link those instead
+	Media map[string]string
+}
+
+// BuildAssetsEsbuild bundles the CSS and JS of srcDir into destDir and
+// returns the asset map ("/static/css/main.css" → its published, possibly
+// hashed, path). Sass entry points (not _partials) are compiled with Dart
+// Sass first and keyed by their .css name, so a theme can switch a
+// stylesheet to Sass without touching its templates. Each of opts.Bundles
+// is keyed by its own name. The files of opts.SiteDir are keyed the same
+// way as those of srcDir, which they shadow.
+func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string, onWrite func(string), cacheDir string, force bool, opts AssetOptions) (map[string]string, error) {
 	srcDir = NormalizePath(srcDir)
 	destDir = NormalizePath(destDir)
 	assets := make(map[string]string)
+	minify, sass := opts.Minify, opts.Sass
 
-	var jsEntryPoints []string
-	var cssEntryPoints []string
+	// Entry points by their path relative to the directory they're in, with
+	// the site's replacing the theme's
+	type entry struct {
+		base, path string
+		js         bool
+	}
+	entries := make(map[string]entry)
 
 	// Calculate input hash
 	inputHash := blake3.New()
 
 	// Find entry points
-	err := afero.Walk(srcFs, srcDir, func(path string, info fs.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
+	scan := func(base string) error {
+		return afero.Walk(srcFs, base, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			baseName := filepath.Base(path)
+
+			// Skip files that must be copied directly without esbuild processing
+			// wasm_engine.js - loaded directly by HTML, defines global variables
+			// engine.js - loaded by wasm_engine.js, expects exact filename
+			if baseName == "wasm_engine.js" || baseName == "engine.js" {
+				return nil
+			}
+
+			rel, _ := SafeRel(base, NormalizePath(path))
+			switch ext {
+			case ".js":
+				entries[rel] = entry{base: base, path: path, js: true}
+			case ".css":
+				entries[rel] = entry{base: base, path: path}
+			case ".scss", ".sass":
+				if !isSassPartial(path) {
+					entries[rel] = entry{base: base, path: path}
+				}
+			}
+
+			// Add to hash (path + mtime + size)
+			if _, err := fmt.Fprintf(inputHash, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano()); err != nil {
+				return fmt.Errorf("failed to write to input hash: %w", err)
+			}
 			return nil
+		})
+	}
+	if err := scan(srcDir); err != nil {
+		return nil, fmt.Errorf("failed to scan for assets: %w", err)
+	}
+	siteDir := ""
+	if opts.SiteDir != "" {
+		siteDir = NormalizePath(opts.SiteDir)
+		if exists, _ := afero.DirExists(srcFs, siteDir); exists && siteDir != srcDir {
+			if err := scan(siteDir); err != nil {
+				return nil, fmt.Errorf("failed to scan for site assets: %w", err)
+			}
 		}
-		ext := strings.ToLower(filepath.Ext(path))
-		baseName := filepath.Base(path)
+	}
 
-		// Skip files that must be copied directly without esbuild processing
-		// wasm_engine.js - loaded directly by HTML, defines global variables
-		// engine.js - loaded by wasm_engine.js, expects exact filename
-		if baseName == "wasm_engine.js" || baseName == "engine.js" {
-			return nil
+	var jsEntryPoints, cssEntryPoints, siteJSEntryPoints, siteCSSEntryPoints []string
+	for _, rel := range slices.Sorted(maps.Keys(entries)) {
+		e := entries[rel]
+		switch {
+		case e.base == srcDir && e.js:
+			jsEntryPoints = append(jsEntryPoints, e.path)
+		case e.base == srcDir:
+			cssEntryPoints = append(cssEntryPoints, e.path)
+		case e.js:
+			siteJSEntryPoints = append(siteJSEntryPoints, e.path)
+		default:
+			siteCSSEntryPoints = append(siteCSSEntryPoints, e.path)
 		}
+	}
 
-		switch ext {
-		case ".js":
-			jsEntryPoints = append(jsEntryPoints, path)
-		case ".css":
-			cssEntryPoints = append(cssEntryPoints, path)
+	var plugins []api.Plugin
+	if slices.ContainsFunc(cssEntryPoints, isSass) || slices.ContainsFunc(siteCSSEntryPoints, isSass) {
+		bin, err := sassBinary(sass)
+		if err != nil {
+			return nil, err
 		}
+		plugins = append(plugins, sassPlugin(bin, sass.LoadPaths))
+		// Partials in the load paths change the output as much as the theme's own
+		for _, dir := range sass.LoadPaths {
+			err := afero.Walk(srcFs, dir, func(path string, info fs.FileInfo, err error) error {
+				if err != nil || info.IsDir() || !IsStylesheet(path) {
+					return err
+				}
+				_, err = fmt.Fprintf(inputHash, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+				return err
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan sass load path %s: %w", dir, err)
+			}
+		}
+	}
 
-		// Add to hash (path + mtime + size)
-		if _, err := fmt.Fprintf(inputHash, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano()); err != nil {
-			return fmt.Errorf("failed to write to input hash: %w", err)
+	media := mediaLinks(opts.Media)
+	if len(media) > 0 {
+		var bases []string
+		for _, dir := range []string{srcDir, siteDir} {
+			if dir == "" {
+				continue
+			}
+			if abs, err := filepath.Abs(dir); err == nil {
+				bases = append(bases, abs)
+			}
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan for assets: %w", err)
+		plugins = append(plugins, media.plugin(bases))
+	}
+
+	// The options change the output as much as the files do
+	_, _ = fmt.Fprintf(inputHash, "minify=%t;bundleJS=%t;", minify, opts.BundleJS)
+	for _, p := range slices.Sorted(maps.Keys(media)) {
+		_, _ = fmt.Fprintf(inputHash, "media=%s:%s;", p, media[p])
+	}
+	for _, name := range slices.Sorted(maps.Keys(opts.Bundles)) {
+		_, _ = fmt.Fprintf(inputHash, "bundle=%s:%s;", name, strings.Join(opts.Bundles[name], ","))
 	}
 
 	currentHash := hex.EncodeToString(inputHash.Sum(nil))
@@ -115,7 +222,37 @@ func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string,
 		}
 	}
 
-	process := func(entryPoints []string, bundle bool) error {
+	// write publishes an output file of esbuild and keeps a copy in the cache
+	write := func(fullPath string, contents []byte) error {
+		// Compute relative path from destDir for VFS
+		relPath, err := filepath.Rel(destDir, fullPath)
+		if err != nil {
+			return fmt.Errorf("failed to compute relative path for %s: %w", fullPath, err)
+		}
+		vfsPath := filepath.Join(destDir, relPath)
+
+		dir := filepath.Dir(vfsPath)
+		if err := destFs.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(destFs, vfsPath, contents, 0644); err != nil {
+			return err
+		}
+		if onWrite != nil {
+			onWrite(vfsPath)
+		}
+
+		// Cache the output file
+		if cachePath != "" {
+			// relPath is css/main.css under public/static
+			cacheFile := filepath.Join(cachePath, relPath)
+			_ = os.MkdirAll(filepath.Dir(cacheFile), 0755)
+			_ = os.WriteFile(cacheFile, contents, 0644)
+		}
+		return nil
+	}
+
+	process := func(entryPoints []string, bundle bool, base string) error {
 		if len(entryPoints) == 0 {
 			return nil
 		}
@@ -124,12 +261,13 @@ func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string,
 			Bundle:            bundle,
 			Write:             false,
 			Outdir:            destDir,
-			Outbase:           srcDir,
+			Outbase:           base,
 			MinifyWhitespace:  minify,
 			MinifyIdentifiers: minify,
 			MinifySyntax:      minify,
 			Sourcemap:         api.SourceMapExternal,
 			Metafile:          true,
+			Plugins:           plugins,
 			Loader: map[string]api.Loader{
 				".woff2": api.LoaderFile,
 				".woff":  api.LoaderFile,
@@ -154,37 +292,9 @@ func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string,
 		}
 
 		for _, outFile := range result.OutputFiles {
-			fullPath := NormalizePath(outFile.Path)
-			// Compute relative path from destDir for VFS
-			relPath, err := filepath.Rel(destDir, fullPath)
-			if err != nil {
-				return fmt.Errorf("failed to compute relative path for %s: %w", fullPath, err)
-			}
-			vfsPath := filepath.Join(destDir, relPath)
-
-			dir := filepath.Dir(vfsPath)
-			if err := destFs.MkdirAll(dir, 0755); err != nil {
+			if err := write(NormalizePath(outFile.Path), outFile.Contents); err != nil {
 				return err
 			}
-			if err := afero.WriteFile(destFs, vfsPath, outFile.Contents, 0644); err != nil {
-				return err
-			}
-			if onWrite != nil {
-				onWrite(vfsPath)
-			}
-
-			// Cache the output file
-			if cachePath != "" {
-				// Relativize path from destDir (public/static)
-				// vfsPath is public/static/css/main.css
-				// rel is css/main.css
-				rel, err := filepath.Rel(destDir, vfsPath)
-				if err == nil {
-					cacheFile := filepath.Join(cachePath, rel)
-					_ = os.MkdirAll(filepath.Dir(cacheFile), 0755)
-					_ = os.WriteFile(cacheFile, outFile.Contents, 0644)
-				}
-			}
 		}
 
 		// Use Metafile to map inputs to outputs correctly
@@ -209,8 +319,11 @@ func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string,
 			// We want the key to be "/static/js/main.js" for compatibility
 
 			entryPointAbs, _ := filepath.Abs(outInfo.EntryPoint)
-			relEntryPoint, _ := SafeRel(srcDir, NormalizePath(entryPointAbs))
+			relEntryPoint, _ := SafeRel(base, NormalizePath(entryPointAbs))
 			relEntryPoint = strings.TrimPrefix(filepath.ToSlash(relEntryPoint), "/")
+			if isSass(relEntryPoint) {
+				relEntryPoint = strings.TrimSuffix(relEntryPoint, filepath.Ext(relEntryPoint)) + ".css"
+			}
 
 			key := "/static/" + relEntryPoint
 
@@ -227,14 +340,36 @@ func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string,
 		return nil
 	}
 
-	// Process CSS with bundling (for @import and fonts)
-	if err := process(cssEntryPoints, true); err != nil {
-		return nil, err
+	// Process CSS with bundling (for @import and fonts), then JS without
+	// bundling by default (to avoid wrapping standalone libraries); the
+	// site's files go last so they replace the theme's outputs
+	for _, batch := range []struct {
+		entryPoints []string
+		bundle      bool
+		base        string
+	}{
+		{cssEntryPoints, true, srcDir},
+		{jsEntryPoints, opts.BundleJS, srcDir},
+		{siteCSSEntryPoints, true, siteDir},
+		{siteJSEntryPoints, opts.BundleJS, siteDir},
+	} {
+		if err := process(batch.entryPoints, batch.bundle, batch.base); err != nil {
+			return nil, err
+		}
 	}
 
-	// Process JS without bundling (to avoid wrapping standalone libraries)
-	if err := process(jsEntryPoints, false); err != nil {
-		return nil, err
+	for _, name := range slices.Sorted(maps.Keys(opts.Bundles)) {
+		files, err := buildBundle(srcFs, srcDir, destDir, name, opts.Bundles[name], minify, plugins, media)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if err := write(f.Path, f.Contents); err != nil {
+				return nil, err
+			}
+		}
+		published, _ := filepath.Rel(destDir, files[0].Path)
+		assets["/static/"+path.Clean(name)] = "/static/" + filepath.ToSlash(published)
 	}
 
 	// Save map to cache
@@ -245,3 +380,84 @@ func BuildAssetsEsbuild(srcFs afero.Fs, destFs afero.Fs, srcDir, destDir string,
 
 	return assets, nil
 }
+
+// buildBundle joins the files of a bundle into destDir/name, with a content
+// hash in the name when minified. Stylesheets are bundled through @import,
+// so their url()s, fonts and Sass work as in any stylesheet; scripts are
+// concatenated, each still a classic script. The bundle is the first file
+// returned, followed by the assets it references. Bundles have no source
+// maps.
+func buildBundle(srcFs afero.Fs, srcDir, destDir, name string, files []string, minify bool, plugins []api.Plugin, media mediaLinks) ([]api.OutputFile, error) {
+	var contents strings.Builder
+	css := strings.EqualFold(filepath.Ext(name), ".css")
+	for _, f := range files {
+		p := filepath.Join(srcDir, filepath.FromSlash(f))
+		if css {
+			if _, err := srcFs.Stat(p); err != nil {
+				return nil, fmt.Errorf("bundle %s: %w", name, err)
+			}
+			fmt.Fprintf(&contents, "@import %q;\n", filepath.ToSlash(p))
+			continue
+		}
+		data, err := afero.ReadFile(srcFs, p)
+		if err != nil {
+			return nil, fmt.Errorf("bundle %s: %w", name, err)
+		}
+		contents.WriteString(media.rewriteScript(string(data)))
+		contents.WriteString("\n;\n") // A file without a trailing semicolon mustn't run into the next
+	}
+
+	loader := api.LoaderJS
+	if css {
+		loader = api.LoaderCSS
+	}
+	result := api.Build(api.BuildOptions{
+		Stdin: &api.StdinOptions{
+			Contents:   contents.String(),
+			ResolveDir: srcDir,
+			Sourcefile: name,
+			Loader:     loader,
+		},
+		Bundle:            css,
+		Write:             false,
+		Outfile:           filepath.Join(destDir, filepath.FromSlash(name)),
+		MinifyWhitespace:  minify,
+		MinifyIdentifiers: minify,
+		MinifySyntax:      minify,
+		Plugins:           plugins,
+		AssetNames:        "assets/[name].[hash]",
+		Loader: map[string]api.Loader{
+			".woff2": api.LoaderFile,
+			".woff":  api.LoaderFile,
+			".ttf":   api.LoaderFile,
+			".png":   api.LoaderFile,
+			".webp":  api.LoaderFile,
+			".svg":   api.LoaderFile,
+		},
+	})
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			slog.Error("esbuild error", "bundle", name, "message", e.Text)
+		}
+		return nil, fmt.Errorf("bundle %s: esbuild failed with %d errors", name, len(result.Errors))
+	}
+
+	out := slices.Clone(result.OutputFiles)
+	main := slices.IndexFunc(out, func(f api.OutputFile) bool {
+		return strings.EqualFold(filepath.Ext(f.Path), filepath.Ext(name))
+	})
+	if main < 0 {
+		return nil, fmt.Errorf("bundle %s: esbuild wrote no %s file", name, filepath.Ext(name))
+	}
+	out[0], out[main] = out[main], out[0]
+	out[0].Path = NormalizePath(out[0].Path)
+	if minify {
+		sum := blake3.Sum256(out[0].Contents)
+		ext := filepath.Ext(out[0].Path)
+		out[0].Path = strings.TrimSuffix(out[0].Path, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+	}
+	for i := range out[1:] {
+		out[i+1].Path = NormalizePath(out[i+1].Path)
+	}
+	return out, nil
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestBuildAssetsBundles(t *testing.T) {
+	src := writeAssets(t, map[string]string{
+		"css/base.css":    "body { margin: 0 }\n",
+		"css/extra.css":   ".card { padding: 1rem }\n",
+		"js/a.js":         "function hello() { return 1 }",
+		"js/b.js":         "console.log(hello())\n",
+		"js/wasm_exec.js": "// copied\n",
+	})
+	destFs := afero.NewMemMapFs()
+	dest := "/public/static"
+	opts := AssetOptions{Bundles: map[string][]string{
+		"css/site.css": {"css/base.css", "css/extra.css"},
+		"js/app.js":    {"js/a.js", "js/b.js"},
+	}}
+
+	assets, err := BuildAssetsEsbuild(afero.NewOsFs(), destFs, src, dest, nil, "", false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assets["/static/css/site.css"]; got != "/static/css/site.css" {
+		t.Errorf(`assets["/static/css/site.css"] = %q`, got)
+	}
+	css, _ := afero.ReadFile(destFs, dest+"/css/site.css")
+	if !strings.Contains(string(css), "margin: 0") || !strings.Contains(string(css), ".card") {
+		t.Errorf("site.css doesn't join both stylesheets:\n%s", css)
+	}
+	js, _ := afero.ReadFile(destFs, dest+"/js/app.js")
+	if i, j := strings.Index(string(js), "function hello"), strings.Index(string(js), "console.log"); i < 0 || j < i {
+		t.Errorf("app.js doesn't join the scripts in order:\n%s", js)
+	}
+	if _, ok := assets["/static/css/base.css"]; !ok {
+		t.Error("files of a bundle are no longer published on their own")
+	}
+
+	// Minified bundles get a content hash, like any bundle
+	opts.Minify = true
+	assets, err = BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), src, dest, nil, "", false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assets["/static/js/app.js"]; !strings.HasPrefix(got, "/static/js/app.") || got == "/static/js/app.js" {
+		t.Errorf("minified app.js published as %q", got)
+	}
+	if got := assets["/static/css/site.css"]; !strings.HasSuffix(got, ".css") || got == "/static/css/site.css" {
+		t.Errorf("minified site.css published as %q", got)
+	}
+
+	opts.Bundles = map[string][]string{"js/app.js": {"js/missing.js"}}
+	if _, err := BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), src, dest, nil, "", false, opts); err == nil || !strings.Contains(err.Error(), "js/app.js") {
+		t.Errorf("missing bundle file: err = %v", err)
+	}
+}
+
+func TestBuildAssetsBundleJS(t *testing.T) {
+	src := writeAssets(t, map[string]string{
+		"js/main.js":      "import { greet } from './lib/greet.js'\ngreet()\n",
+		"js/lib/greet.js": "export function greet() { console.log('hi') }\n",
+	})
+	destFs := afero.NewMemMapFs()
+	dest := "/public/static"
+
+	if _, err := BuildAssetsEsbuild(afero.NewOsFs(), destFs, src, dest, nil, "", false, AssetOptions{BundleJS: true}); err != nil {
+		t.Fatal(err)
+	}
+	js, _ := afero.ReadFile(destFs, dest+"/js/main.js")
+	if strings.Contains(string(js), "import ") || !strings.Contains(string(js), "console.log") {
+		t.Errorf("main.js imports weren't inlined:\n%s", js)
+	}
+}
+
+func TestBuildAssetsCacheKeyHasOptions(t *testing.T) {
+	src := writeAssets(t, map[string]string{"css/main.css": "body {\n  margin: 0;\n}\n"})
+	cacheDir := t.TempDir()
+	dest := "/public/static"
+
+	plain, err := BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), src, dest, nil, cacheDir, false, AssetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	minified, err := BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), src, dest, nil, cacheDir, false, AssetOptions{Minify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain["/static/css/main.css"] == minified["/static/css/main.css"] {
+		t.Errorf("turning minification on reused the cached bundles: %q", minified["/static/css/main.css"])
+	}
+}
+
+func TestBuildAssetsSiteDirShadowsTheme(t *testing.T) {
+	theme := writeAssets(t, map[string]string{
+		"css/main.css": "body { color: red; }\n",
+		"js/app.js":    "console.log('theme')\n",
+	})
+	site := writeAssets(t, map[string]string{
+		"css/main.css":  "body { color: blue; }\n",
+		"css/extra.css": "p { margin: 0; }\n",
+	})
+	destFs := afero.NewMemMapFs()
+	dest := "/public/static"
+
+	assets, err := BuildAssetsEsbuild(afero.NewOsFs(), destFs, theme, dest, nil, "", false, AssetOptions{SiteDir: site})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"/static/css/main.css", "/static/css/extra.css", "/static/js/app.js"} {
+		if assets[key] != key {
+			t.Errorf("assets[%q] = %q", key, assets[key])
+		}
+	}
+	css, _ := afero.ReadFile(destFs, dest+"/css/main.css")
+	if !strings.Contains(string(css), "blue") || strings.Contains(string(css), "red") {
+		t.Errorf("main.css isn't the site's:\n%s", css)
+	}
+}
+
+func TestBuildAssetsMediaLinks(t *testing.T) {
+	src := writeAssets(t, map[string]string{
+		"css/main.css":     ".hero { background: url(../images/hero.png) } .logo { background: url(\"/static/images/logo.svg#mark\") } .x { background: url(../images/other.png) }\n",
+		"js/app.js":        "const logo = \"/static/images/logo.svg\", other = '/static/images/other.svg'\nconsole.log(logo, other)\n",
+		"images/hero.png":  "PNG",
+		"images/other.png": "PNG",
+	})
+	destFs := afero.NewMemMapFs()
+	dest := "/public/static"
+	opts := AssetOptions{
+		Media: map[string]string{
+			"/static/images/hero.webp": "/static/media/hero.1a2b3c4d5e.webp",
+			"/static/images/logo.svg":  "/static/media/logo.0f0f0f0f0f.svg",
+		},
+		Bundles: map[string][]string{"js/all.js": {"js/app.js"}},
+	}
+
+	if _, err := BuildAssetsEsbuild(afero.NewOsFs(), destFs, src, dest, nil, "", false, opts); err != nil {
+		t.Fatal(err)
+	}
+	css, _ := afero.ReadFile(destFs, dest+"/css/main.css")
+	for _, want := range []string{"url(/static/media/hero.1a2b3c4d5e.webp)", "url(/static/media/logo.0f0f0f0f0f.svg#mark)", "/other-"} {
+		if !strings.Contains(string(css), want) {
+			t.Errorf("main.css doesn't contain %q:\n%s", want, css)
+		}
+	}
+	for _, file := range []string{"/js/app.js", "/js/all.js"} {
+		js, _ := afero.ReadFile(destFs, dest+file)
+		if !strings.Contains(string(js), `"/static/media/logo.0f0f0f0f0f.svg"`) || !strings.Contains(string(js), "/static/images/other.svg") {
+			t.Errorf("%s doesn't link the deduplicated logo only:\n%s", file, js)
+		}
+	}
+}
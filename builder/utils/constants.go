@@ -2,6 +2,7 @@ package utils
 
 import (
 	"runtime"
+	"sync/atomic"
 )
 
 // Default constants - these are used as fallbacks
@@ -17,14 +18,36 @@ const (
 // Legacy constant for backward compatibility
 const DefaultWorkerCountMax = 12
 
+// LowMemoryWorkerCountMax caps the default worker count in low-memory mode,
+// since every worker holds a page and its buffers
+const LowMemoryWorkerCountMax = 2
+
+// lowMemory is set by SetLowMemory
+var lowMemory atomic.Bool
+
 // GetDefaultWorkerCount returns the default worker count based on CPU cores
 func GetDefaultWorkerCount() int {
 	workers := runtime.NumCPU()
 	if workers < 2 {
 		return 2
 	}
+	if lowMemory.Load() {
+		return LowMemoryWorkerCountMax
+	}
 	if workers > DefaultWorkerCountMax {
 		return DefaultWorkerCountMax
 	}
 	return workers
 }
+
+// WorkerCount resolves a configured pool size, falling back to
+// GetDefaultWorkerCount when it is unset (0 or less)
+func WorkerCount(configured int) int {
+	if configured <= 0 {
+		return GetDefaultWorkerCount()
+	}
+	if configured > MaxWorkers {
+		return MaxWorkers
+	}
+	return configured
+}
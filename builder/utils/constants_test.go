@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestWorkerCount(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		want       int
+	}{
+		{"unset uses default", 0, GetDefaultWorkerCount()},
+		{"negative uses default", -3, GetDefaultWorkerCount()},
+		{"explicit value", 5, 5},
+		{"capped at MaxWorkers", 100, MaxWorkers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WorkerCount(tt.configured); got != tt.want {
+				t.Errorf("WorkerCount(%d) = %d, want %d", tt.configured, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+const (
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+	exifTypeASCII           = 2
+)
+
+// ExifDate returns the capture date of a JPEG from its EXIF data:
+// DateTimeOriginal, falling back to DateTime. ok is false for other formats
+// and for photos without a date.
+func ExifDate(data []byte) (t time.Time, ok bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return time.Time{}, false
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return time.Time{}, false
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break // Image data starts: no EXIF segment
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffDate(segment[6:])
+		}
+		i += 2 + size
+	}
+	return time.Time{}, false
+}
+
+// tiffDate reads the date tags from the TIFF structure inside an EXIF segment
+func tiffDate(tiff []byte) (time.Time, bool) {
+	if len(tiff) < 8 {
+		return time.Time{}, false
+	}
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return time.Time{}, false
+	}
+
+	// tags reads the entries of the IFD at off into tag -> raw entry
+	tags := func(off uint32) map[uint16][]byte {
+		if int(off)+2 > len(tiff) {
+			return nil
+		}
+		n := int(bo.Uint16(tiff[off:]))
+		entries := make(map[uint16][]byte, n)
+		for i := 0; i < n; i++ {
+			start := int(off) + 2 + i*12
+			if start+12 > len(tiff) {
+				break
+			}
+			entries[bo.Uint16(tiff[start:])] = tiff[start : start+12]
+		}
+		return entries
+	}
+	ascii := func(entry []byte) string {
+		if entry == nil || bo.Uint16(entry[2:]) != exifTypeASCII {
+			return ""
+		}
+		count := int(bo.Uint32(entry[4:]))
+		value := entry[8:12]
+		if count > 4 {
+			off := int(bo.Uint32(entry[8:]))
+			if off+count > len(tiff) {
+				return ""
+			}
+			value = tiff[off : off+count]
+		} else {
+			value = value[:count]
+		}
+		return strings.TrimRight(string(value), "\x00 ")
+	}
+
+	ifd0 := tags(bo.Uint32(tiff[4:]))
+	date := ""
+	if ptr, ok := ifd0[exifTagExifIFD]; ok {
+		date = ascii(tags(bo.Uint32(ptr[8:]))[exifTagDateTimeOriginal])
+	}
+	if date == "" {
+		date = ascii(ifd0[exifTagDateTime])
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", date)
+	return t, err == nil
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// exifJPEG builds a JPEG header whose EXIF has DateTime in IFD0 and
+// DateTimeOriginal in the Exif IFD, each left out when empty
+func exifJPEG(bo binary.ByteOrder, dateTime, original string) []byte {
+	type entry struct {
+		tag   uint16
+		value string
+	}
+	var ifd0 []entry
+	if dateTime != "" {
+		ifd0 = append(ifd0, entry{exifTagDateTime, dateTime})
+	}
+
+	tiff := make([]byte, 8)
+	if bo == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	bo.PutUint16(tiff[2:], 42)
+	bo.PutUint32(tiff[4:], 8)
+
+	n, exifSize := len(ifd0), 0
+	if original != "" {
+		n, exifSize = n+1, 2+12+4
+	}
+	exifOff := 8 + 2 + 12*n + 4
+	dataOff := exifOff + exifSize
+
+	var data []byte
+	str := func(s string) (count, off uint32) {
+		off = uint32(dataOff + len(data))
+		data = append(data, s...)
+		data = append(data, 0)
+		return uint32(len(s) + 1), off
+	}
+	putEntry := func(b []byte, tag, typ uint16, count, value uint32) []byte {
+		e := make([]byte, 12)
+		bo.PutUint16(e, tag)
+		bo.PutUint16(e[2:], typ)
+		bo.PutUint32(e[4:], count)
+		bo.PutUint32(e[8:], value)
+		return append(b, e...)
+	}
+
+	ifd := make([]byte, 2)
+	bo.PutUint16(ifd, uint16(n))
+	for _, e := range ifd0 {
+		count, off := str(e.value)
+		ifd = putEntry(ifd, e.tag, exifTypeASCII, count, off)
+	}
+	var exif []byte
+	if original != "" {
+		ifd = putEntry(ifd, exifTagExifIFD, 4, 1, uint32(exifOff))
+		exif = []byte{0, 0}
+		bo.PutUint16(exif, 1)
+		count, off := str(original)
+		exif = putEntry(exif, exifTagDateTimeOriginal, exifTypeASCII, count, off)
+		exif = append(exif, 0, 0, 0, 0) // No next IFD
+	}
+	ifd = append(ifd, 0, 0, 0, 0)
+
+	tiff = append(append(append(tiff, ifd...), exif...), data...)
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 'J', 'F'} // A JFIF segment first
+	out = append(out, 0xFF, 0xE1, 0, 0)
+	binary.BigEndian.PutUint16(out[len(out)-2:], uint16(len(app1)+2))
+	out = append(out, app1...)
+	return append(out, 0xFF, 0xDA, 0, 2)
+}
+
+func TestExifDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		want   string
+		wantOK bool
+	}{
+		{"original little endian", exifJPEG(binary.LittleEndian, "2020:01:01 00:00:00", "2024:05:01 10:30:00"), "2024-05-01T10:30:00Z", true},
+		{"original big endian", exifJPEG(binary.BigEndian, "", "2023:12:24 18:00:05"), "2023-12-24T18:00:05Z", true},
+		{"DateTime fallback", exifJPEG(binary.LittleEndian, "2021:07:04 09:15:00", ""), "2021-07-04T09:15:00Z", true},
+		{"no date", exifJPEG(binary.LittleEndian, "", ""), "", false},
+		{"unset date", exifJPEG(binary.LittleEndian, "0000:00:00 00:00:00", ""), "", false},
+		{"png", []byte("\x89PNG\r\n\x1a\n...."), "", false},
+		{"truncated", exifJPEG(binary.LittleEndian, "", "2024:05:01 10:30:00")[:30], "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExifDate(tt.data)
+			if ok != tt.wantOK || (ok && got.Format(time.RFC3339) != tt.want) {
+				t.Errorf("ExifDate = %v, %v; want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/Kush-Singh-26/kosh/builder/models"
 )
@@ -54,3 +56,25 @@ func GetBool(m map[string]interface{}, k string) bool {
 	}
 	return false
 }
+
+// GetTime reads a frontmatter date: YYYY-MM-DD (midnight UTC) or an RFC 3339
+// time such as 2024-06-01T09:00:00+02:00. It is zero when unset or invalid.
+func GetTime(m map[string]interface{}, k string) time.Time {
+	switch v := m[k].(type) {
+	case time.Time:
+		return v
+	case string:
+		t, _ := ParseDate(v)
+		return t
+	}
+	return time.Time{}
+}
+
+// ParseDate parses a frontmatter date as GetTime does
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -272,3 +272,44 @@ func TestGetBool(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTime(t *testing.T) {
+	parsed := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		m        map[string]interface{}
+		expected time.Time
+	}{
+		{
+			name:     "date",
+			m:        map[string]interface{}{"publishDate": "2024-06-01"},
+			expected: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC 3339 time",
+			m:        map[string]interface{}{"publishDate": "2024-06-01T11:00:00+02:00"},
+			expected: parsed,
+		},
+		{
+			name:     "parsed time",
+			m:        map[string]interface{}{"publishDate": parsed},
+			expected: parsed,
+		},
+		{
+			name: "invalid",
+			m:    map[string]interface{}{"publishDate": "next week"},
+		},
+		{
+			name: "missing key",
+			m:    map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := GetTime(tt.m, "publishDate"); !result.Equal(tt.expected) {
+				t.Errorf("GetTime() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
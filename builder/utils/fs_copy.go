@@ -1,25 +1,31 @@
 package utils
 
 import (
-	"bytes"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
-	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 
-	"github.com/chai2010/webp"
-	"github.com/disintegration/imaging"
 	"github.com/spf13/afero"
-	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/metrics"
 )
 
-func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, compress bool, excludeExts []string, onWrite func(string), cacheDir string, imageWorkers int) error {
+// CopyDirVFS copies srcDir to dstDir on a pool of imageWorkers goroutines,
+// converting JPEG and PNG images to WebP, and to the copies images asks for,
+// when images.Compress is set. With a non-nil index, files
+// that are unchanged since the last build and already present in the on-disk
+// output are skipped entirely: they aren't written to destFs, so the sync
+// leaves them alone, and onWrite isn't called for them. Images to convert
+// are counted in progress, which may be nil. dest, which may be nil, maps the
+// slash-separated path of each file relative to srcDir to the one it gets
+// under dstDir (before the .webp rename of images), or reports false for
+// files that aren't copied.
+func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, images ImageOptions, dest func(relPath string, info fs.FileInfo) (string, bool), onWrite func(string), cacheDir string, imageWorkers int, index *StaticIndex, progress *metrics.BuildMetrics) error {
 	srcDir = NormalizePath(srcDir)
 	dstDir = NormalizePath(dstDir)
 	if err := destFs.MkdirAll(dstDir, 0755); err != nil {
@@ -32,6 +38,7 @@ func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, compress
 		info    fs.FileInfo
 	}
 
+	compress := images.Compress
 	taskQueue := make(chan fileTask, 100)
 	errChan := make(chan error, 100)
 	var wg sync.WaitGroup
@@ -44,16 +51,42 @@ func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, compress
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for task := range taskQueue {
+			copyTask := func(task fileTask) {
 				ext := strings.ToLower(filepath.Ext(task.path))
 				isImage := (ext == ".jpg" || ext == ".jpeg" || ext == ".png")
+				if compress && isImage {
+					defer progress.WorkDone(metrics.StageImages)
+				}
+
+				if index != nil {
+					target := filepath.Join(dstDir, task.relPath)
+					var options string
+					if compress && isImage {
+						options = images.fingerprint()
+					}
+					same, err := index.unchanged(srcFs, task.path, target, task.info, options)
+					if err != nil {
+						errChan <- fmt.Errorf("failed to hash %s: %w", task.path, err)
+						return
+					}
+					// A compressed image's output size differs from its source
+					if same && !index.wasWritten(target) && outputPresent(target, task.info.Size(), compress && isImage) {
+						index.recordSkip(task.info.Size())
+						return
+					}
+					index.markWritten(target)
+				}
 
 				if compress && isImage {
 					target := filepath.Join(dstDir, task.relPath)
-					if err := processImageVFS(srcFs, destFs, task.path, target, cacheDir); err != nil {
+					written, err := processImageVFS(srcFs, destFs, task.path, target, cacheDir, images)
+					if err != nil {
 						errChan <- fmt.Errorf("failed to process image %s: %w", task.path, err)
-					} else if onWrite != nil {
-						onWrite(target)
+					}
+					if onWrite != nil {
+						for _, path := range written {
+							onWrite(path)
+						}
 					}
 				} else {
 					destPath := filepath.Join(dstDir, task.relPath)
@@ -88,6 +121,9 @@ func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, compress
 					}
 				}
 			}
+			for task := range taskQueue {
+				copyTask(task)
+			}
 		}()
 	}
 
@@ -102,16 +138,19 @@ func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, compress
 		relPath, _ := SafeRel(srcDir, path)
 		ext := strings.ToLower(filepath.Ext(path))
 
-		for _, exclude := range excludeExts {
-			if ext == exclude {
+		finalRelPath := relPath
+		if dest != nil {
+			target, ok := dest(filepath.ToSlash(relPath), info)
+			if !ok {
 				return nil
 			}
+			finalRelPath = filepath.FromSlash(target)
 		}
 
 		isImage := (ext == ".jpg" || ext == ".jpeg" || ext == ".png")
-		finalRelPath := relPath
 		if compress && isImage {
-			finalRelPath = relPath[:len(relPath)-len(filepath.Ext(relPath))] + ".webp"
+			finalRelPath = finalRelPath[:len(finalRelPath)-len(filepath.Ext(finalRelPath))] + ".webp"
+			progress.AddWork(metrics.StageImages, 1)
 		}
 
 		taskQueue <- fileTask{path, finalRelPath, info}
@@ -135,64 +174,12 @@ func CopyDirVFS(srcFs afero.Fs, destFs afero.Fs, srcDir, dstDir string, compress
 	return nil
 }
 
-func processImageVFS(srcFs afero.Fs, destFs afero.Fs, srcPath, dstPath string, cacheDir string) error {
-	srcInfo, err := srcFs.Stat(srcPath)
-	if err == nil {
-		if dstInfo, err := os.Stat(dstPath); err == nil {
-			if !srcInfo.ModTime().After(dstInfo.ModTime()) {
-				data, err := os.ReadFile(dstPath)
-				if err == nil {
-					return afero.WriteFile(destFs, dstPath, data, 0644)
-				}
-			}
-		}
+// outputPresent reports whether a previous build left path on disk, with the
+// expected size unless anySize is set
+func outputPresent(path string, size int64, anySize bool) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
 	}
-
-	var cacheFile string
-	if cacheDir != "" && err == nil {
-		key := fmt.Sprintf("%s-%d-%d", srcPath, srcInfo.Size(), srcInfo.ModTime().UnixNano())
-		hash := blake3.Sum256([]byte(key))
-		hashStr := hex.EncodeToString(hash[:])
-		cacheFile = filepath.Join(cacheDir, hashStr+".webp")
-
-		if data, err := os.ReadFile(cacheFile); err == nil {
-			return WriteFileVFS(destFs, dstPath, data)
-		}
-	}
-
-	file, err := srcFs.Open(srcPath)
-	if err != nil {
-		return fmt.Errorf("failed to open source image %s: %w", srcPath, err)
-	}
-	defer func() { _ = file.Close() }()
-
-	img, err := imaging.Decode(file)
-	if err != nil {
-		return fmt.Errorf("failed to decode image %s: %w", srcPath, err)
-	}
-
-	if img.Bounds().Dx() > 1200 {
-		img = imaging.Resize(img, 1200, 0, imaging.Lanczos)
-	}
-
-	if err := destFs.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-		return fmt.Errorf("failed to create image directory: %w", err)
-	}
-
-	// Encode to buffer once, then write to both cache and destination
-	var buf bytes.Buffer
-	if err := webp.Encode(&buf, img, &webp.Options{Lossless: false, Quality: 80}); err != nil {
-		return fmt.Errorf("failed to encode webp %s: %w", dstPath, err)
-	}
-	encodedData := buf.Bytes()
-
-	// Write to cache file if configured
-	if cacheFile != "" {
-		if err := os.WriteFile(cacheFile, encodedData, 0644); err != nil {
-			slog.Warn("Failed to write image cache file", "path", cacheFile, "error", err)
-		}
-	}
-
-	// Write to destination
-	return afero.WriteFile(destFs, dstPath, encodedData, 0644)
+	return anySize || info.Size() == size
 }
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// MountPoint maps a real directory into the virtual source tree
+type MountPoint struct {
+	Source string // Directory on the underlying filesystem
+	Target string // Virtual path where Source appears (e.g. content/shared)
+}
+
+// MountFs overlays mounted directories onto a base filesystem.
+// Paths under a mount target are served from the mount source, and the
+// target appears as a directory entry of its parent so walks pick it up.
+type MountFs struct {
+	base    afero.Fs
+	mounts  []MountPoint // Slash-separated absolute paths, longest target first
+	virtual afero.Fs     // Ancestors of targets that don't exist on base
+}
+
+// NewMountFs creates a MountFs. Relative mount paths resolve against the working directory.
+func NewMountFs(base afero.Fs, mounts []MountPoint) *MountFs {
+	m := &MountFs{base: base, virtual: afero.NewMemMapFs()}
+	for _, mp := range mounts {
+		target := mountKey(mp.Target)
+		m.mounts = append(m.mounts, MountPoint{Source: mountKey(mp.Source), Target: target})
+		_ = m.virtual.MkdirAll(filepath.FromSlash(target), 0755)
+	}
+	sort.Slice(m.mounts, func(i, j int) bool { return len(m.mounts[i].Target) > len(m.mounts[j].Target) })
+	return m
+}
+
+// Mounts returns the configured mount points with absolute paths
+func (m *MountFs) Mounts() []MountPoint {
+	out := make([]MountPoint, len(m.mounts))
+	copy(out, m.mounts)
+	return out
+}
+
+func mountKey(name string) string {
+	if !filepath.IsAbs(name) {
+		if abs, err := filepath.Abs(name); err == nil {
+			name = abs
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+// RealPath maps a virtual path to the path on the underlying filesystem
+func (m *MountFs) RealPath(name string) string {
+	key := mountKey(name)
+	for _, mp := range m.mounts {
+		if key == mp.Target {
+			return filepath.FromSlash(mp.Source)
+		}
+		if strings.HasPrefix(key, mp.Target+"/") {
+			return filepath.FromSlash(mp.Source + key[len(mp.Target):])
+		}
+	}
+	return name
+}
+
+// VirtualPath maps a real path inside a mount source back to its virtual path.
+// Paths outside every mount are returned unchanged.
+func (m *MountFs) VirtualPath(name string) string {
+	key := mountKey(name)
+	for _, mp := range m.mounts {
+		if key == mp.Source {
+			return mp.Target
+		}
+		if strings.HasPrefix(key, mp.Source+"/") {
+			return mp.Target + key[len(mp.Source):]
+		}
+	}
+	return name
+}
+
+// childMounts returns mount targets that are direct children of dir
+func (m *MountFs) childMounts(dir string) []string {
+	key := mountKey(dir)
+	var names []string
+	for _, mp := range m.mounts {
+		if filepath.ToSlash(filepath.Dir(filepath.FromSlash(mp.Target))) == key {
+			names = append(names, filepath.Base(filepath.FromSlash(mp.Target)))
+		}
+	}
+	return names
+}
+
+func (m *MountFs) isTarget(name string) bool {
+	key := mountKey(name)
+	for _, mp := range m.mounts {
+		if key == mp.Target {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MountFs) Name() string { return "MountFs" }
+
+func (m *MountFs) Open(name string) (afero.File, error) {
+	f, err := m.base.Open(m.RealPath(name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		vf, verr := m.virtual.Open(filepath.FromSlash(mountKey(name)))
+		if verr != nil {
+			return nil, err
+		}
+		f = vf
+	}
+	if children := m.childMounts(name); len(children) > 0 {
+		return &mountDir{File: f, fs: m, dir: name, children: children}, nil
+	}
+	return f, nil
+}
+
+func (m *MountFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag == os.O_RDONLY {
+		return m.Open(name)
+	}
+	return m.base.OpenFile(m.RealPath(name), flag, perm)
+}
+
+func (m *MountFs) Stat(name string) (os.FileInfo, error) {
+	info, err := m.base.Stat(m.RealPath(name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		vinfo, verr := m.virtual.Stat(filepath.FromSlash(mountKey(name)))
+		if verr != nil {
+			return nil, err
+		}
+		return vinfo, nil
+	}
+	if m.isTarget(name) {
+		return renamedInfo{FileInfo: info, name: filepath.Base(name)}, nil
+	}
+	return info, nil
+}
+
+func (m *MountFs) Create(name string) (afero.File, error) { return m.base.Create(m.RealPath(name)) }
+func (m *MountFs) Mkdir(name string, perm os.FileMode) error {
+	return m.base.Mkdir(m.RealPath(name), perm)
+}
+func (m *MountFs) MkdirAll(path string, perm os.FileMode) error {
+	return m.base.MkdirAll(m.RealPath(path), perm)
+}
+func (m *MountFs) Remove(name string) error    { return m.base.Remove(m.RealPath(name)) }
+func (m *MountFs) RemoveAll(path string) error { return m.base.RemoveAll(m.RealPath(path)) }
+func (m *MountFs) Rename(oldname, newname string) error {
+	return m.base.Rename(m.RealPath(oldname), m.RealPath(newname))
+}
+func (m *MountFs) Chmod(name string, mode os.FileMode) error {
+	return m.base.Chmod(m.RealPath(name), mode)
+}
+func (m *MountFs) Chown(name string, uid, gid int) error {
+	return m.base.Chown(m.RealPath(name), uid, gid)
+}
+func (m *MountFs) Chtimes(name string, atime, mtime time.Time) error {
+	return m.base.Chtimes(m.RealPath(name), atime, mtime)
+}
+
+// renamedInfo reports a mount source under its target name
+type renamedInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedInfo) Name() string { return r.name }
+
+// mountDir adds mount targets to the listing of their parent directory
+type mountDir struct {
+	afero.File
+	fs       *MountFs
+	dir      string
+	children []string
+	added    bool
+}
+
+// mountInfos returns the entries for mount targets, once per listing
+func (d *mountDir) mountInfos() []os.FileInfo {
+	if d.added {
+		return nil
+	}
+	d.added = true
+	var infos []os.FileInfo
+	for _, name := range d.children {
+		if info, err := d.fs.Stat(filepath.Join(d.dir, name)); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+func (d *mountDir) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := d.File.Readdir(count)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return infos, err
+	}
+
+	// Mount targets shadow real entries of the same name
+	filtered := infos[:0]
+	for _, info := range infos {
+		if !slices.Contains(d.children, info.Name()) {
+			filtered = append(filtered, info)
+		}
+	}
+
+	// Append mounts once the real listing is exhausted
+	if count <= 0 || len(filtered) == 0 {
+		filtered = append(filtered, d.mountInfos()...)
+		if count > 0 && len(filtered) > 0 {
+			err = nil
+		}
+	}
+	return filtered, err
+}
+
+func (d *mountDir) Readdirnames(n int) ([]string, error) {
+	infos, err := d.Readdir(n)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, err
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestMountFs(t *testing.T) {
+	base := afero.NewMemMapFs()
+	files := map[string]string{
+		"/site/content/index.md":         "home",
+		"/shared/docs/guide.md":          "guide",
+		"/shared/docs/nested/deep.md":    "deep",
+		"/brand/logo.svg":                "<svg/>",
+		"/site/content/shared/hidden.md": "shadowed",
+	}
+	for path, body := range files {
+		if err := afero.WriteFile(base, path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mfs := NewMountFs(base, []MountPoint{
+		{Source: "/shared/docs", Target: "/site/content/shared"},
+		{Source: "/brand", Target: "/site/static/brand"},
+	})
+
+	t.Run("walk includes mounted files", func(t *testing.T) {
+		var got []string
+		err := afero.Walk(mfs, "/site/content", func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				got = append(got, filepath.ToSlash(path))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Walk() error = %v", err)
+		}
+		sort.Strings(got)
+		want := []string{"/site/content/index.md", "/site/content/shared/guide.md", "/site/content/shared/nested/deep.md"}
+		if len(got) != len(want) {
+			t.Fatalf("Walk() = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Walk()[%d] = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("read through mount", func(t *testing.T) {
+		data, err := afero.ReadFile(mfs, "/site/content/shared/nested/deep.md")
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if string(data) != "deep" {
+			t.Errorf("ReadFile() = %q, want %q", data, "deep")
+		}
+	})
+
+	t.Run("mount under missing parent", func(t *testing.T) {
+		if exists, _ := afero.DirExists(mfs, "/site/static"); !exists {
+			t.Fatal("/site/static should exist virtually")
+		}
+		if exists, _ := afero.Exists(mfs, "/site/static/brand/logo.svg"); !exists {
+			t.Error("/site/static/brand/logo.svg should exist")
+		}
+	})
+
+	t.Run("virtual path mapping", func(t *testing.T) {
+		if got := mfs.VirtualPath("/shared/docs/guide.md"); got != "/site/content/shared/guide.md" {
+			t.Errorf("VirtualPath() = %q", got)
+		}
+		if got := mfs.VirtualPath("/elsewhere/file.md"); got != "/elsewhere/file.md" {
+			t.Errorf("VirtualPath() for unmounted path = %q", got)
+		}
+		if got := filepath.ToSlash(mfs.RealPath("/site/content/shared/guide.md")); got != "/shared/docs/guide.md" {
+			t.Errorf("RealPath() = %q", got)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+)
+
+// DefaultImageQuality is the encoder quality of published images
+const DefaultImageQuality = 80
+
+// MaxImageWidth is the width compressed images are scaled down to
+const MaxImageWidth = 1200
+
+// ImageOptions is how CopyDirVFS publishes JPEG and PNG images
+type ImageOptions struct {
+	Compress bool   // Convert to WebP, scaled down to MaxImageWidth
+	Widths   []int  // Also publish these smaller widths (see ImageVariant)
+	FFmpeg   string // Also publish AVIF copies, encoded with this ffmpeg
+	Quality  int    // Encoder quality, 1-100 (default: DefaultImageQuality)
+}
+
+func (o ImageOptions) quality() int {
+	if o.Quality <= 0 || o.Quality > 100 {
+		return DefaultImageQuality
+	}
+	return o.Quality
+}
+
+// fingerprint identifies the options an image was published with, so a
+// change republishes it; "" for plain compression
+func (o ImageOptions) fingerprint() string {
+	if len(o.Widths) == 0 && o.FFmpeg == "" && o.quality() == DefaultImageQuality {
+		return ""
+	}
+	return fmt.Sprintf("%v-%v-%d", o.Widths, o.FFmpeg != "", o.quality())
+}
+
+// VariantWidths returns the widths an image published at width gets smaller
+// copies at: the requested widths below it, ascending
+func VariantWidths(width int, widths []int) []int {
+	var out []int
+	for _, w := range widths {
+		if w > 0 && w < width && !slices.Contains(out, w) {
+			out = append(out, w)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ImageVariant returns the path of a published image's copy in a format
+// ("webp" or "avif") at a width, 0 for its full width:
+// ImageVariant("img/a.webp", 480, "avif") is "img/a-480w.avif".
+func ImageVariant(published string, width int, format string) string {
+	base := strings.TrimSuffix(published, filepath.Ext(published))
+	if width > 0 {
+		base += "-" + strconv.Itoa(width) + "w"
+	}
+	return base + "." + format
+}
+
+// imageOutput is one file an image is published as
+type imageOutput struct {
+	path   string
+	width  int
+	format string
+}
+
+// processImageVFS publishes a JPEG or PNG image as WebP at dstPath, and as
+// the copies opts asks for next to it, returning the files it wrote. Each
+// output is cached under cacheDir by the hash of the source, its width,
+// format and quality, so the image is only decoded when one is missing.
+func processImageVFS(srcFs afero.Fs, destFs afero.Fs, srcPath, dstPath string, cacheDir string, opts ImageOptions) ([]string, error) {
+	data, err := afero.ReadFile(srcFs, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open source image %s: %w", srcPath, err)
+	}
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", srcPath, err)
+	}
+	published := min(header.Width, MaxImageWidth)
+
+	outputs := []imageOutput{{dstPath, published, "webp"}}
+	widths := VariantWidths(published, opts.Widths)
+	for _, w := range widths {
+		outputs = append(outputs, imageOutput{ImageVariant(dstPath, w, "webp"), w, "webp"})
+	}
+	if opts.FFmpeg != "" {
+		outputs = append(outputs, imageOutput{ImageVariant(dstPath, 0, "avif"), published, "avif"})
+		for _, w := range widths {
+			outputs = append(outputs, imageOutput{ImageVariant(dstPath, w, "avif"), w, "avif"})
+		}
+	}
+
+	sum := blake3.Sum256(data)
+	var img image.Image // Decoded on the first cache miss
+	var written []string
+	for _, out := range outputs {
+		var cacheFile string
+		if cacheDir != "" {
+			key := blake3.Sum256(fmt.Appendf(nil, "%x-%d-%s-%d", sum, out.width, out.format, opts.quality()))
+			cacheFile = filepath.Join(cacheDir, hex.EncodeToString(key[:])+"."+out.format)
+			if cached, err := os.ReadFile(cacheFile); err == nil {
+				if err := WriteFileVFS(destFs, out.path, cached); err != nil {
+					return written, err
+				}
+				written = append(written, out.path)
+				continue
+			}
+		}
+
+		if img == nil {
+			if img, err = imaging.Decode(bytes.NewReader(data)); err != nil {
+				return written, fmt.Errorf("failed to decode image %s: %w", srcPath, err)
+			}
+		}
+		encoded, err := encodeImage(img, out, opts)
+		if err != nil {
+			return written, fmt.Errorf("failed to encode %s %s: %w", out.format, out.path, err)
+		}
+		if cacheFile != "" {
+			if err := os.MkdirAll(cacheDir, 0755); err == nil {
+				err = os.WriteFile(cacheFile, encoded, 0644)
+			}
+			if err != nil {
+				slog.Warn("Failed to write image cache file", "path", cacheFile, "error", err)
+			}
+		}
+		if err := WriteFileVFS(destFs, out.path, encoded); err != nil {
+			return written, err
+		}
+		written = append(written, out.path)
+	}
+	return written, nil
+}
+
+// encodeImage scales an image down to an output's width and encodes it
+func encodeImage(img image.Image, out imageOutput, opts ImageOptions) ([]byte, error) {
+	if img.Bounds().Dx() > out.width {
+		img = imaging.Resize(img, out.width, 0, imaging.Lanczos)
+	}
+	if out.format == "avif" {
+		return encodeAVIF(opts.FFmpeg, img, opts.quality())
+	}
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, img, &webp.Options{Quality: float32(opts.quality())}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeAVIF encodes an image with ffmpeg's AV1 encoder, piping it in as PNG.
+// Quality maps onto the encoder's CRF: 80 gives 24, 100 gives 15.
+func encodeAVIF(ffmpeg string, img image.Image, quality int) ([]byte, error) {
+	var in bytes.Buffer
+	if err := png.Encode(&in, img); err != nil {
+		return nil, err
+	}
+	out, err := os.CreateTemp("", "kosh-*.avif")
+	if err != nil {
+		return nil, err
+	}
+	_ = out.Close()
+	defer func() { _ = os.Remove(out.Name()) }()
+
+	crf := 15 + (100-quality)*48/100
+	cmd := exec.Command(ffmpeg, "-hide_banner", "-loglevel", "error", "-y",
+		"-f", "png_pipe", "-i", "pipe:0",
+		"-c:v", "libaom-av1", "-still-picture", "1", "-crf", strconv.Itoa(crf), "-b:v", "0",
+		"-f", "avif", out.Name())
+	cmd.Stdin = &in
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return os.ReadFile(out.Name())
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chai2010/webp"
+	"github.com/spf13/afero"
+)
+
+func TestVariantWidths(t *testing.T) {
+	tests := []struct {
+		width  int
+		widths []int
+		want   []int
+	}{
+		{1200, []int{800, 480, 1600, 480}, []int{480, 800}},
+		{800, []int{800, 1200}, nil},
+		{1200, nil, nil},
+	}
+	for _, tt := range tests {
+		if got := VariantWidths(tt.width, tt.widths); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("VariantWidths(%d, %v) = %v, want %v", tt.width, tt.widths, got, tt.want)
+		}
+	}
+}
+
+func TestImageVariant(t *testing.T) {
+	tests := []struct {
+		width  int
+		format string
+		want   string
+	}{
+		{480, "webp", "static/img/a-480w.webp"},
+		{480, "avif", "static/img/a-480w.avif"},
+		{0, "avif", "static/img/a.avif"},
+		{0, "webp", "static/img/a.webp"},
+	}
+	for _, tt := range tests {
+		if got := ImageVariant("static/img/a.webp", tt.width, tt.format); got != tt.want {
+			t.Errorf("ImageVariant(%d, %q) = %q, want %q", tt.width, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestCopyDirVFSResponsiveImages(t *testing.T) {
+	srcFs := afero.NewMemMapFs()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1000, 500))); err != nil {
+		t.Fatal(err)
+	}
+	_ = afero.WriteFile(srcFs, "static/img/photo.png", buf.Bytes(), 0644)
+	cacheDir := t.TempDir()
+	opts := ImageOptions{Compress: true, Widths: []int{320, 640, 1600}}
+
+	copyImages := func() (afero.Fs, []string) {
+		destFs := afero.NewMemMapFs()
+		var written []string
+		if err := CopyDirVFS(srcFs, destFs, "static", "public/static", opts, nil, func(p string) { written = append(written, p) }, cacheDir, 2, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		return destFs, written
+	}
+
+	destFs, written := copyImages()
+	if len(written) != 3 {
+		t.Fatalf("wrote %v, want the image and 2 smaller copies", written)
+	}
+	for path, width := range map[string]int{
+		"public/static/img/photo.webp":      1000,
+		"public/static/img/photo-320w.webp": 320,
+		"public/static/img/photo-640w.webp": 640,
+	} {
+		data, err := afero.ReadFile(destFs, path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		cfg, err := webp.DecodeConfig(bytes.NewReader(data))
+		if err != nil || cfg.Width != width {
+			t.Errorf("%s is %d wide (%v), want %d", path, cfg.Width, err, width)
+		}
+	}
+
+	// The next build reads every output from the cache
+	cached, _ := os.ReadDir(cacheDir)
+	if len(cached) != 3 {
+		t.Fatalf("cache holds %d files, want 3", len(cached))
+	}
+	if _, written = copyImages(); len(written) != 3 {
+		t.Errorf("cached build wrote %v", written)
+	}
+	if after, _ := os.ReadDir(cacheDir); len(after) != 3 {
+		t.Errorf("cached build re-encoded: cache holds %d files", len(after))
+	}
+
+	// Another quality is another output
+	opts.Quality = 60
+	copyImages()
+	if after, _ := os.ReadDir(cacheDir); len(after) != 6 {
+		t.Errorf("cache holds %d files after changing the quality, want 6", len(after))
+	}
+}
+
+func TestCopyDirVFSRepublishesOnNewOptions(t *testing.T) {
+	srcFs := afero.NewMemMapFs()
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 800, 400)))
+	_ = afero.WriteFile(srcFs, "static/photo.png", buf.Bytes(), 0644)
+	dstDir := filepath.Join(t.TempDir(), "public", "static")
+
+	build := func(index *StaticIndex, opts ImageOptions) []string {
+		var written []string
+		destFs := afero.NewMemMapFs()
+		if err := CopyDirVFS(srcFs, destFs, "static", dstDir, opts, nil, func(p string) { written = append(written, p) }, "", 1, index, nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range written {
+			data, _ := afero.ReadFile(destFs, p)
+			_ = os.MkdirAll(filepath.Dir(p), 0755)
+			_ = os.WriteFile(p, data, 0644)
+		}
+		return written
+	}
+
+	first := NewStaticIndex(nil)
+	build(first, ImageOptions{Compress: true})
+	second := NewStaticIndex(first.Files())
+	if written := build(second, ImageOptions{Compress: true}); len(written) != 0 {
+		t.Errorf("unchanged build wrote %v", written)
+	}
+	// The image is unchanged, but it now has a smaller copy to publish
+	third := NewStaticIndex(second.Files())
+	if written := build(third, ImageOptions{Compress: true, Widths: []int{400}}); len(written) != 2 {
+		t.Errorf("build with new widths wrote %v, want the image and its 400w copy", written)
+	}
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+)
+
+// MediaDir is the directory under the output's static/ that images are
+// published in by content (images.dedupe)
+const MediaDir = "media"
+
+// mediaExts are the images published by content
+var mediaExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true, ".svg": true}
+
+// MediaNames are the content-addressed names of the images in the static
+// directories: each distinct image is published once, as
+// static/media/<name>.<hash>.<ext>, whatever paths it is found under. The
+// hash covers the image and, for images converted to WebP, the options
+// they are converted with, so a name never changes content.
+type MediaNames struct {
+	dest  map[string]string // mediaKey of a source → path under static/ (before the .webp rename), "" for overridden ones
+	emit  map[string]bool   // Sources that publish their name; the others are duplicates
+	links map[string]string // URL path an image had → its content-addressed URL path
+}
+
+// NewMediaNames names the images of staticDirs, where a later directory
+// overrides an earlier one at the same path. Files exclude reports (by path
+// relative to their directory) keep their own path. Unchanged images aren't
+// read again when index has their hash; index and exclude may be nil.
+func NewMediaNames(srcFs afero.Fs, staticDirs []string, images ImageOptions, index *StaticIndex, exclude func(relPath string, info fs.FileInfo) bool) (*MediaNames, error) {
+	type source struct {
+		key, rel, hash string
+	}
+	live := make(map[string]source) // By path relative to the static dirs
+	var overridden []string         // Keys of images a later directory replaced
+	for _, dir := range staticDirs {
+		dir = NormalizePath(dir)
+		if exists, _ := afero.DirExists(srcFs, dir); !exists {
+			continue
+		}
+		err := afero.Walk(srcFs, dir, func(p string, info fs.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := SafeRel(dir, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			ext := strings.ToLower(path.Ext(rel))
+			if !mediaExts[ext] || (exclude != nil && exclude(rel, info)) {
+				delete(live, rel)
+				return nil
+			}
+			sum, err := index.sourceHash(srcFs, p, info)
+			if err != nil {
+				return fmt.Errorf("failed to hash %s: %w", p, err)
+			}
+			if images.Compress && (ext == ".jpg" || ext == ".jpeg" || ext == ".png") {
+				h := blake3.Sum256([]byte(sum + "-" + images.fingerprint()))
+				sum = hex.EncodeToString(h[:])
+			}
+			if prev, ok := live[rel]; ok {
+				overridden = append(overridden, prev.key)
+			}
+			live[rel] = source{mediaKey(dir, rel), rel, sum[:10]}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m := &MediaNames{dest: make(map[string]string), emit: make(map[string]bool), links: make(map[string]string)}
+	for _, key := range overridden {
+		m.dest[key] = "" // Never published
+	}
+	named := make(map[string]string) // hash + extension → name of the first source with it
+	for _, rel := range slices.Sorted(maps.Keys(live)) {
+		src := live[rel]
+		ext := strings.ToLower(path.Ext(rel))
+		name, ok := named[src.hash+ext]
+		if !ok {
+			name = path.Join(MediaDir, mediaStem(rel)+"."+src.hash+ext)
+			named[src.hash+ext] = name
+			m.emit[src.key] = true
+		}
+		m.dest[src.key] = name
+		m.links["/static/"+published(rel, images.Compress)] = "/static/" + published(name, images.Compress)
+	}
+	return m, nil
+}
+
+// Dest is where CopyDirVFS publishes relPath of srcDir: the image's
+// content-addressed name, not copied at all when another path has the same
+// image or a later directory overrides it, and its own path for other
+// files. m may be nil.
+func (m *MediaNames) Dest(srcDir, relPath string) (string, bool) {
+	if m == nil {
+		return relPath, true
+	}
+	key := mediaKey(NormalizePath(srcDir), relPath)
+	name, ok := m.dest[key]
+	if !ok {
+		return relPath, true
+	}
+	return name, m.emit[key]
+}
+
+// Links maps the URL path each image would be published at
+// ("/static/images/a.webp") to its content-addressed one
+// ("/static/media/a.1a2b3c4d5e.webp"); nil for a nil m. Responsive and AVIF
+// copies follow their image, as ImageVariant names them.
+func (m *MediaNames) Links() map[string]string {
+	if m == nil {
+		return nil
+	}
+	return m.links
+}
+
+func mediaKey(dir, rel string) string {
+	return dir + "\x00" + rel
+}
+
+// mediaStem is a file's name without extension, reduced to characters that
+// need no escaping in URLs (and without dots, which would end the name
+// before the hash)
+func mediaStem(rel string) string {
+	stem := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	stem = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, stem)
+	if stem == "" {
+		return "image"
+	}
+	return stem
+}
+
+// published is the path a static file is written at: JPEG and PNG images
+// become WebP when compressed
+func published(p string, compress bool) string {
+	ext := strings.ToLower(path.Ext(p))
+	if compress && (ext == ".jpg" || ext == ".jpeg" || ext == ".png") {
+		return strings.TrimSuffix(p, path.Ext(p)) + ".webp"
+	}
+	return p
+}
+
+// mediaLiteral matches a quoted root-relative static path in a script
+var mediaLiteral = regexp.MustCompile("([\"'`])(/static/[^\"'`\\s]+)([\"'`])")
+
+// mediaLinks maps the URL paths of static images to their content-addressed
+// ones (MediaNames.Links), for the stylesheets and scripts built by esbuild
+type mediaLinks map[string]string
+
+// lookup returns the content-addressed path of an image's URL path. JPEG
+// and PNG sources are found by the WebP they are published as when
+// compressed.
+func (m mediaLinks) lookup(urlPath string) (string, bool) {
+	if to, ok := m[urlPath]; ok {
+		return to, true
+	}
+	ext := strings.ToLower(path.Ext(urlPath))
+	if ext == ".jpg" || ext == ".jpeg" || ext == ".png" {
+		to, ok := m[strings.TrimSuffix(urlPath, path.Ext(urlPath))+".webp"]
+		return to, ok
+	}
+	return "", false
+}
+
+// rewriteScript replaces quoted "/static/..." paths in a script that name
+// an image exactly
+func (m mediaLinks) rewriteScript(js string) string {
+	if len(m) == 0 {
+		return js
+	}
+	return mediaLiteral.ReplaceAllStringFunc(js, func(lit string) string {
+		sub := mediaLiteral.FindStringSubmatch(lit)
+		to, ok := m.lookup(sub[2])
+		if !ok || sub[1] != sub[3] {
+			return lit
+		}
+		return sub[1] + to + sub[3]
+	})
+}
+
+// plugin points the url()s of stylesheets, root-relative or relative to a
+// file in one of bases (the static directories), at the images'
+// content-addressed names instead of a copy esbuild makes, and rewrites the
+// scripts esbuild loads with rewriteScript
+func (m mediaLinks) plugin(bases []string) api.Plugin {
+	return api.Plugin{
+		Name: "media",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: `(?i)\.(jpe?g|png|gif|webp|avif|svg)([?#].*)?$`}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				if args.Kind != api.ResolveCSSURLToken {
+					return api.OnResolveResult{}, nil
+				}
+				link, suffix := args.Path, ""
+				if i := strings.IndexAny(link, "?#"); i >= 0 {
+					link, suffix = link[:i], link[i:]
+				}
+				urlPaths := []string{link}
+				if !strings.HasPrefix(link, "/") && !strings.Contains(link, ":") {
+					file := filepath.Join(args.ResolveDir, filepath.FromSlash(link))
+					urlPaths = urlPaths[:0]
+					for _, base := range bases {
+						if rel, err := filepath.Rel(base, file); err == nil && !strings.HasPrefix(rel, "..") {
+							urlPaths = append(urlPaths, "/static/"+filepath.ToSlash(rel))
+						}
+					}
+				}
+				for _, p := range urlPaths {
+					if to, ok := m.lookup(p); ok {
+						return api.OnResolveResult{Path: to + suffix, External: true}, nil
+					}
+				}
+				return api.OnResolveResult{}, nil
+			})
+
+			build.OnLoad(api.OnLoadOptions{Filter: `\.js$`}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				src, err := os.ReadFile(args.Path)
+				if err != nil {
+					return api.OnLoadResult{}, err
+				}
+				js := m.rewriteScript(string(src))
+				return api.OnLoadResult{Contents: &js, Loader: api.LoaderJS}, nil
+			})
+		},
+	}
+}
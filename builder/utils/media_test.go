@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestMediaNames(t *testing.T) {
+	srcFs := afero.NewMemMapFs()
+	for path, content := range map[string]string{
+		"theme/static/images/logo.svg":     "<svg/>",
+		"theme/static/images/bg.gif":       "theme gif",
+		"static/images/bg.gif":             "site gif", // Overrides the theme's
+		"static/posts/a/diagram.svg":       "<svg>shared</svg>",
+		"static/posts/b/Flow Chart.v2.svg": "<svg>shared</svg>",
+		"static/images/hero.svg":           "<svg>big</svg>", // Excluded
+		"static/docs/guide.pdf":            "not an image",
+	} {
+		_ = afero.WriteFile(srcFs, path, []byte(content), 0644)
+	}
+	exclude := func(relPath string, info fs.FileInfo) bool { return relPath == "images/hero.svg" }
+	media, err := NewMediaNames(srcFs, []string{"theme/static", "static"}, ImageOptions{}, nil, exclude)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := media.Links()
+	keys := slices.Sorted(maps.Keys(links))
+	want := []string{"/static/images/bg.gif", "/static/images/logo.svg", "/static/posts/a/diagram.svg", "/static/posts/b/Flow Chart.v2.svg"}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("linked = %q, want %q", keys, want)
+	}
+	shared := links["/static/posts/a/diagram.svg"]
+	if links["/static/posts/b/Flow Chart.v2.svg"] != shared || !strings.HasPrefix(shared, "/static/media/diagram.") {
+		t.Errorf("identical images named %q and %q", shared, links["/static/posts/b/Flow Chart.v2.svg"])
+	}
+
+	// Each distinct image is copied once, overridden and excluded files not at all
+	destFs := afero.NewMemMapFs()
+	var written []string
+	for _, dir := range []string{"theme/static", "static"} {
+		dest := func(relPath string, info fs.FileInfo) (string, bool) {
+			if exclude(relPath, info) {
+				return "", false
+			}
+			return media.Dest(dir, relPath)
+		}
+		if err := CopyDirVFS(srcFs, destFs, dir, "public/static", ImageOptions{}, dest, func(p string) { written = append(written, p) }, "", 1, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	slices.Sort(written)
+	wantWritten := []string{"public" + links["/static/images/bg.gif"], "public" + links["/static/images/logo.svg"], "public" + shared, "public/static/docs/guide.pdf"}
+	slices.Sort(wantWritten)
+	if !slices.Equal(written, wantWritten) {
+		t.Errorf("written = %q, want %q", written, wantWritten)
+	}
+	bg := "public" + links["/static/images/bg.gif"]
+	if data, _ := afero.ReadFile(destFs, bg); string(data) != "site gif" {
+		t.Errorf("%s = %q, want the site's", bg, data)
+	}
+}
+
+func TestMediaNamesFollowImageOptions(t *testing.T) {
+	srcFs := afero.NewMemMapFs()
+	_ = afero.WriteFile(srcFs, "static/photo.png", []byte("png"), 0644)
+	name := func(opts ImageOptions) string {
+		media, err := NewMediaNames(srcFs, []string{"static"}, opts, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return media.Links()[map[bool]string{true: "/static/photo.webp", false: "/static/photo.png"}[opts.Compress]]
+	}
+	plain, webp, small := name(ImageOptions{}), name(ImageOptions{Compress: true}), name(ImageOptions{Compress: true, Widths: []int{480}})
+	if !strings.HasSuffix(plain, ".png") || !strings.HasSuffix(webp, ".webp") {
+		t.Errorf("names %q, %q", plain, webp)
+	}
+	if webp == small || strings.TrimSuffix(plain, ".png") == strings.TrimSuffix(webp, ".webp") {
+		t.Errorf("the hash doesn't follow the conversion: %q, %q, %q", plain, webp, small)
+	}
+}
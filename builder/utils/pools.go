@@ -5,8 +5,31 @@ import (
 	"bytes"
 	"io"
 	"sync"
+	"sync/atomic"
 )
 
+// lowMemoryBufferSize replaces MaxBufferSize as the pool limit in low-memory mode
+const lowMemoryBufferSize = 16 * 1024
+
+// pooledBufferLimit is the largest buffer the pools keep, and the size of
+// pooled bufio.Writers
+var pooledBufferLimit atomic.Int64
+
+func init() {
+	pooledBufferLimit.Store(MaxBufferSize)
+}
+
+// SetLowMemory shrinks the shared pools and caps the default worker count,
+// trading throughput for a lower peak heap
+func SetLowMemory(on bool) {
+	lowMemory.Store(on)
+	if on {
+		pooledBufferLimit.Store(lowMemoryBufferSize)
+	} else {
+		pooledBufferLimit.Store(MaxBufferSize)
+	}
+}
+
 // BufferPool manages a pool of reusable bytes.Buffer objects
 // to reduce memory allocations during high-throughput operations.
 type BufferPool struct {
@@ -32,7 +55,7 @@ func (p *BufferPool) Get() *bytes.Buffer {
 // Put returns a buffer to the pool, resetting it for reuse.
 // If the buffer is too large, it is discarded to prevent memory hoarding.
 func (p *BufferPool) Put(buf *bytes.Buffer) {
-	if buf.Cap() > MaxBufferSize {
+	if int64(buf.Cap()) > pooledBufferLimit.Load() {
 		return
 	}
 	buf.Reset()
@@ -62,7 +85,7 @@ func (p *BufioWriterPool) Get(w io.Writer) *bufio.Writer {
 		writer.Reset(w)
 		return writer
 	}
-	return bufio.NewWriterSize(w, MaxBufferSize)
+	return bufio.NewWriterSize(w, int(pooledBufferLimit.Load()))
 }
 
 // Put returns a bufio.Writer to the pool
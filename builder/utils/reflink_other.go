@@ -0,0 +1,12 @@
+//go:build !linux
+
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+func reflink(src, dst *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// ErrNoSass is returned when a theme has Sass stylesheets but Dart Sass isn't
+// installed
+var ErrNoSass = errors.New("sass not found on PATH (install Dart Sass from https://sass-lang.com/install, or set sass.binary in kosh.yaml)")
+
+// SassOptions tells BuildAssetsEsbuild how to compile Sass stylesheets
+type SassOptions struct {
+	Binary    string   // Dart Sass executable; empty looks up "sass" on the PATH
+	LoadPaths []string // Extra directories for @use and @import
+}
+
+// IsStylesheet reports whether path is a CSS, SCSS or Sass file
+func IsStylesheet(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".css", ".scss", ".sass":
+		return true
+	}
+	return false
+}
+
+// isSass reports whether path is a Sass stylesheet (either syntax)
+func isSass(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".scss" || ext == ".sass"
+}
+
+// isSassPartial reports whether path is a partial (_name.scss): partials
+// are only pulled in by @use and @import, never compiled on their own
+func isSassPartial(path string) bool {
+	return isSass(path) && strings.HasPrefix(filepath.Base(path), "_")
+}
+
+// sassBinary finds the Dart Sass executable
+func sassBinary(opts SassOptions) (string, error) {
+	name := opts.Binary
+	if name == "" {
+		name = "sass"
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		if opts.Binary != "" {
+			return "", fmt.Errorf("sass.binary %q: %w", opts.Binary, err)
+		}
+		return "", ErrNoSass
+	}
+	return bin, nil
+}
+
+// compileSass runs Dart Sass on a stylesheet and returns its CSS. Relative
+// @use and @import resolve from the file's directory; source maps and
+// minification are left to esbuild.
+func compileSass(bin, path string, loadPaths []string) (string, error) {
+	args := []string{"--no-source-map", "--style=expanded"}
+	for _, dir := range loadPaths {
+		args = append(args, "--load-path="+dir)
+	}
+	args = append(args, path)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("sass: %s", msg)
+		}
+		return "", fmt.Errorf("sass: %w", err)
+	}
+	return string(out), nil
+}
+
+// sassPlugin makes esbuild load .scss and .sass files, whether entry points
+// or imported from CSS, as the CSS Dart Sass compiles them to. Their output
+// files get a .css extension like any stylesheet.
+func sassPlugin(bin string, loadPaths []string) api.Plugin {
+	return api.Plugin{
+		Name: "sass",
+		Setup: func(build api.PluginBuild) {
+			build.OnLoad(api.OnLoadOptions{Filter: `\.s[ac]ss$`}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				css, err := compileSass(bin, args.Path, loadPaths)
+				if err != nil {
+					return api.OnLoadResult{}, err
+				}
+				return api.OnLoadResult{
+					Contents:   &css,
+					Loader:     api.LoaderCSS,
+					ResolveDir: filepath.Dir(args.Path), // url() stays relative to the stylesheet
+				}, nil
+			})
+		},
+	}
+}
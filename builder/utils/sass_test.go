@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// fakeSass writes a stand-in for Dart Sass that drops Sass-only lines
+// ($variables, @use) and records the files it compiled
+func fakeSass(t *testing.T) (bin, log string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sass is a shell script")
+	}
+	dir := t.TempDir()
+	bin = filepath.Join(dir, "sass")
+	log = filepath.Join(dir, "compiled.log")
+	script := "#!/bin/sh\nfor f; do :; done\necho \"$f\" >> " + log + "\ngrep -v -e '^\\$' -e '^@use' \"$f\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bin, log
+}
+
+func writeAssets(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBuildAssetsSass(t *testing.T) {
+	bin, log := fakeSass(t)
+	src := writeAssets(t, map[string]string{
+		"css/main.scss":   "@use 'vars';\n.nav {\n  color: red;\n}\n",
+		"css/_vars.scss":  "$brand: red;\n",
+		"css/plain.css":   "body { margin: 0 }\n",
+		"css/indent.sass": ".a\n",
+	})
+	destFs := afero.NewMemMapFs()
+	dest := "/public/static"
+
+	assets, err := BuildAssetsEsbuild(afero.NewOsFs(), destFs, src, dest, nil, "", false, AssetOptions{Sass: SassOptions{Binary: bin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assets["/static/css/main.css"]; got != "/static/css/main.css" {
+		t.Errorf(`assets["/static/css/main.css"] = %q`, got)
+	}
+	if _, ok := assets["/static/css/plain.css"]; !ok {
+		t.Error("plain CSS is no longer bundled")
+	}
+	css, err := afero.ReadFile(destFs, dest+"/css/main.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(css), ".nav") || strings.Contains(string(css), "@use") {
+		t.Errorf("main.css was not compiled:\n%s", css)
+	}
+	for _, name := range []string{"css/_vars.css", "css/main.scss"} {
+		if ok, _ := afero.Exists(destFs, dest+"/"+name); ok {
+			t.Errorf("%s was published", name)
+		}
+	}
+	compiled, _ := os.ReadFile(log)
+	if strings.Contains(string(compiled), "_vars") || strings.Count(string(compiled), "\n") != 2 {
+		t.Errorf("compiled:\n%s\nwant main.scss and indent.sass only", compiled)
+	}
+
+	// Minified builds publish hashed names under the same key
+	assets, err = BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), src, dest, nil, "", false, AssetOptions{Minify: true, Sass: SassOptions{Binary: bin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := assets["/static/css/main.css"]; !strings.HasPrefix(got, "/static/css/main.") || got == "/static/css/main.css" || !strings.HasSuffix(got, ".css") {
+		t.Errorf("minified main.css published as %q", got)
+	}
+}
+
+func TestBuildAssetsSassErrors(t *testing.T) {
+	src := writeAssets(t, map[string]string{"css/main.scss": ".a { }\n"})
+
+	t.Setenv("PATH", t.TempDir())
+	_, err := BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), src, "/public/static", nil, "", false, AssetOptions{})
+	if !errors.Is(err, ErrNoSass) {
+		t.Errorf("without sass: err = %v, want ErrNoSass", err)
+	}
+
+	// Only Sass needs Dart Sass
+	plain := writeAssets(t, map[string]string{"css/main.css": ".a { }\n"})
+	if _, err := BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), plain, "/public/static", nil, "", false, AssetOptions{}); err != nil {
+		t.Errorf("plain CSS without sass: %v", err)
+	}
+}
+
+func TestBuildAssetsDartSass(t *testing.T) {
+	if _, err := exec.LookPath("sass"); err != nil {
+		t.Skip("Dart Sass is not installed")
+	}
+	src := writeAssets(t, map[string]string{
+		"css/main.scss":  "@use 'vars';\n.nav { a { color: vars.$brand; } }\n",
+		"css/_vars.scss": "$brand: #c00;\n",
+	})
+	destFs := afero.NewMemMapFs()
+	if _, err := BuildAssetsEsbuild(afero.NewOsFs(), destFs, src, "/public/static", nil, "", false, AssetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	css, _ := afero.ReadFile(destFs, "/public/static/css/main.css")
+	if !strings.Contains(string(css), ".nav a") || !strings.Contains(string(css), "#c00") {
+		t.Errorf("main.css:\n%s", css)
+	}
+
+	broken := writeAssets(t, map[string]string{"css/main.scss": ".a { color: $missing; }\n"})
+	if _, err := BuildAssetsEsbuild(afero.NewOsFs(), afero.NewMemMapFs(), broken, "/public/static", nil, "", false, AssetOptions{}); err == nil {
+		t.Error("an undefined variable compiled")
+	}
+}
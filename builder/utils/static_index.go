@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+)
+
+// StaticFile is the recorded state of one copied static file
+type StaticFile struct {
+	Source  string `msgpack:"source"` // Source path the output was copied from
+	Size    int64  `msgpack:"size"`
+	ModTime int64  `msgpack:"mtime"`             // UnixNano
+	Hash    string `msgpack:"hash"`              // BLAKE3 of the contents
+	Options string `msgpack:"options,omitempty"` // How an image was published (ImageOptions), "" for plain copies
+}
+
+// StaticIndex tracks static files across builds so CopyDirVFS can skip files
+// whose contents haven't changed and whose output is already on disk.
+// Files are only hashed when their size or mtime differs from the record.
+// Safe for concurrent use by the copy workers.
+type StaticIndex struct {
+	mu           sync.Mutex
+	previous     map[string]StaticFile
+	sources      map[string]StaticFile // previous by source path, built on first use
+	current      map[string]StaticFile
+	written      map[string]bool // Destinations copied during this build
+	skipped      int
+	skippedBytes int64
+}
+
+// NewStaticIndex creates an index seeded with the files recorded by the last
+// build, keyed by output path. previous may be nil.
+func NewStaticIndex(previous map[string]StaticFile) *StaticIndex {
+	if previous == nil {
+		previous = make(map[string]StaticFile)
+	}
+	return &StaticIndex{
+		previous: previous,
+		current:  make(map[string]StaticFile),
+		written:  make(map[string]bool),
+	}
+}
+
+// unchanged reports whether dst was last copied from srcPath, with the same
+// options, and the source still matches its record, and records its current
+// state. The source is
+// hashed only when size or mtime differ, so untouched assets are never read.
+func (idx *StaticIndex) unchanged(srcFs afero.Fs, srcPath, dst string, info os.FileInfo, options string) (bool, error) {
+	state := StaticFile{Source: srcPath, Size: info.Size(), ModTime: info.ModTime().UnixNano(), Options: options}
+
+	idx.mu.Lock()
+	prev, ok := idx.previous[dst]
+	idx.mu.Unlock()
+	// An output that switched sources (a site file overriding or no longer
+	// overriding a theme file) is always copied again
+	ok = ok && prev.Source == srcPath && prev.Options == options
+
+	if ok && prev.Size == state.Size && prev.ModTime == state.ModTime {
+		state.Hash = prev.Hash
+	} else {
+		hash, err := hashFileVFS(srcFs, srcPath)
+		if err != nil {
+			return false, err
+		}
+		state.Hash = hash
+	}
+
+	idx.mu.Lock()
+	idx.current[dst] = state
+	idx.mu.Unlock()
+	return ok && prev.Hash == state.Hash, nil
+}
+
+// sourceHash returns the BLAKE3 of srcPath, taken from the last build's
+// record of it while its size and mtime match. idx may be nil.
+func (idx *StaticIndex) sourceHash(srcFs afero.Fs, srcPath string, info os.FileInfo) (string, error) {
+	if idx != nil {
+		idx.mu.Lock()
+		if idx.sources == nil {
+			idx.sources = make(map[string]StaticFile, len(idx.previous))
+			for _, state := range idx.previous {
+				idx.sources[state.Source] = state
+			}
+		}
+		prev, ok := idx.sources[srcPath]
+		idx.mu.Unlock()
+		if ok && prev.Size == info.Size() && prev.ModTime == info.ModTime().UnixNano() {
+			return prev.Hash, nil
+		}
+	}
+	return hashFileVFS(srcFs, srcPath)
+}
+
+// markWritten records that dst was copied during this build. A later copy into
+// the same destination (site static overriding theme static) must not be
+// skipped, or the earlier file would win.
+func (idx *StaticIndex) markWritten(dst string) {
+	idx.mu.Lock()
+	idx.written[dst] = true
+	idx.mu.Unlock()
+}
+
+func (idx *StaticIndex) wasWritten(dst string) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.written[dst]
+}
+
+func (idx *StaticIndex) recordSkip(size int64) {
+	idx.mu.Lock()
+	idx.skipped++
+	idx.skippedBytes += size
+	idx.mu.Unlock()
+}
+
+// Files returns the state of every file seen by this build, to be stored for
+// the next one. Files removed from static/ drop out of the record.
+func (idx *StaticIndex) Files() map[string]StaticFile {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	files := make(map[string]StaticFile, len(idx.current))
+	for path, state := range idx.current {
+		files[path] = state
+	}
+	return files
+}
+
+// Skipped returns the number and total size of files that weren't copied
+func (idx *StaticIndex) Skipped() (int, int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.skipped, idx.skippedBytes
+}
+
+func hashFileVFS(fsys afero.Fs, path string) (string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := blake3.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// copyStatic runs one build's static copy into a fresh VFS and syncs the
+// result to disk, returning the files written to the VFS
+func copyStatic(t *testing.T, srcFs afero.Fs, srcDirs []string, dstDir string, index *StaticIndex) []string {
+	t.Helper()
+	destFs := afero.NewMemMapFs()
+	var written []string
+	for _, srcDir := range srcDirs {
+		if err := CopyDirVFS(srcFs, destFs, srcDir, dstDir, ImageOptions{}, nil, func(p string) { written = append(written, p) }, "", 2, index, nil); err != nil {
+			t.Fatalf("CopyDirVFS(%s) failed: %v", srcDir, err)
+		}
+	}
+	for _, p := range written {
+		data, err := afero.ReadFile(destFs, p)
+		if err != nil {
+			t.Fatalf("written file %s missing from VFS: %v", p, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return written
+}
+
+func TestCopyDirVFS_SkipsUnchangedStatic(t *testing.T) {
+	srcFs := afero.NewMemMapFs()
+	_ = afero.WriteFile(srcFs, "static/video.mp4", []byte("large video bytes"), 0644)
+	_ = afero.WriteFile(srcFs, "static/fonts/a.woff2", []byte("font"), 0644)
+	dstDir := filepath.Join(t.TempDir(), "public", "static")
+
+	first := NewStaticIndex(nil)
+	if written := copyStatic(t, srcFs, []string{"static"}, dstDir, first); len(written) != 2 {
+		t.Fatalf("first build wrote %d files, want 2", len(written))
+	}
+	if n, _ := first.Skipped(); n != 0 {
+		t.Errorf("first build skipped %d files, want 0", n)
+	}
+
+	second := NewStaticIndex(first.Files())
+	if written := copyStatic(t, srcFs, []string{"static"}, dstDir, second); len(written) != 0 {
+		t.Errorf("unchanged build wrote %v, want nothing", written)
+	}
+	if n, bytes := second.Skipped(); n != 2 || bytes != int64(len("large video bytes")+len("font")) {
+		t.Errorf("Skipped() = %d, %d", n, bytes)
+	}
+
+	// A changed file is copied, the rest stays skipped
+	_ = afero.WriteFile(srcFs, "static/fonts/a.woff2", []byte("font v2"), 0644)
+	third := NewStaticIndex(second.Files())
+	written := copyStatic(t, srcFs, []string{"static"}, dstDir, third)
+	if len(written) != 1 || filepath.Base(written[0]) != "a.woff2" {
+		t.Errorf("changed build wrote %v, want only a.woff2", written)
+	}
+
+	// A missing output is copied even though the source is unchanged
+	_ = os.Remove(filepath.Join(dstDir, "video.mp4"))
+	fourth := NewStaticIndex(third.Files())
+	written = copyStatic(t, srcFs, []string{"static"}, dstDir, fourth)
+	if len(written) != 1 || filepath.Base(written[0]) != "video.mp4" {
+		t.Errorf("build after deleting output wrote %v, want only video.mp4", written)
+	}
+}
+
+func TestCopyDirVFS_SiteOverridesTheme(t *testing.T) {
+	srcFs := afero.NewMemMapFs()
+	_ = afero.WriteFile(srcFs, "theme/static/logo.svg", []byte("theme"), 0644)
+	_ = afero.WriteFile(srcFs, "static/logo.svg", []byte("site!"), 0644)
+	dstDir := filepath.Join(t.TempDir(), "public", "static")
+	dirs := []string{"theme/static", "static"}
+	out := filepath.Join(dstDir, "logo.svg")
+
+	first := NewStaticIndex(nil)
+	copyStatic(t, srcFs, dirs, dstDir, first)
+
+	// The theme file changes; the site override must still win
+	_ = afero.WriteFile(srcFs, "theme/static/logo.svg", []byte("theme v2"), 0644)
+	second := NewStaticIndex(first.Files())
+	copyStatic(t, srcFs, dirs, dstDir, second)
+	if data, _ := os.ReadFile(out); string(data) != "site!" {
+		t.Errorf("logo.svg = %q, want the site override", data)
+	}
+
+	// Removing the override (same size as the theme file) restores the theme file
+	_ = srcFs.Remove("static/logo.svg")
+	_ = afero.WriteFile(srcFs, "theme/static/logo.svg", []byte("theme"), 0644)
+	third := NewStaticIndex(second.Files())
+	copyStatic(t, srcFs, dirs, dstDir, third)
+	if data, _ := os.ReadFile(out); string(data) != "theme" {
+		t.Errorf("logo.svg = %q, want the theme file", data)
+	}
+}
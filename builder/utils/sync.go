@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
 	"os"
@@ -11,6 +12,9 @@ import (
 	"sync"
 
 	"github.com/spf13/afero"
+	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 )
 
 var (
@@ -25,24 +29,49 @@ var (
 
 // alwaysSyncPaths contains paths that should always be synced regardless of dirty state
 var alwaysSyncPaths = map[string]bool{
-	".nojekyll":               true,
-	"sitemap.xml":             true,
-	"sitemap/sitemap.xml":     true,
-	"rss.xml":                 true,
-	"search_index.json":       true,
-	"search.bin":              true,
-	"manifest.json":           true,
-	"sw.js":                   true,
-	"graph.json":              true,
-	"static/search.wasm":      true,
-	"static/wasm/search.wasm": true,
+	".nojekyll":                   true,
+	"sitemap.xml":                 true,
+	"sitemap/sitemap.xml":         true,
+	"robots.txt":                  true,
+	"rss.xml":                     true,
+	"search_index.json":           true,
+	"search.bin":                  true,
+	"manifest.webmanifest":        true,
+	"favicon.ico":                 true,
+	"apple-touch-icon.png":        true,
+	"sw.js":                       true,
+	"graph.json":                  true,
+	".well-known/webfinger":       true,
+	".well-known/security.txt":    true,
+	".well-known/change-password": true,
+	"static/search.wasm":          true,
+	"static/wasm/search.wasm":     true,
+}
+
+// OutputFile is the recorded state of one file SyncVFS put on disk. While
+// the file's size and mtime still match, its hash can be trusted without
+// reading it again (kosh deploy does).
+type OutputFile struct {
+	Size    int64  `msgpack:"size"`
+	ModTime int64  `msgpack:"mtime"` // UnixNano
+	Hash    string `msgpack:"hash"`  // BLAKE3 of the contents
 }
 
-func SyncVFS(srcFs afero.Fs, targetDir string, dirtyFiles map[string]bool) error {
-	fmt.Println("💾 Syncing in-memory filesystem to disk...")
+// SyncVFS writes the files of srcFs under targetDir to disk, skipping those
+// whose content is already there. With linkDest (a previous output
+// directory), files identical to their copy in it are linked instead of
+// written. It returns the state of every file it synced, keyed by
+// slash-separated path relative to targetDir.
+func SyncVFS(srcFs afero.Fs, targetDir, linkDest string, dirtyFiles map[string]bool) (map[string]OutputFile, error) {
+	logging.Statusf("💾 Syncing in-memory filesystem to disk...")
 
 	targetDirClean := filepath.Clean(targetDir)
 
+	var linker *outputLinker
+	if linkDest != "" && filepath.Clean(linkDest) != targetDirClean {
+		linker = &outputLinker{targetDir: targetDirClean, linkDest: filepath.Clean(linkDest)}
+	}
+
 	var filesToSync []string
 	err := afero.Walk(srcFs, targetDirClean, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
@@ -79,7 +108,7 @@ func SyncVFS(srcFs afero.Fs, targetDir string, dirtyFiles map[string]bool) error
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to scan VFS: %w", err)
+		return nil, fmt.Errorf("failed to scan VFS: %w", err)
 	}
 
 	numWorkers := runtime.NumCPU() * 2
@@ -92,15 +121,24 @@ func SyncVFS(srcFs afero.Fs, targetDir string, dirtyFiles map[string]bool) error
 	errChan := make(chan error, len(filesToSync))
 	var firstErr error
 	var errOnce sync.Once
+	synced := make(map[string]OutputFile, len(filesToSync))
+	var syncedMu sync.Mutex
 
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for path := range fileChan {
-				if err := syncSingleFile(srcFs, path); err != nil {
+				state, err := syncSingleFile(srcFs, path, linker)
+				if err != nil {
 					errOnce.Do(func() { firstErr = err })
 					errChan <- err
+					continue
+				}
+				if rel, err := filepath.Rel(targetDirClean, path); err == nil && state.Hash != "" {
+					syncedMu.Lock()
+					synced[filepath.ToSlash(rel)] = state
+					syncedMu.Unlock()
 				}
 			}
 		}()
@@ -114,18 +152,35 @@ func SyncVFS(srcFs afero.Fs, targetDir string, dirtyFiles map[string]bool) error
 	close(errChan)
 
 	if firstErr != nil {
-		return firstErr
+		return nil, firstErr
 	}
 
-	return nil
+	if linker != nil {
+		logging.Statusf("🔗 Linked %d unchanged files from %s", linker.linked.Load(), linkDest)
+	}
+	return synced, nil
 }
 
-func syncSingleFile(srcFs afero.Fs, path string) error {
+// syncSingleFile puts one file on disk and returns its state there, zero
+// when it can't be told
+func syncSingleFile(srcFs afero.Fs, path string, linker *outputLinker) (OutputFile, error) {
 	srcContent, err := afero.ReadFile(srcFs, path)
 	if err != nil {
-		return err
+		return OutputFile{}, err
+	}
+	if err := writeSyncedFile(path, srcContent, linker); err != nil {
+		return OutputFile{}, err
+	}
+	info, err := os.Stat(filepath.FromSlash(path))
+	if err != nil {
+		return OutputFile{}, nil
 	}
+	sum := blake3.Sum256(srcContent)
+	return OutputFile{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: hex.EncodeToString(sum[:])}, nil
+}
 
+// writeSyncedFile writes srcContent to path unless it is already there
+func writeSyncedFile(path string, srcContent []byte, linker *outputLinker) error {
 	osPath := filepath.FromSlash(path)
 
 	// Check content cache first
@@ -173,8 +228,12 @@ func syncSingleFile(srcFs afero.Fs, path string) error {
 		createdDirsMu.Unlock()
 	}
 
-	if err := os.WriteFile(osPath, srcContent, 0644); err != nil {
-		return err
+	// Replace rather than write in place: the file may be a hardlink into
+	// an older release, from a linkDest build into this directory
+	if linker == nil || !linker.link(osPath, srcContent) {
+		if err := writeFileReplace(osPath, srcContent); err != nil {
+			return err
+		}
 	}
 
 	// Update cache after successful write
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// outputLinker shares the files of a sync that are unchanged from a previous
+// output (rsync's --link-dest): they are reflinked where the filesystem can
+// clone, hardlinked otherwise, instead of written again. A fresh release
+// directory then only costs the pages that changed.
+type outputLinker struct {
+	targetDir  string
+	linkDest   string
+	linked     atomic.Int64
+	noReflink  atomic.Bool // Set after the first failed clone: the filesystem can't
+	noHardlink atomic.Bool // Set after the first failed link: another device
+}
+
+// link makes osPath share the previous output's copy of the file if its
+// content is content. It reports whether it did.
+func (l *outputLinker) link(osPath string, content []byte) bool {
+	rel, err := filepath.Rel(l.targetDir, osPath)
+	if err != nil {
+		return false
+	}
+	prev := filepath.Join(l.linkDest, rel)
+	info, err := os.Stat(prev)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != int64(len(content)) {
+		return false
+	}
+	prevContent, err := os.ReadFile(prev)
+	if err != nil || !bytes.Equal(prevContent, content) {
+		return false
+	}
+
+	tmp := osPath + ".kosh-link"
+	_ = os.Remove(tmp)
+	if !l.reflink(prev, tmp) && !l.hardlink(prev, tmp) {
+		return false
+	}
+	if err := os.Rename(tmp, osPath); err != nil {
+		_ = os.Remove(tmp)
+		return false
+	}
+	l.linked.Add(1)
+	return true
+}
+
+func (l *outputLinker) reflink(src, dst string) bool {
+	if l.noReflink.Load() {
+		return false
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return false
+	}
+	err = reflink(in, out)
+	_ = out.Close()
+	if err != nil {
+		l.noReflink.Store(true)
+		_ = os.Remove(dst)
+		return false
+	}
+	return true
+}
+
+func (l *outputLinker) hardlink(src, dst string) bool {
+	if l.noHardlink.Load() {
+		return false
+	}
+	if err := os.Link(src, dst); err != nil {
+		l.noHardlink.Store(true)
+		return false
+	}
+	return true
+}
+
+// writeFileReplace writes content to a new file renamed over path, so a file
+// hardlinked into a previous output is replaced rather than changed in place
+func writeFileReplace(path string, content []byte) error {
+	tmp := path + ".kosh-tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
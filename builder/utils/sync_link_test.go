@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestSyncVFSLinkDest(t *testing.T) {
+	prev, target := t.TempDir(), filepath.Join(t.TempDir(), "public")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(prev, "posts", "same.html"), "unchanged")
+	writeFile(filepath.Join(prev, "changed.html"), "old")
+
+	mem := afero.NewMemMapFs()
+	for path, content := range map[string]string{"posts/same.html": "unchanged", "changed.html": "new", "added.html": "new"} {
+		if err := afero.WriteFile(mem, filepath.Join(target, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	synced, err := SyncVFS(mem, target, prev, nil)
+	if err != nil {
+		t.Fatalf("SyncVFS failed: %v", err)
+	}
+
+	for path, want := range map[string]string{"posts/same.html": "unchanged", "changed.html": "new", "added.html": "new"} {
+		if got, _ := os.ReadFile(filepath.Join(target, path)); string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	if len(synced) != 3 {
+		t.Errorf("SyncVFS recorded %v, want the 3 files", synced)
+	}
+	addedInfo, _ := os.Stat(filepath.Join(target, "added.html"))
+	addedHash, _ := hashFileVFS(afero.NewOsFs(), filepath.Join(target, "added.html"))
+	if state := synced["added.html"]; addedInfo == nil || state.Size != 3 || state.ModTime != addedInfo.ModTime().UnixNano() || state.Hash != addedHash {
+		t.Errorf("added.html recorded as %+v", state)
+	}
+	prevInfo, _ := os.Stat(filepath.Join(prev, "posts", "same.html"))
+	linkedInfo, _ := os.Stat(filepath.Join(target, "posts", "same.html"))
+	if prevInfo == nil || linkedInfo == nil {
+		t.Fatal("unchanged file missing")
+	}
+	if !os.SameFile(prevInfo, linkedInfo) {
+		t.Log("posts/same.html was cloned (reflink) rather than hardlinked")
+	}
+	if got, _ := os.ReadFile(filepath.Join(prev, "changed.html")); string(got) != "old" {
+		t.Errorf("previous output changed: %q", got)
+	}
+
+	// A later sync into the same directory must not write through the link
+	if err := afero.WriteFile(mem, filepath.Join(target, "posts", "same.html"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SyncVFS(mem, target, prev, nil); err != nil {
+		t.Fatalf("SyncVFS failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(prev, "posts", "same.html")); string(got) != "unchanged" {
+		t.Errorf("previous output changed through the link: %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(target, "posts", "same.html")); string(got) != "edited" {
+		t.Errorf("posts/same.html = %q, want \"edited\"", got)
+	}
+}
+
+func TestSyncVFSWithoutLinkDestKeepsLinkedRelease(t *testing.T) {
+	prev, target := t.TempDir(), filepath.Join(t.TempDir(), "public")
+	if err := os.WriteFile(filepath.Join(prev, "page.html"), []byte("release 1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mem := afero.NewMemMapFs()
+	if err := afero.WriteFile(mem, filepath.Join(target, "page.html"), []byte("release 1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SyncVFS(mem, target, prev, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later build without linkDest writes into the same directory
+	if err := afero.WriteFile(mem, filepath.Join(target, "page.html"), []byte("release 2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SyncVFS(mem, target, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(prev, "page.html")); string(got) != "release 1" {
+		t.Errorf("linked release changed: %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(target, "page.html")); string(got) != "release 2" {
+		t.Errorf("page.html = %q, want \"release 2\"", got)
+	}
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"path/filepath"
 	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
 )
 
 // GetVersionFromPath extracts version from file path
@@ -70,3 +72,18 @@ func GetVersionFromURL(urlPath string) (version, cleanPath string) {
 
 	return "", "/" + urlPath
 }
+
+// FindVersion looks a version up in a page's versions by path or name, or
+// "latest" for the latest version
+func FindVersion(versions []models.VersionInfo, name string) (models.VersionInfo, bool) {
+	name = strings.TrimSpace(name)
+	for _, v := range versions {
+		if strings.EqualFold(name, "latest") && v.IsLatest {
+			return v, true
+		}
+		if name != "" && (v.Path == name || strings.TrimSuffix(v.Name, " (Latest)") == name) {
+			return v, true
+		}
+	}
+	return models.VersionInfo{}, false
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+func TestFindVersion(t *testing.T) {
+	versions := []models.VersionInfo{
+		{Name: "v2.0 (Latest)", Path: "", URL: "/guide.html", IsLatest: true},
+		{Name: "v1.0", Path: "v1.0", URL: "/v1.0/guide.html"},
+	}
+	tests := []struct {
+		name    string
+		wantURL string
+		wantOK  bool
+	}{
+		{"latest", "/guide.html", true},
+		{"Latest", "/guide.html", true},
+		{"v2.0", "/guide.html", true},
+		{"v1.0", "/v1.0/guide.html", true},
+		{"v3.0", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := FindVersion(versions, tt.name)
+		if v.URL != tt.wantURL || ok != tt.wantOK {
+			t.Errorf("FindVersion(%q) = %q, %v, want %q, %v", tt.name, v.URL, ok, tt.wantURL, tt.wantOK)
+		}
+	}
+}
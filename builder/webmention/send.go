@@ -0,0 +1,222 @@
+package webmention
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultSendInterval spaces out requests so a large first run doesn't hammer other sites
+const DefaultSendInterval = time.Second
+
+const maxDiscoveryBody = 1024 * 1024
+
+var (
+	hrefPattern    = regexp.MustCompile(`<a\s[^>]*href="(https?://[^"#]+)[^"]*"`)
+	linkTagPattern = regexp.MustCompile(`(?i)<(?:link|a)\s[^>]*>`)
+	relPattern     = regexp.MustCompile(`(?i)\brel="([^"]*)"`)
+	attrHref       = regexp.MustCompile(`(?i)\bhref="([^"]*)"`)
+	headerLink     = regexp.MustCompile(`<([^>]*)>\s*;[^,]*\brel="?([^",]*)"?`)
+)
+
+// ExternalLinks returns the distinct absolute links inside the <article> of a
+// rendered page that point away from siteURL. Pages without an article (lists,
+// tags) return nil, and navigation or footer links are never included.
+func ExternalLinks(page []byte, siteURL string) []string {
+	start := bytes.Index(page, []byte("<article"))
+	end := bytes.LastIndex(page, []byte("</article>"))
+	if start < 0 || end < start {
+		return nil
+	}
+	page = page[start:end]
+
+	siteHost := ""
+	if u, err := url.Parse(siteURL); err == nil {
+		siteHost = u.Host
+	}
+
+	var links []string
+	for _, m := range hrefPattern.FindAllSubmatch(page, -1) {
+		link := string(m[1])
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" || u.Host == siteHost {
+			continue
+		}
+		if !slices.Contains(links, link) {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+	return links
+}
+
+// Sender sends webmentions and records which source → target pairs were
+// handled in cacheDir/webmentions/sent.json, so only new links are sent.
+type Sender struct {
+	path     string
+	http     *http.Client
+	interval time.Duration
+	logger   *slog.Logger
+	sent     map[string][]string
+}
+
+// NewSender creates a Sender and loads the record of previously sent mentions
+func NewSender(cacheDir string, timeout time.Duration, logger *slog.Logger) *Sender {
+	s := &Sender{
+		path:     filepath.Join(cacheDir, Dir, "sent.json"),
+		http:     &http.Client{Timeout: timeout},
+		interval: DefaultSendInterval,
+		logger:   logger,
+		sent:     make(map[string][]string),
+	}
+	if data, err := os.ReadFile(s.path); err == nil {
+		_ = json.Unmarshal(data, &s.sent)
+	}
+	return s
+}
+
+// SendNew sends a webmention from each source page to every linked target that
+// wasn't handled before. pages maps source URLs to their outgoing links.
+// Targets without a webmention endpoint are recorded too, so they aren't
+// rediscovered on every build. It returns the number of mentions sent.
+func (s *Sender) SendNew(ctx context.Context, pages map[string][]string) (int, error) {
+	sources := make([]string, 0, len(pages))
+	for source := range pages {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	sent := 0
+	first := true
+	for _, source := range sources {
+		for _, target := range pages[source] {
+			if slices.Contains(s.sent[source], target) {
+				continue
+			}
+
+			if !first {
+				select {
+				case <-ctx.Done():
+					return sent, ctx.Err()
+				case <-time.After(s.interval):
+				}
+			}
+			first = false
+
+			ok, err := s.send(ctx, source, target)
+			if err != nil {
+				s.logger.Warn("Failed to send webmention", "source", source, "target", target, "error", err)
+				continue // Retry on the next build
+			}
+			if ok {
+				sent++
+			}
+			s.sent[source] = append(s.sent[source], target)
+		}
+	}
+	return sent, s.save()
+}
+
+// send delivers one webmention. It reports false when the target has no endpoint.
+func (s *Sender) send(ctx context.Context, source, target string) (bool, error) {
+	endpoint, err := s.discover(ctx, target)
+	if err != nil || endpoint == "" {
+		return false, err
+	}
+
+	form := url.Values{"source": {source}, "target": {target}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, fmt.Errorf("endpoint %s returned %s", endpoint, resp.Status)
+	}
+	return true, nil
+}
+
+// discover finds the webmention endpoint of target from its Link header or
+// a <link>/<a> element with rel="webmention"
+func (s *Sender) discover(ctx context.Context, target string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	base := resp.Request.URL
+	for _, h := range resp.Header.Values("Link") {
+		for _, m := range headerLink.FindAllStringSubmatch(h, -1) {
+			if hasRel(m[2], "webmention") {
+				return resolve(base, m[1]), nil
+			}
+		}
+	}
+
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDiscoveryBody))
+	if err != nil {
+		return "", err
+	}
+	for _, tag := range linkTagPattern.FindAll(body, -1) {
+		rel := relPattern.FindSubmatch(tag)
+		href := attrHref.FindSubmatch(tag)
+		if rel != nil && href != nil && hasRel(string(rel[1]), "webmention") {
+			return resolve(base, string(href[1])), nil
+		}
+	}
+	return "", nil
+}
+
+func hasRel(rels, want string) bool {
+	for _, r := range strings.Fields(rels) {
+		if strings.EqualFold(r, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolve(base *url.URL, ref string) string {
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func (s *Sender) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s.sent)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
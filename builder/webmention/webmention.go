@@ -0,0 +1,273 @@
+// Package webmention collects received webmentions from webmention.io for
+// templates and sends webmentions for outgoing links found in built pages.
+package webmention
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+)
+
+// Dir is the subdirectory of the cache directory that holds webmention state
+const Dir = "webmentions"
+
+// DefaultRefresh is the minimum time between webmention.io requests
+const DefaultRefresh = time.Hour
+
+const (
+	defaultAPI = "https://webmention.io/api/mentions.jf2"
+	perPage    = 100
+	maxPages   = 50 // Upper bound on requests per refresh
+	maxBody    = 10 * 1024 * 1024
+)
+
+// store is the on-disk cache of received mentions
+type store struct {
+	FetchedAt time.Time           `json:"fetchedAt"`
+	Mentions  []models.Webmention `json:"mentions"`
+}
+
+// Client serves received webmentions to templates. Mentions are loaded once per
+// Client, from the cache when it is fresher than the refresh interval and from
+// webmention.io otherwise; only mentions newer than the cached ones are fetched.
+type Client struct {
+	dir     string
+	domain  string
+	token   string
+	refresh time.Duration
+	offline bool
+	api     string
+	http    *http.Client
+	logger  *slog.Logger
+
+	once     sync.Once
+	byTarget map[string][]models.Webmention
+}
+
+// New creates a Client for the configured domain, falling back to the host of baseURL
+func New(cacheDir string, cfg config.WebmentionsConfig, baseURL string, timeout time.Duration, offline bool, logger *slog.Logger) *Client {
+	domain := cfg.Domain
+	if domain == "" {
+		if u, err := url.Parse(baseURL); err == nil {
+			domain = u.Hostname()
+		}
+	}
+	refresh := DefaultRefresh
+	if cfg.RefreshMinutes > 0 {
+		refresh = time.Duration(cfg.RefreshMinutes) * time.Minute
+	}
+	return &Client{
+		dir:     filepath.Join(cacheDir, Dir),
+		domain:  domain,
+		token:   cfg.Token,
+		refresh: refresh,
+		offline: offline,
+		api:     defaultAPI,
+		http:    &http.Client{Timeout: timeout},
+		logger:  logger,
+	}
+}
+
+// Mentions returns the mentions received by the page at target, oldest first.
+// target may be an absolute URL or a path.
+func (c *Client) Mentions(target string) []models.Webmention {
+	c.once.Do(c.load)
+	return c.byTarget[Key(target)]
+}
+
+// Key normalizes a page URL so "/posts/a", "/posts/a/" and
+// "https://site/posts/a.html" all refer to the same page
+func Key(target string) string {
+	p := target
+	if u, err := url.Parse(target); err == nil {
+		p = u.Path
+	}
+	p = strings.TrimSuffix(p, "/")
+	p = strings.TrimSuffix(p, ".html")
+	p = strings.TrimSuffix(p, "/index")
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+func (c *Client) load() {
+	c.byTarget = make(map[string][]models.Webmention)
+	if c.domain == "" {
+		return
+	}
+
+	st := c.read()
+	if !c.offline && time.Since(st.FetchedAt) >= c.refresh {
+		fresh, err := c.fetchSince(maxID(st.Mentions))
+		if err != nil {
+			c.logger.Warn("Failed to fetch webmentions, using cached copy", "domain", c.domain, "error", err)
+		} else {
+			st.Mentions = merge(st.Mentions, fresh)
+			st.FetchedAt = time.Now()
+			if err := c.write(st); err != nil {
+				c.logger.Warn("Failed to cache webmentions", "error", err)
+			}
+		}
+	}
+
+	for _, m := range st.Mentions {
+		key := Key(m.Target)
+		c.byTarget[key] = append(c.byTarget[key], m)
+	}
+}
+
+func (c *Client) read() store {
+	var st store
+	if data, err := os.ReadFile(filepath.Join(c.dir, "mentions.json")); err == nil {
+		_ = json.Unmarshal(data, &st)
+	}
+	return st
+}
+
+func (c *Client) write(st store) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.dir, "mentions.json"), data, 0644)
+}
+
+// fetchSince pages through the API for mentions with an ID above sinceID
+func (c *Client) fetchSince(sinceID int) ([]models.Webmention, error) {
+	var all []models.Webmention
+	for page := 0; page < maxPages; page++ {
+		q := url.Values{}
+		q.Set("domain", c.domain)
+		q.Set("per-page", strconv.Itoa(perPage))
+		q.Set("page", strconv.Itoa(page))
+		q.Set("sort-dir", "up")
+		if c.token != "" {
+			q.Set("token", c.token)
+		}
+		if sinceID > 0 {
+			q.Set("since_id", strconv.Itoa(sinceID))
+		}
+
+		batch, err := c.fetchPage(c.api + "?" + q.Encode())
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	return all, nil
+}
+
+func (c *Client) fetchPage(u string) ([]models.Webmention, error) {
+	resp, err := c.http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return nil, err
+	}
+	return parseFeed(body)
+}
+
+// jf2Entry is a single mention in webmention.io's JF2 feed
+type jf2Entry struct {
+	ID        int    `json:"wm-id"`
+	Property  string `json:"wm-property"`
+	Source    string `json:"wm-source"`
+	Target    string `json:"wm-target"`
+	Private   bool   `json:"wm-private"`
+	Received  string `json:"wm-received"`
+	URL       string `json:"url"`
+	Published string `json:"published"`
+	Content   struct {
+		Text string `json:"text"`
+	} `json:"content"`
+	Author models.WebmentionAuthor `json:"author"`
+}
+
+// parseFeed decodes a JF2 feed, dropping private mentions
+func parseFeed(data []byte) ([]models.Webmention, error) {
+	var feed struct {
+		Children []jf2Entry `json:"children"`
+	}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("invalid webmention feed: %w", err)
+	}
+
+	mentions := make([]models.Webmention, 0, len(feed.Children))
+	for _, e := range feed.Children {
+		if e.Private {
+			continue
+		}
+		published, err := time.Parse(time.RFC3339, e.Published)
+		if err != nil {
+			published, _ = time.Parse(time.RFC3339, e.Received)
+		}
+		mentions = append(mentions, models.Webmention{
+			ID:        e.ID,
+			Type:      e.Property,
+			Source:    e.Source,
+			Target:    e.Target,
+			URL:       e.URL,
+			Published: published,
+			Content:   e.Content.Text,
+			Author:    e.Author,
+		})
+	}
+	return mentions, nil
+}
+
+// merge adds fresh mentions to cached ones, replacing entries with the same ID
+func merge(cached, fresh []models.Webmention) []models.Webmention {
+	byID := make(map[int]models.Webmention, len(cached)+len(fresh))
+	for _, m := range cached {
+		byID[m.ID] = m
+	}
+	for _, m := range fresh {
+		byID[m.ID] = m
+	}
+
+	out := make([]models.Webmention, 0, len(byID))
+	for _, m := range byID {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Published.Equal(out[j].Published) {
+			return out[i].Published.Before(out[j].Published)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+func maxID(mentions []models.Webmention) int {
+	id := 0
+	for _, m := range mentions {
+		id = max(id, m.ID)
+	}
+	return id
+}
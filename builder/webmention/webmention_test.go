@@ -0,0 +1,159 @@
+package webmention
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://example.com/posts/hello.html", "/posts/hello"},
+		{"https://example.com/posts/hello/", "/posts/hello"},
+		{"/posts/hello/index.html", "/posts/hello"},
+		{"posts/hello", "/posts/hello"},
+		{"https://example.com/", "/"},
+	}
+	for _, tt := range tests {
+		if got := Key(tt.in); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+const feedPage = `{"type":"feed","children":[
+ {"wm-id":2,"wm-property":"like-of","wm-target":"https://example.com/posts/a.html","wm-received":"2026-01-02T00:00:00Z","url":"https://social/1","author":{"name":"Ann"}},
+ {"wm-id":1,"wm-property":"in-reply-to","wm-target":"https://example.com/posts/a/","published":"2026-01-01T00:00:00Z","content":{"text":"Nice"},"author":{"name":"Bob"}},
+ {"wm-id":3,"wm-property":"mention-of","wm-target":"https://example.com/posts/b.html","wm-private":true}
+]}`
+
+func TestClientMentions(t *testing.T) {
+	var hits atomic.Int32
+	var lastSince atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		lastSince.Store(r.URL.Query().Get("since_id"))
+		if r.URL.Query().Get("domain") != "example.com" {
+			http.Error(w, "bad domain", http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprint(w, feedPage)
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	cfg := config.WebmentionsConfig{}
+	c := New(cacheDir, cfg, "https://example.com", time.Second, false, newTestLogger())
+	c.api = srv.URL
+
+	got := c.Mentions("/posts/a/")
+	if len(got) != 2 {
+		t.Fatalf("Mentions() returned %d mentions, want 2", len(got))
+	}
+	if got[0].Author.Name != "Bob" || got[0].Content != "Nice" || got[1].Type != "like-of" {
+		t.Errorf("Mentions() = %+v, want Bob's reply before Ann's like", got)
+	}
+	if n := len(c.Mentions("https://example.com/posts/b.html")); n != 0 {
+		t.Errorf("private mention was exposed (%d mentions)", n)
+	}
+
+	// Within the refresh interval a new build reads the cache only
+	c2 := New(cacheDir, cfg, "https://example.com", time.Second, false, newTestLogger())
+	c2.api = srv.URL
+	if len(c2.Mentions("/posts/a")) != 2 || hits.Load() != 1 {
+		t.Errorf("hits = %d, want 1 (cached)", hits.Load())
+	}
+
+	// After the interval only newer mentions are requested
+	c3 := New(cacheDir, cfg, "https://example.com", time.Second, false, newTestLogger())
+	c3.api = srv.URL
+	c3.refresh = 0
+	if len(c3.Mentions("/posts/a")) != 2 {
+		t.Error("refetch should merge mentions without duplicates")
+	}
+	if hits.Load() != 2 || lastSince.Load() != "2" {
+		t.Errorf("hits = %d, since_id = %v, want 2 and \"2\"", hits.Load(), lastSince.Load())
+	}
+}
+
+func TestExternalLinks(t *testing.T) {
+	page := []byte(`<nav><a href="https://github.com/me">GitHub</a></nav>
+<article>
+<a href="https://example.com/posts/b.html">internal</a>
+<a href="https://other.org/post#section">ext</a>
+<a class="x" href="https://other.org/post">again</a>
+<a href="http://third.net/">third</a>
+</article>`)
+
+	got := ExternalLinks(page, "https://example.com")
+	want := []string{"http://third.net/", "https://other.org/post"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExternalLinks() = %v, want %v", got, want)
+	}
+	if got := ExternalLinks([]byte(`<a href="https://other.org/">x</a>`), "https://example.com"); got != nil {
+		t.Errorf("ExternalLinks() without <article> = %v, want nil", got)
+	}
+}
+
+func TestSenderSendNew(t *testing.T) {
+	var received atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/header", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `</endpoint>; rel="webmention"`)
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<html><head><link rel="webmention" href="/endpoint"></head></html>`)
+	})
+	mux.HandleFunc("/none", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<html></html>`)
+	})
+	mux.HandleFunc("/endpoint", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("source") == "" || r.FormValue("target") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		received.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	pages := map[string][]string{
+		"https://example.com/posts/a.html": {srv.URL + "/header", srv.URL + "/html", srv.URL + "/none"},
+	}
+
+	s := NewSender(cacheDir, time.Second, newTestLogger())
+	s.interval = 0
+	sent, err := s.SendNew(context.Background(), pages)
+	if err != nil {
+		t.Fatalf("SendNew() error = %v", err)
+	}
+	if sent != 2 || received.Load() != 2 {
+		t.Errorf("sent = %d, received = %d, want 2", sent, received.Load())
+	}
+
+	// Already handled links are not sent again by a later build
+	s2 := NewSender(cacheDir, time.Second, newTestLogger())
+	s2.interval = 0
+	if sent, _ := s2.SendNew(context.Background(), pages); sent != 0 || received.Load() != 2 {
+		t.Errorf("second run sent %d mentions, want 0", sent)
+	}
+}
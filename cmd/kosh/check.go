@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/checks"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/parser"
+)
+
+// maxSEOFindingsShown is how many findings of each class are listed
+const maxSEOFindingsShown = 10
+
+// handleCheckCommand processes check subcommands
+func handleCheckCommand(args []string) {
+	if len(args) < 1 {
+		printCheckUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "--changed", "-changed":
+		checkChanged(args[1:])
+	case "seo":
+		asJSON := false
+		for _, arg := range args[1:] {
+			if arg == "--json" || arg == "-json" {
+				asJSON = true
+			}
+		}
+		checkSEO(asJSON)
+	default:
+		fmt.Printf("Unknown check subcommand: %s\n", args[0])
+		printCheckUsage()
+		os.Exit(1)
+	}
+}
+
+func printCheckUsage() {
+	fmt.Println("Usage: kosh check <subcommand> [arguments]")
+	fmt.Println("       kosh check --changed [--staged] [--json] [files...]")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  seo            Audit titles, descriptions, og:image and duplicate pages of the built site")
+	fmt.Println("\nFlags for seo:")
+	fmt.Println("  --json         Print the findings as JSON")
+	fmt.Println("\nFlags for --changed (check content files changed in git, or the files given):")
+	fmt.Println("  --staged       Only files staged for commit (for pre-commit hooks)")
+	fmt.Println("  --json         Print the findings as JSON")
+}
+
+// checkChanged runs the source checks on the content files changed in git, or
+// on the files given, and exits 1 when a finding's class is in strict.checks
+func checkChanged(args []string) {
+	start := time.Now()
+	asJSON, staged := false, false
+	var files []string
+	for _, arg := range args {
+		switch arg {
+		case "--json", "-json":
+			asJSON = true
+		case "--staged", "-staged":
+			staged = true
+		default:
+			files = append(files, arg)
+		}
+	}
+
+	cfg := config.Load([]string{})
+	if len(files) == 0 {
+		changed, err := checks.ChangedFiles(staged)
+		if err != nil {
+			fmt.Printf("❌ Listing changed files: %v\n", err)
+			os.Exit(1)
+		}
+		files = changed
+	}
+	var content []string
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(cfg.ContentDir), abs)
+		if err != nil || strings.HasPrefix(rel, "..") || !strings.HasSuffix(rel, ".md") {
+			continue
+		}
+		content = append(content, filepath.ToSlash(rel))
+	}
+
+	findings, err := checks.CheckFiles(checks.Options{
+		ContentFs:  afero.NewOsFs(),
+		ContentDir: cfg.ContentDir,
+		OutputFs:   afero.NewOsFs(),
+		OutputDir:  cfg.OutputDir,
+		BaseURL:    cfg.BaseURL,
+		Prose:      cfg.Strict.Prose,
+		HTMLPath:   cfg.HTMLPath,
+		Pages:      knownPages(cfg),
+		HeadingIDs: func(_ string, source []byte) ([]string, bool) {
+			// Includes and API references add headings the source doesn't show
+			if parser.HasIncludes(source) || bytes.Contains(source, []byte("\nopenapi:")) {
+				return nil, false
+			}
+			return parser.HeadingIDs(cfg, source), true
+		},
+	}, content)
+	if err != nil {
+		fmt.Printf("❌ Check failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	failing := cfg.Strict.Checks
+	if len(failing) == 0 {
+		for _, c := range checks.Classes {
+			failing = append(failing, string(c))
+		}
+	}
+	failures := 0
+	byClass := make(map[checks.Class][]checks.Finding)
+	for _, f := range findings {
+		byClass[f.Class] = append(byClass[f.Class], f)
+		if slices.Contains(failing, string(f.Class)) {
+			failures++
+		}
+	}
+
+	if asJSON {
+		if findings == nil {
+			findings = []checks.Finding{}
+		}
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, class := range checks.Classes {
+			found := byClass[class]
+			if len(found) == 0 {
+				continue
+			}
+			icon := "⚠️ "
+			if slices.Contains(failing, string(class)) {
+				icon = "❌"
+			}
+			fmt.Printf("%s %s: %d problem(s)\n", icon, class, len(found))
+			for _, f := range found {
+				fmt.Printf("   %s: %s\n", f.Page, f.Message)
+			}
+		}
+		if len(findings) == 0 {
+			fmt.Printf("✅ %d changed content file(s) checked in %s\n", len(content), time.Since(start).Round(time.Millisecond))
+		}
+	}
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
+
+// knownPages maps the site path of every content file to the heading IDs the
+// build cache has for it (nil for pages not built yet). The cache is skipped
+// when missing or held by a running build.
+func knownPages(cfg *config.Config) map[string][]string {
+	pages := make(map[string][]string)
+	_ = filepath.WalkDir(cfg.ContentDir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(p, ".md") {
+			if rel, err := filepath.Rel(cfg.ContentDir, p); err == nil {
+				pages["/"+cfg.HTMLPath(rel)] = nil
+			}
+		}
+		return nil
+	})
+
+	if _, err := os.Stat(filepath.Join(cfg.CacheDir, "meta.db")); err != nil {
+		return pages
+	}
+	cm, err := cache.OpenWithTimeout(cfg.CacheDir, true, 200*time.Millisecond)
+	if err != nil {
+		return pages
+	}
+	defer func() { _ = cm.Close() }()
+	ids, _ := cm.ListAllPosts()
+	posts, _ := cm.GetPostsByIDs(ids)
+	for _, post := range posts {
+		sitePath := "/" + cfg.HTMLPath(post.Path)
+		if _, ok := pages[sitePath]; !ok || post.Path == "" {
+			continue // Deleted since the last build
+		}
+		headings := make([]string, 0, len(post.TOC))
+		for _, h := range post.TOC {
+			headings = append(headings, h.ID)
+		}
+		pages[sitePath] = headings
+	}
+	return pages
+}
+
+// checkSEO audits the output directory and exits 1 when any finding is an error
+func checkSEO(asJSON bool) {
+	cfg := config.Load([]string{})
+	if _, err := os.Stat(cfg.OutputDir); err != nil {
+		fmt.Printf("❌ No built site in %s. Run 'kosh build' first.\n", cfg.OutputDir)
+		os.Exit(1)
+	}
+
+	findings, err := checks.SEO(afero.NewOsFs(), cfg.OutputDir)
+	if err != nil {
+		fmt.Printf("❌ SEO audit failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	errCount := 0
+	byClass := make(map[checks.Class][]checks.Finding)
+	for _, f := range findings {
+		byClass[f.Class] = append(byClass[f.Class], f)
+		if f.Severity == checks.SeverityError {
+			errCount++
+		}
+	}
+
+	if asJSON {
+		if findings == nil {
+			findings = []checks.Finding{}
+		}
+		data, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("🔍 Auditing %s...\n", cfg.OutputDir)
+		for _, class := range checks.SEOClasses {
+			printSEOFindings(class, byClass[class])
+		}
+		if len(findings) == 0 {
+			fmt.Println("✅ No SEO problems found")
+		} else {
+			fmt.Printf("\n%d error(s), %d warning(s)\n", errCount, len(findings)-errCount)
+		}
+	}
+
+	if errCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// printSEOFindings lists the findings of one class, errors first
+func printSEOFindings(class checks.Class, found []checks.Finding) {
+	if len(found) == 0 {
+		return
+	}
+	shown := 0
+	for _, severity := range []checks.Severity{checks.SeverityError, checks.SeverityWarning} {
+		var list []checks.Finding
+		for _, f := range found {
+			if f.Severity == severity {
+				list = append(list, f)
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+		icon := "⚠️ "
+		if severity == checks.SeverityError {
+			icon = "❌"
+		}
+		fmt.Printf("\n%s %s (%s): %d page(s)\n", icon, class, severity, len(list))
+		for _, f := range list {
+			if shown == maxSEOFindingsShown {
+				break
+			}
+			fmt.Printf("   %s: %s\n", f.Page, f.Message)
+			shown++
+		}
+	}
+	if len(found) > shown {
+		fmt.Printf("   (+%d more)\n", len(found)-shown)
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/internal/scaffold"
+)
+
+// argKind is what a command's positional arguments complete to
+type argKind int
+
+const (
+	argNone argKind = iota
+	argFiles
+	argContent    // Markdown files under the content directory
+	argContentDir // Directories under the content directory
+	argVersions   // Version names from kosh.yaml
+)
+
+// completionSpec describes one command or subcommand for completion
+type completionSpec struct {
+	subcommands []string
+	flags       []string
+	args        argKind
+	values      map[string]any // Flag values that differ from flagValues
+}
+
+// globalFlags are accepted by every command
+var globalFlags = []string{"--log-format", "--log-level", "--quiet", "-q"}
+
+// buildExtraFlags are the build flags main handles itself
+var buildExtraFlags = []string{"--watch", "--all", "--cpuprofile", "--memprofile"}
+
+// completionCommands lists every command and "command subcommand" pair.
+// Keep it in step with printUsage.
+var completionCommands = map[string]completionSpec{
+	"init":           {flags: []string{"--template", "--list-templates", "--offline"}, values: map[string]any{"--template": starterNames()}},
+	"new":            {flags: []string{"--from"}},
+	"meta":           {subcommands: []string{"set", "rename"}},
+	"meta set":       {flags: []string{"--dry-run"}, args: argContent},
+	"meta rename":    {flags: []string{"--dry-run"}, args: argContent},
+	"tags":           {subcommands: []string{"list", "rename", "merge"}},
+	"tags rename":    {flags: []string{"--dry-run"}},
+	"tags merge":     {flags: []string{"--dry-run"}},
+	"stats":          {flags: []string{"--json"}},
+	"search":         {subcommands: []string{"report"}},
+	"search report":  {flags: []string{"--json", "--top", "--since", "--file"}},
+	"build":          {}, // Flags come from config.FlagNames
+	"serve":          {flags: []string{"--dev", "--admin", "--search-log", "--theme-dev", "--host", "--port", "-drafts", "-future", "-baseurl"}},
+	"clean":          {flags: []string{"--cache", "--all"}},
+	"deploy":         {flags: []string{"--dry-run", "--force"}},
+	"cache":          {subcommands: []string{"stats", "gc", "verify", "rebuild", "clear", "inspect"}},
+	"cache gc":       {flags: []string{"--dry-run"}},
+	"cache inspect":  {args: argContent},
+	"config":         {subcommands: []string{"check", "resolve"}},
+	"config check":   {args: argFiles},
+	"config resolve": {flags: []string{"--format", "--json"}},
+	"check":          {subcommands: []string{"seo"}, flags: []string{"--changed", "--staged", "--json"}, args: argContent},
+	"check seo":      {flags: []string{"--json"}},
+	"template":       {subcommands: []string{"test"}},
+	"template test":  {flags: []string{"--dir", "--update"}},
+	"test":           {flags: []string{"--dir", "--update"}, args: argFiles}, // Plus the build flags
+	"theme":          {subcommands: []string{"install", "list"}},
+	"theme install":  {flags: []string{"--name", "--ref", "--force"}},
+	"modules":        {subcommands: []string{"list", "update"}},
+	"export":         {subcommands: []string{"email"}},
+	"export email":   {flags: []string{"--out", "--template"}, args: argContent},
+	"gen":            {subcommands: []string{"cli"}},
+	"gen cli":        {flags: []string{"--out", "--check"}, args: argFiles},
+	"import":         {subcommands: []string{"godoc"}},
+	"import godoc":   {flags: []string{"--out", "--check"}, args: argFiles},
+	"dev":            {subcommands: []string{"mock"}},
+	"dev mock":       {flags: []string{"--posts", "--tags", "--sections", "--seed", "--dir", "--theme-dev", "-host", "-port"}},
+	"bench":          {flags: []string{"-posts", "-images", "-diagrams", "-runs", "-dir", "-json"}},
+	"version":        {subcommands: []string{"diff"}, flags: []string{"--info"}},
+	"version diff":   {flags: []string{"--json"}, args: argVersions},
+	"completion":     {subcommands: []string{"bash", "zsh", "fish", "powershell"}},
+	"help":           {},
+}
+
+// flagValues lists what the value of a flag completes to: fixed words, or
+// an argKind for paths
+var flagValues = map[string]any{
+	"--log-format":     []string{"text", "json"},
+	"--log-level":      []string{"debug", "info", "warn", "error"},
+	"--format":         []string{"yaml", "json"},
+	"--from":           argFiles,
+	"--out":            argFiles,
+	"--file":           argFiles,
+	"--template":       argFiles,
+	"--cpuprofile":     argFiles,
+	"--memprofile":     argFiles,
+	"-report":          argFiles,
+	"-error-summary":   argFiles,
+	"-slow-pages-json": argFiles,
+	"-link-dest":       argFiles,
+	"-dir":             argFiles,
+	"--dir":            argFiles,
+	"--theme-dev":      argFiles,
+	"-json":            argFiles,
+	"-only":            argContentDir,
+}
+
+func starterNames() []string {
+	names := make([]string, len(scaffold.Starters))
+	for i, s := range scaffold.Starters {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// handleCompletionCommand prints the completion script for a shell
+func handleCompletionCommand(args []string) {
+	if len(args) != 1 {
+		printCompletionUsage()
+		os.Exit(1)
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		fmt.Printf("Unknown shell: %s\n", args[0])
+		printCompletionUsage()
+		os.Exit(1)
+	}
+	fmt.Print(script)
+}
+
+func printCompletionUsage() {
+	fmt.Println("Usage: kosh completion bash|zsh|fish|powershell")
+	fmt.Println("\nLoad it in the current shell:")
+	fmt.Println("  bash        source <(kosh completion bash)")
+	fmt.Println("  zsh         source <(kosh completion zsh)")
+	fmt.Println("  fish        kosh completion fish | source")
+	fmt.Println("  powershell  kosh completion powershell | Out-String | Invoke-Expression")
+}
+
+// handleCompleteCommand answers the completion scripts: args are the words
+// after "kosh", the last one being the word under the cursor. Windows
+// PowerShell drops empty arguments, so its script sends "" for one.
+func handleCompleteCommand(args []string) {
+	if n := len(args); n > 0 && args[n-1] == `""` {
+		args[n-1] = ""
+	}
+	for _, c := range complete(args, loadCompletionSite()) {
+		fmt.Println(c)
+	}
+}
+
+// completionSite is the part of kosh.yaml completion needs. It is read
+// directly so config warnings never end up among the candidates.
+type completionSite struct {
+	ContentDir string           `yaml:"contentDir"`
+	Versions   []config.Version `yaml:"versions"`
+}
+
+func loadCompletionSite() completionSite {
+	site := completionSite{ContentDir: "content"}
+	for _, name := range []string{"kosh.yaml", "config.yaml"} {
+		if data, err := os.ReadFile(name); err == nil {
+			_ = yaml.Unmarshal(data, &site)
+			break
+		}
+	}
+	if site.ContentDir == "" {
+		site.ContentDir = "content"
+	}
+	return site
+}
+
+// complete returns the candidates for the last word of words
+func complete(words []string, site completionSite) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, before := words[len(words)-1], words[:len(words)-1]
+
+	// The value of the flag just before the cursor
+	if len(before) > 0 {
+		flag := before[len(before)-1]
+		if spec, ok := completionCommands[before[0]]; ok {
+			if values, ok := spec.values[flag]; ok {
+				return completeValue(values, current, site)
+			}
+		}
+		if values, ok := flagValues[flag]; ok {
+			return completeValue(values, current, site)
+		}
+	}
+
+	// Positional words, without flags and their values
+	var positional []string
+	for i := 0; i < len(before); i++ {
+		if strings.HasPrefix(before[i], "-") {
+			if _, takesValue := flagValues[before[i]]; takesValue {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, before[i])
+	}
+
+	if len(positional) == 0 {
+		if strings.HasPrefix(current, "-") {
+			return filterPrefix(globalFlags, current)
+		}
+		names := make([]string, 0, len(completionCommands))
+		for name := range completionCommands {
+			if !strings.Contains(name, " ") {
+				names = append(names, name)
+			}
+		}
+		return filterPrefix(names, current)
+	}
+
+	key := positional[0]
+	spec, ok := completionCommands[key]
+	if !ok {
+		return nil
+	}
+	if len(positional) > 1 {
+		if sub, ok := completionCommands[key+" "+positional[1]]; ok {
+			spec = sub
+		}
+	}
+	flags := append(append([]string{}, spec.flags...), globalFlags...)
+	if key == "build" || key == "test" {
+		for _, name := range config.FlagNames() {
+			flags = append(flags, "-"+name)
+		}
+	}
+	if key == "build" {
+		flags = append(flags, buildExtraFlags...)
+	}
+
+	if strings.HasPrefix(current, "-") {
+		return filterPrefix(flags, current)
+	}
+	if len(positional) == 1 && len(spec.subcommands) > 0 {
+		return filterPrefix(spec.subcommands, current)
+	}
+	return completeValue(spec.args, current, site)
+}
+
+func completeValue(values any, current string, site completionSite) []string {
+	switch v := values.(type) {
+	case []string:
+		return filterPrefix(v, current)
+	case argKind:
+		switch v {
+		case argFiles:
+			return completePath(current, func(string, fs.DirEntry) bool { return true })
+		case argContent:
+			return completeContent(site.ContentDir, current, false)
+		case argContentDir:
+			return completeContent(site.ContentDir, current, true)
+		case argVersions:
+			var names []string
+			for _, version := range site.Versions {
+				names = append(names, version.Name)
+			}
+			return filterPrefix(names, current)
+		}
+	}
+	return nil
+}
+
+// completeContent offers the markdown files (or only the directories) under
+// the content directory
+func completeContent(contentDir, current string, dirsOnly bool) []string {
+	if current == "" {
+		current = filepath.ToSlash(contentDir) + "/"
+	}
+	return completePath(current, func(_ string, e fs.DirEntry) bool {
+		if dirsOnly {
+			return e.IsDir()
+		}
+		return e.IsDir() || strings.HasSuffix(e.Name(), ".md")
+	})
+}
+
+// completePath lists the entries of the directory part of current that
+// start with its last element. Directories end in a slash so the shell can
+// keep descending.
+func completePath(current string, keep func(dir string, e fs.DirEntry) bool) []string {
+	dir, prefix := filepath.Split(filepath.FromSlash(current))
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var out []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), prefix) || (strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if !keep(dir, e) {
+			continue
+		}
+		name := filepath.ToSlash(filepath.Join(dir, e.Name()))
+		if dir == "" {
+			name = e.Name()
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, c)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// completionScripts ask `kosh __complete` for candidates, so every shell
+// completes the same way and the scripts never go stale
+var completionScripts = map[string]string{
+	"bash": `# kosh bash completion
+_kosh() {
+    local IFS=$'\n'
+    COMPREPLY=($(kosh __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _kosh kosh
+`,
+	"zsh": `#compdef kosh
+# kosh zsh completion
+_kosh() {
+    local -a candidates
+    candidates=("${(@f)$(kosh __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    local c
+    for c in ${candidates:#}; do
+        if [[ $c == */ ]]; then
+            compadd -S '' -- "$c"
+        else
+            compadd -- "$c"
+        fi
+    done
+}
+if [[ "$funcstack[1]" = "_kosh" ]]; then
+    _kosh "$@"
+else
+    compdef _kosh kosh
+fi
+`,
+	"fish": `# kosh fish completion
+function __kosh_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    kosh __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c kosh -f -a '(__kosh_complete)'
+`,
+	"powershell": `# kosh PowerShell completion
+Register-ArgumentCompleter -Native -CommandName kosh -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    kosh __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
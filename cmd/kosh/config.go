@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// handleConfigCommand processes config-related subcommands
+func handleConfigCommand(args []string) {
+	if len(args) < 1 {
+		printConfigUsage()
+		os.Exit(1)
+	}
+
+	subcommand := args[0]
+	subArgs := args[1:]
+
+	switch subcommand {
+	case "check":
+		path := ""
+		if len(subArgs) > 0 {
+			path = subArgs[0]
+		}
+		configCheck(path)
+	case "resolve":
+		configResolve(subArgs)
+	default:
+		fmt.Printf("Unknown config subcommand: %s\n", subcommand)
+		printConfigUsage()
+		os.Exit(1)
+	}
+}
+
+func printConfigUsage() {
+	fmt.Println("Usage: kosh config <subcommand> [arguments]")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  check [file]   Validate kosh.yaml (unknown keys, wrong types, conflicts)")
+	fmt.Println("  resolve        Print the merged effective config")
+	fmt.Println("\nFlags for resolve:")
+	fmt.Println("  --format <f>   Output format: yaml (default) or json")
+	fmt.Println("  --json         Shorthand for --format json")
+}
+
+// findConfigFile returns kosh.yaml, falling back to config.yaml like config.Load
+func findConfigFile() string {
+	if _, err := os.Stat("kosh.yaml"); err != nil {
+		if _, err := os.Stat("config.yaml"); err == nil {
+			return "config.yaml"
+		}
+	}
+	return "kosh.yaml"
+}
+
+func configCheck(path string) {
+	if path == "" {
+		path = findConfigFile()
+	}
+
+	fmt.Printf("🔍 Checking %s...\n", path)
+
+	issues, err := config.CheckFile(path)
+	if err != nil {
+		fmt.Printf("❌ %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("✅ Config is valid")
+		return
+	}
+
+	fmt.Printf("⚠️  Found %d issues:\n", len(issues))
+	for _, issue := range issues {
+		fmt.Printf("  %s:%s\n", path, issue)
+	}
+	os.Exit(1)
+}
+
+// configResolve prints the merged effective configuration.
+// Remaining args are passed to config.Load so build flags like -baseurl apply.
+func configResolve(args []string) {
+	format := "yaml"
+	var loadArgs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if (arg == "--format" || arg == "-format") && i+1 < len(args) {
+			format = args[i+1]
+			i++
+		} else if arg == "--json" || arg == "-json" {
+			format = "json"
+		} else {
+			loadArgs = append(loadArgs, arg)
+		}
+	}
+
+	cfg := config.Load(loadArgs)
+	out, err := cfg.Effective().Marshal(format)
+	if err != nil {
+		fmt.Printf("❌ Failed to resolve config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
+	"github.com/Kush-Singh-26/kosh/builder/run"
+	"github.com/Kush-Singh-26/kosh/internal/mock"
+	"github.com/Kush-Singh-26/kosh/internal/searchlog"
+	"github.com/Kush-Singh-26/kosh/internal/server"
+	"github.com/Kush-Singh-26/kosh/internal/watch"
+)
+
+// serveDev builds cfg in development mode, rebuilds on changes and serves
+// the output with live reload until ctx is cancelled, with the drafts, the
+// status page, and the admin panel and the search log when asked for. It
+// returns an error if the first build fails.
+func serveDev(ctx context.Context, cfg *config.Config, args []string, isAdmin, isSearchLog bool) error {
+	b := run.NewBuilderWithConfig(cfg)
+	b.SetDevMode(true)
+	if err := b.Build(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		w, err := watch.New(b.WatchPaths(), func(event watch.Event) {
+			logging.Statusf("\n⚡ Change detected: %s | Rebuilding...", event.Name)
+			b.BuildChanged(ctx, event.Name, event.Op)
+		})
+		if err != nil {
+			logging.Statusf("❌ Watcher failed: %v", err)
+			return
+		}
+		w.Start()
+	}()
+
+	var admin http.Handler
+	if isAdmin {
+		admin = server.NewAdmin(b.Config().ContentDir)
+	}
+	var searchLog http.Handler
+	if isSearchLog {
+		searchLog = server.NewSearchLog(searchlog.Path(b.Config().CacheDir))
+	}
+	var drafts *server.Drafts
+	if !b.Config().IncludeDrafts {
+		drafts = server.NewDrafts(b.DestFs, b.Config().DevDraftsDir())
+	}
+	var status *server.StatusPage
+	if !b.Config().StatusPage.Disabled {
+		status = server.NewStatusPage(b.Config().DevStatusFile())
+	}
+	live := server.NewLiveReload()
+	defer live.Watch(b.Events())()
+	server.Run(ctx, args, b.Config().OutputDir, b.Config().Build, b.Config().CacheControl, admin, searchLog, drafts, status, live)
+	return nil
+}
+
+// themeDevArg takes --theme-dev <dir> out of args
+func themeDevArg(args []string) (dir string, rest []string) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case (arg == "--theme-dev" || arg == "-theme-dev") && i+1 < len(args):
+			dir = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--theme-dev=") || strings.HasPrefix(arg, "-theme-dev="):
+			_, dir, _ = strings.Cut(arg, "=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return dir, rest
+}
+
+// useThemeDev swaps the configured theme for the one in dir, if any, and
+// reports whether the build can go ahead
+func useThemeDev(cfg *config.Config, dir string) bool {
+	if dir == "" {
+		return true
+	}
+	if err := cfg.UseThemeDir(dir); err != nil {
+		logging.Statusf("❌ --theme-dev: %v", err)
+		return false
+	}
+	logging.Statusf("🎨 Theme under development: %s (templates and static files reload on save)", filepath.Join(cfg.ThemeDir, cfg.Theme))
+	return true
+}
+
+// handleDevCommand processes theme development subcommands
+func handleDevCommand(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		printDevUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "mock":
+		if !runMock(ctx, args[1:]) {
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown dev subcommand: %s\n", args[0])
+		printDevUsage()
+		os.Exit(1)
+	}
+}
+
+// runMock serves the site's theme over generated content: the content,
+// output and cache all live in a temporary directory, so the site is left
+// as it is
+func runMock(ctx context.Context, args []string) bool {
+	themeDev, args := themeDevArg(args)
+	opts, dir, args, err := mock.ParseArgs(args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+
+	tmp, err := os.MkdirTemp("", "kosh-mock-")
+	if err != nil {
+		fmt.Printf("❌ Failed to create a temporary directory: %v\n", err)
+		return false
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	contentDir := filepath.Join(tmp, "content")
+	if dir != "" {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+			fmt.Printf("❌ %s is not empty; pick a new directory for the mock content\n", dir)
+			return false
+		}
+		contentDir = dir
+	}
+
+	files, err := mock.Generate(contentDir, opts)
+	if err != nil {
+		fmt.Printf("❌ Failed to generate mock content: %v\n", err)
+		return false
+	}
+	fmt.Printf("🧪 Generated %d mock posts in %d sections with %d tags (seed %d) in %s\n", len(files), opts.Sections, opts.Tags, opts.Seed, contentDir)
+
+	cfg := config.Load(args)
+	if abs, err := filepath.Abs(contentDir); err == nil {
+		cfg.ContentDir = abs
+	}
+	cfg.OutputDir = filepath.Join(tmp, "public")
+	cfg.CacheDir = filepath.Join(tmp, "cache")
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://localhost:2604"
+	}
+	if !useThemeDev(cfg, themeDev) {
+		return false
+	}
+	logging.Statusf("🚀 Serving theme %q with mock content...", cfg.Theme)
+	if err := serveDev(ctx, cfg, args, false, false); err != nil {
+		logging.Statusf("❌ Build failed: %v", err)
+		return false
+	}
+	return true
+}
+
+func printDevUsage() {
+	fmt.Println("Usage: kosh dev <subcommand> [arguments]")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  mock               Serve the theme over generated lorem content (temporary, site untouched)")
+	fmt.Println("\nFlags for mock:")
+	fmt.Println("  --posts <n>        Number of posts (default: 50)")
+	fmt.Println("  --tags <n>         Number of tags (default: 10)")
+	fmt.Println("  --sections <n>     Number of content sections (default: 3)")
+	fmt.Println("  --seed <n>         Seed for the generated content (default: 1)")
+	fmt.Println("  --dir <dir>        Write the content here and keep it")
+	fmt.Println("  --theme-dev <dir>  Use the theme checkout in dir instead of the configured theme")
+	fmt.Println("  Build and serve flags (--port, --host, --theme, ...) are passed through")
+}
@@ -3,24 +3,44 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
 	"runtime/pprof"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 	"github.com/Kush-Singh-26/kosh/builder/run"
+	"github.com/Kush-Singh-26/kosh/builder/telemetry"
+	"github.com/Kush-Singh-26/kosh/internal/bench"
 	"github.com/Kush-Singh-26/kosh/internal/clean"
+	"github.com/Kush-Singh-26/kosh/internal/deploy"
+	"github.com/Kush-Singh-26/kosh/internal/export"
+	"github.com/Kush-Singh-26/kosh/internal/gen"
+	"github.com/Kush-Singh-26/kosh/internal/meta"
 	"github.com/Kush-Singh-26/kosh/internal/new"
 	"github.com/Kush-Singh-26/kosh/internal/scaffold"
+	"github.com/Kush-Singh-26/kosh/internal/searchlog"
 	"github.com/Kush-Singh-26/kosh/internal/server"
+	"github.com/Kush-Singh-26/kosh/internal/sitetest"
+	"github.com/Kush-Singh-26/kosh/internal/stats"
+	"github.com/Kush-Singh-26/kosh/internal/theme"
 	"github.com/Kush-Singh-26/kosh/internal/version"
 	"github.com/Kush-Singh-26/kosh/internal/watch"
 )
 
 func main() {
+	// Completion queries run before flag parsing: the words may end in a
+	// global flag still waiting for its value
+	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+		handleCompleteCommand(os.Args[2:])
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -28,17 +48,35 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		fmt.Println("\n🛑 Received shutdown signal...")
+		logging.Statusf("\n🛑 Received shutdown signal...")
 		cancel()
 	}()
 
-	if len(os.Args) < 2 {
+	logOpts, cliArgs, err := logging.ParseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	logging.Configure(logOpts)
+
+	if err := telemetry.Setup(); err != nil {
+		logging.Statusf("⚠️  Tracing disabled: %v", err)
+	}
+	defer func() {
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		if err := telemetry.Shutdown(shutdownCtx); err != nil {
+			logging.Statusf("⚠️  Failed to flush traces: %v", err)
+		}
+	}()
+
+	if len(cliArgs) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
-	args := os.Args[2:]
+	command := cliArgs[0]
+	args := cliArgs[1:]
 
 	switch command {
 	case "clean":
@@ -54,23 +92,43 @@ func main() {
 		}
 
 		clean.Run(cleanCache, cleanAll)
-		fmt.Println("\n🔄 Rebuilding site...")
-		run.Run([]string{})
+		logging.Statusf("\n🔄 Rebuilding site...")
+		run.Run(ctx, []string{})
 
 	case "new":
-		new.Run(args)
-		fmt.Println("\n🔄 Building site with new post...")
-		run.Run([]string{})
+		if new.Run(args) {
+			logging.Statusf("\n🔄 Building site with new post...")
+			run.Run(ctx, []string{})
+		}
+
+	case "meta":
+		meta.Run(args)
+
+	case "tags":
+		meta.RunTags(args)
+
+	case "stats":
+		stats.Run(args)
+
+	case "search":
+		handleSearchCommand(args)
 
 	case "init":
 		scaffold.Run(args)
 
 	case "serve":
-		isDev := false
+		themeDev, args := themeDevArg(args)
+		isDev := themeDev != "" // A theme under development needs rebuilds on change
+		isAdmin := false
+		isSearchLog := false
 		var filteredArgs []string
 		for _, arg := range args {
 			if arg == "--dev" || arg == "-dev" {
 				isDev = true
+			} else if arg == "--admin" || arg == "-admin" {
+				isAdmin = true
+			} else if arg == "--search-log" || arg == "-search-log" {
+				isSearchLog = true
 			} else {
 				filteredArgs = append(filteredArgs, arg)
 			}
@@ -78,40 +136,35 @@ func main() {
 		args = filteredArgs
 
 		if isDev {
-			fmt.Println("🚀 Starting Kosh in Development Mode...")
+			logging.Statusf("🚀 Starting Kosh in Development Mode...")
 			// Pre-load config to check baseURL
 			cfg := config.Load(args)
 			if cfg.BaseURL == "" {
 				cfg.BaseURL = "http://localhost:2604"
-				fmt.Println("   📝 Auto-detected baseURL: http://localhost:2604")
+				logging.Statusf("   📝 Auto-detected baseURL: http://localhost:2604")
 			}
-			b := run.NewBuilderWithConfig(cfg)
-			b.SetDevMode(true)
-			if err := b.Build(ctx); err != nil {
-				fmt.Printf("❌ Build failed: %v\n", err)
+			if !useThemeDev(cfg, themeDev) {
+				os.Exit(1)
+			}
+			if err := serveDev(ctx, cfg, args, isAdmin, isSearchLog); err != nil {
+				logging.Statusf("❌ Build failed: %v", err)
 				os.Exit(1)
 			}
-
-			go func() {
-				w, err := watch.New([]string{"content", b.Config().TemplateDir, b.Config().StaticDir, "kosh.yaml"}, func(event watch.Event) {
-					fmt.Printf("\n⚡ Change detected: %s | Rebuilding...\n", event.Name)
-					b.BuildChanged(ctx, event.Name, event.Op)
-				})
-				if err != nil {
-					fmt.Printf("❌ Watcher failed: %v\n", err)
-					return
-				}
-				w.Start()
-			}()
-
-			server.Run(ctx, args, b.Config().OutputDir, b.Config().Build)
 		} else {
+			if isAdmin {
+				logging.Statusf("⚠️  --admin needs --dev, so saved edits get rebuilt")
+			}
 			cfg := config.Load(args)
-			server.Run(ctx, args, cfg.OutputDir, cfg.Build)
+			var searchLog http.Handler
+			if isSearchLog {
+				searchLog = server.NewSearchLog(searchlog.Path(cfg.CacheDir))
+			}
+			server.Run(ctx, args, cfg.OutputDir, cfg.Build, cfg.CacheControl, nil, searchLog, nil, nil, nil)
 		}
 
 	case "build":
 		isWatch := false
+		isAll := false
 		cpuProfile := ""
 		memProfile := ""
 		var filteredArgs []string
@@ -119,6 +172,8 @@ func main() {
 			arg := args[i]
 			if arg == "--watch" || arg == "-watch" {
 				isWatch = true
+			} else if arg == "--all" || arg == "-all" {
+				isAll = true
 			} else if arg == "--cpuprofile" && i+1 < len(args) {
 				cpuProfile = args[i+1]
 				i++
@@ -134,54 +189,112 @@ func main() {
 		if cpuProfile != "" {
 			f, err := os.Create(cpuProfile)
 			if err != nil {
-				fmt.Printf("could not create CPU profile: %v\n", err)
+				logging.Statusf("❌ Could not create CPU profile: %v", err)
 				os.Exit(1)
 			}
 			defer func() { _ = f.Close() }()
 			if err := pprof.StartCPUProfile(f); err != nil {
-				fmt.Printf("could not start CPU profile: %v\n", err)
+				logging.Statusf("❌ Could not start CPU profile: %v", err)
 				os.Exit(1)
 			}
 			defer pprof.StopCPUProfile()
 		}
 
-		if isWatch {
+		if isAll {
+			if err := run.RunAll(ctx, args); err != nil {
+				logging.Statusf("❌ Workspace build failed: %v", err)
+				os.Exit(1)
+			}
+		} else if isWatch {
 			b := run.NewBuilder(args)
 			if err := b.Build(ctx); err != nil {
-				fmt.Printf("❌ Initial build failed: %v\n", err)
+				logging.Statusf("❌ Initial build failed: %v", err)
 				os.Exit(1)
 			}
 
-			w, err := watch.New([]string{"content", b.Config().TemplateDir, b.Config().StaticDir, "kosh.yaml"}, func(event watch.Event) {
-				fmt.Printf("\n⚡ Change detected: %s | Rebuilding...\n", event.Name)
+			w, err := watch.New(b.WatchPaths(), func(event watch.Event) {
+				logging.Statusf("\n⚡ Change detected: %s | Rebuilding...", event.Name)
 				b.BuildChanged(ctx, event.Name, event.Op)
 			})
 			if err != nil {
-				fmt.Printf("❌ Watcher failed: %v\n", err)
+				logging.Statusf("❌ Watcher failed: %v", err)
 				os.Exit(1)
 			}
 			w.Start()
 		} else {
-			run.Run(args)
+			buildErr := run.Run(ctx, args)
 
 			if memProfile != "" {
 				f, err := os.Create(memProfile)
 				if err != nil {
-					fmt.Printf("could not create memory profile: %v\n", err)
+					logging.Statusf("❌ Could not create memory profile: %v", err)
 					os.Exit(1)
 				}
 				defer func() { _ = f.Close() }()
 				runtime.GC()
 				if err := pprof.WriteHeapProfile(f); err != nil {
-					fmt.Printf("could not write memory profile: %v\n", err)
+					logging.Statusf("❌ Could not write memory profile: %v", err)
 					os.Exit(1)
 				}
 			}
+			if buildErr != nil {
+				pprof.StopCPUProfile()
+				os.Exit(1)
+			}
 		}
 
+	case "dev":
+		handleDevCommand(ctx, args)
+
+	case "bench":
+		bench.Run(ctx, args)
+
 	case "cache":
 		handleCacheCommand(args)
 
+	case "config":
+		handleConfigCommand(args)
+
+	case "check":
+		handleCheckCommand(args)
+
+	case "template":
+		handleTemplateCommand(args)
+
+	case "test":
+		if !sitetest.Run(ctx, args) {
+			os.Exit(1)
+		}
+
+	case "completion":
+		handleCompletionCommand(args)
+
+	case "export":
+		export.Run(args)
+
+	case "deploy":
+		if !deploy.Run(args) {
+			os.Exit(1)
+		}
+
+	case "gen":
+		if !gen.Run(args) {
+			os.Exit(1)
+		}
+
+	case "import":
+		if !gen.Import(args) {
+			os.Exit(1)
+		}
+
+	case "theme":
+		if !theme.Run(args) {
+			os.Exit(1)
+		}
+
+	case "modules":
+		handleModulesCommand(ctx, args)
+
 	case "version":
 		if len(args) > 0 && (args[0] == "-info" || args[0] == "--info") {
 			printVersion()
@@ -207,30 +320,75 @@ func main() {
 func printUsage() {
 	fmt.Println("Usage: kosh <command> [arguments]")
 	fmt.Println("\nCommands:")
-	fmt.Println("  init [name]    Initialize a new Kosh site")
+	fmt.Println("  init [name]    Initialize a new Kosh site (--template blog|docs|portfolio|minimal|<git-url>, --list-templates)")
 	fmt.Println("  new <title>    Create a new blog post")
+	fmt.Println("  new --from <f> Create draft posts from a CSV/JSON manifest")
+	fmt.Println("  meta           Edit frontmatter across many posts")
+	fmt.Println("  tags           List, rename and merge tags")
+	fmt.Println("  stats          Content analytics from the build cache (--json)")
+	fmt.Println("  search report  Summarize the searches logged by serve --search-log (--json)")
 	fmt.Println("  build          Build the static site")
-	fmt.Println("  serve          Start the preview server")
+	fmt.Println("  serve          Start the preview server (--dev, --admin for the browser editor,")
+	fmt.Println("                 --search-log to record site searches,")
+	fmt.Println("                 --theme-dev <dir> to develop a theme against this site)")
 	fmt.Println("  clean          Clean output directory")
+	fmt.Println("  deploy [name]  Upload the changed output files to a deploy target (--dry-run, --force)")
 	fmt.Println("  cache          Cache management commands")
+	fmt.Println("  config         Config validation and inspection")
+	fmt.Println("  check          Audit the built site (check seo) or changed content (check --changed)")
+	fmt.Println("  template       Test theme templates against fixtures (template test)")
+	fmt.Println("  test [paths]   Build into a temp dir and diff output files with tests/golden/")
+	fmt.Println("  theme          Install and list themes (theme install, theme list)")
+	fmt.Println("  modules        Content module (git) commands")
+	fmt.Println("  export         Export content to other formats")
+	fmt.Println("  gen            Generate reference pages (gen cli)")
+	fmt.Println("  import         Import API docs as content pages (import godoc)")
+	fmt.Println("  dev mock       Serve the theme over generated lorem content (--posts, --tags)")
+	fmt.Println("  bench          Benchmark cold and warm builds of a generated site")
 	fmt.Println("  version        Version management commands")
+	fmt.Println("  completion     Shell completion script (bash, zsh, fish, powershell)")
 	fmt.Println("  help           Show this help message")
 	fmt.Println("\nBuild Flags:")
 	fmt.Println("  --watch              Watch for changes and rebuild")
+	fmt.Println("  --all                Build every site in sites/*/kosh.yaml")
 	fmt.Println("  --cpuprofile <file>  Write CPU profile to file")
 	fmt.Println("  --memprofile <file>  Write memory profile to file")
 	fmt.Println("  -baseurl <url>       Override base URL from config")
 	fmt.Println("  -drafts              Include draft posts in build")
+	fmt.Println("  -future              Include posts whose publishDate is still to come")
+	fmt.Println("  -draft-previews      Build drafts at unguessable preview/<token>.html URLs")
+	fmt.Println("  -audience <name>     Build the variant for an audience (audience: frontmatter)")
 	fmt.Println("  -theme <name>        Override theme from config")
+	fmt.Println("  -offline             Use cached remote data only (getRemote/getJSON)")
+	fmt.Println("  -low-memory          Bounded-memory build for very large sites")
+	fmt.Println("  -only <path>         Build one content subtree, e.g. content/docs/v3/")
+	fmt.Println("  -link-dest <dir>     Link files unchanged from a previous output instead of writing them")
+	fmt.Println("  -parse-workers <n>   Markdown parsing workers (also -render-workers,")
+	fmt.Println("                       -card-workers, -image-workers)")
+	fmt.Println("  -slow-pages <n>      Print the N slowest pages after the build")
+	fmt.Println("  -slow-pages-json <f> Write the slowest pages to a JSON file")
+	fmt.Println("  -report <file>       Write a build report (.json or .html)")
+	fmt.Println("  -strict              Fail on content problems (strict.checks in kosh.yaml)")
+	fmt.Println("  -max-errors <n>      Print only the first N errors; fail if there are more")
+	fmt.Println("  -fail-fast           Stop the build at the first error")
+	fmt.Println("  -error-summary <f>   Write the errors, grouped by type, to a JSON file")
+	fmt.Println("\nGlobal Flags:")
+	fmt.Println("  --log-format <f>     Log output: text (default) or json")
+	fmt.Println("  --log-level <level>  debug, info (default), warn or error")
+	fmt.Println("  --quiet, -q          Only print warnings and errors")
 	fmt.Println("\nServe Flags:")
 	fmt.Println("  --dev                Enable development mode (build + watch + serve)")
 	fmt.Println("  --host <host>        Host/IP to bind to (default: localhost)")
 	fmt.Println("  --port <port>        Port to listen on (default: 2604)")
 	fmt.Println("  -drafts              Include draft posts in development mode")
+	fmt.Println("  -future              Include posts whose publishDate is still to come")
 	fmt.Println("  -baseurl <url>       Override base URL from config")
 	fmt.Println("\nClean Flags:")
 	fmt.Println("  --cache              Also clean .kosh-cache directory")
 	fmt.Println("  --all                Clean all versions including versioned folders")
+	fmt.Println("\nDeploy Flags:")
+	fmt.Println("  --dry-run, -n        List what would be uploaded and deleted")
+	fmt.Println("  --force              Upload every file, not only the changed ones")
 	fmt.Println("\nCache Commands:")
 	fmt.Println("  cache stats          Show cache statistics")
 	fmt.Println("  cache gc             Run garbage collection on cache")
@@ -240,10 +398,53 @@ func printUsage() {
 	fmt.Println("  cache inspect <path> Show cache entry for a file")
 	fmt.Println("\nCache GC Flags:")
 	fmt.Println("  --dry-run, -n        Show what would be deleted without deleting")
+	fmt.Println("\nConfig Commands:")
+	fmt.Println("  config check [file]  Validate kosh.yaml with line numbers")
+	fmt.Println("  config resolve       Print merged config (--format yaml|json)")
+	fmt.Println("\nCheck Commands:")
+	fmt.Println("  check seo            Title/description lengths, duplicates, og:image (--json)")
+	fmt.Println("  check --changed      Frontmatter, links and prose of content changed in git")
+	fmt.Println("                       (--staged for pre-commit hooks, or the files given; --json)")
+	fmt.Println("\nTemplate Commands:")
+	fmt.Println("  template test [names] Render fixtures in <theme>/tests, diff with golden HTML")
+	fmt.Println("                       (--dir <dir>, --update to accept the output)")
+	fmt.Println("\nTest Flags:")
+	fmt.Println("  --update             Write the output of the given (or all golden) paths")
+	fmt.Println("  --dir <dir>          Golden snapshot directory (default: tests/golden)")
+	fmt.Println("                       Build flags such as -drafts come before the paths")
+	fmt.Println("\nTheme Commands:")
+	fmt.Println("  theme install <url>  Clone a theme into themes/ (url#ref; --name <dir>, --force)")
+	fmt.Println("  theme list           Show installed themes and the site's template overrides")
+	fmt.Println("\nModules Commands:")
+	fmt.Println("  modules list         Show content modules and cache state")
+	fmt.Println("  modules update       Re-fetch all content modules")
+	fmt.Println("\nMeta Commands:")
+	fmt.Println("  meta set <k>=<v> [globs]  Set a frontmatter key, e.g. draft=false 'content/posts/**'")
+	fmt.Println("  meta rename <old> <new>   Rename a frontmatter key (--dry-run to preview)")
+	fmt.Println("\nTags Commands:")
+	fmt.Println("  tags list                 Show how many posts use each tag")
+	fmt.Println("  tags rename <old> <new>   Retag posts and redirect the old tag page")
+	fmt.Println("  tags merge <tag>... <to>  Fold several tags into one (--dry-run to preview)")
+	fmt.Println("\nExport Commands:")
+	fmt.Println("  export email <path>  Email-safe HTML + plain text (--out <dir>, --template <file>)")
+	fmt.Println("\nGen Commands:")
+	fmt.Println("  gen cli <spec>       CLI reference pages from a cobra YAML tree or kosh CLI schema")
+	fmt.Println("                       (--out <dir>, default content/cli; --check fails if stale)")
+	fmt.Println("\nImport Commands:")
+	fmt.Println("  import godoc [pkgs]  Go package docs as pages (default ./...; --out <dir>,")
+	fmt.Println("                       default content/api; --check fails if stale)")
+	fmt.Println("\nBench Flags:")
+	fmt.Println("  -posts <n>           Posts to generate (default: 500)")
+	fmt.Println("  -images <n>          PNG images to generate (default: 50)")
+	fmt.Println("  -diagrams <n>        D2 diagrams to generate (default: 20)")
+	fmt.Println("  -runs <n>            Warm builds after the cold build (default: 3)")
+	fmt.Println("  -dir <path>          Keep the generated site in an empty directory")
+	fmt.Println("  -json <file>         Also write the results as JSON")
 	fmt.Println("\nVersion Commands:")
 	fmt.Println("  version              Show current documentation version info")
 	fmt.Println("  version <vX.X>       Freeze current latest and start new version")
 	fmt.Println("  version --info       Show Kosh build information")
+	fmt.Println("  version diff <a> <b> Report added/removed/changed pages between versions")
 }
 
 func printVersion() {
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/modules"
+)
+
+// handleModulesCommand processes content module subcommands
+func handleModulesCommand(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		printModulesUsage()
+		os.Exit(1)
+	}
+
+	cfg := config.Load([]string{})
+	if len(cfg.Modules) == 0 {
+		fmt.Println("No content modules configured in kosh.yaml")
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		modulesList(cfg)
+	case "update":
+		modulesUpdate(ctx, cfg)
+	default:
+		fmt.Printf("Unknown modules subcommand: %s\n", args[0])
+		printModulesUsage()
+		os.Exit(1)
+	}
+}
+
+func printModulesUsage() {
+	fmt.Println("Usage: kosh modules <subcommand>")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  list           Show configured content modules and cache state")
+	fmt.Println("  update         Re-fetch all content modules")
+}
+
+func modulesList(cfg *config.Config) {
+	fmt.Println("📦 Content Modules")
+	fmt.Println("════════════════════════════════════════")
+	for _, m := range cfg.Modules {
+		ref := m.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		state := "not fetched"
+		if _, err := os.Stat(modules.CheckoutDir(cfg.CacheDir, m)); err == nil {
+			state = "cached"
+		}
+		fmt.Printf("%s@%s → content/%s (%s)\n", m.URL, ref, modules.Target(m), state)
+	}
+}
+
+func modulesUpdate(ctx context.Context, cfg *config.Config) {
+	failed := 0
+	for _, m := range cfg.Modules {
+		fmt.Printf("🔄 Fetching %s...\n", m.URL)
+		if _, err := modules.Update(ctx, cfg.CacheDir, m); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✅ Modules updated")
+}
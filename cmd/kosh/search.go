@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Kush-Singh-26/kosh/internal/searchlog"
+)
+
+// handleSearchCommand processes search-related subcommands
+func handleSearchCommand(args []string) {
+	if len(args) < 1 {
+		printSearchUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "report":
+		searchlog.Run(args[1:])
+	default:
+		fmt.Printf("Unknown search subcommand: %s\n", args[0])
+		printSearchUsage()
+		os.Exit(1)
+	}
+}
+
+func printSearchUsage() {
+	fmt.Println("Usage: kosh search <subcommand> [arguments]")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  report         Summarize the searches logged by 'kosh serve --search-log'")
+	fmt.Println("\nFlags for report:")
+	fmt.Println("  --json         Print the report as JSON")
+	fmt.Println("  --top <n>      Queries and terms per list (default 20, 0 for all)")
+	fmt.Println("  --since <d>    Only searches in the last d, e.g. 7d or 12h")
+	fmt.Println("  --file <path>  Read this log instead of the site's")
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Kush-Singh-26/kosh/internal/templatetest"
+)
+
+// handleTemplateCommand processes theme template subcommands
+func handleTemplateCommand(args []string) {
+	if len(args) < 1 {
+		printTemplateUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "test":
+		if !templatetest.Run(args[1:]) {
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown template subcommand: %s\n", args[0])
+		printTemplateUsage()
+		os.Exit(1)
+	}
+}
+
+func printTemplateUsage() {
+	fmt.Println("Usage: kosh template <subcommand> [arguments]")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  test [fixture...]  Render templates against YAML fixtures and compare with golden HTML")
+	fmt.Println("\nFlags for test:")
+	fmt.Println("  --dir <dir>        Fixture directory (default: <theme>/tests)")
+	fmt.Println("  --update           Write the current output as the golden files")
+}
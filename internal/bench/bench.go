@@ -0,0 +1,206 @@
+// Package bench builds a generated site cold and warm and prints comparable
+// timings, so build performance can be compared across Kosh versions
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/run"
+)
+
+// RunResult is one build of the benchmark site
+type RunResult struct {
+	Name        string        `json:"name"`
+	Duration    time.Duration `json:"-"`
+	DurationMs  float64       `json:"duration_ms"`
+	Posts       int           `json:"posts"`
+	CacheHits   int           `json:"cache_hits"`
+	CacheMisses int           `json:"cache_misses"`
+	AllocBytes  uint64        `json:"alloc_bytes"`
+}
+
+// Result is the full benchmark, as written by --json
+type Result struct {
+	Options      Options     `json:"options"`
+	GoVersion    string      `json:"go_version"`
+	CPUs         int         `json:"cpus"`
+	Runs         []RunResult `json:"runs"` // The cold build first, then the warm builds
+	WarmMedianMs float64     `json:"warm_median_ms"`
+}
+
+// Run executes `kosh bench`
+func Run(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	opts := Options{}
+	fs.IntVar(&opts.Posts, "posts", 500, "Number of posts to generate")
+	fs.IntVar(&opts.Images, "images", 50, "Number of PNG images to generate")
+	fs.IntVar(&opts.Diagrams, "diagrams", 20, "Number of D2 diagrams to generate")
+	warm := fs.Int("runs", 3, "Number of warm builds after the cold build")
+	dir := fs.String("dir", "", "Generate the site here and keep it (default: a temporary directory)")
+	jsonPath := fs.String("json", "", "Also write the results to this JSON file")
+	if err := fs.Parse(normalizeFlags(args)); err != nil {
+		return
+	}
+	if opts.Posts < 1 || opts.Images < 0 || opts.Diagrams < 0 || *warm < 1 {
+		fmt.Println("❌ -posts and -runs must be at least 1; -images and -diagrams can't be negative")
+		return
+	}
+
+	siteDir := *dir
+	if siteDir == "" {
+		tmp, err := os.MkdirTemp("", "kosh-bench-")
+		if err != nil {
+			fmt.Printf("❌ Failed to create a temporary directory: %v\n", err)
+			return
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+		siteDir = tmp
+	} else if entries, err := os.ReadDir(siteDir); err == nil && len(entries) > 0 {
+		fmt.Printf("❌ %s is not empty; pick a new directory for the benchmark site\n", siteDir)
+		return
+	}
+
+	fmt.Printf("🧪 Generating %d posts, %d images, %d diagrams in %s\n", opts.Posts, opts.Images, opts.Diagrams, siteDir)
+	if err := Generate(siteDir, opts); err != nil {
+		fmt.Printf("❌ Failed to generate the benchmark site: %v\n", err)
+		return
+	}
+
+	result, err := runBuilds(ctx, siteDir, opts, *warm)
+	if err != nil {
+		fmt.Printf("❌ Benchmark failed: %v\n", err)
+		return
+	}
+	fmt.Print(result.String())
+
+	if *jsonPath != "" {
+		if err := result.Write(*jsonPath); err != nil {
+			fmt.Printf("❌ Failed to write %s: %v\n", *jsonPath, err)
+			return
+		}
+		fmt.Printf("📋 Results written to %s\n", *jsonPath)
+	}
+}
+
+// normalizeFlags lets --flag spellings through the standard flag package
+func normalizeFlags(args []string) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if len(arg) > 2 && arg[:2] == "--" {
+			arg = arg[1:]
+		}
+		out[i] = arg
+	}
+	return out
+}
+
+// runBuilds builds the site in siteDir once from scratch and then warm times
+// against the cache the first build left behind
+func runBuilds(ctx context.Context, siteDir string, opts Options, warm int) (*Result, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(siteDir); err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Chdir(root) }()
+
+	result := &Result{Options: opts, GoVersion: runtime.Version(), CPUs: runtime.NumCPU()}
+	for i := 0; i <= warm; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		name := "cold"
+		if i > 0 {
+			name = fmt.Sprintf("warm %d", i)
+		}
+		fmt.Printf("\n⏱️  Build %d/%d (%s)\n", i+1, warm+1, name)
+		r, err := build(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		result.Runs = append(result.Runs, r)
+	}
+
+	var warmTimes []time.Duration
+	for _, r := range result.Runs[1:] {
+		warmTimes = append(warmTimes, r.Duration)
+	}
+	result.WarmMedianMs = millis(median(warmTimes))
+	return result, nil
+}
+
+func build(ctx context.Context, name string) (RunResult, error) {
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	b := run.NewBuilderWithConfig(config.Load(nil))
+	err := b.Build(ctx)
+	b.SaveCaches()
+	b.Close()
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		return RunResult{}, err
+	}
+	m := b.Metrics()
+	return RunResult{
+		Name:        name,
+		Duration:    elapsed,
+		DurationMs:  millis(elapsed),
+		Posts:       m.PostsProcessed,
+		CacheHits:   m.CacheHits,
+		CacheMisses: m.CacheMisses,
+		AllocBytes:  after.TotalAlloc - before.TotalAlloc,
+	}, nil
+}
+
+func median(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(ds)
+	slices.Sort(sorted)
+	if len(sorted)%2 == 1 {
+		return sorted[len(sorted)/2]
+	}
+	return (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// String formats the results as a table
+func (r *Result) String() string {
+	s := fmt.Sprintf("\n🏁 Benchmark: %d posts, %d images, %d diagrams (%s, %d CPUs)\n",
+		r.Options.Posts, r.Options.Images, r.Options.Diagrams, r.GoVersion, r.CPUs)
+	s += fmt.Sprintf("   %-8s %12s %8s %12s %12s\n", "Build", "Time", "Posts", "Cache hits", "Allocated")
+	for _, run := range r.Runs {
+		s += fmt.Sprintf("   %-8s %12s %8d %12s %9.1f MB\n", run.Name, run.Duration.Round(time.Millisecond),
+			run.Posts, fmt.Sprintf("%d/%d", run.CacheHits, run.CacheHits+run.CacheMisses), float64(run.AllocBytes)/(1024*1024))
+	}
+	s += fmt.Sprintf("   Warm median: %.0f ms\n", r.WarmMedianMs)
+	return s
+}
+
+// Write saves the results to path as JSON
+func (r *Result) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
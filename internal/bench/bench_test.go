@@ -0,0 +1,68 @@
+package bench
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	opts := Options{Posts: 60, Images: 2, Diagrams: 3}
+	dirA, dirB := t.TempDir(), t.TempDir()
+	if err := Generate(dirA, opts); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if err := Generate(dirB, opts); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	posts, _ := filepath.Glob(filepath.Join(dirA, "content", "*", "*.md"))
+	if len(posts) != opts.Posts {
+		t.Errorf("generated %d posts, want %d", len(posts), opts.Posts)
+	}
+	if sections, _ := filepath.Glob(filepath.Join(dirA, "content", "section-*")); len(sections) != 2 {
+		t.Errorf("generated %d sections, want 2", len(sections))
+	}
+	images, _ := filepath.Glob(filepath.Join(dirA, "static", "images", "*.png"))
+	if len(images) != opts.Images {
+		t.Errorf("generated %d images, want %d", len(images), opts.Images)
+	}
+
+	diagrams := 0
+	for _, path := range posts {
+		a, _ := os.ReadFile(path)
+		b, _ := os.ReadFile(filepath.Join(dirB, strings.TrimPrefix(path, dirA)))
+		if !bytes.Equal(a, b) {
+			t.Errorf("%s differs between runs; the site must be deterministic", filepath.Base(path))
+		}
+		diagrams += strings.Count(string(a), "```d2")
+	}
+	if diagrams != opts.Diagrams {
+		t.Errorf("generated %d diagrams, want %d", diagrams, opts.Diagrams)
+	}
+
+	for _, rel := range []string{"kosh.yaml", "themes/bench/templates/layout.html", "themes/bench/static/css/site.css"} {
+		if _, err := os.Stat(filepath.Join(dirA, rel)); err != nil {
+			t.Errorf("missing %s: %v", rel, err)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		in   []time.Duration
+		want time.Duration
+	}{
+		{nil, 0},
+		{[]time.Duration{3, 1, 2}, 2},
+		{[]time.Duration{4, 1, 3, 2}, 2},
+	}
+	for _, tt := range tests {
+		if got := median(tt.in); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
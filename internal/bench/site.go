@@ -0,0 +1,184 @@
+package bench
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Options sizes the synthetic site
+type Options struct {
+	Posts    int `json:"posts"`
+	Images   int `json:"images"`
+	Diagrams int `json:"diagrams"`
+}
+
+// postsPerSection keeps the sidebar tree realistic for large sites
+const postsPerSection = 50
+
+const siteConfig = `title: "Kosh Bench"
+description: "Synthetic site generated by kosh bench"
+baseURL: "http://localhost:2604"
+language: "en"
+theme: "bench"
+themeDir: "themes"
+compressImages: true
+postsPerPage: 10
+`
+
+const layoutTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+{{ if .Assets }}<link rel="stylesheet" href="{{ .BaseURL }}{{ index .Assets "/static/css/site.css" }}">{{ end }}
+</head>
+<body>
+<main>
+<h1>{{ .Title }}</h1>
+{{ .Content }}
+{{ range .Posts }}<article><a href="{{ .Link }}">{{ .Title }}</a> <span>{{ .ReadingTime }} min</span></article>
+{{ end }}
+</main>
+</body>
+</html>
+`
+
+const siteCSS = `body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 0 auto; }
+pre { overflow-x: auto; }
+table { border-collapse: collapse; }
+`
+
+var words = strings.Fields(`static site generator build cache render template markdown
+content version search index page section theme asset image diagram graph worker pool
+parse output incremental metadata sidebar feed sitemap config module hash token query
+layout partial shortcode anchor heading table list code block link reference document`)
+
+var tags = []string{"go", "performance", "docs", "guide", "tutorial", "reference", "design", "release", "testing", "ops"}
+
+// Generate writes a synthetic site sized by opts into dir. The output only
+// depends on opts, so sites generated by different Kosh versions are
+// byte-for-byte identical and their build numbers comparable.
+func Generate(dir string, opts Options) error {
+	files := map[string]string{
+		"kosh.yaml":                          siteConfig,
+		"themes/bench/templates/layout.html": layoutTemplate,
+		"themes/bench/static/css/site.css":   siteCSS,
+	}
+	for rel, content := range files {
+		if err := writeFile(filepath.Join(dir, rel), []byte(content)); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < opts.Images; i++ {
+		if err := writeImage(filepath.Join(dir, "static", "images", imageName(i)), i); err != nil {
+			return err
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(2604, 1))
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < opts.Posts; i++ {
+		section := fmt.Sprintf("section-%02d", i/postsPerSection+1)
+		path := filepath.Join(dir, "content", section, fmt.Sprintf("post-%05d.md", i+1))
+		post := generatePost(rng, i, opts, base.AddDate(0, 0, i))
+		if err := writeFile(path, []byte(post)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generatePost(rng *rand.Rand, i int, opts Options, date time.Time) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "---\ntitle: \"Post %d: %s\"\n", i+1, sentence(rng, 4))
+	fmt.Fprintf(&sb, "date: \"%s\"\n", date.Format("2006-01-02"))
+	fmt.Fprintf(&sb, "description: \"%s\"\n", sentence(rng, 12))
+	fmt.Fprintf(&sb, "tags: [\"%s\", \"%s\"]\n", tags[i%len(tags)], tags[(i/len(tags)+1)%len(tags)])
+	fmt.Fprintf(&sb, "weight: %d\n---\n\n", i+1)
+
+	for s := 0; s < 4; s++ {
+		fmt.Fprintf(&sb, "## %s\n\n", sentence(rng, 3))
+		for p := 0; p < 3; p++ {
+			sb.WriteString(paragraph(rng))
+			sb.WriteString("\n\n")
+		}
+		switch s {
+		case 1:
+			fmt.Fprintf(&sb, "```go\nfunc step%d(n int) int {\n\treturn n * %d\n}\n```\n\n", i, s+2)
+		case 2:
+			sb.WriteString("| Option | Value |\n|--------|-------|\n")
+			for r := 0; r < 4; r++ {
+				fmt.Fprintf(&sb, "| %s | %d |\n", words[rng.IntN(len(words))], rng.IntN(1000))
+			}
+			sb.WriteString("\n")
+		case 3:
+			for r := 0; r < 5; r++ {
+				fmt.Fprintf(&sb, "- %s\n", sentence(rng, 6))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if opts.Images > 0 {
+		fmt.Fprintf(&sb, "![Figure %d](/static/images/%s)\n\n", i+1, imageName(i%opts.Images))
+	}
+	// Spread diagrams over the posts; more diagrams than posts doubles up
+	for d := i; d < opts.Diagrams; d += max(opts.Posts, 1) {
+		fmt.Fprintf(&sb, "```d2\nbuild%d -> cache%d: lookup\ncache%d -> render%d: miss\nrender%d -> output%d\n```\n\n", d, d, d, d, d, d)
+	}
+	return sb.String()
+}
+
+func sentence(rng *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = words[rng.IntN(len(words))]
+	}
+	return strings.Join(parts, " ")
+}
+
+func paragraph(rng *rand.Rand) string {
+	s := sentence(rng, 40+rng.IntN(40))
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+func imageName(i int) string {
+	return fmt.Sprintf("bench-%04d.png", i+1)
+}
+
+// writeImage draws a 640x480 gradient so every image compresses differently
+func writeImage(path string, i int) error {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			img.Set(x, y, color.RGBA{uint8(x + i*37), uint8(y + i*11), uint8(x ^ y + i), 255})
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,223 @@
+// Package deploy publishes the output directory to a hosting target,
+// uploading only the files that changed since the last deploy. The diff
+// compares the BLAKE3 hashes of the output, mostly taken by the build, with
+// a manifest of the hashes the target holds. The manifest is never served:
+// rsync and sftp keep it next to the site directory, other targets in the
+// cache directory (see record).
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// Target types
+const (
+	S3          = "s3"
+	GitHubPages = "github-pages"
+	Netlify     = "netlify"
+	Rsync       = "rsync"
+	SFTP        = "sftp"
+)
+
+// Target is a place the site is published to
+type Target interface {
+	// Manifest returns the manifest of the last deploy, empty when the
+	// target has none
+	Manifest(ctx context.Context) (Manifest, error)
+	// Publish uploads plan.Upload from dir and deletes plan.Delete, then
+	// stores manifest. The manifest goes last, so an interrupted deploy
+	// is redone by the next one.
+	Publish(ctx context.Context, dir string, plan Plan, manifest Manifest) error
+}
+
+// Options change what Deploy does
+type Options struct {
+	DryRun bool                        // Only plan
+	Force  bool                        // Upload every file, whatever the target's manifest says
+	Hashes map[string]utils.OutputFile // The build's record of the files of dir, whose hashes Scan reuses
+}
+
+// Deploy publishes dir to target and returns what it changed
+func Deploy(ctx context.Context, target Target, dir string, opts Options) (Plan, error) {
+	if c, ok := target.(io.Closer); ok {
+		defer func() { _ = c.Close() }()
+	}
+	local, err := Scan(dir, opts.Hashes)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to hash %s: %w", dir, err)
+	}
+	remote, err := target.Manifest(ctx)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to read the deploy manifest: %w", err)
+	}
+	plan := Diff(local, remote)
+	if opts.Force {
+		plan.Upload = slices.Sorted(maps.Keys(local))
+		plan.Unchanged = 0
+	}
+	if opts.DryRun || plan.Empty() {
+		return plan, nil
+	}
+	return plan, target.Publish(ctx, dir, plan, local)
+}
+
+// NewTarget returns the target a deploy config describes
+func NewTarget(cfg *config.Config, t config.DeployTarget) (Target, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	record := recordPath(cfg.CacheDir, t)
+	switch t.Type {
+	case S3:
+		return newS3Target(client, cfg.CacheControl, t, record)
+	case GitHubPages:
+		return newGitHubTarget(t, record), nil
+	case Netlify:
+		return newNetlifyTarget(client, t, record)
+	case Rsync:
+		return newRsyncTarget(t)
+	case SFTP:
+		return newSFTPTarget(t)
+	}
+	return nil, fmt.Errorf("unknown deploy target type %q (expected s3, github-pages, netlify, rsync or sftp)", t.Type)
+}
+
+// Run runs `kosh deploy [target] [--dry-run] [--force]` and reports
+// whether it succeeded
+func Run(args []string) bool {
+	var name string
+	var opts Options
+	for _, arg := range args {
+		switch arg {
+		case "--dry-run", "-dry-run", "-n":
+			opts.DryRun = true
+		case "--force", "-force":
+			opts.Force = true
+		default:
+			if name == "" {
+				name = arg
+			}
+		}
+	}
+
+	cfg := config.Load(nil)
+	if len(cfg.Deploy) == 0 {
+		fmt.Println("❌ No deploy targets: add a deploy: list to kosh.yaml")
+		return false
+	}
+	spec := cfg.Deploy[0]
+	if name != "" {
+		i := slices.IndexFunc(cfg.Deploy, func(t config.DeployTarget) bool { return t.DisplayName() == name })
+		if i < 0 {
+			fmt.Printf("❌ Unknown deploy target: %s\n", name)
+			return false
+		}
+		spec = cfg.Deploy[i]
+	}
+	if info, err := os.Stat(cfg.OutputDir); err != nil || !info.IsDir() {
+		fmt.Printf("❌ Nothing to deploy in %s: run kosh build first\n", cfg.OutputDir)
+		return false
+	}
+
+	target, err := NewTarget(cfg, spec)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	opts.Hashes = outputHashes(cfg.CacheDir)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("🚀 Deploying %s to %s (%s)...\n", cfg.OutputDir, spec.DisplayName(), spec.Type)
+	start := time.Now()
+	plan, err := Deploy(ctx, target, cfg.OutputDir, opts)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("❌ Deploy interrupted; the next deploy uploads what is missing")
+		} else {
+			fmt.Printf("❌ Deploy failed: %v\n", err)
+		}
+		return false
+	}
+
+	switch {
+	case opts.DryRun:
+		for _, path := range plan.Upload {
+			fmt.Printf("   upload %s\n", path)
+		}
+		for _, path := range plan.Delete {
+			fmt.Printf("   delete %s\n", path)
+		}
+		fmt.Printf("🔍 Dry run: %d to upload, %d to delete, %d unchanged\n", len(plan.Upload), len(plan.Delete), plan.Unchanged)
+	case plan.Empty():
+		fmt.Printf("✅ %s is up to date (%d files)\n", spec.DisplayName(), plan.Unchanged)
+	default:
+		fmt.Printf("✅ Deployed in %v: %d uploaded, %d deleted, %d unchanged\n",
+			time.Since(start).Round(time.Millisecond), len(plan.Upload), len(plan.Delete), plan.Unchanged)
+	}
+	return true
+}
+
+// outputHashes returns the output files the last build recorded, or none
+// when the cache can't be opened (a dev server holds it): the output is
+// then hashed in full
+func outputHashes(cacheDir string) map[string]utils.OutputFile {
+	if _, err := os.Stat(filepath.Join(cacheDir, "meta.db")); err != nil {
+		return nil
+	}
+	cm, err := cache.OpenWithTimeout(cacheDir, false, 200*time.Millisecond)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = cm.Close() }()
+	files, _ := cm.GetOutputFiles()
+	return files
+}
+
+// parallel runs fn over items with up to workers at once and returns the
+// first error, after which no new items are started
+func parallel(ctx context.Context, items []string, workers int, fn func(context.Context, string) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	work := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(work)
+		for _, item := range items {
+			select {
+			case work <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for range workers {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for item := range work {
+				if err := fn(ctx, item); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+	for range workers {
+		<-done
+	}
+	if err := context.Cause(ctx); err != nil && ctx.Err() != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// memTarget is a target that keeps what it holds in memory
+type memTarget struct {
+	files     map[string]string
+	manifest  Manifest
+	published int
+}
+
+func (m *memTarget) Manifest(context.Context) (Manifest, error) {
+	if m.manifest == nil {
+		return Manifest{}, nil
+	}
+	return m.manifest, nil
+}
+
+func (m *memTarget) Publish(_ context.Context, dir string, plan Plan, manifest Manifest) error {
+	m.published++
+	for _, rel := range plan.Upload {
+		data, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		m.files[rel] = string(data)
+	}
+	for _, rel := range plan.Delete {
+		delete(m.files, rel)
+	}
+	m.manifest = manifest
+	return nil
+}
+
+func writeOutput(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDeployUploadsOnlyChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeOutput(t, dir, map[string]string{
+		"index.html":         "<h1>Home</h1>",
+		"posts/a/index.html": "<h1>A</h1>",
+		"static/app.css":     "body{}",
+	})
+	target := &memTarget{files: map[string]string{}}
+
+	plan, err := Deploy(context.Background(), target, dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Upload) != 3 || len(target.files) != 3 {
+		t.Fatalf("first deploy uploaded %v", plan.Upload)
+	}
+
+	// Nothing changed: the target isn't touched
+	plan, err = Deploy(context.Background(), target, dir, Options{})
+	if err != nil || !plan.Empty() || plan.Unchanged != 3 || target.published != 1 {
+		t.Fatalf("second deploy: plan %+v, published %d times, err %v", plan, target.published, err)
+	}
+
+	writeOutput(t, dir, map[string]string{"index.html": "<h1>Home!</h1>", "posts/b/index.html": "<h1>B</h1>"})
+	if err := os.RemoveAll(filepath.Join(dir, "posts/a")); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err = Deploy(context.Background(), target, dir, Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(plan.Upload, []string{"index.html", "posts/b/index.html"}) || !slices.Equal(plan.Delete, []string{"posts/a/index.html"}) || plan.Unchanged != 1 {
+		t.Errorf("plan = %+v", plan)
+	}
+	if target.published != 1 {
+		t.Error("a dry run published")
+	}
+
+	if _, err := Deploy(context.Background(), target, dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if target.files["index.html"] != "<h1>Home!</h1>" || target.files["posts/a/index.html"] != "" || len(target.files) != 3 {
+		t.Errorf("target holds %v", target.files)
+	}
+
+	plan, err = Deploy(context.Background(), target, dir, Options{Force: true, DryRun: true})
+	if err != nil || len(plan.Upload) != 3 {
+		t.Errorf("forced plan = %+v, %v", plan, err)
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeOutput(t, dir, map[string]string{"a.txt": "a", ".git/HEAD": "ref"})
+	m, err := Scan(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 || m["a.txt"] == "" {
+		t.Fatalf("Scan = %v, want only a.txt", m)
+	}
+	decoded, err := DecodeManifest(m.Encode())
+	if err != nil || decoded["a.txt"] != m["a.txt"] {
+		t.Errorf("DecodeManifest(Encode()) = %v, %v", decoded, err)
+	}
+}
+
+func TestScanReusesBuildHashes(t *testing.T) {
+	dir := t.TempDir()
+	writeOutput(t, dir, map[string]string{"same.html": "same", "edited.html": "edited"})
+	known := map[string]utils.OutputFile{}
+	for _, rel := range []string{"same.html", "edited.html"} {
+		info, err := os.Stat(filepath.Join(dir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		known[rel] = utils.OutputFile{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Hash: "recorded"}
+	}
+	// Edited after the build: same size, another mtime
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "edited.html"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Scan(dir, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["same.html"] != "recorded" {
+		t.Errorf("same.html = %q, want the build's hash", m["same.html"])
+	}
+	if want, _ := hashFile(filepath.Join(dir, "edited.html")); m["edited.html"] != want {
+		t.Errorf("edited.html = %q, want it hashed again (%s)", m["edited.html"], want)
+	}
+}
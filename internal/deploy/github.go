@@ -0,0 +1,167 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// githubTarget commits the output to a branch GitHub Pages serves, with the
+// git command line, so the user's git credentials apply. Manifest makes a
+// shallow clone of the branch that Publish then commits and pushes. Every
+// file of the branch is served, so the manifest is recorded locally with
+// the git blob ID of each file.
+type githubTarget struct {
+	repo   string
+	branch string
+	work   string // Clone of the branch
+	record *record
+}
+
+func newGitHubTarget(t config.DeployTarget, recordPath string) Target {
+	branch := t.Branch
+	if branch == "" {
+		branch = "gh-pages"
+	}
+	return &githubTarget{repo: t.Repo, branch: branch, record: loadRecord(recordPath)}
+}
+
+func (g *githubTarget) Manifest(ctx context.Context) (Manifest, error) {
+	if g.repo == "" {
+		out, err := git(ctx, "", "remote", "get-url", "origin")
+		if err != nil {
+			return nil, fmt.Errorf("no repo configured and no origin remote: %w", err)
+		}
+		g.repo = strings.TrimSpace(out)
+	}
+	work, err := os.MkdirTemp("", "kosh-deploy-*")
+	if err != nil {
+		return nil, err
+	}
+	g.work = work
+
+	// ls-remote exits with 2 when the branch doesn't exist yet
+	if _, err := git(ctx, "", "ls-remote", "--exit-code", "--heads", g.repo, g.branch); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) || exit.ExitCode() != 2 {
+			return nil, err
+		}
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"checkout", "-q", "--orphan", g.branch},
+			{"remote", "add", "origin", g.repo},
+		} {
+			if _, err := git(ctx, work, args...); err != nil {
+				return nil, err
+			}
+		}
+		return Manifest{}, nil
+	}
+
+	if _, err := git(ctx, "", "clone", "-q", "--depth", "1", "--single-branch", "--branch", g.branch, g.repo, work); err != nil {
+		return nil, err
+	}
+	blobs, err := g.blobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return g.record.resolve(blobs), nil
+}
+
+// blobs returns the blob ID of every file of the clone's HEAD
+func (g *githubTarget) blobs(ctx context.Context) (map[string]string, error) {
+	out, err := git(ctx, g.work, "ls-tree", "-r", "-z", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	blobs := make(map[string]string)
+	for _, entry := range strings.Split(out, "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		meta, file, ok := strings.Cut(entry, "\t")
+		if fields := strings.Fields(meta); ok && len(fields) == 3 {
+			blobs[file] = fields[2]
+		}
+	}
+	return blobs, nil
+}
+
+func (g *githubTarget) Publish(ctx context.Context, dir string, plan Plan, manifest Manifest) error {
+	for _, rel := range plan.Upload {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(g.work, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+	}
+	for _, rel := range plan.Delete {
+		if err := os.Remove(filepath.Join(g.work, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if _, err := git(ctx, g.work, "add", "-A"); err != nil {
+		return err
+	}
+	// Without a record every file is written again, often unchanged
+	if status, err := git(ctx, g.work, "status", "--porcelain"); err != nil {
+		return err
+	} else if status != "" {
+		commit := []string{"commit", "-q", "-m", "Deploy " + time.Now().UTC().Format(time.RFC3339)}
+		if out, _ := git(ctx, g.work, "config", "user.email"); strings.TrimSpace(out) == "" {
+			commit = append([]string{"-c", "user.name=kosh", "-c", "user.email=kosh@localhost"}, commit...)
+		}
+		if _, err := git(ctx, g.work, commit...); err != nil {
+			return err
+		}
+		if _, err := git(ctx, g.work, "push", "-q", "origin", "HEAD:refs/heads/"+g.branch); err != nil {
+			return err
+		}
+	}
+
+	blobs, err := g.blobs(ctx)
+	if err != nil {
+		return err
+	}
+	if err := g.record.save(manifest, blobs); err != nil {
+		return fmt.Errorf("failed to record the deploy: %w", err)
+	}
+	return nil
+}
+
+// Close removes the clone
+func (g *githubTarget) Close() error {
+	if g.work == "" {
+		return nil
+	}
+	return os.RemoveAll(g.work)
+}
+
+// git runs a git command in dir and returns its output; errors carry what
+// git printed
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return stdout.String(), fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
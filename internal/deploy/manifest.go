@@ -0,0 +1,241 @@
+package deploy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/blake3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// manifestSuffix names a manifest kept next to the site directory:
+// "/var/www/site" has "/var/www/site.kosh-deploy.json"
+const manifestSuffix = ".kosh-deploy.json"
+
+// Manifest maps the slash-separated paths of a deploy to the BLAKE3 hashes
+// of their contents
+type Manifest map[string]string
+
+// manifestJSON is the stored form of a Manifest
+type manifestJSON struct {
+	Files Manifest `json:"files"`
+}
+
+// Encode returns the manifest as stored on a target
+func (m Manifest) Encode() []byte {
+	data, _ := json.MarshalIndent(manifestJSON{Files: m}, "", "  ")
+	return append(data, '\n')
+}
+
+// DecodeManifest reads a stored manifest
+func DecodeManifest(data []byte) (Manifest, error) {
+	var stored manifestJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Files == nil {
+		stored.Files = Manifest{}
+	}
+	return stored.Files, nil
+}
+
+// Scan hashes every file under dir but .git. Files whose size and mtime
+// match their record in known (the hashes the build took when it wrote
+// them) aren't read. known may be nil.
+func Scan(dir string, known map[string]utils.OutputFile) (Manifest, error) {
+	manifest := Manifest{}
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if state, ok := known[rel]; ok {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() == state.Size && info.ModTime().UnixNano() == state.ModTime {
+				manifest[rel] = state.Hash
+				return nil
+			}
+		}
+		paths = append(paths, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), 16) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rel := range work {
+				sum, err := hashFile(filepath.Join(dir, filepath.FromSlash(rel)))
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				manifest[rel] = sum
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, rel := range paths {
+		work <- rel
+	}
+	close(work)
+	wg.Wait()
+	return manifest, firstErr
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := blake3.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// record is the manifest of the last deploy to a target that can't keep one
+// out of sight itself (S3, Netlify, GitHub Pages), stored in the cache
+// directory. Native holds the target's own checksum of each file then: an
+// S3 ETag, a Netlify SHA-1, a git blob ID. A target that lists its files
+// with those checksums tells which recorded hashes still hold, so a
+// missing or stale record (another machine deployed since) costs uploads,
+// never a wrong diff.
+type record struct {
+	path   string
+	Files  Manifest          `json:"files"`
+	Native map[string]string `json:"native"`
+}
+
+// recordPath is where the record of deploys to t is kept: one file per
+// destination, so a deploy target and largeFiles.storage never share one
+func recordPath(cacheDir string, t config.DeployTarget) string {
+	sum := blake3.Sum256([]byte(strings.Join([]string{t.Type, t.Endpoint, t.Bucket, t.Prefix, t.Repo, t.Branch, t.Site}, "\x00")))
+	return filepath.Join(cacheDir, "deploy", hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadRecord reads the record at path. A missing or unreadable record is
+// empty.
+func loadRecord(path string) *record {
+	r := &record{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, r)
+	}
+	if r.Files == nil {
+		r.Files = Manifest{}
+	}
+	if r.Native == nil {
+		r.Native = map[string]string{}
+	}
+	return r
+}
+
+// resolve returns the manifest of a target holding listed (path → native
+// checksum). A file keeps its recorded hash while its checksum is the
+// recorded one; others get none, so they differ from any output file.
+func (r *record) resolve(listed map[string]string) Manifest {
+	manifest := make(Manifest, len(listed))
+	for rel, native := range listed {
+		if sum, ok := r.Files[rel]; ok && native != "" && r.Native[rel] == native {
+			manifest[rel] = sum
+		} else {
+			manifest[rel] = ""
+		}
+	}
+	return manifest
+}
+
+// save replaces the record with manifest and the native checksums of its
+// files
+func (r *record) save(manifest Manifest, native map[string]string) error {
+	r.Files, r.Native = manifest, native
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// Plan is what a deploy changes on its target
+type Plan struct {
+	Upload    []string // New and changed files
+	Delete    []string // Files the output no longer has
+	Unchanged int
+}
+
+// Empty reports whether the target is already up to date
+func (p Plan) Empty() bool {
+	return len(p.Upload) == 0 && len(p.Delete) == 0
+}
+
+// Diff plans the deploy of local onto a target holding remote
+func Diff(local, remote Manifest) Plan {
+	var plan Plan
+	for path, sum := range local {
+		if remote[path] == sum {
+			plan.Unchanged++
+		} else {
+			plan.Upload = append(plan.Upload, path)
+		}
+	}
+	for path := range remote {
+		if _, ok := local[path]; !ok {
+			plan.Delete = append(plan.Delete, path)
+		}
+	}
+	slices.Sort(plan.Upload)
+	slices.Sort(plan.Delete)
+	return plan
+}
+
+// pageURLs returns the URL paths a file is served at: "/docs/index.html" is
+// also "/docs/"
+func pageURLs(rel string) []string {
+	urls := []string{"/" + rel}
+	if rel == "index.html" {
+		urls = append(urls, "/")
+	} else if dir, ok := strings.CutSuffix(rel, "/index.html"); ok {
+		urls = append(urls, "/"+dir+"/")
+	}
+	return urls
+}
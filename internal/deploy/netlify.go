@@ -0,0 +1,158 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// netlifyTarget publishes through Netlify's file digest API. A Netlify
+// deploy lists every file of the site by SHA-1 and Netlify answers with the
+// digests it doesn't have, so unchanged files are never sent and removed
+// files disappear with the new deploy. Everything a deploy holds is served,
+// so the manifest is recorded locally with the SHA-1 of each file and
+// checked against the files of the live deploy.
+type netlifyTarget struct {
+	client *http.Client
+	api    string // API base URL
+	site   string
+	token  string
+	record *record
+}
+
+func newNetlifyTarget(client *http.Client, t config.DeployTarget, recordPath string) (Target, error) {
+	if t.Site == "" {
+		return nil, fmt.Errorf("deploy target %s: netlify needs the site ID", t.DisplayName())
+	}
+	token := t.Token
+	if token == "" {
+		token = os.Getenv("NETLIFY_AUTH_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("deploy target %s: no Netlify token (set NETLIFY_AUTH_TOKEN or token)", t.DisplayName())
+	}
+	return &netlifyTarget{
+		client: client,
+		api:    "https://api.netlify.com/api/v1",
+		site:   t.Site,
+		token:  token,
+		record: loadRecord(recordPath),
+	}, nil
+}
+
+func (n *netlifyTarget) Manifest(ctx context.Context) (Manifest, error) {
+	var files []struct {
+		Path string `json:"path"`
+		SHA  string `json:"sha"`
+	}
+	if err := n.call(ctx, http.MethodGet, "/sites/"+url.PathEscape(n.site)+"/files", "", nil, &files); err != nil {
+		return nil, err
+	}
+	listed := make(map[string]string, len(files))
+	for _, f := range files {
+		listed[strings.TrimPrefix(f.Path, "/")] = f.SHA
+	}
+	return n.record.resolve(listed), nil
+}
+
+func (n *netlifyTarget) Publish(ctx context.Context, dir string, plan Plan, manifest Manifest) error {
+	// Netlify wants every file, by SHA-1, with a leading slash. Unchanged
+	// files keep the digest recorded for them.
+	native := make(map[string]string, len(manifest))
+	digests := make(map[string]string, len(manifest))
+	paths := make(map[string]string, len(manifest)) // Digest → file
+	for rel, sum := range manifest {
+		digest := n.record.Native[rel]
+		if digest == "" || n.record.Files[rel] != sum {
+			data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+			if err != nil {
+				return err
+			}
+			digest = sha1Hex(data)
+		}
+		native[rel] = digest
+		digests["/"+rel] = digest
+		paths[digest] = rel
+	}
+
+	body, err := json.Marshal(map[string]any{"files": digests})
+	if err != nil {
+		return err
+	}
+	var deploy struct {
+		ID       string   `json:"id"`
+		Required []string `json:"required"`
+	}
+	if err := n.call(ctx, http.MethodPost, "/sites/"+url.PathEscape(n.site)+"/deploys", "application/json", body, &deploy); err != nil {
+		return err
+	}
+
+	err = parallel(ctx, deploy.Required, 8, func(ctx context.Context, digest string) error {
+		rel, ok := paths[digest]
+		if !ok {
+			return fmt.Errorf("netlify asked for unknown file %s", digest)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		segments := strings.Split(rel, "/")
+		for i, seg := range segments {
+			segments[i] = url.PathEscape(seg)
+		}
+		endpoint := "/deploys/" + url.PathEscape(deploy.ID) + "/files/" + strings.Join(segments, "/")
+		return n.call(ctx, http.MethodPut, endpoint, "application/octet-stream", data, nil)
+	})
+	if err != nil {
+		return err
+	}
+	if err := n.record.save(manifest, native); err != nil {
+		return fmt.Errorf("failed to record the deploy: %w", err)
+	}
+	return nil
+}
+
+func sha1Hex(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// call sends an authenticated API request and decodes the JSON response
+// into out, when given
+func (n *netlifyTarget) call(ctx context.Context, method, endpoint, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, n.api+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("netlify %s %s: %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package deploy
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+)
+
+// maxInvalidationPaths is how many paths are invalidated one by one; more
+// become a single wildcard, which CloudFront bills as one path
+const maxInvalidationPaths = 100
+
+// s3Target publishes to an S3 bucket (or S3-compatible storage) over its
+// REST API, signing requests with AWS Signature Version 4. A bucket served
+// as a site has no private place for the manifest, so it is recorded
+// locally with the ETag of each object and checked against a listing of
+// the prefix. Only objects a deploy recorded are ever deleted: the bucket
+// may hold other files.
+type s3Target struct {
+	client       *http.Client
+	policy       config.CacheControlConfig
+	bucketURL    string // Objects are bucketURL + "/" + key
+	prefix       string
+	region       string
+	distribution string
+	cloudFront   string // CloudFront API base URL
+	creds        awsCredentials
+	record       *record
+}
+
+type awsCredentials struct {
+	accessKey, secretKey, sessionToken string
+}
+
+func newS3Target(client *http.Client, policy config.CacheControlConfig, t config.DeployTarget, recordPath string) (Target, error) {
+	if t.Bucket == "" {
+		return nil, fmt.Errorf("deploy target %s: s3 needs a bucket", t.DisplayName())
+	}
+	s := &s3Target{
+		client:       client,
+		policy:       policy,
+		prefix:       strings.Trim(t.Prefix, "/"),
+		region:       t.Region,
+		distribution: t.Distribution,
+		cloudFront:   "https://cloudfront.amazonaws.com",
+		creds: awsCredentials{
+			accessKey:    cmp.Or(t.AccessKey, os.Getenv("AWS_ACCESS_KEY_ID")),
+			secretKey:    cmp.Or(t.SecretKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		record: loadRecord(recordPath),
+	}
+	if s.creds.accessKey == "" || s.creds.secretKey == "" {
+		return nil, fmt.Errorf("deploy target %s: no AWS credentials (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or accessKey and secretKey)", t.DisplayName())
+	}
+	if s.region == "" {
+		s.region = cmp.Or(os.Getenv("AWS_REGION"), "us-east-1")
+	}
+	if t.Endpoint != "" {
+		s.bucketURL = strings.TrimSuffix(t.Endpoint, "/") + "/" + url.PathEscape(t.Bucket) // Path style
+	} else {
+		s.bucketURL = "https://" + t.Bucket + ".s3." + s.region + ".amazonaws.com"
+	}
+	if s.prefix != "" {
+		s.prefix += "/"
+	}
+	return s, nil
+}
+
+func (s *s3Target) Manifest(ctx context.Context) (Manifest, error) {
+	etags, err := s.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]string)
+	for rel := range s.record.Files {
+		if etag, ok := etags[rel]; ok {
+			listed[rel] = etag
+		}
+	}
+	return s.record.resolve(listed), nil
+}
+
+// list returns the ETag of every object under the prefix, by path relative
+// to it
+func (s *s3Target) list(ctx context.Context) (map[string]string, error) {
+	etags := make(map[string]string)
+	var token string
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if s.prefix != "" {
+			query.Set("prefix", s.prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.bucketURL+"/?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		s.creds.sign(req, nil, "s3", s.region, time.Now())
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("listing the bucket: %w", s3Error(resp, body))
+		}
+		var page struct {
+			Contents []struct {
+				Key  string `xml:"Key"`
+				ETag string `xml:"ETag"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("listing the bucket: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if rel, ok := strings.CutPrefix(obj.Key, s.prefix); ok {
+				etags[rel] = strings.Trim(obj.ETag, `"`)
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return etags, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *s3Target) Publish(ctx context.Context, dir string, plan Plan, manifest Manifest) error {
+	native := make(map[string]string, len(manifest))
+	for rel := range manifest {
+		if s.record.Files[rel] == manifest[rel] {
+			native[rel] = s.record.Native[rel]
+		}
+	}
+	var mu sync.Mutex
+	err := parallel(ctx, plan.Upload, 8, func(ctx context.Context, rel string) error {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		header := http.Header{"Content-Type": {contentType(rel)}}
+		if cc := generators.CacheControlFor(s.policy, rel); cc != "" {
+			header.Set("Cache-Control", cc)
+		}
+		respHeader, err := s.expect(ctx, http.MethodPut, rel, header, data, http.StatusOK)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		native[rel] = strings.Trim(respHeader.Get("ETag"), `"`)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = parallel(ctx, plan.Delete, 8, func(ctx context.Context, rel string) error {
+		_, err := s.expect(ctx, http.MethodDelete, rel, nil, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.record.save(manifest, native); err != nil {
+		return fmt.Errorf("failed to record the deploy: %w", err)
+	}
+	if s.distribution != "" {
+		return s.invalidate(ctx, slices.Concat(plan.Upload, plan.Delete))
+	}
+	return nil
+}
+
+// contentType is the Content-Type an object is served with: the one
+// recorded for generated files without a usable extension, else the
+// extension's
+func contentType(rel string) string {
+	if t := generators.ContentTypeFor(rel); t != "" {
+		return t
+	}
+	if t := mime.TypeByExtension(path.Ext(rel)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// do sends a signed request for the object at key rel
+func (s *s3Target) do(ctx context.Context, method, rel string, header http.Header, body []byte) (*http.Response, error) {
+	key := s.prefix + rel
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.bucketURL+"/"+strings.Join(segments, "/"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	s.creds.sign(req, body, "s3", s.region, time.Now())
+	return s.client.Do(req)
+}
+
+// expect sends a request and fails unless the response has one of the
+// wanted status codes. It returns the response headers.
+func (s *s3Target) expect(ctx context.Context, method, rel string, header http.Header, body []byte, want ...int) (http.Header, error) {
+	resp, err := s.do(ctx, method, rel, header, body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(resp.Body)
+	if !slices.Contains(want, resp.StatusCode) {
+		return nil, fmt.Errorf("%s %s: %w", method, rel, s3Error(resp, respBody))
+	}
+	return resp.Header, nil
+}
+
+// s3Error turns an S3 error response into an error
+func s3Error(resp *http.Response, body []byte) error {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return fmt.Errorf("%s: %s: %s", resp.Status, e.Code, e.Message)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
+
+// invalidate asks CloudFront to drop its cached copies of the changed files
+func (s *s3Target) invalidate(ctx context.Context, changed []string) error {
+	var paths []string
+	for _, rel := range changed {
+		for _, u := range pageURLs(rel) {
+			paths = append(paths, "/"+s.prefix+strings.TrimPrefix(u, "/"))
+		}
+	}
+	if len(paths) > maxInvalidationPaths {
+		paths = []string{"/" + s.prefix + "*"}
+	}
+
+	type batch struct {
+		XMLName xml.Name `xml:"http://cloudfront.amazonaws.com/doc/2020-05-31/ InvalidationBatch"`
+		Paths   struct {
+			Quantity int      `xml:"Quantity"`
+			Items    []string `xml:"Items>Path"`
+		} `xml:"Paths"`
+		CallerReference string `xml:"CallerReference"`
+	}
+	var b batch
+	b.Paths.Quantity = len(paths)
+	b.Paths.Items = paths
+	b.CallerReference = fmt.Sprintf("kosh-%d", time.Now().UnixNano())
+	body, err := xml.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	endpoint := s.cloudFront + "/2020-05-31/distribution/" + url.PathEscape(s.distribution) + "/invalidation"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	s.creds.sign(req, body, "cloudfront", "us-east-1", time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("CloudFront invalidation: %w", s3Error(resp, respBody))
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers to a request. The host,
+// x-amz-* headers and the payload hash are signed.
+func (c awsCredentials) sign(req *http.Request, body []byte, service, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash) // S3 requires it
+	}
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var canonicalQuery []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			canonicalQuery = append(canonicalQuery, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+c.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape percent-encodes a path segment, query key or value the way
+// SigV4 expects: everything but letters, digits and -_.~
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,229 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// rsyncTarget copies files with rsync, to a directory or over SSH. The
+// changed and the removed files go in one --files-from list: with
+// --delete-missing-args rsync deletes the entries missing from the output.
+// The manifest is kept next to the site directory, where it isn't served.
+type rsyncTarget struct {
+	dest     string // Ends with "/"
+	manifest string // rsync location of the manifest
+	port     int
+}
+
+func newRsyncTarget(t config.DeployTarget) (Target, error) {
+	if t.Dest == "" {
+		return nil, fmt.Errorf("deploy target %s: rsync needs a dest", t.DisplayName())
+	}
+	if _, err := exec.LookPath("rsync"); err != nil {
+		return nil, fmt.Errorf("deploy target %s: rsync is not installed", t.DisplayName())
+	}
+	dest := strings.TrimSuffix(t.Dest, "/")
+	// host:path is remote, unless the colon comes after a slash
+	host, dir, ok := strings.Cut(dest, ":")
+	if !ok || strings.Contains(host, "/") {
+		host, dir = "", dest
+	}
+	manifest, err := manifestPath(t, dir)
+	if err != nil {
+		return nil, err
+	}
+	if host != "" {
+		manifest = host + ":" + manifest
+	}
+	return &rsyncTarget{dest: dest + "/", manifest: manifest, port: t.Port}, nil
+}
+
+// manifestPath is where rsync and sftp keep the manifest of a site in dir:
+// the manifest setting, else next to dir
+func manifestPath(t config.DeployTarget, dir string) (string, error) {
+	if t.Manifest != "" {
+		return t.Manifest, nil
+	}
+	if dir == "" || dir == "." || dir == "~" {
+		return "", fmt.Errorf("deploy target %s: the site is a home or root directory; set manifest to a path outside it", t.DisplayName())
+	}
+	return dir + manifestSuffix, nil
+}
+
+func (r *rsyncTarget) rsync(ctx context.Context, stdin *strings.Reader, args ...string) error {
+	if r.port != 0 {
+		args = append([]string{"-e", "ssh -p " + strconv.Itoa(r.port)}, args...)
+	}
+	return run(ctx, stdin, "rsync", args...)
+}
+
+func (r *rsyncTarget) Manifest(ctx context.Context) (Manifest, error) {
+	tmp, err := os.MkdirTemp("", "kosh-deploy-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	// rsync exits with 23 when the source file doesn't exist
+	local := filepath.Join(tmp, "manifest.json")
+	if err := r.rsync(ctx, nil, "-q", r.manifest, local); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && exit.ExitCode() == 23 {
+			return Manifest{}, nil
+		}
+		return nil, err
+	}
+	data, err := os.ReadFile(local)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeManifest(data)
+}
+
+func (r *rsyncTarget) Publish(ctx context.Context, dir string, plan Plan, manifest Manifest) error {
+	list := strings.Join(slices.Concat(plan.Upload, plan.Delete), "\n") + "\n"
+	src := strings.TrimSuffix(dir, string(filepath.Separator)) + "/"
+	if err := r.rsync(ctx, strings.NewReader(list), "-a", "--files-from=-", "--delete-missing-args", src, r.dest); err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "kosh-deploy-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	local := filepath.Join(tmp, "manifest.json")
+	if err := os.WriteFile(local, manifest.Encode(), 0644); err != nil {
+		return err
+	}
+	return r.rsync(ctx, nil, "-q", local, r.manifest)
+}
+
+// sftpTarget uploads with the OpenSSH sftp client in batch mode, for hosts
+// that offer SFTP but no shell. Like rsync, it keeps the manifest next to
+// the site directory.
+type sftpTarget struct {
+	host     string // [user@]host
+	root     string // Remote directory of the site
+	manifest string // Remote path of the manifest
+	port     int
+}
+
+func newSFTPTarget(t config.DeployTarget) (Target, error) {
+	host, root, ok := strings.Cut(t.Dest, ":")
+	if !ok || host == "" {
+		return nil, fmt.Errorf("deploy target %s: sftp needs a dest like user@host:/var/www/site", t.DisplayName())
+	}
+	if _, err := exec.LookPath("sftp"); err != nil {
+		return nil, fmt.Errorf("deploy target %s: sftp is not installed", t.DisplayName())
+	}
+	root = strings.TrimSuffix(root, "/")
+	manifest, err := manifestPath(t, root)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpTarget{host: host, root: root, manifest: manifest, port: t.Port}, nil
+}
+
+// remote is the remote path of a file of the site
+func (s *sftpTarget) remote(rel string) string {
+	if s.root == "" {
+		return rel
+	}
+	return s.root + "/" + rel
+}
+
+// batch runs sftp commands; a leading "-" makes sftp ignore a failure
+func (s *sftpTarget) batch(ctx context.Context, commands []string) error {
+	args := []string{"-q", "-b", "-"}
+	if s.port != 0 {
+		args = append(args, "-P", strconv.Itoa(s.port))
+	}
+	return run(ctx, strings.NewReader(strings.Join(commands, "\n")+"\n"), "sftp", append(args, s.host)...)
+}
+
+func (s *sftpTarget) Manifest(ctx context.Context) (Manifest, error) {
+	tmp, err := os.MkdirTemp("", "kosh-deploy-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	local := filepath.Join(tmp, "manifest.json")
+	if err := s.batch(ctx, []string{"-get " + sftpQuote(s.manifest) + " " + sftpQuote(local)}); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(local)
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeManifest(data)
+}
+
+func (s *sftpTarget) Publish(ctx context.Context, dir string, plan Plan, manifest Manifest) error {
+	tmp, err := os.MkdirTemp("", "kosh-deploy-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	local := filepath.Join(tmp, "manifest.json")
+	if err := os.WriteFile(local, manifest.Encode(), 0644); err != nil {
+		return err
+	}
+
+	var commands []string
+	made := map[string]bool{".": true}
+	for _, rel := range plan.Upload {
+		// sftp has no mkdir -p: make each missing parent, ignoring existing ones
+		var parents []string
+		for d := path.Dir(rel); !made[d]; d = path.Dir(d) {
+			made[d] = true
+			parents = append(parents, d)
+		}
+		for i := len(parents) - 1; i >= 0; i-- {
+			commands = append(commands, "-mkdir "+sftpQuote(s.remote(parents[i])))
+		}
+		commands = append(commands, "put "+sftpQuote(filepath.Join(dir, filepath.FromSlash(rel)))+" "+sftpQuote(s.remote(rel)))
+	}
+	for _, rel := range plan.Delete {
+		commands = append(commands, "-rm "+sftpQuote(s.remote(rel)))
+	}
+	commands = append(commands, "put "+sftpQuote(local)+" "+sftpQuote(s.manifest))
+	return s.batch(ctx, commands)
+}
+
+// sftpQuote quotes a path for an sftp batch file
+func sftpQuote(p string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(p) + `"`
+}
+
+// run runs a command, feeding it stdin, and returns an error carrying what
+// it printed when it fails
+func run(ctx context.Context, stdin *strings.Reader, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
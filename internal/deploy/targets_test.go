@@ -0,0 +1,330 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	creds := awsCredentials{accessKey: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	creds.sign(req, nil, "service", "us-east-1", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// fakeS3 stores objects by path and records CloudFront invalidations
+type fakeS3 struct {
+	mu            sync.Mutex
+	objects       map[string]string
+	headers       map[string]http.Header
+	invalidations []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/") {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/invalidation"):
+		f.invalidations = append(f.invalidations, string(body))
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		f.objects[r.URL.Path] = string(body)
+		f.headers[r.URL.Path] = r.Header.Clone()
+		w.Header().Set("ETag", `"`+sha1Hex(body)+`"`)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		// One object per page, to exercise continuation
+		bucket := strings.TrimSuffix(r.URL.Path, "/")
+		var keys []string
+		for path := range f.objects {
+			if key, ok := strings.CutPrefix(path, bucket+"/"); ok && strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		start := 0
+		if token := r.URL.Query().Get("continuation-token"); token != "" {
+			start, _ = strconv.Atoi(token)
+		}
+		_, _ = io.WriteString(w, "<ListBucketResult>")
+		if start < len(keys) {
+			key := keys[start]
+			_, _ = fmt.Fprintf(w, "<Contents><Key>%s</Key><ETag>&quot;%s&quot;</ETag></Contents>", key, sha1Hex([]byte(f.objects[bucket+"/"+key])))
+		}
+		if start+1 < len(keys) {
+			_, _ = fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+		}
+		_, _ = io.WriteString(w, "</ListBucketResult>")
+	case r.Method == http.MethodGet:
+		obj, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>")
+			return
+		}
+		_, _ = io.WriteString(w, obj)
+	}
+}
+
+func TestS3Target(t *testing.T) {
+	fake := &fakeS3{objects: map[string]string{}, headers: map[string]http.Header{}}
+	fake.objects["/site/blog/media/talk.mp4"] = "not deployed by kosh"
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeOutput(t, dir, map[string]string{
+		"index.html":              "<h1>Home</h1>",
+		"docs/index.html":         "<h1>Docs</h1>",
+		"static/app.1a2b3c4d.css": "body{}",
+		".well-known/webfinger":   `{"subject":"acct:me@example.com"}`,
+	})
+	spec := config.DeployTarget{Type: S3, Bucket: "site", Prefix: "/blog/", Endpoint: srv.URL, Distribution: "E1", AccessKey: "AK", SecretKey: "SK"}
+	policy := config.CacheControlConfig{HTML: "no-cache", Assets: "public, max-age=31536000, immutable"}
+	record := filepath.Join(t.TempDir(), "record.json")
+	newTarget := func(record string) Target {
+		target, err := newS3Target(srv.Client(), policy, spec, record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target.(*s3Target).cloudFront = srv.URL
+		return target
+	}
+
+	if _, err := Deploy(context.Background(), newTarget(record), dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if fake.objects["/site/blog/docs/index.html"] != "<h1>Docs</h1>" {
+		t.Fatalf("objects = %v", fake.objects)
+	}
+	if h := fake.headers["/site/blog/static/app.1a2b3c4d.css"]; h.Get("Content-Type") != "text/css; charset=utf-8" || h.Get("Cache-Control") != policy.Assets {
+		t.Errorf("css uploaded with %v", h)
+	}
+	if h := fake.headers["/site/blog/.well-known/webfinger"]; h.Get("Content-Type") != "application/jrd+json" {
+		t.Errorf("webfinger uploaded with %v", h)
+	}
+	if h := fake.headers["/site/blog/index.html"]; h.Get("Cache-Control") != "no-cache" {
+		t.Errorf("html uploaded with %v", h)
+	}
+	if len(fake.objects) != 5 {
+		t.Errorf("bucket holds %v, want the site and the foreign file, no manifest", fake.objects)
+	}
+	if _, err := os.Stat(record); err != nil {
+		t.Errorf("deploy not recorded: %v", err)
+	}
+
+	writeOutput(t, dir, map[string]string{"docs/index.html": "<h1>Docs 2</h1>"})
+	if err := os.Remove(filepath.Join(dir, "index.html")); err != nil {
+		t.Fatal(err)
+	}
+	fake.invalidations = nil
+	plan, err := Deploy(context.Background(), newTarget(record), dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Upload) != 1 || len(plan.Delete) != 1 {
+		t.Errorf("plan = %+v", plan)
+	}
+	if _, ok := fake.objects["/site/blog/index.html"]; ok {
+		t.Error("removed page is still in the bucket")
+	}
+	if _, ok := fake.objects["/site/blog/media/talk.mp4"]; !ok {
+		t.Error("an object kosh didn't deploy was deleted")
+	}
+	if len(fake.invalidations) != 1 {
+		t.Fatalf("invalidations = %v", fake.invalidations)
+	}
+	for _, want := range []string{"<Quantity>4</Quantity>", "<Path>/blog/docs/</Path>", "<Path>/blog/</Path>", "<Path>/blog/index.html</Path>"} {
+		if !strings.Contains(fake.invalidations[0], want) {
+			t.Errorf("invalidation is missing %s:\n%s", want, fake.invalidations[0])
+		}
+	}
+
+	// A changed object no longer matches the record
+	fake.objects["/site/blog/docs/index.html"] = "edited in the console"
+	plan, err = Deploy(context.Background(), newTarget(record), dir, Options{DryRun: true})
+	if err != nil || !slices.Equal(plan.Upload, []string{"docs/index.html"}) || plan.Unchanged != 2 {
+		t.Errorf("plan after an edit = %+v, %v", plan, err)
+	}
+
+	// Without a record (another machine) every file is uploaded, none deleted
+	plan, err = Deploy(context.Background(), newTarget(filepath.Join(t.TempDir(), "record.json")), dir, Options{DryRun: true})
+	if err != nil || len(plan.Upload) != 3 || len(plan.Delete) != 0 {
+		t.Errorf("plan without a record = %+v, %v", plan, err)
+	}
+}
+
+func TestNetlifyTarget(t *testing.T) {
+	var mu sync.Mutex
+	live := map[string]string{} // Files of the published deploy by SHA-1
+	uploaded := map[string]string{}
+	var listed map[string]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/sites/abc/files", func(w http.ResponseWriter, r *http.Request) {
+		type file struct {
+			Path string `json:"path"`
+			SHA  string `json:"sha"`
+		}
+		files := []file{}
+		for path, sum := range live {
+			files = append(files, file{path, sum})
+		}
+		_ = json.NewEncoder(w).Encode(files)
+	})
+	mux.HandleFunc("POST /api/v1/sites/abc/deploys", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Files map[string]string `json:"files"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		listed, live = body.Files, body.Files
+		// Netlify already has the stylesheet
+		var required []string
+		for path, sum := range body.Files {
+			if path != "/app.css" {
+				required = append(required, sum)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "d1", "required": required})
+	})
+	mux.HandleFunc("PUT /api/v1/deploys/d1/files/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		uploaded[strings.TrimPrefix(r.URL.Path, "/api/v1/deploys/d1/files/")] = string(body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	writeOutput(t, dir, map[string]string{"index.html": "home", "app.css": "body{}"})
+	target, err := newNetlifyTarget(srv.Client(), config.DeployTarget{Type: Netlify, Site: "abc", Token: "tok"}, filepath.Join(t.TempDir(), "record.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.(*netlifyTarget).api = srv.URL + "/api/v1"
+
+	if _, err := Deploy(context.Background(), target, dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || listed["/index.html"] != sha1Hex([]byte("home")) {
+		t.Errorf("deploy listed %v, want every file by SHA-1", listed)
+	}
+	if uploaded["index.html"] != "home" || len(uploaded) != 1 {
+		t.Errorf("uploaded %v, want index.html only", uploaded)
+	}
+
+	// Once the deploy is live, an unchanged site isn't deployed again
+	listed = nil
+	plan, err := Deploy(context.Background(), target, dir, Options{})
+	if err != nil || !plan.Empty() || listed != nil {
+		t.Errorf("redeploy: plan %+v, listed %v, err %v", plan, listed, err)
+	}
+
+	// A deploy made elsewhere doesn't match the record
+	live = map[string]string{"/index.html": sha1Hex([]byte("other")), "/app.css": sha1Hex([]byte("body{}"))}
+	plan, err = Deploy(context.Background(), target, dir, Options{DryRun: true})
+	if err != nil || !slices.Equal(plan.Upload, []string{"index.html"}) || plan.Unchanged != 1 {
+		t.Errorf("plan after another deploy = %+v, %v", plan, err)
+	}
+}
+
+func TestGitHubTarget(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	repo := filepath.Join(t.TempDir(), "site.git")
+	if _, err := git(ctx, "", "init", "-q", "--bare", repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	writeOutput(t, dir, map[string]string{"index.html": "home", "old.html": "old", ".nojekyll": ""})
+	spec := config.DeployTarget{Type: GitHubPages, Repo: repo}
+	record := filepath.Join(t.TempDir(), "record.json")
+	if _, err := Deploy(ctx, newGitHubTarget(spec, record), dir, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "old.html")); err != nil {
+		t.Fatal(err)
+	}
+	writeOutput(t, dir, map[string]string{"index.html": "home 2"})
+	plan, err := Deploy(ctx, newGitHubTarget(spec, record), dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Upload) != 1 || len(plan.Delete) != 1 || plan.Unchanged != 1 {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	files, err := git(ctx, "", "--git-dir", repo, "ls-tree", "--name-only", "gh-pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(files); strings.Join(got, " ") != ".nojekyll index.html" {
+		t.Errorf("gh-pages holds %v", got)
+	}
+
+	// Without a record every file is written again, but an unchanged
+	// checkout makes no commit
+	plan, err = Deploy(ctx, newGitHubTarget(spec, filepath.Join(t.TempDir(), "record.json")), dir, Options{})
+	if err != nil || len(plan.Upload) != 2 {
+		t.Errorf("plan without a record = %+v, %v", plan, err)
+	}
+	log, _ := git(ctx, "", "--git-dir", repo, "rev-list", "--count", "gh-pages")
+	if strings.TrimSpace(log) != "2" {
+		t.Errorf("gh-pages has %s commits, want 2", strings.TrimSpace(log))
+	}
+}
+
+func TestManifestPath(t *testing.T) {
+	for _, tc := range []struct {
+		dir, manifest, want string
+	}{
+		{"/var/www/site", "", "/var/www/site.kosh-deploy.json"},
+		{"www", "", "www.kosh-deploy.json"},
+		{"/var/www/site", "/srv/kosh/site.json", "/srv/kosh/site.json"},
+		{"", "", ""}, // The home or root directory: the manifest would be served
+		{"~", "", ""},
+	} {
+		got, err := manifestPath(config.DeployTarget{Manifest: tc.manifest}, tc.dir)
+		if got != tc.want || (err != nil) != (tc.want == "") {
+			t.Errorf("manifestPath(%q, %q) = %q, %v; want %q", tc.dir, tc.manifest, got, err, tc.want)
+		}
+	}
+}
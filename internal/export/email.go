@@ -0,0 +1,217 @@
+package export
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+
+	"github.com/PuerkitoBio/goquery"
+	meta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/parser"
+)
+
+// EmailTemplate is the theme template used for email exports, if present
+const EmailTemplate = "email.html"
+
+//go:embed email_default.html
+var defaultEmailTemplate string
+
+// emailData is the context passed to the email template
+type emailData struct {
+	Title       string
+	Description string
+	Date        string
+	Author      string
+	Site        string
+	BaseURL     string
+	Permalink   string
+	Content     template.HTML
+}
+
+// Email is an exported post ready to paste into a newsletter tool
+type Email struct {
+	HTML string
+	Text string
+}
+
+func runEmail(args []string) {
+	var path, outDir, templatePath string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--out", "-out":
+			if i+1 < len(args) {
+				outDir = args[i+1]
+				i++
+			}
+		case "--template", "-template":
+			if i+1 < len(args) {
+				templatePath = args[i+1]
+				i++
+			}
+		default:
+			if path == "" {
+				path = args[i]
+			}
+		}
+	}
+	if path == "" {
+		printUsage()
+		return
+	}
+
+	cfg := config.Load(nil)
+	if templatePath == "" {
+		templatePath = filepath.Join(cfg.TemplateDir, EmailTemplate)
+	}
+
+	email, err := RenderEmail(cfg, path, templatePath)
+	if err != nil {
+		fmt.Printf("❌ Export failed: %v\n", err)
+		return
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if outDir != "" {
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			fmt.Printf("❌ Export failed: %v\n", err)
+			return
+		}
+		base = filepath.Join(outDir, base)
+	}
+	htmlPath, textPath := base+".email.html", base+".email.txt"
+	if err := os.WriteFile(htmlPath, []byte(email.HTML), 0644); err != nil {
+		fmt.Printf("❌ Export failed: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(textPath, []byte(email.Text), 0644); err != nil {
+		fmt.Printf("❌ Export failed: %v\n", err)
+		return
+	}
+	fmt.Printf("✅ Exported: %s\n", htmlPath)
+	fmt.Printf("✅ Exported: %s\n", textPath)
+}
+
+// RenderEmail renders the post at path into email-safe HTML with inlined CSS
+// and a plain-text alternative. templatePath falls back to the built-in
+// template when the file doesn't exist.
+func RenderEmail(cfg *config.Config, path, templatePath string) (*Email, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	md := mdParser.NewEmail()
+	ctx := parser.NewContext()
+	var body bytes.Buffer
+	if err := md.Convert(source, &body, parser.WithContext(ctx)); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	metaData := meta.Get(ctx)
+
+	tmplText := defaultEmailTemplate
+	if data, err := os.ReadFile(templatePath); err == nil {
+		tmplText = string(data)
+	}
+	tmpl, err := template.New(EmailTemplate).Parse(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email template: %w", err)
+	}
+
+	data := emailData{
+		Title:       utils.GetString(metaData, "title"),
+		Description: utils.GetString(metaData, "description"),
+		Date:        utils.GetString(metaData, "date"),
+		Author:      cfg.Author.Name,
+		Site:        cfg.Title,
+		BaseURL:     cfg.BaseURL,
+		Permalink:   permalink(cfg, path),
+		Content:     template.HTML(body.String()),
+	}
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		return nil, fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(&page)
+	if err != nil {
+		return nil, err
+	}
+	absolutizeLinks(doc, data.Permalink)
+	inlineCSS(doc)
+	out, err := goquery.OuterHtml(doc.Selection)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Email{HTML: out, Text: plainText(data, source)}, nil
+}
+
+// permalink returns the published URL of a content file, mirroring the build
+func permalink(cfg *config.Config, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	rel, err := utils.SafeRel(cfg.ContentDir, abs)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	htmlRel := strings.ToLower(strings.Replace(filepath.ToSlash(rel), ".md", ".html", 1))
+	return utils.BuildURL(cfg.BaseURL, "", htmlRel)
+}
+
+// absolutizeLinks makes links and images absolute against the post's URL,
+// since relative URLs mean nothing inside an inbox
+func absolutizeLinks(doc *goquery.Document, pageURL string) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+	resolve := func(attr string) func(int, *goquery.Selection) {
+		return func(_ int, s *goquery.Selection) {
+			ref, _ := s.Attr(attr)
+			if ref == "" || strings.HasPrefix(ref, "#") || strings.Contains(ref, "://") || strings.HasPrefix(ref, "mailto:") {
+				return
+			}
+			if strings.HasSuffix(ref, ".md") {
+				ref = strings.ToLower(strings.TrimSuffix(ref, ".md") + ".html")
+			}
+			if u, err := base.Parse(ref); err == nil {
+				s.SetAttr(attr, u.String())
+			}
+		}
+	}
+	doc.Find("a[href]").Each(resolve("href"))
+	doc.Find("img[src]").Each(resolve("src"))
+}
+
+// plainText builds the text/plain alternative. Markdown already reads well as
+// plain text, so the body is the post source without its frontmatter.
+func plainText(data emailData, source []byte) string {
+	body := source
+	if bytes.HasPrefix(bytes.TrimSpace(source), []byte("---")) {
+		if parts := bytes.SplitN(source, []byte("---"), 3); len(parts) == 3 {
+			body = parts[2]
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(data.Title)
+	b.WriteString("\n")
+	b.WriteString(strings.Repeat("=", len([]rune(data.Title))))
+	b.WriteString("\n\n")
+	b.WriteString(strings.TrimSpace(string(body)))
+	b.WriteString("\n\n---\nRead this post online: ")
+	b.WriteString(data.Permalink)
+	b.WriteString("\n")
+	return b.String()
+}
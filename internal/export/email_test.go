@@ -0,0 +1,78 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestInlineCSS(t *testing.T) {
+	page := `<html><head><style>
+/* comment */
+p, .lead { color: #111; }
+.lead { font-size: 18px; }
+a:hover { color: red; }
+@media (max-width: 600px) { p { font-size: 14px; } }
+</style></head><body><p class="lead" style="margin: 0">Hi <a href="#">x</a></p></body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inlineCSS(doc)
+
+	style, _ := doc.Find("p").Attr("style")
+	want := "color: #111; font-size: 18px; margin: 0"
+	if style != want {
+		t.Errorf("p style = %q, want %q", style, want)
+	}
+	rest := doc.Find("style").Text()
+	if !strings.Contains(rest, "a:hover") || !strings.Contains(rest, "@media") || strings.Contains(rest, ".lead") {
+		t.Errorf("remaining <style> = %q, want only the hover and media rules", rest)
+	}
+}
+
+func TestRenderEmail(t *testing.T) {
+	dir := t.TempDir()
+	contentDir := filepath.Join(dir, "content")
+	if err := os.MkdirAll(contentDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	post := filepath.Join(contentDir, "Hello.md")
+	source := "---\ntitle: Hello\ndate: \"2026-01-02\"\n---\n\nSee [the intro](intro.md) and ![pic](/static/a.png).\n"
+	if err := os.WriteFile(post, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{Title: "My Site", BaseURL: "https://example.com", ContentDir: contentDir}
+	email, err := RenderEmail(cfg, post, filepath.Join(dir, "missing.html"))
+	if err != nil {
+		t.Fatalf("RenderEmail() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`href="https://example.com/intro.html"`,
+		`src="https://example.com/static/a.png"`,
+		`href="https://example.com/hello.html"`,
+		`<h1 class="title" style="`,
+	} {
+		if !strings.Contains(email.HTML, want) {
+			t.Errorf("HTML missing %q:\n%s", want, email.HTML)
+		}
+	}
+	if strings.Contains(email.HTML, ".container {") {
+		t.Error("inlinable CSS should be removed from <style>")
+	}
+
+	if !strings.HasPrefix(email.Text, "Hello\n=====\n\nSee [the intro](intro.md)") {
+		t.Errorf("Text = %q", email.Text)
+	}
+	if !strings.HasSuffix(email.Text, "https://example.com/hello.html\n") {
+		t.Errorf("Text should end with the permalink: %q", email.Text)
+	}
+}
@@ -0,0 +1,24 @@
+// Package export converts content into formats used outside the site
+package export
+
+import "fmt"
+
+// Run dispatches `kosh export <format> ...`
+func Run(args []string) {
+	if len(args) < 1 {
+		printUsage()
+		return
+	}
+
+	switch args[0] {
+	case "email":
+		runEmail(args[1:])
+	default:
+		fmt.Printf("❌ Unknown export format: %s\n", args[0])
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: kosh export email <content-path> [--out <dir>] [--template <file>]")
+}
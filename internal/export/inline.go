@@ -0,0 +1,131 @@
+package export
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// cssRule is a single selector with its declarations
+type cssRule struct {
+	selector     string
+	declarations string
+}
+
+// inlineCSS moves <style> rules into style attributes, which is the only
+// styling most mail clients honor. Rules apply in source order and existing
+// style attributes win. At-rules and pseudo-class rules can't be inlined and
+// stay in the <style> element for the clients that support them.
+func inlineCSS(doc *goquery.Document) {
+	var rules []cssRule
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		inlinable, rest := parseCSS(s.Text())
+		rules = append(rules, inlinable...)
+		if strings.TrimSpace(rest) == "" {
+			s.Remove()
+		} else {
+			s.SetText(rest)
+		}
+	})
+
+	applied := make(map[*html.Node][]string)
+	var order []*html.Node
+	for _, r := range rules {
+		doc.Find(r.selector).Each(func(_ int, s *goquery.Selection) {
+			node := s.Get(0)
+			decls, seen := applied[node]
+			if !seen {
+				order = append(order, node)
+			}
+			if !slices.Contains(decls, r.declarations) {
+				applied[node] = append(decls, r.declarations)
+			}
+		})
+	}
+
+	for _, node := range order {
+		s := goquery.NewDocumentFromNode(node).Selection
+		style := strings.Join(applied[node], "; ")
+		if existing, ok := s.Attr("style"); ok && strings.TrimSpace(existing) != "" {
+			style += "; " + strings.TrimSuffix(strings.TrimSpace(existing), ";")
+		}
+		s.SetAttr("style", style)
+	}
+}
+
+// parseCSS splits a stylesheet into inlinable rules and the remaining CSS
+func parseCSS(css string) ([]cssRule, string) {
+	css = cssComment.ReplaceAllString(css, "")
+
+	var rules []cssRule
+	var rest strings.Builder
+	for {
+		css = strings.TrimSpace(css)
+		if css == "" {
+			break
+		}
+
+		if strings.HasPrefix(css, "@") {
+			end := atRuleEnd(css)
+			rest.WriteString(css[:end])
+			rest.WriteString("\n")
+			css = css[end:]
+			continue
+		}
+
+		open := strings.Index(css, "{")
+		closing := strings.Index(css, "}")
+		if open < 0 || closing < open {
+			break // Malformed tail: ignore
+		}
+		selectors := css[:open]
+		decls := strings.TrimSuffix(strings.TrimSpace(css[open+1:closing]), ";")
+		block := css[:closing+1]
+		css = css[closing+1:]
+
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || decls == "" {
+				continue
+			}
+			if strings.Contains(sel, ":") {
+				rest.WriteString(block)
+				rest.WriteString("\n")
+				break
+			}
+			rules = append(rules, cssRule{selector: sel, declarations: decls})
+		}
+	}
+	return rules, rest.String()
+}
+
+// atRuleEnd returns the end of the at-rule at the start of css,
+// including a nested block such as @media { ... }
+func atRuleEnd(css string) int {
+	semi := strings.Index(css, ";")
+	open := strings.Index(css, "{")
+	if open < 0 || (semi >= 0 && semi < open) {
+		if semi < 0 {
+			return len(css)
+		}
+		return semi + 1
+	}
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(css)
+}
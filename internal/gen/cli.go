@@ -0,0 +1,599 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cliGenerator marks the pages `kosh gen cli` owns, so a later run can
+// remove the pages of commands that no longer exist
+const cliGenerator = "kosh gen cli"
+
+// Command is a command of a CLI, as described in a kosh CLI schema:
+//
+//	name: kosh
+//	short: Static site generator
+//	persistentFlags:
+//	  - {name: config, shorthand: c, type: string, usage: Config file}
+//	commands:
+//	  - name: build
+//	    short: Build the site
+//	    usage: kosh build [flags]
+//	    flags:
+//	      - {name: watch, type: bool, usage: Rebuild on changes}
+type Command struct {
+	Name            string     `yaml:"name"`
+	Short           string     `yaml:"short"`
+	Long            string     `yaml:"long"`
+	Usage           string     `yaml:"usage"` // Defaults to the command path
+	Aliases         []string   `yaml:"aliases"`
+	Example         string     `yaml:"example"`
+	Deprecated      string     `yaml:"deprecated"` // Why, and what to use instead
+	Hidden          bool       `yaml:"hidden"`     // Left out of the reference
+	Flags           []Flag     `yaml:"flags"`
+	PersistentFlags []Flag     `yaml:"persistentFlags"` // Inherited by subcommands
+	Commands        []*Command `yaml:"commands"`
+
+	parent    *Command
+	inherited []Flag // Flags of ancestors that apply here
+	seeAlso   []string
+}
+
+// Flag is a command-line flag
+type Flag struct {
+	Name       string `yaml:"name"`
+	Shorthand  string `yaml:"shorthand"`
+	Type       string `yaml:"type"`
+	Default    string `yaml:"default"`
+	Usage      string `yaml:"usage"`
+	Required   bool   `yaml:"required"`
+	Deprecated string `yaml:"deprecated"`
+}
+
+// cobraDoc is a command as cobra's doc.GenYamlTree writes it, one file per
+// command with the full command path as its name
+type cobraDoc struct {
+	Name             string        `yaml:"name"`
+	Synopsis         string        `yaml:"synopsis"`
+	Description      string        `yaml:"description"`
+	Usage            string        `yaml:"usage"`
+	Options          []cobraOption `yaml:"options"`
+	InheritedOptions []cobraOption `yaml:"inherited_options"`
+	Example          string        `yaml:"example"`
+	SeeAlso          []string      `yaml:"see_also"`
+}
+
+type cobraOption struct {
+	Name         string `yaml:"name"`
+	Shorthand    string `yaml:"shorthand"`
+	DefaultValue string `yaml:"default_value"`
+	Usage        string `yaml:"usage"`
+}
+
+func (o cobraOption) flag() Flag {
+	return Flag{Name: o.Name, Shorthand: o.Shorthand, Default: o.DefaultValue, Usage: o.Usage}
+}
+
+func runCLI(args []string) bool {
+	var spec, outDir string
+	var check bool
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--out", "-out":
+			if i+1 < len(args) {
+				outDir = args[i+1]
+				i++
+			}
+		case "--check", "-check":
+			check = true
+		default:
+			if spec == "" {
+				spec = args[i]
+			}
+		}
+	}
+	if spec == "" {
+		printUsage()
+		return false
+	}
+	if outDir == "" {
+		outDir = filepath.Join(config.Load(nil).ContentDir, "cli")
+	}
+
+	root, err := LoadCLI(spec)
+	if err != nil {
+		fmt.Printf("❌ Failed to read CLI description: %v\n", err)
+		return false
+	}
+	changed, err := syncPages(outDir, cliGenerator, CLIPages(root), check)
+	if err != nil {
+		fmt.Printf("❌ Failed to write CLI reference: %v\n", err)
+		return false
+	}
+	return report("CLI reference", "kosh gen cli "+spec, changed, check)
+}
+
+// LoadCLI reads a CLI description: a kosh CLI schema (see Command), or the
+// YAML cobra's doc.GenYamlTree writes, given as its output directory or a
+// single file holding one or more of its documents
+func LoadCLI(path string) (*Command, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if ext := filepath.Ext(e.Name()); !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: no YAML files", path)
+		}
+	}
+
+	var docs []cobraDoc
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var nodes []yaml.Node
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var node yaml.Node
+			if err := dec.Decode(&node); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			nodes = append(nodes, node)
+		}
+
+		for _, node := range nodes {
+			// A directory or a stream of documents can only be cobra's
+			if len(files) == 1 && len(nodes) == 1 && !isCobraDoc(&node) {
+				var root Command
+				if err := node.Decode(&root); err != nil {
+					return nil, fmt.Errorf("%s: %w", file, err)
+				}
+				if root.Name == "" {
+					return nil, fmt.Errorf("%s: the root command has no name", file)
+				}
+				root.resolve(nil)
+				return &root, nil
+			}
+			var doc cobraDoc
+			if err := node.Decode(&doc); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return cobraTree(docs)
+}
+
+// isCobraDoc tells cobra's documents, which have a multi-word name or
+// cobra-only fields, from a kosh schema
+func isCobraDoc(node *yaml.Node) bool {
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "synopsis", "options", "inherited_options", "see_also":
+			return true
+		case "name":
+			if strings.Contains(strings.TrimSpace(node.Content[i+1].Value), " ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cobraTree assembles cobra's per-command documents into a command tree by
+// their command paths. A parent without a document of its own gets a stub.
+func cobraTree(docs []cobraDoc) (*Command, error) {
+	byPath := make(map[string]*Command)
+	var paths []string
+	for _, doc := range docs {
+		path := strings.Join(strings.Fields(doc.Name), " ")
+		if path == "" {
+			return nil, errors.New("cobra document without a name")
+		}
+		if _, dup := byPath[path]; dup {
+			return nil, fmt.Errorf("command %q is described twice", path)
+		}
+		cmd := &Command{
+			Short:   doc.Synopsis,
+			Long:    doc.Description,
+			Usage:   doc.Usage,
+			Example: doc.Example,
+			seeAlso: doc.SeeAlso,
+		}
+		for _, o := range doc.Options {
+			cmd.Flags = append(cmd.Flags, o.flag())
+		}
+		for _, o := range doc.InheritedOptions {
+			cmd.inherited = append(cmd.inherited, o.flag())
+		}
+		byPath[path] = cmd
+		paths = append(paths, path)
+	}
+
+	// Parents before children; siblings alphabetically, as cobra lists them
+	slices.SortFunc(paths, func(a, b string) int {
+		if d := strings.Count(a, " ") - strings.Count(b, " "); d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+
+	var root *Command
+	var attach func(path string) *Command
+	attach = func(path string) *Command {
+		cmd, ok := byPath[path]
+		if !ok {
+			cmd = &Command{}
+			byPath[path] = cmd
+		}
+		if cmd.Name != "" {
+			return cmd // Already placed
+		}
+		words := strings.Fields(path)
+		cmd.Name = words[len(words)-1]
+		if len(words) == 1 {
+			if root != nil {
+				return nil
+			}
+			root = cmd
+			return cmd
+		}
+		parent := attach(strings.Join(words[:len(words)-1], " "))
+		if parent == nil {
+			return nil
+		}
+		cmd.parent = parent
+		parent.Commands = append(parent.Commands, cmd)
+		return cmd
+	}
+	for _, path := range paths {
+		if attach(path) == nil {
+			return nil, fmt.Errorf("commands %q and %q have different roots", root.Name, path)
+		}
+	}
+
+	// Stubs take their description from the see_also entries naming them
+	for _, doc := range docs {
+		for _, s := range doc.SeeAlso {
+			path, short := splitSeeAlso(s)
+			if cmd, ok := byPath[path]; ok && cmd.Short == "" {
+				cmd.Short = short
+			}
+		}
+	}
+	return root, nil
+}
+
+// resolve links a schema's commands to their parents and works out the
+// flags each inherits: those persistent flags of its ancestors that it
+// doesn't redefine, nearest first
+func (c *Command) resolve(parent *Command) {
+	c.parent = parent
+	if parent != nil {
+		own := make(map[string]bool)
+		for _, f := range slices.Concat(c.Flags, c.PersistentFlags) {
+			own[f.Name] = true
+		}
+		for _, f := range slices.Concat(parent.PersistentFlags, parent.inherited) {
+			if !own[f.Name] {
+				own[f.Name] = true
+				c.inherited = append(c.inherited, f)
+			}
+		}
+	}
+	for _, sub := range c.Commands {
+		sub.resolve(c)
+	}
+}
+
+// Path is the command's full invocation, e.g. "kosh cache gc"
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Page is the file name of the command's reference page
+func (c *Command) Page() string {
+	return strings.ToLower(strings.ReplaceAll(c.Path(), " ", "-")) + ".md"
+}
+
+// Anchor is the id of the command's row in the root page's command index
+func (c *Command) Anchor() string {
+	return "cmd-" + strings.TrimSuffix(c.Page(), ".md")
+}
+
+// visible returns the subcommands the reference documents
+func (c *Command) visible() []*Command {
+	var subs []*Command
+	for _, sub := range c.Commands {
+		if !sub.Hidden {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// walk visits the documented commands depth-first
+func (c *Command) walk(visit func(*Command)) {
+	visit(c)
+	for _, sub := range c.visible() {
+		sub.walk(visit)
+	}
+}
+
+// CLIPages renders a reference page for each command that isn't hidden.
+// Pages are weighted in depth-first order so the root sorts first and
+// subcommands follow their parent.
+func CLIPages(root *Command) Pages {
+	var all []*Command
+	root.walk(func(c *Command) { all = append(all, c) })
+	links := newCommandLinker(all)
+
+	pages := make(Pages, len(all))
+	for i, c := range all {
+		pages[c.Page()] = []byte(renderCommand(c, len(all)-i, links, all))
+	}
+	return pages
+}
+
+func renderCommand(c *Command, weight int, links *commandLinker, all []*Command) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", c.Path())
+	if c.Short != "" {
+		fmt.Fprintf(&b, "description: %q\n", c.Short)
+	}
+	fmt.Fprintf(&b, "weight: %d\n", weight)
+	fmt.Fprintf(&b, "generated: %q\n", cliGenerator)
+	b.WriteString("---\n\n")
+	b.WriteString("<!-- Generated by kosh gen cli; edit the CLI description instead. -->\n\n")
+
+	if c.Deprecated != "" {
+		fmt.Fprintf(&b, "> **Deprecated:** %s\n\n", links.link(c.Deprecated, c))
+	}
+	switch {
+	case c.Long != "":
+		b.WriteString(links.link(strings.TrimSpace(c.Long), c) + "\n\n")
+	case c.Short != "":
+		b.WriteString(links.link(c.Short, c) + "\n\n")
+	}
+
+	usage := c.Usage
+	if usage == "" {
+		usage = c.Path()
+	}
+	b.WriteString("## Usage\n\n")
+	b.WriteString(codeBlock("sh", usage))
+
+	if len(c.Aliases) > 0 {
+		b.WriteString("## Aliases\n\n")
+		for i, a := range c.Aliases {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "`%s`", a)
+		}
+		b.WriteString("\n\n")
+	}
+
+	if flags := slices.Concat(c.Flags, c.PersistentFlags); len(flags) > 0 {
+		b.WriteString("## Flags\n\n")
+		writeFlags(&b, flags, c, links)
+	}
+	if len(c.inherited) > 0 {
+		b.WriteString("## Inherited Flags\n\n")
+		writeFlags(&b, c.inherited, c, links)
+	}
+
+	if c.Example != "" {
+		b.WriteString("## Examples\n\n")
+		b.WriteString(codeBlock("sh", strings.Trim(c.Example, "\n")))
+	}
+
+	if subs := c.visible(); len(subs) > 0 {
+		b.WriteString("## Subcommands\n\n")
+		b.WriteString("| Command | Description |\n|---|---|\n")
+		for _, sub := range subs {
+			fmt.Fprintf(&b, "| [`%s`](%s) | %s |\n", sub.Path(), sub.Page(), cell(links.link(sub.Short, c)))
+		}
+		b.WriteString("\n")
+	}
+
+	if c.parent == nil && len(all) > 1 {
+		b.WriteString("## Command Index\n\n")
+		b.WriteString("| Command | Description |\n|---|---|\n")
+		for _, cmd := range all[1:] {
+			fmt.Fprintf(&b, "| <span id=\"%s\"></span>[`%s`](%s) | %s |\n", cmd.Anchor(), cmd.Path(), cmd.Page(), cell(links.link(cmd.Short, c)))
+		}
+		b.WriteString("\n")
+	}
+
+	var seeAlso []string
+	if c.parent != nil {
+		seeAlso = append(seeAlso, c.parent.Path())
+	}
+	for _, s := range c.seeAlso {
+		path, _ := splitSeeAlso(s)
+		if path != c.Path() && !slices.Contains(seeAlso, path) && !isChild(c, path) {
+			seeAlso = append(seeAlso, path)
+		}
+	}
+	if len(seeAlso) > 0 {
+		b.WriteString("## See Also\n\n")
+		for _, path := range seeAlso {
+			if target, ok := links.byPath[path]; ok {
+				fmt.Fprintf(&b, "- [`%s`](%s)", path, target.Page())
+				if target.Short != "" {
+					b.WriteString(" — " + target.Short)
+				}
+				b.WriteString("\n")
+			} else {
+				fmt.Fprintf(&b, "- `%s`\n", path)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// splitSeeAlso splits a cobra see_also entry, "kosh cache\t - Manage the
+// cache", into the command path and its description
+func splitSeeAlso(s string) (path, short string) {
+	path, short, _ = strings.Cut(s, "\t")
+	return strings.Join(strings.Fields(path), " "), strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(short), "-"))
+}
+
+// isChild reports whether path names a subcommand the page already lists
+func isChild(c *Command, path string) bool {
+	for _, sub := range c.visible() {
+		if sub.Path() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFlags writes a flag table, each row anchored as #flag-<name> so
+// other pages can link to a single flag
+func writeFlags(b *strings.Builder, flags []Flag, c *Command, links *commandLinker) {
+	b.WriteString("| Flag | Type | Default | Description |\n|---|---|---|---|\n")
+	for _, f := range flags {
+		name := "`--" + f.Name + "`"
+		if f.Shorthand != "" {
+			name = "`-" + f.Shorthand + "`, " + name
+		}
+		usage := links.link(f.Usage, c)
+		if f.Required {
+			usage = "**Required.** " + usage
+		}
+		if f.Deprecated != "" {
+			usage = "**Deprecated:** " + links.link(f.Deprecated, c) + " " + usage
+		}
+		def := ""
+		if f.Default != "" {
+			def = "`" + f.Default + "`"
+		}
+		fmt.Fprintf(b, "| <span id=\"flag-%s\"></span>%s | %s | %s | %s |\n",
+			flagID(f.Name), name, cell(f.Type), cell(def), cell(strings.TrimSpace(usage)))
+	}
+	b.WriteString("\n")
+}
+
+var nonIDChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func flagID(name string) string {
+	return nonIDChars.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// cell makes text safe for a table cell
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// codeBlock fences text, with a longer fence than any run of backticks in it
+func codeBlock(lang, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + text + "\n" + fence + "\n\n"
+}
+
+// commandLinker turns code spans naming a command, like `kosh cache gc`
+// or `kosh build --watch`, into links to its page
+type commandLinker struct {
+	byPath map[string]*Command
+}
+
+func newCommandLinker(cmds []*Command) *commandLinker {
+	l := &commandLinker{byPath: make(map[string]*Command, len(cmds))}
+	for _, c := range cmds {
+		l.byPath[c.Path()] = c
+	}
+	return l
+}
+
+var codeSpan = regexp.MustCompile("\\[?`([^`\n]+)`")
+
+// link links the code spans in Markdown text outside fenced code blocks.
+// A span names the longest command its words start with, unless the next
+// word would have to be an unknown subcommand; spans naming the page's own
+// command and spans already inside a link are left alone.
+func (l *commandLinker) link(text string, page *Command) string {
+	lines := strings.Split(text, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		lines[i] = codeSpan.ReplaceAllStringFunc(line, func(m string) string {
+			if strings.HasPrefix(m, "[") {
+				return m
+			}
+			target := l.command(m[1 : len(m)-1])
+			if target == nil || target == page {
+				return m
+			}
+			return "[" + m + "](" + target.Page() + ")"
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (l *commandLinker) command(span string) *Command {
+	words := strings.Fields(span)
+	for n := len(words); n > 0; n-- {
+		if c, ok := l.byPath[strings.Join(words[:n], " ")]; ok {
+			if n < len(words) && !strings.HasPrefix(words[n], "-") && len(c.visible()) > 0 {
+				return nil // `kosh frobnicate`
+			}
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `name: kosh
+short: Static site generator
+long: |
+  Builds sites from Markdown. Start with ` + "`kosh build`" + `, or
+  ` + "`kosh cache gc --dry-run`" + ` to tidy up.
+
+  ` + "```" + `
+  ` + "`kosh build`" + ` stays as written in code
+  ` + "```" + `
+persistentFlags:
+  - {name: config, shorthand: c, type: string, default: kosh.yaml, usage: Config file}
+commands:
+  - name: build
+    short: Build the site
+    usage: kosh build [flags]
+    aliases: [b]
+    example: kosh build --watch
+    flags:
+      - {name: watch, shorthand: w, type: bool, usage: "Rebuild on changes | live"}
+      - {name: config, type: string, usage: Overrides the root flag}
+  - name: cache
+    short: Manage the build cache
+    commands:
+      - name: gc
+        short: Remove unused cache entries
+        flags:
+          - {name: dry-run, type: bool, usage: Only report, required: true}
+  - name: debug
+    hidden: true
+`
+
+func TestCLIPagesFromSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cli.yaml")
+	if err := os.WriteFile(path, []byte(testSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root, err := LoadCLI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := CLIPages(root)
+
+	var names []string
+	for name := range pages {
+		names = append(names, name)
+	}
+	if len(pages) != 4 || pages["kosh-debug.md"] != nil {
+		t.Fatalf("pages = %v, want kosh, build, cache and cache gc (debug is hidden)", names)
+	}
+
+	rootPage := string(pages["kosh.md"])
+	for _, want := range []string{
+		"title: \"kosh\"\n",
+		"weight: 4\n",
+		"generated: \"kosh gen cli\"\n",
+		"Start with [`kosh build`](kosh-build.md), or\n[`kosh cache gc --dry-run`](kosh-cache-gc.md)",
+		"`kosh build` stays as written in code",
+		"<span id=\"flag-config\"></span>`-c`, `--config` | string | `kosh.yaml` |",
+		"| [`kosh cache`](kosh-cache.md) | Manage the build cache |",
+		"<span id=\"cmd-kosh-cache-gc\"></span>[`kosh cache gc`](kosh-cache-gc.md)",
+	} {
+		if !strings.Contains(rootPage, want) {
+			t.Errorf("kosh.md is missing %q:\n%s", want, rootPage)
+		}
+	}
+	if strings.Contains(rootPage, "kosh debug") {
+		t.Errorf("kosh.md lists the hidden command:\n%s", rootPage)
+	}
+
+	build := string(pages["kosh-build.md"])
+	for _, want := range []string{
+		"weight: 3\n",
+		"```sh\nkosh build [flags]\n```",
+		"## Aliases\n\n`b`",
+		"`-w`, `--watch` | bool |  | Rebuild on changes \\| live |",
+		"## Examples\n\n```sh\nkosh build --watch\n```",
+		"- [`kosh`](kosh.md) — Static site generator",
+	} {
+		if !strings.Contains(build, want) {
+			t.Errorf("kosh-build.md is missing %q:\n%s", want, build)
+		}
+	}
+	// build redefines --config, so it isn't inherited
+	if strings.Contains(build, "## Inherited Flags") {
+		t.Errorf("kosh-build.md inherits a flag it redefines:\n%s", build)
+	}
+
+	gc := string(pages["kosh-cache-gc.md"])
+	for _, want := range []string{
+		"```sh\nkosh cache gc\n```",
+		"**Required.** Only report",
+		"## Inherited Flags\n\n| Flag | Type | Default | Description |\n|---|---|---|---|\n| <span id=\"flag-config\"></span>`-c`, `--config`",
+		"- [`kosh cache`](kosh-cache.md)",
+	} {
+		if !strings.Contains(gc, want) {
+			t.Errorf("kosh-cache-gc.md is missing %q:\n%s", want, gc)
+		}
+	}
+}
+
+func TestLoadCLICobraTree(t *testing.T) {
+	dir := t.TempDir()
+	docs := map[string]string{
+		"kosh.yaml": `name: kosh
+synopsis: Static site generator
+options:
+  - name: help
+    shorthand: h
+    default_value: "false"
+    usage: help for kosh
+see_also:
+  - "kosh cache\t - Manage the build cache"
+`,
+		// kosh cache has no document of its own
+		"kosh_cache_gc.yaml": `name: kosh cache gc
+synopsis: Remove unused cache entries
+usage: kosh cache gc [flags]
+inherited_options:
+  - name: config
+    default_value: kosh.yaml
+    usage: Config file
+see_also:
+  - "kosh cache\t - Manage the build cache"
+  - "kosh\t - Static site generator"
+`,
+	}
+	for name, doc := range docs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := LoadCLI(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Name != "kosh" || len(root.Commands) != 1 || root.Commands[0].Name != "cache" {
+		t.Fatalf("tree = %+v, want kosh > cache > gc", root)
+	}
+	gc := root.Commands[0].Commands[0]
+	if gc.Path() != "kosh cache gc" || gc.Page() != "kosh-cache-gc.md" {
+		t.Errorf("gc path %q, page %q", gc.Path(), gc.Page())
+	}
+
+	pages := CLIPages(root)
+	page := string(pages["kosh-cache-gc.md"])
+	for _, want := range []string{
+		"description: \"Remove unused cache entries\"",
+		"```sh\nkosh cache gc [flags]\n```",
+		"## Inherited Flags",
+		"<span id=\"flag-config\"></span>`--config` |  | `kosh.yaml` | Config file |",
+		"## See Also\n\n- [`kosh cache`](kosh-cache.md) — Manage the build cache\n- [`kosh`](kosh.md) — Static site generator\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("kosh-cache-gc.md is missing %q:\n%s", want, page)
+		}
+	}
+	if !strings.Contains(string(pages["kosh-cache.md"]), "description: \"Manage the build cache\"") {
+		t.Errorf("the stub parent's page doesn't take its description from see_also:\n%s", pages["kosh-cache.md"])
+	}
+	// A see_also entry naming a subcommand the page already lists isn't repeated
+	if strings.Contains(string(pages["kosh.md"]), "## See Also") {
+		t.Errorf("kosh.md repeats its subcommands under See Also:\n%s", pages["kosh.md"])
+	}
+}
+
+func TestSyncPages(t *testing.T) {
+	dir := t.TempDir()
+	stale := "---\ntitle: \"kosh old\"\ngenerated: \"kosh gen cli\"\n---\n"
+	handwritten := "---\ntitle: Intro\n---\n"
+	for name, data := range map[string]string{"kosh-old.md": stale, "intro.md": handwritten} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages := Pages{"kosh.md": []byte("---\ngenerated: \"kosh gen cli\"\n---\n")}
+	changed, err := syncPages(dir, cliGenerator, pages, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("check reported %v, want kosh.md and kosh-old.md", changed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kosh.md")); err == nil {
+		t.Error("check wrote a page")
+	}
+
+	if _, err := syncPages(dir, cliGenerator, pages, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kosh-old.md")); err == nil {
+		t.Error("the stale generated page wasn't removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "intro.md")); err != nil {
+		t.Error("a handwritten page was removed")
+	}
+
+	changed, err = syncPages(dir, cliGenerator, pages, true)
+	if err != nil || len(changed) != 0 {
+		t.Errorf("after syncing, check reported %v, %v", changed, err)
+	}
+}
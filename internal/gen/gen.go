@@ -0,0 +1,147 @@
+// Package gen writes reference pages into the content directory from
+// machine-readable descriptions of what they document, so the pages can be
+// regenerated whenever the source changes instead of edited by hand
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Run dispatches `kosh gen <kind> ...` and reports whether it succeeded
+func Run(args []string) bool {
+	if len(args) < 1 {
+		printUsage()
+		return false
+	}
+
+	switch args[0] {
+	case "cli":
+		return runCLI(args[1:])
+	default:
+		fmt.Printf("❌ Unknown generator: %s\n", args[0])
+		printUsage()
+		return false
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: kosh gen cli <spec> [--out <dir>] [--check]")
+}
+
+// Import dispatches `kosh import <source> ...` and reports whether it
+// succeeded
+func Import(args []string) bool {
+	if len(args) < 1 {
+		printImportUsage()
+		return false
+	}
+
+	switch args[0] {
+	case "godoc":
+		return runGodoc(args[1:])
+	default:
+		fmt.Printf("❌ Unknown import source: %s\n", args[0])
+		printImportUsage()
+		return false
+	}
+}
+
+func printImportUsage() {
+	fmt.Println("Usage: kosh import godoc [packages] [--out <dir>] [--check]")
+}
+
+// Pages are generated Markdown files by name, relative to their directory
+type Pages map[string][]byte
+
+// syncPages writes pages into dir and deletes the pages an earlier run
+// generated there (their frontmatter has `generated: <generator>`) that
+// aren't part of this one; other files are left alone. With check set
+// nothing is written. It returns the files that differ(ed).
+func syncPages(dir, generator string, pages Pages, check bool) ([]string, error) {
+	var changed []string
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		if _, ok := pages[e.Name()]; ok {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil || !generatedBy(data, generator) {
+			continue
+		}
+		changed = append(changed, path)
+		if !check {
+			if err := os.Remove(path); err != nil {
+				return changed, err
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(pages)) {
+		path := filepath.Join(dir, name)
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, pages[name]) {
+			continue
+		}
+		changed = append(changed, path)
+		if check {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return changed, err
+		}
+		if err := os.WriteFile(path, pages[name], 0644); err != nil {
+			return changed, err
+		}
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// generatedBy reports whether a page's frontmatter names the generator
+func generatedBy(page []byte, generator string) bool {
+	page = bytes.ReplaceAll(page, []byte("\r\n"), []byte("\n"))
+	rest, ok := bytes.CutPrefix(page, []byte("---\n"))
+	if !ok {
+		return false
+	}
+	front, _, ok := bytes.Cut(rest, []byte("\n---"))
+	if !ok {
+		return false
+	}
+	return bytes.Contains(append([]byte("\n"), front...), []byte(fmt.Sprintf("\ngenerated: %q", generator)))
+}
+
+// report prints what a run changed, or in check mode what is out of date
+func report(what, command string, changed []string, check bool) bool {
+	switch {
+	case len(changed) == 0:
+		fmt.Printf("✅ %s is up to date\n", what)
+		return true
+	case check:
+		fmt.Printf("❌ %s is out of date (%d files); run %s:\n", what, len(changed), command)
+		for _, path := range changed {
+			fmt.Printf("   %s\n", path)
+		}
+		return false
+	}
+	for _, path := range changed {
+		if _, err := os.Stat(path); err != nil {
+			fmt.Printf("🗑️  Removed: %s\n", path)
+		} else {
+			fmt.Printf("✅ Wrote: %s\n", path)
+		}
+	}
+	return true
+}
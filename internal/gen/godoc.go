@@ -0,0 +1,611 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// godocGenerator marks the pages `kosh import godoc` owns, so a later run
+// can remove the pages of packages that no longer exist
+const godocGenerator = "kosh import godoc"
+
+// GoPackage is a documented package of a Go module
+type GoPackage struct {
+	ImportPath string
+	Module     string // Path of the module the package is in
+	Doc        *doc.Package
+
+	fset    *token.FileSet
+	imports []*ast.ImportSpec // Of every non-test file
+}
+
+func runGodoc(args []string) bool {
+	var patterns []string
+	var outDir string
+	var check bool
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--out", "-out":
+			if i+1 < len(args) {
+				outDir = args[i+1]
+				i++
+			}
+		case "--check", "-check":
+			check = true
+		default:
+			patterns = append(patterns, args[i])
+		}
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	if outDir == "" {
+		outDir = filepath.Join(config.Load(nil).ContentDir, "api")
+	}
+
+	pkgs, err := LoadGoPackages(patterns)
+	if err != nil {
+		fmt.Printf("❌ Failed to load Go packages: %v\n", err)
+		return false
+	}
+	if len(pkgs) == 0 {
+		fmt.Printf("❌ No documentable Go packages match %s\n", strings.Join(patterns, " "))
+		return false
+	}
+	pages, err := GodocPages(pkgs)
+	if err != nil {
+		fmt.Printf("❌ Failed to render API reference: %v\n", err)
+		return false
+	}
+	changed, err := syncPages(outDir, godocGenerator, pages, check)
+	if err != nil {
+		fmt.Printf("❌ Failed to write API reference: %v\n", err)
+		return false
+	}
+	return report("API reference", "kosh import godoc "+strings.Join(patterns, " "), changed, check)
+}
+
+// LoadGoPackages reads the documentation of the packages in the directories
+// the patterns name, like the go command: "./..." is the directory and every
+// directory below it, short of testdata, vendor, hidden directories and
+// nested modules. Commands (package main) have no API and are skipped.
+func LoadGoPackages(patterns []string) ([]*GoPackage, error) {
+	var dirs []string
+	seen := map[string]bool{}
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, pattern := range patterns {
+		base, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+		if base == "..." {
+			base, recursive = ".", true
+		}
+		base, err := filepath.Abs(filepath.FromSlash(base))
+		if err != nil {
+			return nil, err
+		}
+		if !recursive {
+			add(base)
+			continue
+		}
+		err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != base {
+				name := d.Name()
+				if name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			add(path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pkgs []*GoPackage
+	modules := map[string][2]string{} // Directory -> module root and path
+	for _, dir := range dirs {
+		root, module, err := findModule(dir, modules)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		importPath := module
+		if rel != "." {
+			importPath = path.Join(module, filepath.ToSlash(rel))
+		}
+		pkg, err := loadGoPackage(dir, importPath)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != nil {
+			pkg.Module = module
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	slices.SortFunc(pkgs, func(a, b *GoPackage) int { return strings.Compare(a.ImportPath, b.ImportPath) })
+	return pkgs, nil
+}
+
+// findModule returns the root directory and the path of the module dir is
+// in, from the nearest go.mod above it
+func findModule(dir string, cache map[string][2]string) (string, string, error) {
+	var visited []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if found, ok := cache[d]; ok {
+			for _, v := range visited {
+				cache[v] = found
+			}
+			return found[0], found[1], nil
+		}
+		visited = append(visited, d)
+		data, err := os.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			module := modulePath(data)
+			if module == "" {
+				return "", "", fmt.Errorf("%s has no module line", filepath.Join(d, "go.mod"))
+			}
+			for _, v := range visited {
+				cache[v] = [2]string{d, module}
+			}
+			return d, module, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", "", fmt.Errorf("%s is not in a Go module (no go.mod above it)", dir)
+		}
+	}
+}
+
+// modulePath reads the module line of a go.mod
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			rest = strings.TrimSpace(rest)
+			if unquoted, err := strconv.Unquote(rest); err == nil {
+				return unquoted
+			}
+			return rest
+		}
+	}
+	return ""
+}
+
+// loadGoPackage parses the package in dir with its tests, whose examples
+// go/doc attaches to what they exemplify. It returns nil when dir holds no
+// Go package or a command.
+func loadGoPackage(dir, importPath string) (*GoPackage, error) {
+	bp, err := build.Default.ImportDir(dir, 0)
+	var noGo *build.NoGoError
+	if errors.As(err, &noGo) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bp.Name == "main" {
+		return nil, nil
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	var imports []*ast.ImportSpec
+	for _, names := range [][]string{bp.GoFiles, bp.CgoFiles, bp.TestGoFiles, bp.XTestGoFiles} {
+		for _, name := range names {
+			f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, f)
+			if !strings.HasSuffix(name, "_test.go") {
+				imports = append(imports, f.Imports...)
+			}
+		}
+	}
+	pkg, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, err
+	}
+	return &GoPackage{ImportPath: importPath, Doc: pkg, fset: fset, imports: imports}, nil
+}
+
+// Page is the name of the package's page: its path in the module, with
+// dashes for slashes, or the package name for the module's root package
+func (p *GoPackage) Page() string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(p.ImportPath, p.Module), "/")
+	if rel == "" {
+		return p.Doc.Name + ".md"
+	}
+	return strings.ReplaceAll(rel, "/", "-") + ".md"
+}
+
+// GodocPages renders a page per package and an index.md listing them
+func GodocPages(pkgs []*GoPackage) (Pages, error) {
+	links := &godocLinker{byPath: map[string]*GoPackage{}}
+	pages := make(Pages, len(pkgs)+1)
+	owner := map[string]string{"index.md": "the package index"}
+	for _, p := range pkgs {
+		if other, ok := owner[p.Page()]; ok {
+			return nil, fmt.Errorf("%s and %s would both be written to %s", other, p.ImportPath, p.Page())
+		}
+		owner[p.Page()] = p.ImportPath
+		links.byPath[p.ImportPath] = p
+	}
+	pages["index.md"] = []byte(renderPackageIndex(pkgs, len(pkgs)+1))
+	for i, p := range pkgs {
+		pages[p.Page()] = []byte(renderPackage(p, len(pkgs)-i, links))
+	}
+	return pages, nil
+}
+
+func renderPackageIndex(pkgs []*GoPackage, weight int) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", pkgs[0].Module)
+	fmt.Fprintf(&b, "description: %q\n", "Go API reference of "+pkgs[0].Module)
+	fmt.Fprintf(&b, "weight: %d\n", weight)
+	fmt.Fprintf(&b, "generated: %q\n", godocGenerator)
+	b.WriteString("---\n\n")
+	b.WriteString("<!-- Generated by kosh import godoc; edit the Go doc comments instead. -->\n\n")
+	b.WriteString("| Package | Synopsis |\n|---------|----------|\n")
+	for _, p := range pkgs {
+		fmt.Fprintf(&b, "| [`%s`](%s) | %s |\n", p.ImportPath, p.Page(), cell(p.Doc.Synopsis(p.Doc.Doc)))
+	}
+	return b.String()
+}
+
+func renderPackage(p *GoPackage, weight int, links *godocLinker) string {
+	pkg := p.Doc
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %q\n", p.ImportPath)
+	if synopsis := pkg.Synopsis(pkg.Doc); synopsis != "" {
+		fmt.Fprintf(&b, "description: %q\n", synopsis)
+	}
+	fmt.Fprintf(&b, "weight: %d\n", weight)
+	fmt.Fprintf(&b, "generated: %q\n", godocGenerator)
+	b.WriteString("---\n\n")
+	b.WriteString("<!-- Generated by kosh import godoc; edit the Go doc comments instead. -->\n\n")
+	b.WriteString(codeBlock("go", fmt.Sprintf("import %q", p.ImportPath)))
+
+	if pkg.Doc != "" || len(pkg.Examples) > 0 {
+		b.WriteString("## Overview\n\n")
+		b.WriteString(links.comment(p, pkg.Doc, 3))
+		writeExamples(&b, p, pkg.Examples, links)
+	}
+
+	writeIndex(&b, p)
+
+	if len(pkg.Consts) > 0 {
+		b.WriteString("## Constants\n\n")
+		for _, v := range pkg.Consts {
+			writeValue(&b, p, v, links)
+		}
+	}
+	if len(pkg.Vars) > 0 {
+		b.WriteString("## Variables\n\n")
+		for _, v := range pkg.Vars {
+			writeValue(&b, p, v, links)
+		}
+	}
+	if len(pkg.Funcs) > 0 {
+		b.WriteString("## Functions\n\n")
+		for _, f := range pkg.Funcs {
+			writeFunc(&b, p, f, "###", links)
+		}
+	}
+	if len(pkg.Types) > 0 {
+		b.WriteString("## Types\n\n")
+		for _, t := range pkg.Types {
+			fmt.Fprintf(&b, "### <span id=\"%s\"></span>type %s\n\n", t.Name, t.Name)
+			writeDecl(&b, p, t.Decl, t.Name, links)
+			b.WriteString(links.comment(p, t.Doc, 4))
+			writeExamples(&b, p, t.Examples, links)
+			for _, v := range slices.Concat(t.Consts, t.Vars) {
+				writeValue(&b, p, v, links)
+			}
+			for _, f := range slices.Concat(t.Funcs, t.Methods) {
+				writeFunc(&b, p, f, "####", links)
+			}
+		}
+	}
+	if bugs := pkg.Notes["BUG"]; len(bugs) > 0 {
+		b.WriteString("## Bugs\n\n")
+		for _, note := range bugs {
+			fmt.Fprintf(&b, "- %s\n", strings.Join(strings.Fields(note.Body), " "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// writeIndex lists the package's functions, types and their methods, with
+// their signatures, linked to where they are documented
+func writeIndex(b *strings.Builder, p *GoPackage) {
+	pkg := p.Doc
+	if len(pkg.Consts)+len(pkg.Vars)+len(pkg.Funcs)+len(pkg.Types) == 0 {
+		return
+	}
+	b.WriteString("## Index\n\n")
+	if len(pkg.Consts) > 0 {
+		b.WriteString("- [Constants](#constants)\n")
+	}
+	if len(pkg.Vars) > 0 {
+		b.WriteString("- [Variables](#variables)\n")
+	}
+	for _, f := range pkg.Funcs {
+		fmt.Fprintf(b, "- [`%s`](#%s)\n", signature(p, f.Decl), funcID(f))
+	}
+	for _, t := range pkg.Types {
+		fmt.Fprintf(b, "- [`type %s`](#%s)\n", t.Name, t.Name)
+		for _, f := range slices.Concat(t.Funcs, t.Methods) {
+			fmt.Fprintf(b, "  - [`%s`](#%s)\n", signature(p, f.Decl), funcID(f))
+		}
+	}
+	b.WriteString("\n")
+}
+
+// writeValue documents a const or var declaration
+func writeValue(b *strings.Builder, p *GoPackage, v *doc.Value, links *godocLinker) {
+	writeDecl(b, p, v.Decl, "", links)
+	b.WriteString(links.comment(p, v.Doc, 4))
+}
+
+// writeFunc documents a function or method under a heading of the given
+// level, anchored at its name ("Name", or "Type.Name" for a method)
+func writeFunc(b *strings.Builder, p *GoPackage, f *doc.Func, level string, links *godocLinker) {
+	title := "func " + f.Name
+	if f.Recv != "" {
+		title = "func (" + f.Recv + ") " + f.Name
+	}
+	fmt.Fprintf(b, "%s <span id=\"%s\"></span>%s\n\n", level, funcID(f), title)
+	writeDecl(b, p, f.Decl, "", links)
+	b.WriteString(links.comment(p, f.Doc, len(level)+1))
+	writeExamples(b, p, f.Examples, links)
+}
+
+func funcID(f *doc.Func) string {
+	if f.Recv == "" {
+		return f.Name
+	}
+	return strings.TrimPrefix(f.Recv, "*") + "." + f.Name
+}
+
+// writeDecl writes a declaration as highlighted Go, followed by links to
+// the documented types and functions it uses, which a code block can't
+// link itself
+func writeDecl(b *strings.Builder, p *GoPackage, decl ast.Decl, self string, links *godocLinker) {
+	b.WriteString(codeBlock("go", printDecl(p, decl)))
+	if refs := links.refs(p, decl, self); len(refs) > 0 {
+		b.WriteString("Uses " + strings.Join(refs, ", ") + "\n\n")
+	}
+}
+
+// printDecl formats a declaration as gofmt would, without its doc comment
+// (which is rendered as Markdown) but with those of its fields and specs.
+// go/doc has already dropped function bodies and unexported fields.
+func printDecl(p *GoPackage, decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		c := *d
+		c.Doc = nil
+		decl = &c
+	case *ast.GenDecl:
+		c := *d
+		c.Doc = nil
+		decl = &c
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, p.fset, decl); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// signature is a declaration on one line, for the index
+func signature(p *GoPackage, decl ast.Decl) string {
+	return strings.Join(strings.Fields(printDecl(p, decl)), " ")
+}
+
+// exampleOutput matches the comment that starts an example's expected
+// output, which is shown apart from the code
+var exampleOutput = regexp.MustCompile(`^//\s*(?i:unordered output|output):`)
+
+// writeExamples writes examples as their code, unwrapped from the example
+// function, and their expected output
+func writeExamples(b *strings.Builder, p *GoPackage, examples []*doc.Example, links *godocLinker) {
+	for _, ex := range examples {
+		title := "Example"
+		if ex.Suffix != "" {
+			title += " (" + ex.Suffix + ")"
+		}
+		fmt.Fprintf(b, "**%s**\n\n", title)
+		if ex.Doc != "" {
+			b.WriteString(links.comment(p, ex.Doc, 5))
+		}
+
+		var buf bytes.Buffer
+		cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+		if err := cfg.Fprint(&buf, p.fset, &printer.CommentedNode{Node: ex.Code, Comments: ex.Comments}); err != nil {
+			continue
+		}
+		code := buf.String()
+		if _, ok := ex.Code.(*ast.BlockStmt); ok {
+			code = strings.TrimSuffix(strings.TrimPrefix(code, "{\n"), "}")
+			lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimPrefix(line, "\t")
+			}
+			// The output comment ends the function; it is shown below instead
+			for i := len(lines) - 1; i >= 0; i-- {
+				line := strings.TrimSpace(lines[i])
+				if exampleOutput.MatchString(line) {
+					lines = lines[:i]
+					break
+				}
+				if !strings.HasPrefix(line, "//") {
+					break
+				}
+			}
+			code = strings.Join(lines, "\n")
+		}
+		b.WriteString(codeBlock("go", strings.TrimSpace(code)))
+		if ex.Output != "" {
+			b.WriteString("Output:\n\n")
+			b.WriteString(codeBlock("", strings.TrimRight(ex.Output, "\n")))
+		}
+	}
+}
+
+// godocLinker resolves references to Go identifiers: to the page of a
+// package being imported, or else to pkg.go.dev
+type godocLinker struct {
+	byPath map[string]*GoPackage
+}
+
+// url is the URL of a package's documentation, or of a symbol in it, seen
+// from the page of the package from
+func (l *godocLinker) url(from *GoPackage, importPath, symbol string) string {
+	if importPath == from.ImportPath {
+		return "#" + symbol
+	}
+	target := "https://pkg.go.dev/" + importPath
+	if p, ok := l.byPath[importPath]; ok {
+		target = p.Page()
+	}
+	if symbol != "" {
+		target += "#" + symbol
+	}
+	return target
+}
+
+// comment renders a doc comment as Markdown, with its headings at level
+// and its doc links ([Name], [pkg.Name]) resolved
+func (l *godocLinker) comment(p *GoPackage, text string, level int) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	pr := p.Doc.Printer()
+	pr.HeadingLevel = min(level, 6)
+	// Goldmark here has no {#id} attribute syntax; headings get automatic ids
+	pr.HeadingID = func(*comment.Heading) string { return "" }
+	pr.DocLinkURL = func(link *comment.DocLink) string {
+		importPath := link.ImportPath
+		if importPath == "" {
+			importPath = p.ImportPath
+		}
+		symbol := link.Name
+		if link.Recv != "" {
+			symbol = link.Recv + "." + link.Name
+		}
+		return l.url(p, importPath, symbol)
+	}
+	return strings.TrimRight(string(pr.Markdown(p.Doc.Parser().Parse(text))), "\n") + "\n\n"
+}
+
+// refs links the documented identifiers a declaration refers to: exported
+// types of its own package other than self, and exported names of the
+// packages it imports
+func (l *godocLinker) refs(p *GoPackage, decl ast.Decl, self string) []string {
+	local := map[string]bool{}
+	for _, t := range p.Doc.Types {
+		local[t.Name] = true
+	}
+	var refs []string
+	seen := map[string]bool{}
+	add := func(label, url string) {
+		if !seen[label] {
+			seen[label] = true
+			refs = append(refs, fmt.Sprintf("[`%s`](%s)", label, url))
+		}
+	}
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && n.Sel.IsExported() {
+				if importPath := l.importPath(p, x.Name); importPath != "" {
+					add(x.Name+"."+n.Sel.Name, l.url(p, importPath, n.Sel.Name))
+					return false
+				}
+			}
+		case *ast.Ident:
+			if local[n.Name] && n.Name != self {
+				add(n.Name, l.url(p, p.ImportPath, n.Name))
+			}
+		}
+		return true
+	})
+	return refs
+}
+
+// importPath is the path of the package a file of p imports as name
+func (l *godocLinker) importPath(p *GoPackage, name string) string {
+	for _, spec := range p.imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return importPath
+			}
+			continue
+		}
+		if imported, ok := l.byPath[importPath]; ok {
+			if imported.Doc.Name == name {
+				return importPath
+			}
+		} else if guessPackageName(importPath) == name {
+			return importPath
+		}
+	}
+	return ""
+}
+
+// guessPackageName is the name a package is likely declared with, by the
+// usual conventions: the last element of its path, skipping a major version
+// suffix, without a "go-" prefix or a ".v3"-style suffix
+func guessPackageName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name, _, _ = strings.Cut(name, ".")
+	return strings.ReplaceAll(name, "-", "")
+}
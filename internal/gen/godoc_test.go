@@ -0,0 +1,156 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testModule is a small module: a root package, a package using it, a
+// command and a testdata directory
+var testModule = map[string]string{
+	"go.mod": "module example.com/shapes\n\ngo 1.22\n",
+	"shapes.go": `// Package shapes measures shapes.
+//
+// # Units
+//
+// Lengths are in metres; see [Square.Area] and [geo.Distance].
+package shapes
+
+import (
+	"io"
+
+	"example.com/shapes/geo"
+)
+
+// Unit is the unit of a length
+const Unit = "m"
+
+// Square is a square
+type Square struct {
+	Side float64 // Length of a side
+	At   geo.Point
+	name string
+}
+
+// NewSquare returns a square with the given side
+func NewSquare(side float64) *Square {
+	return &Square{Side: side}
+}
+
+// Area is the area of the square
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+// Describe writes a description of s to w
+func Describe(w io.Writer, s *Square) error {
+	_, err := io.WriteString(w, "square")
+	return err
+}
+`,
+	"example_test.go": `package shapes_test
+
+import (
+	"fmt"
+
+	"example.com/shapes"
+)
+
+func ExampleSquare_Area() {
+	sq := shapes.NewSquare(2)
+	fmt.Println(sq.Area())
+	// Output: 4
+}
+`,
+	"geo/geo.go": `// Package geo has points.
+package geo
+
+// Point is a point in the plane
+type Point struct{ X, Y float64 }
+
+// Distance is the distance between two points
+func Distance(a, b Point) float64 { return 0 }
+`,
+	"cmd/shapes/main.go": "package main\n\nfunc main() {}\n",
+	"testdata/bad.go":    "package broken(\n",
+}
+
+func TestGodocPages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, testModule)
+
+	pkgs, err := LoadGoPackages([]string{filepath.ToSlash(dir) + "/..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 2 || pkgs[0].ImportPath != "example.com/shapes" || pkgs[1].ImportPath != "example.com/shapes/geo" {
+		t.Fatalf("loaded %d packages, want shapes and shapes/geo only", len(pkgs))
+	}
+	pages, err := GodocPages(pkgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 || pages["index.md"] == nil || pages["geo.md"] == nil {
+		t.Fatalf("got %d pages, want index.md, shapes.md and geo.md", len(pages))
+	}
+
+	index := string(pages["index.md"])
+	if !strings.Contains(index, "| [`example.com/shapes/geo`](geo.md) | Package geo has points. |") {
+		t.Errorf("index doesn't list geo:\n%s", index)
+	}
+
+	page := string(pages["shapes.md"])
+	for _, want := range []string{
+		`title: "example.com/shapes"`,
+		`description: "Package shapes measures shapes."`,
+		`generated: "kosh import godoc"`,
+		"### Units\n",                     // Doc comment heading, without an {#id}
+		"[Square.Area](#Square.Area)",     // Doc link in the package
+		"[geo.Distance](geo.md#Distance)", // Doc link to an imported package
+		"- [`func Describe(w io.Writer, s *Square) error`](#Describe)",
+		"  - [`func (s *Square) Area() float64`](#Square.Area)",
+		"### <span id=\"Square\"></span>type Square\n",
+		"\tSide float64 // Length of a side\n",
+		"// contains filtered or unexported fields",
+		"Uses [`geo.Point`](geo.md#Point)\n",
+		"Uses [`io.Writer`](https://pkg.go.dev/io#Writer), [`Square`](#Square)\n",
+		"#### <span id=\"NewSquare\"></span>func NewSquare\n",
+		"#### <span id=\"Square.Area\"></span>func (*Square) Area\n",
+		"**Example**\n\n```go\nsq := shapes.NewSquare(2)\nfmt.Println(sq.Area())\n```\n\nOutput:\n\n```\n4\n```\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("shapes.md is missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "name string") {
+		t.Error("shapes.md documents an unexported field")
+	}
+}
+
+func TestGuessPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"io":                          "io",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/go-chi/chi/v5":    "chi",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+	} {
+		if got := guessPackageName(path); got != want {
+			t.Errorf("guessPackageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
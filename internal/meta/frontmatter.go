@@ -0,0 +1,206 @@
+package meta
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// errNoFrontmatter is returned for files that don't start with a --- block
+var errNoFrontmatter = errors.New("no frontmatter")
+
+// frontmatter is a post split into lines, with the YAML block located so
+// edits only touch the lines of the key they change
+type frontmatter struct {
+	lines []string // Every line of the file, without line endings
+	eol   string   // "\n" or "\r\n", as found in the file
+	start int      // Index of the first line after the opening ---
+	end   int      // Index of the closing ---
+	keys  []fmKey  // Top-level keys in file order
+}
+
+// fmKey is a top-level key and the lines its value spans
+type fmKey struct {
+	name    string
+	line    int // Index into lines
+	end     int // One past the last line of the value
+	comment string
+	value   *yaml.Node // Line numbers are relative to the first line parsed
+}
+
+func parseFrontmatter(src []byte) (*frontmatter, error) {
+	eol := "\n"
+	if bytes.Contains(src, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(strings.TrimPrefix(lines[0], "\ufeff")) != "---" {
+		return nil, errNoFrontmatter
+	}
+	fm := &frontmatter{lines: lines, eol: eol, start: 1, end: -1}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t") == "---" {
+			fm.end = i
+			break
+		}
+	}
+	if fm.end < 0 {
+		return nil, errors.New("frontmatter is never closed with ---")
+	}
+
+	keys, err := parseKeys(lines, fm.start, fm.end)
+	if err != nil {
+		return nil, fmt.Errorf("frontmatter: %w", err)
+	}
+	fm.keys = keys
+	return fm, nil
+}
+
+// parseKeys locates the top-level keys of the YAML mapping in lines[start:end]
+func parseKeys(lines []string, start, end int) ([]fmKey, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[start:end], "\n")), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil // Empty block
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("not a mapping")
+	}
+
+	var keys []fmKey
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		keys = append(keys, fmKey{name: k.Value, line: start + k.Line - 1, comment: v.LineComment, value: v})
+	}
+	for i := range keys {
+		next := end
+		if i+1 < len(keys) {
+			next = keys[i+1].line
+		}
+		// Blank lines and comments before the next key belong to it
+		for next-1 > keys[i].line {
+			if l := strings.TrimSpace(lines[next-1]); l != "" && !strings.HasPrefix(l, "#") {
+				break
+			}
+			next--
+		}
+		keys[i].end = next
+	}
+	return keys, nil
+}
+
+func (fm *frontmatter) find(name string) *fmKey {
+	for i := range fm.keys {
+		if fm.keys[i].name == name {
+			return &fm.keys[i]
+		}
+	}
+	return nil
+}
+
+func (fm *frontmatter) bytes() []byte {
+	return []byte(strings.Join(fm.lines, fm.eol))
+}
+
+// splice replaces lines[from:to] with repl
+func (fm *frontmatter) splice(from, to int, repl ...string) {
+	lines := make([]string, 0, len(fm.lines)-(to-from)+len(repl))
+	lines = append(lines, fm.lines[:from]...)
+	lines = append(lines, repl...)
+	fm.lines = append(lines, fm.lines[to:]...)
+}
+
+// setKey sets a top-level key to value, replacing its old value in place or
+// adding it at the end of the block. value is written as-is when it is valid
+// YAML on its own line and quoted otherwise.
+func setKey(src []byte, key, value string) ([]byte, bool, error) {
+	fm, err := parseFrontmatter(src)
+	if err != nil {
+		return nil, false, err
+	}
+
+	line := yamlKey(key) + ": " + yamlValue(value)
+	k := fm.find(key)
+	if k == nil {
+		fm.splice(fm.end, fm.end, line)
+		return fm.bytes(), true, nil
+	}
+
+	// Keep a trailing comment on a single-line value
+	if k.comment != "" && k.end == k.line+1 {
+		line += " " + k.comment
+	}
+	if k.end == k.line+1 && fm.lines[k.line] == line {
+		return src, false, nil
+	}
+	fm.splice(k.line, k.end, line)
+	return fm.bytes(), true, nil
+}
+
+// renameKey renames a top-level key, leaving its value untouched
+func renameKey(src []byte, oldKey, newKey string) ([]byte, bool, error) {
+	fm, err := parseFrontmatter(src)
+	if err != nil {
+		return nil, false, err
+	}
+	k := fm.find(oldKey)
+	if k == nil {
+		return src, false, nil
+	}
+	if fm.find(newKey) != nil {
+		return nil, false, fmt.Errorf("both %s and %s are set", oldKey, newKey)
+	}
+
+	// The key may be quoted in the file; keep everything from the colon on
+	line := fm.lines[k.line]
+	colon := keyEnd(line)
+	if colon < 0 {
+		return nil, false, fmt.Errorf("line %d: cannot find the end of key %s", k.line+1, oldKey)
+	}
+	fm.lines[k.line] = yamlKey(newKey) + line[colon:]
+	return fm.bytes(), true, nil
+}
+
+// keyEnd returns the index of the colon ending the key on a mapping line
+func keyEnd(line string) int {
+	if line != "" && (line[0] == '"' || line[0] == '\'') {
+		if close := strings.IndexByte(line[1:], line[0]); close >= 0 {
+			if i := strings.IndexByte(line[close+2:], ':'); i >= 0 {
+				return close + 2 + i
+			}
+		}
+		return -1
+	}
+	return strings.Index(line, ":")
+}
+
+// yamlValue returns value unchanged if "k: value" parses as a single value,
+// and quoted otherwise. "#1" and "a #b" would lose text to a comment.
+func yamlValue(value string) string {
+	var doc yaml.Node
+	if value == "" || strings.ContainsAny(value, "\r\n") ||
+		strings.HasPrefix(value, "#") || strings.Contains(value, " #") ||
+		yaml.Unmarshal([]byte("k: "+value), &doc) != nil ||
+		len(doc.Content) != 1 || len(doc.Content[0].Content) != 2 {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// yamlKey quotes a key that wouldn't parse back as itself
+func yamlKey(key string) string {
+	var m map[string]any
+	if yaml.Unmarshal([]byte(key+": x"), &m) == nil && len(m) == 1 {
+		if _, ok := m[key]; ok {
+			return key
+		}
+	}
+	return strconv.Quote(key)
+}
@@ -0,0 +1,241 @@
+// Package meta edits the frontmatter of many posts at once
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// edit rewrites one file's source, reporting whether it changed
+type edit func(src []byte) ([]byte, bool, error)
+
+// Run dispatches `kosh meta set|rename ...`
+func Run(args []string) {
+	dryRun := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--dry-run" || arg == "-n" {
+			dryRun = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if len(rest) < 1 {
+		printUsage()
+		return
+	}
+
+	var (
+		fn       edit
+		what     string
+		patterns []string
+	)
+	switch rest[0] {
+	case "set":
+		if len(rest) < 2 || !strings.Contains(rest[1], "=") {
+			printUsage()
+			return
+		}
+		key, value, _ := strings.Cut(rest[1], "=")
+		if key = strings.TrimSpace(key); key == "" {
+			printUsage()
+			return
+		}
+		fn = func(src []byte) ([]byte, bool, error) { return setKey(src, key, value) }
+		what, patterns = fmt.Sprintf("set %s", key), rest[2:]
+	case "rename":
+		if len(rest) < 3 || rest[1] == rest[2] {
+			printUsage()
+			return
+		}
+		oldKey, newKey := rest[1], rest[2]
+		fn = func(src []byte) ([]byte, bool, error) { return renameKey(src, oldKey, newKey) }
+		what, patterns = fmt.Sprintf("renamed %s to %s", oldKey, newKey), rest[3:]
+	default:
+		fmt.Printf("❌ Unknown meta subcommand: %s\n", rest[0])
+		printUsage()
+		return
+	}
+
+	cfg := config.Load([]string{})
+	files, err := expand(cfg.ContentDir, patterns)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+	if len(files) == 0 {
+		fmt.Println("⚠️  No markdown files matched")
+		return
+	}
+
+	changed := apply(files, fn, dryRun)
+	switch {
+	case dryRun:
+		fmt.Printf("\n🔍 Dry run: would have %s in %d of %d files\n", what, len(changed), len(files))
+	case len(changed) > 0:
+		fmt.Printf("\n📝 %s in %d of %d files\n", upperFirst(what), len(changed), len(files))
+		invalidate(cfg, changed)
+	default:
+		fmt.Printf("\n📝 Nothing to change in %d files\n", len(files))
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: kosh meta set <key>=<value> [files or globs...] [--dry-run]")
+	fmt.Println("       kosh meta rename <old-key> <new-key> [files or globs...] [--dry-run]")
+	fmt.Println("\nGlobs may use ** for any number of directories, e.g. 'content/posts/**'.")
+	fmt.Println("Without files, every post under the content directory is edited.")
+}
+
+// apply runs fn over every file, writing back the ones it changed. Files
+// that can't be edited are reported and left alone.
+func apply(files []string, fn edit, dryRun bool) []string {
+	var changed []string
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", displayPath(file), err)
+			continue
+		}
+		out, ok, err := fn(src)
+		if errors.Is(err, errNoFrontmatter) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("⚠️  Skipped %s: %v\n", displayPath(file), err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !dryRun {
+			if err := os.WriteFile(file, out, 0644); err != nil {
+				fmt.Printf("❌ %s: %v\n", displayPath(file), err)
+				continue
+			}
+		}
+		fmt.Printf("✅ %s\n", displayPath(file))
+		changed = append(changed, file)
+	}
+	return changed
+}
+
+// invalidate drops the cache entries of the changed posts so the next build
+// parses them again and picks up their new frontmatter hashes
+func invalidate(cfg *config.Config, files []string) {
+	if _, err := os.Stat(cfg.CacheDir); err != nil {
+		return // Never built, nothing cached
+	}
+	cm, err := cache.Open(cfg.CacheDir, false)
+	if err != nil {
+		fmt.Printf("⚠️  Could not open the cache (%v); changed posts are rebuilt by modification time\n", err)
+		return
+	}
+	defer func() { _ = cm.Close() }()
+
+	dropped := 0
+	for _, file := range files {
+		relPath, err := utils.SafeRel(cfg.ContentDir, file)
+		if err != nil {
+			continue
+		}
+		post, err := cm.GetPostByPath(relPath)
+		if err != nil || post == nil {
+			continue
+		}
+		if err := cm.DeletePost(post.PostID); err == nil {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		fmt.Printf("♻️  Cleared %d cached post(s); the next build re-reads them\n", dropped)
+	}
+}
+
+// expand resolves files, directories and globs to the markdown files they
+// match. No patterns means every post under contentDir.
+func expand(contentDir string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{contentDir}
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	add := func(file string) {
+		if !seen[file] && strings.EqualFold(filepath.Ext(file), ".md") {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, pattern := range patterns {
+		abs, err := filepath.Abs(pattern)
+		if err != nil {
+			return nil, err
+		}
+		// Plain files and directories, including ones the shell already expanded
+		if info, err := os.Stat(abs); err == nil {
+			if !info.IsDir() {
+				add(abs)
+				continue
+			}
+			abs = filepath.Join(abs, "**")
+		}
+
+		glob := filepath.ToSlash(abs)
+		root := filepath.FromSlash(staticPrefix(glob))
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && utils.MatchGlob(glob, filepath.ToSlash(p)) {
+				add(p)
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// staticPrefix returns the directories of a glob before its first wildcard
+func staticPrefix(glob string) string {
+	parts := strings.Split(glob, "/")
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[") {
+			if i == 0 {
+				return "/"
+			}
+			return strings.Join(parts[:i], "/") + "/"
+		}
+	}
+	return path.Dir(glob)
+}
+
+// displayPath shows a file relative to the working directory when possible
+func displayPath(file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return file
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
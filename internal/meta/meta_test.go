@@ -0,0 +1,145 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const post = `---
+title: "Hello"   # shown in the feed
+draft: true # publish later
+tags:
+  - go
+  - web
+
+# Ordering on the index
+weight: 3
+---
+
+Body with draft: true in it.
+`
+
+func TestSetKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		key, value  string
+		want        string
+		wantChanged bool
+		wantErr     string
+	}{
+		{
+			name: "scalar keeps its comment", src: post, key: "draft", value: "false", wantChanged: true,
+			want: strings.Replace(post, "draft: true # publish later", "draft: false # publish later", 1),
+		},
+		{
+			name: "block value is replaced", src: post, key: "tags", value: "[go]", wantChanged: true,
+			want: strings.Replace(post, "tags:\n  - go\n  - web\n", "tags: [go]\n", 1),
+		},
+		{
+			name: "new key goes last", src: post, key: "author", value: "Ada: Lovelace", wantChanged: true,
+			want: strings.Replace(post, "weight: 3\n---", "weight: 3\nauthor: \"Ada: Lovelace\"\n---", 1),
+		},
+		{
+			name: "unchanged", src: post, key: "weight", value: "3", want: post,
+		},
+		{
+			name: "crlf", src: "---\r\ndraft: true\r\n---\r\nBody\r\n", key: "draft", value: "false", wantChanged: true,
+			want: "---\r\ndraft: false\r\n---\r\nBody\r\n",
+		},
+		{
+			name: "comment-like value is quoted", src: "---\ntitle: x\n---\n", key: "title", value: "#1", wantChanged: true,
+			want: "---\ntitle: \"#1\"\n---\n",
+		},
+		{
+			name: "unclosed", src: "---\ntitle: x\n", key: "draft", value: "true", wantErr: "never closed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := setKey([]byte(tt.src), tt.key, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("setKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setKey() error = %v", err)
+			}
+			if changed != tt.wantChanged || string(got) != tt.want {
+				t.Errorf("setKey() = %v,\n%s\nwant %v,\n%s", changed, got, tt.wantChanged, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	got, changed, err := renameKey([]byte(post), "tags", "categories")
+	if err != nil || !changed {
+		t.Fatalf("renameKey() changed = %v, error = %v", changed, err)
+	}
+	if want := strings.Replace(post, "tags:\n", "categories:\n", 1); string(got) != want {
+		t.Errorf("renameKey() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, changed, _ := renameKey([]byte(post), "author", "by"); changed {
+		t.Error("renaming a missing key should change nothing")
+	}
+	if _, _, err := renameKey([]byte(post), "title", "draft"); err == nil {
+		t.Error("renaming onto an existing key should fail")
+	}
+	if _, _, err := renameKey([]byte("# No frontmatter\n"), "title", "name"); err != errNoFrontmatter {
+		t.Errorf("renameKey() error = %v, want errNoFrontmatter", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"posts/a.md", "posts/2024/b.md", "posts/2024/c.txt", "docs/d.md"} {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(post), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rel := func(files []string) []string {
+		var out []string
+		for _, f := range files {
+			r, _ := filepath.Rel(dir, f)
+			out = append(out, filepath.ToSlash(r))
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "everything", want: []string{"docs/d.md", "posts/2024/b.md", "posts/a.md"}},
+		{name: "double star", patterns: []string{"posts/**"}, want: []string{"posts/2024/b.md", "posts/a.md"}},
+		{name: "single level", patterns: []string{"posts/*.md"}, want: []string{"posts/a.md"}},
+		{name: "directory and file", patterns: []string{"docs", "posts/a.md", "docs/d.md"}, want: []string{"docs/d.md", "posts/a.md"}},
+		{name: "no match", patterns: []string{"drafts/**"}},
+	}
+
+	t.Chdir(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := expand(dir, tt.patterns)
+			if err != nil {
+				t.Fatalf("expand() error = %v", err)
+			}
+			if got := rel(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expand(%v) = %v, want %v", tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
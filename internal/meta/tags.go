@@ -0,0 +1,351 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// RunTags dispatches `kosh tags list|rename|merge ...`
+func RunTags(args []string) {
+	dryRun := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--dry-run" || arg == "-n" {
+			dryRun = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	if len(rest) < 1 {
+		printTagsUsage()
+		return
+	}
+
+	cfg := config.Load([]string{})
+	files, err := expand(cfg.ContentDir, nil)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return
+	}
+
+	switch rest[0] {
+	case "list":
+		listTags(files)
+	case "rename":
+		if len(rest) != 3 {
+			printTagsUsage()
+			return
+		}
+		retag(cfg, files, rest[1:2], rest[2], dryRun)
+	case "merge":
+		if len(rest) < 3 {
+			printTagsUsage()
+			return
+		}
+		retag(cfg, files, rest[1:len(rest)-1], rest[len(rest)-1], dryRun)
+	default:
+		fmt.Printf("❌ Unknown tags subcommand: %s\n", rest[0])
+		printTagsUsage()
+	}
+}
+
+func printTagsUsage() {
+	fmt.Println("Usage: kosh tags list")
+	fmt.Println("       kosh tags rename <old> <new> [--dry-run]")
+	fmt.Println("       kosh tags merge <tag>... <into> [--dry-run]")
+}
+
+// tagCount is a tag and the number of posts using it
+type tagCount struct {
+	Tag   string
+	Count int
+}
+
+// countTags counts the posts per tag, matching tags like the build does
+// (case-insensitive, trimmed)
+func countTags(files []string) []tagCount {
+	counts := make(map[string]int)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		fm, err := parseFrontmatter(src)
+		if err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, tag := range fm.tags() {
+			if key := normalizeTag(tag); key != "" && !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	list := make([]tagCount, 0, len(counts))
+	for tag, n := range counts {
+		list = append(list, tagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	return list
+}
+
+func listTags(files []string) {
+	tags := countTags(files)
+	if len(tags) == 0 {
+		fmt.Println("🏷️  No tags in use")
+		return
+	}
+	fmt.Printf("🏷️  %d tags in %d posts\n", len(tags), len(files))
+	for _, t := range tags {
+		fmt.Printf("   %5d  %s\n", t.Count, t.Tag)
+	}
+}
+
+// retag replaces the tags in from with into across every post, then records
+// redirects for the old tag pages in kosh.yaml
+func retag(cfg *config.Config, files []string, from []string, into string, dryRun bool) {
+	into = strings.TrimSpace(into)
+	if normalizeTag(into) == "" {
+		printTagsUsage()
+		return
+	}
+	replace := make(map[string]bool)
+	for _, tag := range from {
+		if key := normalizeTag(tag); key != "" && key != normalizeTag(into) {
+			replace[key] = true
+		}
+	}
+	if len(replace) == 0 {
+		fmt.Println("⚠️  Nothing to rename")
+		return
+	}
+
+	changed := apply(files, func(src []byte) ([]byte, bool, error) {
+		return replaceTags(src, replace, into)
+	}, dryRun)
+
+	redirects := make(map[string]string, len(replace))
+	olds := make([]string, 0, len(replace))
+	for old := range replace {
+		redirects[old] = normalizeTag(into)
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	what := fmt.Sprintf("%s → %s", strings.Join(olds, ", "), into)
+
+	if dryRun {
+		fmt.Printf("\n🔍 Dry run: would have retagged %s in %d of %d files\n", what, len(changed), len(files))
+		return
+	}
+	fmt.Printf("\n🏷️  Retagged %s in %d of %d files\n", what, len(changed), len(files))
+	if len(changed) > 0 {
+		invalidate(cfg, changed)
+	}
+
+	file := configFile()
+	src, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("❌ %s: %v\n", file, err)
+		return
+	}
+	out, err := addTagRedirects(src, redirects)
+	if err != nil {
+		fmt.Printf("❌ %s: %v\n", file, err)
+		return
+	}
+	if err := os.WriteFile(file, out, 0644); err != nil {
+		fmt.Printf("❌ %s: %v\n", file, err)
+		return
+	}
+	fmt.Printf("↪️  Redirecting /tags/%s.html in %s (tagRedirects)\n", strings.Join(olds, ".html, /tags/"), file)
+}
+
+// configFile returns kosh.yaml, falling back to config.yaml like config.Load
+func configFile() string {
+	if _, err := os.Stat("kosh.yaml"); err != nil {
+		if _, err := os.Stat("config.yaml"); err == nil {
+			return "config.yaml"
+		}
+	}
+	return "kosh.yaml"
+}
+
+// tags returns the post's tags: a list, or a single tag written as a string
+func (fm *frontmatter) tags() []string {
+	k := fm.find("tags")
+	if k == nil {
+		return nil
+	}
+	switch k.value.Kind {
+	case yaml.ScalarNode:
+		if k.value.Tag == "!!null" {
+			return nil
+		}
+		return []string{k.value.Value}
+	case yaml.SequenceNode:
+		var tags []string
+		for _, item := range k.value.Content {
+			if item.Kind == yaml.ScalarNode {
+				tags = append(tags, item.Value)
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+// replaceTags swaps every tag in replace (normalized) for into, dropping
+// duplicates. The list keeps its flow or block style, indentation and quoting.
+func replaceTags(src []byte, replace map[string]bool, into string) ([]byte, bool, error) {
+	fm, err := parseFrontmatter(src)
+	if err != nil {
+		return nil, false, err
+	}
+	k := fm.find("tags")
+	if k == nil || (k.value.Kind != yaml.SequenceNode && k.value.Kind != yaml.ScalarNode) {
+		return src, false, nil
+	}
+
+	items := k.value.Content
+	if k.value.Kind == yaml.ScalarNode {
+		items = []*yaml.Node{k.value}
+	}
+	var tags []string
+	var styles []yaml.Style
+	hit, seen := false, make(map[string]bool)
+	for _, item := range items {
+		tag := item.Value
+		if replace[normalizeTag(tag)] {
+			tag, hit = into, true
+		}
+		if key := normalizeTag(tag); !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+			styles = append(styles, item.Style)
+		}
+	}
+	if !hit {
+		return src, false, nil
+	}
+
+	key := fm.lines[k.line][:keyEnd(fm.lines[k.line])]
+	var repl []string
+	switch {
+	case k.value.Kind == yaml.ScalarNode:
+		repl = []string{key + ": " + formatTag(tags[0], styles[0], false)}
+	case k.value.Style&yaml.FlowStyle != 0 || k.end == k.line+1:
+		formatted := make([]string, len(tags))
+		for i, tag := range tags {
+			formatted[i] = formatTag(tag, styles[i], true)
+		}
+		repl = []string{key + ": [" + strings.Join(formatted, ", ") + "]"}
+	default:
+		// Keep the dash indentation of the first item
+		first := fm.lines[fm.start+items[0].Line-1]
+		indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+		repl = []string{fm.lines[k.line]}
+		for i, tag := range tags {
+			repl = append(repl, indent+"- "+formatTag(tag, styles[i], false))
+		}
+	}
+	if k.comment != "" && len(repl) == 1 {
+		repl[0] += " " + k.comment
+	}
+	fm.splice(k.line, k.end, repl...)
+	return fm.bytes(), true, nil
+}
+
+// formatTag writes a tag with the quoting style it had, quoting plain tags
+// that wouldn't survive as plain YAML
+func formatTag(tag string, style yaml.Style, flow bool) string {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		return strconv.Quote(tag)
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + strings.ReplaceAll(tag, "'", "''") + "'"
+	case flow && strings.ContainsAny(tag, ",[]{}"):
+		return strconv.Quote(tag)
+	}
+	return yamlValue(tag)
+}
+
+// addTagRedirects merges redirects into the tagRedirects block of a config
+// file, pointing existing redirects at their new target and dropping any
+// for tags that are now targets again. Other lines are left as they are.
+func addTagRedirects(src []byte, add map[string]string) ([]byte, error) {
+	fm := &frontmatter{eol: "\n"}
+	if strings.Contains(string(src), "\r\n") {
+		fm.eol = "\r\n"
+	}
+	fm.lines = strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	fm.end = len(fm.lines)
+	if fm.lines[fm.end-1] == "" {
+		fm.end-- // Insert before the final newline
+	}
+	keys, err := parseKeys(fm.lines, 0, fm.end)
+	if err != nil {
+		return nil, err
+	}
+	fm.keys = keys
+
+	merged := make(map[string]string)
+	k := fm.find("tagRedirects")
+	if k != nil {
+		if err := k.value.Decode(&merged); err != nil {
+			return nil, fmt.Errorf("tagRedirects: %w", err)
+		}
+	}
+	for old, target := range merged {
+		if next, ok := add[normalizeTag(target)]; ok {
+			merged[old] = next
+		}
+	}
+	for old, target := range add {
+		merged[old] = target
+	}
+	for old, target := range merged {
+		if normalizeTag(old) == normalizeTag(target) {
+			delete(merged, old)
+		}
+	}
+	// A tag that is a target again has its own page
+	for _, target := range add {
+		delete(merged, target)
+	}
+
+	olds := make([]string, 0, len(merged))
+	for old := range merged {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+	block := []string{"tagRedirects:"}
+	for _, old := range olds {
+		block = append(block, "  "+yamlKey(old)+": "+yamlValue(merged[old]))
+	}
+
+	if k != nil {
+		fm.splice(k.line, k.end, block...)
+	} else {
+		fm.splice(fm.end, fm.end, block...)
+	}
+	return fm.bytes(), nil
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
@@ -0,0 +1,120 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceTags(t *testing.T) {
+	replace := map[string]bool{"golang": true, "go-lang": true}
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "block list keeps indentation",
+			src:  "---\ntitle: x\ntags:\n    - Golang\n    - web\n# next\ndraft: false\n---\n",
+			want: "---\ntitle: x\ntags:\n    - go\n    - web\n# next\ndraft: false\n---\n",
+		},
+		{
+			name: "flow list keeps quoting and drops duplicates",
+			src:  "---\ntags: [\"golang\", \"go\", \"go-lang\"] # topics\n---\nBody\n",
+			want: "---\ntags: [\"go\"] # topics\n---\nBody\n",
+		},
+		{
+			name: "single tag",
+			src:  "---\ntags: golang\n---\n",
+			want: "---\ntags: go\n---\n",
+		},
+		{
+			name: "untouched",
+			src:  "---\ntags: [rust, golang-tools]\n---\n",
+			want: "---\ntags: [rust, golang-tools]\n---\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := replaceTags([]byte(tt.src), replace, "go")
+			if err != nil {
+				t.Fatalf("replaceTags() error = %v", err)
+			}
+			if string(got) != tt.want || changed != (tt.src != tt.want) {
+				t.Errorf("replaceTags() = %v,\n%s\nwant\n%s", changed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddTagRedirects(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		add  map[string]string
+		want string
+	}{
+		{
+			name: "appended",
+			src:  "title: Blog\n# theme\ntheme: blog\n",
+			add:  map[string]string{"golang": "go"},
+			want: "title: Blog\n# theme\ntheme: blog\ntagRedirects:\n  golang: go\n",
+		},
+		{
+			name: "chains collapse",
+			src:  "tagRedirects:\n  js: javascript\n\ntheme: blog\n",
+			add:  map[string]string{"javascript": "web"},
+			want: "tagRedirects:\n  javascript: web\n  js: web\n\ntheme: blog\n",
+		},
+		{
+			name: "renaming back drops the old redirect",
+			src:  "tagRedirects: {go: golang}\n",
+			add:  map[string]string{"golang": "go"},
+			want: "tagRedirects:\n  golang: go\n",
+		},
+		{
+			name: "empty file",
+			add:  map[string]string{"c++": "cpp"},
+			want: "tagRedirects:\n  c++: cpp\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addTagRedirects([]byte(tt.src), tt.add)
+			if err != nil {
+				t.Fatalf("addTagRedirects() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("addTagRedirects() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountTags(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writePost(t, dir, "a.md", "---\ntags: [Go, web]\n---\n"),
+		writePost(t, dir, "b.md", "---\ntags:\n  - go\n  - GO\n---\n"),
+		writePost(t, dir, "c.md", "No frontmatter\n"),
+	}
+	var got []string
+	for _, tc := range countTags(files) {
+		got = append(got, tc.Tag+"="+strings.Repeat("|", tc.Count))
+	}
+	if strings.Join(got, " ") != "go=|| web=|" {
+		t.Errorf("countTags() = %v", got)
+	}
+}
+
+func writePost(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,310 @@
+// Package mock fabricates lorem ipsum content for theme development (`kosh
+// dev mock`): posts of varied length with code, images, math, tables and
+// diagrams, spread over sections and tags, so layouts, pagination and search
+// can be tried at scale without writing content
+package mock
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options sizes the generated content
+type Options struct {
+	Posts    int
+	Tags     int
+	Sections int    // Content folders the posts are spread over
+	Seed     uint64 // The same seed gives the same content
+}
+
+// DefaultOptions are the sizes `kosh dev mock` uses without flags
+func DefaultOptions() Options {
+	return Options{Posts: 50, Tags: 10, Sections: 3, Seed: 1}
+}
+
+var mockFlags = map[string]bool{"posts": true, "tags": true, "sections": true, "seed": true, "dir": true}
+
+// ParseArgs takes the mock flags (--posts, --tags, --sections, --seed,
+// --dir) out of args and returns the rest, which are build and serve flags
+func ParseArgs(args []string) (opts Options, dir string, rest []string, err error) {
+	opts = DefaultOptions()
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || !mockFlags[name] {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, "", nil, fmt.Errorf("--%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "dir" {
+			dir = value
+			continue
+		}
+		n, convErr := strconv.ParseUint(value, 10, 64)
+		if convErr != nil {
+			return opts, "", nil, fmt.Errorf("--%s: %q is not a number", name, value)
+		}
+		switch name {
+		case "posts":
+			opts.Posts = int(n)
+		case "tags":
+			opts.Tags = int(n)
+		case "sections":
+			opts.Sections = int(n)
+		case "seed":
+			opts.Seed = n
+		}
+	}
+	if opts.Posts < 1 || opts.Sections < 1 {
+		return opts, "", nil, fmt.Errorf("--posts and --sections must be at least 1")
+	}
+	return opts, dir, rest, nil
+}
+
+var lorem = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do
+eiusmod tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis
+nostrud exercitation ullamco laboris nisi aliquip ex ea commodo consequat duis aute irure
+in reprehenderit voluptate velit esse cillum fugiat nulla pariatur excepteur sint occaecat
+cupidatat non proident sunt culpa qui officia deserunt mollit anim id est laborum`)
+
+var tagNames = strings.Fields(`design tutorial release performance guide reference
+testing notes ops security research mobile web data tooling`)
+
+var sectionNames = strings.Fields(`articles guides notes tutorials reference journal`)
+
+// codeSamples are fenced blocks in the languages themes most often style
+var codeSamples = []string{
+	"```go\nfunc handler(w http.ResponseWriter, r *http.Request) {\n\tname := r.URL.Query().Get(\"name\")\n\tif name == \"\" {\n\t\tname = \"world\"\n\t}\n\tfmt.Fprintf(w, \"Hello, %s!\\n\", name)\n}\n```",
+	"```python\ndef fibonacci(n: int) -> list[int]:\n    seq = [0, 1]\n    while len(seq) < n:\n        seq.append(seq[-1] + seq[-2])\n    return seq[:n]\n```",
+	"```javascript\nconst debounce = (fn, ms = 200) => {\n  let timer;\n  return (...args) => {\n    clearTimeout(timer);\n    timer = setTimeout(() => fn(...args), ms);\n  };\n};\n```",
+	"```bash\n#!/usr/bin/env bash\nset -euo pipefail\nfor f in content/**/*.md; do\n  wc -w \"$f\"\ndone | sort -n | tail -5\n```",
+	"```yaml\ntitle: \"My Site\"\nbaseURL: \"https://example.com\"\npostsPerPage: 10\nfeatures:\n  search: true\n```",
+}
+
+var mathSamples = []string{
+	"$$\n\\int_0^\\infty e^{-x^2}\\,dx = \\frac{\\sqrt{\\pi}}{2}\n$$",
+	"$$\n\\sum_{k=1}^{n} k = \\frac{n(n+1)}{2}\n$$",
+	"$$\nA = \\begin{pmatrix} a & b \\\\ c & d \\end{pmatrix}, \\quad \\det A = ad - bc\n$$",
+}
+
+var inlineMath = []string{`$e^{i\pi} + 1 = 0$`, `$O(n \log n)$`, `$\alpha + \beta = \gamma$`}
+
+// imageSizes cover the aspect ratios that break layouts: wide, standard,
+// square and portrait
+var imageSizes = [][2]int{{1600, 900}, {1200, 900}, {800, 800}, {600, 900}}
+
+var imageColors = []string{"#4f46e5", "#0891b2", "#059669", "#d97706", "#dc2626", "#7c3aed"}
+
+// Generate writes opts.Posts markdown files into contentDir, under
+// opts.Sections folders, and returns their paths relative to contentDir
+func Generate(contentDir string, opts Options) ([]string, error) {
+	rng := rand.New(rand.NewPCG(opts.Seed, 2604))
+	tags := makeTags(opts.Tags)
+	sections := makeSections(opts.Sections)
+	base := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	files := make([]string, 0, opts.Posts)
+	for i := 0; i < opts.Posts; i++ {
+		section := sections[i%len(sections)]
+		title := sentence(rng, 2+rng.IntN(9))
+		rel := filepath.ToSlash(filepath.Join(section, fmt.Sprintf("%03d-%s.md", i+1, slug(title))))
+		// Older posts first, a few days apart at varying times of day
+		date := base.AddDate(0, 0, -3*(opts.Posts-i)).Add(time.Duration(rng.IntN(12*60)) * time.Minute)
+
+		var prev string
+		if i > 0 {
+			prev = files[i-1]
+		}
+		post := generatePost(rng, i, title, date, pickTags(rng, tags), prev)
+		if err := writeFile(filepath.Join(contentDir, filepath.FromSlash(rel)), []byte(post)); err != nil {
+			return nil, err
+		}
+		files = append(files, rel)
+	}
+	return files, nil
+}
+
+func generatePost(rng *rand.Rand, i int, title string, date time.Time, tags []string, prev string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "---\ntitle: %q\n", capitalize(title))
+	fmt.Fprintf(&sb, "date: %q\n", date.Format("2006-01-02"))
+	fmt.Fprintf(&sb, "description: %q\n", capitalize(sentence(rng, 8+rng.IntN(20)))+".")
+	fmt.Fprintf(&sb, "tags: [%s]\n", quoteAll(tags))
+	fmt.Fprintf(&sb, "weight: %d\n", i+1)
+	if i < 2 {
+		sb.WriteString("pinned: true\n")
+	}
+	sb.WriteString("---\n\n")
+
+	sb.WriteString(paragraph(rng))
+	sb.WriteString("\n\n")
+
+	// Lengths vary from a short note to a long article with nested headings
+	sectionCount := []int{1, 2, 3, 4, 6, 9}[rng.IntN(6)]
+	for s := 0; s < sectionCount; s++ {
+		fmt.Fprintf(&sb, "## %s\n\n", capitalize(sentence(rng, 2+rng.IntN(5))))
+		for p := rng.IntN(3); p >= 0; p-- {
+			sb.WriteString(paragraph(rng))
+			sb.WriteString("\n\n")
+		}
+		switch rng.IntN(9) {
+		case 0, 1:
+			sb.WriteString(codeSamples[rng.IntN(len(codeSamples))])
+			sb.WriteString("\n\n")
+		case 2:
+			sb.WriteString(image(rng, i, s))
+			sb.WriteString("\n\n")
+		case 3:
+			fmt.Fprintf(&sb, "%s %s %s.\n\n", capitalize(sentence(rng, 6)), inlineMath[rng.IntN(len(inlineMath))], sentence(rng, 5))
+			sb.WriteString(mathSamples[rng.IntN(len(mathSamples))])
+			sb.WriteString("\n\n")
+		case 4:
+			sb.WriteString(table(rng))
+		case 5:
+			fmt.Fprintf(&sb, "### %s\n\n", capitalize(sentence(rng, 3)))
+			for r := 2 + rng.IntN(4); r > 0; r-- {
+				fmt.Fprintf(&sb, "- %s\n", capitalize(sentence(rng, 3+rng.IntN(10))))
+			}
+			sb.WriteString("\n")
+		case 6:
+			fmt.Fprintf(&sb, "> %s.\n\n", capitalize(sentence(rng, 15+rng.IntN(20))))
+		case 7:
+			if prev != "" {
+				fmt.Fprintf(&sb, "See also [the previous post](/%s) and the [Go documentation](https://go.dev/doc/).\n\n", strings.TrimSuffix(prev, ".md")+".html")
+			}
+		}
+	}
+
+	// Diagrams render slowly, so only one post in five gets one
+	if i%5 == 4 {
+		fmt.Fprintf(&sb, "## Architecture\n\n```d2\nclient -> server: request %d\nserver -> cache: lookup\ncache -> server: hit\nserver -> client: response\n```\n\n", i+1)
+	}
+	return sb.String()
+}
+
+// image is a placeholder SVG as a data URI, so no image files are needed
+func image(rng *rand.Rand, post, section int) string {
+	size := imageSizes[rng.IntN(len(imageSizes))]
+	color := imageColors[rng.IntN(len(imageColors))]
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d"><rect width="100%%" height="100%%" fill="%s"/><text x="50%%" y="50%%" fill="#fff" font-family="sans-serif" font-size="%d" text-anchor="middle" dominant-baseline="middle">%d × %d</text></svg>`,
+		size[0], size[1], size[0], size[1], color, size[1]/8, size[0], size[1])
+	return fmt.Sprintf("![Figure %d.%d: %s](data:image/svg+xml;base64,%s)", post+1, section+1, sentence(rng, 4), base64.StdEncoding.EncodeToString([]byte(svg)))
+}
+
+func table(rng *rand.Rand) string {
+	var sb strings.Builder
+	sb.WriteString("| Name | Description | Value |\n|------|-------------|------:|\n")
+	for r := 3 + rng.IntN(5); r > 0; r-- {
+		fmt.Fprintf(&sb, "| `%s` | %s | %d |\n", lorem[rng.IntN(len(lorem))], capitalize(sentence(rng, 2+rng.IntN(8))), rng.IntN(10000))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// makeTags names n tags, numbering them once the word list runs out
+func makeTags(n int) []string {
+	tags := make([]string, n)
+	for i := range tags {
+		if i < len(tagNames) {
+			tags[i] = tagNames[i]
+		} else {
+			tags[i] = fmt.Sprintf("topic-%d", i+1)
+		}
+	}
+	return tags
+}
+
+func makeSections(n int) []string {
+	sections := make([]string, n)
+	for i := range sections {
+		if i < len(sectionNames) {
+			sections[i] = sectionNames[i]
+		} else {
+			sections[i] = fmt.Sprintf("section-%d", i+1)
+		}
+	}
+	return sections
+}
+
+// pickTags gives a post up to four tags, favouring the first ones so tag
+// pages range from crowded (pagination) to a single post
+func pickTags(rng *rand.Rand, tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	var picked []string
+	for n := 1 + rng.IntN(4); n > 0; n-- {
+		t := tags[int(float64(len(tags))*rng.Float64()*rng.Float64())]
+		if !contains(picked, t) {
+			picked = append(picked, t)
+		}
+	}
+	return picked
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func sentence(rng *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = lorem[rng.IntN(len(lorem))]
+	}
+	return strings.Join(parts, " ")
+}
+
+func paragraph(rng *rand.Rand) string {
+	var sb strings.Builder
+	for s := 2 + rng.IntN(5); s > 0; s-- {
+		sb.WriteString(capitalize(sentence(rng, 6+rng.IntN(14))))
+		sb.WriteString(". ")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func slug(title string) string {
+	words := strings.Fields(title)
+	if len(words) > 4 {
+		words = words[:4]
+	}
+	return strings.Join(words, "-")
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
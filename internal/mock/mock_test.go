@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	opts := Options{Posts: 40, Tags: 20, Sections: 2, Seed: 7}
+	dirA, dirB := t.TempDir(), t.TempDir()
+	files, err := Generate(dirA, opts)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, err := Generate(dirB, opts); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(files) != opts.Posts {
+		t.Fatalf("generated %d posts, want %d", len(files), opts.Posts)
+	}
+
+	tags := map[string]bool{}
+	var all strings.Builder
+	for _, rel := range files {
+		a, err := os.ReadFile(filepath.Join(dirA, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := os.ReadFile(filepath.Join(dirB, rel))
+		if string(a) != string(b) {
+			t.Errorf("%s differs between runs with the same seed", rel)
+		}
+		if !strings.HasPrefix(string(a), "---\ntitle: ") {
+			t.Errorf("%s has no frontmatter", rel)
+		}
+		for _, line := range strings.Split(string(a), "\n") {
+			if list, ok := strings.CutPrefix(line, "tags: ["); ok {
+				for _, tag := range strings.Split(strings.TrimSuffix(list, "]"), ", ") {
+					tags[tag] = true
+				}
+			}
+		}
+		all.Write(a)
+	}
+
+	if sections, _ := filepath.Glob(filepath.Join(dirA, "*")); len(sections) != opts.Sections {
+		t.Errorf("generated %d sections, want %d", len(sections), opts.Sections)
+	}
+	if len(tags) < 2 || len(tags) > opts.Tags {
+		t.Errorf("posts use %d tags, want between 2 and %d", len(tags), opts.Tags)
+	}
+	for _, want := range []string{"```go", "```d2", "$$", "data:image/svg+xml;base64,", "| Name |", "pinned: true"} {
+		if !strings.Contains(all.String(), want) {
+			t.Errorf("generated content has no %q", want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	opts, dir, rest, err := ParseArgs([]string{"--posts", "200", "-tags=4", "--port", "8080", "--dir", "mock", "-drafts"})
+	if err != nil {
+		t.Fatalf("ParseArgs() error = %v", err)
+	}
+	want := Options{Posts: 200, Tags: 4, Sections: 3, Seed: 1}
+	if opts != want || dir != "mock" {
+		t.Errorf("ParseArgs() = %+v, %q, want %+v, \"mock\"", opts, dir, want)
+	}
+	if !reflect.DeepEqual(rest, []string{"--port", "8080", "-drafts"}) {
+		t.Errorf("ParseArgs() rest = %q", rest)
+	}
+
+	for _, args := range [][]string{{"--posts"}, {"--posts", "many"}, {"--posts", "0"}} {
+		if _, _, _, err := ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs(%q) succeeded, want an error", args)
+		}
+	}
+}
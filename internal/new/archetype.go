@@ -0,0 +1,83 @@
+package new
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ArchetypeDir holds the site's post templates: <section>.md for posts in
+// that section, default.md for the rest
+const ArchetypeDir = "archetypes"
+
+// defaultArchetype is used when the site has no archetype of its own
+const defaultArchetype = `---
+title: {{ quote .Title }}
+date: {{ quote .Date }}
+description: "Enter a short description here..."
+tags: {{ list .Tags }}
+pinned: false
+draft: {{ .Draft }}
+---
+
+## Introduction
+
+Start writing here...
+`
+
+// Post is what an archetype is executed with
+type Post struct {
+	Title   string
+	Date    string // YYYY-MM-DD
+	Tags    []string
+	Section string // Directory under content/, "" for the top level
+	Draft   bool
+}
+
+var archetypeFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+	"list": func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+}
+
+// loadArchetype returns the template for posts in section: the site's
+// archetypes/<section>.md, then archetypes/default.md, then the built-in one
+func loadArchetype(section string) (*template.Template, error) {
+	candidates := []string{filepath.Join(ArchetypeDir, "default.md")}
+	if section != "" {
+		// Nested sections use the archetype of their top-level section
+		name := strings.SplitN(filepath.ToSlash(section), "/", 2)[0] + ".md"
+		candidates = append([]string{filepath.Join(ArchetypeDir, name)}, candidates...)
+	}
+
+	name, text := "default", defaultArchetype
+	for _, path := range candidates {
+		if data, err := os.ReadFile(path); err == nil {
+			name, text = path, string(data)
+			break
+		}
+	}
+	tmpl, err := template.New(name).Funcs(archetypeFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("archetype %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// render executes the archetype for post
+func render(tmpl *template.Template, post Post) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, post); err != nil {
+		return nil, fmt.Errorf("archetype %s: %w", tmpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,147 @@
+package new
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// manifestEntry is one post of a JSON manifest
+type manifestEntry struct {
+	Title   string  `json:"title"`
+	Date    string  `json:"date"`
+	Tags    tagList `json:"tags"`
+	Section string  `json:"section"`
+	Draft   *bool   `json:"draft"`
+}
+
+// tagList accepts tags as a JSON array or a comma separated string
+type tagList []string
+
+func (t *tagList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*t = list
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("tags must be a list or a comma separated string")
+	}
+	*t = splitTags(s)
+	return nil
+}
+
+// readManifest reads the posts to create from a CSV file with a header row
+// (title, date, tags, section, draft) or a JSON array of the same fields.
+// Posts are drafts dated today unless the manifest says otherwise.
+func readManifest(file string, today time.Time) ([]Post, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []manifestEntry
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	} else if entries, err = readCSV(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	posts := make([]Post, 0, len(entries))
+	for i, e := range entries {
+		post, err := e.post(today)
+		if err != nil {
+			return nil, fmt.Errorf("%s: entry %d: %w", file, i+1, err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+func readCSV(r io.Reader) ([]manifestEntry, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("empty manifest")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("the header row needs a title column")
+	}
+	cell := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	entries := make([]manifestEntry, 0, len(rows)-1)
+	for n, row := range rows[1:] {
+		e := manifestEntry{
+			Title:   cell(row, "title"),
+			Date:    cell(row, "date"),
+			Tags:    splitTags(cell(row, "tags")),
+			Section: cell(row, "section"),
+		}
+		if v := cell(row, "draft"); v != "" {
+			draft, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: draft %q is not true or false", n+2, v)
+			}
+			e.Draft = &draft
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// post validates an entry and fills in its defaults
+func (e manifestEntry) post(today time.Time) (Post, error) {
+	p := Post{Title: strings.TrimSpace(e.Title), Date: e.Date, Tags: e.Tags, Draft: true}
+	if p.Title == "" {
+		return p, errors.New("missing title")
+	}
+	if p.Date == "" {
+		p.Date = today.Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", p.Date); err != nil {
+		return p, fmt.Errorf("date %q is not YYYY-MM-DD", p.Date)
+	}
+	if e.Draft != nil {
+		p.Draft = *e.Draft
+	}
+
+	if strings.Contains(e.Section, "..") {
+		return p, fmt.Errorf("section %q must stay inside content/", e.Section)
+	}
+	p.Section = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(strings.TrimSpace(e.Section))), "/")
+	return p, nil
+}
+
+// splitTags splits "go, web; tools" into its tags
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -1,8 +1,10 @@
 package new
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -32,47 +34,99 @@ func sanitizeSlug(title string) string {
 	return slug
 }
 
-// Run creates a new blog post file
-func Run(args []string) {
-	if len(args) < 1 {
+// ContentDir is where new posts are created
+const ContentDir = "content"
+
+// Run creates a new post, or with --from every post of a CSV/JSON manifest,
+// and reports whether anything was created
+func Run(args []string) bool {
+	var manifest, title string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case (arg == "--from" || arg == "-from") && i+1 < len(args):
+			manifest = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--from=") || strings.HasPrefix(arg, "-from="):
+			manifest = arg[strings.Index(arg, "=")+1:]
+		case title == "":
+			title = arg
+		}
+	}
+
+	if manifest != "" {
+		return createFromManifest(manifest)
+	}
+	if title == "" {
 		fmt.Println("Usage: kosh new \"My New Post Title\"")
-		return
+		fmt.Println("       kosh new --from posts.csv")
+		return false
 	}
 
-	title := args[0]
-	// Create a safe filename slug
-	slug := sanitizeSlug(title)
-	if slug == "" {
-		fmt.Println("❌ Error: Title produces empty slug after sanitization")
-		return
+	post := Post{Title: title, Date: time.Now().Format("2006-01-02")}
+	filename, err := create(post)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return false
 	}
-	filename := fmt.Sprintf("content/%s.md", slug)
+	fmt.Printf("✅ Created: %s\n", filename)
+	return true
+}
 
-	// Basic Frontmatter template
-	content := fmt.Sprintf(`---
-title: "%s"
-date: "%s"
-description: "Enter a short description here..."
-tags: []
-pinned: false
-draft: false
----
+// createFromManifest creates a post for every entry of a manifest, skipping
+// posts that already exist. Nothing is written if any entry is invalid.
+func createFromManifest(manifest string) bool {
+	posts, err := readManifest(manifest, time.Now())
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		return false
+	}
 
-## Introduction
+	created, skipped := 0, 0
+	for _, post := range posts {
+		filename, err := create(post)
+		switch {
+		case errors.Is(err, os.ErrExist):
+			fmt.Printf("⏭️  Skipped (exists): %s\n", filename)
+			skipped++
+		case err != nil:
+			fmt.Printf("❌ Error: %q: %v\n", post.Title, err)
+		default:
+			fmt.Printf("✅ Created: %s\n", filename)
+			created++
+		}
+	}
+	fmt.Printf("📝 Created %d of %d posts from %s (%d already existed)\n", created, len(posts), manifest, skipped)
+	return created > 0
+}
 
-Start writing here...
-`, title, time.Now().Format("2006-01-02"))
+// create writes post from its section's archetype and returns the file name.
+// An existing file is never overwritten: the error then wraps os.ErrExist.
+func create(post Post) (string, error) {
+	// Create a safe filename slug
+	slug := sanitizeSlug(post.Title)
+	if slug == "" {
+		return "", errors.New("title produces empty slug after sanitization")
+	}
+	filename := filepath.ToSlash(filepath.Join(ContentDir, post.Section, slug+".md"))
 
 	// Check if file exists to avoid overwriting
 	if _, err := os.Stat(filename); err == nil {
-		fmt.Println("❌ Error: File already exists:", filename)
-		return
+		return filename, fmt.Errorf("file already exists: %s: %w", filename, os.ErrExist)
 	}
 
-	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
-		fmt.Println("Error creating file:", err)
-		return
+	tmpl, err := loadArchetype(post.Section)
+	if err != nil {
+		return filename, err
 	}
-
-	fmt.Printf("✅ Created: %s\n", filename)
+	content, err := render(tmpl, post)
+	if err != nil {
+		return filename, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return filename, err
+	}
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		return filename, fmt.Errorf("creating file: %w", err)
+	}
+	return filename, nil
 }
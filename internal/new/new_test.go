@@ -0,0 +1,121 @@
+package new
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var today = time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []Post
+		wantErr string
+	}{
+		{
+			name: "csv",
+			file: "posts.csv",
+			content: "Title,Date,Tags,Section,Draft\n" +
+				"Launch week,2025-04-01,\"news, launch\",blog,false\n" +
+				"Roadmap,,planning,,\n",
+			want: []Post{
+				{Title: "Launch week", Date: "2025-04-01", Tags: []string{"news", "launch"}, Section: "blog"},
+				{Title: "Roadmap", Date: "2025-03-14", Tags: []string{"planning"}, Draft: true},
+			},
+		},
+		{
+			name:    "json",
+			file:    "posts.json",
+			content: `[{"title": "API guide", "tags": ["api"], "section": "docs/v2/"}, {"title": "FAQ", "tags": "help; faq"}]`,
+			want: []Post{
+				{Title: "API guide", Date: "2025-03-14", Tags: []string{"api"}, Section: "docs/v2", Draft: true},
+				{Title: "FAQ", Date: "2025-03-14", Tags: []string{"help", "faq"}, Draft: true},
+			},
+		},
+		{
+			name:    "missing title column",
+			file:    "no-title.csv",
+			content: "name,date\nx,2025-01-01\n",
+			wantErr: "needs a title column",
+		},
+		{
+			name:    "bad date",
+			file:    "bad-date.csv",
+			content: "title,date\nFirst,2025-01-01\nSecond,March 3\n",
+			wantErr: "entry 2: date \"March 3\" is not YYYY-MM-DD",
+		},
+		{
+			name:    "section outside content",
+			file:    "escape.json",
+			content: `[{"title": "x", "section": "../../etc"}]`,
+			wantErr: "must stay inside content/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, tt.content)
+			got, err := readManifest(path, today)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readManifest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readManifest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readManifest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateUsesArchetypes(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, "archetypes/blog.md", "---\ntitle: {{ quote .Title }}\ntags: {{ list .Tags }}\nlayout: post\n---\n")
+
+	blog, err := create(Post{Title: `Say "hi"`, Date: "2025-03-14", Tags: []string{"a", "b"}, Section: "blog/2025"})
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	plain, err := create(Post{Title: "Plain", Date: "2025-03-14", Draft: true})
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+
+	if blog != "content/blog/2025/say-hi.md" || plain != "content/plain.md" {
+		t.Errorf("created %s and %s", blog, plain)
+	}
+	if data, _ := os.ReadFile(blog); string(data) != "---\ntitle: \"Say \\\"hi\\\"\"\ntags: [\"a\", \"b\"]\nlayout: post\n---\n" {
+		t.Errorf("section archetype output = %q", data)
+	}
+	if data, _ := os.ReadFile(plain); !strings.Contains(string(data), "tags: []\n") || !strings.Contains(string(data), "draft: true\n") {
+		t.Errorf("default archetype output = %q", data)
+	}
+
+	if _, err := create(Post{Title: "Plain", Date: "2025-03-14"}); !errors.Is(err, os.ErrExist) {
+		t.Errorf("creating an existing post: error = %v, want os.ErrExist", err)
+	}
+}
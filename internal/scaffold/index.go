@@ -0,0 +1,129 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/remote"
+)
+
+// DefaultIndexURL is the curated list of official and community starters.
+// KOSH_STARTER_INDEX overrides it, e.g. for a mirror.
+const DefaultIndexURL = "https://raw.githubusercontent.com/Kush-Singh-26/kosh-starters/main/index.json"
+
+// indexTimeout keeps an unreachable index from stalling kosh init
+const indexTimeout = 5 * time.Second
+
+// IndexEntry is one starter of the index
+type IndexEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"` // Git repository, usable as --template
+	Official    bool     `json:"official,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Index is the starter index document
+type Index struct {
+	Starters []IndexEntry `json:"starters"`
+}
+
+// Find returns the starter called name
+func (ix *Index) Find(name string) (IndexEntry, bool) {
+	for _, e := range ix.Starters {
+		if strings.EqualFold(e.Name, name) {
+			return e, true
+		}
+	}
+	return IndexEntry{}, false
+}
+
+func indexURL() string {
+	if u := os.Getenv("KOSH_STARTER_INDEX"); u != "" {
+		return u
+	}
+	return DefaultIndexURL
+}
+
+// indexCacheDir holds the last fetched index. There is no site (and no
+// .kosh-cache) yet when kosh init runs, so it lives in the user cache dir.
+func indexCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "kosh")
+}
+
+// LoadIndex fetches the starter index, revalidating the cached copy. When the
+// network is unavailable (or offline is set) the cached copy is used.
+func LoadIndex(cacheDir, url string, offline bool) (*Index, error) {
+	client := remote.New(cacheDir, indexTimeout, offline, nil, slog.Default())
+	body, err := client.GetRemote(url)
+	if err != nil {
+		return nil, err
+	}
+	var ix Index
+	if err := json.Unmarshal([]byte(body), &ix); err != nil {
+		return nil, fmt.Errorf("invalid starter index %s: %w", url, err)
+	}
+	valid := ix.Starters[:0]
+	for _, e := range ix.Starters {
+		if e.Name != "" && e.URL != "" {
+			valid = append(valid, e)
+		}
+	}
+	ix.Starters = valid
+	sort.SliceStable(ix.Starters, func(i, j int) bool {
+		if ix.Starters[i].Official != ix.Starters[j].Official {
+			return ix.Starters[i].Official
+		}
+		return ix.Starters[i].Name < ix.Starters[j].Name
+	})
+	return &ix, nil
+}
+
+// listTemplates prints the built-in starters and those of the index
+func listTemplates(offline bool) {
+	fmt.Println("🧩 Built-in templates")
+	fmt.Println("════════════════════════════════════════")
+	for _, s := range Starters {
+		fmt.Printf("  %-20s %s\n", s.Name, s.Description)
+	}
+
+	ix, err := LoadIndex(indexCacheDir(), indexURL(), offline)
+	if err != nil {
+		fmt.Printf("\n⚠️  Starter index unavailable: %v\n", err)
+		return
+	}
+
+	for _, official := range []bool{true, false} {
+		title := "🌍 Community starters"
+		if official {
+			title = "🏛️  Official starters"
+		}
+		printed := false
+		for _, e := range ix.Starters {
+			if e.Official != official {
+				continue
+			}
+			if !printed {
+				fmt.Printf("\n%s\n", title)
+				fmt.Println("════════════════════════════════════════")
+				printed = true
+			}
+			desc := e.Description
+			if len(e.Tags) > 0 {
+				desc += " [" + strings.Join(e.Tags, ", ") + "]"
+			}
+			fmt.Printf("  %-20s %s\n  %-20s %s\n", e.Name, desc, "", e.URL)
+		}
+	}
+	fmt.Println("\n👉 kosh init [name] --template <name>")
+}
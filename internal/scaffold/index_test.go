@@ -0,0 +1,58 @@
+package scaffold
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testIndex = `{"starters": [
+	{"name": "zine", "description": "Community zine", "url": "https://example.com/zine.git"},
+	{"name": "Handbook", "description": "Official handbook", "url": "https://example.com/handbook.git", "official": true},
+	{"name": "broken", "description": "No url"}
+]}`
+
+func TestLoadIndex(t *testing.T) {
+	up := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer srv.Close()
+	cacheDir := t.TempDir()
+
+	ix, err := LoadIndex(cacheDir, srv.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range ix.Starters {
+		names = append(names, e.Name)
+	}
+	// Official first, entries without a url dropped
+	if len(names) != 2 || names[0] != "Handbook" || names[1] != "zine" {
+		t.Fatalf("starters = %v, want [Handbook zine]", names)
+	}
+	if e, ok := ix.Find("handbook"); !ok || e.URL != "https://example.com/handbook.git" {
+		t.Errorf("Find(handbook) = %+v, %v", e, ok)
+	}
+
+	// The cached copy is used when the index can't be fetched
+	up = false
+	for _, offline := range []bool{false, true} {
+		ix, err := LoadIndex(cacheDir, srv.URL, offline)
+		if err != nil {
+			t.Fatalf("offline=%v: %v", offline, err)
+		}
+		if len(ix.Starters) != 2 {
+			t.Errorf("offline=%v: %d starters from cache, want 2", offline, len(ix.Starters))
+		}
+	}
+
+	if _, err := LoadIndex(t.TempDir(), srv.URL, true); err == nil {
+		t.Error("offline without a cached index should fail")
+	}
+}
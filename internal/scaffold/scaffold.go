@@ -1,77 +1,136 @@
 package scaffold
 
 import (
+	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/modules"
 )
 
-const defaultKoshYaml = `# Site Configuration
-title: "My Kosh Site"
-description: "A new site built with Kosh"
-baseURL: "http://localhost:2604"
-language: "en"
-
-author:
-  name: "Author Name"
-  url: "https://example.com"
-
-# Navigation
-menu:
-  - name: "Home"
-    url: "/"
-  - name: "Tags"
-    url: "/tags/index.html"
-
-# Features
-postsPerPage: 10
-compressImages: true
-
-# Theme Configuration
-theme: "blog"
-themeDir: "themes"
-# templateDir and staticDir will default to themes/<theme>/templates and themes/<theme>/static
-`
-
-const firstPost = `---
-title: "Hello World"
-date: "%s"
-tags: ["kosh", "welcome"]
-draft: false
----
-
-# Welcome to Kosh!
-
-This is your first post. You can edit this file in ` + "`content/hello-world.md`" + `.
-
-## Getting Started
-
-1.  **Themes**: Kosh requires a theme. Install the official blog theme:
-    ` + "```bash" + `
-    git clone https://github.com/Kush-Singh-26/kosh-theme-blog themes/blog
-    ` + "```" + `
-    
-    Or create your own theme with this structure:
-    ` + "```" + `
-    themes/your-theme/
-    ├── templates/
-    │   ├── layout.html
-    │   └── index.html
-    ├── static/
-    │   ├── css/
-    │   └── js/
-    └── theme.yaml
-    ` + "```" + `
-
-2.  **Run**: Start the dev server.
-    ` + "```bash" + `
-    kosh serve --dev
-    ` + "```" + `
-`
-
-// Run initializes a new Kosh project
+// DefaultStarter is used when kosh init is run without --template
+const DefaultStarter = "blog"
+
+// Starter is a built-in site template for kosh init
+type Starter struct {
+	Name        string
+	Description string
+	Next        []string // Steps printed once the site is created
+}
+
+// Starters lists the built-in templates, embedded from starters/<name>/
+var Starters = []Starter{
+	{
+		Name:        "blog",
+		Description: "Posts with tags, pagination and a menu, for the official blog theme",
+		Next:        []string{"git clone https://github.com/Kush-Singh-26/kosh-theme-blog themes/blog"},
+	},
+	{
+		Name:        "docs",
+		Description: "Documentation with a sidebar tree, weights and a versions preset",
+		Next:        []string{"git clone https://github.com/Kush-Singh-26/kosh-theme-docs themes/docs"},
+	},
+	{
+		Name:        "portfolio",
+		Description: "Project cards with pinned work and an about page, theme included",
+	},
+	{
+		Name:        "minimal",
+		Description: "One page and a two-template theme to build on",
+	},
+}
+
+//go:embed all:starters
+var startersFS embed.FS
+
+// dateToken in Markdown files is replaced with the current date
+const dateToken = "{{date}}"
+
+// Run initializes a new Kosh project, in the current directory or in
+// [name], from a built-in starter or a git repository
 func Run(args []string) {
-	fmt.Println("🌱 Initializing new Kosh project...")
+	template := DefaultStarter
+	list, offline := false, false
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--list-templates" || arg == "-list-templates":
+			list = true
+		case arg == "--offline" || arg == "-offline":
+			offline = true
+		case (arg == "--template" || arg == "-template" || arg == "-t") && i+1 < len(args):
+			template = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--template="):
+			template = strings.TrimPrefix(arg, "--template=")
+		case strings.HasPrefix(arg, "-"):
+			fmt.Printf("❌ Unknown flag: %s\n", arg)
+			printUsage()
+			return
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if list {
+		listTemplates(offline)
+		return
+	}
+	if len(positional) > 1 {
+		printUsage()
+		return
+	}
+	root := "."
+	if len(positional) == 1 {
+		root = positional[0]
+	}
+
+	// Names that aren't built in are looked up in the starter index
+	if _, ok := findStarter(template); !ok && !isGitURL(template) {
+		ix, err := LoadIndex(indexCacheDir(), indexURL(), offline)
+		if err != nil {
+			fmt.Printf("❌ Unknown template: %s (starter index unavailable: %v)\n", template, err)
+			printUsage()
+			return
+		}
+		e, ok := ix.Find(template)
+		if !ok {
+			fmt.Printf("❌ Unknown template: %s\n", template)
+			printUsage()
+			return
+		}
+		template = e.URL
+	}
+
+	var src fs.FS
+	var starter Starter
+	if s, ok := findStarter(template); ok {
+		starter = s
+		sub, err := fs.Sub(startersFS, path.Join("starters", s.Name))
+		if err != nil {
+			fmt.Printf("❌ Failed to read template '%s': %v\n", s.Name, err)
+			return
+		}
+		src = sub
+	} else {
+		dir, cleanup, err := fetchStarter(template)
+		if err != nil {
+			fmt.Printf("❌ Failed to fetch template: %v\n", err)
+			return
+		}
+		defer cleanup()
+		starter = Starter{Name: modules.RepoName(template)}
+		src = os.DirFS(dir)
+	}
+
+	fmt.Printf("🌱 Initializing new Kosh project (%s template)...\n", starter.Name)
 
 	// 1. Create Directories
 	dirs := []string{
@@ -82,34 +141,106 @@ func Run(args []string) {
 	}
 
 	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
 			fmt.Printf("❌ Failed to create directory '%s': %v\n", dir, err)
 			return
 		}
 		fmt.Printf("   📁 Created '%s/'\n", dir)
 	}
 
-	// 2. Create kosh.yaml
-	if _, err := os.Stat("kosh.yaml"); os.IsNotExist(err) {
-		if err := os.WriteFile("kosh.yaml", []byte(defaultKoshYaml), 0644); err != nil {
-			fmt.Printf("❌ Failed to create kosh.yaml: %v\n", err)
-			return
-		}
-		fmt.Println("   📄 Created 'kosh.yaml'")
-	} else {
-		fmt.Println("   ⚠️ 'kosh.yaml' already exists, skipping.")
+	// 2. Copy the starter: kosh.yaml, content and any bundled theme
+	if err := copyStarter(root, src, time.Now()); err != nil {
+		fmt.Printf("❌ Failed to copy template: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n✅ Project initialized successfully!")
+	if root != "." {
+		fmt.Printf("   👉 cd %s\n", root)
+	}
+	for _, step := range starter.Next {
+		fmt.Printf("   👉 %s\n", step)
 	}
+	fmt.Println("   👉 kosh serve --dev")
+}
+
+func printUsage() {
+	fmt.Println("Usage: kosh init [name] [--template <template|git-url>]")
+	fmt.Println("       kosh init --list-templates [--offline]")
+	fmt.Println("\nTemplates:")
+	for _, s := range Starters {
+		fmt.Printf("  %-14s %s\n", s.Name, s.Description)
+	}
+	fmt.Println("  <name>         A starter from the index, see --list-templates")
+	fmt.Println("  <git-url>      A community starter, cloned without its history (url#ref for a branch or tag)")
+}
 
-	// 3. Create first post
-	if _, err := os.Stat("content/hello-world.md"); os.IsNotExist(err) {
-		content := fmt.Sprintf(firstPost, time.Now().Format("2006-01-02"))
-		if err := os.WriteFile("content/hello-world.md", []byte(content), 0644); err != nil {
-			fmt.Printf("❌ Failed to create first post: %v\n", err)
-		} else {
-			fmt.Println("   📝 Created 'content/hello-world.md'")
+func findStarter(name string) (Starter, bool) {
+	for _, s := range Starters {
+		if s.Name == name {
+			return s, true
 		}
 	}
+	return Starter{}, false
+}
 
-	fmt.Println("\n✅ Project initialized successfully!")
-	fmt.Println("   👉 Clone a theme into 'themes/' to get started.")
+// isGitURL reports whether a --template value names a repository rather
+// than a built-in starter
+func isGitURL(s string) bool {
+	return strings.Contains(s, "://") || strings.HasPrefix(s, "git@") || strings.HasSuffix(s, ".git")
+}
+
+// fetchStarter shallow-clones a starter repository (url or url#ref) into a
+// temporary directory, reusing the content module fetcher
+func fetchStarter(template string) (string, func(), error) {
+	url, ref, _ := strings.Cut(template, "#")
+	tmp, err := os.MkdirTemp("", "kosh-starter-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmp) }
+
+	fmt.Printf("⬇️  Fetching %s...\n", template)
+	dir, err := modules.Ensure(context.Background(), tmp, config.ContentModule{URL: url, Ref: ref})
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dir, cleanup, nil
+}
+
+// copyStarter writes every file of src under root, leaving existing files
+// alone. Markdown files have {{date}} replaced with today's date.
+func copyStarter(root string, src fs.FS, now time.Time) error {
+	return fs.WalkDir(src, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(root, filepath.FromSlash(p)), 0755)
+		}
+
+		dest := filepath.Join(root, filepath.FromSlash(p))
+		if _, err := os.Stat(dest); err == nil {
+			fmt.Printf("   ⚠️ '%s' already exists, skipping.\n", p)
+			return nil
+		}
+		data, err := fs.ReadFile(src, p)
+		if err != nil {
+			return err
+		}
+		icon := "📄"
+		if strings.HasSuffix(p, ".md") {
+			data = []byte(strings.ReplaceAll(string(data), dateToken, now.Format("2006-01-02")))
+			icon = "📝"
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("   %s Created '%s'\n", icon, p)
+		return nil
+	})
 }
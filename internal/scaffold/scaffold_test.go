@@ -0,0 +1,99 @@
+package scaffold
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStartersAreEmbedded(t *testing.T) {
+	for _, s := range Starters {
+		t.Run(s.Name, func(t *testing.T) {
+			data, err := fs.ReadFile(startersFS, path.Join("starters", s.Name, "kosh.yaml"))
+			if err != nil {
+				t.Fatalf("kosh.yaml: %v", err)
+			}
+			var cfg struct {
+				Theme string `yaml:"theme"`
+			}
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				t.Fatalf("kosh.yaml: %v", err)
+			}
+
+			// The theme ships with the starter or a step says how to get it
+			_, err = fs.Stat(startersFS, path.Join("starters", s.Name, "themes", cfg.Theme, "templates", "layout.html"))
+			if err != nil && !strings.Contains(strings.Join(s.Next, "\n"), "themes/"+cfg.Theme) {
+				t.Errorf("theme %q is neither bundled nor explained in Next", cfg.Theme)
+			}
+
+			posts, _ := fs.Glob(startersFS, path.Join("starters", s.Name, "content", "*.md"))
+			if len(posts) == 0 {
+				t.Error("no starter content")
+			}
+		})
+	}
+}
+
+func TestCopyStarter(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "kosh.yaml"), []byte("title: mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := fstest.MapFS{
+		"kosh.yaml":            {Data: []byte("title: starter\n")},
+		"content/hello.md":     {Data: []byte("date: \"{{date}}\"\n")},
+		"themes/x/layout.html": {Data: []byte("{{date}}")},
+		".git/HEAD":            {Data: []byte("ref: refs/heads/main\n")},
+	}
+
+	if err := copyStarter(root, src, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"kosh.yaml", "title: mine\n"},                 // Existing files are kept
+		{"content/hello.md", "date: \"2024-03-09\"\n"}, // Dates only in Markdown
+		{"themes/x/layout.html", "{{date}}"},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(tt.file)))
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		t.Error(".git was copied")
+	}
+}
+
+func TestIsGitURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"https://github.com/org/starter", true},
+		{"git@github.com:org/starter.git", true},
+		{"file:///srv/starter#v1", true},
+		{"blog", false},
+		{"my-starter", false},
+	}
+	for _, tt := range tests {
+		if got := isGitURL(tt.in); got != tt.want {
+			t.Errorf("isGitURL(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package searchlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// defaultTop is how many queries and terms each list of the report shows
+const defaultTop = 20
+
+// Count is a named number, in report order
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Report summarizes a search log
+type Report struct {
+	Searches    int       `json:"searches"`
+	Unique      int       `json:"unique"`      // Distinct queries
+	ZeroResults int       `json:"zeroResults"` // Searches that found nothing
+	From        time.Time `json:"from,omitzero"`
+	To          time.Time `json:"to,omitzero"`
+	Top         []Count   `json:"top"`       // Most searched queries
+	Zero        []Count   `json:"zero"`      // Queries that found nothing, most searched first
+	ZeroTerms   []Count   `json:"zeroTerms"` // Words of those queries
+}
+
+// Summarize builds the report of entries, listing up to top queries and
+// terms (0 for all). Queries are compared case-insensitively.
+func Summarize(entries []Entry, top int) Report {
+	var r Report
+	queries := make(map[string]int)
+	zero := make(map[string]int)
+	zeroTerms := make(map[string]int)
+	for _, e := range entries {
+		q := strings.ToLower(strings.Join(strings.Fields(e.Query), " "))
+		if q == "" {
+			continue
+		}
+		r.Searches++
+		queries[q]++
+		if r.From.IsZero() || e.Time.Before(r.From) {
+			r.From = e.Time
+		}
+		if e.Time.After(r.To) {
+			r.To = e.Time
+		}
+		if e.Results > 0 {
+			continue
+		}
+		r.ZeroResults++
+		zero[q]++
+		for _, term := range strings.Fields(q) {
+			// Filters and quotes aren't what was looked for
+			term = strings.Trim(strings.TrimPrefix(term, "tag:"), `"'`)
+			if term != "" {
+				zeroTerms[term]++
+			}
+		}
+	}
+	r.Unique = len(queries)
+	r.Top = sorted(queries, top)
+	r.Zero = sorted(zero, top)
+	r.ZeroTerms = sorted(zeroTerms, top)
+	return r
+}
+
+// sorted lists m by count, then name, keeping the first top (0 = all)
+func sorted(m map[string]int, top int) []Count {
+	list := make([]Count, 0, len(m))
+	for name, n := range m {
+		list = append(list, Count{Name: name, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
+
+// Run prints the report of the site's search log: `kosh search report
+// [--json] [--top N] [--since 7d] [--file path]`
+func Run(args []string) {
+	asJSON := false
+	top := defaultTop
+	var since time.Duration
+	path := ""
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && name != "json" && i+1 < len(args) {
+			value = args[i+1]
+			i++
+		}
+		switch name {
+		case "json":
+			asJSON = true
+		case "top":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("❌ Invalid --top %q\n", value)
+				os.Exit(1)
+			}
+			top = n
+		case "since":
+			d, err := parseSince(value)
+			if err != nil {
+				fmt.Printf("❌ Invalid --since %q (e.g. 7d, 12h)\n", value)
+				os.Exit(1)
+			}
+			since = d
+		case "file":
+			path = value
+		default:
+			fmt.Printf("❌ Unknown flag: %s\n", args[i])
+			os.Exit(1)
+		}
+	}
+	if path == "" {
+		path = Path(config.Load([]string{}).CacheDir)
+	}
+
+	entries, err := Read(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("❌ No search log at %s. Run 'kosh serve --search-log' and use the site search first.\n", path)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Printf("❌ Failed to read the search log: %v\n", err)
+		os.Exit(1)
+	}
+	if since > 0 {
+		cutoff := time.Now().Add(-since)
+		kept := entries[:0]
+		for _, e := range entries {
+			if !e.Time.Before(cutoff) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+
+	report := Summarize(entries, top)
+	if asJSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	report.print()
+}
+
+// parseSince reads a duration, with d for days
+func parseSince(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid days %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (r Report) print() {
+	fmt.Println("🔎 Search Report")
+	fmt.Println("════════════════════════════════════════")
+	fmt.Printf("Searches:        %d (%d distinct)\n", r.Searches, r.Unique)
+	if r.Searches > 0 {
+		fmt.Printf("No results:      %d (%.0f%%)\n", r.ZeroResults, 100*float64(r.ZeroResults)/float64(r.Searches))
+		fmt.Printf("Period:          %s – %s\n", r.From.Local().Format("2006-01-02 15:04"), r.To.Local().Format("2006-01-02 15:04"))
+	}
+	printCounts("📈 Top Queries", r.Top)
+	printCounts("🕳️  Queries Without Results", r.Zero)
+	printCounts("🔤 Terms Without Results", r.ZeroTerms)
+	if len(r.Zero) > 0 {
+		fmt.Println("\nAdd these words to titles, descriptions or tags of the pages people were looking for.")
+	}
+}
+
+func printCounts(title string, counts []Count) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", title)
+	fmt.Println("────────────────────────────────────────")
+	for _, c := range counts {
+		fmt.Printf("%5d  %s\n", c.Count, c.Name)
+	}
+}
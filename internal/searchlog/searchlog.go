@@ -0,0 +1,105 @@
+// Package searchlog records the queries typed into the site search while it
+// is tested on the preview server (`kosh serve --search-log`) and summarizes
+// them for `kosh search report`, to show which pages need better titles,
+// tags or synonyms.
+package searchlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the log file in the cache directory
+const FileName = "search-log.jsonl"
+
+// maxQueryLength caps a logged query, in runes
+const maxQueryLength = 200
+
+// Path returns the log file of a site
+func Path(cacheDir string) string {
+	return filepath.Join(cacheDir, FileName)
+}
+
+// Entry is one search, as the search script reported it
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Query   string    `json:"query"`
+	Results int       `json:"results"`
+	Version string    `json:"version,omitempty"` // Version filter, "all" or empty
+}
+
+// Log appends entries to a JSON lines file
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New creates a log writing to path. The file is created on the first entry.
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the file the log writes to
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append records e. The query is trimmed, collapsed and capped; empty
+// queries are dropped.
+func (l *Log) Append(e Entry) error {
+	e.Query = strings.Join(strings.Fields(e.Query), " ")
+	if e.Query == "" {
+		return nil
+	}
+	if runes := []rune(e.Query); len(runes) > maxQueryLength {
+		e.Query = string(runes[:maxQueryLength])
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the entries of a log file. Lines that aren't entries (a
+// write cut short) are skipped.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Query != "" {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return entries, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package searchlog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogAppendRead(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "cache"))
+	l := New(path)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, e := range []Entry{
+		{Time: at, Query: "  install\n  guide ", Results: 3, Version: "v2"},
+		{Time: at, Query: "   "},
+		{Time: at, Query: strings.Repeat("é", maxQueryLength+10)},
+	} {
+		if err := l.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A cut-short line is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"time":"2026-03-01T12:00:00Z","que`)
+	_ = f.Close()
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Time: at, Query: "install guide", Results: 3, Version: "v2"},
+		{Time: at, Query: strings.Repeat("é", maxQueryLength)},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Read = %+v, want %+v", entries, want)
+	}
+
+	if _, err := Read(filepath.Join(t.TempDir(), FileName)); !os.IsNotExist(err) {
+		t.Errorf("Read of a missing log: err = %v, want not exist", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: day.Add(2 * time.Hour), Query: "Install", Results: 4},
+		{Time: day, Query: "install", Results: 4},
+		{Time: day.Add(time.Hour), Query: "kubernetes helm", Results: 0},
+		{Time: day.Add(3 * time.Hour), Query: "helm", Results: 0},
+		{Time: day.Add(time.Hour), Query: `tag:deploy "helm"`, Results: 0},
+	}
+	r := Summarize(entries, 0)
+
+	if r.Searches != 5 || r.Unique != 4 || r.ZeroResults != 3 {
+		t.Errorf("counts = %d/%d/%d, want 5/4/3", r.Searches, r.Unique, r.ZeroResults)
+	}
+	if !r.From.Equal(day) || !r.To.Equal(day.Add(3*time.Hour)) {
+		t.Errorf("period = %v – %v", r.From, r.To)
+	}
+	if want := (Count{"install", 2}); r.Top[0] != want {
+		t.Errorf("Top[0] = %+v, want %+v", r.Top[0], want)
+	}
+	wantTerms := []Count{{"helm", 3}, {"deploy", 1}, {"kubernetes", 1}}
+	if !reflect.DeepEqual(r.ZeroTerms, wantTerms) {
+		t.Errorf("ZeroTerms = %+v, want %+v", r.ZeroTerms, wantTerms)
+	}
+	if got := Summarize(entries, 1); len(got.Zero) != 1 || len(got.Top) != 1 {
+		t.Errorf("top 1 kept %d and %d queries", len(got.Top), len(got.Zero))
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	for in, want := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "12h": 12 * time.Hour, "0d": 0} {
+		if got, err := parseSince(in); err != nil || got != want {
+			t.Errorf("parseSince(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"d", "-1d", "week"} {
+		if _, err := parseSince(in); err == nil {
+			t.Errorf("parseSince(%q) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"gopkg.in/yaml.v3"
+
+	mdParser "github.com/Kush-Singh-26/kosh/builder/parser"
+)
+
+// AdminPrefix is where the dev admin panel is mounted
+const AdminPrefix = "/__kosh/"
+
+// maxAdminBody caps a saved post or preview request
+const maxAdminBody = 10 << 20
+
+// AdminTokenHeader carries the session token on API requests
+const AdminTokenHeader = "X-Kosh-Admin-Token"
+
+// adminTokenPlaceholder is replaced with the session token in adminPage
+const adminTokenPlaceholder = "{{KOSH_ADMIN_TOKEN}}"
+
+// Admin is the content editor served by `kosh serve --dev --admin`. Saves go
+// straight to the content directory, so the dev watcher rebuilds the site.
+type Admin struct {
+	contentDir string
+	md         goldmark.Markdown
+	token      string          // Required on API requests, embedded in the page
+	hosts      map[string]bool // Host header names the panel answers to
+}
+
+// NewAdmin creates the admin panel for contentDir
+func NewAdmin(contentDir string) *Admin {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	// The email parser renders standalone HTML (inline-styled code, no site
+	// CSS or diagram rendering), which is what a quick preview needs
+	return &Admin{
+		contentDir: contentDir,
+		md:         mdParser.NewEmail(),
+		token:      hex.EncodeToString(key),
+		hosts:      map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true},
+	}
+}
+
+// AllowHost adds the host the server was started with (-host) to the names
+// the panel answers to
+func (a *Admin) AllowHost(host string) {
+	if host != "" {
+		a.hosts[strings.ToLower(strings.Trim(host, "[]"))] = true
+	}
+}
+
+// adminFile is a content file in the list
+type adminFile struct {
+	Path    string `json:"path"` // Relative to the content directory, slash-separated
+	Title   string `json:"title"`
+	Draft   bool   `json:"draft"`
+	Date    string `json:"date,omitempty"`
+	ModTime int64  `json:"modTime"` // Unix nanoseconds
+}
+
+// adminDoc is a content file split for editing
+type adminDoc struct {
+	Path        string `json:"path"`
+	Frontmatter string `json:"frontmatter"`
+	Body        string `json:"body"`
+	ModTime     int64  `json:"modTime"` // On save: the version edited, 0 to skip the check
+}
+
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isLoopback(r.RemoteAddr) {
+		http.Error(w, "the admin panel is only served to this machine", http.StatusForbidden)
+		return
+	}
+	// A page on another domain that rebinds its name to 127.0.0.1 is
+	// same-origin with itself, so the Host header is what gives it away
+	if !a.hosts[requestHost(r)] {
+		http.Error(w, "unknown host", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet && !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+
+	route := strings.TrimPrefix(r.URL.Path, AdminPrefix)
+	if strings.HasPrefix(route, "api/") && subtle.ConstantTimeCompare([]byte(r.Header.Get(AdminTokenHeader)), []byte(a.token)) != 1 {
+		http.Error(w, "missing or invalid admin token; reload the admin page", http.StatusForbidden)
+		return
+	}
+
+	switch route {
+	case "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte(strings.Replace(adminPage, adminTokenPlaceholder, a.token, 1)))
+	case "api/files":
+		a.handleList(w)
+	case "api/file":
+		switch r.Method {
+		case http.MethodGet:
+			a.handleLoad(w, r.URL.Query().Get("path"))
+		case http.MethodPut:
+			a.handleSave(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "api/preview":
+		a.handlePreview(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (a *Admin) handleList(w http.ResponseWriter) {
+	files := []adminFile{}
+	err := filepath.WalkDir(a.contentDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != a.contentDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".md") {
+			return nil
+		}
+		src, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(a.contentDir, p)
+		f := adminFile{Path: filepath.ToSlash(rel), ModTime: info.ModTime().UnixNano()}
+		fm, _, _ := splitPost(strings.ReplaceAll(string(src), "\r\n", "\n"))
+		var meta struct {
+			Title string `yaml:"title"`
+			Draft bool   `yaml:"draft"`
+			Date  string `yaml:"date"`
+		}
+		if yaml.Unmarshal([]byte(fm), &meta) == nil {
+			f.Title, f.Draft, f.Date = meta.Title, meta.Draft, meta.Date
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	writeJSON(w, files)
+}
+
+func (a *Admin) handleLoad(w http.ResponseWriter, rel string) {
+	full, err := a.resolve(rel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	src, err := os.ReadFile(full)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	fm, body, _ := splitPost(strings.ReplaceAll(string(src), "\r\n", "\n"))
+	writeJSON(w, adminDoc{Path: rel, Frontmatter: fm, Body: body, ModTime: info.ModTime().UnixNano()})
+}
+
+// handleSave writes a post back, refusing when the file changed on disk since
+// it was loaded so an edit in another editor is never overwritten
+func (a *Admin) handleSave(w http.ResponseWriter, r *http.Request) {
+	var doc adminDoc
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBody)).Decode(&doc); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	full, err := a.resolve(doc.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var check map[string]any
+	if err := yaml.Unmarshal([]byte(doc.Frontmatter), &check); err != nil {
+		http.Error(w, "frontmatter is not valid YAML: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if doc.ModTime != 0 && info.ModTime().UnixNano() != doc.ModTime {
+		http.Error(w, "the file changed on disk since it was opened; reload it first", http.StatusConflict)
+		return
+	}
+
+	eol := "\n"
+	if old, err := os.ReadFile(full); err == nil && bytes.Contains(old, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+	out := joinPost(doc.Frontmatter, doc.Body)
+	if eol != "\n" {
+		out = strings.ReplaceAll(out, "\n", eol)
+	}
+	if err := writeFileAtomic(full, []byte(out), info.Mode().Perm()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if info, err = os.Stat(full); err == nil {
+		doc.ModTime = info.ModTime().UnixNano()
+	}
+	writeJSON(w, map[string]int64{"modTime": doc.ModTime})
+}
+
+func (a *Admin) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var doc adminDoc
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBody)).Decode(&doc); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var buf bytes.Buffer
+	if err := a.md.Convert([]byte(doc.Body), &buf); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// resolve maps a content-relative path to a .md file inside the content
+// directory
+func (a *Admin) resolve(rel string) (string, error) {
+	if rel == "" || !strings.HasSuffix(rel, ".md") {
+		return "", errors.New("path must be a .md file")
+	}
+	return validatePath(a.contentDir, filepath.FromSlash(rel))
+}
+
+// splitPost separates YAML frontmatter from the markdown body. ok is false
+// when the file has no frontmatter, in which case all of it is the body.
+func splitPost(src string) (frontmatter, body string, ok bool) {
+	src = strings.TrimPrefix(src, "\ufeff")
+	rest, found := strings.CutPrefix(src, "---\n")
+	if !found {
+		return "", src, false
+	}
+	if strings.HasPrefix(rest, "---\n") || rest == "---" {
+		return "", strings.TrimPrefix(strings.TrimPrefix(rest, "---"), "\n"), true
+	}
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n---") {
+			return "", src, false
+		}
+		return strings.TrimSuffix(rest, "\n---"), "", true
+	}
+	return rest[:end], rest[end+len("\n---\n"):], true
+}
+
+// joinPost is the inverse of splitPost. A post without frontmatter stays
+// without it.
+func joinPost(frontmatter, body string) string {
+	frontmatter = strings.TrimRight(frontmatter, "\n")
+	if strings.TrimSpace(frontmatter) == "" {
+		return body
+	}
+	return "---\n" + frontmatter + "\n---\n" + body
+}
+
+// writeFileAtomic replaces path through a temporary file in the same
+// directory, so the watcher never rebuilds from a half-written post
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".kosh-admin-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// Some filesystems keep the old modification time on rename
+	now := time.Now()
+	return os.Chtimes(path, now, now)
+}
+
+// isLoopback reports whether a request comes from this machine, so binding
+// the dev server to 0.0.0.0 doesn't open the editor to the network
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requestHost returns the lowercased host name of the Host header, without
+// its port or IPv6 brackets
+func requestHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// sameOrigin rejects writes from other sites open in the same browser
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return r.Header.Get("Sec-Fetch-Site") == "" || r.Header.Get("Sec-Fetch-Site") == "same-origin"
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,179 @@
+package server
+
+// adminPage is the single-page editor served at /__kosh/
+const adminPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<meta name="kosh-admin-token" content="{{KOSH_ADMIN_TOKEN}}">
+<title>Kosh Admin</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; height: 100vh; display: grid; grid-template-columns: 18rem 1fr; font: 14px/1.5 system-ui, sans-serif; color: #1c1c1e; }
+aside { border-right: 1px solid #ddd; display: flex; flex-direction: column; min-height: 0; background: #fafafa; }
+aside h1 { margin: 0; padding: .75rem 1rem; font-size: 1rem; }
+#filter { margin: 0 1rem .5rem; padding: .4rem .5rem; border: 1px solid #ccc; border-radius: 4px; }
+#files { list-style: none; margin: 0; padding: 0; overflow-y: auto; flex: 1; }
+#files li { padding: .35rem 1rem; cursor: pointer; border-left: 3px solid transparent; }
+#files li:hover { background: #eee; }
+#files li.active { background: #e6ebfb; border-left-color: #3b5bdb; }
+#files .path { display: block; color: #888; font-size: 12px; }
+#files .draft { color: #c77700; font-size: 11px; margin-left: .3rem; }
+main { display: grid; grid-template-rows: auto auto 1fr; min-height: 0; }
+header { display: flex; gap: .75rem; align-items: center; padding: .5rem 1rem; border-bottom: 1px solid #ddd; }
+header .name { font-weight: 600; flex: 1; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+#status { color: #888; }
+#status.error { color: #c92a2a; }
+button { padding: .35rem .9rem; border: 0; border-radius: 4px; background: #3b5bdb; color: #fff; cursor: pointer; }
+button:disabled { background: #aab; cursor: default; }
+#frontmatter { width: 100%; height: 9rem; border: 0; border-bottom: 1px solid #ddd; padding: .75rem 1rem; font: 13px/1.5 ui-monospace, monospace; resize: vertical; background: #f6f8fa; }
+.split { display: grid; grid-template-columns: 1fr 1fr; min-height: 0; }
+#body { border: 0; border-right: 1px solid #ddd; padding: 1rem; font: 14px/1.6 ui-monospace, monospace; resize: none; }
+#preview { padding: 0 1.5rem; overflow-y: auto; }
+#preview img { max-width: 100%; }
+#preview pre { overflow-x: auto; padding: .75rem; border-radius: 4px; }
+.empty { color: #888; padding: 2rem; }
+</style>
+</head>
+<body>
+<aside>
+  <h1>📝 Kosh Admin</h1>
+  <input id="filter" type="search" placeholder="Filter content…">
+  <ul id="files"></ul>
+</aside>
+<main>
+  <header>
+    <span class="name" id="name">No file open</span>
+    <span id="status"></span>
+    <button id="save" disabled>Save</button>
+  </header>
+  <textarea id="frontmatter" spellcheck="false" placeholder="Frontmatter (YAML)" disabled></textarea>
+  <div class="split">
+    <textarea id="body" spellcheck="true" placeholder="Markdown" disabled></textarea>
+    <div id="preview"><p class="empty">Pick a file to edit. Saving writes it to disk and the dev server rebuilds the site.</p></div>
+  </div>
+</main>
+<script>
+(function () {
+  const api = location.pathname.replace(/\/?$/, '/') + 'api/';
+  const $ = (id) => document.getElementById(id);
+  const token = document.querySelector('meta[name="kosh-admin-token"]').content;
+  let files = [], current = null, dirty = false, previewTimer = null;
+
+  function setStatus(text, isError) {
+    $('status').textContent = text;
+    $('status').className = isError ? 'error' : '';
+  }
+
+  function setDirty(d) {
+    dirty = d;
+    $('save').disabled = !current || !d;
+    if (d) setStatus('Unsaved changes');
+  }
+
+  async function request(path, options) {
+    options = options || {};
+    options.headers = { 'X-Kosh-Admin-Token': token };
+    const res = await fetch(api + path, options);
+    if (!res.ok) throw new Error((await res.text()).trim() || res.statusText);
+    return res;
+  }
+
+  function renderList() {
+    const q = $('filter').value.toLowerCase();
+    const list = $('files');
+    list.textContent = '';
+    for (const f of files) {
+      if (q && !(f.path + ' ' + f.title).toLowerCase().includes(q)) continue;
+      const li = document.createElement('li');
+      li.className = current && current.path === f.path ? 'active' : '';
+      const title = document.createElement('span');
+      title.textContent = f.title || f.path;
+      li.appendChild(title);
+      if (f.draft) {
+        const d = document.createElement('span');
+        d.className = 'draft';
+        d.textContent = 'draft';
+        li.appendChild(d);
+      }
+      const p = document.createElement('span');
+      p.className = 'path';
+      p.textContent = f.path;
+      li.appendChild(p);
+      li.onclick = () => open(f.path);
+      list.appendChild(li);
+    }
+  }
+
+  async function loadList() {
+    files = await (await request('files')).json();
+    renderList();
+  }
+
+  async function open(path) {
+    if (dirty && !confirm('Discard unsaved changes?')) return;
+    try {
+      current = await (await request('file?path=' + encodeURIComponent(path))).json();
+    } catch (e) {
+      setStatus(e.message, true);
+      return;
+    }
+    $('name').textContent = current.path;
+    $('frontmatter').value = current.frontmatter;
+    $('body').value = current.body;
+    $('frontmatter').disabled = $('body').disabled = false;
+    setDirty(false);
+    setStatus('');
+    renderList();
+    preview();
+  }
+
+  async function preview() {
+    if (!current) return;
+    try {
+      const res = await request('preview', { method: 'POST', body: JSON.stringify({ body: $('body').value }) });
+      $('preview').innerHTML = await res.text();
+    } catch (e) {
+      setStatus(e.message, true);
+    }
+  }
+
+  async function save() {
+    if (!current) return;
+    const doc = { path: current.path, frontmatter: $('frontmatter').value, body: $('body').value, modTime: current.modTime };
+    try {
+      const res = await request('file', { method: 'PUT', body: JSON.stringify(doc) });
+      current.modTime = (await res.json()).modTime;
+      current.frontmatter = doc.frontmatter;
+      current.body = doc.body;
+      setDirty(false);
+      setStatus('Saved ' + new Date().toLocaleTimeString() + ', rebuilding…');
+      loadList();
+    } catch (e) {
+      setStatus(e.message, true);
+    }
+  }
+
+  $('filter').oninput = renderList;
+  $('save').onclick = save;
+  $('frontmatter').oninput = () => setDirty(true);
+  $('body').oninput = () => {
+    setDirty(true);
+    clearTimeout(previewTimer);
+    previewTimer = setTimeout(preview, 300);
+  };
+  document.addEventListener('keydown', (e) => {
+    if ((e.ctrlKey || e.metaKey) && e.key === 's') {
+      e.preventDefault();
+      save();
+    }
+  });
+  window.addEventListener('beforeunload', (e) => { if (dirty) e.preventDefault(); });
+
+  loadList().catch((e) => setStatus(e.message, true));
+})();
+</script>
+</body>
+</html>
+`
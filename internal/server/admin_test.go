@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPost(t *testing.T) {
+	tests := []struct {
+		name, src, fm, body string
+		ok                  bool
+	}{
+		{"frontmatter", "---\ntitle: A\n---\n# Hi\n", "title: A", "# Hi\n", true},
+		{"no frontmatter", "# Hi\n", "", "# Hi\n", false},
+		{"empty frontmatter", "---\n---\nbody", "", "body", true},
+		{"only frontmatter", "---\ntitle: A\n---", "title: A", "", true},
+		{"unterminated", "---\ntitle: A\n", "", "---\ntitle: A\n", false},
+		{"body rule", "---\na: 1\n---\nx\n---\ny\n", "a: 1", "x\n---\ny\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, ok := splitPost(tt.src)
+			if fm != tt.fm || body != tt.body || ok != tt.ok {
+				t.Errorf("splitPost = %q, %q, %v; want %q, %q, %v", fm, body, ok, tt.fm, tt.body, tt.ok)
+			}
+			if ok && fm != "" && body != "" && joinPost(fm, body) != tt.src {
+				t.Errorf("joinPost doesn't round-trip %q", tt.src)
+			}
+		})
+	}
+}
+
+func newTestAdmin(t *testing.T) (*Admin, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "posts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	post := "---\r\ntitle: Hello\r\ndraft: true\r\n---\r\nFirst *post*\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "posts", "hello.md"), []byte(post), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewAdmin(dir), dir
+}
+
+func adminRequest(a *Admin, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.RemoteAddr = "127.0.0.1:5000"
+	r.Host = "localhost:2604"
+	r.Header.Set(AdminTokenHeader, a.token)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	return w
+}
+
+func TestAdminEditRoundTrip(t *testing.T) {
+	a, dir := newTestAdmin(t)
+
+	w := adminRequest(a, http.MethodGet, AdminPrefix+"api/files", "", nil)
+	var files []adminFile
+	if err := json.Unmarshal(w.Body.Bytes(), &files); err != nil {
+		t.Fatalf("list: %v: %s", err, w.Body)
+	}
+	if len(files) != 1 || files[0].Path != "posts/hello.md" || files[0].Title != "Hello" || !files[0].Draft {
+		t.Fatalf("list = %+v", files)
+	}
+
+	w = adminRequest(a, http.MethodGet, AdminPrefix+"api/file?path=posts/hello.md", "", nil)
+	var doc adminDoc
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("load: %v: %s", err, w.Body)
+	}
+	if doc.Frontmatter != "title: Hello\ndraft: true" || doc.Body != "First *post*\n" {
+		t.Fatalf("load = %+v", doc)
+	}
+
+	doc.Frontmatter = "title: Hello\ndraft: false"
+	doc.Body = "Edited\n"
+	payload, _ := json.Marshal(doc)
+	w = adminRequest(a, http.MethodPut, AdminPrefix+"api/file", string(payload), map[string]string{"Origin": "http://localhost:2604"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("save: %d %s", w.Code, w.Body)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "posts", "hello.md"))
+	if want := "---\r\ntitle: Hello\r\ndraft: false\r\n---\r\nEdited\r\n"; string(got) != want {
+		t.Errorf("saved %q, want %q (line endings kept)", got, want)
+	}
+
+	// Saving again with the stale modTime is a conflict
+	w = adminRequest(a, http.MethodPut, AdminPrefix+"api/file", string(payload), nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("stale save: %d, want 409", w.Code)
+	}
+
+	w = adminRequest(a, http.MethodPost, AdminPrefix+"api/preview", `{"body": "# Title\n\n*x*"}`, nil)
+	if !strings.Contains(w.Body.String(), "<em>x</em>") {
+		t.Errorf("preview = %s", w.Body)
+	}
+}
+
+func TestAdminRejects(t *testing.T) {
+	a, _ := newTestAdmin(t)
+	a.AllowHost("192.168.1.5")
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		remote string
+		header map[string]string
+		want   int
+	}{
+		{"rebound host", http.MethodGet, AdminPrefix + "api/files", "", "", map[string]string{"Host": "attacker.test:2604"}, http.StatusForbidden},
+		{"rebound host page", http.MethodGet, AdminPrefix, "", "", map[string]string{"Host": "attacker.test"}, http.StatusForbidden},
+		{"configured host", http.MethodGet, AdminPrefix + "api/files", "", "", map[string]string{"Host": "192.168.1.5:2604"}, http.StatusOK},
+		{"ipv6 loopback host", http.MethodGet, AdminPrefix + "api/files", "", "", map[string]string{"Host": "[::1]:2604"}, http.StatusOK},
+		{"missing token", http.MethodGet, AdminPrefix + "api/files", "", "", map[string]string{AdminTokenHeader: ""}, http.StatusForbidden},
+		{"wrong token", http.MethodPost, AdminPrefix + "api/preview", `{"body": "x"}`, "", map[string]string{AdminTokenHeader: "guess"}, http.StatusForbidden},
+		{"traversal", http.MethodGet, AdminPrefix + "api/file?path=../secret.md", "", "", nil, http.StatusBadRequest},
+		{"not markdown", http.MethodGet, AdminPrefix + "api/file?path=posts/x.yaml", "", "", nil, http.StatusBadRequest},
+		{"remote client", http.MethodGet, AdminPrefix + "api/files", "", "192.168.1.20:4000", nil, http.StatusForbidden},
+		{"cross origin", http.MethodPut, AdminPrefix + "api/file", `{"path": "posts/hello.md"}`, "", map[string]string{"Origin": "https://evil.test"}, http.StatusForbidden},
+		{"bad yaml", http.MethodPut, AdminPrefix + "api/file", `{"path": "posts/hello.md", "frontmatter": "title: [x"}`, "", nil, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			r.RemoteAddr = "127.0.0.1:5000"
+			if tt.remote != "" {
+				r.RemoteAddr = tt.remote
+			}
+			r.Host = "localhost:2604"
+			r.Header.Set(AdminTokenHeader, a.token)
+			for k, v := range tt.header {
+				if k == "Host" {
+					r.Host = v
+					continue
+				}
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+}
+
+func TestAdminPageToken(t *testing.T) {
+	a, _ := newTestAdmin(t)
+	w := adminRequest(a, http.MethodGet, AdminPrefix, "", map[string]string{AdminTokenHeader: ""})
+	if w.Code != http.StatusOK {
+		t.Fatalf("page: %d %s", w.Code, w.Body)
+	}
+	if want := `<meta name="kosh-admin-token" content="` + a.token + `">`; len(a.token) != 64 || !strings.Contains(w.Body.String(), want) {
+		t.Errorf("page doesn't embed the session token %q", a.token)
+	}
+	if b, _ := newTestAdmin(t); b.token == a.token {
+		t.Error("two admin panels share a token")
+	}
+}
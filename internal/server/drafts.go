@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// Drafts serves the drafts a dev build rendered outside the output directory
+// (config.DevDraftsDir) at config.DevDraftsPath, with an index of them at
+// the prefix itself
+type Drafts struct {
+	fs   afero.Fs
+	dir  string
+	live *LiveReload // Set by Run in dev mode
+}
+
+// NewDrafts serves the drafts rendered to dir on fsys, the build's output
+// filesystem (in memory unless --low-memory)
+func NewDrafts(fsys afero.Fs, dir string) *Drafts {
+	return &Drafts{fs: fsys, dir: dir}
+}
+
+var draftsIndex = template.Must(template.New("drafts").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<meta name="robots" content="noindex">
+<title>Drafts</title>
+<style>
+body { max-width: 60rem; margin: 2rem auto; padding: 0 1rem; font: 14px/1.5 system-ui, sans-serif; color: #1c1c1e; }
+h1 { font-size: 1.4rem; }
+.meta, .empty { color: #888; }
+li { margin: .25rem 0; }
+</style>
+</head>
+<body>
+<h1>Drafts</h1>
+<p class="meta">Rendered by the dev server, never part of the output directory.</p>
+{{ if . }}<ul>{{ range . }}
+<li><a href="{{ . }}">{{ . }}</a></li>{{ end }}
+</ul>{{ else }}<p class="empty">No drafts.</p>{{ end }}
+</body>
+</html>
+`))
+
+func (d *Drafts) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rel := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, config.DevDraftsPath)), "/")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	var content []byte
+	if rel == "" {
+		var buf bytes.Buffer
+		if err := draftsIndex.Execute(&buf, d.list()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		content = buf.Bytes()
+	} else {
+		var err error
+		if content, err = d.page(rel); err != nil {
+			http.Error(w, "404 - No draft at "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+	}
+	if d.live != nil {
+		content = injectLiveReload(content)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(content)
+}
+
+// page reads a draft the way the output directory is served: the path
+// itself, with .html added, or its index.html
+func (d *Drafts) page(rel string) ([]byte, error) {
+	var err error
+	for _, candidate := range []string{rel, rel + ".html", rel + "/index.html"} {
+		if !strings.HasSuffix(candidate, ".html") {
+			continue
+		}
+		var content []byte
+		if content, err = afero.ReadFile(d.fs, filepath.Join(d.dir, filepath.FromSlash(candidate))); err == nil {
+			return content, nil
+		}
+	}
+	return nil, err
+}
+
+// list returns the URLs of the rendered drafts, sorted
+func (d *Drafts) list() []string {
+	var urls []string
+	_ = afero.Walk(d.fs, d.dir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(p, ".html") {
+			return nil
+		}
+		if rel, err := filepath.Rel(d.dir, p); err == nil {
+			urls = append(urls, config.DevDraftsPath+filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	slices.Sort(urls)
+	return urls
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestDrafts(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/cache/drafts/posts/wip.html", []byte("<html><head></head><body>wip</body></html>"), 0644)
+	_ = afero.WriteFile(fs, "/cache/drafts/about/index.html", []byte("<p>about</p>"), 0644)
+	drafts := NewDrafts(fs, "/cache/drafts")
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		drafts.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+	for _, path := range []string{"/drafts/posts/wip.html", "/drafts/posts/wip"} {
+		if code, body := get(path); code != http.StatusOK || !strings.Contains(body, "wip") {
+			t.Errorf("%s = %d %q", path, code, body)
+		}
+	}
+	if code, body := get("/drafts/about/"); code != http.StatusOK || !strings.Contains(body, "about") {
+		t.Errorf("/drafts/about/ = %d %q", code, body)
+	}
+	for _, path := range []string{"/drafts/missing.html", "/drafts/../../etc/passwd"} {
+		if code, _ := get(path); code != http.StatusNotFound {
+			t.Errorf("%s = %d, want 404", path, code)
+		}
+	}
+
+	_, index := get("/drafts/")
+	about, wip := strings.Index(index, `<a href="/drafts/about/index.html">`), strings.Index(index, `<a href="/drafts/posts/wip.html">`)
+	if about < 0 || wip < about {
+		t.Errorf("index doesn't list the drafts in order:\n%s", index)
+	}
+
+	drafts.live = NewLiveReload()
+	if _, body := get("/drafts/posts/wip.html"); !strings.Contains(body, liveReloadScript+"</head>") {
+		t.Error("draft served without live reload")
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/Kush-Singh-26/kosh/builder/events"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// LiveReloadPath is the dev server's live reload WebSocket
+const LiveReloadPath = "/__livereload"
+
+// reloadMessage is pushed to the open pages after a rebuild
+type reloadMessage struct {
+	Type  string `json:"type"`            // "reload", "css" (swap the stylesheets) or "error"
+	Path  string `json:"path,omitempty"`  // The file the rebuild was for
+	Error string `json:"error,omitempty"` // Why the build failed
+}
+
+// LiveReload tells the pages open on the dev server when a build finishes:
+// they reload, or swap their stylesheets when only a stylesheet changed.
+// Pages get its script injected as they are served.
+type LiveReload struct {
+	mu      sync.Mutex
+	clients map[chan reloadMessage]struct{}
+}
+
+// NewLiveReload creates a live reload endpoint with no pages connected
+func NewLiveReload() *LiveReload {
+	return &LiveReload{clients: make(map[chan reloadMessage]struct{})}
+}
+
+// Watch pushes every build finished on bus to the open pages
+func (l *LiveReload) Watch(bus *events.Bus) (unsubscribe func()) {
+	return events.Subscribe(bus, func(e events.BuildFinished) {
+		l.broadcast(messageFor(e))
+	})
+}
+
+// messageFor decides what the pages do after a build: a failed build leaves
+// them as they are, and a rebuild for a stylesheet alone swaps styles. Full
+// builds, including those run when a bundle's hashed name changed, reload.
+func messageFor(e events.BuildFinished) reloadMessage {
+	switch {
+	case e.Err != nil:
+		return reloadMessage{Type: "error", Path: e.Changed, Error: e.Err.Error()}
+	case e.Changed != "" && utils.IsStylesheet(e.Changed):
+		return reloadMessage{Type: "css", Path: e.Changed}
+	default:
+		return reloadMessage{Type: "reload", Path: e.Changed}
+	}
+}
+
+func (l *LiveReload) broadcast(msg reloadMessage) {
+	l.mu.Lock()
+	for client := range l.clients {
+		select {
+		case client <- msg:
+		default: // The page is still busy with earlier messages
+		}
+	}
+	l.mu.Unlock()
+
+	// Themes that listen on /events themselves reload too
+	if msg.Type == "reload" {
+		notifySSEClients()
+	}
+}
+
+func (l *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			if !sameOrigin(r) {
+				return fmt.Errorf("cross-origin live reload rejected")
+			}
+			return nil
+		},
+		Handler: l.serveConn,
+	}
+	ws.ServeHTTP(w, r)
+}
+
+// serveConn sends messages to one page until it goes away
+func (l *LiveReload) serveConn(ws *websocket.Conn) {
+	client := make(chan reloadMessage, 4)
+	l.mu.Lock()
+	l.clients[client] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.clients, client)
+		l.mu.Unlock()
+	}()
+
+	// Pages don't send anything; reading notices when they close
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case msg := <-client:
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// liveReloadScript connects a page to LiveReloadPath and reconnects while
+// the server restarts. Stylesheets are swapped for the ones of the rebuilt
+// page, since hashed file names change with their content; the new one is
+// loaded before the old one is removed, so the page doesn't flash.
+const liveReloadScript = `<script>(() => {
+	window.koshLiveReload = true;
+	const url = (location.protocol === "https:" ? "wss://" : "ws://") + location.host + "` + LiveReloadPath + `";
+	const sameOrigin = (link) => new URL(link.href, location.href).origin === location.origin;
+	const stylesheets = (doc) => [...doc.querySelectorAll('link[rel="stylesheet"]')].filter(sameOrigin);
+	async function swapStylesheets() {
+		let fresh;
+		try {
+			const html = await (await fetch(location.href, { cache: "no-store" })).text();
+			fresh = stylesheets(new DOMParser().parseFromString(html, "text/html"));
+		} catch {
+			return location.reload();
+		}
+		const current = stylesheets(document);
+		if (fresh.length !== current.length) return location.reload();
+		current.forEach((link, i) => {
+			const href = new URL(fresh[i].getAttribute("href"), location.href);
+			href.searchParams.set("livereload", Date.now());
+			const next = link.cloneNode();
+			next.href = href.href;
+			next.onload = next.onerror = () => link.remove();
+			link.after(next);
+		});
+	}
+	let retries = 0;
+	function connect() {
+		const ws = new WebSocket(url);
+		ws.onopen = () => {
+			if (retries > 0) location.reload();
+		};
+		ws.onmessage = (event) => {
+			const msg = JSON.parse(event.data);
+			if (msg.type === "css") swapStylesheets();
+			else if (msg.type === "reload") location.reload();
+			else if (msg.type === "error") console.error("kosh: build failed:", msg.error);
+		};
+		ws.onclose = () => setTimeout(connect, Math.min(250 * 2 ** retries++, 5000));
+	}
+	connect();
+})();</script>`
+
+// injectLiveReload adds the live reload script to an HTML page, at the end
+// of its head so it runs before the theme's scripts
+func injectLiveReload(page []byte) []byte {
+	// Only ASCII is lowered, so offsets in lower are offsets in page
+	lower := make([]byte, len(page))
+	for i, c := range page {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	for _, tag := range []string{"</head>", "</body>"} {
+		if i := bytes.LastIndex(lower, []byte(tag)); i >= 0 {
+			out := make([]byte, 0, len(page)+len(liveReloadScript))
+			out = append(out, page[:i]...)
+			out = append(out, liveReloadScript...)
+			return append(out, page[i:]...)
+		}
+	}
+	return append(page[:len(page):len(page)], liveReloadScript...)
+}
+
+// serveHTML writes the page at path with the live reload script. It
+// reports false when the file can't be read, leaving the response alone.
+func (l *LiveReload) serveHTML(w http.ResponseWriter, r *http.Request, path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, path, info.ModTime(), bytes.NewReader(injectLiveReload(content)))
+	return true
+}
+
+// htmlPage returns the HTML file http.FileServer would answer a request
+// with. Requests it redirects (a directory without its slash, an explicit
+// index.html) are left to it.
+func htmlPage(rawPath, fullPath string, info os.FileInfo) (string, bool) {
+	if info.IsDir() {
+		if !strings.HasSuffix(rawPath, "/") {
+			return "", false
+		}
+		fullPath = filepath.Join(fullPath, "index.html")
+	} else if strings.HasSuffix(rawPath, "/index.html") {
+		return "", false
+	}
+	if !strings.EqualFold(filepath.Ext(fullPath), ".html") {
+		return "", false
+	}
+	return fullPath, true
+}
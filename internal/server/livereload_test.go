@@ -0,0 +1,105 @@
+package server
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/Kush-Singh-26/kosh/builder/events"
+)
+
+func TestMessageFor(t *testing.T) {
+	for _, tc := range []struct {
+		event events.BuildFinished
+		want  string
+	}{
+		{events.BuildFinished{}, "reload"},
+		{events.BuildFinished{Changed: "content/post.md"}, "reload"},
+		{events.BuildFinished{Changed: "themes/blog/static/css/layout.css"}, "css"},
+		{events.BuildFinished{Changed: "assets/scss/_buttons.scss"}, "css"},
+		{events.BuildFinished{Changed: "static/js/main.js"}, "reload"},
+		{events.BuildFinished{Changed: "static/css/main.css", Err: errors.New("boom")}, "error"},
+	} {
+		if got := messageFor(tc.event); got.Type != tc.want {
+			t.Errorf("messageFor(%+v) = %q, want %q", tc.event, got.Type, tc.want)
+		}
+	}
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	got := string(injectLiveReload([]byte("<html><HEAD><title>x</title></HEAD><body>ı</body></html>")))
+	if !strings.HasPrefix(got, "<html><HEAD><title>x</title>"+liveReloadScript+"</HEAD>") {
+		t.Errorf("script not at the end of the head:\n%s", got)
+	}
+	if got := string(injectLiveReload([]byte("<p>fragment"))); got != "<p>fragment"+liveReloadScript {
+		t.Errorf("fragment = %q", got)
+	}
+}
+
+func TestHTMLPage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "post.html"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dirInfo, _ := os.Stat(dir)
+	fileInfo, _ := os.Stat(filepath.Join(dir, "post.html"))
+
+	if page, ok := htmlPage("/docs/", dir, dirInfo); !ok || page != filepath.Join(dir, "index.html") {
+		t.Errorf("directory = %q, %v", page, ok)
+	}
+	if _, ok := htmlPage("/docs", dir, dirInfo); ok {
+		t.Error("a directory without its slash is redirected, not injected")
+	}
+	if _, ok := htmlPage("/post.html", filepath.Join(dir, "post.html"), fileInfo); !ok {
+		t.Error("post.html not injected")
+	}
+}
+
+func TestLiveReloadPushesBuilds(t *testing.T) {
+	live := NewLiveReload()
+	bus := events.New()
+	defer live.Watch(bus)()
+	srv := httptest.NewServer(live)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	if _, err := websocket.Dial(wsURL, "", "http://evil.example"); err == nil {
+		t.Error("cross-origin connection accepted")
+	}
+	ws, err := websocket.Dial(wsURL, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ws.Close() }()
+
+	// The server registers the page after the handshake
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		live.mu.Lock()
+		n := len(live.clients)
+		live.mu.Unlock()
+		if n == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	bus.Publish(events.BuildFinished{Changed: "static/css/main.css"})
+	bus.Publish(events.BuildFinished{Changed: "content/post.md"})
+	_ = ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, want := range []reloadMessage{{Type: "css", Path: "static/css/main.css"}, {Type: "reload", Path: "content/post.md"}} {
+		var got reloadMessage
+		if err := websocket.JSON.Receive(ws, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("message = %+v, want %+v", got, want)
+		}
+	}
+}
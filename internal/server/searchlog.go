@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/internal/searchlog"
+)
+
+// SearchLogPath is where the search script reports queries when the preview
+// server runs with --search-log
+const SearchLogPath = "/__search-log"
+
+// maxSearchLogBody caps a reported search
+const maxSearchLogBody = 4 << 10
+
+// SearchLog records the searches made on the preview server. It only answers
+// this machine, so a site opened from the network doesn't fill the log.
+type SearchLog struct {
+	log *searchlog.Log
+}
+
+// NewSearchLog creates the endpoint, appending to the log file at path
+func NewSearchLog(path string) *SearchLog {
+	return &SearchLog{log: searchlog.New(path)}
+}
+
+// Path returns the file searches are written to
+func (s *SearchLog) Path() string {
+	return s.log.Path()
+}
+
+// searchReport is what the search script posts
+type searchReport struct {
+	Query   string `json:"query"`
+	Results int    `json:"results"`
+	Version string `json:"version"`
+}
+
+func (s *SearchLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isLoopback(r.RemoteAddr) {
+		http.Error(w, "the search log only records this machine", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// The search script probes the endpoint before reporting
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		var report searchReport
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSearchLogBody)).Decode(&report); err != nil {
+			http.Error(w, "invalid search report", http.StatusBadRequest)
+			return
+		}
+		if report.Results < 0 {
+			report.Results = 0
+		}
+		entry := searchlog.Entry{
+			Time:    time.Now().UTC(),
+			Query:   report.Query,
+			Results: report.Results,
+			Version: report.Version,
+		}
+		if err := s.log.Append(entry); err != nil {
+			http.Error(w, "failed to record search", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
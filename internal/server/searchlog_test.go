@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/internal/searchlog"
+)
+
+func TestSearchLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), searchlog.FileName)
+	s := NewSearchLog(path)
+	request := func(method, remote, body string, header map[string]string) int {
+		r := httptest.NewRequest(method, SearchLogPath, strings.NewReader(body))
+		r.RemoteAddr = remote
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w.Code
+	}
+	local := "127.0.0.1:5000"
+
+	if code := request(http.MethodHead, local, "", nil); code != http.StatusNoContent {
+		t.Errorf("probe = %d, want 204", code)
+	}
+	if code := request(http.MethodPost, local, `{"query":"helm chart","results":0,"version":"v2"}`, nil); code != http.StatusNoContent {
+		t.Errorf("report = %d, want 204", code)
+	}
+	if code := request(http.MethodPost, "192.168.1.20:5000", `{"query":"remote"}`, nil); code != http.StatusForbidden {
+		t.Errorf("remote report = %d, want 403", code)
+	}
+	if code := request(http.MethodPost, local, `{"query":"other site"}`, map[string]string{"Origin": "https://evil.example"}); code != http.StatusForbidden {
+		t.Errorf("cross-origin report = %d, want 403", code)
+	}
+	if code := request(http.MethodPost, local, `{"query":`+`"`+strings.Repeat("x", maxSearchLogBody)+`"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("oversized report = %d, want 400", code)
+	}
+	if code := request(http.MethodDelete, local, "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE = %d, want 405", code)
+	}
+
+	entries, err := searchlog.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Query != "helm chart" || entries[0].Results != 0 || entries[0].Version != "v2" {
+		t.Errorf("logged %+v, want the one local search", entries)
+	}
+}
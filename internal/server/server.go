@@ -9,18 +9,26 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/generators"
+	"github.com/Kush-Singh-26/kosh/builder/logging"
 )
 
-func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.BuildConfig) {
+// Run serves outputDir with live reload. With live (dev mode), pages get its
+// script and reload when a build finishes; otherwise changes to outputDir
+// are announced on /events. admin, when not nil, is mounted at AdminPrefix,
+// drafts at config.DevDraftsPath and status at config.DevStatusPath.
+// Fingerprinted assets get the policy's Cache-Control value; everything else
+// is revalidated on each request so edits show up.
+func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.BuildConfig, policy config.CacheControlConfig, admin, searchLog http.Handler, drafts *Drafts, status *StatusPage, live *LiveReload) {
 	fs := flag.NewFlagSet("serve", flag.ExitOnError)
 	host := fs.String("host", "localhost", "The host/IP to bind to")
 	port := fs.String("port", "2604", "The port to listen on")
 
 	_ = fs.Bool("drafts", false, "Include drafts (handled by builder)")
+	_ = fs.Bool("future", false, "Include future posts (handled by builder)")
 	_ = fs.String("baseurl", "", "Base URL (handled by builder)")
 	_ = fs.Bool("compress", false, "Enable compression (handled by builder)")
 
@@ -46,18 +54,40 @@ func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.
 		debounceDuration = buildCfg.DebounceDuration
 	}
 
-	startWatcherWithConfig(staticDir, debounceDuration)
+	if live == nil {
+		startWatcherWithConfig(staticDir, debounceDuration)
+	}
 	defer stopWatcher()
 
 	go func() {
 		<-ctx.Done()
-		fmt.Println("\n🛑 Shutting down server...")
+		logging.Statusf("\n🛑 Shutting down server...")
 		stopWatcher()
 	}()
 
 	fileServer := http.FileServer(http.Dir(staticDir))
 
 	http.HandleFunc("/events", handleSSE)
+	if live != nil {
+		http.Handle(LiveReloadPath, live)
+	}
+	if admin != nil {
+		if a, ok := admin.(*Admin); ok {
+			a.AllowHost(*host)
+		}
+		http.Handle(AdminPrefix, admin)
+	}
+	if searchLog != nil {
+		http.Handle(SearchLogPath, searchLog)
+	}
+	if drafts != nil {
+		drafts.live = live
+		http.Handle(config.DevDraftsPath, drafts)
+	}
+	if status != nil {
+		status.live = live
+		http.Handle(config.DevStatusPath, status)
+	}
 
 	http.HandleFunc("/", gzipHandler(func(w http.ResponseWriter, r *http.Request) {
 		rawPath := r.URL.Path
@@ -76,6 +106,9 @@ func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.
 				w.WriteHeader(http.StatusNotFound)
 				notFoundPath := filepath.Join(staticDir, "404.html")
 				if content, readErr := os.ReadFile(notFoundPath); readErr == nil {
+					if live != nil {
+						content = injectLiveReload(content)
+					}
 					_, _ = w.Write(content)
 				} else {
 					_, _ = w.Write([]byte("404 - Page Not Found"))
@@ -87,21 +120,31 @@ func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.
 			return
 		}
 
-		filename := filepath.Base(normalizedPath)
-		if isHashedAsset(filename) {
-			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-		} else if fileInfo.IsDir() || strings.HasSuffix(filename, ".html") {
-			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate")
-			w.Header().Set("Pragma", "no-cache")
-			w.Header().Set("Expires", "0")
-		} else {
-			w.Header().Set("Cache-Control", "public, max-age=60")
+		if rel, err := filepath.Rel(staticDir, fullPath); err == nil {
+			if fileInfo.IsDir() {
+				rel += "/"
+			}
+			if generators.CacheClass(rel) != generators.CacheAssets {
+				w.Header().Set("Cache-Control", "no-cache")
+			} else if value := generators.CacheControlFor(policy, rel); value != "" {
+				w.Header().Set("Cache-Control", value)
+			}
+			if contentType := generators.ContentTypeFor(rel); contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
 		}
 
+		if live != nil {
+			if page, ok := htmlPage(rawPath, fullPath, fileInfo); ok && live.serveHTML(w, r, page) {
+				return
+			}
+		}
 		fileServer.ServeHTTP(w, r)
 	}))
 
-	go broadcastReload()
+	if live == nil {
+		go broadcastReload()
+	}
 
 	httpServer := &http.Server{
 		Addr:    addr,
@@ -110,7 +153,7 @@ func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.
 
 	go func() {
 		<-ctx.Done()
-		fmt.Println("\n🛑 Shutting down HTTP server...")
+		logging.Statusf("\n🛑 Shutting down HTTP server...")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
@@ -118,14 +161,30 @@ func Run(ctx context.Context, args []string, outputDir string, buildCfg *config.
 		}
 	}()
 
-	fmt.Printf("🌍 Serving on http://%s\n", addr)
+	logging.Statusf("🌍 Serving on http://%s", addr)
 	if *host == "0.0.0.0" {
-		fmt.Println("   (Accessible on your local network)")
+		logging.Statusf("   (Accessible on your local network)")
+	}
+	if live != nil {
+		logging.Statusf("   (Live reload over WebSocket at %s)", LiveReloadPath)
+	} else {
+		logging.Statusf("   (Auto-reload enabled via /events)")
+	}
+	if admin != nil {
+		logging.Statusf("🛠️  Admin panel on http://%s%s", addr, AdminPrefix)
+	}
+	if drafts != nil {
+		logging.Statusf("📝 Drafts on http://%s%s", addr, config.DevDraftsPath)
+	}
+	if status != nil {
+		logging.Statusf("📋 Content status on http://%s%s", addr, config.DevStatusPath)
+	}
+	if l, ok := searchLog.(*SearchLog); ok {
+		logging.Statusf("🔎 Logging searches to %s (see 'kosh search report')", l.Path())
 	}
-	fmt.Println("   (Auto-reload enabled via /events)")
 
 	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
-	fmt.Println("✅ Server stopped.")
+	logging.Statusf("✅ Server stopped.")
 }
@@ -37,13 +37,18 @@ func handleSSE(w http.ResponseWriter, r *http.Request) {
 
 func broadcastReload() {
 	for range reloadChan {
-		clientMu.Lock()
-		for clientChan := range clients {
-			select {
-			case clientChan <- struct{}{}:
-			default:
-			}
+		notifySSEClients()
+	}
+}
+
+// notifySSEClients tells every /events client to reload
+func notifySSEClients() {
+	clientMu.Lock()
+	for clientChan := range clients {
+		select {
+		case clientChan <- struct{}{}:
+		default:
 		}
-		clientMu.Unlock()
 	}
+	clientMu.Unlock()
 }
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// StatusPage serves the content status page a dev build wrote outside the
+// output directory (config.DevStatusFile) at config.DevStatusPath
+type StatusPage struct {
+	path string
+	live *LiveReload // Set by Run in dev mode
+}
+
+// NewStatusPage serves the status page written to path
+func NewStatusPage(path string) *StatusPage {
+	return &StatusPage{path: path}
+}
+
+func (s *StatusPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.Trim(strings.TrimPrefix(r.URL.Path, config.DevStatusPath), "/") != "" {
+		http.NotFound(w, r)
+		return
+	}
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		http.Error(w, "404 - No status page yet (statusPage.disabled, or the first build hasn't finished)", http.StatusNotFound)
+		return
+	}
+	if s.live != nil {
+		content = injectLiveReload(content)
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(content)
+}
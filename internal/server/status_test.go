@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatusPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.html")
+	status := NewStatusPage(path)
+	get := func(target string) (int, string) {
+		rec := httptest.NewRecorder()
+		status.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, _ := get("/__status/"); code != http.StatusNotFound {
+		t.Errorf("before the first build: %d, want 404", code)
+	}
+	if err := os.WriteFile(path, []byte("<html><head></head><body>2 drafts</body></html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := get("/__status/"); code != http.StatusOK || !strings.Contains(body, "2 drafts") {
+		t.Errorf("/__status/ = %d %q", code, body)
+	}
+	if code, _ := get("/__status/other.html"); code != http.StatusNotFound {
+		t.Errorf("/__status/other.html = %d, want 404", code)
+	}
+}
@@ -70,21 +70,3 @@ func gzipHandler(next http.HandlerFunc) http.HandlerFunc {
 		next(gzw, r)
 	}
 }
-
-func isHashedAsset(filename string) bool {
-	parts := strings.Split(filename, ".")
-	if len(parts) >= 3 {
-		hashPart := parts[len(parts)-2]
-		if len(hashPart) >= 8 && len(hashPart) <= 12 {
-			isHex := true
-			for _, c := range hashPart {
-				if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
-					isHex = false
-					break
-				}
-			}
-			return isHex
-		}
-	}
-	return false
-}
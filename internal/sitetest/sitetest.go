@@ -0,0 +1,237 @@
+// Package sitetest builds a site into a temporary directory and compares
+// selected output files with committed golden snapshots (`kosh test`), so an
+// upgrade of Kosh that changes the output is caught before it ships
+package sitetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/run"
+	"github.com/Kush-Singh-26/kosh/internal/templatetest"
+)
+
+// DefaultDir holds the golden snapshots, relative to the site root. Files
+// mirror their path in the output directory.
+const DefaultDir = "tests/golden"
+
+// Result is the outcome of one golden file
+type Result struct {
+	Path    string // Relative to the output directory, slash-separated
+	Status  templatetest.Status
+	Message string
+}
+
+// normalizers replace what changes between builds of the same site: asset
+// fingerprints, digests, build timestamps and dates of the build itself
+var normalizers = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\.[A-Z0-9]{8}\.(css|js|mjs)\b`), ".HASH.$1"},
+	{regexp.MustCompile(`\bsha(256|384|512)-[A-Za-z0-9+/]+=*`), "sha$1-HASH"},
+	{regexp.MustCompile(`\b[0-9a-f]{16,128}\b`), "HASH"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})\b`), "DATETIME"},
+	{regexp.MustCompile(`\b(Mon|Tue|Wed|Thu|Fri|Sat|Sun), \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} ([+-]\d{4}|[A-Z]{3})`), "DATETIME"},
+	{regexp.MustCompile(`\b1\d{9}(\d{3})?\b`), "TIMESTAMP"},
+}
+
+// textExts are compared as normalized text; other files byte for byte
+var textExts = map[string]bool{
+	".html": true, ".htm": true, ".xml": true, ".json": true, ".txt": true, ".css": true,
+	".js": true, ".mjs": true, ".webmanifest": true, ".svg": true, ".md": true, "": true,
+}
+
+// Normalize rewrites the parts of a build output that differ between builds
+// of unchanged content, for files compared as text
+func Normalize(name string, data []byte) []byte {
+	if !textExts[strings.ToLower(path.Ext(name))] {
+		return data
+	}
+	for _, n := range normalizers {
+		data = n.re.ReplaceAll(data, []byte(n.repl))
+	}
+	return data
+}
+
+// Run handles `kosh test [build flags] [output paths...] [--dir <dir>]
+// [--update]` and reports whether every golden file matched
+func Run(ctx context.Context, args []string) bool {
+	dir := DefaultDir
+	update := false
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--dir", "-dir":
+			if i+1 < len(args) {
+				dir = args[i+1]
+				i++
+			}
+		case "--update", "-update":
+			update = true
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	buildArgs, paths := config.SplitArgs(rest)
+
+	goldens, err := goldenFiles(dir)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	selected := goldens
+	if len(paths) > 0 {
+		selected = nil
+		for _, p := range paths {
+			selected = append(selected, strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "/"))
+		}
+	}
+	if len(selected) == 0 {
+		fmt.Printf("⚠️  No golden files in %s. Add some with: kosh test --update index.html\n", dir)
+		return true
+	}
+
+	tmp, err := os.MkdirTemp("", "kosh-test-")
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	fmt.Printf("🧪 Building the site into %s...\n", tmp)
+	cfg := config.Load(buildArgs)
+	cfg.OutputDir = filepath.Join(tmp, "public")
+	cfg.CacheDir = filepath.Join(tmp, "cache")
+	b := run.NewBuilderWithConfig(cfg)
+	err = b.Build(ctx)
+	b.Close()
+	if err != nil {
+		fmt.Printf("❌ Build failed: %v\n", err)
+		return false
+	}
+
+	results := Compare(cfg.OutputDir, dir, selected, update)
+	failed := 0
+	fmt.Println()
+	for _, r := range results {
+		switch r.Status {
+		case templatetest.Passed:
+			fmt.Printf("   ✅ %s\n", r.Path)
+		case templatetest.Updated:
+			fmt.Printf("   📝 %s\n", r.Path)
+		default:
+			failed++
+			fmt.Printf("   ❌ %s: %s\n", r.Path, r.Message)
+		}
+	}
+	switch {
+	case failed > 0:
+		fmt.Printf("\n❌ %d of %d golden files failed (--update accepts the new output)\n", failed, len(results))
+		return false
+	case update:
+		fmt.Printf("\n📝 Updated %d golden files in %s\n", len(results), dir)
+	default:
+		fmt.Printf("\n✅ %d golden files match\n", len(results))
+	}
+	return true
+}
+
+// goldenFiles lists the snapshots in dir as output paths
+func goldenFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Compare checks each output path of outputDir against its snapshot in
+// goldenDir, both normalized; update writes the normalized output instead
+func Compare(outputDir, goldenDir string, paths []string, update bool) []Result {
+	results := make([]Result, 0, len(paths))
+	for _, p := range paths {
+		res := Result{Path: p}
+		got, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(p)))
+		if err != nil {
+			res.Status, res.Message = templatetest.Errored, "not in the build output"
+			results = append(results, res)
+			continue
+		}
+		got = Normalize(p, got)
+		golden := filepath.Join(goldenDir, filepath.FromSlash(p))
+
+		if update {
+			err := os.MkdirAll(filepath.Dir(golden), 0755)
+			if err == nil {
+				err = os.WriteFile(golden, got, 0644)
+			}
+			if err != nil {
+				res.Status, res.Message = templatetest.Errored, err.Error()
+			} else {
+				res.Status = templatetest.Updated
+			}
+			results = append(results, res)
+			continue
+		}
+
+		want, err := os.ReadFile(golden)
+		switch {
+		case err != nil:
+			res.Status, res.Message = templatetest.Errored, "no golden file; run with --update to create it"
+		case !textExts[strings.ToLower(path.Ext(p))]:
+			if !bytes.Equal(want, got) {
+				res.Status, res.Message = templatetest.Failed, "binary content differs"
+			}
+		default:
+			if line, w, g, differs := templatetest.FirstDiff(Normalize(p, want), got); differs {
+				res.Status = templatetest.Failed
+				col, w, g := around(w, g)
+				res.Message = fmt.Sprintf("line %d, column %d differs\n        want: %s\n        got:  %s", line, col, w, g)
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// around cuts two differing lines down to the text near their first
+// difference, since minified pages are a few very long lines. col is 1-based.
+func around(want, got string) (col int, w, g string) {
+	i := 0
+	for i < len(want) && i < len(got) && want[i] == got[i] {
+		i++
+	}
+	cut := func(s string) string {
+		start, end := max(i-60, 0), min(i+60, len(s))
+		out := s[start:end]
+		if start > 0 {
+			out = "…" + out
+		}
+		if end < len(s) {
+			out += "…"
+		}
+		return out
+	}
+	return i + 1, cut(want), cut(got)
+}
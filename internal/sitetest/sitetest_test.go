@@ -0,0 +1,71 @@
+package sitetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kush-Singh-26/kosh/internal/templatetest"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"asset fingerprint", `<link href=/static/css/layout.55IG56C6.css>`, `<link href=/static/css/layout.HASH.css>`},
+		{"hex digest", `data-id="3f2a9c1be0d4a7f6"`, `data-id="HASH"`},
+		{"sri", `integrity="sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC"`, `integrity="sha384-HASH"`},
+		{"rfc3339", `<updated>2026-10-16T20:05:59Z</updated>`, `<updated>DATETIME</updated>`},
+		{"rss date", `<lastBuildDate>Fri, 16 Oct 2026 20:05:59 +0000</lastBuildDate>`, `<lastBuildDate>DATETIME</lastBuildDate>`},
+		{"build version", `app.js?v=1792180000`, `app.js?v=TIMESTAMP`},
+		{"content kept", `<time>2024-03-01</time> 42 posts, DEADBEEF`, `<time>2024-03-01</time> 42 posts, DEADBEEF`},
+	}
+	for _, tt := range tests {
+		if got := string(Normalize("page.html", []byte(tt.in))); got != tt.want {
+			t.Errorf("%s: Normalize() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := string(Normalize("card.webp", []byte("1792180000"))); got != "1792180000" {
+		t.Errorf("Normalize() changed a binary file: %q", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	root := t.TempDir()
+	out, golden := filepath.Join(root, "public"), filepath.Join(root, "golden")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(out, "index.html"), "<link href=/a.AAAA1111.css><p>Home</p>")
+	write(filepath.Join(out, "posts/hello.html"), "<p>Hello</p>")
+	write(filepath.Join(out, "logo.png"), "\x89PNG1")
+
+	paths := []string{"index.html", "posts/hello.html", "logo.png"}
+	for _, r := range Compare(out, golden, paths, true) {
+		if r.Status != templatetest.Updated {
+			t.Fatalf("Compare(update) %s = %d (%s)", r.Path, r.Status, r.Message)
+		}
+	}
+
+	// A new build with other fingerprints still matches; changed content doesn't
+	write(filepath.Join(out, "index.html"), "<link href=/a.BBBB2222.css><p>Home</p>\n")
+	write(filepath.Join(out, "posts/hello.html"), "<p>Hello, world</p>")
+	write(filepath.Join(out, "logo.png"), "\x89PNG2")
+	want := map[string]templatetest.Status{
+		"index.html":       templatetest.Passed,
+		"posts/hello.html": templatetest.Failed,
+		"logo.png":         templatetest.Failed,
+		"gone.html":        templatetest.Errored,
+	}
+	for _, r := range Compare(out, golden, append(paths, "gone.html"), false) {
+		if r.Status != want[r.Path] {
+			t.Errorf("Compare() %s = %d (%s), want %d", r.Path, r.Status, r.Message, want[r.Path])
+		}
+	}
+}
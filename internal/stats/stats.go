@@ -0,0 +1,275 @@
+// Package stats reports content analytics from the build cache
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+)
+
+// wordsPerMinute matches the reading time estimate of the post pipeline
+const wordsPerMinute = 120
+
+// topTags is how many tags the text report lists
+const topTags = 15
+
+// Page is what the report needs to know about one cached post
+type Page struct {
+	Path        string // Relative to the content directory
+	Link        string // Absolute URL
+	Date        time.Time
+	Tags        []string
+	Words       int
+	ReadingTime int // Minutes
+	Draft       bool
+	HTML        []byte
+}
+
+// Count is a named number, in report order
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Report is the content analytics of a site
+type Report struct {
+	Posts          int      `json:"posts"`
+	Drafts         int      `json:"drafts"`
+	Words          int      `json:"words"`
+	AvgReadingTime float64  `json:"avgReadingTime"` // Minutes
+	PostsPerMonth  []Count  `json:"postsPerMonth"`  // YYYY-MM, newest first
+	WordsBySection []Count  `json:"wordsBySection"` // Most words first
+	Tags           []Count  `json:"tags"`           // Most used first
+	Orphans        []string `json:"orphans"`        // Posts no other post links to
+}
+
+// Run prints the content analytics of the last build
+func Run(args []string) {
+	asJSON := false
+	for _, arg := range args {
+		if arg == "--json" || arg == "-json" {
+			asJSON = true
+		}
+	}
+
+	start := time.Now()
+	cfg := config.Load([]string{})
+	if _, err := os.Stat(cfg.CacheDir); err != nil {
+		fmt.Println("❌ No build cache yet. Run 'kosh build' first.")
+		return
+	}
+	pages, err := load(cfg.CacheDir)
+	if err != nil {
+		fmt.Printf("❌ Failed to read the cache: %v\n", err)
+		return
+	}
+	report := Compute(pages)
+
+	if asJSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	report.print()
+	fmt.Printf("\n⏱️  From the build cache in %s\n", time.Since(start).Round(time.Millisecond))
+}
+
+// load reads every post of the cache with its rendered HTML
+func load(cacheDir string) ([]Page, error) {
+	cm, err := cache.Open(cacheDir, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cm.Close() }()
+
+	ids, err := cm.ListAllPosts()
+	if err != nil {
+		return nil, err
+	}
+	posts, err := cm.GetPostsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]Page, 0, len(posts))
+	for _, p := range posts {
+		html, _ := cm.GetHTMLContent(p)
+		words := p.WordCount
+		if words == 0 {
+			// Cached before word counts were stored
+			words = p.ReadingTime * wordsPerMinute
+		}
+		pages = append(pages, Page{
+			Path: p.Path, Link: p.Link, Date: p.Date, Tags: p.Tags,
+			Words: words, ReadingTime: p.ReadingTime, Draft: p.Draft, HTML: html,
+		})
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Path < pages[j].Path })
+	return pages, nil
+}
+
+// Compute builds the report. Drafts are counted but left out of every
+// other figure, as they are out of the published site.
+func Compute(pages []Page) Report {
+	var r Report
+	months := make(map[string]int)
+	sections := make(map[string]int)
+	tags := make(map[string]int)
+	minutes := 0
+
+	byPath := make(map[string]string) // Canonical URL path -> post path
+	for _, p := range pages {
+		if p.Draft {
+			r.Drafts++
+			continue
+		}
+		r.Posts++
+		r.Words += p.Words
+		minutes += p.ReadingTime
+		if !p.Date.IsZero() {
+			months[p.Date.Format("2006-01")]++
+		}
+		sections[section(p.Path)] += p.Words
+		seen := make(map[string]bool)
+		for _, t := range p.Tags {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" && !seen[t] {
+				seen[t] = true
+				tags[t]++
+			}
+		}
+		if u, err := url.Parse(p.Link); err == nil {
+			byPath[canonical(u.Path)] = p.Path
+		}
+	}
+	if r.Posts > 0 {
+		r.AvgReadingTime = float64(minutes) / float64(r.Posts)
+	}
+
+	r.PostsPerMonth = sorted(months, func(a, b Count) bool { return a.Name > b.Name })
+	r.WordsBySection = sorted(sections, byCount)
+	r.Tags = sorted(tags, byCount)
+	r.Orphans = orphans(pages, byPath)
+	return r
+}
+
+// orphans returns the published posts that no other page links to
+func orphans(pages []Page, byPath map[string]string) []string {
+	linked := make(map[string]bool)
+	for _, p := range pages {
+		base, err := url.Parse(p.Link)
+		if err != nil {
+			continue
+		}
+		for _, m := range hrefRegex.FindAllSubmatch(p.HTML, -1) {
+			ref, err := url.Parse(strings.Trim(string(m[1]), `"'`))
+			if err != nil {
+				continue
+			}
+			target := base.ResolveReference(ref)
+			if target.Host != base.Host {
+				continue
+			}
+			if to, ok := byPath[canonical(target.Path)]; ok && to != p.Path {
+				linked[to] = true
+			}
+		}
+	}
+
+	var list []string
+	for _, p := range pages {
+		if !p.Draft && !linked[p.Path] {
+			list = append(list, p.Path)
+		}
+	}
+	return list
+}
+
+// hrefRegex matches link targets, quoted or not (output HTML is minified)
+var hrefRegex = regexp.MustCompile(`(?i)<a\s[^>]*?href=("[^"]*"|'[^']*'|[^\s>]+)`)
+
+// canonical maps the URL forms of one page (foo.html, foo/, foo/index.html)
+// to a single key
+func canonical(p string) string {
+	p = path.Clean("/" + p)
+	p = strings.TrimSuffix(p, "/index.html")
+	p = strings.TrimSuffix(p, ".html")
+	return strings.TrimSuffix(p, "/")
+}
+
+// section is the top-level directory of a post, "(root)" for top-level posts
+func section(p string) string {
+	if dir, _, ok := strings.Cut(strings.TrimPrefix(p, "/"), "/"); ok {
+		return dir
+	}
+	return "(root)"
+}
+
+func byCount(a, b Count) bool {
+	if a.Count != b.Count {
+		return a.Count > b.Count
+	}
+	return a.Name < b.Name
+}
+
+func sorted(m map[string]int, less func(a, b Count) bool) []Count {
+	list := make([]Count, 0, len(m))
+	for name, n := range m {
+		list = append(list, Count{Name: name, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool { return less(list[i], list[j]) })
+	return list
+}
+
+func (r Report) print() {
+	fmt.Println("📊 Content Statistics")
+	fmt.Println("════════════════════════════════════════")
+	fmt.Printf("Posts:           %d (%d drafts)\n", r.Posts, r.Drafts)
+	fmt.Printf("Words:           %d\n", r.Words)
+	fmt.Printf("Avg Reading:     %.1f min\n", r.AvgReadingTime)
+
+	if len(r.PostsPerMonth) > 0 {
+		fmt.Println("\n📅 Posts per Month")
+		fmt.Println("────────────────────────────────────────")
+		for _, c := range r.PostsPerMonth {
+			fmt.Printf("%s  %4d  %s\n", c.Name, c.Count, strings.Repeat("█", min(c.Count, 40)))
+		}
+	}
+
+	if len(r.WordsBySection) > 0 {
+		fmt.Println("\n📂 Words per Section")
+		fmt.Println("────────────────────────────────────────")
+		for _, c := range r.WordsBySection {
+			fmt.Printf("%-20s %8d\n", c.Name, c.Count)
+		}
+	}
+
+	if len(r.Tags) > 0 {
+		fmt.Println("\n🏷️  Tags")
+		fmt.Println("────────────────────────────────────────")
+		for i, c := range r.Tags {
+			if i == topTags {
+				fmt.Printf("… and %d more\n", len(r.Tags)-topTags)
+				break
+			}
+			fmt.Printf("%-20s %8d\n", c.Name, c.Count)
+		}
+	}
+
+	fmt.Println("\n🔗 Orphan Pages (no inbound links)")
+	fmt.Println("────────────────────────────────────────")
+	if len(r.Orphans) == 0 {
+		fmt.Println("none")
+	}
+	for _, p := range r.Orphans {
+		fmt.Println(p)
+	}
+}
@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompute(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	pages := []Page{
+		{
+			Path: "index.md", Link: "https://example.com/", Date: day("2025-01-05"),
+			Tags: []string{"Go", "go"}, Words: 100, ReadingTime: 1,
+			HTML: []byte(`<a href=posts/first.html>first</a><a href="https://other.com/posts/second.html">x</a>`),
+		},
+		{
+			Path: "posts/first.md", Link: "https://example.com/posts/first.html", Date: day("2025-01-20"),
+			Tags: []string{"go", "web"}, Words: 300, ReadingTime: 3,
+			HTML: []byte(`<a href='../posts/second/#intro'>second</a><a href="first.html">self</a>`),
+		},
+		{
+			Path: "posts/second.md", Link: "https://example.com/posts/second.html", Date: day("2025-03-01"),
+			Words: 200, ReadingTime: 2,
+		},
+		{
+			Path: "posts/lonely.md", Link: "https://example.com/posts/lonely.html",
+			Words: 50, ReadingTime: 1, HTML: []byte(`<a href="/">home</a>`),
+		},
+		{Path: "posts/wip.md", Link: "https://example.com/posts/wip.html", Draft: true, Words: 999, ReadingTime: 9},
+	}
+
+	got := Compute(pages)
+	want := Report{
+		Posts: 4, Drafts: 1, Words: 650, AvgReadingTime: 1.75,
+		PostsPerMonth:  []Count{{"2025-03", 1}, {"2025-01", 2}},
+		WordsBySection: []Count{{"posts", 550}, {"(root)", 100}},
+		Tags:           []Count{{"go", 2}, {"web", 1}},
+		Orphans:        []string{"posts/lonely.md"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compute() =\n%+v\nwant\n%+v", got, want)
+	}
+}
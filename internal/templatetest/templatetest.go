@@ -0,0 +1,240 @@
+// Package templatetest renders theme templates against YAML fixtures and
+// compares the output with golden HTML files, so a theme can be refactored
+// without building a site (`kosh template test`)
+package templatetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/models"
+	"github.com/Kush-Singh-26/kosh/builder/renderer"
+)
+
+// DefaultDir is where fixtures live, relative to the theme directory
+const DefaultDir = "tests"
+
+// Fixture is one test case: the template to render and the data it gets
+type Fixture struct {
+	Template string                 `yaml:"template"` // "layout.html", "partials/card.html" or a defined name
+	Config   *config.Config         `yaml:"config"`   // Exposed as .Config, in kosh.yaml form
+	Data     map[string]interface{} `yaml:"data"`     // PageData fields, matched case-insensitively
+}
+
+// Result is the outcome of one fixture
+type Result struct {
+	Name    string // Fixture path relative to the fixture directory, without .yaml
+	Golden  string // Golden file path
+	Status  Status
+	Message string // Why it failed, or the first differing line
+}
+
+// Status of a fixture run
+type Status int
+
+const (
+	Passed  Status = iota
+	Failed         // Output differs from the golden file
+	Errored        // The fixture or template is broken
+	Updated        // Golden file written with --update
+)
+
+// Run handles `kosh template test [fixture...] [--dir <dir>] [--update]` and
+// reports whether every fixture passed
+func Run(args []string) bool {
+	var dir string
+	var filters []string
+	update := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--dir", "-dir":
+			if i+1 < len(args) {
+				dir = args[i+1]
+				i++
+			}
+		case "--update", "-update":
+			update = true
+		default:
+			filters = append(filters, args[i])
+		}
+	}
+
+	cfg := config.Load(nil)
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(cfg.TemplateDir), DefaultDir)
+	}
+	fmt.Printf("🧪 Testing templates in %s against %s...\n", cfg.TemplateDir, dir)
+
+	results, err := RunFixtures(cfg.TemplateDir, dir, filters, update)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return false
+	}
+	if len(results) == 0 {
+		fmt.Printf("⚠️  No fixtures found (%s/*.yaml)\n", dir)
+		return true
+	}
+
+	failed, updated := 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case Passed:
+			fmt.Printf("   ✅ %s\n", r.Name)
+		case Updated:
+			updated++
+			fmt.Printf("   📝 %s: updated %s\n", r.Name, r.Golden)
+		default:
+			failed++
+			fmt.Printf("   ❌ %s: %s\n", r.Name, r.Message)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\n❌ %d of %d template tests failed (--update accepts the new output)\n", failed, len(results))
+		return false
+	}
+	if updated > 0 {
+		fmt.Printf("\n📝 Updated %d golden files\n", updated)
+		return true
+	}
+	fmt.Printf("\n✅ %d template tests passed\n", len(results))
+	return true
+}
+
+// RunFixtures renders every fixture in dir (or those whose name matches one
+// of filters, as a path.Match pattern) with the templates of templateDir.
+// Each fixture's golden file is the fixture path with .html instead of .yaml;
+// update writes the output there instead of comparing.
+func RunFixtures(templateDir, dir string, filters []string, update bool) ([]Result, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && (strings.HasSuffix(p, ".yaml") || strings.HasSuffix(p, ".yml")) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var results []Result
+	for _, file := range files {
+		rel, _ := filepath.Rel(dir, file)
+		name := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+		if !matches(name, filters) {
+			continue
+		}
+		results = append(results, runFixture(templateDir, file, name, update))
+	}
+	return results, nil
+}
+
+func matches(name string, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		f = strings.TrimSuffix(strings.TrimSuffix(filepath.ToSlash(f), ".yaml"), ".yml")
+		if ok, _ := path.Match(f, name); ok || f == name || path.Base(name) == f {
+			return true
+		}
+	}
+	return false
+}
+
+func runFixture(templateDir, file, name string, update bool) Result {
+	res := Result{Name: name, Golden: strings.TrimSuffix(file, filepath.Ext(file)) + ".html"}
+	fixture, data, err := LoadFixture(file)
+	if err != nil {
+		res.Status, res.Message = Errored, err.Error()
+		return res
+	}
+	got, err := renderer.ExecuteTemplate(templateDir, fixture.Template, data)
+	if err != nil {
+		res.Status, res.Message = Errored, err.Error()
+		return res
+	}
+
+	if update {
+		if err := os.WriteFile(res.Golden, got, 0644); err != nil {
+			res.Status, res.Message = Errored, err.Error()
+			return res
+		}
+		res.Status = Updated
+		return res
+	}
+
+	want, err := os.ReadFile(res.Golden)
+	if err != nil {
+		res.Status, res.Message = Errored, fmt.Sprintf("no golden file (%v); run with --update to create it", err)
+		return res
+	}
+	if line, w, g, differs := FirstDiff(want, got); differs {
+		res.Status = Failed
+		res.Message = fmt.Sprintf("line %d differs\n        want: %s\n        got:  %s", line, w, g)
+	}
+	return res
+}
+
+// LoadFixture reads a fixture and builds the PageData it describes. Data
+// keys match PageData fields case-insensitively ("title", "tabTitle"), the
+// way encoding/json does, and dates may be YAML timestamps.
+func LoadFixture(file string) (*Fixture, models.PageData, error) {
+	var data models.PageData
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, data, err
+	}
+	var fixture Fixture
+	if err := yaml.Unmarshal(raw, &fixture); err != nil {
+		return nil, data, fmt.Errorf("invalid fixture: %w", err)
+	}
+	if fixture.Template == "" {
+		return nil, data, errors.New("fixture has no template")
+	}
+
+	encoded, err := json.Marshal(fixture.Data)
+	if err == nil {
+		err = json.Unmarshal(encoded, &data)
+	}
+	if err != nil {
+		return nil, data, fmt.Errorf("invalid data: %w", err)
+	}
+	if fixture.Config == nil {
+		fixture.Config = &config.Config{}
+	}
+	data.Config = fixture.Config
+	return &fixture, data, nil
+}
+
+// FirstDiff compares two outputs line by line, ignoring trailing whitespace
+// and a final newline, and returns the first line (1-based) that differs
+func FirstDiff(want, got []byte) (line int, wantLine, gotLine string, differs bool) {
+	w := strings.Split(strings.TrimRight(string(want), " \t\r\n"), "\n")
+	g := strings.Split(strings.TrimRight(string(got), " \t\r\n"), "\n")
+	for i := 0; i < max(len(w), len(g)); i++ {
+		var a, b string
+		if i < len(w) {
+			a = strings.TrimRight(w[i], " \t\r")
+		}
+		if i < len(g) {
+			b = strings.TrimRight(g[i], " \t\r")
+		}
+		if a != b || (i >= len(w)) != (i >= len(g)) {
+			return i + 1, a, b, true
+		}
+	}
+	return 0, "", "", false
+}
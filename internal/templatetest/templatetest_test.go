@@ -0,0 +1,84 @@
+package templatetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunFixtures(t *testing.T) {
+	root := t.TempDir()
+	templates := filepath.Join(root, "templates")
+	fixtures := filepath.Join(root, "tests")
+	writeFile(t, filepath.Join(templates, "layout.html"), `<title>{{ .TabTitle }}</title>{{ template "partials/card.html" . }}`)
+	writeFile(t, filepath.Join(templates, "partials/card.html"), `{{ range .Posts }}<a href="{{ .Link }}">{{ .Title }}</a> {{ .DateObj.Format "2006-01-02" }}{{ end }} by {{ .Config.Author.Name }}`)
+	writeFile(t, filepath.Join(fixtures, "card.yaml"), `template: partials/card.html
+config:
+  author:
+    name: Ada
+data:
+  posts:
+    - title: Hello
+      link: /hello.html
+      dateObj: 2024-03-01
+`)
+	writeFile(t, filepath.Join(fixtures, "pages/layout.yaml"), "template: layout.html\ndata:\n  tabTitle: Home | Site\n")
+	writeFile(t, filepath.Join(fixtures, "broken.yaml"), "data: {}\n")
+
+	results, err := RunFixtures(templates, fixtures, []string{"card", "pages/*"}, true)
+	if err != nil {
+		t.Fatalf("RunFixtures(update) error = %v", err)
+	}
+	if len(results) != 2 || results[0].Status != Updated || results[1].Status != Updated {
+		t.Fatalf("RunFixtures(update) = %+v, want card and pages/layout updated", results)
+	}
+	golden, _ := os.ReadFile(filepath.Join(fixtures, "card.html"))
+	if want := `<a href="/hello.html">Hello</a> 2024-03-01 by Ada`; string(golden) != want {
+		t.Errorf("card golden = %q, want %q", golden, want)
+	}
+
+	// A template change fails the fixture, a fixture without template errors
+	writeFile(t, filepath.Join(templates, "layout.html"), `<title>{{ .Title }}</title>{{ template "partials/card.html" . }}`)
+	results, err = RunFixtures(templates, fixtures, nil, false)
+	if err != nil {
+		t.Fatalf("RunFixtures() error = %v", err)
+	}
+	want := map[string]Status{"broken": Errored, "card": Passed, "pages/layout": Failed}
+	if len(results) != len(want) {
+		t.Fatalf("RunFixtures() = %+v, want %d results", results, len(want))
+	}
+	for _, r := range results {
+		if r.Status != want[r.Name] {
+			t.Errorf("%s: status %d (%s), want %d", r.Name, r.Status, r.Message, want[r.Name])
+		}
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	tests := []struct {
+		name, want, got string
+		line            int
+	}{
+		{"equal", "<p>a</p>\n<p>b</p>\n", "<p>a</p>\n<p>b</p>", 0},
+		{"trailing whitespace", "<p>a</p>  \r\n", "<p>a</p>", 0},
+		{"changed line", "<p>a</p>\n<p>b</p>", "<p>a</p>\n<p>c</p>", 2},
+		{"extra line", "<p>a</p>", "<p>a</p>\n<p>b</p>", 2},
+		{"missing blank line", "<p>a</p>\n\n<p>b</p>", "<p>a</p>\n<p>b</p>", 2},
+	}
+	for _, tt := range tests {
+		line, _, _, differs := FirstDiff([]byte(tt.want), []byte(tt.got))
+		if line != tt.line || differs != (tt.line != 0) {
+			t.Errorf("%s: FirstDiff() = line %d, differs %v, want line %d", tt.name, line, differs, tt.line)
+		}
+	}
+}
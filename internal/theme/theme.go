@@ -0,0 +1,299 @@
+// Package theme installs themes into a site's theme directory from git
+// repositories and lists the installed ones
+package theme
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/modules"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Installed is a theme in the theme directory
+type Installed struct {
+	Name   string             // Directory name, what kosh.yaml's theme: refers to
+	Dir    string             // Absolute path
+	Meta   config.ThemeConfig // From theme.yaml
+	Source string             // Git URL the theme was installed from, if known
+	Commit string             // Commit checked out, if known
+}
+
+// Run dispatches `kosh theme <subcommand> ...` and reports whether it
+// succeeded
+func Run(args []string) bool {
+	if len(args) < 1 {
+		printUsage()
+		return false
+	}
+
+	cfg := config.Load(nil)
+	switch args[0] {
+	case "install":
+		return runInstall(cfg, args[1:])
+	case "list":
+		return runList(cfg)
+	default:
+		fmt.Printf("❌ Unknown theme subcommand: %s\n", args[0])
+		printUsage()
+		return false
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: kosh theme <subcommand>")
+	fmt.Println("\nSubcommands:")
+	fmt.Println("  install <git-url>   Clone a theme into the theme directory (url#ref for a branch or tag;")
+	fmt.Println("                      --name <dir>, --ref <ref>, --force replaces an installed copy)")
+	fmt.Println("  list                Show installed themes and the templates the site overrides")
+}
+
+func runInstall(cfg *config.Config, args []string) bool {
+	var url, ref, name string
+	var force bool
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--name", "-name":
+			if i+1 < len(args) {
+				name = args[i+1]
+				i++
+			}
+		case "--ref", "-ref":
+			if i+1 < len(args) {
+				ref = args[i+1]
+				i++
+			}
+		case "--force", "-force":
+			force = true
+		default:
+			if url == "" {
+				url = args[i]
+			}
+		}
+	}
+	if url == "" {
+		printUsage()
+		return false
+	}
+	if u, r, ok := strings.Cut(url, "#"); ok {
+		url = u
+		if ref == "" {
+			ref = r
+		}
+	}
+
+	fmt.Printf("⬇️  Fetching %s...\n", url)
+	theme, err := Install(context.Background(), cfg.ThemeDir, url, ref, name, force)
+	if err != nil {
+		fmt.Printf("❌ Failed to install theme: %v\n", err)
+		return false
+	}
+	fmt.Printf("✅ Installed theme %s into %s\n", theme.Name, theme.Dir)
+	if theme.Name != cfg.Theme {
+		fmt.Printf("   👉 Set `theme: %s` in kosh.yaml, or build with -theme %s\n", theme.Name, theme.Name)
+	}
+	return true
+}
+
+func runList(cfg *config.Config) bool {
+	themes, err := List(cfg.ThemeDir)
+	if err != nil {
+		fmt.Printf("❌ Failed to list themes: %v\n", err)
+		return false
+	}
+	fmt.Printf("🎨 Themes in %s\n", cfg.ThemeDir)
+	fmt.Println("════════════════════════════════════════")
+	if len(themes) == 0 {
+		fmt.Println("No themes installed; add one with kosh theme install <git-url>")
+	}
+	for _, t := range themes {
+		marker := " "
+		if t.Name == cfg.Theme {
+			marker = "*"
+		}
+		line := marker + " " + t.Name
+		if t.Meta.Version != "" {
+			line += " " + t.Meta.Version
+		}
+		if t.Meta.Description != "" {
+			line += " — " + t.Meta.Description
+		}
+		fmt.Println(line)
+		if t.Source != "" {
+			source := t.Source
+			if t.Commit != "" {
+				source += " @ " + t.Commit[:min(len(t.Commit), 12)]
+			}
+			fmt.Printf("    %s\n", source)
+		}
+	}
+
+	overrides, err := Overrides(cfg.SiteTemplateDir, cfg.TemplateDir)
+	if err != nil {
+		fmt.Printf("❌ Failed to read site templates: %v\n", err)
+		return false
+	}
+	if len(overrides) > 0 {
+		fmt.Printf("\nSite templates overriding %s:\n", cfg.Theme)
+		for _, rel := range overrides {
+			fmt.Printf("  %s/%s\n", config.SiteTemplatesDir, rel)
+		}
+	}
+	return true
+}
+
+// DefaultName is the directory a theme is installed as: the repository
+// name, without a kosh-theme- prefix ("kosh-theme-blog" installs as "blog")
+func DefaultName(url string) string {
+	return strings.TrimPrefix(modules.RepoName(url), "kosh-theme-")
+}
+
+// Install shallow-clones the theme at url (ref: a branch, tag or commit;
+// the default branch when empty) into themeDir/name, name defaulting to
+// DefaultName. The checkout keeps its .git, so it records where it came
+// from. A repository without templates/layout.html isn't a theme and is
+// rejected; an installed theme is only replaced with force.
+func Install(ctx context.Context, themeDir, url, ref, name string, force bool) (Installed, error) {
+	if name == "" {
+		name = DefaultName(url)
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return Installed{}, fmt.Errorf("invalid theme name %q", name)
+	}
+	dest := filepath.Join(themeDir, name)
+	if _, err := os.Stat(dest); err == nil && !force {
+		return Installed{}, fmt.Errorf("%s already exists (use --force to replace it)", dest)
+	}
+
+	if err := os.MkdirAll(themeDir, 0755); err != nil {
+		return Installed{}, err
+	}
+	// Fetched next to its destination so the rename can't cross filesystems
+	tmp, err := os.MkdirTemp(themeDir, ".install-*")
+	if err != nil {
+		return Installed{}, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	checkout, err := modules.Ensure(ctx, tmp, config.ContentModule{URL: url, Ref: ref})
+	if err != nil {
+		return Installed{}, err
+	}
+	if _, err := os.Stat(filepath.Join(checkout, "templates", "layout.html")); err != nil {
+		return Installed{}, fmt.Errorf("%s is not a theme: it has no templates/layout.html", url)
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return Installed{}, err
+	}
+	if err := os.Rename(checkout, dest); err != nil {
+		return Installed{}, err
+	}
+	return load(dest)
+}
+
+// List returns the themes in themeDir: its directories with a templates
+// directory, by name
+func List(themeDir string) ([]Installed, error) {
+	entries, err := os.ReadDir(themeDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var themes []Installed
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(themeDir, e.Name())
+		if info, err := os.Stat(filepath.Join(dir, "templates")); err != nil || !info.IsDir() {
+			continue
+		}
+		t, err := load(dir)
+		if err != nil {
+			return nil, err
+		}
+		themes = append(themes, t)
+	}
+	return themes, nil
+}
+
+// Overrides lists the template files of siteDir that replace a file of
+// themeDir, as slash paths relative to both
+func Overrides(siteDir, themeDir string) ([]string, error) {
+	if siteDir == "" {
+		return nil, nil
+	}
+	var overrides []string
+	err := filepath.WalkDir(siteDir, func(path string, d os.DirEntry, err error) error {
+		if os.IsNotExist(err) && path == siteDir {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(siteDir, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(themeDir, rel)); err == nil {
+			overrides = append(overrides, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	slices.Sort(overrides)
+	return overrides, err
+}
+
+// load reads what is known about the theme in dir: its theme.yaml and the
+// origin and commit of its git checkout
+func load(dir string) (Installed, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return Installed{}, err
+	}
+	t := Installed{Name: filepath.Base(abs), Dir: abs, Meta: config.ThemeConfig{Name: filepath.Base(abs)}}
+	if data, err := os.ReadFile(filepath.Join(abs, "theme.yaml")); err == nil {
+		if err := yaml.Unmarshal(data, &t.Meta); err != nil {
+			return Installed{}, fmt.Errorf("%s: %w", filepath.Join(abs, "theme.yaml"), err)
+		}
+	}
+	t.Source, t.Commit = gitOrigin(filepath.Join(abs, ".git"))
+	return t, nil
+}
+
+// gitOrigin reads the origin URL and the checked-out commit of a git
+// directory without running git. A checkout on a branch reports no commit.
+func gitOrigin(gitDir string) (url, commit string) {
+	if head, err := os.ReadFile(filepath.Join(gitDir, "HEAD")); err == nil {
+		if h := strings.TrimSpace(string(head)); !strings.HasPrefix(h, "ref:") {
+			commit = h
+		}
+	}
+	f, err := os.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return "", commit
+	}
+	defer func() { _ = f.Close() }()
+	inOrigin := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inOrigin && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value), commit
+		}
+	}
+	return "", commit
+}
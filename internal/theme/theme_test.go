@@ -0,0 +1,111 @@
+package theme
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// gitRepo commits files into a new repository named name and returns its path
+func gitRepo(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	repo := filepath.Join(t.TempDir(), name)
+	writeFiles(t, repo, files)
+	for _, args := range [][]string{
+		{"init", "--quiet", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	return repo
+}
+
+func TestInstallAndList(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := gitRepo(t, "kosh-theme-paper", map[string]string{
+		"templates/layout.html": "<main>{{ .Content }}</main>",
+		"static/css/main.css":   "body{}",
+		"theme.yaml":            "name: Paper\nversion: 1.2.0\ndescription: A quiet theme\n",
+	})
+	themeDir := filepath.Join(t.TempDir(), "themes")
+	ctx := context.Background()
+
+	theme, err := Install(ctx, themeDir, repo, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Name != "paper" || theme.Meta.Version != "1.2.0" || theme.Source != repo || len(theme.Commit) != 40 {
+		t.Errorf("installed %+v", theme)
+	}
+	if _, err := os.Stat(filepath.Join(themeDir, "paper", "static", "css", "main.css")); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Install(ctx, themeDir, repo, "", "", false); err == nil {
+		t.Error("installing over an existing theme without force succeeded")
+	}
+	if _, err := Install(ctx, themeDir, repo, "", "paper", true); err != nil {
+		t.Errorf("forced reinstall: %v", err)
+	}
+
+	notTheme := gitRepo(t, "docs", map[string]string{"README.md": "# Docs"})
+	if _, err := Install(ctx, themeDir, notTheme, "", "", false); err == nil {
+		t.Error("a repository without templates/layout.html was installed")
+	}
+
+	themes, err := List(themeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(themes) != 1 || themes[0].Name != "paper" || themes[0].Meta.Description != "A quiet theme" {
+		t.Errorf("List = %+v, want only paper (no leftovers of failed installs)", themes)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	theme, site := t.TempDir(), t.TempDir()
+	writeFiles(t, theme, map[string]string{"layout.html": "", "partials/footer.html": "", "partials/nav.html": ""})
+	writeFiles(t, site, map[string]string{"partials/footer.html": "", "partials/extra.html": ""})
+
+	got, err := Overrides(site, theme)
+	if err != nil || !slices.Equal(got, []string{"partials/footer.html"}) {
+		t.Errorf("Overrides = %v, %v", got, err)
+	}
+	if got, err := Overrides(filepath.Join(site, "missing"), theme); err != nil || got != nil {
+		t.Errorf("Overrides of a missing dir = %v, %v", got, err)
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/Kush-Singh-26/kosh-theme-blog": "blog",
+		"git@github.com:someone/paper.git":                 "paper",
+	} {
+		if got := DefaultName(url); got != want {
+			t.Errorf("DefaultName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
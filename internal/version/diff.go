@@ -0,0 +1,228 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Kush-Singh-26/kosh/builder/cache"
+	"github.com/Kush-Singh-26/kosh/builder/config"
+	"github.com/Kush-Singh-26/kosh/builder/utils"
+)
+
+// pageHashes holds the hashes used to compare a page across two versions
+type pageHashes struct {
+	Content string
+	Body    string
+}
+
+// DiffEntry describes a single page that differs between two versions
+type DiffEntry struct {
+	Path            string `json:"path"`
+	FrontmatterOnly bool   `json:"frontmatterOnly,omitempty"`
+}
+
+// DiffReport is the result of comparing the page sets of two versions
+type DiffReport struct {
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	Added     []DiffEntry `json:"added"`
+	Removed   []DiffEntry `json:"removed"`
+	Changed   []DiffEntry `json:"changed"`
+	Unchanged int         `json:"unchanged"`
+}
+
+// runDiff handles `kosh version diff <from> <to> [--json]`
+func runDiff(args []string) {
+	asJSON := false
+	var names []string
+	for _, arg := range args {
+		if arg == "--json" || arg == "-json" {
+			asJSON = true
+		} else {
+			names = append(names, arg)
+		}
+	}
+
+	if len(names) != 2 {
+		fmt.Println("Usage: kosh version diff <from> <to> [--json]")
+		os.Exit(1)
+	}
+
+	cfg := loadConfig()
+	if cfg == nil {
+		fmt.Println("❌ Error: Could not load kosh.yaml")
+		os.Exit(1)
+	}
+
+	report, err := diffVersions(cfg, "content", names[0], names[1])
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("❌ Error encoding report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	printDiffReport(report)
+}
+
+// diffVersions compares the markdown pages of two configured versions
+func diffVersions(cfg *config.Config, contentDir, from, to string) (*DiffReport, error) {
+	fromVersion := findVersion(cfg, from)
+	if fromVersion == nil {
+		return nil, fmt.Errorf("version '%s' not found in kosh.yaml", from)
+	}
+	toVersion := findVersion(cfg, to)
+	if toVersion == nil {
+		return nil, fmt.Errorf("version '%s' not found in kosh.yaml", to)
+	}
+
+	fromPages, err := collectVersionPages(cfg, contentDir, fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", from, err)
+	}
+	toPages, err := collectVersionPages(cfg, contentDir, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", to, err)
+	}
+
+	return compareVersionPages(from, to, fromPages, toPages), nil
+}
+
+// compareVersionPages classifies pages as added, removed or changed
+func compareVersionPages(from, to string, fromPages, toPages map[string]pageHashes) *DiffReport {
+	report := &DiffReport{
+		From:    from,
+		To:      to,
+		Added:   []DiffEntry{},
+		Removed: []DiffEntry{},
+		Changed: []DiffEntry{},
+	}
+
+	for path, newHashes := range toPages {
+		oldHashes, ok := fromPages[path]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, DiffEntry{Path: path})
+		case oldHashes.Content != newHashes.Content:
+			report.Changed = append(report.Changed, DiffEntry{
+				Path:            path,
+				FrontmatterOnly: oldHashes.Body == newHashes.Body,
+			})
+		default:
+			report.Unchanged++
+		}
+	}
+
+	for path := range fromPages {
+		if _, ok := toPages[path]; !ok {
+			report.Removed = append(report.Removed, DiffEntry{Path: path})
+		}
+	}
+
+	sortEntries(report.Added)
+	sortEntries(report.Removed)
+	sortEntries(report.Changed)
+	return report
+}
+
+func sortEntries(entries []DiffEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+}
+
+func findVersion(cfg *config.Config, name string) *config.Version {
+	for i := range cfg.Versions {
+		if cfg.Versions[i].Name == name {
+			return &cfg.Versions[i]
+		}
+	}
+	return nil
+}
+
+// collectVersionPages hashes every markdown file of a version, keyed by its
+// path relative to the version root. A version without a path lives at the
+// content root, so other version folders are skipped.
+func collectVersionPages(cfg *config.Config, contentDir string, v *config.Version) (map[string]pageHashes, error) {
+	root := contentDir
+	if v.Path != "" {
+		root = filepath.Join(contentDir, v.Path)
+	}
+
+	versionPaths := make(map[string]bool)
+	for _, other := range cfg.Versions {
+		if other.Path != "" {
+			versionPaths[other.Path] = true
+		}
+	}
+
+	pages := make(map[string]pageHashes)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			if v.Path == "" && versionPaths[relPath] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		pages[filepath.ToSlash(relPath)] = pageHashes{
+			Content: cache.HashContent(data),
+			Body:    utils.GetBodyHash(data),
+		}
+		return nil
+	})
+	return pages, err
+}
+
+func printDiffReport(report *DiffReport) {
+	fmt.Printf("📊 Version Diff: %s → %s\n", report.From, report.To)
+	fmt.Println("════════════════════════════════════════")
+
+	fmt.Printf("\n➕ Added (%d)\n", len(report.Added))
+	for _, e := range report.Added {
+		fmt.Printf("   %s\n", e.Path)
+	}
+
+	fmt.Printf("\n➖ Removed (%d)\n", len(report.Removed))
+	for _, e := range report.Removed {
+		fmt.Printf("   %s\n", e.Path)
+	}
+
+	fmt.Printf("\n✏️  Changed (%d)\n", len(report.Changed))
+	for _, e := range report.Changed {
+		if e.FrontmatterOnly {
+			fmt.Printf("   %s (frontmatter only)\n", e.Path)
+		} else {
+			fmt.Printf("   %s\n", e.Path)
+		}
+	}
+
+	fmt.Printf("\n   Unchanged: %d\n", report.Unchanged)
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
 	"github.com/Kush-Singh-26/kosh/builder/config"
 )
 
@@ -19,6 +20,11 @@ func Run(args []string) {
 		return
 	}
 
+	if args[0] == "diff" {
+		runDiff(args[1:])
+		return
+	}
+
 	versionName := args[0]
 
 	cfg := loadConfig()
@@ -1,6 +1,8 @@
 package version
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Kush-Singh-26/kosh/builder/config"
@@ -87,3 +89,83 @@ func TestCopyFile(t *testing.T) {
 		}
 	})
 }
+
+func TestCompareVersionPages(t *testing.T) {
+	from := map[string]pageHashes{
+		"intro.md":   {Content: "a", Body: "a-body"},
+		"install.md": {Content: "b", Body: "b-body"},
+		"legacy.md":  {Content: "c", Body: "c-body"},
+		"api.md":     {Content: "d", Body: "d-body"},
+	}
+	to := map[string]pageHashes{
+		"intro.md":   {Content: "a", Body: "a-body"},
+		"install.md": {Content: "b2", Body: "b2-body"},
+		"api.md":     {Content: "d2", Body: "d-body"},
+		"new.md":     {Content: "e", Body: "e-body"},
+	}
+
+	report := compareVersionPages("v1.0", "v2.0", from, to)
+
+	if len(report.Added) != 1 || report.Added[0].Path != "new.md" {
+		t.Errorf("Added = %v, want [new.md]", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Path != "legacy.md" {
+		t.Errorf("Removed = %v, want [legacy.md]", report.Removed)
+	}
+	if len(report.Changed) != 2 {
+		t.Fatalf("Changed = %v, want 2 entries", report.Changed)
+	}
+	if report.Changed[0].Path != "api.md" || !report.Changed[0].FrontmatterOnly {
+		t.Errorf("Changed[0] = %+v, want api.md frontmatter only", report.Changed[0])
+	}
+	if report.Changed[1].Path != "install.md" || report.Changed[1].FrontmatterOnly {
+		t.Errorf("Changed[1] = %+v, want install.md body change", report.Changed[1])
+	}
+	if report.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", report.Unchanged)
+	}
+}
+
+func TestCollectVersionPagesSkipsOtherVersions(t *testing.T) {
+	contentDir := t.TempDir()
+	writeFile := func(rel, body string) {
+		t.Helper()
+		path := filepath.Join(contentDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("index.md", "---\ntitle: Home\n---\nroot")
+	writeFile("guide/setup.md", "---\ntitle: Setup\n---\nsetup")
+	writeFile("v1.0/index.md", "---\ntitle: Home\n---\nold")
+
+	cfg := &config.Config{Versions: []config.Version{
+		{Name: "v2.0", Path: "", IsLatest: true},
+		{Name: "v1.0", Path: "v1.0"},
+	}}
+
+	latest, err := collectVersionPages(cfg, contentDir, &cfg.Versions[0])
+	if err != nil {
+		t.Fatalf("collectVersionPages() error = %v", err)
+	}
+	if len(latest) != 2 {
+		t.Errorf("latest pages = %d, want 2", len(latest))
+	}
+	if _, ok := latest["guide/setup.md"]; !ok {
+		t.Error("latest pages missing guide/setup.md")
+	}
+
+	frozen, err := collectVersionPages(cfg, contentDir, &cfg.Versions[1])
+	if err != nil {
+		t.Fatalf("collectVersionPages() error = %v", err)
+	}
+	if len(frozen) != 1 {
+		t.Errorf("frozen pages = %d, want 1", len(frozen))
+	}
+	if frozen["index.md"].Content == latest["index.md"].Content {
+		t.Error("expected differing content hashes for index.md")
+	}
+}
@@ -52,7 +52,7 @@
                     const result = await WebAssembly.instantiateStreaming(response, go.importObject);
                     go.run(result.instance);
 
-                    const binPath = joinPath(baseURL, '/search.bin');
+                    const binPath = window.siteSearchIndex || joinPath(baseURL, '/search.bin');
                     await window.initSearch(binPath);
 
                     wasmLoaded = true;
@@ -86,6 +86,36 @@
             });
         }
 
+        // Report searches to the preview server when it runs with --search-log
+        // (see `kosh search report`). Published sites never send anything.
+        const SEARCH_LOG = '/__search-log';
+        let searchLogEnabled = null;
+        let searchLogTimer = null;
+
+        function searchLogAvailable() {
+            if (searchLogEnabled) return searchLogEnabled;
+            const local = ['localhost', '127.0.0.1', '[::1]'].includes(window.location.hostname);
+            searchLogEnabled = !local ? Promise.resolve(false) :
+                fetch(SEARCH_LOG, { method: 'HEAD', cache: 'no-store' })
+                    .then(res => res.status === 204)
+                    .catch(() => false);
+            return searchLogEnabled;
+        }
+
+        // logSearch waits for typing to settle so only the final query is logged
+        function logSearch(query, results, version) {
+            clearTimeout(searchLogTimer);
+            searchLogTimer = setTimeout(async () => {
+                if (!(await searchLogAvailable())) return;
+                fetch(SEARCH_LOG, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ query, results: results ? results.length : 0, version }),
+                    keepalive: true
+                }).catch(() => {});
+            }, 1000);
+        }
+
         function performSearch() {
             if (!wasmLoaded || !searchInput) return;
             const query = searchInput.value.trim();
@@ -99,6 +129,7 @@
             try {
                 const results = window.searchPosts(query, versionFilter);
                 renderResults(results);
+                logSearch(query, results, versionFilter);
             } catch (err) {
                 console.error("Search execution failed:", err);
             }